@@ -0,0 +1,94 @@
+package horizonclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+)
+
+// BalanceSnapshotHandler is a function that is called when a new balance snapshot is received
+type BalanceSnapshotHandler func(hProtocol.BalanceSnapshot)
+
+// BuildURL creates the endpoint to be queried based on the data in the BalanceHistoryRequest struct.
+func (br BalanceHistoryRequest) BuildURL() (endpoint string, err error) {
+	switch {
+	case br.ForAccount != "" && br.ForContract != "":
+		return endpoint, errors.New("invalid request: too many parameters")
+	case br.ForAccount != "":
+		endpoint = fmt.Sprintf("accounts/%s/balance_history", br.ForAccount)
+	case br.ForContract != "":
+		endpoint = fmt.Sprintf("contracts/%s/balance_history", br.ForContract)
+	default:
+		return endpoint, errors.New("invalid request: no account or contract")
+	}
+	if br.Asset == "" {
+		return endpoint, errors.New("invalid request: no asset")
+	}
+
+	params := map[string]string{"asset": br.Asset}
+	if br.StartLedger > 0 {
+		params["start_ledger"] = strconv.FormatUint(uint64(br.StartLedger), 10)
+	}
+	if br.EndLedger > 0 {
+		params["end_ledger"] = strconv.FormatUint(uint64(br.EndLedger), 10)
+	}
+	if !br.StartTime.IsZero() {
+		params["start_time"] = strconv.FormatInt(br.StartTime.UnixNano()/1e6, 10)
+	}
+	if !br.EndTime.IsZero() {
+		params["end_time"] = strconv.FormatInt(br.EndTime.UnixNano()/1e6, 10)
+	}
+
+	queryParams := addQueryParams(
+		params,
+		cursor(br.Cursor),
+		limit(br.Limit),
+		br.Order,
+	)
+	if queryParams != "" {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, queryParams)
+	}
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the balance history endpoint
+func (br BalanceHistoryRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := br.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
+
+// StreamBalanceHistory streams the balance history of an account or a contract. Use context.WithCancel
+// to stop streaming or context.Background() if you want to stream indefinitely. BalanceSnapshotHandler is
+// a user-supplied function that is executed for each streamed balance snapshot received.
+func (br BalanceHistoryRequest) StreamBalanceHistory(ctx context.Context, client *Client, handler BalanceSnapshotHandler) error {
+	endpoint, err := br.BuildURL()
+	if err != nil {
+		return errors.Wrap(err, "unable to build endpoint for balance history request")
+	}
+
+	url := fmt.Sprintf("%s%s", client.fixHorizonURL(), endpoint)
+	return client.stream(ctx, url, func(data []byte) error {
+		var snapshot hProtocol.BalanceSnapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return errors.Wrap(err, "error unmarshaling data for balance history request")
+		}
+		handler(snapshot)
+		return nil
+	})
+}
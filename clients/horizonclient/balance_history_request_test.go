@@ -0,0 +1,186 @@
+package horizonclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceHistoryRequestBuildUrl(t *testing.T) {
+	br := BalanceHistoryRequest{Asset: "native"}
+	_, err := br.BuildURL()
+
+	// It should return an error if no account or contract is set
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid request: no account or contract")
+	}
+
+	br = BalanceHistoryRequest{
+		ForAccount:  "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Asset:       "native",
+	}
+	_, err = br.BuildURL()
+
+	// It should return an error if both an account and a contract are set
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid request: too many parameters")
+	}
+
+	br = BalanceHistoryRequest{ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"}
+	_, err = br.BuildURL()
+
+	// It should return an error if no asset is set
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid request: no asset")
+	}
+
+	br = BalanceHistoryRequest{
+		ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		Asset:      "native",
+	}
+	endpoint, err := br.BuildURL()
+
+	// It should return valid account balance history endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native", endpoint)
+
+	br = BalanceHistoryRequest{
+		ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Asset:       "USD:GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		StartLedger: 10,
+		EndLedger:   20,
+		StartTime:   time.Unix(1000, 0),
+		EndTime:     time.Unix(2000, 0),
+		Cursor:      "123456",
+		Limit:       30,
+		Order:       OrderDesc,
+	}
+	endpoint, err = br.BuildURL()
+
+	// It should return valid contract balance history endpoint with query params and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/balance_history?asset=USD%3AGAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY&cursor=123456&end_ledger=20&end_time=2000000&limit=30&order=desc&start_ledger=10&start_time=1000000", endpoint)
+}
+
+func TestBalanceHistoryRequestStreamBalanceHistory(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	request := BalanceHistoryRequest{
+		ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		Asset:      "native",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=now",
+	).ReturnString(200, balanceSnapshotStreamResponse)
+
+	var snapshots []hProtocol.BalanceSnapshot
+	err := client.StreamBalanceHistory(ctx, request, func(snapshot hProtocol.BalanceSnapshot) {
+		snapshots = append(snapshots, snapshot)
+		cancel()
+	})
+
+	if assert.NoError(t, err) && assert.Len(t, snapshots, 1) {
+		assert.Equal(t, "240518168576", snapshots[0].PagingToken())
+		assert.Equal(t, "native", snapshots[0].Type)
+		assert.Equal(t, int32(56), snapshots[0].Ledger)
+		assert.Equal(t, "100.0000000", snapshots[0].Balance)
+	}
+}
+
+func TestNextBalanceHistoryPage(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native",
+	).ReturnString(200, firstBalanceHistoryPage)
+
+	page, err := client.BalanceHistory(BalanceHistoryRequest{
+		ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		Asset:      "native",
+	})
+	if assert.NoError(t, err) && assert.Len(t, page.Embedded.Records, 1) {
+		assert.Equal(t, "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY", page.Embedded.Records[0].Address)
+		assert.Equal(t, "100.0000000", page.Embedded.Records[0].Balance)
+	}
+
+	hmock.On(
+		"GET",
+		"https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=asc",
+	).ReturnString(200, emptyBalanceHistoryPage)
+
+	nextPage, err := client.NextBalanceHistoryPage(page)
+	if assert.NoError(t, err) {
+		assert.Len(t, nextPage.Embedded.Records, 0)
+	}
+}
+
+var balanceSnapshotStreamResponse = `data: {"_links":{"ledger":{"href":"https://horizon-testnet.stellar.org/ledgers/56"}},"id":"GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY-CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC-56","paging_token":"240518168576","address":"GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY","asset_type":"native","contract_id":"CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC","ledger":56,"ledger_close_time":"2024-01-02T03:04:05Z","balance":"100.0000000"}
+`
+
+var firstBalanceHistoryPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=&limit=10&order=asc"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=asc"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=desc"
+    }
+  },
+  "_embedded": {
+    "records": [
+      {
+        "_links": {
+          "ledger": {
+            "href": "https://horizon-testnet.stellar.org/ledgers/56"
+          }
+        },
+        "id": "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY-CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC-56",
+        "paging_token": "240518168576",
+        "address": "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+        "asset_type": "native",
+        "contract_id": "CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC",
+        "ledger": 56,
+        "ledger_close_time": "2024-01-02T03:04:05Z",
+        "balance": "100.0000000"
+      }
+    ]
+  }
+}`
+
+var emptyBalanceHistoryPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=asc"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=asc"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/balance_history?asset=native&cursor=240518168576&limit=10&order=desc"
+    }
+  },
+  "_embedded": {
+    "records": []
+  }
+}`
@@ -361,6 +361,28 @@ func (c *Client) Effects(request EffectRequest) (effects effects.EffectsPage, er
 	return
 }
 
+// ContractEvents returns contract events. It can be used to return the events of a contract or the
+// events of all the contracts, filtered by type and topics.
+func (c *Client) ContractEvents(request ContractEventRequest) (events hProtocol.ContractEventsPage, err error) {
+	err = c.sendRequest(request, &events)
+	return
+}
+
+// TokenTransfers returns token transfers: the transfers, mints, burns, clawbacks and fees of classic
+// assets, stellar asset contracts and SEP-41 tokens. It can be used to return the transfers of an
+// account, of a token contract or of an asset.
+func (c *Client) TokenTransfers(request TokenTransferRequest) (transfers hProtocol.TokenTransfersPage, err error) {
+	err = c.sendRequest(request, &transfers)
+	return
+}
+
+// BalanceHistory returns the balance of an asset held by an account or a contract at the end of each
+// ledger in which it changed.
+func (c *Client) BalanceHistory(request BalanceHistoryRequest) (snapshots hProtocol.BalanceHistoryPage, err error) {
+	err = c.sendRequest(request, &snapshots)
+	return
+}
+
 // Assets returns asset information.
 // See https://developers.stellar.org/api/resources/assets/list/
 func (c *Client) Assets(request AssetRequest) (assets hProtocol.AssetsPage, err error) {
@@ -718,6 +740,29 @@ func (c *Client) StreamEffects(ctx context.Context, request EffectRequest, handl
 	return request.StreamEffects(ctx, c, handler)
 }
 
+// StreamContractEvents streams contract events. It can be used to stream all contract events or the
+// events of a contract. Use context.WithCancel to stop streaming or context.Background() if you want
+// to stream indefinitely. ContractEventHandler is a user-supplied function that is executed for each
+// streamed contract event received.
+func (c *Client) StreamContractEvents(ctx context.Context, request ContractEventRequest, handler ContractEventHandler) error {
+	return request.StreamContractEvents(ctx, c, handler)
+}
+
+// StreamTokenTransfers streams token transfers. It can be used to stream all token transfers or the
+// transfers of an account, of a token contract or of an asset. Use context.WithCancel to stop streaming
+// or context.Background() if you want to stream indefinitely. TokenTransferHandler is a user-supplied
+// function that is executed for each streamed token transfer received.
+func (c *Client) StreamTokenTransfers(ctx context.Context, request TokenTransferRequest, handler TokenTransferHandler) error {
+	return request.StreamTokenTransfers(ctx, c, handler)
+}
+
+// StreamBalanceHistory streams the balance history of an account or a contract. Use context.WithCancel
+// to stop streaming or context.Background() if you want to stream indefinitely. BalanceSnapshotHandler is
+// a user-supplied function that is executed for each streamed balance snapshot received.
+func (c *Client) StreamBalanceHistory(ctx context.Context, request BalanceHistoryRequest, handler BalanceSnapshotHandler) error {
+	return request.StreamBalanceHistory(ctx, c, handler)
+}
+
 // StreamOperations streams stellar operations. It can be used to stream all operations or operations
 // for an account. Use context.WithCancel to stop streaming or context.Background() if you want to
 // stream indefinitely. OperationHandler is a user-supplied function that is executed for each streamed
@@ -828,6 +873,42 @@ func (c *Client) PrevEffectsPage(page effects.EffectsPage) (efp effects.EffectsP
 	return
 }
 
+// NextContractEventsPage returns the next page of contract events.
+func (c *Client) NextContractEventsPage(page hProtocol.ContractEventsPage) (events hProtocol.ContractEventsPage, err error) {
+	err = c.sendGetRequest(page.Links.Next.Href, &events)
+	return
+}
+
+// PrevContractEventsPage returns the previous page of contract events.
+func (c *Client) PrevContractEventsPage(page hProtocol.ContractEventsPage) (events hProtocol.ContractEventsPage, err error) {
+	err = c.sendGetRequest(page.Links.Prev.Href, &events)
+	return
+}
+
+// NextTokenTransfersPage returns the next page of token transfers.
+func (c *Client) NextTokenTransfersPage(page hProtocol.TokenTransfersPage) (transfers hProtocol.TokenTransfersPage, err error) {
+	err = c.sendGetRequest(page.Links.Next.Href, &transfers)
+	return
+}
+
+// PrevTokenTransfersPage returns the previous page of token transfers.
+func (c *Client) PrevTokenTransfersPage(page hProtocol.TokenTransfersPage) (transfers hProtocol.TokenTransfersPage, err error) {
+	err = c.sendGetRequest(page.Links.Prev.Href, &transfers)
+	return
+}
+
+// NextBalanceHistoryPage returns the next page of balance snapshots.
+func (c *Client) NextBalanceHistoryPage(page hProtocol.BalanceHistoryPage) (snapshots hProtocol.BalanceHistoryPage, err error) {
+	err = c.sendGetRequest(page.Links.Next.Href, &snapshots)
+	return
+}
+
+// PrevBalanceHistoryPage returns the previous page of balance snapshots.
+func (c *Client) PrevBalanceHistoryPage(page hProtocol.BalanceHistoryPage) (snapshots hProtocol.BalanceHistoryPage, err error) {
+	err = c.sendGetRequest(page.Links.Prev.Href, &snapshots)
+	return
+}
+
 // NextTransactionsPage returns the next page of transactions.
 func (c *Client) NextTransactionsPage(page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
 	err = c.sendGetRequest(page.Links.Next.Href, &transactions)
@@ -948,5 +1029,36 @@ func (c *Client) PrevLiquidityPoolsPage(page hProtocol.LiquidityPoolsPage) (lp h
 	return
 }
 
+// ContractDetail returns the instance of a contract, including its executable and instance storage.
+func (c *Client) ContractDetail(request ContractRequest) (contract hProtocol.Contract, err error) {
+	err = c.sendRequest(request, &contract)
+	return
+}
+
+// ContractData returns the contract data entries of a contract. They can be filtered by durability
+// and key prefix.
+func (c *Client) ContractData(request ContractDataRequest) (data hProtocol.ContractDataPage, err error) {
+	err = c.sendRequest(request, &data)
+	return
+}
+
+// NextContractDataPage returns the next page of contract data entries.
+func (c *Client) NextContractDataPage(page hProtocol.ContractDataPage) (data hProtocol.ContractDataPage, err error) {
+	err = c.sendGetRequest(page.Links.Next.Href, &data)
+	return
+}
+
+// PrevContractDataPage returns the previous page of contract data entries.
+func (c *Client) PrevContractDataPage(page hProtocol.ContractDataPage) (data hProtocol.ContractDataPage, err error) {
+	err = c.sendGetRequest(page.Links.Prev.Href, &data)
+	return
+}
+
+// ContractCode returns the wasm code with the given hash.
+func (c *Client) ContractCode(request ContractCodeRequest) (code hProtocol.ContractCode, err error) {
+	err = c.sendRequest(request, &code)
+	return
+}
+
 // ensure that the horizon client implements ClientInterface
 var _ ClientInterface = &Client{}
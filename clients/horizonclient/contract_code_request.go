@@ -0,0 +1,47 @@
+package horizonclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// ContractCodeRequest struct contains data for getting the wasm code of contracts from a horizon server.
+type ContractCodeRequest struct {
+	Hash string
+}
+
+// BuildURL creates the endpoint to be queried based on the data in the ContractCodeRequest struct.
+func (r ContractCodeRequest) BuildURL() (endpoint string, err error) {
+	nParams := countParams(r.Hash)
+	if nParams <= 0 {
+		err = errors.New("invalid request: no parameters")
+	}
+	if err != nil {
+		return endpoint, err
+	}
+
+	endpoint = fmt.Sprintf(
+		"contract_code/%s",
+		r.Hash,
+	)
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the contract code endpoint
+func (r ContractCodeRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := r.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
@@ -0,0 +1,56 @@
+package horizonclient
+
+import (
+	"testing"
+
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractCodeRequestBuildUrl(t *testing.T) {
+	// It should return an error when no hash is set
+	endpoint, err := ContractCodeRequest{}.BuildURL()
+	assert.EqualError(t, err, "invalid request: no parameters")
+	assert.Equal(t, "", endpoint)
+
+	// It should return valid contract code endpoint and no errors
+	endpoint, err = ContractCodeRequest{Hash: "0200000000000000000000000000000000000000000000000000000000000000"}.BuildURL()
+	require.NoError(t, err)
+	assert.Equal(t, "contract_code/0200000000000000000000000000000000000000000000000000000000000000", endpoint)
+}
+
+func TestContractCodeRequest(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	hmock.On(
+		"GET",
+		"https://localhost/contract_code/0200000000000000000000000000000000000000000000000000000000000000",
+	).ReturnString(200, contractCodeResponse)
+
+	response, err := client.ContractCode(ContractCodeRequest{
+		Hash: "0200000000000000000000000000000000000000000000000000000000000000",
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0200000000000000000000000000000000000000000000000000000000000000", response.Hash)
+		assert.Equal(t, "AGFzbQ==", response.Code)
+		assert.Equal(t, uint32(9), response.LastModifiedLedger)
+		assert.Equal(t, uint32(200), response.LiveUntilLedgerSeq)
+	}
+}
+
+var contractCodeResponse = `{
+  "_links": {
+    "self": {
+      "href": "https://localhost/contract_code/0200000000000000000000000000000000000000000000000000000000000000"
+    }
+  },
+  "hash": "0200000000000000000000000000000000000000000000000000000000000000",
+  "code": "AGFzbQ==",
+  "last_modified_ledger": 9,
+  "live_until_ledger_seq": 200
+}`
@@ -0,0 +1,74 @@
+package horizonclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// ContractDataRequest struct contains data for getting the contract data entries of a contract
+// from a horizon server. "ForContract" is required.
+// "Durability" is either "persistent" or "temporary". If it is not set, entries of both
+// durabilities are returned.
+// "KeyPrefix" is a base64 encoded ScVal XDR. Only the entries whose key is equal to it are
+// returned, or, when it is a vector, the entries whose key is a vector starting with its elements.
+// The query parameters (Order, Cursor and Limit) are optional. All or none can be set.
+type ContractDataRequest struct {
+	ForContract string
+	Durability  string
+	KeyPrefix   string
+	Order       Order
+	Cursor      string
+	Limit       uint
+}
+
+// BuildURL creates the endpoint to be queried based on the data in the ContractDataRequest struct.
+func (r ContractDataRequest) BuildURL() (endpoint string, err error) {
+	nParams := countParams(r.ForContract)
+	if nParams <= 0 {
+		err = errors.New("invalid request: no contract")
+	}
+	if err != nil {
+		return endpoint, err
+	}
+
+	endpoint = fmt.Sprintf(
+		"contracts/%s/data",
+		r.ForContract,
+	)
+
+	if queryParams := addQueryParams(
+		map[string]string{
+			"durability": r.Durability,
+			"key_prefix": r.KeyPrefix,
+		},
+		cursor(r.Cursor),
+		limit(r.Limit),
+		r.Order,
+	); len(queryParams) > 0 {
+		endpoint = fmt.Sprintf(
+			"%s?%s",
+			endpoint,
+			queryParams,
+		)
+	}
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the contract data endpoint
+func (r ContractDataRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := r.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
@@ -0,0 +1,133 @@
+package horizonclient
+
+import (
+	"testing"
+
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractDataRequestBuildUrl(t *testing.T) {
+	// It should return an error when no contract is set
+	_, err := ContractDataRequest{}.BuildURL()
+	assert.EqualError(t, err, "invalid request: no contract")
+
+	cr := ContractDataRequest{ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}
+	endpoint, err := cr.BuildURL()
+
+	// It should return valid contract data endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data", endpoint)
+
+	cr = ContractDataRequest{
+		ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Durability:  "temporary",
+		KeyPrefix:   "AAAABQAAAAAAAAH+",
+		Cursor:      "0100000000000000000000000000000000000000000000000000000000000000",
+		Limit:       30,
+		Order:       OrderAsc,
+	}
+	endpoint, err = cr.BuildURL()
+
+	// It should return valid contract data endpoint with query params and no errors
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?"+
+			"cursor=0100000000000000000000000000000000000000000000000000000000000000&"+
+			"durability=temporary&key_prefix=AAAABQAAAAAAAAH%2B&limit=30&order=asc",
+		endpoint,
+	)
+}
+
+func TestNextContractDataPage(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	hmock.On(
+		"GET",
+		"https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?durability=temporary",
+	).ReturnString(200, firstContractDataPage)
+
+	page, err := client.ContractData(ContractDataRequest{
+		ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Durability:  "temporary",
+	})
+	if assert.NoError(t, err) && assert.Len(t, page.Embedded.Records, 1) {
+		record := page.Embedded.Records[0]
+		assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000000", record.PagingToken())
+		assert.Equal(t, "temporary", record.Durability)
+		assert.Equal(t, "AAAADwAAAAdjb3VudGVyAA==", record.Key.XDR)
+		assert.JSONEq(t, `"counter"`, string(record.Key.JSON))
+		assert.JSONEq(t, `7`, string(record.Value.JSON))
+	}
+
+	hmock.On(
+		"GET",
+		"https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=asc",
+	).ReturnString(200, emptyContractDataPage)
+
+	nextPage, err := client.NextContractDataPage(page)
+	if assert.NoError(t, err) {
+		assert.Len(t, nextPage.Embedded.Records, 0)
+	}
+}
+
+var firstContractDataPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=&durability=temporary&limit=10&order=asc"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=asc"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=desc"
+    }
+  },
+  "_embedded": {
+    "records": [
+      {
+        "_links": {
+          "contract": {
+            "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+          }
+        },
+        "paging_token": "0100000000000000000000000000000000000000000000000000000000000000",
+        "contract_id": "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+        "durability": "temporary",
+        "key": {
+          "xdr": "AAAADwAAAAdjb3VudGVyAA==",
+          "json": "counter"
+        },
+        "value": {
+          "xdr": "AAAAAwAAAAc=",
+          "json": 7
+        },
+        "last_modified_ledger": 11,
+        "live_until_ledger_seq": 27
+      }
+    ]
+  }
+}`
+
+var emptyContractDataPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=asc"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=asc"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data?cursor=0100000000000000000000000000000000000000000000000000000000000000&durability=temporary&limit=10&order=desc"
+    }
+  },
+  "_embedded": {
+    "records": []
+  }
+}`
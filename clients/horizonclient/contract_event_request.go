@@ -0,0 +1,80 @@
+package horizonclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+)
+
+// ContractEventHandler is a function that is called when a new contract event is received
+type ContractEventHandler func(hProtocol.ContractEvent)
+
+// BuildURL creates the endpoint to be queried based on the data in the ContractEventRequest struct.
+// If no contract is set, it defaults to the build the URL for the events of all the contracts
+func (cr ContractEventRequest) BuildURL() (endpoint string, err error) {
+	if len(cr.Topics) > 4 {
+		return endpoint, errors.New("invalid request: too many topics")
+	}
+
+	endpoint = "contract_events"
+	if cr.ForContract != "" {
+		endpoint = fmt.Sprintf("contracts/%s/events", cr.ForContract)
+	}
+
+	queryParams := addQueryParams(
+		map[string]string{
+			"type":   cr.Type,
+			"topics": strings.Join(cr.Topics, ","),
+		},
+		cursor(cr.Cursor),
+		limit(cr.Limit),
+		cr.Order,
+	)
+	if queryParams != "" {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, queryParams)
+	}
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the contract events endpoint
+func (cr ContractEventRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := cr.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
+
+// StreamContractEvents streams contract events. It can be used to stream all contract events or the
+// events of a contract. Use context.WithCancel to stop streaming or context.Background() if you want
+// to stream indefinitely. ContractEventHandler is a user-supplied function that is executed for each
+// streamed contract event received.
+func (cr ContractEventRequest) StreamContractEvents(ctx context.Context, client *Client, handler ContractEventHandler) error {
+	endpoint, err := cr.BuildURL()
+	if err != nil {
+		return errors.Wrap(err, "unable to build endpoint for contract events request")
+	}
+
+	url := fmt.Sprintf("%s%s", client.fixHorizonURL(), endpoint)
+	return client.stream(ctx, url, func(data []byte) error {
+		var event hProtocol.ContractEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return errors.Wrap(err, "error unmarshaling data for contract events request")
+		}
+		handler(event)
+		return nil
+	})
+}
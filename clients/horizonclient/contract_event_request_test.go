@@ -0,0 +1,176 @@
+package horizonclient
+
+import (
+	"context"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractEventRequestBuildUrl(t *testing.T) {
+	cr := ContractEventRequest{}
+	endpoint, err := cr.BuildURL()
+
+	// It should return valid all contract events endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contract_events", endpoint)
+
+	cr = ContractEventRequest{ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}
+	endpoint, err = cr.BuildURL()
+
+	// It should return valid contract events endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/events", endpoint)
+
+	cr = ContractEventRequest{
+		Type:   "contract",
+		Topics: []string{"AAAABQAAAAAAAAH+", "*"},
+		Cursor: "123456",
+		Limit:  30,
+		Order:  OrderAsc,
+	}
+	endpoint, err = cr.BuildURL()
+
+	// It should return valid all contract events endpoint with query params and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contract_events?cursor=123456&limit=30&order=asc&topics=AAAABQAAAAAAAAH%2B%2C%2A&type=contract", endpoint)
+
+	cr = ContractEventRequest{Topics: []string{"*", "*", "*", "*", "*"}}
+	_, err = cr.BuildURL()
+
+	// error case: too many topics
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid request: too many topics")
+	}
+}
+
+func TestContractEventRequestStreamContractEvents(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	eventRequest := ContractEventRequest{ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hmock.On(
+		"GET",
+		"https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/events?cursor=now",
+	).ReturnString(200, contractEventStreamResponse)
+
+	var events []hProtocol.ContractEvent
+	err := client.StreamContractEvents(ctx, eventRequest, func(event hProtocol.ContractEvent) {
+		events = append(events, event)
+		cancel()
+	})
+
+	if assert.NoError(t, err) && assert.Len(t, events, 1) {
+		assert.Equal(t, "240518172673-1", events[0].PagingToken())
+		assert.Equal(t, "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", events[0].ContractID)
+		assert.Equal(t, []string{"AAAADwAAAAh0cmFuc2Zlcg==", "AAAAAQ=="}, events[0].Topics)
+	}
+
+	// test error
+	ctx, cancel = context.WithCancel(context.Background())
+	hmock.On(
+		"GET",
+		"https://localhost/contract_events?cursor=now",
+	).ReturnString(500, contractEventStreamResponse)
+
+	err = client.StreamContractEvents(ctx, ContractEventRequest{}, func(event hProtocol.ContractEvent) {
+		cancel()
+	})
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "got bad HTTP status code 500")
+	}
+}
+
+func TestNextContractEventsPage(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	hmock.On(
+		"GET",
+		"https://localhost/contract_events?type=system",
+	).ReturnString(200, firstContractEventsPage)
+
+	page, err := client.ContractEvents(ContractEventRequest{Type: "system"})
+	if assert.NoError(t, err) && assert.Len(t, page.Embedded.Records, 1) {
+		assert.Equal(t, "system", page.Embedded.Records[0].Type)
+		assert.Empty(t, page.Embedded.Records[0].Topics)
+	}
+
+	hmock.On(
+		"GET",
+		"https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=asc&type=system",
+	).ReturnString(200, emptyContractEventsPage)
+
+	nextPage, err := client.NextContractEventsPage(page)
+	if assert.NoError(t, err) {
+		assert.Len(t, nextPage.Embedded.Records, 0)
+	}
+}
+
+var contractEventStreamResponse = `data: {"_links":{"transaction":{"href":"https://horizon-testnet.stellar.org/transactions/2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"},"operation":{"href":"https://horizon-testnet.stellar.org/operations/240518172673"}},"id":"0000000240518172673-0000000001","paging_token":"240518172673-1","type":"contract","contract_id":"CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF","ledger":56,"ledger_close_time":"2024-01-02T03:04:05Z","transaction_hash":"2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d","topics":["AAAADwAAAAh0cmFuc2Zlcg==","AAAAAQ=="],"value":"AAAAAwAAAAc="}
+`
+
+var firstContractEventsPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=&limit=10&order=asc&type=system"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=asc&type=system"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=desc&type=system"
+    }
+  },
+  "_embedded": {
+    "records": [
+      {
+        "_links": {
+          "transaction": {
+            "href": "https://horizon-testnet.stellar.org/transactions/2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+          },
+          "operation": {
+            "href": "https://horizon-testnet.stellar.org/operations/240518172673"
+          }
+        },
+        "id": "0000000240518172673-0000000002",
+        "paging_token": "240518172673-2",
+        "type": "system",
+        "ledger": 56,
+        "ledger_close_time": "2024-01-02T03:04:05Z",
+        "transaction_hash": "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d",
+        "topics": [],
+        "value": "AAAAAwAAAAc="
+      }
+    ]
+  }
+}`
+
+var emptyContractEventsPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=asc&type=system"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=asc&type=system"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/contract_events?cursor=240518172673-2&limit=10&order=desc&type=system"
+    }
+  },
+  "_embedded": {
+    "records": []
+  }
+}`
@@ -0,0 +1,47 @@
+package horizonclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// ContractRequest struct contains data for getting the instance of a contract from a horizon server.
+type ContractRequest struct {
+	ContractID string
+}
+
+// BuildURL creates the endpoint to be queried based on the data in the ContractRequest struct.
+func (r ContractRequest) BuildURL() (endpoint string, err error) {
+	nParams := countParams(r.ContractID)
+	if nParams <= 0 {
+		err = errors.New("invalid request: no parameters")
+	}
+	if err != nil {
+		return endpoint, err
+	}
+
+	endpoint = fmt.Sprintf(
+		"contracts/%s",
+		r.ContractID,
+	)
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the contract endpoint
+func (r ContractRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := r.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
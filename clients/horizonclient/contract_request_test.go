@@ -0,0 +1,100 @@
+package horizonclient
+
+import (
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractRequestBuildUrl(t *testing.T) {
+	// It should return an error when no contract is set
+	endpoint, err := ContractRequest{}.BuildURL()
+	assert.EqualError(t, err, "invalid request: no parameters")
+	assert.Equal(t, "", endpoint)
+
+	// It should return valid contract endpoint and no errors
+	endpoint, err = ContractRequest{ContractID: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}.BuildURL()
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", endpoint)
+}
+
+func TestContractDetailRequest(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	request := ContractRequest{ContractID: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}
+
+	hmock.On(
+		"GET",
+		"https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+	).ReturnString(200, contractResponse)
+
+	response, err := client.ContractDetail(request)
+	if assert.NoError(t, err) {
+		assert.IsType(t, response, hProtocol.Contract{})
+		assert.Equal(t, "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", response.ID)
+		assert.Equal(t, "wasm", response.ExecutableType)
+		assert.Equal(t, "0200000000000000000000000000000000000000000000000000000000000000", response.WasmHash)
+		if assert.Len(t, response.Storage, 1) {
+			assert.Equal(t, "AAAADwAAAAVBZG1pbgAAAA==", response.Storage[0].Key.XDR)
+			assert.JSONEq(t, `"Admin"`, string(response.Storage[0].Key.JSON))
+		}
+		assert.Equal(t, uint32(100), response.LiveUntilLedgerSeq)
+	}
+
+	// failure response
+	hmock.On(
+		"GET",
+		"https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+	).ReturnString(404, notFoundResponse)
+
+	_, err = client.ContractDetail(request)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "horizon error")
+		horizonError, ok := err.(*Error)
+		assert.Equal(t, ok, true)
+		assert.Equal(t, horizonError.Problem.Title, "Resource Missing")
+	}
+}
+
+var contractResponse = `{
+  "_links": {
+    "self": {
+      "href": "https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+    },
+    "data": {
+      "href": "https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/data{?cursor,limit,order}",
+      "templated": true
+    },
+    "events": {
+      "href": "https://localhost/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/events{?cursor,limit,order}",
+      "templated": true
+    },
+    "code": {
+      "href": "https://localhost/contract_code/0200000000000000000000000000000000000000000000000000000000000000"
+    }
+  },
+  "id": "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+  "executable_type": "wasm",
+  "wasm_hash": "0200000000000000000000000000000000000000000000000000000000000000",
+  "storage": [
+    {
+      "key": {
+        "xdr": "AAAADwAAAAVBZG1pbgAAAA==",
+        "json": "Admin"
+      },
+      "value": {
+        "xdr": "AAAAAQ==",
+        "json": null
+      }
+    }
+  ],
+  "last_modified_ledger": 10,
+  "live_until_ledger_seq": 100
+}`
@@ -181,6 +181,9 @@ type ClientInterface interface {
 	AccountDetail(request AccountRequest) (hProtocol.Account, error)
 	AccountData(request AccountRequest) (hProtocol.AccountData, error)
 	Effects(request EffectRequest) (effects.EffectsPage, error)
+	ContractEvents(request ContractEventRequest) (hProtocol.ContractEventsPage, error)
+	TokenTransfers(request TokenTransferRequest) (hProtocol.TokenTransfersPage, error)
+	BalanceHistory(request BalanceHistoryRequest) (hProtocol.BalanceHistoryPage, error)
 	Assets(request AssetRequest) (hProtocol.AssetsPage, error)
 	Ledgers(request LedgerRequest) (hProtocol.LedgersPage, error)
 	LedgerDetail(sequence uint32) (hProtocol.Ledger, error)
@@ -210,6 +213,9 @@ type ClientInterface interface {
 	StreamTransactions(ctx context.Context, request TransactionRequest, handler TransactionHandler) error
 	StreamTrades(ctx context.Context, request TradeRequest, handler TradeHandler) error
 	StreamEffects(ctx context.Context, request EffectRequest, handler EffectHandler) error
+	StreamContractEvents(ctx context.Context, request ContractEventRequest, handler ContractEventHandler) error
+	StreamTokenTransfers(ctx context.Context, request TokenTransferRequest, handler TokenTransferHandler) error
+	StreamBalanceHistory(ctx context.Context, request BalanceHistoryRequest, handler BalanceSnapshotHandler) error
 	StreamOperations(ctx context.Context, request OperationRequest, handler OperationHandler) error
 	StreamPayments(ctx context.Context, request OperationRequest, handler OperationHandler) error
 	StreamOffers(ctx context.Context, request OfferRequest, handler OfferHandler) error
@@ -223,6 +229,12 @@ type ClientInterface interface {
 	PrevLedgersPage(hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
 	NextEffectsPage(effects.EffectsPage) (effects.EffectsPage, error)
 	PrevEffectsPage(effects.EffectsPage) (effects.EffectsPage, error)
+	NextContractEventsPage(hProtocol.ContractEventsPage) (hProtocol.ContractEventsPage, error)
+	PrevContractEventsPage(hProtocol.ContractEventsPage) (hProtocol.ContractEventsPage, error)
+	NextTokenTransfersPage(hProtocol.TokenTransfersPage) (hProtocol.TokenTransfersPage, error)
+	PrevTokenTransfersPage(hProtocol.TokenTransfersPage) (hProtocol.TokenTransfersPage, error)
+	NextBalanceHistoryPage(hProtocol.BalanceHistoryPage) (hProtocol.BalanceHistoryPage, error)
+	PrevBalanceHistoryPage(hProtocol.BalanceHistoryPage) (hProtocol.BalanceHistoryPage, error)
 	NextTransactionsPage(hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
 	PrevTransactionsPage(hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
 	NextOperationsPage(operations.OperationsPage) (operations.OperationsPage, error)
@@ -240,6 +252,11 @@ type ClientInterface interface {
 	LiquidityPools(request LiquidityPoolsRequest) (hProtocol.LiquidityPoolsPage, error)
 	NextLiquidityPoolsPage(hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error)
 	PrevLiquidityPoolsPage(hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error)
+	ContractDetail(request ContractRequest) (hProtocol.Contract, error)
+	ContractData(request ContractDataRequest) (hProtocol.ContractDataPage, error)
+	NextContractDataPage(hProtocol.ContractDataPage) (hProtocol.ContractDataPage, error)
+	PrevContractDataPage(hProtocol.ContractDataPage) (hProtocol.ContractDataPage, error)
+	ContractCode(request ContractCodeRequest) (hProtocol.ContractCode, error)
 }
 
 // DefaultTestNetClient is a default client to connect to test network.
@@ -299,6 +316,58 @@ type EffectRequest struct {
 	Limit            uint
 }
 
+// ContractEventRequest struct contains data for getting contract events from a horizon server.
+// If "ForContract" is not set, the events of all the contracts are returned.
+// "Type" is either "contract" or "system". "Topics" are matched against the first topics of the
+// events, in order, each one being a base64 encoded ScVal XDR or the "*" wildcard. Not more than
+// four topics can be set.
+// The query parameters (Order, Cursor and Limit) are optional. All or none can be set.
+type ContractEventRequest struct {
+	ForContract string
+	Type        string
+	Topics      []string
+	Order       Order
+	Cursor      string
+	Limit       uint
+}
+
+// TokenTransferRequest struct contains data for getting token transfers from a horizon server.
+// "ForAccount" returns the transfers from or to an account and "ForContract" the transfers of a token
+// contract. Both can be set at the same time. If none are set, the transfers of all the tokens are
+// returned.
+// "ForAsset" is a classic asset in canonical form ("native" or "Code:IssuerAccountID"), its transfers
+// are the ones of its stellar asset contract.
+// "Type" is one of "transfer", "mint", "burn", "clawback" or "fee".
+// The query parameters (Order, Cursor and Limit) are optional. All or none can be set.
+type TokenTransferRequest struct {
+	ForAccount  string
+	ForContract string
+	ForAsset    string
+	Type        string
+	Order       Order
+	Cursor      string
+	Limit       uint
+}
+
+// BalanceHistoryRequest struct contains data for getting the balance history of an account or a
+// contract from a horizon server. "ForAccount" or "ForContract" must be set.
+// "Asset" is a classic asset in canonical form ("native" or "Code:IssuerAccountID") and is required.
+// The history is restricted to the ledgers in [StartLedger, EndLedger) and to the ledgers closed in
+// [StartTime, EndTime), zero values leave the range unbounded.
+// The query parameters (Order, Cursor and Limit) are optional. All or none can be set.
+type BalanceHistoryRequest struct {
+	ForAccount  string
+	ForContract string
+	Asset       string
+	StartLedger uint32
+	EndLedger   uint32
+	StartTime   time.Time
+	EndTime     time.Time
+	Order       Order
+	Cursor      string
+	Limit       uint
+}
+
 // AssetRequest struct contains data for getting asset details from a horizon server.
 // If "ForAssetCode" and "ForAssetIssuer" are not set, it returns all assets.
 // The query parameters (Order, Cursor and Limit) are optional. All or none can be set.
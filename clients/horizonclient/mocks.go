@@ -43,6 +43,24 @@ func (m *MockClient) Effects(request EffectRequest) (effects.EffectsPage, error)
 	return a.Get(0).(effects.EffectsPage), a.Error(1)
 }
 
+// ContractEvents is a mocking method
+func (m *MockClient) ContractEvents(request ContractEventRequest) (hProtocol.ContractEventsPage, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.ContractEventsPage), a.Error(1)
+}
+
+// TokenTransfers is a mocking method
+func (m *MockClient) TokenTransfers(request TokenTransferRequest) (hProtocol.TokenTransfersPage, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.TokenTransfersPage), a.Error(1)
+}
+
+// BalanceHistory is a mocking method
+func (m *MockClient) BalanceHistory(request BalanceHistoryRequest) (hProtocol.BalanceHistoryPage, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.BalanceHistoryPage), a.Error(1)
+}
+
 // Assets is a mocking method
 func (m *MockClient) Assets(request AssetRequest) (hProtocol.AssetsPage, error) {
 	a := m.Called(request)
@@ -214,6 +232,21 @@ func (m *MockClient) StreamEffects(ctx context.Context, request EffectRequest, h
 	return m.Called(ctx, request, handler).Error(0)
 }
 
+// StreamContractEvents is a mocking method
+func (m *MockClient) StreamContractEvents(ctx context.Context, request ContractEventRequest, handler ContractEventHandler) error {
+	return m.Called(ctx, request, handler).Error(0)
+}
+
+// StreamTokenTransfers is a mocking method
+func (m *MockClient) StreamTokenTransfers(ctx context.Context, request TokenTransferRequest, handler TokenTransferHandler) error {
+	return m.Called(ctx, request, handler).Error(0)
+}
+
+// StreamBalanceHistory is a mocking method
+func (m *MockClient) StreamBalanceHistory(ctx context.Context, request BalanceHistoryRequest, handler BalanceSnapshotHandler) error {
+	return m.Called(ctx, request, handler).Error(0)
+}
+
 // StreamOperations is a mocking method
 func (m *MockClient) StreamOperations(ctx context.Context, request OperationRequest, handler OperationHandler) error {
 	return m.Called(ctx, request, handler).Error(0)
@@ -287,6 +320,42 @@ func (m *MockClient) PrevEffectsPage(page effects.EffectsPage) (effects.EffectsP
 	return a.Get(0).(effects.EffectsPage), a.Error(1)
 }
 
+// NextContractEventsPage is a mocking method
+func (m *MockClient) NextContractEventsPage(page hProtocol.ContractEventsPage) (hProtocol.ContractEventsPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.ContractEventsPage), a.Error(1)
+}
+
+// PrevContractEventsPage is a mocking method
+func (m *MockClient) PrevContractEventsPage(page hProtocol.ContractEventsPage) (hProtocol.ContractEventsPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.ContractEventsPage), a.Error(1)
+}
+
+// NextTokenTransfersPage is a mocking method
+func (m *MockClient) NextTokenTransfersPage(page hProtocol.TokenTransfersPage) (hProtocol.TokenTransfersPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.TokenTransfersPage), a.Error(1)
+}
+
+// PrevTokenTransfersPage is a mocking method
+func (m *MockClient) PrevTokenTransfersPage(page hProtocol.TokenTransfersPage) (hProtocol.TokenTransfersPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.TokenTransfersPage), a.Error(1)
+}
+
+// NextBalanceHistoryPage is a mocking method
+func (m *MockClient) NextBalanceHistoryPage(page hProtocol.BalanceHistoryPage) (hProtocol.BalanceHistoryPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.BalanceHistoryPage), a.Error(1)
+}
+
+// PrevBalanceHistoryPage is a mocking method
+func (m *MockClient) PrevBalanceHistoryPage(page hProtocol.BalanceHistoryPage) (hProtocol.BalanceHistoryPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.BalanceHistoryPage), a.Error(1)
+}
+
 // NextTransactionsPage is a mocking method
 func (m *MockClient) NextTransactionsPage(page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error) {
 	a := m.Called(page)
@@ -383,6 +452,36 @@ func (m *MockClient) PrevLiquidityPoolsPage(page hProtocol.LiquidityPoolsPage) (
 	return a.Get(0).(hProtocol.LiquidityPoolsPage), a.Error(1)
 }
 
+// ContractDetail is a mocking method
+func (m *MockClient) ContractDetail(request ContractRequest) (hProtocol.Contract, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.Contract), a.Error(1)
+}
+
+// ContractData is a mocking method
+func (m *MockClient) ContractData(request ContractDataRequest) (hProtocol.ContractDataPage, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.ContractDataPage), a.Error(1)
+}
+
+// NextContractDataPage is a mocking method
+func (m *MockClient) NextContractDataPage(page hProtocol.ContractDataPage) (hProtocol.ContractDataPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.ContractDataPage), a.Error(1)
+}
+
+// PrevContractDataPage is a mocking method
+func (m *MockClient) PrevContractDataPage(page hProtocol.ContractDataPage) (hProtocol.ContractDataPage, error) {
+	a := m.Called(page)
+	return a.Get(0).(hProtocol.ContractDataPage), a.Error(1)
+}
+
+// ContractCode is a mocking method
+func (m *MockClient) ContractCode(request ContractCodeRequest) (hProtocol.ContractCode, error) {
+	a := m.Called(request)
+	return a.Get(0).(hProtocol.ContractCode), a.Error(1)
+}
+
 func (m *MockAdminClient) GetIngestionAccountFilter() (hProtocol.AccountFilterConfig, error) {
 	a := m.Called()
 	return a.Get(0).(hProtocol.AccountFilterConfig), a.Error(1)
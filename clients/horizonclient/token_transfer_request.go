@@ -0,0 +1,80 @@
+package horizonclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+)
+
+// TokenTransferHandler is a function that is called when a new token transfer is received
+type TokenTransferHandler func(hProtocol.TokenTransfer)
+
+// BuildURL creates the endpoint to be queried based on the data in the TokenTransferRequest struct.
+// If no account or contract is set, it defaults to the build the URL for the transfers of all the tokens
+func (tr TokenTransferRequest) BuildURL() (endpoint string, err error) {
+	endpoint = "token_transfers"
+	params := map[string]string{
+		"asset": tr.ForAsset,
+		"type":  tr.Type,
+	}
+	switch {
+	case tr.ForAccount != "":
+		endpoint = fmt.Sprintf("accounts/%s/token_transfers", tr.ForAccount)
+		params["contract_id"] = tr.ForContract
+	case tr.ForContract != "":
+		endpoint = fmt.Sprintf("contracts/%s/token_transfers", tr.ForContract)
+	}
+
+	queryParams := addQueryParams(
+		params,
+		cursor(tr.Cursor),
+		limit(tr.Limit),
+		tr.Order,
+	)
+	if queryParams != "" {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, queryParams)
+	}
+
+	_, err = url.Parse(endpoint)
+	if err != nil {
+		err = errors.Wrap(err, "failed to parse endpoint")
+	}
+
+	return endpoint, err
+}
+
+// HTTPRequest returns the http request for the token transfers endpoint
+func (tr TokenTransferRequest) HTTPRequest(horizonURL string) (*http.Request, error) {
+	endpoint, err := tr.BuildURL()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.NewRequest("GET", horizonURL+endpoint, nil)
+}
+
+// StreamTokenTransfers streams token transfers. It can be used to stream all token transfers or the
+// transfers of an account, of a token contract or of an asset. Use context.WithCancel to stop streaming
+// or context.Background() if you want to stream indefinitely. TokenTransferHandler is a user-supplied
+// function that is executed for each streamed token transfer received.
+func (tr TokenTransferRequest) StreamTokenTransfers(ctx context.Context, client *Client, handler TokenTransferHandler) error {
+	endpoint, err := tr.BuildURL()
+	if err != nil {
+		return errors.Wrap(err, "unable to build endpoint for token transfers request")
+	}
+
+	url := fmt.Sprintf("%s%s", client.fixHorizonURL(), endpoint)
+	return client.stream(ctx, url, func(data []byte) error {
+		var transfer hProtocol.TokenTransfer
+		if err := json.Unmarshal(data, &transfer); err != nil {
+			return errors.Wrap(err, "error unmarshaling data for token transfers request")
+		}
+		handler(transfer)
+		return nil
+	})
+}
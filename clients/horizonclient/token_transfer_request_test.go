@@ -0,0 +1,187 @@
+package horizonclient
+
+import (
+	"context"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenTransferRequestBuildUrl(t *testing.T) {
+	tr := TokenTransferRequest{}
+	endpoint, err := tr.BuildURL()
+
+	// It should return valid all token transfers endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "token_transfers", endpoint)
+
+	tr = TokenTransferRequest{ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"}
+	endpoint, err = tr.BuildURL()
+
+	// It should return valid account token transfers endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/token_transfers", endpoint)
+
+	tr = TokenTransferRequest{
+		ForAccount:  "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+	}
+	endpoint, err = tr.BuildURL()
+
+	// It should filter the account token transfers by contract
+	require.NoError(t, err)
+	assert.Equal(t, "accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/token_transfers?contract_id=CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", endpoint)
+
+	tr = TokenTransferRequest{ForContract: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"}
+	endpoint, err = tr.BuildURL()
+
+	// It should return valid contract token transfers endpoint and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF/token_transfers", endpoint)
+
+	tr = TokenTransferRequest{
+		ForAsset: "USD:GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+		Type:     "mint",
+		Cursor:   "123456",
+		Limit:    30,
+		Order:    OrderDesc,
+	}
+	endpoint, err = tr.BuildURL()
+
+	// It should return valid all token transfers endpoint with query params and no errors
+	require.NoError(t, err)
+	assert.Equal(t, "token_transfers?asset=USD%3AGAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY&cursor=123456&limit=30&order=desc&type=mint", endpoint)
+}
+
+func TestTokenTransferRequestStreamTokenTransfers(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	request := TokenTransferRequest{ForAccount: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY/token_transfers?cursor=now",
+	).ReturnString(200, tokenTransferStreamResponse)
+
+	var transfers []hProtocol.TokenTransfer
+	err := client.StreamTokenTransfers(ctx, request, func(transfer hProtocol.TokenTransfer) {
+		transfers = append(transfers, transfer)
+		cancel()
+	})
+
+	if assert.NoError(t, err) && assert.Len(t, transfers, 1) {
+		assert.Equal(t, "240518172673-1", transfers[0].PagingToken())
+		assert.Equal(t, "transfer", transfers[0].Type)
+		assert.Equal(t, "native", transfers[0].AssetType)
+		assert.Equal(t, "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY", transfers[0].From)
+		assert.Equal(t, "10000000", transfers[0].Amount)
+	}
+
+	// test error
+	ctx, cancel = context.WithCancel(context.Background())
+	hmock.On(
+		"GET",
+		"https://localhost/token_transfers?cursor=now",
+	).ReturnString(500, tokenTransferStreamResponse)
+
+	err = client.StreamTokenTransfers(ctx, TokenTransferRequest{}, func(transfer hProtocol.TokenTransfer) {
+		cancel()
+	})
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "got bad HTTP status code 500")
+	}
+}
+
+func TestNextTokenTransfersPage(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	hmock.On(
+		"GET",
+		"https://localhost/token_transfers?type=fee",
+	).ReturnString(200, firstTokenTransfersPage)
+
+	page, err := client.TokenTransfers(TokenTransferRequest{Type: "fee"})
+	if assert.NoError(t, err) && assert.Len(t, page.Embedded.Records, 1) {
+		assert.Equal(t, "fee", page.Embedded.Records[0].Type)
+		assert.Equal(t, "-40", page.Embedded.Records[0].Amount)
+		assert.Nil(t, page.Embedded.Records[0].Links.Operation)
+	}
+
+	hmock.On(
+		"GET",
+		"https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=asc&type=fee",
+	).ReturnString(200, emptyTokenTransfersPage)
+
+	nextPage, err := client.NextTokenTransfersPage(page)
+	if assert.NoError(t, err) {
+		assert.Len(t, nextPage.Embedded.Records, 0)
+	}
+}
+
+var tokenTransferStreamResponse = `data: {"_links":{"transaction":{"href":"https://horizon-testnet.stellar.org/transactions/2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"},"operation":{"href":"https://horizon-testnet.stellar.org/operations/240518172673"}},"id":"0000000240518172673-0000000001","paging_token":"240518172673-1","type":"transfer","ledger":56,"ledger_close_time":"2024-01-02T03:04:05Z","transaction_hash":"2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d","contract_id":"CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC","asset_type":"native","from":"GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY","to":"GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON","amount":"10000000"}
+`
+
+var firstTokenTransfersPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=&limit=10&order=asc&type=fee"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=asc&type=fee"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=desc&type=fee"
+    }
+  },
+  "_embedded": {
+    "records": [
+      {
+        "_links": {
+          "transaction": {
+            "href": "https://horizon-testnet.stellar.org/transactions/2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+          }
+        },
+        "id": "0000000240518168576-0000000002",
+        "paging_token": "240518168576-2",
+        "type": "fee",
+        "ledger": 56,
+        "ledger_close_time": "2024-01-02T03:04:05Z",
+        "transaction_hash": "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d",
+        "contract_id": "CDLZFC3SYJYDZT7K67VZ75HPJVIEUVNIXF47ZG2FB2RMQQVU2HHGCYSC",
+        "asset_type": "native",
+        "from": "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+        "amount": "-40"
+      }
+    ]
+  }
+}`
+
+var emptyTokenTransfersPage = `{
+  "_links": {
+    "self": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=asc&type=fee"
+    },
+    "next": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=asc&type=fee"
+    },
+    "prev": {
+      "href": "https://horizon-testnet.stellar.org/token_transfers?cursor=240518168576-2&limit=10&order=desc&type=fee"
+    }
+  },
+  "_embedded": {
+    "records": []
+  }
+}`
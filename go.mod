@@ -68,6 +68,7 @@ require (
 	github.com/docker/go-connections v0.5.0
 	github.com/fsouza/fake-gcs-server v1.49.2
 	github.com/go-chi/chi/v5 v5.1.0
+	github.com/graphql-go/graphql v0.8.1
 	github.com/riandyrn/otelchi v0.12.1
 	github.com/stellar/stellar-rpc v0.9.6-0.20250130160539-be7702aa01ba
 	go.opentelemetry.io/otel v1.34.0
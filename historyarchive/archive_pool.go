@@ -71,6 +71,15 @@ func NewArchivePoolWithBackoff(archiveURLs []string, opts ArchiveOptions, strate
 	return &ap, nil
 }
 
+// Archives returns the archives of the pool, so requests can be sent to a
+// specific archive of the pool, for example to retry a download from a
+// different archive.
+func (pa *ArchivePool) Archives() []ArchiveInterface {
+	archives := make([]ArchiveInterface, len(pa.pool))
+	copy(archives, pa.pool)
+	return archives
+}
+
 func (pa *ArchivePool) GetStats() []ArchiveStats {
 	stats := []ArchiveStats{}
 	for _, archive := range pa.pool {
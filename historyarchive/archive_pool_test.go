@@ -108,3 +108,17 @@ func TestArchivePoolCycles(t *testing.T) {
 		740*time.Millisecond, // some leeway
 		"")
 }
+
+func TestArchivePoolArchives(t *testing.T) {
+	pool, err := NewArchivePool([]string{
+		"file:///tmp/fake-archive/1",
+		"file:///tmp/fake-archive/2",
+	}, ArchiveOptions{})
+	require.NoError(t, err)
+
+	archives := pool.(*ArchivePool).Archives()
+	require.Len(t, archives, 2)
+	assert.NotSame(t, archives[0], archives[1])
+	archives[0] = nil
+	assert.NotNil(t, pool.(*ArchivePool).Archives()[0])
+}
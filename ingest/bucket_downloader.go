@@ -0,0 +1,240 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+const defaultBucketDownloadConcurrency = 4
+
+// VerifiedBucketsConfig configures how a CheckpointChangeReader created with
+// NewCheckpointChangeReaderWithVerifiedBuckets downloads buckets.
+type VerifiedBucketsConfig struct {
+	// CachePath is the directory where verified buckets are stored, keyed by
+	// their hash. Buckets found in it are reused instead of being downloaded
+	// again, so it can be shared across runs.
+	CachePath string
+	// Concurrency is the number of buckets downloaded in parallel, 4 when
+	// not set.
+	Concurrency int
+}
+
+// bucketDownload is the download of a single bucket, done is closed when the
+// download is finished.
+type bucketDownload struct {
+	done chan struct{}
+	err  error
+}
+
+// bucketDownloader downloads buckets in parallel to a local cache. The hash
+// of every bucket is verified while it is downloaded and, when it does not
+// match, the bucket is downloaded again from the next archive.
+type bucketDownloader struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	archives    []historyarchive.ArchiveInterface
+	cachePath   string
+	concurrency int
+	downloads   map[historyarchive.Hash]*bucketDownload
+	wg          sync.WaitGroup
+}
+
+func newBucketDownloader(
+	ctx context.Context,
+	archive historyarchive.ArchiveInterface,
+	config VerifiedBucketsConfig,
+) (*bucketDownloader, error) {
+	if config.CachePath == "" {
+		return nil, errors.New("bucket cache path is required")
+	}
+	if err := os.MkdirAll(config.CachePath, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create bucket cache directory")
+	}
+
+	archives := []historyarchive.ArchiveInterface{archive}
+	if pool, ok := archive.(*historyarchive.ArchivePool); ok {
+		archives = pool.Archives()
+	}
+	concurrency := config.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBucketDownloadConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &bucketDownloader{
+		ctx:         ctx,
+		cancel:      cancel,
+		archives:    archives,
+		cachePath:   config.CachePath,
+		concurrency: concurrency,
+		downloads:   map[historyarchive.Hash]*bucketDownload{},
+	}, nil
+}
+
+// start downloads the buckets in the background, in the given order.
+func (d *bucketDownloader) start(buckets []historyarchive.Hash) {
+	type queuedBucket struct {
+		index int
+		hash  historyarchive.Hash
+	}
+	queue := make(chan queuedBucket, len(buckets))
+	for i, hash := range buckets {
+		if _, ok := d.downloads[hash]; ok {
+			continue
+		}
+		d.downloads[hash] = &bucketDownload{done: make(chan struct{})}
+		queue <- queuedBucket{index: i, hash: hash}
+	}
+	close(queue)
+
+	for i := 0; i < d.concurrency; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for bucket := range queue {
+				download := d.downloads[bucket.hash]
+				download.err = d.download(bucket.index, bucket.hash)
+				close(download.done)
+			}
+		}()
+	}
+}
+
+// stop cancels the downloads in progress and waits for them to return.
+func (d *bucketDownloader) stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *bucketDownloader) path(hash historyarchive.Hash) string {
+	return filepath.Join(d.cachePath, "bucket-"+hash.String()+".xdr.gz")
+}
+
+// cachedSize returns the size of the bucket in the cache, if it is cached.
+func (d *bucketDownloader) cachedSize(hash historyarchive.Hash) (int64, bool) {
+	info, err := os.Stat(d.path(hash))
+	if err != nil {
+		return 0, false
+	}
+	return info.Size(), true
+}
+
+// open waits until the bucket is downloaded and returns a stream reading it
+// from the cache.
+func (d *bucketDownloader) open(hash historyarchive.Hash) (*xdr.Stream, error) {
+	download, ok := d.downloads[hash]
+	if !ok {
+		return nil, errors.Errorf("bucket %s is not downloaded", hash)
+	}
+	select {
+	case <-download.done:
+	case <-d.ctx.Done():
+		return nil, d.ctx.Err()
+	}
+	if download.err != nil {
+		return nil, download.err
+	}
+
+	file, err := os.Open(d.path(hash))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open cached bucket")
+	}
+	return xdr.NewGzStream(file)
+}
+
+// download downloads the bucket to the cache, unless it is cached already.
+// The first archive the bucket is downloaded from depends on its index, so
+// the downloads are spread across the archives.
+func (d *bucketDownloader) download(index int, hash historyarchive.Hash) error {
+	if _, ok := d.cachedSize(hash); ok {
+		return nil
+	}
+
+	var err error
+	for i := range d.archives {
+		if d.ctx.Err() != nil {
+			return d.ctx.Err()
+		}
+		archive := d.archives[(index+i)%len(d.archives)]
+		if err = d.downloadFrom(archive, hash); err == nil {
+			return nil
+		}
+	}
+	return errors.Wrapf(err, "could not download bucket %s from any of the %d archives", hash, len(d.archives))
+}
+
+// downloadFrom downloads the bucket from the archive to a temporary file,
+// which is moved to the cache once the hash of the bucket is verified.
+func (d *bucketDownloader) downloadFrom(archive historyarchive.ArchiveInterface, hash historyarchive.Hash) (err error) {
+	stream, err := archive.GetXdrStreamForHash(hash)
+	if err != nil {
+		return errors.Wrap(err, "could not open bucket")
+	}
+	stream.SetExpectedHash(hash)
+
+	file, err := os.CreateTemp(d.cachePath, "bucket-"+hash.String()+"-*.tmp")
+	if err != nil {
+		stream.Close()
+		return errors.Wrap(err, "could not create bucket file")
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+
+	// the entries are encoded again when they are written to the cache, the
+	// hash of the written entries is checked too so a cached bucket always
+	// matches its hash when it is read
+	writtenHash := sha256.New()
+	gzipWriter := gzip.NewWriter(file)
+	writer := io.MultiWriter(gzipWriter, writtenHash)
+	var entry xdr.BucketEntry
+	for {
+		if err = d.ctx.Err(); err != nil {
+			stream.Close()
+			return err
+		}
+		if err = stream.ReadOne(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			stream.Close()
+			return errors.Wrap(err, "could not read bucket entry")
+		}
+		if err = xdr.MarshalFramed(writer, entry); err != nil {
+			stream.Close()
+			return errors.Wrap(err, "could not write bucket entry")
+		}
+	}
+	if err = stream.Close(); err != nil {
+		return errors.Wrap(err, "could not verify bucket")
+	}
+	if !bytes.Equal(writtenHash.Sum(nil), hash[:]) {
+		return errors.New("hash of the cached bucket does not match the bucket hash")
+	}
+
+	if err = gzipWriter.Close(); err != nil {
+		return errors.Wrap(err, "could not write bucket file")
+	}
+	if err = file.Sync(); err != nil {
+		return errors.Wrap(err, "could not sync bucket file")
+	}
+	if err = file.Close(); err != nil {
+		return errors.Wrap(err, "could not close bucket file")
+	}
+	if err = os.Rename(file.Name(), d.path(hash)); err != nil {
+		return errors.Wrap(err, "could not move bucket file to the cache")
+	}
+	return nil
+}
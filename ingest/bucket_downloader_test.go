@@ -0,0 +1,148 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/xdr"
+)
+
+const verifiedBucketsLedger = uint32(63)
+
+// encodeBucket returns the encoded bucket made of the given entries and its
+// hash.
+func encodeBucket(t *testing.T, entries ...xdr.BucketEntry) ([]byte, historyarchive.Hash) {
+	var b bytes.Buffer
+	for _, entry := range entries {
+		require.NoError(t, xdr.MarshalFramed(&b, entry))
+	}
+	return b.Bytes(), sha256.Sum256(b.Bytes())
+}
+
+func bucketStream(raw []byte) *xdr.Stream {
+	return xdrStreamFromBuffer(bytes.NewBuffer(raw))
+}
+
+func verifiedBucketsArchive(has historyarchive.HistoryArchiveState) *historyarchive.MockArchive {
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetCheckpointManager").
+		Return(historyarchive.NewCheckpointManager(historyarchive.DefaultCheckpointFrequency))
+	archive.On("GetCheckpointHAS", verifiedBucketsLedger).Return(has, nil)
+	return archive
+}
+
+func readAccountBalances(t *testing.T, reader *CheckpointChangeReader) (map[string]xdr.Int64, error) {
+	balances := map[string]xdr.Int64{}
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		account := change.Post.Data.MustAccount()
+		balances[account.AccountId.Address()] = account.Balance
+	}
+	require.NoError(t, reader.Close())
+	return balances, nil
+}
+
+func TestCheckpointChangeReaderWithVerifiedBuckets(t *testing.T) {
+	ctx := context.Background()
+	cachePath := filepath.Join(t.TempDir(), "buckets")
+	first := "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"
+	second := "GB7BDSZU2Y27LYNLALKKALB52WS2IZWYBDGY6EQBLEED3TJOCVMZRH7H"
+	curr, currHash := encodeBucket(t, entryAccount(xdr.BucketEntryTypeLiveentry, first, 2))
+	snap, snapHash := encodeBucket(t,
+		entryAccount(xdr.BucketEntryTypeLiveentry, first, 1),
+		entryAccount(xdr.BucketEntryTypeLiveentry, second, 3),
+	)
+	corrupted, _ := encodeBucket(t, entryAccount(xdr.BucketEntryTypeLiveentry, first, 100))
+
+	var has historyarchive.HistoryArchiveState
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = historyarchive.Hash{}.String()
+		has.CurrentBuckets[i].Snap = historyarchive.Hash{}.String()
+	}
+	has.CurrentBuckets[0].Curr = currHash.String()
+	has.CurrentBuckets[0].Snap = snapHash.String()
+	expected := map[string]xdr.Int64{first: 2, second: 3}
+
+	_, err := NewCheckpointChangeReaderWithVerifiedBuckets(ctx, verifiedBucketsArchive(has), verifiedBucketsLedger, VerifiedBucketsConfig{})
+	require.EqualError(t, err, "bucket cache path is required")
+
+	// the first archive returns a corrupted bucket, which is downloaded again
+	// from the second archive
+	corruptedArchive := verifiedBucketsArchive(has)
+	corruptedArchive.On("BucketExists", mock.Anything).Return(true, nil).Twice()
+	corruptedArchive.On("BucketSize", mock.Anything).Return(int64(100), nil).Twice()
+	corruptedArchive.On("GetXdrStreamForHash", currHash).Return(bucketStream(corrupted), nil).Once()
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetXdrStreamForHash", currHash).Return(bucketStream(curr), nil).Once()
+	archive.On("GetXdrStreamForHash", snapHash).Return(bucketStream(snap), nil).Once()
+
+	reader, err := NewCheckpointChangeReaderWithVerifiedBuckets(ctx, corruptedArchive, verifiedBucketsLedger, VerifiedBucketsConfig{
+		CachePath:   cachePath,
+		Concurrency: 2,
+	})
+	require.NoError(t, err)
+	reader.bucketDownloader.archives = []historyarchive.ArchiveInterface{corruptedArchive, archive}
+	balances, err := readAccountBalances(t, reader)
+	require.NoError(t, err)
+	require.Equal(t, expected, balances)
+	corruptedArchive.AssertExpectations(t)
+	archive.AssertExpectations(t)
+
+	files, err := os.ReadDir(cachePath)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	require.ElementsMatch(t, []string{
+		"bucket-" + currHash.String() + ".xdr.gz",
+		"bucket-" + snapHash.String() + ".xdr.gz",
+	}, names)
+
+	// the cached buckets are reused without accessing the archive
+	cachedArchive := verifiedBucketsArchive(has)
+	reader, err = NewCheckpointChangeReaderWithVerifiedBuckets(ctx, cachedArchive, verifiedBucketsLedger, VerifiedBucketsConfig{
+		CachePath: cachePath,
+	})
+	require.NoError(t, err)
+	balances, err = readAccountBalances(t, reader)
+	require.NoError(t, err)
+	require.Equal(t, expected, balances)
+	cachedArchive.AssertExpectations(t)
+
+	// a bucket which is corrupted in every archive is not cached
+	corruptedArchive = verifiedBucketsArchive(has)
+	corruptedArchive.On("BucketExists", mock.Anything).Return(true, nil).Twice()
+	corruptedArchive.On("BucketSize", mock.Anything).Return(int64(100), nil).Twice()
+	corruptedArchive.On("GetXdrStreamForHash", currHash).Return(bucketStream(corrupted), nil).Once()
+	corruptedArchive.On("GetXdrStreamForHash", snapHash).Return(bucketStream(corrupted), nil).Once()
+	emptyCachePath := t.TempDir()
+	reader, err = NewCheckpointChangeReaderWithVerifiedBuckets(ctx, corruptedArchive, verifiedBucketsLedger, VerifiedBucketsConfig{
+		CachePath: emptyCachePath,
+	})
+	require.NoError(t, err)
+	_, err = readAccountBalances(t, reader)
+	require.ErrorContains(t, err, "could not download bucket "+currHash.String()+" from any of the 1 archives")
+	require.ErrorContains(t, err, "Stream hash does not match expected hash!")
+	// the reader returns io.EOF once the downloads in progress are stopped
+	for err != io.EOF {
+		_, err = reader.Read()
+	}
+	files, err = os.ReadDir(emptyCachePath)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -141,11 +141,26 @@ func (c Change) LedgerKey() (xdr.LedgerKey, error) {
 // stellar-core source:
 // https://github.com/stellar/stellar-core/blob/e584b43/src/ledger/LedgerTxn.cpp#L582
 func GetChangesFromLedgerEntryChanges(ledgerEntryChanges xdr.LedgerEntryChanges) []Change {
+	return getFilteredChangesFromLedgerEntryChanges(ledgerEntryChanges, nil, noFootprint)
+}
+
+// getFilteredChangesFromLedgerEntryChanges behaves as
+// GetChangesFromLedgerEntryChanges but only returns the changes matched by the
+// filter, when it is not nil. Changes which are not matched are skipped before
+// they are sorted.
+func getFilteredChangesFromLedgerEntryChanges(
+	ledgerEntryChanges xdr.LedgerEntryChanges,
+	filter *changeFilter,
+	footprint footprintFunc,
+) []Change {
 	changes := make([]Change, 0, len(ledgerEntryChanges))
 	for i, entryChange := range ledgerEntryChanges {
 		switch entryChange.Type {
 		case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
 			created := entryChange.MustCreated()
+			if filter != nil && !filter.match(&created, footprint) {
+				continue
+			}
 			changes = append(changes, Change{
 				Type:       created.Data.Type,
 				Pre:        nil,
@@ -162,6 +177,9 @@ func GetChangesFromLedgerEntryChanges(ledgerEntryChanges xdr.LedgerEntryChanges)
 			if !ok {
 				state = ledgerEntryChanges[i-1].MustRestored()
 			}
+			if filter != nil && !filter.match(&state, footprint) {
+				continue
+			}
 			changes = append(changes, Change{
 				Type:       state.Data.Type,
 				Pre:        &state,
@@ -177,6 +195,9 @@ func GetChangesFromLedgerEntryChanges(ledgerEntryChanges xdr.LedgerEntryChanges)
 			if !ok {
 				state = ledgerEntryChanges[i-1].MustRestored()
 			}
+			if filter != nil && !filter.match(&state, footprint) {
+				continue
+			}
 			changes = append(changes, Change{
 				Type:       state.Data.Type,
 				Pre:        &state,
@@ -185,6 +206,9 @@ func GetChangesFromLedgerEntryChanges(ledgerEntryChanges xdr.LedgerEntryChanges)
 			})
 		case xdr.LedgerEntryChangeTypeLedgerEntryRestored:
 			restored := entryChange.MustRestored()
+			if filter != nil && !filter.match(&restored, footprint) {
+				continue
+			}
 			changes = append(changes, Change{
 				Type:       restored.Data.Type,
 				Pre:        nil,
@@ -0,0 +1,350 @@
+package ingest
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// ChangeFilter selects the changes returned by the filtered ledger readers,
+// see NewFilteredLedgerChangeReader and NewFilteredLedgerTransactionReader.
+// The changes are filtered before they are converted to Changes, so narrow
+// filters skip the conversion, sorting and envelope hashing done to read a
+// ledger. The zero value matches every change.
+//
+// The readers created from the raw XDR of a ledger, e.g.
+// NewFilteredLedgerChangeReaderFromRawLedgerCloseMeta, decode it lazily: the
+// metas of the transactions which don't contain the key of any account,
+// contract or asset filtered are skipped rather than decoded. Filters on
+// entry types alone, or on the native asset, can't skip any meta.
+type ChangeFilter struct {
+	// EntryTypes are the types of the ledger entries matched. Entries of any
+	// type are matched if empty.
+	EntryTypes []xdr.LedgerEntryType
+
+	// Accounts, Contracts and Assets restrict the changes matched to the
+	// ledger entries they own. All the entries are matched if they are all
+	// empty, otherwise an entry is matched if it is owned by any of them:
+	//
+	//   - Accounts own their account, trust lines, offers and data entries
+	//     and the claimable balances they can claim,
+	//   - Contracts own their contract data,
+	//   - Assets own their trust lines, the offers selling or buying them, the
+	//     claimable balances and liquidity pools holding them and the contract
+	//     data of their Stellar Asset Contract.
+	//
+	// The TTL entries of the contract data owned are matched as well.
+	Accounts  []string
+	Contracts []string
+	Assets    []xdr.Asset
+}
+
+// changeFilter is a ChangeFilter compiled for fast matching.
+type changeFilter struct {
+	entryTypes map[xdr.LedgerEntryType]bool
+	accounts   map[xdr.Uint256]bool
+	contracts  map[xdr.ContractId]bool
+	assets     []xdr.Asset
+	filterType bool
+	filterKeys bool
+	// needles are the keys found in the XDR of every entry matched, TTL
+	// entries aside, or nil if the entries matched can't be found in the raw
+	// XDR of the changes
+	needles [][]byte
+}
+
+// compile validates the filter and compiles it. The network passphrase is
+// used to derive the ids of the Stellar Asset Contracts of the assets.
+func (f ChangeFilter) compile(networkPassphrase string) (*changeFilter, error) {
+	filter := &changeFilter{
+		entryTypes: map[xdr.LedgerEntryType]bool{},
+		accounts:   map[xdr.Uint256]bool{},
+		contracts:  map[xdr.ContractId]bool{},
+		assets:     f.Assets,
+		filterType: len(f.EntryTypes) > 0,
+		filterKeys: len(f.Accounts) > 0 || len(f.Contracts) > 0 || len(f.Assets) > 0,
+	}
+	for _, entryType := range f.EntryTypes {
+		filter.entryTypes[entryType] = true
+	}
+	for _, account := range f.Accounts {
+		raw, err := strkey.Decode(strkey.VersionByteAccountID, account)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid account %s", account)
+		}
+		var key xdr.Uint256
+		copy(key[:], raw)
+		filter.accounts[key] = true
+	}
+	for _, contract := range f.Contracts {
+		raw, err := strkey.Decode(strkey.VersionByteContract, contract)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid contract %s", contract)
+		}
+		var contractID xdr.ContractId
+		copy(contractID[:], raw)
+		filter.contracts[contractID] = true
+	}
+	for _, asset := range f.Assets {
+		contractID, err := asset.ContractID(networkPassphrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid asset %s", asset.StringCanonical())
+		}
+		filter.contracts[contractID] = true
+	}
+	filter.needles = filter.xdrNeedles()
+	return filter, nil
+}
+
+// xdrNeedles returns the keys searched in the raw XDR of the changes: the keys
+// of the accounts, the ids of the contracts and the issuers of the assets.
+func (f *changeFilter) xdrNeedles() [][]byte {
+	if !f.filterKeys {
+		return nil
+	}
+	var needles [][]byte
+	for key := range f.accounts {
+		needles = append(needles, key[:])
+	}
+	for contractID := range f.contracts {
+		needles = append(needles, contractID[:])
+	}
+	for _, asset := range f.assets {
+		issuer, err := asset.GetIssuerAccountId()
+		if err != nil {
+			// the native asset has no key found in its entries
+			return nil
+		}
+		key, ok := issuer.GetEd25519()
+		if !ok {
+			return nil
+		}
+		needles = append(needles, key[:])
+	}
+	return needles
+}
+
+// footprintFunc returns the soroban footprint of the transaction the changes
+// filtered belong to. It is only called to match TTL entries.
+type footprintFunc func() (xdr.LedgerFootprint, bool)
+
+func noFootprint() (xdr.LedgerFootprint, bool) {
+	return xdr.LedgerFootprint{}, false
+}
+
+// match returns true if the change of the entry is matched by the filter.
+func (f *changeFilter) match(entry *xdr.LedgerEntry, footprint footprintFunc) bool {
+	if f.filterType && !f.entryTypes[entry.Data.Type] {
+		return false
+	}
+	if !f.filterKeys {
+		return true
+	}
+
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		return f.matchAccount(entry.Data.Account.AccountId)
+	case xdr.LedgerEntryTypeTrustline:
+		trustLine := entry.Data.TrustLine
+		return f.matchAccount(trustLine.AccountId) ||
+			(trustLine.Asset.Type != xdr.AssetTypeAssetTypePoolShare && f.matchAsset(trustLine.Asset.ToAsset()))
+	case xdr.LedgerEntryTypeOffer:
+		offer := entry.Data.Offer
+		return f.matchAccount(offer.SellerId) || f.matchAsset(offer.Selling) || f.matchAsset(offer.Buying)
+	case xdr.LedgerEntryTypeData:
+		return f.matchAccount(entry.Data.Data.AccountId)
+	case xdr.LedgerEntryTypeClaimableBalance:
+		claimableBalance := entry.Data.ClaimableBalance
+		if f.matchAsset(claimableBalance.Asset) {
+			return true
+		}
+		for _, claimant := range claimableBalance.Claimants {
+			if v0, ok := claimant.GetV0(); ok && f.matchAccount(v0.Destination) {
+				return true
+			}
+		}
+		return false
+	case xdr.LedgerEntryTypeLiquidityPool:
+		params, ok := entry.Data.LiquidityPool.Body.GetConstantProduct()
+		return ok && (f.matchAsset(params.Params.AssetA) || f.matchAsset(params.Params.AssetB))
+	case xdr.LedgerEntryTypeContractData:
+		return f.matchContract(entry.Data.ContractData.Contract)
+	case xdr.LedgerEntryTypeTtl:
+		return f.matchTTL(entry.Data.Ttl.KeyHash, footprint)
+	default:
+		return false
+	}
+}
+
+// mayMatchAccounts returns false if no account entry is matched by the
+// filter. A nil filter matches every entry.
+func (f *changeFilter) mayMatchAccounts() bool {
+	if f == nil {
+		return true
+	}
+	if f.filterType && !f.entryTypes[xdr.LedgerEntryTypeAccount] {
+		return false
+	}
+	return !f.filterKeys || len(f.accounts) > 0
+}
+
+// searchesXDR returns true if the changes matched can be searched in the raw
+// XDR of transaction metas, see mayMatchXDR.
+func (f *changeFilter) searchesXDR() bool {
+	return f.needles != nil
+}
+
+// mayMatchXDR returns false if the raw XDR of the changes doesn't contain the
+// key of any entry matched by the filter, in which case none of the changes
+// is matched, TTL entries aside.
+func (f *changeFilter) mayMatchXDR(raw []byte) bool {
+	if f.needles == nil {
+		return true
+	}
+	for _, needle := range f.needles {
+		if bytes.Contains(raw, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayMatchFootprints returns false if the raw XDR of the transactions doesn't
+// contain the id of any contract matched by the filter, in which case no TTL
+// entry changed by the transactions is matched.
+func (f *changeFilter) mayMatchFootprints(raw []byte) bool {
+	if f.filterType && !f.entryTypes[xdr.LedgerEntryTypeTtl] {
+		return false
+	}
+	for contractID := range f.contracts {
+		if bytes.Contains(raw, contractID[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFootprint returns true if the footprint contains contract data matched
+// by the filter, whose TTL entries may be matched.
+func (f *changeFilter) matchFootprint(footprint footprintFunc) bool {
+	fp, ok := footprint()
+	if !ok {
+		return false
+	}
+	for _, keys := range [][]xdr.LedgerKey{fp.ReadOnly, fp.ReadWrite} {
+		for _, key := range keys {
+			if key.Type == xdr.LedgerEntryTypeContractData && f.matchContract(key.ContractData.Contract) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *changeFilter) matchAccount(accountID xdr.AccountId) bool {
+	key, ok := accountID.GetEd25519()
+	return ok && f.accounts[key]
+}
+
+func (f *changeFilter) matchAsset(asset xdr.Asset) bool {
+	for _, other := range f.assets {
+		if asset.Equals(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *changeFilter) matchContract(address xdr.ScAddress) bool {
+	contractID, ok := address.GetContractId()
+	return ok && f.contracts[contractID]
+}
+
+// matchTTL returns true if the TTL entry belongs to contract data matched by
+// the filter. TTL entries only hold the hash of the key of their entry, which
+// is found in the footprint of the transaction changing them.
+func (f *changeFilter) matchTTL(keyHash xdr.Hash, footprint footprintFunc) bool {
+	if len(f.contracts) == 0 {
+		return false
+	}
+	fp, ok := footprint()
+	if !ok {
+		return false
+	}
+	for _, keys := range [][]xdr.LedgerKey{fp.ReadOnly, fp.ReadWrite} {
+		for _, key := range keys {
+			if key.Type != xdr.LedgerEntryTypeContractData || !f.matchContract(key.ContractData.Contract) {
+				continue
+			}
+			raw, err := key.MarshalBinary()
+			if err == nil && sha256.Sum256(raw) == keyHash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny returns true if the change of any of the entries is matched by the
+// filter.
+func (f *changeFilter) matchAny(changes xdr.LedgerEntryChanges, footprint footprintFunc) bool {
+	for i := range changes {
+		if entry := ledgerEntryChangeEntry(&changes[i]); entry != nil && f.match(entry, footprint) {
+			return true
+		}
+	}
+	return false
+}
+
+// ledgerEntryChangeEntry returns the entry of the change.
+func ledgerEntryChangeEntry(change *xdr.LedgerEntryChange) *xdr.LedgerEntry {
+	switch change.Type {
+	case xdr.LedgerEntryChangeTypeLedgerEntryCreated:
+		return change.Created
+	case xdr.LedgerEntryChangeTypeLedgerEntryUpdated:
+		return change.Updated
+	case xdr.LedgerEntryChangeTypeLedgerEntryState:
+		return change.State
+	case xdr.LedgerEntryChangeTypeLedgerEntryRestored:
+		return change.Restored
+	default:
+		// removed changes are preceded by the state of the entry
+		return nil
+	}
+}
+
+// transactionMetaChanges returns all the groups of ledger entry changes of the
+// transaction meta.
+func transactionMetaChanges(meta xdr.TransactionMeta) []xdr.LedgerEntryChanges {
+	var groups []xdr.LedgerEntryChanges
+	switch meta.V {
+	case 0:
+		for _, op := range *meta.Operations {
+			groups = append(groups, op.Changes)
+		}
+	case 1:
+		groups = append(groups, meta.V1.TxChanges)
+		for _, op := range meta.V1.Operations {
+			groups = append(groups, op.Changes)
+		}
+	case 2:
+		groups = append(groups, meta.V2.TxChangesBefore, meta.V2.TxChangesAfter)
+		for _, op := range meta.V2.Operations {
+			groups = append(groups, op.Changes)
+		}
+	case 3:
+		groups = append(groups, meta.V3.TxChangesBefore, meta.V3.TxChangesAfter)
+		for _, op := range meta.V3.Operations {
+			groups = append(groups, op.Changes)
+		}
+	case 4:
+		groups = append(groups, meta.V4.TxChangesBefore, meta.V4.TxChangesAfter)
+		for _, op := range meta.V4.Operations {
+			groups = append(groups, op.Changes)
+		}
+	}
+	return groups
+}
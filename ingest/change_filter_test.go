@@ -0,0 +1,354 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// filterTestChange is the part of a Change compared by the filter tests.
+type filterTestChange struct {
+	Type           xdr.LedgerEntryType
+	ChangeType     xdr.LedgerEntryChangeType
+	Pre, Post      *xdr.LedgerEntry
+	Reason         LedgerEntryChangeReason
+	OperationIndex uint32
+	Transaction    xdr.Hash
+}
+
+func readFilterTestChanges(t *testing.T, reader *LedgerChangeReader) []filterTestChange {
+	var changes []filterTestChange
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tested := filterTestChange{
+			Type:           change.Type,
+			ChangeType:     change.ChangeType,
+			Pre:            change.Pre,
+			Post:           change.Post,
+			Reason:         change.Reason,
+			OperationIndex: change.OperationIndex,
+		}
+		if change.Transaction != nil {
+			tested.Transaction = change.Transaction.Hash
+		}
+		changes = append(changes, tested)
+	}
+	require.NoError(t, reader.Close())
+	return changes
+}
+
+func filterTestEntry(change filterTestChange) xdr.LedgerEntry {
+	if change.Post != nil {
+		return *change.Post
+	}
+	return *change.Pre
+}
+
+func TestFilteredLedgerChangeReader(t *testing.T) {
+	ctx := context.Background()
+	backend, err := ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		Seed:                11,
+		Accounts:            8,
+		Assets:              3,
+		Payments:            2,
+		Offers:              2,
+		ContractInvocations: 1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, ledgerbackend.BoundedRange(2, 6)))
+
+	var ledgers []xdr.LedgerCloseMeta
+	var all []filterTestChange
+	for sequence := uint32(2); sequence <= 6; sequence++ {
+		lcm, err := backend.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+		ledgers = append(ledgers, lcm)
+		reader, err := NewLedgerChangeReaderFromLedgerCloseMeta(network.TestNetworkPassphrase, lcm)
+		require.NoError(t, err)
+		all = append(all, readFilterTestChanges(t, reader)...)
+	}
+
+	var account string
+	var asset xdr.Asset
+	for _, change := range all {
+		if change.Type == xdr.LedgerEntryTypeTrustline {
+			trustLine := filterTestEntry(change).Data.MustTrustLine()
+			account = trustLine.AccountId.Address()
+			asset = trustLine.Asset.ToAsset()
+			break
+		}
+	}
+	require.NotEmpty(t, account)
+
+	for _, testCase := range []struct {
+		name   string
+		filter ChangeFilter
+		match  func(entry xdr.LedgerEntry) bool
+	}{
+		{
+			name:   "no filter",
+			filter: ChangeFilter{},
+			match:  func(entry xdr.LedgerEntry) bool { return true },
+		},
+		{
+			name:   "entry types",
+			filter: ChangeFilter{EntryTypes: []xdr.LedgerEntryType{xdr.LedgerEntryTypeTrustline, xdr.LedgerEntryTypeOffer}},
+			match: func(entry xdr.LedgerEntry) bool {
+				return entry.Data.Type == xdr.LedgerEntryTypeTrustline || entry.Data.Type == xdr.LedgerEntryTypeOffer
+			},
+		},
+		{
+			name:   "account",
+			filter: ChangeFilter{Accounts: []string{account}},
+			match: func(entry xdr.LedgerEntry) bool {
+				switch entry.Data.Type {
+				case xdr.LedgerEntryTypeAccount:
+					return entry.Data.MustAccount().AccountId.Address() == account
+				case xdr.LedgerEntryTypeTrustline:
+					return entry.Data.MustTrustLine().AccountId.Address() == account
+				case xdr.LedgerEntryTypeOffer:
+					return entry.Data.MustOffer().SellerId.Address() == account
+				}
+				return false
+			},
+		},
+		{
+			name: "asset trust lines",
+			filter: ChangeFilter{
+				EntryTypes: []xdr.LedgerEntryType{xdr.LedgerEntryTypeTrustline},
+				Assets:     []xdr.Asset{asset},
+			},
+			match: func(entry xdr.LedgerEntry) bool {
+				return entry.Data.Type == xdr.LedgerEntryTypeTrustline &&
+					entry.Data.MustTrustLine().Asset.ToAsset().Equals(asset)
+			},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var expected []filterTestChange
+			for _, change := range all {
+				if testCase.match(filterTestEntry(change)) {
+					expected = append(expected, change)
+				}
+			}
+			require.NotEmpty(t, expected)
+
+			var changes, rawChanges []filterTestChange
+			for _, lcm := range ledgers {
+				reader, err := NewFilteredLedgerChangeReaderFromLedgerCloseMeta(network.TestNetworkPassphrase, lcm, testCase.filter)
+				require.NoError(t, err)
+				changes = append(changes, readFilterTestChanges(t, reader)...)
+
+				raw, err := lcm.MarshalBinary()
+				require.NoError(t, err)
+				reader, err = NewFilteredLedgerChangeReaderFromRawLedgerCloseMeta(network.TestNetworkPassphrase, raw, testCase.filter)
+				require.NoError(t, err)
+				rawChanges = append(rawChanges, readFilterTestChanges(t, reader)...)
+			}
+			require.Equal(t, expected, changes)
+			require.Equal(t, expected, rawChanges)
+		})
+	}
+
+	// the transaction reader skips the transactions without matching changes
+	reader, err := NewFilteredLedgerTransactionReaderFromLedgerCloseMeta(network.TestNetworkPassphrase, ledgers[4], ChangeFilter{
+		Accounts: []string{account},
+	})
+	require.NoError(t, err)
+	read := 0
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		read++
+		feeChanges := tx.GetFeeChanges()
+		changes, err := tx.GetChanges()
+		require.NoError(t, err)
+		changes = append(changes, feeChanges...)
+		require.NotEmpty(t, changes)
+		for _, change := range changes {
+			entry := change.Post
+			if entry == nil {
+				entry = change.Pre
+			}
+			require.Contains(t, []xdr.LedgerEntryType{
+				xdr.LedgerEntryTypeAccount,
+				xdr.LedgerEntryTypeTrustline,
+				xdr.LedgerEntryTypeOffer,
+			}, entry.Data.Type)
+		}
+	}
+	require.Greater(t, read, 0)
+	require.Less(t, read, ledgers[4].CountTransactions())
+	require.Less(t, reader.hashedEnvelopes, ledgers[4].CountTransactions())
+
+	// the raw reader doesn't decode the metas of the transactions skipped
+	raw, err := ledgers[4].MarshalBinary()
+	require.NoError(t, err)
+	reader, err = NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta(network.TestNetworkPassphrase, raw, ChangeFilter{
+		Accounts: []string{account},
+	})
+	require.NoError(t, err)
+	rawRead := 0
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		rawRead++
+		require.Equal(t, ledgers[4].TxApplyProcessing(int(tx.Index-1)), tx.UnsafeMeta)
+		require.Equal(t, ledgers[4].FeeProcessing(int(tx.Index-1)), tx.FeeChanges)
+	}
+	require.Equal(t, read, rawRead)
+	undecoded := 0
+	for _, meta := range reader.undecodedMetas {
+		if meta != nil {
+			undecoded++
+		}
+	}
+	require.Equal(t, ledgers[4].CountTransactions()-read, undecoded)
+
+	_, err = NewFilteredLedgerChangeReaderFromLedgerCloseMeta(network.TestNetworkPassphrase, ledgers[0], ChangeFilter{
+		Accounts: []string{"GABC"},
+	})
+	require.ErrorContains(t, err, "invalid change filter: invalid account GABC")
+}
+
+func TestChangeFilterMatchesTTLOfContractData(t *testing.T) {
+	var contractID, otherContractID xdr.ContractId
+	contractID[0], otherContractID[0] = 1, 2
+	keyHash := func(contractID xdr.ContractId) (xdr.LedgerKey, xdr.Hash) {
+		key := xdr.LedgerKey{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.LedgerKeyContractData{
+				Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+				Durability: xdr.ContractDataDurabilityPersistent,
+			},
+		}
+		raw, err := key.MarshalBinary()
+		require.NoError(t, err)
+		return key, sha256.Sum256(raw)
+	}
+	key, hash := keyHash(contractID)
+	otherKey, otherHash := keyHash(otherContractID)
+	ttl := func(hash xdr.Hash) *xdr.LedgerEntry {
+		return &xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl:  &xdr.TtlEntry{KeyHash: hash, LiveUntilLedgerSeq: 100},
+		}}
+	}
+
+	filter, err := ChangeFilter{
+		Contracts: []string{strkey.MustEncode(strkey.VersionByteContract, contractID[:])},
+	}.compile(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	footprint := func() (xdr.LedgerFootprint, bool) {
+		return xdr.LedgerFootprint{ReadWrite: []xdr.LedgerKey{otherKey, key}}, true
+	}
+	require.True(t, filter.match(ttl(hash), footprint))
+	require.False(t, filter.match(ttl(otherHash), footprint))
+	require.False(t, filter.match(ttl(hash), noFootprint))
+}
+
+func TestFilteredRawLedgerMatchesTTLOfContractData(t *testing.T) {
+	var contractID xdr.ContractId
+	contractID[0] = 1
+	key := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Durability: xdr.ContractDataDurabilityPersistent,
+		},
+	}
+	rawKey, err := key.MarshalBinary()
+	require.NoError(t, err)
+	ttl := func(liveUntil xdr.Uint32) *xdr.LedgerEntry {
+		return &xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl:  &xdr.TtlEntry{KeyHash: sha256.Sum256(rawKey), LiveUntilLedgerSeq: liveUntil},
+		}}
+	}
+
+	// the first transaction extends the TTL of the contract instance, the
+	// meta of the second one doesn't change any entry
+	var envelopes []xdr.TransactionEnvelope
+	var metas []xdr.TransactionResultMeta
+	for i, footprint := range []xdr.LedgerFootprint{{ReadOnly: []xdr.LedgerKey{key}}, {}} {
+		envelope := xdr.TransactionEnvelope{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+			V1: &xdr.TransactionV1Envelope{Tx: xdr.Transaction{
+				SourceAccount: xdr.MustMuxedAddress(keypair.MustRandom().Address()),
+				SeqNum:        xdr.SequenceNumber(i + 1),
+				Ext: xdr.TransactionExt{V: 1, SorobanData: &xdr.SorobanTransactionData{
+					Resources: xdr.SorobanResources{Footprint: footprint},
+				}},
+			}},
+		}
+		hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		meta := xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{}}
+		if i == 0 {
+			meta.V3.TxChangesAfter = xdr.LedgerEntryChanges{
+				{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: ttl(100)},
+				{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: ttl(200)},
+			}
+		}
+		envelopes = append(envelopes, envelope)
+		metas = append(metas, xdr.TransactionResultMeta{
+			Result: xdr.TransactionResultPair{
+				TransactionHash: hash,
+				Result: xdr.TransactionResult{Result: xdr.TransactionResultResult{
+					Code:    xdr.TransactionResultCodeTxSuccess,
+					Results: &[]xdr.OperationResult{},
+				}},
+			},
+			TxApplyProcessing: meta,
+		})
+	}
+	lcm := xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{
+		TxSet: xdr.GeneralizedTransactionSet{V: 1, V1TxSet: &xdr.TransactionSetV1{
+			Phases: []xdr.TransactionPhase{{V: 0, V0Components: &[]xdr.TxSetComponent{{
+				TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{Txs: envelopes},
+			}}}},
+		}},
+		TxProcessing: metas,
+	}}
+	raw, err := lcm.MarshalBinary()
+	require.NoError(t, err)
+
+	reader, err := NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta(network.TestNetworkPassphrase, raw, ChangeFilter{
+		Contracts: []string{strkey.MustEncode(strkey.VersionByteContract, contractID[:])},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, reader.undecodedMetas[0])
+	tx, err := reader.Read()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), tx.Index)
+	changes, err := tx.GetChanges()
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, ttl(200), changes[0].Post)
+	_, err = reader.Read()
+	require.ErrorIs(t, err, io.EOF)
+	require.Nil(t, reader.undecodedMetas[0])
+	require.NotNil(t, reader.undecodedMetas[1])
+}
@@ -40,6 +40,10 @@ type CheckpointChangeReader struct {
 
 	encodingBuffer *xdr.EncodingBuffer
 
+	// bucketDownloader is set when buckets are read from a verified local
+	// cache, see NewCheckpointChangeReaderWithVerifiedBuckets
+	bucketDownloader *bucketDownloader
+
 	// This should be set to true in tests only
 	disableBucketListHashValidation bool
 	sleep                           func(time.Duration)
@@ -99,6 +103,32 @@ func NewCheckpointChangeReader(
 	}, nil
 }
 
+// NewCheckpointChangeReaderWithVerifiedBuckets constructs a new
+// CheckpointChangeReader instance which downloads the buckets of the
+// checkpoint in parallel to a local cache before reading them.
+//
+// The hash of every bucket is verified against the history archive state
+// while it is downloaded. When archive is a *historyarchive.ArchivePool, a
+// bucket which fails to download or doesn't match its hash is downloaded
+// again from another archive of the pool. Verified buckets are kept in
+// config.CachePath and reused by later readers.
+func NewCheckpointChangeReaderWithVerifiedBuckets(
+	ctx context.Context,
+	archive historyarchive.ArchiveInterface,
+	sequence uint32,
+	config VerifiedBucketsConfig,
+) (*CheckpointChangeReader, error) {
+	reader, err := NewCheckpointChangeReader(ctx, archive, sequence)
+	if err != nil {
+		return nil, err
+	}
+	reader.bucketDownloader, err = newBucketDownloader(ctx, archive, config)
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
 // VerifyBucketList verifies that the bucket list hash computed from the history archive snapshot
 // associated with the CheckpointChangeReader matches the expectedHash.
 // Assuming expectedHash comes from a trusted source (captive-core running in unbounded mode), this
@@ -123,9 +153,23 @@ func (r *CheckpointChangeReader) VerifyBucketList(expectedHash xdr.Hash) error {
 }
 
 func (r *CheckpointChangeReader) bucketExists(hash historyarchive.Hash) (bool, error) {
+	if r.bucketDownloader != nil {
+		if _, ok := r.bucketDownloader.cachedSize(hash); ok {
+			return true, nil
+		}
+	}
 	return r.archive.BucketExists(hash)
 }
 
+func (r *CheckpointChangeReader) bucketSize(hash historyarchive.Hash) (int64, error) {
+	if r.bucketDownloader != nil {
+		if size, ok := r.bucketDownloader.cachedSize(hash); ok {
+			return size, nil
+		}
+	}
+	return r.archive.BucketSize(hash)
+}
+
 // streamBuckets is internal method that streams buckets from the given HAS.
 //
 // Buckets should be processed from oldest to newest, `snap` and then `curr` at
@@ -191,7 +235,7 @@ func (r *CheckpointChangeReader) streamBuckets() {
 			return
 		}
 
-		size, err := r.archive.BucketSize(hash)
+		size, err := r.bucketSize(hash)
 		if err != nil {
 			r.readChan <- r.error(
 				errors.Wrapf(err, "error checking bucket size: %s", hash),
@@ -204,6 +248,11 @@ func (r *CheckpointChangeReader) streamBuckets() {
 		r.readBytesMutex.Unlock()
 	}
 
+	if r.bucketDownloader != nil {
+		r.bucketDownloader.start(buckets)
+		defer r.bucketDownloader.stop()
+	}
+
 	for i, hash := range buckets {
 		oldestBucket := i == len(buckets)-1
 		if shouldContinue := r.streamBucketContents(hash, oldestBucket); !shouldContinue {
@@ -268,7 +317,13 @@ func (r *CheckpointChangeReader) newXDRStream(hash historyarchive.Hash) (
 	*xdr.Stream,
 	error,
 ) {
-	rdr, e := r.archive.GetXdrStreamForHash(hash)
+	var rdr *xdr.Stream
+	var e error
+	if r.bucketDownloader != nil {
+		rdr, e = r.bucketDownloader.open(hash)
+	} else {
+		rdr, e = r.archive.GetXdrStreamForHash(hash)
+	}
 	if e == nil && !r.disableBucketListHashValidation {
 		// Calling SetExpectedHash will enable validation of the stream hash. If hashes
 		// don't match, rdr.Close() will return an error.
@@ -0,0 +1,176 @@
+package ingest
+
+import (
+	"reflect"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// lazyLedgerCloseMeta is a ledger decoded from its raw XDR, in which the
+// metas of the transactions which can't be matched by a filter are skipped.
+type lazyLedgerCloseMeta struct {
+	lcm xdr.LedgerCloseMeta
+	// undecodedMetas are the raw XDR of the TransactionResultMeta (or
+	// TransactionResultMetaV1) of the transactions which were not decoded, of
+	// which only the result is decoded. It is nil for the decoded ones.
+	undecodedMetas [][]byte
+	// footprintsMayMatch is false if no footprint of the transaction set
+	// contains contract data matched by the filter, in which case the TTL
+	// entries changed by the undecoded metas aren't matched either.
+	footprintsMayMatch bool
+}
+
+// decodeLazyLedgerCloseMeta decodes the raw XDR of a LedgerCloseMeta,
+// skipping the transaction metas which don't contain the key of any entry
+// matched by the filter. Skipping a meta only requires finding its size,
+// which is much cheaper than decoding its changes.
+func decodeLazyLedgerCloseMeta(raw []byte, filter *changeFilter) (lazyLedgerCloseMeta, error) {
+	var ledger lazyLedgerCloseMeta
+	if !filter.searchesXDR() {
+		err := ledger.lcm.UnmarshalBinary(raw)
+		return ledger, err
+	}
+
+	version, err := readXDRUint32(raw)
+	if err != nil {
+		return ledger, err
+	}
+	ledger.lcm.V = int32(version)
+	var body reflect.Value
+	switch ledger.lcm.V {
+	case 0:
+		ledger.lcm.V0 = &xdr.LedgerCloseMetaV0{}
+		body = reflect.ValueOf(ledger.lcm.V0).Elem()
+	case 1:
+		ledger.lcm.V1 = &xdr.LedgerCloseMetaV1{}
+		body = reflect.ValueOf(ledger.lcm.V1).Elem()
+	case 2:
+		ledger.lcm.V2 = &xdr.LedgerCloseMetaV2{}
+		body = reflect.ValueOf(ledger.lcm.V2).Elem()
+	default:
+		return ledger, errors.Errorf("unknown LedgerCloseMeta version %d", ledger.lcm.V)
+	}
+
+	decoder := xdr.NewBytesDecoder()
+	offset := 4
+	for i := 0; i < body.NumField(); i++ {
+		field := body.Field(i)
+		var n int
+		if body.Type().Field(i).Name == "TxProcessing" {
+			ledger.footprintsMayMatch = filter.mayMatchFootprints(raw[:offset])
+			n, ledger.undecodedMetas, err = decodeLazyTxProcessing(decoder, raw[offset:], field, filter)
+		} else {
+			n, err = decodeLazyXDR(decoder, raw[offset:], field)
+		}
+		offset += n
+		if err != nil {
+			return ledger, errors.Wrapf(err, "error decoding %s", body.Type().Field(i).Name)
+		}
+	}
+	if offset != len(raw) {
+		return ledger, errors.Errorf("input not fully consumed. expected to read: %d, actual: %d", len(raw), offset)
+	}
+	return ledger, nil
+}
+
+// decodeLazyTxProcessing decodes the transaction metas of a ledger, skipping
+// the ones which can't be matched by the filter. Only the leading fields of
+// the skipped metas are decoded, up to their result which holds the hash of
+// their transaction.
+func decodeLazyTxProcessing(
+	decoder *xdr.BytesDecoder,
+	data []byte,
+	field reflect.Value,
+	filter *changeFilter,
+) (int, [][]byte, error) {
+	count, err := readXDRUint32(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if uint64(count)*4 > uint64(len(data)-4) {
+		return 0, nil, errXDRTruncated
+	}
+
+	metas := reflect.MakeSlice(field.Type(), int(count), int(count))
+	skip := xdrSkipperOf(field.Type().Elem())
+	var undecoded [][]byte
+	offset := 4
+	for i := 0; i < int(count); i++ {
+		size, err := skip(data[offset:])
+		if err != nil {
+			return offset, nil, errors.Wrapf(err, "error skipping meta %d", i)
+		}
+		raw := data[offset : offset+size]
+		meta := metas.Index(i)
+		if filter.mayMatchXDR(raw) {
+			var n int
+			if n, err = decodeLazyXDR(decoder, raw, meta); err == nil && n != size {
+				err = errors.Errorf("decoded %d bytes out of %d", n, size)
+			}
+		} else {
+			if undecoded == nil {
+				undecoded = make([][]byte, count)
+			}
+			undecoded[i] = raw
+			err = decodeLazyResult(decoder, raw, meta)
+		}
+		if err != nil {
+			return offset, nil, errors.Wrapf(err, "error decoding meta %d", i)
+		}
+		offset += size
+	}
+	if count > 0 {
+		field.Set(metas)
+	}
+	return offset, undecoded, nil
+}
+
+// decodeLazyResult decodes the fields of the transaction meta up to its
+// result.
+func decodeLazyResult(decoder *xdr.BytesDecoder, data []byte, meta reflect.Value) error {
+	offset := 0
+	for i := 0; i < meta.NumField(); i++ {
+		n, err := decodeLazyXDR(decoder, data[offset:], meta.Field(i))
+		if err != nil {
+			return err
+		}
+		if meta.Type().Field(i).Name == "Result" {
+			return nil
+		}
+		offset += n
+	}
+	return errors.Errorf("%s has no result", meta.Type())
+}
+
+// decodeLazyXDR decodes the value at the start of the data, which is either
+// a type generated by xdrgen or an array of them.
+func decodeLazyXDR(decoder *xdr.BytesDecoder, data []byte, value reflect.Value) (int, error) {
+	if decodable, ok := value.Addr().Interface().(xdr.DecoderFrom); ok {
+		return decoder.DecodeBytes(decodable, data)
+	}
+	if value.Kind() != reflect.Slice {
+		return 0, errors.Errorf("unsupported type %s", value.Type())
+	}
+
+	count, err := readXDRUint32(data)
+	if err != nil {
+		return 0, err
+	}
+	if uint64(count)*4 > uint64(len(data)-4) {
+		return 0, errXDRTruncated
+	}
+	elements := reflect.MakeSlice(value.Type(), int(count), int(count))
+	offset := 4
+	for i := 0; i < int(count); i++ {
+		n, err := decodeLazyXDR(decoder, data[offset:], elements.Index(i))
+		offset += n
+		if err != nil {
+			return offset, err
+		}
+	}
+	if count > 0 {
+		value.Set(elements)
+	}
+	return offset, nil
+}
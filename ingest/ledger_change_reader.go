@@ -76,6 +76,75 @@ func NewLedgerChangeReaderFromLedgerCloseMeta(networkPassphrase string, ledger x
 	}, nil
 }
 
+// NewFilteredLedgerChangeReader constructs a new LedgerChangeReader instance
+// bound to the given ledger, which only returns the changes matched by the
+// filter. The changes are filtered before they are converted to Changes and
+// the transactions without any change matched are skipped, see
+// NewFilteredLedgerTransactionReader.
+// Note that the returned LedgerChangeReader is not thread safe and should not be shared
+// by multiple goroutines.
+func NewFilteredLedgerChangeReader(
+	ctx context.Context,
+	backend ledgerbackend.LedgerBackend,
+	networkPassphrase string,
+	sequence uint32,
+	filter ChangeFilter,
+) (*LedgerChangeReader, error) {
+	transactionReader, err := NewFilteredLedgerTransactionReader(ctx, backend, networkPassphrase, sequence, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerChangeReader{
+		LedgerTransactionReader: transactionReader,
+		state:                   feeChangesState,
+	}, nil
+}
+
+// NewFilteredLedgerChangeReaderFromLedgerCloseMeta constructs a new filtered
+// LedgerChangeReader instance bound to the given ledger, see
+// NewFilteredLedgerChangeReader.
+// Note that the returned LedgerChangeReader is not thread safe and should not be shared
+// by multiple goroutines.
+func NewFilteredLedgerChangeReaderFromLedgerCloseMeta(
+	networkPassphrase string,
+	ledger xdr.LedgerCloseMeta,
+	filter ChangeFilter,
+) (*LedgerChangeReader, error) {
+	transactionReader, err := NewFilteredLedgerTransactionReaderFromLedgerCloseMeta(networkPassphrase, ledger, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerChangeReader{
+		LedgerTransactionReader: transactionReader,
+		state:                   feeChangesState,
+	}, nil
+}
+
+// NewFilteredLedgerChangeReaderFromRawLedgerCloseMeta constructs a new filtered
+// LedgerChangeReader instance bound to the raw XDR of the given ledger, which
+// is decoded lazily, see NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta.
+// Note that the returned LedgerChangeReader is not thread safe and should not be shared
+// by multiple goroutines.
+func NewFilteredLedgerChangeReaderFromRawLedgerCloseMeta(
+	networkPassphrase string,
+	rawLedgerCloseMeta []byte,
+	filter ChangeFilter,
+) (*LedgerChangeReader, error) {
+	transactionReader, err := NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta(
+		networkPassphrase, rawLedgerCloseMeta, filter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LedgerChangeReader{
+		LedgerTransactionReader: transactionReader,
+		state:                   feeChangesState,
+	}, nil
+}
+
 type compactingChangeReader struct {
 	input     ChangeReader
 	changes   []Change
@@ -154,6 +223,13 @@ func (r *LedgerChangeReader) Read() (Change, error) {
 
 	switch r.state {
 	case feeChangesState, metaChangesState, postTxApplyState:
+		// fee changes only change accounts, they are skipped altogether when
+		// the filter can't match them
+		if r.state != metaChangesState && !r.filter.mayMatchAccounts() {
+			r.state++
+			return r.Read()
+		}
+
 		tx, err := r.LedgerTransactionReader.Read()
 		if err != nil {
 			if err == io.EOF {
@@ -186,8 +262,10 @@ func (r *LedgerChangeReader) Read() (Change, error) {
 	case upgradeChangesState:
 		// Get upgrade changes
 		if r.upgradeIndex < len(r.LedgerTransactionReader.lcm.UpgradesProcessing()) {
-			changes := GetChangesFromLedgerEntryChanges(
+			changes := getFilteredChangesFromLedgerEntryChanges(
 				r.LedgerTransactionReader.lcm.UpgradesProcessing()[r.upgradeIndex].Changes,
+				r.filter,
+				noFootprint,
 			)
 			ledgerUpgrades := r.LedgerTransactionReader.lcm.UpgradesProcessing()
 			for i := range changes {
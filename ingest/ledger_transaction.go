@@ -29,6 +29,10 @@ type LedgerTransaction struct {
 	LedgerVersion uint32
 	Ledger        xdr.LedgerCloseMeta // This is read-only and not to be modified by downstream functions
 	Hash          xdr.Hash
+
+	// filter is set when the transaction is read by a filtered reader, the
+	// changes of the transaction are restricted to the changes it matches
+	filter *changeFilter
 }
 
 type TransactionEvents struct {
@@ -48,7 +52,7 @@ func (t *LedgerTransaction) FeeAccount() xdr.MuxedAccount {
 // GetFeeChanges returns a developer friendly representation of LedgerEntryChanges
 // connected to fees.
 func (t *LedgerTransaction) GetFeeChanges() []Change {
-	changes := GetChangesFromLedgerEntryChanges(t.FeeChanges)
+	changes := t.changesFromLedgerEntryChanges(t.FeeChanges)
 	for i := range changes {
 		changes[i].Reason = LedgerEntryChangeReasonFee
 		changes[i].Transaction = t
@@ -60,7 +64,7 @@ func (t *LedgerTransaction) GetFeeChanges() []Change {
 // GetPostApplyFeeChanges returns a developer friendly representation of LedgerEntryChanges
 // connected to fee refunds which are applied after all transactions are executed.
 func (t *LedgerTransaction) GetPostApplyFeeChanges() []Change {
-	changes := GetChangesFromLedgerEntryChanges(t.PostTxApplyFeeChanges)
+	changes := t.changesFromLedgerEntryChanges(t.PostTxApplyFeeChanges)
 	for i := range changes {
 		changes[i].Reason = LedgerEntryChangeReasonFeeRefund
 		changes[i].Transaction = t
@@ -69,8 +73,22 @@ func (t *LedgerTransaction) GetPostApplyFeeChanges() []Change {
 	return changes
 }
 
+// changesFromLedgerEntryChanges returns the changes matched by the filter of
+// the transaction, if any.
+func (t *LedgerTransaction) changesFromLedgerEntryChanges(ledgerEntryChanges xdr.LedgerEntryChanges) []Change {
+	if t.filter == nil {
+		return GetChangesFromLedgerEntryChanges(ledgerEntryChanges)
+	}
+	return getFilteredChangesFromLedgerEntryChanges(ledgerEntryChanges, t.filter, t.footprint)
+}
+
+func (t *LedgerTransaction) footprint() (xdr.LedgerFootprint, bool) {
+	sorobanData, ok := t.GetSorobanData()
+	return sorobanData.Resources.Footprint, ok
+}
+
 func (t *LedgerTransaction) getTransactionChanges(ledgerEntryChanges xdr.LedgerEntryChanges) []Change {
-	changes := GetChangesFromLedgerEntryChanges(ledgerEntryChanges)
+	changes := t.changesFromLedgerEntryChanges(ledgerEntryChanges)
 	for i := range changes {
 		changes[i].Reason = LedgerEntryChangeReasonTransaction
 		changes[i].Transaction = t
@@ -231,7 +249,7 @@ func (t *LedgerTransaction) operationChanges(ops operationsMeta, index uint32) [
 		return []Change{}
 	}
 
-	changes := GetChangesFromLedgerEntryChanges(ops.getChanges(index))
+	changes := t.changesFromLedgerEntryChanges(ops.getChanges(index))
 
 	for i := range changes {
 		changes[i].Reason = LedgerEntryChangeReasonOperation
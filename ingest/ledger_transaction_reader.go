@@ -23,6 +23,20 @@ type LedgerTransactionReader struct {
 	envelopesByHash map[xdr.Hash]xdr.TransactionEnvelope // set once
 
 	readIdx int // tracks iteration & seeking
+
+	// filter is set by the filtered constructors, in which case the envelopes
+	// are hashed lazily, only until the envelope of a transaction read is
+	// found
+	filter            *changeFilter
+	networkPassphrase string
+	envelopes         []xdr.TransactionEnvelope
+	hashedEnvelopes   int
+
+	// undecodedMetas are set by the raw filtered constructors to the raw XDR of
+	// the transaction metas which were not decoded, see lazyLedgerCloseMeta.
+	// The metas are decoded once their transaction may be matched.
+	undecodedMetas     [][]byte
+	footprintsMayMatch bool
 }
 
 // NewLedgerTransactionReader creates a new TransactionReader instance. Note
@@ -48,14 +62,97 @@ func NewLedgerTransactionReader(
 func NewLedgerTransactionReaderFromLedgerCloseMeta(
 	networkPassphrase string,
 	ledgerCloseMeta xdr.LedgerCloseMeta,
+) (*LedgerTransactionReader, error) {
+	return newLedgerTransactionReader(networkPassphrase, ledgerCloseMeta, nil)
+}
+
+// NewFilteredLedgerTransactionReader creates a new TransactionReader instance
+// which only returns the transactions changing ledger entries matched by the
+// filter. The changes returned by the transactions are restricted to the
+// changes matched by the filter.
+//
+// Transactions are matched on their meta, so the envelopes of the transactions
+// which are not returned are not hashed unless they precede, in the
+// transaction set, the envelope of a transaction returned. The ledger is fully
+// decoded by the backend, use
+// NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta to skip decoding
+// the metas of the transactions which are not returned. Note that
+// TransactionReader is not thread safe and should not be shared by multiple
+// goroutines.
+func NewFilteredLedgerTransactionReader(
+	ctx context.Context,
+	backend ledgerbackend.LedgerBackend,
+	networkPassphrase string,
+	sequence uint32,
+	filter ChangeFilter,
+) (*LedgerTransactionReader, error) {
+	ledgerCloseMeta, err := backend.GetLedger(ctx, sequence)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting ledger from the backend")
+	}
+
+	return NewFilteredLedgerTransactionReaderFromLedgerCloseMeta(networkPassphrase, ledgerCloseMeta, filter)
+}
+
+// NewFilteredLedgerTransactionReaderFromLedgerCloseMeta creates a new filtered
+// TransactionReader instance from xdr.LedgerCloseMeta, see
+// NewFilteredLedgerTransactionReader.
+func NewFilteredLedgerTransactionReaderFromLedgerCloseMeta(
+	networkPassphrase string,
+	ledgerCloseMeta xdr.LedgerCloseMeta,
+	filter ChangeFilter,
+) (*LedgerTransactionReader, error) {
+	compiled, err := filter.compile(networkPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid change filter")
+	}
+	return newLedgerTransactionReader(networkPassphrase, ledgerCloseMeta, compiled)
+}
+
+// NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta creates a new
+// filtered TransactionReader instance from the raw XDR of a LedgerCloseMeta,
+// see NewFilteredLedgerTransactionReader. The ledger is decoded lazily: the
+// metas of the transactions which don't contain the key of any account,
+// contract or asset filtered are skipped, and only decoded if the footprint
+// of their transaction holds a contract filtered, whose TTL entries they may
+// change. The metas of the transactions which are not returned may thus be
+// empty in the Ledger of the transactions returned.
+func NewFilteredLedgerTransactionReaderFromRawLedgerCloseMeta(
+	networkPassphrase string,
+	rawLedgerCloseMeta []byte,
+	filter ChangeFilter,
+) (*LedgerTransactionReader, error) {
+	compiled, err := filter.compile(networkPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid change filter")
+	}
+	ledger, err := decodeLazyLedgerCloseMeta(rawLedgerCloseMeta, compiled)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding ledger close meta")
+	}
+	reader, err := newLedgerTransactionReader(networkPassphrase, ledger.lcm, compiled)
+	if err != nil {
+		return nil, err
+	}
+	reader.undecodedMetas = ledger.undecodedMetas
+	reader.footprintsMayMatch = ledger.footprintsMayMatch
+	return reader, nil
+}
+
+func newLedgerTransactionReader(
+	networkPassphrase string,
+	ledgerCloseMeta xdr.LedgerCloseMeta,
+	filter *changeFilter,
 ) (*LedgerTransactionReader, error) {
 	reader := &LedgerTransactionReader{
-		lcm:             ledgerCloseMeta,
-		envelopesByHash: make(map[xdr.Hash]xdr.TransactionEnvelope, ledgerCloseMeta.CountTransactions()),
-		readIdx:         0,
+		lcm:               ledgerCloseMeta,
+		envelopesByHash:   make(map[xdr.Hash]xdr.TransactionEnvelope, ledgerCloseMeta.CountTransactions()),
+		readIdx:           0,
+		filter:            filter,
+		networkPassphrase: networkPassphrase,
 	}
 
-	if err := reader.storeTransactions(networkPassphrase); err != nil {
+	if err := reader.storeTransactions(); err != nil {
 		return nil, errors.Wrap(err, "error extracting transactions from ledger close meta")
 	}
 	return reader, nil
@@ -74,17 +171,29 @@ func (reader *LedgerTransactionReader) GetHeader() xdr.LedgerHeaderHistoryEntry
 // Read returns the next transaction in the ledger, ordered by tx number, each time
 // it is called. When there are no more transactions to return, an EOF error is returned.
 func (reader *LedgerTransactionReader) Read() (LedgerTransaction, error) {
-	if reader.readIdx >= reader.lcm.CountTransactions() {
-		return LedgerTransaction{}, io.EOF
+	for reader.readIdx < reader.lcm.CountTransactions() {
+		i := reader.readIdx
+		reader.readIdx++ // next read will advance even on error
+
+		if reader.filter != nil {
+			matched, err := reader.matches(i)
+			if err != nil {
+				return LedgerTransaction{}, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		return reader.transaction(i)
 	}
-	i := reader.readIdx
-	reader.readIdx++ // next read will advance even on error
+	return LedgerTransaction{}, io.EOF
+}
 
+func (reader *LedgerTransactionReader) transaction(i int) (LedgerTransaction, error) {
 	hash := reader.lcm.TransactionHash(i)
-	envelope, ok := reader.envelopesByHash[hash]
-	if !ok {
-		hexHash := hex.EncodeToString(hash[:])
-		return LedgerTransaction{}, errors.Errorf("unknown tx hash in LedgerCloseMeta: %v", hexHash)
+	envelope, err := reader.envelope(hash)
+	if err != nil {
+		return LedgerTransaction{}, err
 	}
 
 	var postTxApplyFeeChanges xdr.LedgerEntryChanges
@@ -102,9 +211,101 @@ func (reader *LedgerTransactionReader) Read() (LedgerTransaction, error) {
 		LedgerVersion:         uint32(reader.lcm.LedgerHeaderHistoryEntry().Header.LedgerVersion),
 		Ledger:                reader.lcm,
 		Hash:                  hash,
+		filter:                reader.filter,
 	}, nil
 }
 
+// matches returns true if the transaction at the given index changes any
+// ledger entry matched by the filter.
+func (reader *LedgerTransactionReader) matches(i int) (bool, error) {
+	footprint := func() (xdr.LedgerFootprint, bool) {
+		envelope, err := reader.envelope(reader.lcm.TransactionHash(i))
+		if err != nil {
+			// the error is returned when the transaction is read
+			return xdr.LedgerFootprint{}, false
+		}
+		tx := LedgerTransaction{Envelope: envelope}
+		return tx.footprint()
+	}
+
+	if i < len(reader.undecodedMetas) && reader.undecodedMetas[i] != nil {
+		// the meta of the transaction may only change TTL entries matched
+		if !reader.footprintsMayMatch || !reader.filter.matchFootprint(footprint) {
+			return false, nil
+		}
+		if err := reader.decodeMeta(i); err != nil {
+			return false, err
+		}
+	}
+
+	if reader.filter.matchAny(reader.lcm.FeeProcessing(i), footprint) {
+		return true, nil
+	}
+	for _, changes := range transactionMetaChanges(reader.lcm.TxApplyProcessing(i)) {
+		if reader.filter.matchAny(changes, footprint) {
+			return true, nil
+		}
+	}
+	if lcmV2, ok := reader.lcm.GetV2(); ok {
+		return reader.filter.matchAny(lcmV2.TxProcessing[i].PostTxApplyFeeProcessing, footprint), nil
+	}
+	return false, nil
+}
+
+// decodeMeta decodes the undecoded meta of the transaction at the given index.
+func (reader *LedgerTransactionReader) decodeMeta(i int) error {
+	raw := reader.undecodedMetas[i]
+	var err error
+	switch reader.lcm.V {
+	case 0:
+		err = reader.lcm.V0.TxProcessing[i].UnmarshalBinary(raw)
+	case 1:
+		err = reader.lcm.V1.TxProcessing[i].UnmarshalBinary(raw)
+	case 2:
+		err = reader.lcm.V2.TxProcessing[i].UnmarshalBinary(raw)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not decode meta %d", i)
+	}
+	reader.undecodedMetas[i] = nil
+	if reader.lcm.ProtocolVersion() < 10 && reader.lcm.TxApplyProcessing(i).V < 2 &&
+		len(reader.lcm.FeeProcessing(i)) > 0 {
+		return badMetaVersionErr
+	}
+	return nil
+}
+
+// envelope returns the envelope of the transaction with the given hash,
+// hashing the envelopes which are not hashed yet until it is found.
+func (reader *LedgerTransactionReader) envelope(hash xdr.Hash) (xdr.TransactionEnvelope, error) {
+	if envelope, ok := reader.envelopesByHash[hash]; ok {
+		return envelope, nil
+	}
+	for reader.envelopesByHash != nil && reader.hashedEnvelopes < len(reader.envelopes) {
+		envelopeHash, err := reader.hashNextEnvelope()
+		if err != nil {
+			return xdr.TransactionEnvelope{}, err
+		}
+		if envelopeHash == hash {
+			return reader.envelopesByHash[hash], nil
+		}
+	}
+	hexHash := hex.EncodeToString(hash[:])
+	return xdr.TransactionEnvelope{}, errors.Errorf("unknown tx hash in LedgerCloseMeta: %v", hexHash)
+}
+
+func (reader *LedgerTransactionReader) hashNextEnvelope() (xdr.Hash, error) {
+	i := reader.hashedEnvelopes
+	tx := reader.envelopes[i]
+	hash, err := network.HashTransactionInEnvelope(tx, reader.networkPassphrase)
+	if err != nil {
+		return xdr.Hash{}, errors.Wrapf(err, "could not hash transaction %d in TxSet", i)
+	}
+	reader.envelopesByHash[xdr.Hash(hash)] = tx
+	reader.hashedEnvelopes++
+	return xdr.Hash(hash), nil
+}
+
 // Rewind resets the reader back to the first transaction in the ledger
 func (reader *LedgerTransactionReader) Rewind() {
 	reader.Seek(0)
@@ -122,18 +323,19 @@ func (reader *LedgerTransactionReader) Seek(index int) error {
 
 // storeHashes creates a mapping between hashes and envelopes in order to
 // correctly provide a per-transaction view on-the-fly when Read() is called.
-func (reader *LedgerTransactionReader) storeTransactions(networkPassphrase string) error {
+func (reader *LedgerTransactionReader) storeTransactions() error {
 	// See https://github.com/stellar/go/pull/2720: envelopes in the meta (which
 	// just come straight from the agreed-upon transaction set) are not in the
 	// same order as the actual list of metas (which are sorted by hash), so we
 	// need to hash the envelopes *first* to properly associate them with their
-	// metas.
-	for i, tx := range reader.lcm.TransactionEnvelopes() {
-		hash, err := network.HashTransactionInEnvelope(tx, networkPassphrase)
-		if err != nil {
-			return errors.Wrapf(err, "could not hash transaction %d in TxSet", i)
+	// metas. Filtered readers hash them lazily, when they are read.
+	reader.envelopes = reader.lcm.TransactionEnvelopes()
+	for i := range reader.envelopes {
+		if reader.filter == nil {
+			if _, err := reader.hashNextEnvelope(); err != nil {
+				return err
+			}
 		}
-		reader.envelopesByHash[xdr.Hash(hash)] = tx
 
 		// We check the version only if FeeProcessing is non-empty, because some
 		// backends (like HistoryArchiveBackend) do not return meta.
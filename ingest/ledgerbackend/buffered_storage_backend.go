@@ -5,10 +5,12 @@ package ledgerbackend
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/support/datastore"
 	"github.com/stellar/go/xdr"
@@ -22,6 +24,28 @@ type BufferedStorageBackendConfig struct {
 	NumWorkers uint32        `toml:"num_workers"`
 	RetryLimit uint32        `toml:"retry_limit"`
 	RetryWait  time.Duration `toml:"retry_wait"`
+	// MixedCompression enables reading buckets where ledger files were written
+	// with different compressors, e.g. during a migration. When a file is not
+	// found with the schema's file extension, it is looked up with the file
+	// extensions of all other registered compressors.
+	MixedCompression bool `toml:"mixed_compression"`
+	// VerifyChecksums enables verifying the content of each downloaded ledger
+	// file against the SHA-256 recorded in its object metadata. Files without
+	// a recorded checksum are not verified. Mismatching files are retried and
+	// fail with a ChecksumMismatchError once RetryLimit is exceeded.
+	VerifyChecksums bool `toml:"verify_checksums"`
+}
+
+// ChecksumMismatchError is returned when the content of a ledger file does not
+// match the SHA-256 recorded in its object metadata.
+type ChecksumMismatchError struct {
+	ObjectKey string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for file %s: expected sha256 %s, got %s", e.ObjectKey, e.Expected, e.Actual)
 }
 
 // BufferedStorageBackend is a ledger backend that reads from a storage service.
@@ -41,6 +65,10 @@ type BufferedStorageBackend struct {
 	lcmBatch   xdr.LedgerCloseMetaBatch
 	nextLedger uint32
 	lastLedger uint32
+
+	// checksumMismatches counts ledger files failing checksum verification,
+	// it is nil unless metrics are registered.
+	checksumMismatches prometheus.Counter
 }
 
 // NewBufferedStorageBackend returns a new BufferedStorageBackend instance.
@@ -66,6 +94,14 @@ func NewBufferedStorageBackend(config BufferedStorageBackendConfig, dataStore da
 	return bsBackend, nil
 }
 
+func (bsb *BufferedStorageBackend) registerMetrics(registry *prometheus.Registry, namespace string) {
+	bsb.checksumMismatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ingest", Name: "datastore_checksum_mismatches_total",
+		Help: "number of ledger files downloaded from the datastore whose content did not match the recorded checksum",
+	})
+	registry.MustRegister(bsb.checksumMismatches)
+}
+
 // GetLatestLedgerSequence returns the most recent ledger sequence number available in the buffer.
 func (bsb *BufferedStorageBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
 	bsb.bsBackendLock.RLock()
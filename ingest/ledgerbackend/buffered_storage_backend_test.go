@@ -3,6 +3,8 @@ package ledgerbackend
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"math"
@@ -11,6 +13,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -612,3 +616,168 @@ func TestLedgerBufferRetryLimit(t *testing.T) {
 	assert.ErrorContains(t, err, objectName)
 	assert.ErrorContains(t, err, "transient error")
 }
+
+func TestLedgerBufferMixedCompression(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 1
+	bsb.config.BufferSize = 2
+	bsb.config.MixedCompression = true
+	bsb.schema.FileExtension = compressxdr.DefaultCompressor.Name()
+
+	mockDataStore := new(datastore.MockDataStore)
+	t.Cleanup(func() {
+		mockDataStore.AssertExpectations(t)
+	})
+	// ledger 3 was written with the schema's compressor, ledger 4 with gzip
+	gzipBatch := createTestLedgerCloseMetaBatch(4, 4, 1)
+	var buf bytes.Buffer
+	_, err := compressxdr.NewXDREncoder(compressxdr.GzipCompressor{}, gzipBatch).WriteTo(&buf)
+	assert.NoError(t, err)
+
+	keyFor := func(seq uint32, ext string) string {
+		schema := bsb.schema
+		schema.FileExtension = ext
+		return schema.GetObjectKeyFromSequenceNumber(seq)
+	}
+	mockDataStore.On("GetFile", mock.Anything, keyFor(3, "zst")).Return(createLCMBatchReader(3, 3, 1), nil).Once()
+	mockDataStore.On("GetFile", mock.Anything, keyFor(4, "zst")).Return(nil, os.ErrNotExist).Once()
+	mockDataStore.On("GetFile", mock.Anything, keyFor(4, "gz")).Return(io.NopCloser(&buf), nil).Once()
+	bsb.dataStore = mockDataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 4)))
+
+	lcm, err := bsb.GetLedger(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), lcm.LedgerSequence())
+
+	lcm, err = bsb.GetLedger(ctx, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, gzipBatch.LedgerCloseMetas[0], lcm)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestLedgerBufferMixedCompressionNotFound(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 1
+	bsb.config.BufferSize = 1
+	bsb.config.MixedCompression = true
+
+	mockDataStore := new(datastore.MockDataStore)
+	t.Cleanup(func() {
+		mockDataStore.AssertExpectations(t)
+	})
+	objectName := bsb.schema.GetObjectKeyFromSequenceNumber(3)
+	mockDataStore.On("GetFile", mock.Anything, mock.Anything).Return(nil, os.ErrNotExist).Times(len(compressxdr.Extensions()) + 1)
+	bsb.dataStore = mockDataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 3)))
+
+	_, err := bsb.GetLedger(ctx, 3)
+	assert.ErrorContains(t, err, "ledger object containing sequence 3 is missing")
+	assert.ErrorContains(t, err, objectName)
+}
+
+func TestLedgerBufferZstdDictionary(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 1
+	bsb.config.BufferSize = 1
+
+	var samples [][]byte
+	for i := 0; i < 200; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(
+			"ledger %d closed with tx set hash %032d and %d operations", i, i*7919, i%13)))
+	}
+	dictionary, err := compressxdr.TrainZstdDictionary(samples, 1, 4096)
+	assert.NoError(t, err)
+	bsb.schema.ZstdDictionaries = [][]byte{dictionary}
+	compressor, err := bsb.schema.GetCompressor()
+	assert.NoError(t, err)
+
+	batch := createTestLedgerCloseMetaBatch(3, 3, 1)
+	var buf bytes.Buffer
+	_, err = compressxdr.NewXDREncoder(compressor, batch).WriteTo(&buf)
+	assert.NoError(t, err)
+
+	mockDataStore := new(datastore.MockDataStore)
+	t.Cleanup(func() {
+		mockDataStore.AssertExpectations(t)
+	})
+	mockDataStore.On("GetFile", mock.Anything, bsb.schema.GetObjectKeyFromSequenceNumber(3)).
+		Return(io.NopCloser(&buf), nil).Once()
+	bsb.dataStore = mockDataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 3)))
+	lcm, err := bsb.GetLedger(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, batch.LedgerCloseMetas[0], lcm)
+	assert.NoError(t, bsb.Close())
+}
+
+func TestLedgerBufferVerifyChecksums(t *testing.T) {
+	ctx := context.Background()
+	bsb := createBufferedStorageBackendForTesting()
+	bsb.config.NumWorkers = 1
+	// ledger 5 must only be downloaded once ledger 4 is read, its failure
+	// cancels the buffer
+	bsb.config.BufferSize = 1
+	bsb.config.RetryLimit = 1
+	bsb.config.VerifyChecksums = true
+	registry := prometheus.NewRegistry()
+	bsb.registerMetrics(registry, "test")
+
+	var files [][]byte
+	for seq := uint32(3); seq <= 5; seq++ {
+		var buf bytes.Buffer
+		_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, createTestLedgerCloseMetaBatch(seq, seq, 1)).WriteTo(&buf)
+		assert.NoError(t, err)
+		files = append(files, buf.Bytes())
+	}
+	sum := sha256.Sum256(files[0])
+	validChecksum := hex.EncodeToString(sum[:])
+	corruptChecksum := hex.EncodeToString(make([]byte, sha256.Size))
+
+	mockDataStore := new(datastore.MockDataStore)
+	t.Cleanup(func() {
+		mockDataStore.AssertExpectations(t)
+	})
+	key := bsb.schema.GetObjectKeyFromSequenceNumber
+	for i, seq := range []uint32{3, 4} {
+		mockDataStore.On("GetFile", mock.Anything, key(seq)).
+			Return(io.NopCloser(bytes.NewReader(files[i])), nil).Once()
+	}
+	// ledger 3 matches its checksum and ledger 4 predates checksums
+	mockDataStore.On("GetFileMetadata", mock.Anything, key(3)).
+		Return(datastore.MetaData{ContentSHA256: validChecksum}.ToMap(), nil).Once()
+	mockDataStore.On("GetFileMetadata", mock.Anything, key(4)).
+		Return(datastore.MetaData{}.ToMap(), nil).Once()
+	// ledger 5 is corrupt, it is retried once before failing
+	for i := 0; i < 2; i++ {
+		mockDataStore.On("GetFile", mock.Anything, key(5)).
+			Return(io.NopCloser(bytes.NewReader(files[2])), nil).Once()
+	}
+	mockDataStore.On("GetFileMetadata", mock.Anything, key(5)).
+		Return(datastore.MetaData{ContentSHA256: corruptChecksum}.ToMap(), nil).Twice()
+	bsb.dataStore = mockDataStore
+
+	assert.NoError(t, bsb.PrepareRange(ctx, BoundedRange(3, 5)))
+	for _, seq := range []uint32{3, 4} {
+		lcm, err := bsb.GetLedger(ctx, seq)
+		assert.NoError(t, err)
+		assert.Equal(t, seq, lcm.LedgerSequence())
+	}
+
+	_, err := bsb.GetLedger(ctx, 5)
+	var mismatch *ChecksumMismatchError
+	assert.ErrorAs(t, err, &mismatch)
+	sum = sha256.Sum256(files[2])
+	assert.Equal(t, ChecksumMismatchError{
+		ObjectKey: key(5),
+		Expected:  corruptChecksum,
+		Actual:    hex.EncodeToString(sum[:]),
+	}, *mismatch)
+	assert.Equal(t, float64(2), testutil.ToFloat64(bsb.checksumMismatches))
+	assert.NoError(t, bsb.Close())
+}
@@ -111,9 +111,16 @@ type CaptiveStellarCore struct {
 
 	config                   CaptiveCoreConfig
 	captiveCoreStartDuration prometheus.Summary
-	captiveCoreNewDBCounter  prometheus.Counter
+	captiveCoreDBMetrics     *captiveCoreDBMetrics
 	stellarCoreClient        *stellarcore.Client
 	captiveCoreVersion       string // Updates when captive-core restarts
+
+	// pendingToml is the toml reloaded from config.TomlPath which is not
+	// applied yet, tomlReloads is notified when it is set.
+	pendingToml       *CaptiveCoreToml
+	pendingTomlLock   sync.Mutex
+	tomlReloads       chan struct{}
+	tomlReloadCounter *prometheus.CounterVec
 }
 
 // CaptiveCoreConfig contains all the parameters required to create a CaptiveStellarCore instance
@@ -142,8 +149,12 @@ type CaptiveCoreConfig struct {
 	Context context.Context
 	// StoragePath is the (optional) base path passed along to Core's
 	// BUCKET_DIR_PATH which specifies where various bucket data should be
-	// stored. We always append /captive-core to this directory, since we clean
-	// it up entirely on shutdown.
+	// stored. We always append /captive-core to this directory. The directory
+	// is kept when captive core is closed, except on windows, so the next
+	// unbounded range prepared, including after a restart of the process,
+	// reuses the buckets db instead of catching up when it belongs to
+	// NetworkPassphrase and its last closed ledger is not after the start of
+	// the range.
 	StoragePath string
 
 	// CoreProtocolVersionFn is a function that returns the protocol version of the stellar-core binary.
@@ -151,6 +162,18 @@ type CaptiveCoreConfig struct {
 
 	// CoreBuildVersionFn is a function that returns the build version of the stellar-core binary.
 	CoreBuildVersionFn CoreBuildVersionFunc
+
+	// TomlPath is the (optional) path of the file Toml was read from. When
+	// set, the file is watched and every change of its content is validated
+	// with NewCaptiveCoreTomlFromData using TomlParams. A valid toml replaces
+	// Toml and, when an unbounded range is prepared, stellar-core is
+	// restarted with it, resuming from the ledger following the last ledger
+	// returned by GetLedger. Invalid changes are ignored.
+	TomlPath   string
+	TomlParams CaptiveCoreTomlParams
+	// OnTomlReload is an (optional) function called for every change of
+	// TomlPath, once it is applied or rejected.
+	OnTomlReload func(TomlReloadEvent)
 }
 
 // NewCaptive returns a new CaptiveStellarCore instance.
@@ -215,11 +238,22 @@ func NewCaptive(config CaptiveCoreConfig) (*CaptiveStellarCore, error) {
 		cancel:            cancel,
 		config:            config,
 		checkpointManager: historyarchive.NewCheckpointManager(config.CheckpointFrequency),
+		tomlReloads:       make(chan struct{}, 1),
 	}
 
 	c.stellarCoreRunnerFactory = func() stellarCoreRunnerInterface {
 		c.setCoreVersion()
-		return newStellarCoreRunner(config, c.captiveCoreNewDBCounter)
+		// c.config.Toml is replaced when the toml is reloaded
+		return newStellarCoreRunner(c.config, c.captiveCoreDBMetrics)
+	}
+
+	if config.TomlPath != "" {
+		tomlWatcher, err := newTomlWatcher(c)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go tomlWatcher.loop()
 	}
 
 	if config.Toml != nil && config.Toml.HTTPPort != 0 {
@@ -311,19 +345,43 @@ func (c *CaptiveStellarCore) registerMetrics(registry *prometheus.Registry, name
 		Help:       "duration of start up time when running captive core on an unbounded range, sliding window = 10m",
 		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
 	})
-	c.captiveCoreNewDBCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	c.captiveCoreDBMetrics = &captiveCoreDBMetrics{
+		newDBCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "captive_stellar_core_new_db",
+			Help:      "counter for the number of times we start up captive core with a new buckets db, sliding window = 10m",
+		}),
+		reusedDBCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "captive_stellar_core_reused_db",
+			Help:      "counter for the number of times we start up captive core reusing the buckets db of a previous run, skipping catchup",
+		}),
+		replayedLedgersCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ingest",
+			Name:      "captive_stellar_core_reused_db_replayed_ledgers",
+			Help:      "counter for the number of ledgers replayed by captive core to reach the start of the range from the last ledger of a reused buckets db",
+		}),
+	}
+
+	c.tomlReloadCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: namespace,
 		Subsystem: "ingest",
-		Name:      "captive_stellar_core_new_db",
-		Help:      "counter for the number of times we start up captive core with a new buckets db, sliding window = 10m",
-	})
+		Name:      "captive_stellar_core_toml_reloads",
+		Help:      "counter for the number of changes of the captive core toml file, labeled by status: applied, invalid or failed",
+	}, []string{"status"})
 
 	registry.MustRegister(
+		c.tomlReloadCounter,
 		coreSynced,
 		supportedProtocolVersion,
 		latestLedger,
 		c.captiveCoreStartDuration,
-		c.captiveCoreNewDBCounter,
+		c.captiveCoreDBMetrics.newDBCounter,
+		c.captiveCoreDBMetrics.reusedDBCounter,
+		c.captiveCoreDBMetrics.replayedLedgersCounter,
 	)
 }
 
@@ -497,6 +555,9 @@ func (c *CaptiveStellarCore) startPreparingRange(ctx context.Context, ledgerRang
 // history archive. This issue is being fixed in Stellar-Core.
 func (c *CaptiveStellarCore) PrepareRange(ctx context.Context, ledgerRange Range) error {
 	startTime := time.Now()
+	if err := c.applyPendingToml(ctx); err != nil {
+		return errors.Wrap(err, "error reloading toml")
+	}
 	if alreadyPrepared, err := c.startPreparingRange(ctx, ledgerRange); err != nil {
 		return errors.Wrap(err, "error starting prepare range")
 	} else if alreadyPrepared {
@@ -589,30 +650,46 @@ func (c *CaptiveStellarCore) isPrepared(ledgerRange Range) bool {
 // This function behaves differently for bounded and unbounded ranges:
 //   - BoundedRange: After getting the last ledger in a range this method will
 //     also Close() the backend.
+//   - UnboundedRange: When CaptiveCoreConfig.TomlPath is set and the toml is
+//     reloaded, stellar-core is restarted before returning the ledger.
 func (c *CaptiveStellarCore) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	for {
+		if err := c.applyPendingToml(ctx); err != nil {
+			return xdr.LedgerCloseMeta{}, err
+		}
+		ledger, reload, err := c.getLedger(ctx, sequence)
+		if !reload {
+			return ledger, err
+		}
+	}
+}
+
+// getLedger returns the ledger, unless the toml is reloaded while waiting for
+// it in which case it returns true.
+func (c *CaptiveStellarCore) getLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, bool, error) {
 	c.stellarCoreLock.RLock()
 	defer c.stellarCoreLock.RUnlock()
 
 	if c.cachedMeta != nil && sequence == c.cachedMeta.LedgerSequence() {
 		// GetLedger can be called multiple times using the same sequence, ex. to create
 		// change and transaction readers. If we have this ledger buffered, let's return it.
-		return *c.cachedMeta, nil
+		return *c.cachedMeta, false, nil
 	}
 
 	if c.closed {
-		return xdr.LedgerCloseMeta{}, errors.New("stellar-core is no longer usable")
+		return xdr.LedgerCloseMeta{}, false, errors.New("stellar-core is no longer usable")
 	}
 
 	if c.prepared == nil {
-		return xdr.LedgerCloseMeta{}, errors.New("session is not prepared, call PrepareRange first")
+		return xdr.LedgerCloseMeta{}, false, errors.New("session is not prepared, call PrepareRange first")
 	}
 
 	if c.stellarCoreRunner == nil {
-		return xdr.LedgerCloseMeta{}, errors.New("stellar-core cannot be nil, call PrepareRange first")
+		return xdr.LedgerCloseMeta{}, false, errors.New("stellar-core cannot be nil, call PrepareRange first")
 	}
 
 	if sequence < c.nextExpectedSequence() {
-		return xdr.LedgerCloseMeta{}, errors.Errorf(
+		return xdr.LedgerCloseMeta{}, false, errors.Errorf(
 			"requested ledger %d is behind the captive core stream (expected=%d)",
 			sequence,
 			c.nextExpectedSequence(),
@@ -620,7 +697,7 @@ func (c *CaptiveStellarCore) GetLedger(ctx context.Context, sequence uint32) (xd
 	}
 
 	if c.lastLedger != nil && sequence > *c.lastLedger {
-		return xdr.LedgerCloseMeta{}, errors.Errorf(
+		return xdr.LedgerCloseMeta{}, false, errors.Errorf(
 			"reading past bounded range (requested sequence=%d, last ledger in range=%d)",
 			sequence,
 			*c.lastLedger,
@@ -629,19 +706,21 @@ func (c *CaptiveStellarCore) GetLedger(ctx context.Context, sequence uint32) (xd
 
 	ch, ok := c.stellarCoreRunner.getMetaPipe()
 	if !ok {
-		return xdr.LedgerCloseMeta{}, errors.New("stellar-core is not running, call PrepareRange first")
+		return xdr.LedgerCloseMeta{}, false, errors.New("stellar-core is not running, call PrepareRange first")
 	}
 
 	// Now loop along the range until we find the ledger we want.
 	for {
 		select {
 		case <-ctx.Done():
-			return xdr.LedgerCloseMeta{}, ctx.Err()
+			return xdr.LedgerCloseMeta{}, false, ctx.Err()
 		case result, ok := <-ch:
 			found, ledger, err := c.handleMetaPipeResult(sequence, result, ok)
 			if found || err != nil {
-				return ledger, err
+				return ledger, false, err
 			}
+		case <-c.tomlReloads:
+			return xdr.LedgerCloseMeta{}, true, nil
 		}
 	}
 }
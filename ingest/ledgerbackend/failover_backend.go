@@ -0,0 +1,439 @@
+package ledgerbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+// Ensure FailoverBackend implements LedgerBackend
+var _ LedgerBackend = (*FailoverBackend)(nil)
+
+const failoverBackendDefaultRetryInterval = 30 * time.Second
+
+// FailoverSource is a backend of a FailoverBackend.
+type FailoverSource struct {
+	// Required, name of the source, used in logs and metrics
+	Name string
+	// Required, the backend. It is prepared and closed by the FailoverBackend.
+	Backend LedgerBackend
+	// Optional, returns a new instance of the backend. When set, a source which
+	// failed to get a ledger, or which is past the requested ledger, is closed
+	// and replaced by a new instance before being used again. Otherwise the
+	// source is caught up by reading the ledgers it missed, and can't serve
+	// ledgers preceding the ledgers it already returned.
+	NewBackend func() (LedgerBackend, error)
+}
+
+type FailoverBackendConfig struct {
+	// Required, the sources in order of preference, e.g. a BufferedStorageBackend,
+	// then a RPCLedgerBackend and a CaptiveStellarCore as last resort.
+	Sources []FailoverSource
+
+	// Optional, maximum time to wait for a ledger from a source before failing
+	// over to the next one. The last source is waited for without timeout.
+	// If not set, sources only fail over on errors.
+	SourceTimeout time.Duration
+
+	// Optional, time after which a failed source is tried again, if not set
+	// defaults to 30 seconds.
+	RetryInterval time.Duration
+
+	// Optional, if nil uses go default logger
+	Log *log.Entry
+}
+
+// FailoverHashMismatchError is returned when a ledger does not follow the
+// previous ledger served by the FailoverBackend, e.g. because a source is
+// configured for a different network.
+type FailoverHashMismatchError struct {
+	Source             string
+	Sequence           uint32
+	PreviousLedgerHash xdr.Hash
+	ExpectedHash       xdr.Hash
+}
+
+func (e *FailoverHashMismatchError) Error() string {
+	return fmt.Sprintf("previous ledger hash %s of ledger %d from source %s does not match hash %s of ledger %d",
+		e.PreviousLedgerHash.HexString(), e.Sequence, e.Source, e.ExpectedHash.HexString(), e.Sequence-1)
+}
+
+// failoverSourceState tracks the progress of a source.
+type failoverSourceState struct {
+	FailoverSource
+	// prepared is true once PrepareRange succeeded for the source
+	prepared bool
+	// stale is true after the source failed to get a ledger, it is then
+	// replaced before being used if it has a NewBackend
+	stale bool
+	// next is the ledger following the last ledger returned by the backend,
+	// which is the next ledger it can return once prepared
+	next uint32
+	// failedAt is the time of the last failure of the source, zero if it
+	// did not fail since it last served a ledger
+	failedAt time.Time
+}
+
+// FailoverBackend is a ledger backend combining several sources. Ledgers are
+// served by the most preferred source which has them: a source is failed over
+// when it returns an error, or does not return a ledger within SourceTimeout,
+// and is tried again after RetryInterval.
+//
+// Sources are only prepared once they are needed, from the requested ledger.
+// Sources which fell behind because other sources served ledgers meanwhile are
+// caught up by reading the ledgers they missed when their prepared range
+// already includes the requested ledger, e.g. a BufferedStorageBackend or a
+// CaptiveStellarCore prepared with an unbounded range, and are prepared again
+// from the requested ledger otherwise.
+//
+// Every ledger served is verified to follow the previous one by its previous
+// ledger hash, so sources can't diverge across switches.
+//
+// GetLedger calls are serialized. Close and PrepareRange cancel the GetLedger
+// call in progress instead of waiting for a slow source.
+type FailoverBackend struct {
+	config  FailoverBackendConfig
+	sources []*failoverSourceState
+	// sourceLock is held while the sources are used by GetLedger and
+	// PrepareRange. The state of the sources, lastSeq and lastHash are
+	// guarded by sourceLock, the other fields by lock, which is only held
+	// briefly. The backends of the sources, active and preparedRange are
+	// modified while holding both.
+	sourceLock sync.Mutex
+	lock       sync.Mutex
+
+	preparedRange *Range
+	// active is the index of the source which served the last ledger
+	active   int
+	lastSeq  uint32
+	lastHash xdr.Hash
+	closed   bool
+	// cancelGetLedger cancels the GetLedger call in progress, nil if there is
+	// none
+	cancelGetLedger context.CancelFunc
+	now             func() time.Time
+
+	// ledgersServed and failures are nil unless metrics are registered
+	ledgersServed *prometheus.CounterVec
+	failures      *prometheus.CounterVec
+}
+
+// NewFailoverBackend returns a new FailoverBackend instance.
+func NewFailoverBackend(config FailoverBackendConfig) (*FailoverBackend, error) {
+	if len(config.Sources) == 0 {
+		return nil, errors.New("at least one source is required")
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = failoverBackendDefaultRetryInterval
+	}
+	if config.Log == nil {
+		config.Log = log.DefaultLogger
+	}
+
+	names := map[string]bool{}
+	backend := &FailoverBackend{config: config, now: time.Now}
+	for _, source := range config.Sources {
+		if source.Name == "" || source.Backend == nil {
+			return nil, errors.New("sources require a name and a backend")
+		}
+		if names[source.Name] {
+			return nil, fmt.Errorf("duplicate source name %s", source.Name)
+		}
+		names[source.Name] = true
+		backend.sources = append(backend.sources, &failoverSourceState{FailoverSource: source})
+	}
+	return backend, nil
+}
+
+func (f *FailoverBackend) registerMetrics(registry *prometheus.Registry, namespace string) {
+	f.ledgersServed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ingest", Name: "failover_ledgers_served_total",
+		Help: "number of ledgers served by each source of the failover ledger backend",
+	}, []string{"source"})
+	f.failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ingest", Name: "failover_source_failures_total",
+		Help: "number of times each source of the failover ledger backend failed and was failed over",
+	}, []string{"source"})
+	registry.MustRegister(f.ledgersServed, f.failures)
+}
+
+// ActiveSource returns the name of the source which served the last ledger.
+func (f *FailoverBackend) ActiveSource() string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.sources[f.active].Name
+}
+
+// GetLatestLedgerSequence returns the latest ledger sequence of the active source.
+func (f *FailoverBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return 0, errors.New("FailoverBackend is closed")
+	}
+	if f.preparedRange == nil {
+		f.lock.Unlock()
+		return 0, errors.New("FailoverBackend must be prepared before calling GetLatestLedgerSequence")
+	}
+	backend := f.sources[f.active].Backend
+	f.lock.Unlock()
+	return backend.GetLatestLedgerSequence(ctx)
+}
+
+// GetLedger returns the ledger from the most preferred source which has it,
+// blocking until the ledger is available.
+func (f *FailoverBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	f.sourceLock.Lock()
+	defer f.sourceLock.Unlock()
+
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return xdr.LedgerCloseMeta{}, errors.New("FailoverBackend is closed")
+	}
+	if f.preparedRange == nil {
+		f.lock.Unlock()
+		return xdr.LedgerCloseMeta{}, errors.New("FailoverBackend must be prepared before calling GetLedger")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	f.cancelGetLedger = cancel
+	f.lock.Unlock()
+	defer func() {
+		f.lock.Lock()
+		f.cancelGetLedger = nil
+		f.lock.Unlock()
+		cancel()
+	}()
+
+	var errs []error
+	for i, source := range f.sources {
+		last := i == len(f.sources)-1
+		if !last && !source.failedAt.IsZero() && f.now().Sub(source.failedAt) < f.config.RetryInterval {
+			continue
+		}
+
+		lcm, err := f.getLedgerFromSource(ctx, source, sequence, last)
+		if err == nil {
+			if i != f.active {
+				f.config.Log.Infof("FailoverBackend switched from source %s to %s at ledger %d",
+					f.sources[f.active].Name, source.Name, sequence)
+			}
+			f.lock.Lock()
+			f.active = i
+			f.lock.Unlock()
+			source.failedAt = time.Time{}
+			if f.ledgersServed != nil {
+				f.ledgersServed.With(prometheus.Labels{"source": source.Name}).Inc()
+			}
+			return lcm, nil
+		}
+		// the caller gave up, or the backend was closed or prepared again, the
+		// source didn't fail
+		if ctx.Err() != nil {
+			return xdr.LedgerCloseMeta{}, ctx.Err()
+		}
+
+		f.config.Log.WithError(err).Warnf("FailoverBackend source %s failed to get ledger %d", source.Name, sequence)
+		source.failedAt = f.now()
+		if f.failures != nil {
+			f.failures.With(prometheus.Labels{"source": source.Name}).Inc()
+		}
+		errs = append(errs, fmt.Errorf("source %s: %w", source.Name, err))
+	}
+	return xdr.LedgerCloseMeta{}, fmt.Errorf("no source could get ledger %d: %w", sequence, errors.Join(errs...))
+}
+
+func (f *FailoverBackend) getLedgerFromSource(ctx context.Context, source *failoverSourceState, sequence uint32, last bool) (xdr.LedgerCloseMeta, error) {
+	if !last && f.config.SourceTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.config.SourceTimeout)
+		defer cancel()
+	}
+
+	if err := f.syncSource(ctx, source, sequence); err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	lcm, err := source.Backend.GetLedger(ctx, sequence)
+	if err != nil {
+		source.stale = true
+		return xdr.LedgerCloseMeta{}, err
+	}
+	source.next = sequence + 1
+	source.stale = false
+
+	if f.lastSeq != 0 && sequence == f.lastSeq+1 && lcm.PreviousLedgerHash() != f.lastHash {
+		return xdr.LedgerCloseMeta{}, &FailoverHashMismatchError{
+			Source:             source.Name,
+			Sequence:           sequence,
+			PreviousLedgerHash: lcm.PreviousLedgerHash(),
+			ExpectedHash:       f.lastHash,
+		}
+	}
+	f.lastSeq = sequence
+	f.lastHash = lcm.LedgerHash()
+	return lcm, nil
+}
+
+// syncSource ensures the next ledger returned by the source is sequence,
+// replacing it, preparing it again or reading the ledgers it missed.
+func (f *FailoverBackend) syncSource(ctx context.Context, source *failoverSourceState, sequence uint32) error {
+	if source.prepared && !source.stale && source.next == sequence {
+		return nil
+	}
+	if source.prepared && source.NewBackend != nil && (source.stale || source.next > sequence) {
+		if err := f.replaceSource(source); err != nil {
+			return err
+		}
+	}
+
+	ledgerRange := UnboundedRange(sequence)
+	if f.preparedRange.bounded {
+		ledgerRange = BoundedRange(sequence, f.preparedRange.to)
+	}
+	if source.prepared {
+		// preparing a range the backend considers prepared is a no-op, which
+		// doesn't move the next ledger of the backend
+		prepared, err := source.Backend.IsPrepared(ctx, ledgerRange)
+		if err != nil {
+			return fmt.Errorf("failed to check if range %v is prepared: %w", ledgerRange, err)
+		}
+		if prepared {
+			return f.catchUpSource(ctx, source, sequence)
+		}
+	}
+
+	err := source.Backend.PrepareRange(ctx, ledgerRange)
+	if err == nil {
+		source.prepared = true
+		source.stale = false
+		source.next = sequence
+		return nil
+	}
+	if !source.prepared || source.next > sequence {
+		return fmt.Errorf("failed to prepare range %v: %w", ledgerRange, err)
+	}
+	// some backends can only be prepared once
+	return f.catchUpSource(ctx, source, sequence)
+}
+
+// catchUpSource reads the ledgers the source missed, up to sequence.
+func (f *FailoverBackend) catchUpSource(ctx context.Context, source *failoverSourceState, sequence uint32) error {
+	if source.next > sequence {
+		return fmt.Errorf("source already returned ledger %d", source.next-1)
+	}
+	for ; source.next < sequence; source.next++ {
+		if _, err := source.Backend.GetLedger(ctx, source.next); err != nil {
+			source.stale = true
+			return fmt.Errorf("failed to catch up from ledger %d: %w", source.next, err)
+		}
+	}
+	return nil
+}
+
+// replaceSource closes the backend of the source and replaces it by a new
+// instance, which is prepared when used.
+func (f *FailoverBackend) replaceSource(source *failoverSourceState) error {
+	backend, err := source.NewBackend()
+	if err != nil {
+		return fmt.Errorf("failed to create a new backend: %w", err)
+	}
+
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		backend.Close()
+		return errors.New("FailoverBackend is closed")
+	}
+	previous := source.Backend
+	source.Backend = backend
+	f.lock.Unlock()
+
+	if err = previous.Close(); err != nil {
+		f.config.Log.WithError(err).Warnf("FailoverBackend failed to close the replaced backend of source %s", source.Name)
+	}
+	source.prepared = false
+	source.stale = false
+	source.next = 0
+	return nil
+}
+
+// PrepareRange prepares the most preferred source which can prepare the range.
+func (f *FailoverBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	f.lock.Lock()
+	if f.closed {
+		f.lock.Unlock()
+		return errors.New("FailoverBackend is closed")
+	}
+	// the ledger requested in the previous range is not needed anymore
+	if f.cancelGetLedger != nil {
+		f.cancelGetLedger()
+	}
+	f.lock.Unlock()
+
+	f.sourceLock.Lock()
+	defer f.sourceLock.Unlock()
+
+	var errs []error
+	for i, source := range f.sources {
+		err := source.Backend.PrepareRange(ctx, ledgerRange)
+		if err == nil {
+			source.prepared = true
+			source.stale = false
+			source.next = ledgerRange.from
+			f.lock.Lock()
+			f.active = i
+			f.preparedRange = &ledgerRange
+			f.lock.Unlock()
+			f.lastSeq = 0
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		f.config.Log.WithError(err).Warnf("FailoverBackend source %s failed to prepare range %v", source.Name, ledgerRange)
+		source.failedAt = f.now()
+		errs = append(errs, fmt.Errorf("source %s: %w", source.Name, err))
+	}
+	return fmt.Errorf("no source could prepare range %v: %w", ledgerRange, errors.Join(errs...))
+}
+
+// IsPrepared returns true if the given range is prepared.
+func (f *FailoverBackend) IsPrepared(ctx context.Context, ledgerRange Range) (bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.closed {
+		return false, errors.New("FailoverBackend is closed")
+	}
+	return f.preparedRange != nil && *f.preparedRange == ledgerRange, nil
+}
+
+// Close closes every source, without waiting for the GetLedger call in
+// progress, which is canceled.
+func (f *FailoverBackend) Close() error {
+	f.lock.Lock()
+	f.closed = true
+	if f.cancelGetLedger != nil {
+		f.cancelGetLedger()
+	}
+	backends := make([]LedgerBackend, len(f.sources))
+	for i, source := range f.sources {
+		backends[i] = source.Backend
+	}
+	f.lock.Unlock()
+
+	var errs []error
+	for i, source := range f.sources {
+		if err := backends[i].Close(); err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", source.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
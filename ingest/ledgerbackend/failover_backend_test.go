@@ -0,0 +1,308 @@
+package ledgerbackend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+func failoverTestHash(seq uint32) xdr.Hash {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], seq)
+	return sha256.Sum256(b[:])
+}
+
+func createChainedLedgerCloseMeta(seq uint32) xdr.LedgerCloseMeta {
+	lcm := createLedgerCloseMeta(seq)
+	lcm.V0.LedgerHeader.Hash = failoverTestHash(seq)
+	lcm.V0.LedgerHeader.Header.PreviousLedgerHash = failoverTestHash(seq - 1)
+	return lcm
+}
+
+func TestFailoverBackend(t *testing.T) {
+	ctx := context.Background()
+	primary, fallback := &MockDatabaseBackend{}, &MockDatabaseBackend{}
+	t.Cleanup(func() {
+		primary.AssertExpectations(t)
+		fallback.AssertExpectations(t)
+	})
+
+	backend, err := NewFailoverBackend(FailoverBackendConfig{
+		Sources: []FailoverSource{
+			{Name: "datastore", Backend: primary},
+			{Name: "rpc", Backend: fallback},
+		},
+		RetryInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+	registry := prometheus.NewRegistry()
+	ledgerBackend := WithMetrics(backend, registry, "test")
+
+	primary.On("PrepareRange", ctx, UnboundedRange(2)).Return(nil).Once()
+	require.NoError(t, ledgerBackend.PrepareRange(ctx, UnboundedRange(2)))
+	prepared, err := ledgerBackend.IsPrepared(ctx, UnboundedRange(2))
+	require.NoError(t, err)
+	require.True(t, prepared)
+
+	primary.On("GetLedger", mock.Anything, uint32(2)).Return(createChainedLedgerCloseMeta(2), nil).Once()
+	lcm, err := ledgerBackend.GetLedger(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), lcm.LedgerSequence())
+	require.Equal(t, "datastore", backend.ActiveSource())
+
+	// the primary lags, the fallback is prepared from ledger 3
+	primary.On("GetLedger", mock.Anything, uint32(3)).Return(xdr.LedgerCloseMeta{}, errors.New("lagging")).Once()
+	fallback.On("PrepareRange", mock.Anything, UnboundedRange(3)).Return(nil).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(3)).Return(createChainedLedgerCloseMeta(3), nil).Once()
+	lcm, err = ledgerBackend.GetLedger(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), lcm.LedgerSequence())
+	require.Equal(t, "rpc", backend.ActiveSource())
+
+	// the primary is not retried before the retry interval
+	fallback.On("GetLedger", mock.Anything, uint32(4)).Return(createChainedLedgerCloseMeta(4), nil).Once()
+	_, err = ledgerBackend.GetLedger(ctx, 4)
+	require.NoError(t, err)
+
+	// the primary caught up and is prepared again
+	now = now.Add(time.Minute)
+	primary.On("IsPrepared", mock.Anything, UnboundedRange(5)).Return(false, nil).Once()
+	primary.On("PrepareRange", mock.Anything, UnboundedRange(5)).Return(nil).Once()
+	primary.On("GetLedger", mock.Anything, uint32(5)).Return(createChainedLedgerCloseMeta(5), nil).Once()
+	_, err = ledgerBackend.GetLedger(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, "datastore", backend.ActiveSource())
+
+	// preparing the fallback again would be a no-op, it catches up from
+	// ledger 5
+	primary.On("GetLedger", mock.Anything, uint32(6)).Return(xdr.LedgerCloseMeta{}, errors.New("lagging")).Once()
+	fallback.On("IsPrepared", mock.Anything, UnboundedRange(6)).Return(true, nil).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(5)).Return(createChainedLedgerCloseMeta(5), nil).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(6)).Return(createChainedLedgerCloseMeta(6), nil).Once()
+	_, err = ledgerBackend.GetLedger(ctx, 6)
+	require.NoError(t, err)
+	require.Equal(t, "rpc", backend.ActiveSource())
+
+	// ledgers which don't follow the previous ledger are rejected
+	fork := createChainedLedgerCloseMeta(7)
+	fork.V0.LedgerHeader.Header.PreviousLedgerHash = xdr.Hash{1}
+	fallback.On("GetLedger", mock.Anything, uint32(7)).Return(fork, nil).Once()
+	_, err = ledgerBackend.GetLedger(ctx, 7)
+	var mismatchErr *FailoverHashMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+	require.Equal(t, FailoverHashMismatchError{
+		Source:             "rpc",
+		Sequence:           7,
+		PreviousLedgerHash: xdr.Hash{1},
+		ExpectedHash:       failoverTestHash(6),
+	}, *mismatchErr)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(backend.ledgersServed.WithLabelValues("datastore")))
+	require.Equal(t, 3.0, testutil.ToFloat64(backend.ledgersServed.WithLabelValues("rpc")))
+	require.Equal(t, 2.0, testutil.ToFloat64(backend.failures.WithLabelValues("datastore")))
+	require.Equal(t, 1.0, testutil.ToFloat64(backend.failures.WithLabelValues("rpc")))
+
+	primary.On("Close").Return(nil).Once()
+	fallback.On("Close").Return(errors.New("boom")).Once()
+	require.EqualError(t, ledgerBackend.Close(), "source rpc: boom")
+	_, err = ledgerBackend.GetLedger(ctx, 8)
+	require.EqualError(t, err, "FailoverBackend is closed")
+}
+
+func TestFailoverBackendSourceTimeout(t *testing.T) {
+	ctx := context.Background()
+	primary, fallback := &MockDatabaseBackend{}, &MockDatabaseBackend{}
+	backend, err := NewFailoverBackend(FailoverBackendConfig{
+		Sources: []FailoverSource{
+			{Name: "datastore", Backend: primary},
+			{Name: "core", Backend: fallback},
+		},
+		SourceTimeout: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	primary.On("PrepareRange", ctx, BoundedRange(2, 10)).Return(errors.New("unavailable")).Once()
+	fallback.On("PrepareRange", ctx, BoundedRange(2, 10)).Return(nil).Once()
+	require.NoError(t, backend.PrepareRange(ctx, BoundedRange(2, 10)))
+	require.Equal(t, "core", backend.ActiveSource())
+
+	fallback.On("GetLedger", mock.Anything, uint32(2)).Return(createChainedLedgerCloseMeta(2), nil).Once()
+	_, err = backend.GetLedger(ctx, 2)
+	require.NoError(t, err)
+
+	// the primary blocks until its timeout
+	backend.sources[0].failedAt = time.Time{}
+	primary.On("PrepareRange", mock.Anything, BoundedRange(3, 10)).Return(nil).Once()
+	primary.On("GetLedger", mock.Anything, uint32(3)).
+		Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(xdr.LedgerCloseMeta{}, context.DeadlineExceeded).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(3)).Return(createChainedLedgerCloseMeta(3), nil).Once()
+	lcm, err := backend.GetLedger(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), lcm.LedgerSequence())
+	require.Equal(t, "core", backend.ActiveSource())
+
+	// all sources failing returns the error of each
+	backend.sources[0].failedAt = time.Time{}
+	primary.On("IsPrepared", mock.Anything, BoundedRange(4, 10)).Return(false, nil).Once()
+	primary.On("PrepareRange", mock.Anything, BoundedRange(4, 10)).Return(nil).Once()
+	primary.On("GetLedger", mock.Anything, uint32(4)).Return(xdr.LedgerCloseMeta{}, errors.New("missing")).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(4)).Return(xdr.LedgerCloseMeta{}, errors.New("crashed")).Once()
+	_, err = backend.GetLedger(ctx, 4)
+	require.EqualError(t, err, "no source could get ledger 4: source datastore: missing\nsource core: crashed")
+
+	primary.AssertExpectations(t)
+	fallback.AssertExpectations(t)
+
+	_, err = NewFailoverBackend(FailoverBackendConfig{Sources: []FailoverSource{
+		{Name: "core", Backend: primary},
+		{Name: "core", Backend: fallback},
+	}})
+	require.EqualError(t, err, "duplicate source name core")
+}
+
+func TestFailoverBackendRecoversBufferedStorage(t *testing.T) {
+	ctx := context.Background()
+	dataStore, err := datastore.FromFilesystemPath(t.TempDir())
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10}
+	compressor, err := schema.GetCompressor()
+	require.NoError(t, err)
+	writeLedger := func(seq uint32) {
+		batch := xdr.LedgerCloseMetaBatch{
+			StartSequence:    xdr.Uint32(seq),
+			EndSequence:      xdr.Uint32(seq),
+			LedgerCloseMetas: []xdr.LedgerCloseMeta{createChainedLedgerCloseMeta(seq)},
+		}
+		metaData, err := datastore.NewLedgerBatchMetaData(batch, "passphrase", compressor, "", "")
+		require.NoError(t, err)
+		_, err = datastore.UploadLedgerBatch(ctx, dataStore, schema.GetObjectKeyFromSequenceNumber(seq), &batch, compressor, metaData, false)
+		require.NoError(t, err)
+	}
+	writeLedger(2)
+	writeLedger(3)
+
+	primary, err := NewBufferedStorageBackend(BufferedStorageBackendConfig{
+		BufferSize: 2,
+		NumWorkers: 1,
+		RetryLimit: 1,
+		RetryWait:  time.Millisecond,
+	}, dataStore, schema)
+	require.NoError(t, err)
+	fallback := &MockDatabaseBackend{}
+	backend, err := NewFailoverBackend(FailoverBackendConfig{
+		Sources: []FailoverSource{
+			{Name: "datastore", Backend: primary},
+			{Name: "rpc", Backend: fallback},
+		},
+		SourceTimeout: 100 * time.Millisecond,
+		RetryInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	backend.now = func() time.Time { return now }
+
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(2)))
+	for seq := uint32(2); seq <= 3; seq++ {
+		_, err = backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, "datastore", backend.ActiveSource())
+	}
+
+	// ledger 4 is not exported yet
+	fallback.On("PrepareRange", mock.Anything, UnboundedRange(4)).Return(nil).Once()
+	for seq := uint32(4); seq <= 5; seq++ {
+		fallback.On("GetLedger", mock.Anything, seq).Return(createChainedLedgerCloseMeta(seq), nil).Once()
+		_, err = backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, "rpc", backend.ActiveSource())
+	}
+
+	// the primary catches up on the ledgers served by the fallback
+	for seq := uint32(4); seq <= 6; seq++ {
+		writeLedger(seq)
+	}
+	now = now.Add(time.Minute)
+	lcm, err := backend.GetLedger(ctx, 6)
+	require.NoError(t, err)
+	require.Equal(t, uint32(6), lcm.LedgerSequence())
+	require.Equal(t, "datastore", backend.ActiveSource())
+
+	fallback.On("Close").Return(nil).Once()
+	require.NoError(t, backend.Close())
+	fallback.AssertExpectations(t)
+}
+
+func TestFailoverBackendNewBackend(t *testing.T) {
+	ctx := context.Background()
+	stale, replacement, fallback := &MockDatabaseBackend{}, &MockDatabaseBackend{}, &MockDatabaseBackend{}
+	backend, err := NewFailoverBackend(FailoverBackendConfig{
+		Sources: []FailoverSource{
+			{
+				Name:    "core",
+				Backend: stale,
+				NewBackend: func() (LedgerBackend, error) {
+					return replacement, nil
+				},
+			},
+			{Name: "rpc", Backend: fallback},
+		},
+	})
+	require.NoError(t, err)
+
+	stale.On("PrepareRange", ctx, UnboundedRange(2)).Return(nil).Once()
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(2)))
+	stale.On("GetLedger", mock.Anything, uint32(2)).Return(xdr.LedgerCloseMeta{}, errors.New("crashed")).Once()
+	fallback.On("PrepareRange", mock.Anything, UnboundedRange(2)).Return(nil).Once()
+	fallback.On("GetLedger", mock.Anything, uint32(2)).Return(createChainedLedgerCloseMeta(2), nil).Once()
+	_, err = backend.GetLedger(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "rpc", backend.ActiveSource())
+
+	// the failed source is replaced and prepared from the requested ledger
+	backend.sources[0].failedAt = time.Time{}
+	stale.On("Close").Return(nil).Once()
+	replacement.On("PrepareRange", mock.Anything, UnboundedRange(3)).Return(nil).Once()
+	replacement.On("GetLedger", mock.Anything, uint32(3)).Return(createChainedLedgerCloseMeta(3), nil).Once()
+	_, err = backend.GetLedger(ctx, 3)
+	require.NoError(t, err)
+	require.Equal(t, "core", backend.ActiveSource())
+
+	// closing cancels the ledger being fetched
+	fetching := make(chan struct{})
+	replacement.On("GetLedger", mock.Anything, uint32(4)).
+		Run(func(args mock.Arguments) {
+			close(fetching)
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(xdr.LedgerCloseMeta{}, context.Canceled).Once()
+	done := make(chan error)
+	go func() {
+		_, err := backend.GetLedger(ctx, 4)
+		done <- err
+	}()
+	<-fetching
+	replacement.On("Close").Return(nil).Once()
+	fallback.On("Close").Return(nil).Once()
+	require.NoError(t, backend.Close())
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	stale.AssertExpectations(t)
+	replacement.AssertExpectations(t)
+	fallback.AssertExpectations(t)
+}
@@ -76,6 +76,31 @@ func newFileWatcherWithOptions(
 	}, nil
 }
 
+func newTomlWatcher(captive *CaptiveStellarCore) (*fileWatcher, error) {
+	return newTomlWatcherWithOptions(captive, hashFile, 10*time.Second)
+}
+
+func newTomlWatcherWithOptions(
+	captive *CaptiveStellarCore,
+	hashFile func(string) (hash, error),
+	tickerDuration time.Duration,
+) (*fileWatcher, error) {
+	hashResult, err := hashFile(captive.config.TomlPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not hash captive core toml")
+	}
+
+	return &fileWatcher{
+		pathToFile: captive.config.TomlPath,
+		duration:   tickerDuration,
+		onChange:   captive.tomlChanged,
+		exit:       captive.config.Context.Done(),
+		log:        captive.config.Log,
+		hashFile:   hashFile,
+		lastHash:   hashResult,
+	}, nil
+}
+
 func (f *fileWatcher) loop() {
 	ticker := time.NewTicker(f.duration)
 
@@ -106,6 +131,7 @@ func (f *fileWatcher) fileChanged() bool {
 			f.lastHash,
 			hashResult,
 		)
+		f.lastHash = hashResult
 		return true
 	}
 	return false
@@ -115,6 +115,10 @@ func TestFileChanged(t *testing.T) {
 	ms.setResponse(hash{1}, nil)
 	assert.True(t, fw.fileChanged())
 	assert.Equal(t, 6, ms.getCallCount())
+
+	// a change is only reported once
+	assert.False(t, fw.fileChanged())
+	assert.Equal(t, 7, ms.getCallCount())
 }
 
 func TestCloseRunnerBeforeFileWatcherLoop(t *testing.T) {
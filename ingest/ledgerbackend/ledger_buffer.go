@@ -3,12 +3,15 @@ package ledgerbackend
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/support/collections/heap"
 	"github.com/stellar/go/support/compressxdr"
@@ -18,7 +21,8 @@ import (
 
 type ledgerBatchObject struct {
 	payload     []byte
-	startLedger int // Ledger sequence used as the priority for the priorityqueue.
+	compressor  compressxdr.Compressor // Compressor matching the extension of the downloaded file.
+	startLedger int                    // Ledger sequence used as the priority for the priorityqueue.
 }
 
 type ledgerBuffer struct {
@@ -37,7 +41,7 @@ type ledgerBuffer struct {
 	// the number of tasks (both pending and in-flight) + len(ledgerQueue) + ledgerPriorityQueue.Len()
 	// is always less than or equal to the config.BufferSize
 	taskQueue           chan uint32                   // Buffer next object read
-	ledgerQueue         chan []byte                   // Order corrected lcm batches
+	ledgerQueue         chan ledgerBatchObject        // Order corrected lcm batches
 	ledgerPriorityQueue *heap.Heap[ledgerBatchObject] // Priority is set to the sequence number
 	priorityQueueLock   sync.Mutex
 
@@ -47,6 +51,12 @@ type ledgerBuffer struct {
 	nextTaskLedger    uint32 // The next task ledger that should be added to taskQueue
 	ledgerRange       Range
 	currentLedgerLock sync.RWMutex
+
+	// fileExtensions are the extensions ledger files are looked up with, in order.
+	// The first one is always the schema's file extension.
+	fileExtensions []string
+
+	checksumMismatches prometheus.Counter
 }
 
 func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBuffer, error) {
@@ -61,18 +71,38 @@ func (bsb *BufferedStorageBackend) newLedgerBuffer(ledgerRange Range) (*ledgerBu
 	}
 	pq := heap.New(less, int(bsb.config.BufferSize))
 
+	primary, err := bsb.schema.GetCompressor()
+	if err != nil {
+		cancel(err)
+		return nil, err
+	}
+	primaryExtension := bsb.schema.FileExtension
+	if primaryExtension == "" {
+		primaryExtension = primary.Name()
+	}
+	fileExtensions := []string{primaryExtension}
+	if bsb.config.MixedCompression {
+		for _, extension := range compressxdr.Extensions() {
+			if extension != primaryExtension {
+				fileExtensions = append(fileExtensions, extension)
+			}
+		}
+	}
+
 	ledgerBuffer := &ledgerBuffer{
 		config:              bsb.config,
 		dataStore:           bsb.dataStore,
 		schema:              bsb.schema,
 		taskQueue:           make(chan uint32, bsb.config.BufferSize),
-		ledgerQueue:         make(chan []byte, bsb.config.BufferSize),
+		ledgerQueue:         make(chan ledgerBatchObject, bsb.config.BufferSize),
 		ledgerPriorityQueue: pq,
 		currentLedger:       ledgerRange.from,
 		nextTaskLedger:      ledgerRange.from,
 		ledgerRange:         ledgerRange,
 		context:             ctx,
 		cancel:              cancel,
+		fileExtensions:      fileExtensions,
+		checksumMismatches:  bsb.checksumMismatches,
 	}
 
 	// Start workers to read LCM files
@@ -127,7 +157,7 @@ func (lb *ledgerBuffer) worker(ctx context.Context) {
 			return
 		case sequence := <-lb.taskQueue:
 			for attempt := uint32(0); attempt <= lb.config.RetryLimit; {
-				ledgerObject, err := lb.downloadLedgerObject(ctx, sequence)
+				ledgerObject, compressor, err := lb.downloadLedgerObject(ctx, sequence)
 				if err != nil {
 					if errors.Is(err, os.ErrNotExist) {
 						// ledgerObject not found and unbounded
@@ -161,32 +191,80 @@ func (lb *ledgerBuffer) worker(ctx context.Context) {
 				// Thus, the number of tasks decreases by 1 and the priority queue length increases by 1.
 				// This keeps the overall total the same (<= BufferSize). As long as the the ledger buffer invariant
 				// was maintained in the previous state, it is still maintained during this state transition.
-				lb.storeObject(ledgerObject, sequence)
+				lb.storeObject(ledgerObject, compressor, sequence)
 				break
 			}
 		}
 	}
 }
 
-func (lb *ledgerBuffer) downloadLedgerObject(ctx context.Context, sequence uint32) ([]byte, error) {
-	objectKey := lb.schema.GetObjectKeyFromSequenceNumber(sequence)
+func (lb *ledgerBuffer) downloadLedgerObject(ctx context.Context, sequence uint32) ([]byte, compressxdr.Compressor, error) {
+	var notFoundErr error
+	for _, extension := range lb.fileExtensions {
+		schema := lb.schema
+		schema.FileExtension = extension
+		objectKey := schema.GetObjectKeyFromSequenceNumber(sequence)
+
+		reader, err := lb.dataStore.GetFile(ctx, objectKey)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to retrieve file: %s", objectKey)
+			if errors.Is(err, os.ErrNotExist) {
+				if notFoundErr == nil {
+					notFoundErr = err
+				}
+				continue
+			}
+			return nil, nil, err
+		}
 
-	reader, err := lb.dataStore.GetFile(ctx, objectKey)
-	if err != nil {
-		return nil, errors.Wrapf(err, "unable to retrieve file: %s", objectKey)
+		objectBytes, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed reading file: %s", objectKey)
+		}
+
+		if lb.config.VerifyChecksums {
+			if err = lb.verifyChecksum(ctx, objectKey, objectBytes); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		compressor, err := schema.GetCompressor()
+		if err != nil {
+			return nil, nil, err
+		}
+		return objectBytes, compressor, nil
 	}
 
-	defer reader.Close()
+	return nil, nil, notFoundErr
+}
 
-	objectBytes, err := io.ReadAll(reader)
+// verifyChecksum compares the SHA-256 of a downloaded ledger file with the one
+// recorded in its object metadata, if any.
+func (lb *ledgerBuffer) verifyChecksum(ctx context.Context, objectKey string, objectBytes []byte) error {
+	metadataMap, err := lb.dataStore.GetFileMetadata(ctx, objectKey)
+	if err != nil {
+		return errors.Wrapf(err, "unable to retrieve metadata of file: %s", objectKey)
+	}
+	metaData, err := datastore.NewMetaDataFromMap(metadataMap)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed reading file: %s", objectKey)
+		return errors.Wrapf(err, "invalid metadata of file: %s", objectKey)
+	}
+	if metaData.ContentSHA256 == "" {
+		return nil
 	}
 
-	return objectBytes, nil
+	sum := sha256.Sum256(objectBytes)
+	if actual := hex.EncodeToString(sum[:]); actual != metaData.ContentSHA256 {
+		if lb.checksumMismatches != nil {
+			lb.checksumMismatches.Inc()
+		}
+		return &ChecksumMismatchError{ObjectKey: objectKey, Expected: metaData.ContentSHA256, Actual: actual}
+	}
+	return nil
 }
 
-func (lb *ledgerBuffer) storeObject(ledgerObject []byte, sequence uint32) {
+func (lb *ledgerBuffer) storeObject(ledgerObject []byte, compressor compressxdr.Compressor, sequence uint32) {
 	lb.priorityQueueLock.Lock()
 	defer lb.priorityQueueLock.Unlock()
 
@@ -195,6 +273,7 @@ func (lb *ledgerBuffer) storeObject(ledgerObject []byte, sequence uint32) {
 
 	lb.ledgerPriorityQueue.Push(ledgerBatchObject{
 		payload:     ledgerObject,
+		compressor:  compressor,
 		startLedger: int(sequence),
 	})
 
@@ -203,7 +282,7 @@ func (lb *ledgerBuffer) storeObject(ledgerObject []byte, sequence uint32) {
 	// Thus the overall sum of ledgerPriorityQueue.Len() + len(lb.ledgerQueue) remains the same.
 	for lb.ledgerPriorityQueue.Len() > 0 && lb.currentLedger == uint32(lb.ledgerPriorityQueue.Peek().startLedger) {
 		item := lb.ledgerPriorityQueue.Pop()
-		lb.ledgerQueue <- item.payload
+		lb.ledgerQueue <- item
 		lb.currentLedger += lb.schema.LedgersPerFile
 	}
 }
@@ -215,7 +294,7 @@ func (lb *ledgerBuffer) getFromLedgerQueue(ctx context.Context) (xdr.LedgerClose
 			return xdr.LedgerCloseMetaBatch{}, context.Cause(lb.context)
 		case <-ctx.Done():
 			return xdr.LedgerCloseMetaBatch{}, ctx.Err()
-		case compressedBinary := <-lb.ledgerQueue:
+		case ledgerObject := <-lb.ledgerQueue:
 			// The ledger buffer invariant is maintained here because
 			// we create an extra task when consuming one item from the ledger queue.
 			// Thus len(ledgerQueue) decreases by 1 and the number of tasks increases by 1.
@@ -224,8 +303,8 @@ func (lb *ledgerBuffer) getFromLedgerQueue(ctx context.Context) (xdr.LedgerClose
 			lb.pushTaskQueue()
 
 			lcmBatch := xdr.LedgerCloseMetaBatch{}
-			decoder := compressxdr.NewXDRDecoder(compressxdr.DefaultCompressor, &lcmBatch)
-			_, err := decoder.ReadFrom(bytes.NewReader(compressedBinary))
+			decoder := compressxdr.NewXDRDecoder(ledgerObject.compressor, &lcmBatch)
+			_, err := decoder.ReadFrom(bytes.NewReader(ledgerObject.payload))
 			if err != nil {
 				return xdr.LedgerCloseMetaBatch{}, err
 			}
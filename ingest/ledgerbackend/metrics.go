@@ -14,6 +14,12 @@ func WithMetrics(base LedgerBackend, registry *prometheus.Registry, namespace st
 	if captiveCoreBackend, ok := base.(*CaptiveStellarCore); ok {
 		captiveCoreBackend.registerMetrics(registry, namespace)
 	}
+	if bufferedStorageBackend, ok := base.(*BufferedStorageBackend); ok {
+		bufferedStorageBackend.registerMetrics(registry, namespace)
+	}
+	if failoverBackend, ok := base.(*FailoverBackend); ok {
+		failoverBackend.registerMetrics(registry, namespace)
+	}
 	summary := prometheus.NewSummary(
 		prometheus.SummaryOpts{
 			Namespace: namespace, Subsystem: "ingest", Name: "ledger_fetch_duration_seconds",
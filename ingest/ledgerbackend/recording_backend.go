@@ -0,0 +1,247 @@
+package ledgerbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+// Ensure RecordingBackend implements LedgerBackend
+var _ LedgerBackend = (*RecordingBackend)(nil)
+
+type RecordingBackendConfig struct {
+	// Required, configures the manifest of the datastore ledgers are recorded
+	// to, see datastore.PublishConfig. The schema and compression must match
+	// the manifest if the datastore already has one.
+	DataStoreConfig datastore.DataStoreConfig
+	// Optional, the stellar-core version recorded in the metadata of files
+	CoreVersion string
+	// Optional, the version of the recording application recorded in the
+	// metadata of files
+	Version string
+	// Optional, when true errors uploading a file are logged and the file is
+	// skipped instead of failing GetLedger
+	IgnoreUploadErrors bool
+	// Optional, the number of complete files waiting to be uploaded before
+	// GetLedger blocks, defaults to 2
+	UploadQueueSize int
+	// Optional, if nil uses go default logger
+	Log *log.Entry
+}
+
+// RecordingBackend is a ledger backend wrapping another backend, which
+// records every ledger returned by GetLedger to a datastore in the galexie
+// file layout. The datastore can then be read by a BufferedStorageBackend, so
+// ledgers ingested once from captive core can be replayed without catchup.
+//
+// Ledgers are uploaded in the background once all the ledgers of their file
+// were returned, files which already exist are not overwritten. GetLedger only
+// blocks while the upload queue is full, and returns the error of a failed
+// upload on the following calls. Close waits for the queued files to be
+// uploaded. Files are only recorded from their first ledger, so the ledgers
+// preceding the first file boundary of a range, and those of an incomplete
+// file when the backend is closed, are not recorded.
+type RecordingBackend struct {
+	LedgerBackend
+
+	config     RecordingBackendConfig
+	dataStore  datastore.DataStore
+	schema     datastore.DataStoreSchema
+	compressor compressxdr.Compressor
+	lock       sync.Mutex
+	// batch holds the ledgers of the file being recorded, nil while waiting
+	// for the first ledger of a file
+	batch  *xdr.LedgerCloseMetaBatch
+	closed bool
+
+	// uploads are the complete files waiting to be uploaded by the upload
+	// goroutine, which closes uploadsDone once they are all uploaded
+	uploads       chan *xdr.LedgerCloseMetaBatch
+	uploadsDone   chan struct{}
+	cancelUploads context.CancelFunc
+	// uploadErr is the error of the first failed upload, uploads stop after
+	// it unless upload errors are ignored
+	uploadErr     error
+	uploadErrLock sync.Mutex
+}
+
+// NewRecordingBackend returns a RecordingBackend recording the ledgers of base
+// to dataStore. The manifest of the datastore is published if it doesn't
+// exist yet.
+func NewRecordingBackend(ctx context.Context, base LedgerBackend, dataStore datastore.DataStore, config RecordingBackendConfig) (*RecordingBackend, error) {
+	if config.Log == nil {
+		config.Log = log.DefaultLogger
+	}
+	if _, _, err := datastore.PublishConfig(ctx, dataStore, config.DataStoreConfig); err != nil {
+		return nil, errors.Wrap(err, "could not configure datastore")
+	}
+	schema, err := datastore.LoadSchema(ctx, dataStore, config.DataStoreConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve datastore schema")
+	}
+	compressor, err := schema.GetCompressor()
+	if err != nil {
+		return nil, err
+	}
+	if config.UploadQueueSize <= 0 {
+		config.UploadQueueSize = 2
+	}
+
+	uploadCtx, cancelUploads := context.WithCancel(context.Background())
+	r := &RecordingBackend{
+		LedgerBackend: base,
+		config:        config,
+		dataStore:     dataStore,
+		schema:        schema,
+		compressor:    compressor,
+		uploads:       make(chan *xdr.LedgerCloseMetaBatch, config.UploadQueueSize),
+		uploadsDone:   make(chan struct{}),
+		cancelUploads: cancelUploads,
+	}
+	go r.runUploads(uploadCtx)
+	return r, nil
+}
+
+// GetLedger returns the ledger from the wrapped backend, after recording it.
+func (r *RecordingBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	lcm, err := r.LedgerBackend.GetLedger(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+
+	if err = r.getUploadErr(); err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if err = r.record(ctx, lcm); err != nil {
+		if !r.config.IgnoreUploadErrors {
+			return xdr.LedgerCloseMeta{}, err
+		}
+		r.config.Log.WithError(err).Warnf("Failed to record ledger %d", sequence)
+		r.batch = nil
+	}
+	return lcm, nil
+}
+
+func (r *RecordingBackend) record(ctx context.Context, lcm xdr.LedgerCloseMeta) error {
+	sequence := lcm.LedgerSequence()
+	if r.batch != nil && len(r.batch.LedgerCloseMetas) > 0 {
+		last := r.batch.LedgerCloseMetas[len(r.batch.LedgerCloseMetas)-1].LedgerSequence()
+		if sequence == last {
+			// the same ledger was requested again
+			return nil
+		}
+		if sequence != last+1 {
+			r.config.Log.Warnf("Ledger %d does not follow ledger %d, discarding ledgers %d-%d",
+				sequence, last, r.batch.StartSequence, last)
+			r.batch = nil
+		}
+	}
+
+	if r.batch == nil {
+		// ledger 2 is the first ledger of the network, the first file starts with it
+		fileStart := max(2, r.schema.GetSequenceNumberStartBoundary(sequence))
+		if sequence != fileStart {
+			return nil
+		}
+		r.batch = &xdr.LedgerCloseMetaBatch{
+			StartSequence: xdr.Uint32(sequence),
+			EndSequence:   xdr.Uint32(r.schema.GetSequenceNumberEndBoundary(sequence)),
+		}
+	}
+
+	if err := r.batch.AddLedger(lcm); err != nil {
+		return err
+	}
+	if sequence != uint32(r.batch.EndSequence) {
+		return nil
+	}
+	batch := r.batch
+	r.batch = nil
+	if r.closed {
+		return errors.New("recording backend is closed")
+	}
+	select {
+	case r.uploads <- batch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runUploads uploads the queued files until the queue is closed.
+func (r *RecordingBackend) runUploads(ctx context.Context) {
+	defer close(r.uploadsDone)
+	for batch := range r.uploads {
+		if r.getUploadErr() != nil {
+			// the remaining files are discarded
+			continue
+		}
+		if err := r.upload(ctx, batch); err != nil {
+			if r.config.IgnoreUploadErrors {
+				r.config.Log.WithError(err).Warnf("Failed to record ledgers %d-%d",
+					batch.StartSequence, batch.EndSequence)
+				continue
+			}
+			r.uploadErrLock.Lock()
+			r.uploadErr = err
+			r.uploadErrLock.Unlock()
+		}
+	}
+}
+
+func (r *RecordingBackend) getUploadErr() error {
+	r.uploadErrLock.Lock()
+	defer r.uploadErrLock.Unlock()
+	return r.uploadErr
+}
+
+func (r *RecordingBackend) upload(ctx context.Context, batch *xdr.LedgerCloseMetaBatch) error {
+	objectKey := r.schema.GetObjectKeyFromSequenceNumber(uint32(batch.StartSequence))
+	metaData, err := datastore.NewLedgerBatchMetaData(
+		*batch, r.config.DataStoreConfig.NetworkPassphrase, r.compressor, r.config.CoreVersion, r.config.Version,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "error creating metadata of %s", objectKey)
+	}
+	upload, err := datastore.UploadLedgerBatch(ctx, r.dataStore, objectKey, batch, r.compressor, metaData, false)
+	if err != nil {
+		return err
+	}
+	if upload.Uploaded {
+		r.config.Log.Debugf("Recorded ledgers %d-%d to %s", metaData.StartLedger, metaData.EndLedger, objectKey)
+	}
+	return nil
+}
+
+// Close waits for the queued files to be uploaded and closes the wrapped
+// backend, the ledgers of an incomplete file are not recorded. The error of a
+// failed upload is returned unless upload errors are ignored.
+func (r *RecordingBackend) Close() error {
+	r.lock.Lock()
+	if r.batch != nil && len(r.batch.LedgerCloseMetas) > 0 {
+		r.config.Log.Infof("Discarding ledgers %d-%d of incomplete file",
+			r.batch.StartSequence, r.batch.LedgerCloseMetas[len(r.batch.LedgerCloseMetas)-1].LedgerSequence())
+	}
+	r.batch = nil
+	if !r.closed {
+		r.closed = true
+		close(r.uploads)
+	}
+	r.lock.Unlock()
+
+	<-r.uploadsDone
+	r.cancelUploads()
+	if err := r.LedgerBackend.Close(); err != nil {
+		return err
+	}
+	return r.getUploadErr()
+}
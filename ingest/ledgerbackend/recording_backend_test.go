@@ -0,0 +1,182 @@
+package ledgerbackend
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/datastore"
+)
+
+func TestRecordingBackend(t *testing.T) {
+	ctx := context.Background()
+	config := datastore.DataStoreConfig{
+		Type:              "Filesystem",
+		Params:            map[string]string{"destination_path": t.TempDir()},
+		Schema:            datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: 2},
+		NetworkPassphrase: "passphrase",
+		Compression:       "zstd",
+	}
+	dataStore, err := datastore.NewDataStore(ctx, config)
+	require.NoError(t, err)
+
+	base := &MockDatabaseBackend{}
+	base.On("PrepareRange", ctx, UnboundedRange(3)).Return(nil).Once()
+	for seq := uint32(3); seq <= 18; seq++ {
+		if seq != 12 && seq != 13 {
+			base.On("GetLedger", ctx, seq).Return(createChainedLedgerCloseMeta(seq), nil)
+		}
+	}
+	base.On("Close").Return(nil).Once()
+	t.Cleanup(func() {
+		base.AssertExpectations(t)
+	})
+
+	backend, err := NewRecordingBackend(ctx, base, dataStore, RecordingBackendConfig{
+		DataStoreConfig: config,
+		CoreVersion:     "v23.0.0",
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(3)))
+	for seq := uint32(3); seq <= 18; seq++ {
+		// ledger 9 is requested twice and ledgers 12 and 13 are skipped
+		switch seq {
+		case 9:
+			lcm, err := backend.GetLedger(ctx, seq)
+			require.NoError(t, err)
+			require.Equal(t, seq, lcm.LedgerSequence())
+		case 12, 13:
+			continue
+		}
+		lcm, err := backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, seq, lcm.LedgerSequence())
+	}
+	require.NoError(t, backend.Close())
+
+	// ledger 3 precedes the first file boundary, the files of ledgers 12-15
+	// and 16-19 are incomplete
+	for seq, expected := range map[uint32]bool{3: false, 4: true, 8: true, 12: false, 16: false} {
+		exists, err := dataStore.Exists(ctx, backend.schema.GetObjectKeyFromSequenceNumber(seq))
+		require.NoError(t, err)
+		require.Equal(t, expected, exists, "ledger %d", seq)
+	}
+
+	metaData, err := dataStore.GetFileMetadata(ctx, backend.schema.GetObjectKeyFromSequenceNumber(8))
+	require.NoError(t, err)
+	parsed, err := datastore.NewMetaDataFromMap(metaData)
+	require.NoError(t, err)
+	require.Equal(t, uint32(8), parsed.StartLedger)
+	require.Equal(t, uint32(11), parsed.EndLedger)
+	require.Equal(t, "v23.0.0", parsed.CoreVersion)
+	require.Equal(t, failoverTestHash(11).HexString(), parsed.EndLedgerHash)
+	require.NotEmpty(t, parsed.ContentSHA256)
+
+	// the recorded ledgers can be replayed
+	schema, err := datastore.LoadSchema(ctx, dataStore, config)
+	require.NoError(t, err)
+	replay, err := NewBufferedStorageBackend(BufferedStorageBackendConfig{
+		BufferSize:      2,
+		NumWorkers:      1,
+		RetryLimit:      0,
+		VerifyChecksums: true,
+	}, dataStore, schema)
+	require.NoError(t, err)
+	require.NoError(t, replay.PrepareRange(ctx, BoundedRange(4, 11)))
+	for seq := uint32(4); seq <= 11; seq++ {
+		lcm, err := replay.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, failoverTestHash(seq), lcm.LedgerHash())
+	}
+	require.NoError(t, replay.Close())
+}
+
+// interceptingDataStore blocks the uploads of files until release is closed,
+// and fails them with err if set.
+type interceptingDataStore struct {
+	datastore.DataStore
+	release chan struct{}
+	err     error
+}
+
+func (d *interceptingDataStore) PutFileIfNotExists(ctx context.Context, path string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	if d.release != nil {
+		<-d.release
+	}
+	if d.err != nil {
+		return false, d.err
+	}
+	return d.DataStore.PutFileIfNotExists(ctx, path, in, metaData)
+}
+
+func newInterceptedRecordingBackend(t *testing.T, last uint32) (*RecordingBackend, *interceptingDataStore) {
+	ctx := context.Background()
+	config := datastore.DataStoreConfig{
+		Type:              "Filesystem",
+		Params:            map[string]string{"destination_path": t.TempDir()},
+		Schema:            datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: 2},
+		NetworkPassphrase: "passphrase",
+		Compression:       "zstd",
+	}
+	fileStore, err := datastore.NewDataStore(ctx, config)
+	require.NoError(t, err)
+	dataStore := &interceptingDataStore{DataStore: fileStore}
+
+	base := &MockDatabaseBackend{}
+	for seq := uint32(4); seq <= last; seq++ {
+		base.On("GetLedger", ctx, seq).Return(createChainedLedgerCloseMeta(seq), nil)
+	}
+	base.On("Close").Return(nil).Once()
+
+	backend, err := NewRecordingBackend(ctx, base, dataStore, RecordingBackendConfig{
+		DataStoreConfig: config,
+		UploadQueueSize: 2,
+	})
+	require.NoError(t, err)
+	return backend, dataStore
+}
+
+func TestRecordingBackendUploadsInBackground(t *testing.T) {
+	ctx := context.Background()
+	backend, dataStore := newInterceptedRecordingBackend(t, 15)
+	dataStore.release = make(chan struct{})
+
+	// the files of ledgers 4-7, 8-11 and 12-15 are queued while the first
+	// upload is blocked
+	for seq := uint32(4); seq <= 15; seq++ {
+		_, err := backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+	}
+	exists, err := dataStore.Exists(ctx, backend.schema.GetObjectKeyFromSequenceNumber(4))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	close(dataStore.release)
+	require.NoError(t, backend.Close())
+	for _, seq := range []uint32{4, 8, 12} {
+		exists, err = dataStore.Exists(ctx, backend.schema.GetObjectKeyFromSequenceNumber(seq))
+		require.NoError(t, err)
+		require.True(t, exists, "ledger %d", seq)
+	}
+}
+
+func TestRecordingBackendUploadError(t *testing.T) {
+	ctx := context.Background()
+	backend, dataStore := newInterceptedRecordingBackend(t, 8)
+	dataStore.err = errors.New("transient error")
+
+	for seq := uint32(4); seq <= 7; seq++ {
+		_, err := backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+	}
+	expected := "error uploading " + backend.schema.GetObjectKeyFromSequenceNumber(4) + ": transient error"
+	require.Eventually(t, func() bool {
+		_, err := backend.GetLedger(ctx, 8)
+		return err != nil && err.Error() == expected
+	}, 5*time.Second, 10*time.Millisecond)
+	require.EqualError(t, backend.Close(), expected)
+}
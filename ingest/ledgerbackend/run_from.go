@@ -12,26 +12,40 @@ import (
 	"github.com/stellar/go/support/log"
 )
 
+// captiveCoreDBMetrics counts how captive core is started on unbounded
+// ranges, either with a new buckets db, which requires a catchup, or reusing
+// the db left in the storage path by a previous run.
+type captiveCoreDBMetrics struct {
+	newDBCounter prometheus.Counter
+	// reusedDBCounter and replayedLedgersCounter measure the catchups skipped,
+	// the ledgers replayed are the ledgers between the last ledger of the
+	// reused db and the start of the range.
+	reusedDBCounter        prometheus.Counter
+	replayedLedgersCounter prometheus.Counter
+}
+
 type runFromStream struct {
-	dir                     workingDir
-	from                    uint32
-	coreCmdFactory          coreCmdFactory
-	log                     *log.Entry
-	captiveCoreNewDBCounter prometheus.Counter
+	dir               workingDir
+	from              uint32
+	networkPassphrase string
+	coreCmdFactory    coreCmdFactory
+	log               *log.Entry
+	dbMetrics         *captiveCoreDBMetrics
 }
 
-func newRunFromStream(r *stellarCoreRunner, from uint32, captiveCoreNewDBCounter prometheus.Counter) runFromStream {
+func newRunFromStream(r *stellarCoreRunner, from uint32, dbMetrics *captiveCoreDBMetrics) runFromStream {
 	// We only use ephemeral directories on windows because there is
 	// no way to terminate captive core gracefully on windows.
 	// Having an ephemeral directory ensures that it is wiped out
 	// whenever we terminate captive core
 	dir := newWorkingDir(r, runtime.GOOS == "windows")
 	return runFromStream{
-		dir:                     dir,
-		from:                    from,
-		coreCmdFactory:          newCoreCmdFactory(r, dir),
-		log:                     r.log,
-		captiveCoreNewDBCounter: captiveCoreNewDBCounter,
+		dir:               dir,
+		from:              from,
+		networkPassphrase: r.networkPassphrase,
+		coreCmdFactory:    newCoreCmdFactory(r, dir),
+		log:               r.log,
+		dbMetrics:         dbMetrics,
 	}
 }
 
@@ -66,21 +80,32 @@ func (s runFromStream) start(ctx context.Context) (cmd cmdI, captiveCorePipe pip
 		}
 	}()
 
-	// Check if on-disk core DB exists and what's the LCL there. If not what
-	// we need remove storage dir and start from scratch.
+	// Check if on-disk core DB exists, which network it belongs to and what's
+	// the LCL there. If not what we need remove storage dir and start from
+	// scratch.
 	var info stellarcore.InfoResponse
 	info, err = s.offlineInfo(ctx)
 	if err != nil {
 		s.log.Infof("Error running offline-info: %v, removing existing storage-dir contents", err)
 		createNewDB = true
+	} else if s.networkPassphrase != "" && info.Info.Network != s.networkPassphrase {
+		s.log.Infof("Unexpected network in Stellar-Core DB: %q (want: %q), removing existing storage-dir contents", info.Info.Network, s.networkPassphrase)
+		createNewDB = true
 	} else if info.Info.Ledger.Num <= 1 || uint32(info.Info.Ledger.Num) > s.from {
 		s.log.Infof("Unexpected LCL in Stellar-Core DB: %d (want: %d), removing existing storage-dir contents", info.Info.Ledger.Num, s.from)
 		createNewDB = true
 	}
 
-	if createNewDB {
-		if s.captiveCoreNewDBCounter != nil {
-			s.captiveCoreNewDBCounter.Inc()
+	if !createNewDB {
+		replayed := max(int(s.from)-1-info.Info.Ledger.Num, 0)
+		s.log.Infof("Reusing Stellar-Core DB with LCL %d, skipping catchup and replaying %d ledgers", info.Info.Ledger.Num, replayed)
+		if s.dbMetrics != nil {
+			s.dbMetrics.reusedDBCounter.Inc()
+			s.dbMetrics.replayedLedgersCounter.Add(float64(replayed))
+		}
+	} else {
+		if s.dbMetrics != nil {
+			s.dbMetrics.newDBCounter.Inc()
 		}
 		if err = s.dir.remove(); err != nil {
 			return nil, pipe{}, fmt.Errorf("error removing existing storage-dir contents: %w", err)
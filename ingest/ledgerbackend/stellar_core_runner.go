@@ -7,8 +7,6 @@ import (
 	"math/rand"
 	"sync"
 
-	"github.com/prometheus/client_golang/prometheus"
-
 	"github.com/stellar/go/support/log"
 )
 
@@ -65,10 +63,11 @@ type stellarCoreRunner struct {
 
 	closeOnce sync.Once
 
-	storagePath string
-	toml        *CaptiveCoreToml
+	storagePath       string
+	networkPassphrase string
+	toml              *CaptiveCoreToml
 
-	captiveCoreNewDBCounter prometheus.Counter
+	dbMetrics *captiveCoreDBMetrics
 
 	log *log.Entry
 }
@@ -82,19 +81,20 @@ func createRandomHexString(n int) string {
 	return string(b)
 }
 
-func newStellarCoreRunner(config CaptiveCoreConfig, captiveCoreNewDBCounter prometheus.Counter) *stellarCoreRunner {
+func newStellarCoreRunner(config CaptiveCoreConfig, dbMetrics *captiveCoreDBMetrics) *stellarCoreRunner {
 	ctx, cancel := context.WithCancel(config.Context)
 
 	runner := &stellarCoreRunner{
-		executablePath: config.BinaryPath,
-		ctx:            ctx,
-		cancel:         cancel,
-		storagePath:    config.StoragePath,
-		log:            config.Log,
-		toml:           config.Toml,
-
-		captiveCoreNewDBCounter: captiveCoreNewDBCounter,
-		systemCaller:            realSystemCaller{},
+		executablePath:    config.BinaryPath,
+		ctx:               ctx,
+		cancel:            cancel,
+		storagePath:       config.StoragePath,
+		networkPassphrase: config.NetworkPassphrase,
+		log:               config.Log,
+		toml:              config.Toml,
+
+		dbMetrics:    dbMetrics,
+		systemCaller: realSystemCaller{},
 	}
 
 	return runner
@@ -107,7 +107,7 @@ func (r *stellarCoreRunner) context() context.Context {
 
 // runFrom executes the run command with a starting ledger on the captive core subprocess
 func (r *stellarCoreRunner) runFrom(from uint32) error {
-	return r.startMetaStream(newRunFromStream(r, from, r.captiveCoreNewDBCounter))
+	return r.startMetaStream(newRunFromStream(r, from, r.dbMetrics))
 }
 
 // catchup executes the catchup command on the captive core subprocess
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/stellar/go/network"
 	"github.com/stellar/go/protocols/stellarcore"
 	"github.com/stellar/go/support/errors"
 	"github.com/stellar/go/support/log"
@@ -262,13 +263,15 @@ func TestRunFromUseDBLedgersMatch(t *testing.T) {
 		Context:            context.Background(),
 		Toml:               captiveCoreToml,
 		StoragePath:        "/tmp/captive-core",
-	}, createNewDBCounter())
+		NetworkPassphrase:  network.PublicNetworkPassphrase,
+	}, createDBMetrics())
 
 	cmdMock := simpleCommandMock()
 	cmdMock.On("Wait").Return(nil)
 
 	offlineInfoCmdMock := simpleCommandMock()
 	infoResponse := stellarcore.InfoResponse{}
+	infoResponse.Info.Network = network.PublicNetworkPassphrase
 	infoResponse.Info.Ledger.Num = 100
 	infoResponseBytes, err := json.Marshal(infoResponse)
 	assert.NoError(t, err)
@@ -303,7 +306,9 @@ func TestRunFromUseDBLedgersMatch(t *testing.T) {
 	assert.NoError(t, runner.runFrom(100))
 	assert.NoError(t, runner.close())
 
-	assert.Equal(t, float64(0), getNewDBCounterMetric(runner))
+	assert.Equal(t, float64(0), getCounterMetric(runner.dbMetrics.newDBCounter))
+	assert.Equal(t, float64(1), getCounterMetric(runner.dbMetrics.reusedDBCounter))
+	assert.Equal(t, float64(0), getCounterMetric(runner.dbMetrics.replayedLedgersCounter))
 }
 
 func TestRunFromUseDBLedgersBehind(t *testing.T) {
@@ -319,7 +324,7 @@ func TestRunFromUseDBLedgersBehind(t *testing.T) {
 		Context:            context.Background(),
 		Toml:               captiveCoreToml,
 		StoragePath:        "/tmp/captive-core",
-	}, createNewDBCounter())
+	}, createDBMetrics())
 
 	newDBCmdMock := simpleCommandMock()
 	newDBCmdMock.On("Run").Return(nil)
@@ -366,18 +371,28 @@ func TestRunFromUseDBLedgersBehind(t *testing.T) {
 	assert.NoError(t, runner.runFrom(100))
 	assert.NoError(t, runner.close())
 
-	assert.Equal(t, float64(0), getNewDBCounterMetric(runner))
+	assert.Equal(t, float64(0), getCounterMetric(runner.dbMetrics.newDBCounter))
+	assert.Equal(t, float64(1), getCounterMetric(runner.dbMetrics.reusedDBCounter))
+	assert.Equal(t, float64(9), getCounterMetric(runner.dbMetrics.replayedLedgersCounter))
 }
 
-func createNewDBCounter() prometheus.Counter {
-	return prometheus.NewCounter(prometheus.CounterOpts{
-		Namespace: "test", Subsystem: "captive_core", Name: "new_db_counter",
-	})
+func createDBMetrics() *captiveCoreDBMetrics {
+	return &captiveCoreDBMetrics{
+		newDBCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "test", Subsystem: "captive_core", Name: "new_db_counter",
+		}),
+		reusedDBCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "test", Subsystem: "captive_core", Name: "reused_db_counter",
+		}),
+		replayedLedgersCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "test", Subsystem: "captive_core", Name: "reused_db_replayed_ledgers",
+		}),
+	}
 }
 
-func getNewDBCounterMetric(runner *stellarCoreRunner) float64 {
+func getCounterMetric(counter prometheus.Counter) float64 {
 	value := &dto.Metric{}
-	err := runner.captiveCoreNewDBCounter.Write(value)
+	err := counter.Write(value)
 	if err != nil {
 		panic(err)
 	}
@@ -397,7 +412,7 @@ func TestRunFromUseDBLedgersInFront(t *testing.T) {
 		Context:            context.Background(),
 		Toml:               captiveCoreToml,
 		StoragePath:        "/tmp/captive-core",
-	}, createNewDBCounter())
+	}, createDBMetrics())
 
 	newDBCmdMock := simpleCommandMock()
 	newDBCmdMock.On("Run").Return(nil)
@@ -461,5 +476,89 @@ func TestRunFromUseDBLedgersInFront(t *testing.T) {
 
 	assert.NoError(t, runner.runFrom(100))
 	assert.NoError(t, runner.close())
-	assert.Equal(t, float64(1), getNewDBCounterMetric(runner))
+	assert.Equal(t, float64(1), getCounterMetric(runner.dbMetrics.newDBCounter))
+	assert.Equal(t, float64(0), getCounterMetric(runner.dbMetrics.reusedDBCounter))
+}
+
+func TestRunFromUseDBOtherNetwork(t *testing.T) {
+	captiveCoreToml, err := NewCaptiveCoreToml(CaptiveCoreTomlParams{})
+	assert.NoError(t, err)
+
+	captiveCoreToml.AddExamplePubnetValidators()
+
+	runner := newStellarCoreRunner(CaptiveCoreConfig{
+		BinaryPath:         "/usr/bin/stellar-core",
+		HistoryArchiveURLs: []string{"http://localhost"},
+		Log:                log.New(),
+		Context:            context.Background(),
+		Toml:               captiveCoreToml,
+		StoragePath:        "/tmp/captive-core",
+		NetworkPassphrase:  network.PublicNetworkPassphrase,
+	}, createDBMetrics())
+
+	newDBCmdMock := simpleCommandMock()
+	newDBCmdMock.On("Run").Return(nil)
+
+	catchupCmdMock := simpleCommandMock()
+	catchupCmdMock.On("Run").Return(nil)
+
+	cmdMock := simpleCommandMock()
+	cmdMock.On("Wait").Return(nil)
+
+	offlineInfoCmdMock := simpleCommandMock()
+	infoResponse := stellarcore.InfoResponse{}
+	infoResponse.Info.Network = network.TestNetworkPassphrase
+	infoResponse.Info.Ledger.Num = 90
+	infoResponseBytes, err := json.Marshal(infoResponse)
+	assert.NoError(t, err)
+	offlineInfoCmdMock.On("Output").Return(infoResponseBytes, nil)
+	offlineInfoCmdMock.On("Wait").Return(nil)
+
+	// Replace system calls with a mock
+	scMock := &mockSystemCaller{}
+	defer scMock.AssertExpectations(t)
+	// Storage dir is removed because networks do not match
+	scMock.On("removeAll", mock.Anything).Return(nil).Once()
+	scMock.On("stat", mock.Anything).Return(isDirImpl(true), nil)
+	scMock.On("writeFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	scMock.On("command",
+		runner.ctx,
+		"/usr/bin/stellar-core",
+		"--conf",
+		mock.Anything,
+		"offline-info",
+	).Return(offlineInfoCmdMock)
+	scMock.On("command",
+		runner.ctx,
+		"/usr/bin/stellar-core",
+		"--conf",
+		mock.Anything,
+		"--console",
+		"new-db",
+	).Return(newDBCmdMock)
+	scMock.On("command",
+		runner.ctx,
+		"/usr/bin/stellar-core",
+		"--conf",
+		mock.Anything,
+		"--console",
+		"catchup",
+		"99/0",
+	).Return(catchupCmdMock)
+	scMock.On("command",
+		runner.ctx,
+		"/usr/bin/stellar-core",
+		"--conf",
+		mock.Anything,
+		"--console",
+		"run",
+		"--metadata-output-stream",
+		"fd:3",
+	).Return(cmdMock)
+	runner.systemCaller = scMock
+
+	assert.NoError(t, runner.runFrom(100))
+	assert.NoError(t, runner.close())
+	assert.Equal(t, float64(1), getCounterMetric(runner.dbMetrics.newDBCounter))
+	assert.Equal(t, float64(0), getCounterMetric(runner.dbMetrics.reusedDBCounter))
 }
@@ -0,0 +1,256 @@
+package ledgerbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/xdr"
+)
+
+// Ensure SyntheticBackend implements LedgerBackend
+var _ LedgerBackend = (*SyntheticBackend)(nil)
+
+const (
+	syntheticDefaultProtocolVersion       = 23
+	syntheticDefaultCloseInterval         = 5 * time.Second
+	syntheticDefaultAccounts              = 10
+	syntheticDefaultTransactionsPerLedger = 10
+	syntheticMaxAssets                    = 10
+	syntheticMaxOperationsPerTransaction  = 100
+)
+
+// syntheticDefaultStartCloseTime is the close time of the first ledger when
+// not configured, a fixed time so ledgers don't depend on when they are
+// generated.
+var syntheticDefaultStartCloseTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SyntheticScenario declares the ledgers generated by a SyntheticBackend.
+//
+// The first ledgers set the scenario up: the root account of the network
+// creates an issuer for every asset and the accounts, which trust every asset
+// and are funded by the issuers. The following ledgers hold
+// TransactionsPerLedger transactions of random accounts, drawn according to
+// the Payments, Offers and ContractInvocations weights.
+type SyntheticScenario struct {
+	// Required, the passphrase of the network the transactions are hashed for
+	NetworkPassphrase string
+	// Optional, seed of the generator. A scenario generates the same ledgers
+	// for a given seed.
+	Seed int64
+	// Optional, protocol version of the ledgers, defaults to 23. Protocols
+	// prior to 23 are not supported.
+	ProtocolVersion uint32
+	// Optional, close time of ledger 2, defaults to 2025-01-01T00:00:00Z
+	StartCloseTime time.Time
+	// Optional, time between the close of consecutive ledgers, defaults to
+	// 5 seconds
+	CloseInterval time.Duration
+
+	// Optional, number of accounts transacting, defaults to 10
+	Accounts int
+	// Optional, number of credit assets, at most 10. Every asset has its own
+	// issuer and is trusted by every account.
+	Assets int
+
+	// Optional, number of transactions per ledger, defaults to 10
+	TransactionsPerLedger int
+	// Optional, number of operations of classic transactions, defaults to 1.
+	// Transactions invoking contracts always have a single operation.
+	OperationsPerTransaction int
+	// Optional, relative weight of payments between accounts. Payments are the
+	// only operations if no weight is set.
+	Payments int
+	// Optional, relative weight of sell offers, which cross the offers of
+	// other accounts at a price of 1 and rest on the order book otherwise.
+	// Requires at least one asset.
+	Offers int
+	// Optional, relative weight of transactions invoking the transfer
+	// function of Stellar Asset Contracts, emitting SAC transfer events
+	ContractInvocations int
+	// Optional, fraction of the transactions wrapped in a fee bump
+	// transaction paid by another account, between 0 and 1
+	FeeBumpRatio float64
+	// Optional, when true fees and classic operations emit CAP-67 unified
+	// events, as stellar-core does when EMIT_CLASSIC_EVENTS is enabled
+	UnifiedEvents bool
+}
+
+func (s *SyntheticScenario) setDefaults() error {
+	if s.NetworkPassphrase == "" {
+		return errors.New("network passphrase is required")
+	}
+	if s.ProtocolVersion == 0 {
+		s.ProtocolVersion = syntheticDefaultProtocolVersion
+	}
+	if s.ProtocolVersion < syntheticDefaultProtocolVersion {
+		return fmt.Errorf("protocol version %d is not supported, must be at least %d",
+			s.ProtocolVersion, syntheticDefaultProtocolVersion)
+	}
+	if s.StartCloseTime.IsZero() {
+		s.StartCloseTime = syntheticDefaultStartCloseTime
+	}
+	if s.CloseInterval == 0 {
+		s.CloseInterval = syntheticDefaultCloseInterval
+	}
+	if s.Accounts == 0 {
+		s.Accounts = syntheticDefaultAccounts
+	}
+	if s.TransactionsPerLedger == 0 {
+		s.TransactionsPerLedger = syntheticDefaultTransactionsPerLedger
+	}
+	if s.OperationsPerTransaction == 0 {
+		s.OperationsPerTransaction = 1
+	}
+	if s.Payments == 0 && s.Offers == 0 && s.ContractInvocations == 0 {
+		s.Payments = 1
+	}
+
+	switch {
+	case s.Accounts < 2:
+		return errors.New("at least 2 accounts are required")
+	case s.Assets < 0 || s.Assets > syntheticMaxAssets:
+		return fmt.Errorf("assets must be between 0 and %d", syntheticMaxAssets)
+	case s.TransactionsPerLedger < 0:
+		return errors.New("transactions per ledger must be positive")
+	case s.OperationsPerTransaction < 0 || s.OperationsPerTransaction > syntheticMaxOperationsPerTransaction:
+		return fmt.Errorf("operations per transaction must be between 1 and %d", syntheticMaxOperationsPerTransaction)
+	case s.Payments < 0 || s.Offers < 0 || s.ContractInvocations < 0:
+		return errors.New("operation weights must be positive")
+	case s.Offers > 0 && s.Assets == 0:
+		return errors.New("offers require at least one asset")
+	case s.FeeBumpRatio < 0 || s.FeeBumpRatio > 1:
+		return errors.New("fee bump ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// SyntheticBackend is a ledger backend generating ledgers from a
+// SyntheticScenario instead of reading them from a network. Ledgers are valid
+// LedgerCloseMeta: ledger headers are hash chained, transaction hashes match
+// their envelopes and ledger entry changes are consistent with the
+// transactions, so they can be ingested by processors at any scale without
+// running stellar-core.
+//
+// Ledgers only depend on the scenario and their sequence, preparing a range
+// starting after ledger 2 generates the ledgers preceding it first.
+type SyntheticBackend struct {
+	scenario      SyntheticScenario
+	lock          sync.Mutex
+	generator     *syntheticGenerator
+	preparedRange *Range
+	closed        bool
+}
+
+// NewSyntheticBackend returns a new SyntheticBackend generating the ledgers of
+// the given scenario.
+func NewSyntheticBackend(scenario SyntheticScenario) (*SyntheticBackend, error) {
+	if err := scenario.setDefaults(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	generator, err := newSyntheticGenerator(scenario)
+	if err != nil {
+		return nil, err
+	}
+	return &SyntheticBackend{scenario: scenario, generator: generator}, nil
+}
+
+// GetLatestLedgerSequence returns the end of the prepared range if it is
+// bounded, or the latest ledger generated otherwise.
+func (s *SyntheticBackend) GetLatestLedgerSequence(ctx context.Context) (uint32, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return 0, errors.New("SyntheticBackend is closed")
+	}
+	if s.preparedRange == nil {
+		return 0, errors.New("SyntheticBackend must be prepared before calling GetLatestLedgerSequence")
+	}
+	if s.preparedRange.bounded {
+		return s.preparedRange.to, nil
+	}
+	return max(s.preparedRange.from, s.generator.sequence), nil
+}
+
+// GetLedger generates the ledgers up to sequence and returns it.
+func (s *SyntheticBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return xdr.LedgerCloseMeta{}, errors.New("SyntheticBackend is closed")
+	}
+	if s.preparedRange == nil {
+		return xdr.LedgerCloseMeta{}, errors.New("SyntheticBackend must be prepared before calling GetLedger")
+	}
+	if sequence < s.preparedRange.from || (s.preparedRange.bounded && sequence > s.preparedRange.to) {
+		return xdr.LedgerCloseMeta{}, fmt.Errorf("requested ledger %d is outside of the prepared range %v",
+			sequence, *s.preparedRange)
+	}
+
+	if sequence <= s.generator.sequence {
+		// the generator can only move forward, start over
+		generator, err := newSyntheticGenerator(s.scenario)
+		if err != nil {
+			return xdr.LedgerCloseMeta{}, err
+		}
+		s.generator = generator
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return xdr.LedgerCloseMeta{}, err
+		}
+		lcm, err := s.generator.next()
+		if err != nil {
+			return xdr.LedgerCloseMeta{}, fmt.Errorf("could not generate ledger %d: %w", s.generator.sequence+1, err)
+		}
+		if lcm.LedgerSequence() == sequence {
+			return lcm, nil
+		}
+	}
+}
+
+// PrepareRange prepares the given range, any range starting from ledger 2
+// can be prepared.
+func (s *SyntheticBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return errors.New("SyntheticBackend is closed")
+	}
+	if ledgerRange.from < 2 {
+		return fmt.Errorf("invalid range %v, the first ledger is 2", ledgerRange)
+	}
+	if ledgerRange.bounded && ledgerRange.to < ledgerRange.from {
+		return fmt.Errorf("invalid range %v, end is before start", ledgerRange)
+	}
+	s.preparedRange = &ledgerRange
+	return nil
+}
+
+// IsPrepared returns true if the given range is within the prepared range.
+func (s *SyntheticBackend) IsPrepared(ctx context.Context, ledgerRange Range) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed || s.preparedRange == nil || s.preparedRange.from > ledgerRange.from {
+		return false, nil
+	}
+	if !s.preparedRange.bounded {
+		return true, nil
+	}
+	return ledgerRange.bounded && s.preparedRange.to >= ledgerRange.to, nil
+}
+
+// Close closes the backend.
+func (s *SyntheticBackend) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,173 @@
+package ledgerbackend_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/xdr"
+)
+
+func syntheticLedgers(t *testing.T, scenario ledgerbackend.SyntheticScenario, ledgerRange ledgerbackend.Range) []xdr.LedgerCloseMeta {
+	ctx := context.Background()
+	backend, err := ledgerbackend.NewSyntheticBackend(scenario)
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, ledgerRange))
+
+	var ledgers []xdr.LedgerCloseMeta
+	for seq := ledgerRange.From(); seq <= ledgerRange.To(); seq++ {
+		lcm, err := backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, seq, lcm.LedgerSequence())
+		ledgers = append(ledgers, lcm)
+	}
+	require.NoError(t, backend.Close())
+	return ledgers
+}
+
+func TestSyntheticBackendIsDeterministic(t *testing.T) {
+	scenario := ledgerbackend.SyntheticScenario{
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		Seed:                42,
+		Accounts:            5,
+		Assets:              2,
+		Payments:            2,
+		Offers:              1,
+		ContractInvocations: 1,
+		FeeBumpRatio:        0.2,
+	}
+	ledgers := syntheticLedgers(t, scenario, ledgerbackend.BoundedRange(2, 20))
+	for i, lcm := range ledgers {
+		hash, err := xdr.HashXdr(lcm.LedgerHeaderHistoryEntry().Header)
+		require.NoError(t, err)
+		require.Equal(t, hash, lcm.LedgerHash())
+		if i > 0 {
+			require.Equal(t, ledgers[i-1].LedgerHash(), lcm.PreviousLedgerHash())
+		}
+		require.Equal(t, uint32(23), lcm.ProtocolVersion())
+		require.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(i)*5*time.Second), lcm.ClosedAt())
+	}
+
+	// ledgers don't depend on the range prepared
+	require.Equal(t, ledgers[10:], syntheticLedgers(t, scenario, ledgerbackend.BoundedRange(12, 20)))
+
+	scenario.Seed = 43
+	require.NotEqual(t, ledgers[5:], syntheticLedgers(t, scenario, ledgerbackend.BoundedRange(7, 20)))
+}
+
+func TestSyntheticBackendLedgersAreProcessable(t *testing.T) {
+	for _, unifiedEvents := range []bool{false, true} {
+		scenario := ledgerbackend.SyntheticScenario{
+			NetworkPassphrase:        network.TestNetworkPassphrase,
+			Seed:                     7,
+			Accounts:                 30,
+			Assets:                   3,
+			TransactionsPerLedger:    20,
+			OperationsPerTransaction: 3,
+			Payments:                 3,
+			Offers:                   3,
+			ContractInvocations:      2,
+			FeeBumpRatio:             0.25,
+			UnifiedEvents:            unifiedEvents,
+		}
+		var processor *token_transfer.EventsProcessor
+		if unifiedEvents {
+			processor = token_transfer.NewEventsProcessorForUnifiedEvents(scenario.NetworkPassphrase)
+		} else {
+			processor = token_transfer.NewEventsProcessor(scenario.NetworkPassphrase)
+		}
+
+		eventTypes := map[string]int{}
+		txs, feeBumps, sorobanTxs := 0, 0, 0
+		for _, lcm := range syntheticLedgers(t, scenario, ledgerbackend.BoundedRange(2, 50)) {
+			require.NoError(t, token_transfer.VerifyEvents(lcm, scenario.NetworkPassphrase, unifiedEvents))
+			events, err := processor.EventsFromLedger(lcm)
+			require.NoError(t, err)
+			for _, event := range events {
+				eventTypes[event.GetEventType()]++
+			}
+
+			txReader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(scenario.NetworkPassphrase, lcm)
+			require.NoError(t, err)
+			for {
+				tx, err := txReader.Read()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				require.True(t, tx.Successful())
+				txs++
+				if tx.Envelope.IsFeeBump() {
+					feeBumps++
+				}
+				if tx.IsSorobanTx() {
+					sorobanTxs++
+				}
+			}
+
+			changeReader, err := ingest.NewLedgerChangeReaderFromLedgerCloseMeta(scenario.NetworkPassphrase, lcm)
+			require.NoError(t, err)
+			for {
+				_, err := changeReader.Read()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+			}
+		}
+
+		require.Equal(t, 4+20*48, txs, "4 setup transactions and 20 transactions per ledger")
+		require.Positive(t, feeBumps)
+		require.Positive(t, sorobanTxs)
+		for _, eventType := range []string{token_transfer.FeeEvent, token_transfer.TransferEvent, token_transfer.MintEvent} {
+			require.Positive(t, eventTypes[eventType], eventType)
+		}
+	}
+}
+
+func TestSyntheticBackendRange(t *testing.T) {
+	ctx := context.Background()
+	_, err := ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{})
+	require.EqualError(t, err, "invalid scenario: network passphrase is required")
+	_, err = ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Offers:            1,
+	})
+	require.EqualError(t, err, "invalid scenario: offers require at least one asset")
+
+	backend, err := ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	})
+	require.NoError(t, err)
+	_, err = backend.GetLedger(ctx, 2)
+	require.EqualError(t, err, "SyntheticBackend must be prepared before calling GetLedger")
+	require.EqualError(t, backend.PrepareRange(ctx, ledgerbackend.UnboundedRange(1)), "invalid range [1,latest), the first ledger is 2")
+
+	require.NoError(t, backend.PrepareRange(ctx, ledgerbackend.UnboundedRange(5)))
+	prepared, err := backend.IsPrepared(ctx, ledgerbackend.BoundedRange(6, 100))
+	require.NoError(t, err)
+	require.True(t, prepared)
+	prepared, err = backend.IsPrepared(ctx, ledgerbackend.UnboundedRange(4))
+	require.NoError(t, err)
+	require.False(t, prepared)
+
+	_, err = backend.GetLedger(ctx, 4)
+	require.EqualError(t, err, "requested ledger 4 is outside of the prepared range [5,latest)")
+	lcm, err := backend.GetLedger(ctx, 8)
+	require.NoError(t, err)
+	require.Equal(t, uint32(8), lcm.LedgerSequence())
+	latest, err := backend.GetLatestLedgerSequence(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint32(8), latest)
+
+	require.NoError(t, backend.Close())
+	_, err = backend.GetLedger(ctx, 9)
+	require.EqualError(t, err, "SyntheticBackend is closed")
+}
@@ -0,0 +1,1076 @@
+package ledgerbackend
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	syntheticBaseFee     = 100
+	syntheticBaseReserve = 5_000_000
+	// syntheticTotalCoins is the balance of the root account at genesis
+	syntheticTotalCoins = 100_000_000_000 * 10_000_000
+	// syntheticStartingBalance is the balance accounts are created and topped
+	// up with, syntheticMinimumBalance the native balance under which they
+	// are topped up by the root account
+	syntheticStartingBalance = 10_000 * 10_000_000
+	syntheticMinimumBalance  = 1_000 * 10_000_000
+	// syntheticAssetBalance is the balance issuers fund accounts with
+	syntheticAssetBalance = 1_000_000 * 10_000_000
+	// syntheticRootAccount is the index of the root account
+	syntheticRootAccount = 0
+)
+
+type syntheticTxKind int
+
+const (
+	syntheticSetupTx syntheticTxKind = iota
+	syntheticTopUpTx
+	syntheticClassicTx
+	syntheticContractTx
+)
+
+type syntheticOpKind int
+
+const (
+	syntheticPaymentOp syntheticOpKind = iota
+	syntheticOfferOp
+)
+
+// syntheticAccount is the state of an account and its trustlines. Balances
+// and the related fields are indexed by asset, index 0 being the native
+// balance of the account entry and the others the trustlines.
+type syntheticAccount struct {
+	id            xdr.AccountId
+	seqNum        int64
+	numSubEntries uint32
+	// issued is the index of the asset issued by the account, 0 if none
+	issued       int
+	exists       []bool
+	balances     []int64
+	lastModified []uint32
+	// reserved are the amounts sold by the open offers of the account
+	reserved []int64
+}
+
+func (a *syntheticAccount) available(asset int) int64 {
+	return a.balances[asset] - a.reserved[asset]
+}
+
+type syntheticOffer struct {
+	id           int64
+	seller       int
+	selling      int
+	buying       int
+	amount       int64
+	lastModified uint32
+}
+
+// syntheticEntryKey identifies an offer if offer is set, otherwise the account
+// entry or a trustline of an account.
+type syntheticEntryKey struct {
+	account int
+	asset   int
+	offer   int64
+}
+
+// syntheticTx is a transaction of the ledger being generated, its envelope is
+// built once its fee is charged.
+type syntheticTx struct {
+	kind      syntheticTxKind
+	source    int
+	feeSource int
+	feeBump   bool
+	// accounts are the accounts created by setup transactions or the account
+	// topped up
+	accounts    []int
+	ops         int
+	resourceFee int64
+	fee         int64
+	refund      int64
+
+	envelope               xdr.TransactionEnvelope
+	result                 xdr.TransactionResultPair
+	feeProcessing          xdr.LedgerEntryChanges
+	meta                   xdr.TransactionMeta
+	postApplyFeeProcessing xdr.LedgerEntryChanges
+}
+
+// syntheticGenerator generates the ledgers of a scenario one after the other,
+// tracking the state of the ledger entries they modify.
+type syntheticGenerator struct {
+	scenario    SyntheticScenario
+	rand        *rand.Rand
+	assets      []xdr.Asset
+	contractIDs []xdr.ContractId
+	accounts    []*syntheticAccount
+	// traffic is the index of the first account which isn't the root or an
+	// issuer
+	traffic     int
+	offers      map[int64]*syntheticOffer
+	orderBook   map[[2]int][]int64
+	nextOfferID int64
+	feePool     int64
+	// setup holds the setup transactions not generated yet, each creates the
+	// given accounts
+	setup [][]int
+
+	// sequence is the last ledger generated and header its header
+	sequence uint32
+	header   xdr.LedgerHeaderHistoryEntry
+}
+
+func newSyntheticGenerator(scenario SyntheticScenario) (*syntheticGenerator, error) {
+	g := &syntheticGenerator{
+		scenario:    scenario,
+		rand:        rand.New(rand.NewSource(scenario.Seed)),
+		assets:      []xdr.Asset{xdr.MustNewNativeAsset()},
+		offers:      map[int64]*syntheticOffer{},
+		orderBook:   map[[2]int][]int64{},
+		nextOfferID: 1,
+		traffic:     1 + scenario.Assets,
+	}
+
+	root := g.addAccount(keypair.Root(scenario.NetworkPassphrase).Address())
+	root.exists[0] = true
+	root.balances[0] = syntheticTotalCoins
+	root.lastModified[0] = 1
+
+	var issuers []int
+	for i := 1; i <= scenario.Assets; i++ {
+		address, err := g.randomAddress()
+		if err != nil {
+			return nil, err
+		}
+		issuer := g.addAccount(address)
+		issuer.issued = i
+		issuers = append(issuers, len(g.accounts)-1)
+		g.assets = append(g.assets, xdr.MustNewCreditAsset(fmt.Sprintf("SYN%d", i), issuer.id.Address()))
+	}
+	for _, asset := range g.assets {
+		contractID, err := asset.ContractID(scenario.NetworkPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("could not compute contract id of %s: %w", asset.StringCanonical(), err)
+		}
+		g.contractIDs = append(g.contractIDs, contractID)
+	}
+	for i := 0; i < scenario.Accounts; i++ {
+		address, err := g.randomAddress()
+		if err != nil {
+			return nil, err
+		}
+		g.addAccount(address)
+	}
+
+	if len(issuers) > 0 {
+		g.setup = append(g.setup, issuers)
+	}
+	perTx := syntheticMaxOperationsPerTransaction / (1 + 2*scenario.Assets)
+	for start := g.traffic; start < len(g.accounts); start += perTx {
+		var accounts []int
+		for i := start; i < min(start+perTx, len(g.accounts)); i++ {
+			accounts = append(accounts, i)
+		}
+		g.setup = append(g.setup, accounts)
+	}
+
+	genesis := xdr.LedgerHeader{
+		LedgerVersion: xdr.Uint32(scenario.ProtocolVersion),
+		ScpValue: xdr.StellarValue{
+			CloseTime: xdr.TimePoint(scenario.StartCloseTime.Add(-scenario.CloseInterval).Unix()),
+		},
+		LedgerSeq:    1,
+		TotalCoins:   syntheticTotalCoins,
+		BaseFee:      syntheticBaseFee,
+		BaseReserve:  syntheticBaseReserve,
+		MaxTxSetSize: 100,
+	}
+	hash, err := xdr.HashXdr(genesis)
+	if err != nil {
+		return nil, err
+	}
+	g.header = xdr.LedgerHeaderHistoryEntry{Hash: hash, Header: genesis}
+	g.sequence = 1
+	return g, nil
+}
+
+func (g *syntheticGenerator) addAccount(address string) *syntheticAccount {
+	assets := 1 + g.scenario.Assets
+	account := &syntheticAccount{
+		id:           xdr.MustAddress(address),
+		exists:       make([]bool, assets),
+		balances:     make([]int64, assets),
+		lastModified: make([]uint32, assets),
+		reserved:     make([]int64, assets),
+	}
+	g.accounts = append(g.accounts, account)
+	return account
+}
+
+func (g *syntheticGenerator) randomAddress() (string, error) {
+	var seed [32]byte
+	g.rand.Read(seed[:])
+	kp, err := keypair.FromRawSeed(seed)
+	if err != nil {
+		return "", err
+	}
+	return kp.Address(), nil
+}
+
+// randomAccount returns a random account other than the root account, the
+// issuers and the given account.
+func (g *syntheticGenerator) randomAccount(except int) int {
+	for {
+		account := g.traffic + g.rand.Intn(len(g.accounts)-g.traffic)
+		if account != except {
+			return account
+		}
+	}
+}
+
+// randomAmount returns an amount of at most 1% of the available balance, 0 if
+// the balance is too low.
+func (g *syntheticGenerator) randomAmount(available int64) int64 {
+	if available < 100 {
+		return 0
+	}
+	return 1 + g.rand.Int63n(available/100)
+}
+
+func (g *syntheticGenerator) randomOpKind() syntheticOpKind {
+	if g.rand.Intn(g.scenario.Payments+g.scenario.Offers) < g.scenario.Payments {
+		return syntheticPaymentOp
+	}
+	return syntheticOfferOp
+}
+
+// next generates the next ledger.
+func (g *syntheticGenerator) next() (xdr.LedgerCloseMeta, error) {
+	sequence := g.sequence + 1
+	txs := g.selectTransactions()
+
+	for _, tx := range txs {
+		g.chargeFee(sequence, tx)
+	}
+	for _, tx := range txs {
+		if err := g.applyTransaction(sequence, tx); err != nil {
+			return xdr.LedgerCloseMeta{}, err
+		}
+	}
+	for _, tx := range txs {
+		if tx.refund > 0 {
+			changes := g.newChanges()
+			changes.touch(syntheticEntryKey{account: tx.feeSource})
+			g.accounts[tx.feeSource].balances[0] += tx.refund
+			g.feePool -= tx.refund
+			tx.postApplyFeeProcessing = changes.changes(sequence)
+		}
+	}
+
+	baseFee := xdr.Int64(syntheticBaseFee)
+	var classic []xdr.TransactionEnvelope
+	var contracts xdr.DependentTxCluster
+	processing := make([]xdr.TransactionResultMetaV1, 0, len(txs))
+	var resultSet xdr.TransactionResultSet
+	for _, tx := range txs {
+		resultSet.Results = append(resultSet.Results, tx.result)
+		if tx.kind == syntheticContractTx {
+			contracts = append(contracts, tx.envelope)
+		} else {
+			classic = append(classic, tx.envelope)
+		}
+		processing = append(processing, xdr.TransactionResultMetaV1{
+			Result:                   tx.result,
+			FeeProcessing:            tx.feeProcessing,
+			TxApplyProcessing:        tx.meta,
+			PostTxApplyFeeProcessing: tx.postApplyFeeProcessing,
+		})
+	}
+	classicComponents := []xdr.TxSetComponent{}
+	if len(classic) > 0 {
+		classicComponents = append(classicComponents, xdr.TxSetComponent{
+			Type: xdr.TxSetComponentTypeTxsetCompTxsMaybeDiscountedFee,
+			TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{
+				BaseFee: &baseFee,
+				Txs:     classic,
+			},
+		})
+	}
+	contractsComponent := &xdr.ParallelTxsComponent{BaseFee: &baseFee}
+	if len(contracts) > 0 {
+		// transactions of a single cluster are applied sequentially
+		contractsComponent.ExecutionStages = []xdr.ParallelTxExecutionStage{{contracts}}
+	}
+	txSet := xdr.GeneralizedTransactionSet{
+		V: 1,
+		V1TxSet: &xdr.TransactionSetV1{
+			PreviousLedgerHash: g.header.Hash,
+			Phases: []xdr.TransactionPhase{
+				{V: 0, V0Components: &classicComponents},
+				{V: 1, ParallelTxsComponent: contractsComponent},
+			},
+		},
+	}
+	txSetHash, err := xdr.HashXdr(txSet)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	resultSetHash, err := xdr.HashXdr(resultSet)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+
+	closeTime := g.scenario.StartCloseTime.Add(time.Duration(sequence-2) * g.scenario.CloseInterval)
+	header := xdr.LedgerHeader{
+		LedgerVersion:      xdr.Uint32(g.scenario.ProtocolVersion),
+		PreviousLedgerHash: g.header.Hash,
+		ScpValue: xdr.StellarValue{
+			TxSetHash: txSetHash,
+			CloseTime: xdr.TimePoint(closeTime.Unix()),
+		},
+		TxSetResultHash: resultSetHash,
+		LedgerSeq:       xdr.Uint32(sequence),
+		TotalCoins:      syntheticTotalCoins,
+		FeePool:         xdr.Int64(g.feePool),
+		IdPool:          xdr.Uint64(g.nextOfferID - 1),
+		BaseFee:         syntheticBaseFee,
+		BaseReserve:     syntheticBaseReserve,
+		MaxTxSetSize:    xdr.Uint32(max(100, g.scenario.TransactionsPerLedger)),
+	}
+	hash, err := xdr.HashXdr(header)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	g.header = xdr.LedgerHeaderHistoryEntry{Hash: hash, Header: header}
+	g.sequence = sequence
+
+	return xdr.LedgerCloseMeta{
+		V: 2,
+		V2: &xdr.LedgerCloseMetaV2{
+			LedgerHeader: g.header,
+			TxSet:        txSet,
+			TxProcessing: processing,
+		},
+	}, nil
+}
+
+// selectTransactions returns the transactions of the next ledger in the order
+// they are applied: setup transactions until all the accounts are created,
+// then transactions topping up accounts which are low on lumens, classic
+// transactions and contract invocations.
+func (g *syntheticGenerator) selectTransactions() []*syntheticTx {
+	var txs []*syntheticTx
+	if len(g.setup) > 0 {
+		for len(g.setup) > 0 && len(txs) < g.scenario.TransactionsPerLedger {
+			accounts := g.setup[0]
+			g.setup = g.setup[1:]
+			ops := 0
+			for _, account := range accounts {
+				ops++
+				if g.accounts[account].issued == 0 {
+					ops += 2 * g.scenario.Assets
+				}
+			}
+			txs = append(txs, &syntheticTx{
+				kind:     syntheticSetupTx,
+				source:   syntheticRootAccount,
+				accounts: accounts,
+				ops:      ops,
+			})
+		}
+		return txs
+	}
+
+	for account := g.traffic; account < len(g.accounts) && len(txs) < g.scenario.TransactionsPerLedger; account++ {
+		if g.accounts[account].available(0) < syntheticMinimumBalance {
+			txs = append(txs, &syntheticTx{
+				kind:     syntheticTopUpTx,
+				source:   syntheticRootAccount,
+				accounts: []int{account},
+				ops:      1,
+			})
+		}
+	}
+
+	total := g.scenario.Payments + g.scenario.Offers + g.scenario.ContractInvocations
+	for len(txs) < g.scenario.TransactionsPerLedger {
+		tx := &syntheticTx{
+			kind:   syntheticClassicTx,
+			source: g.randomAccount(-1),
+			ops:    g.scenario.OperationsPerTransaction,
+		}
+		if g.rand.Intn(total) >= g.scenario.Payments+g.scenario.Offers {
+			tx.kind = syntheticContractTx
+			tx.ops = 1
+			tx.resourceFee = 50_000 + g.rand.Int63n(100_000)
+			tx.refund = g.rand.Int63n(tx.resourceFee / 2)
+		}
+		tx.feeSource = tx.source
+		if g.rand.Float64() < g.scenario.FeeBumpRatio {
+			tx.feeBump = true
+			tx.feeSource = g.randomAccount(tx.source)
+		}
+		txs = append(txs, tx)
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].kind < txs[j].kind
+	})
+	return txs
+}
+
+// chargeFee charges the fee of the transaction, transactions whose fee source
+// can't pay are fee bumped by the root account.
+func (g *syntheticGenerator) chargeFee(sequence uint32, tx *syntheticTx) {
+	computeFee := func() int64 {
+		ops := int64(tx.ops)
+		if tx.kind == syntheticContractTx {
+			// the inclusion fee of contract invocations doesn't depend on
+			// their operation
+			ops = 1
+		}
+		if tx.feeBump {
+			ops++
+		}
+		return ops*syntheticBaseFee + tx.resourceFee
+	}
+	tx.fee = computeFee()
+	if tx.feeSource != syntheticRootAccount && g.accounts[tx.feeSource].available(0) < tx.fee {
+		tx.feeBump = true
+		tx.feeSource = syntheticRootAccount
+		tx.fee = computeFee()
+	}
+
+	changes := g.newChanges()
+	changes.touch(syntheticEntryKey{account: tx.feeSource})
+	g.accounts[tx.feeSource].balances[0] -= tx.fee
+	g.feePool += tx.fee
+	tx.feeProcessing = changes.changes(sequence)
+}
+
+func (g *syntheticGenerator) applyTransaction(sequence uint32, tx *syntheticTx) error {
+	source := g.accounts[tx.source]
+	changes := g.newChanges()
+	changes.touch(syntheticEntryKey{account: tx.source})
+	source.seqNum++
+	meta := xdr.TransactionMetaV4{TxChangesBefore: changes.changes(sequence)}
+	transaction := xdr.Transaction{
+		SourceAccount: source.id.ToMuxedAccount(),
+		Fee:           xdr.Uint32(int64(tx.ops)*syntheticBaseFee + tx.resourceFee),
+		SeqNum:        xdr.SequenceNumber(source.seqNum),
+		Cond:          xdr.Preconditions{Type: xdr.PreconditionTypePrecondNone},
+	}
+
+	var results []xdr.OperationResult
+	addOperation := func(op xdr.Operation, result xdr.OperationResult, changes *syntheticChanges, events []xdr.ContractEvent) {
+		transaction.Operations = append(transaction.Operations, op)
+		results = append(results, result)
+		meta.Operations = append(meta.Operations, xdr.OperationMetaV2{
+			Changes: changes.changes(sequence),
+			Events:  events,
+		})
+	}
+
+	switch tx.kind {
+	case syntheticSetupTx:
+		for _, account := range tx.accounts {
+			addOperation(g.createAccount(account))
+			if g.accounts[account].issued != 0 {
+				continue
+			}
+			for asset := 1; asset < len(g.assets); asset++ {
+				addOperation(g.changeTrust(account, asset))
+				addOperation(g.payment(g.assetIssuer(asset), account, asset, syntheticAssetBalance))
+			}
+		}
+	case syntheticTopUpTx:
+		op, result, changes, events := g.payment(syntheticRootAccount, tx.accounts[0], 0, syntheticStartingBalance)
+		// the operation source is the transaction source
+		op.SourceAccount = nil
+		addOperation(op, result, changes, events)
+	case syntheticClassicTx:
+		for i := 0; i < tx.ops; i++ {
+			if g.randomOpKind() == syntheticPaymentOp {
+				to := g.randomAccount(tx.source)
+				asset := g.rand.Intn(len(g.assets))
+				amount := g.randomAmount(source.available(asset))
+				if amount == 0 {
+					asset = 0
+					amount = g.randomAmount(source.available(0))
+				}
+				op, result, changes, events := g.payment(tx.source, to, asset, amount)
+				op.SourceAccount = nil
+				addOperation(op, result, changes, events)
+			} else {
+				addOperation(g.sellOffer(sequence, tx.source))
+			}
+		}
+	case syntheticContractTx:
+		op, result, changes, events, sorobanData := g.invokeTransfer(tx.source)
+		sorobanData.ResourceFee = xdr.Int64(tx.resourceFee)
+		transaction.Ext = xdr.TransactionExt{V: 1, SorobanData: &sorobanData}
+		addOperation(op, result, changes, events)
+		returnValue := xdr.ScVal{Type: xdr.ScValTypeScvVoid}
+		meta.SorobanMeta = &xdr.SorobanTransactionMetaV2{ReturnValue: &returnValue}
+	}
+
+	if g.scenario.UnifiedEvents {
+		meta.Events = append(meta.Events, xdr.TransactionEvent{
+			Stage: xdr.TransactionEventStageTransactionEventStageBeforeAllTxs,
+			Event: g.feeEvent(tx.feeSource, tx.fee),
+		})
+		if tx.refund > 0 {
+			meta.Events = append(meta.Events, xdr.TransactionEvent{
+				Stage: xdr.TransactionEventStageTransactionEventStageAfterAllTxs,
+				Event: g.feeEvent(tx.feeSource, -tx.refund),
+			})
+		}
+	}
+	tx.meta = xdr.TransactionMeta{V: 4, V4: &meta}
+
+	tx.envelope = xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1:   &xdr.TransactionV1Envelope{Tx: transaction},
+	}
+	txResult := xdr.TransactionResult{
+		FeeCharged: xdr.Int64(tx.fee - tx.refund),
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxSuccess,
+			Results: &results,
+		},
+	}
+	if tx.feeBump {
+		innerHash, err := network.HashTransactionInEnvelope(tx.envelope, g.scenario.NetworkPassphrase)
+		if err != nil {
+			return err
+		}
+		tx.envelope = xdr.TransactionEnvelope{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTxFeeBump,
+			FeeBump: &xdr.FeeBumpTransactionEnvelope{
+				Tx: xdr.FeeBumpTransaction{
+					FeeSource: g.accounts[tx.feeSource].id.ToMuxedAccount(),
+					Fee:       xdr.Int64(tx.fee),
+					InnerTx: xdr.FeeBumpTransactionInnerTx{
+						Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+						V1:   tx.envelope.V1,
+					},
+				},
+			},
+		}
+		txResult.Result = xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFeeBumpInnerSuccess,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				TransactionHash: innerHash,
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxSuccess,
+						Results: &results,
+					},
+				},
+			},
+		}
+	}
+	hash, err := network.HashTransactionInEnvelope(tx.envelope, g.scenario.NetworkPassphrase)
+	if err != nil {
+		return err
+	}
+	tx.result = xdr.TransactionResultPair{TransactionHash: hash, Result: txResult}
+	return nil
+}
+
+func (g *syntheticGenerator) assetIssuer(asset int) int {
+	// issuers are created right after the root account
+	return asset
+}
+
+func (g *syntheticGenerator) createAccount(account int) (xdr.Operation, xdr.OperationResult, *syntheticChanges, []xdr.ContractEvent) {
+	changes := g.newChanges()
+	changes.touch(syntheticEntryKey{account: syntheticRootAccount})
+	changes.touch(syntheticEntryKey{account: account})
+	g.accounts[syntheticRootAccount].balances[0] -= syntheticStartingBalance
+	created := g.accounts[account]
+	created.exists[0] = true
+	created.balances[0] = syntheticStartingBalance
+	created.seqNum = int64(g.sequence+1) << 32
+
+	op := xdr.Operation{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeCreateAccount,
+			CreateAccountOp: &xdr.CreateAccountOp{
+				Destination:     created.id,
+				StartingBalance: syntheticStartingBalance,
+			},
+		},
+	}
+	result := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type:                xdr.OperationTypeCreateAccount,
+			CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+		},
+	}
+	return op, result, changes, g.classicEvents(g.assetEvent(0, syntheticRootAccount, account, syntheticStartingBalance))
+}
+
+func (g *syntheticGenerator) changeTrust(account, asset int) (xdr.Operation, xdr.OperationResult, *syntheticChanges, []xdr.ContractEvent) {
+	changes := g.newChanges()
+	changes.touch(syntheticEntryKey{account: account})
+	changes.touch(syntheticEntryKey{account: account, asset: asset})
+	trustor := g.accounts[account]
+	trustor.numSubEntries++
+	trustor.exists[asset] = true
+
+	source := trustor.id.ToMuxedAccount()
+	op := xdr.Operation{
+		SourceAccount: &source,
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeChangeTrust,
+			ChangeTrustOp: &xdr.ChangeTrustOp{
+				Line:  g.assets[asset].ToChangeTrustAsset(),
+				Limit: math.MaxInt64,
+			},
+		},
+	}
+	result := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type:              xdr.OperationTypeChangeTrust,
+			ChangeTrustResult: &xdr.ChangeTrustResult{Code: xdr.ChangeTrustResultCodeChangeTrustSuccess},
+		},
+	}
+	return op, result, changes, nil
+}
+
+// payment returns a payment with an explicit source account.
+func (g *syntheticGenerator) payment(from, to, asset int, amount int64) (xdr.Operation, xdr.OperationResult, *syntheticChanges, []xdr.ContractEvent) {
+	changes := g.newChanges()
+	g.updateBalance(changes, from, asset, -amount)
+	g.updateBalance(changes, to, asset, amount)
+
+	source := g.accounts[from].id.ToMuxedAccount()
+	op := xdr.Operation{
+		SourceAccount: &source,
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypePayment,
+			PaymentOp: &xdr.PaymentOp{
+				Destination: g.accounts[to].id.ToMuxedAccount(),
+				Asset:       g.assets[asset],
+				Amount:      xdr.Int64(amount),
+			},
+		},
+	}
+	result := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess},
+		},
+	}
+	return op, result, changes, g.classicEvents(g.assetEvent(asset, from, to, amount))
+}
+
+// sellOffer returns an offer selling an asset for another at a price of 1,
+// crossing the offers of other accounts on the opposite side of the order
+// book. The remaining amount rests on the order book.
+func (g *syntheticGenerator) sellOffer(sequence uint32, taker int) (xdr.Operation, xdr.OperationResult, *syntheticChanges, []xdr.ContractEvent) {
+	selling := g.rand.Intn(len(g.assets))
+	buying := (selling + 1 + g.rand.Intn(len(g.assets)-1)) % len(g.assets)
+	amount := g.randomAmount(g.accounts[taker].available(selling))
+	if amount == 0 {
+		selling, buying = 0, 1+g.rand.Intn(len(g.assets)-1)
+		amount = g.randomAmount(g.accounts[taker].available(0))
+	}
+
+	changes := g.newChanges()
+	var claims []xdr.ClaimAtom
+	var events []xdr.ContractEvent
+	remaining := amount
+	opposite := [2]int{buying, selling}
+	var resting []int64
+	for _, offerID := range g.orderBook[opposite] {
+		offer := g.offers[offerID]
+		if remaining == 0 || offer.seller == taker {
+			resting = append(resting, offerID)
+			continue
+		}
+		filled := min(remaining, offer.amount)
+		remaining -= filled
+		changes.touch(syntheticEntryKey{offer: offerID})
+		offer.amount -= filled
+		maker := g.accounts[offer.seller]
+		maker.reserved[buying] -= filled
+		g.updateBalance(changes, offer.seller, buying, -filled)
+		g.updateBalance(changes, offer.seller, selling, filled)
+		g.updateBalance(changes, taker, selling, -filled)
+		g.updateBalance(changes, taker, buying, filled)
+		if offer.amount == 0 {
+			changes.touch(syntheticEntryKey{account: offer.seller})
+			maker.numSubEntries--
+			delete(g.offers, offerID)
+		} else {
+			resting = append(resting, offerID)
+		}
+
+		claims = append(claims, xdr.ClaimAtom{
+			Type: xdr.ClaimAtomTypeClaimAtomTypeOrderBook,
+			OrderBook: &xdr.ClaimOfferAtom{
+				SellerId:     maker.id,
+				OfferId:      xdr.Int64(offerID),
+				AssetSold:    g.assets[buying],
+				AmountSold:   xdr.Int64(filled),
+				AssetBought:  g.assets[selling],
+				AmountBought: xdr.Int64(filled),
+			},
+		})
+		events = append(events,
+			g.assetEvent(buying, offer.seller, taker, filled),
+			g.assetEvent(selling, taker, offer.seller, filled),
+		)
+	}
+	g.orderBook[opposite] = resting
+
+	offerResult := xdr.ManageOfferSuccessResultOffer{Effect: xdr.ManageOfferEffectManageOfferDeleted}
+	if remaining > 0 {
+		offer := &syntheticOffer{
+			id:      g.nextOfferID,
+			seller:  taker,
+			selling: selling,
+			buying:  buying,
+			amount:  remaining,
+		}
+		g.nextOfferID++
+		changes.touch(syntheticEntryKey{account: taker})
+		changes.touch(syntheticEntryKey{offer: offer.id})
+		g.accounts[taker].numSubEntries++
+		g.accounts[taker].reserved[selling] += remaining
+		g.offers[offer.id] = offer
+		side := [2]int{selling, buying}
+		g.orderBook[side] = append(g.orderBook[side], offer.id)
+
+		offer.lastModified = sequence
+		offerResult = xdr.ManageOfferSuccessResultOffer{
+			Effect: xdr.ManageOfferEffectManageOfferCreated,
+			Offer:  g.offerEntry(offer).Data.Offer,
+		}
+	}
+
+	op := xdr.Operation{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeManageSellOffer,
+			ManageSellOfferOp: &xdr.ManageSellOfferOp{
+				Selling: g.assets[selling],
+				Buying:  g.assets[buying],
+				Amount:  xdr.Int64(amount),
+				Price:   xdr.Price{N: 1, D: 1},
+			},
+		},
+	}
+	result := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type: xdr.OperationTypeManageSellOffer,
+			ManageSellOfferResult: &xdr.ManageSellOfferResult{
+				Code: xdr.ManageSellOfferResultCodeManageSellOfferSuccess,
+				Success: &xdr.ManageOfferSuccessResult{
+					OffersClaimed: claims,
+					Offer:         offerResult,
+				},
+			},
+		},
+	}
+	return op, result, changes, g.classicEvents(events...)
+}
+
+// invokeTransfer returns an invocation of the transfer function of the
+// Stellar Asset Contract of a random asset, and the soroban data of its
+// transaction.
+func (g *syntheticGenerator) invokeTransfer(from int) (xdr.Operation, xdr.OperationResult, *syntheticChanges, []xdr.ContractEvent, xdr.SorobanTransactionData) {
+	to := g.randomAccount(from)
+	asset := g.rand.Intn(len(g.assets))
+	amount := g.randomAmount(g.accounts[from].available(asset))
+	if amount == 0 {
+		asset = 0
+		amount = g.randomAmount(g.accounts[from].available(0))
+	}
+
+	changes := g.newChanges()
+	g.updateBalance(changes, from, asset, -amount)
+	g.updateBalance(changes, to, asset, amount)
+
+	contract := g.contractAddress(asset)
+	op := xdr.Operation{
+		Body: xdr.OperationBody{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+				HostFunction: xdr.HostFunction{
+					Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+					InvokeContract: &xdr.InvokeContractArgs{
+						ContractAddress: contract,
+						FunctionName:    "transfer",
+						Args: []xdr.ScVal{
+							g.addressVal(from),
+							g.addressVal(to),
+							int128Val(amount),
+						},
+					},
+				},
+			},
+		},
+	}
+	returnHash := xdr.Hash(sha256.Sum256([]byte{}))
+	result := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionResult: &xdr.InvokeHostFunctionResult{
+				Code:    xdr.InvokeHostFunctionResultCodeInvokeHostFunctionSuccess,
+				Success: &returnHash,
+			},
+		},
+	}
+
+	instanceKey := xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance}
+	sorobanData := xdr.SorobanTransactionData{
+		Resources: xdr.SorobanResources{
+			Footprint: xdr.LedgerFootprint{
+				ReadOnly: []xdr.LedgerKey{{
+					Type: xdr.LedgerEntryTypeContractData,
+					ContractData: &xdr.LedgerKeyContractData{
+						Contract:   contract,
+						Key:        instanceKey,
+						Durability: xdr.ContractDataDurabilityPersistent,
+					},
+				}},
+				ReadWrite: []xdr.LedgerKey{g.balanceKey(from, asset), g.balanceKey(to, asset)},
+			},
+			Instructions:  xdr.Uint32(1_000_000 + g.rand.Intn(1_000_000)),
+			DiskReadBytes: xdr.Uint32(1_000 + g.rand.Intn(1_000)),
+			WriteBytes:    xdr.Uint32(500 + g.rand.Intn(500)),
+		},
+	}
+	return op, result, changes, []xdr.ContractEvent{g.assetEvent(asset, from, to, amount)}, sorobanData
+}
+
+// updateBalance updates the balance of an account, the balance of issuers in
+// their own asset isn't tracked.
+func (g *syntheticGenerator) updateBalance(changes *syntheticChanges, account, asset int, amount int64) {
+	if asset != 0 && g.accounts[account].issued == asset {
+		return
+	}
+	changes.touch(syntheticEntryKey{account: account, asset: asset})
+	g.accounts[account].balances[asset] += amount
+}
+
+// classicEvents returns the events of a classic operation, which are only
+// emitted with unified events.
+func (g *syntheticGenerator) classicEvents(events ...xdr.ContractEvent) []xdr.ContractEvent {
+	if !g.scenario.UnifiedEvents {
+		return nil
+	}
+	return events
+}
+
+// assetEvent returns the event of the Stellar Asset Contract of the asset for
+// a movement of amount from an account to another, following CAP-67.
+func (g *syntheticGenerator) assetEvent(asset, from, to int, amount int64) xdr.ContractEvent {
+	assetName := xdr.ScString(g.assets[asset].StringCanonical())
+	assetTopic := xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &assetName}
+	var topics []xdr.ScVal
+	switch {
+	case asset != 0 && g.accounts[from].issued == asset:
+		topics = []xdr.ScVal{symbolVal("mint"), g.addressVal(to), assetTopic}
+	case asset != 0 && g.accounts[to].issued == asset:
+		topics = []xdr.ScVal{symbolVal("burn"), g.addressVal(from), assetTopic}
+	default:
+		topics = []xdr.ScVal{symbolVal("transfer"), g.addressVal(from), g.addressVal(to), assetTopic}
+	}
+	return g.contractEvent(asset, topics, int128Val(amount))
+}
+
+// feeEvent returns the event of a fee charged to an account, or refunded to
+// it if amount is negative.
+func (g *syntheticGenerator) feeEvent(account int, amount int64) xdr.ContractEvent {
+	return g.contractEvent(0, []xdr.ScVal{symbolVal("fee"), g.addressVal(account)}, int128Val(amount))
+}
+
+func (g *syntheticGenerator) contractEvent(asset int, topics []xdr.ScVal, data xdr.ScVal) xdr.ContractEvent {
+	contractID := g.contractIDs[asset]
+	return xdr.ContractEvent{
+		Type:       xdr.ContractEventTypeContract,
+		ContractId: &contractID,
+		Body: xdr.ContractEventBody{
+			V:  0,
+			V0: &xdr.ContractEventV0{Topics: topics, Data: data},
+		},
+	}
+}
+
+func (g *syntheticGenerator) contractAddress(asset int) xdr.ScAddress {
+	contractID := g.contractIDs[asset]
+	return xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID}
+}
+
+func (g *syntheticGenerator) addressVal(account int) xdr.ScVal {
+	accountID := g.accounts[account].id
+	address := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeAccount, AccountId: &accountID}
+	return xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &address}
+}
+
+func symbolVal(symbol string) xdr.ScVal {
+	sym := xdr.ScSymbol(symbol)
+	return xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+}
+
+func int128Val(amount int64) xdr.ScVal {
+	parts := xdr.Int128Parts{Hi: xdr.Int64(amount >> 63), Lo: xdr.Uint64(uint64(amount))}
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &parts}
+}
+
+func (g *syntheticGenerator) balanceKey(account, asset int) xdr.LedgerKey {
+	accountID := g.accounts[account].id
+	if asset == 0 {
+		return xdr.LedgerKey{
+			Type:    xdr.LedgerEntryTypeAccount,
+			Account: &xdr.LedgerKeyAccount{AccountId: accountID},
+		}
+	}
+	return xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeTrustline,
+		TrustLine: &xdr.LedgerKeyTrustLine{
+			AccountId: accountID,
+			Asset:     g.assets[asset].ToTrustLineAsset(),
+		},
+	}
+}
+
+func (g *syntheticGenerator) offerEntry(offer *syntheticOffer) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		LastModifiedLedgerSeq: xdr.Uint32(offer.lastModified),
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeOffer,
+			Offer: &xdr.OfferEntry{
+				SellerId: g.accounts[offer.seller].id,
+				OfferId:  xdr.Int64(offer.id),
+				Selling:  g.assets[offer.selling],
+				Buying:   g.assets[offer.buying],
+				Amount:   xdr.Int64(offer.amount),
+				Price:    xdr.Price{N: 1, D: 1},
+			},
+		},
+	}
+}
+
+// entry returns the current state of a ledger entry, false if it doesn't
+// exist.
+func (g *syntheticGenerator) entry(key syntheticEntryKey) (xdr.LedgerEntry, bool) {
+	if key.offer != 0 {
+		offer, ok := g.offers[key.offer]
+		if !ok {
+			return xdr.LedgerEntry{}, false
+		}
+		return g.offerEntry(offer), true
+	}
+
+	account := g.accounts[key.account]
+	if !account.exists[key.asset] {
+		return xdr.LedgerEntry{}, false
+	}
+	entry := xdr.LedgerEntry{LastModifiedLedgerSeq: xdr.Uint32(account.lastModified[key.asset])}
+	if key.asset == 0 {
+		entry.Data = xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId:     account.id,
+				Balance:       xdr.Int64(account.balances[0]),
+				SeqNum:        xdr.SequenceNumber(account.seqNum),
+				NumSubEntries: xdr.Uint32(account.numSubEntries),
+				Thresholds:    xdr.Thresholds{1, 0, 0, 0},
+			},
+		}
+	} else {
+		entry.Data = xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTrustline,
+			TrustLine: &xdr.TrustLineEntry{
+				AccountId: account.id,
+				Asset:     g.assets[key.asset].ToTrustLineAsset(),
+				Balance:   xdr.Int64(account.balances[key.asset]),
+				Limit:     math.MaxInt64,
+				Flags:     xdr.Uint32(xdr.TrustLineFlagsAuthorizedFlag),
+			},
+		}
+	}
+	return entry, true
+}
+
+// syntheticChanges records the ledger entries modified by an operation, or
+// the fee processing of a transaction, to build their changes.
+type syntheticChanges struct {
+	g    *syntheticGenerator
+	keys []syntheticEntryKey
+	// pre holds the state of the entries before they were modified, nil for
+	// entries created
+	pre map[syntheticEntryKey]*xdr.LedgerEntry
+}
+
+func (g *syntheticGenerator) newChanges() *syntheticChanges {
+	return &syntheticChanges{g: g, pre: map[syntheticEntryKey]*xdr.LedgerEntry{}}
+}
+
+// touch records the state of an entry before it is modified.
+func (c *syntheticChanges) touch(key syntheticEntryKey) {
+	if _, ok := c.pre[key]; ok {
+		return
+	}
+	c.keys = append(c.keys, key)
+	if entry, ok := c.g.entry(key); ok {
+		c.pre[key] = &entry
+	} else {
+		c.pre[key] = nil
+	}
+}
+
+// changes returns the changes of the entries touched, the entries which still
+// exist are last modified in the given ledger.
+func (c *syntheticChanges) changes(sequence uint32) xdr.LedgerEntryChanges {
+	var changes xdr.LedgerEntryChanges
+	for _, key := range c.keys {
+		if offer, ok := c.g.offers[key.offer]; ok && key.offer != 0 {
+			offer.lastModified = sequence
+		} else if key.offer == 0 && c.g.accounts[key.account].exists[key.asset] {
+			c.g.accounts[key.account].lastModified[key.asset] = sequence
+		}
+
+		pre := c.pre[key]
+		post, exists := c.g.entry(key)
+		switch {
+		case pre == nil && exists:
+			changes = append(changes, xdr.LedgerEntryChange{
+				Type:    xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+				Created: &post,
+			})
+		case exists:
+			changes = append(changes,
+				xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: pre},
+				xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+			)
+		case pre != nil:
+			ledgerKey, err := pre.LedgerKey()
+			if err != nil {
+				panic(err)
+			}
+			changes = append(changes,
+				xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: pre},
+				xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &ledgerKey},
+			)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,128 @@
+package ledgerbackend
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// TomlReloadStatus is the outcome of a change of the captive core toml file
+// watched by CaptiveStellarCore.
+type TomlReloadStatus string
+
+const (
+	// TomlReloadApplied is the status of a valid toml which replaced the
+	// captive core toml. Stellar-core is restarted with it when it is
+	// running an unbounded range, otherwise it is used by the next session.
+	TomlReloadApplied TomlReloadStatus = "applied"
+	// TomlReloadInvalid is the status of a toml which could not be read or
+	// validated. It is ignored, stellar-core keeps running with the previous
+	// toml.
+	TomlReloadInvalid TomlReloadStatus = "invalid"
+	// TomlReloadFailed is the status of a valid toml stellar-core could not
+	// be restarted with.
+	TomlReloadFailed TomlReloadStatus = "failed"
+)
+
+// TomlReloadEvent describes a change of the captive core toml file, see
+// CaptiveCoreConfig.TomlPath.
+type TomlReloadEvent struct {
+	// Path is the path of the captive core toml file
+	Path   string
+	Status TomlReloadStatus
+	// Ledger is the ledger stellar-core was restarted from, which follows the
+	// last ledger returned by GetLedger. It is 0 when stellar-core was not
+	// restarted.
+	Ledger uint32
+	// Err is the error of invalid or failed reloads
+	Err error
+}
+
+// tomlChanged is called by the toml watcher when the content of the toml file
+// changes. A valid toml is applied by the next call to GetLedger or
+// PrepareRange, which own stellar-core.
+func (c *CaptiveStellarCore) tomlChanged() {
+	var toml *CaptiveCoreToml
+	data, err := os.ReadFile(c.config.TomlPath)
+	if err == nil {
+		toml, err = NewCaptiveCoreTomlFromData(data, c.config.TomlParams)
+	}
+	if err != nil {
+		c.reportTomlReload(TomlReloadEvent{Status: TomlReloadInvalid, Err: err})
+		return
+	}
+
+	c.pendingTomlLock.Lock()
+	c.pendingToml = toml
+	c.pendingTomlLock.Unlock()
+
+	// wake up GetLedger if it is waiting for a ledger, stellar-core may be
+	// stuck with the previous toml
+	select {
+	case c.tomlReloads <- struct{}{}:
+	default:
+	}
+}
+
+// applyPendingToml replaces the captive core toml with the toml reloaded since
+// the last call, if any, and restarts stellar-core with it when it is running
+// an unbounded range.
+func (c *CaptiveStellarCore) applyPendingToml(ctx context.Context) error {
+	c.pendingTomlLock.Lock()
+	toml := c.pendingToml
+	c.pendingToml = nil
+	c.pendingTomlLock.Unlock()
+	if toml == nil {
+		return nil
+	}
+	// the toml is applied, there is no need to wake up GetLedger anymore
+	select {
+	case <-c.tomlReloads:
+	default:
+	}
+
+	c.stellarCoreLock.Lock()
+	defer c.stellarCoreLock.Unlock()
+
+	c.config.Toml = toml
+	if c.closed || c.prepared == nil || c.lastLedger != nil || c.stellarCoreRunner == nil {
+		// bounded ranges are not restarted, the new toml is used when the next
+		// range is prepared
+		c.reportTomlReload(TomlReloadEvent{Status: TomlReloadApplied})
+		return nil
+	}
+
+	from := c.nextExpectedSequence()
+	if err := c.stellarCoreRunner.close(); err != nil {
+		c.config.Log.WithError(err).Warn("could not close stellar-core to reload its toml")
+	}
+	if err := c.openOnlineReplaySubprocess(ctx, from); err != nil {
+		err = errors.Wrap(err, "error restarting stellar-core with the reloaded toml")
+		c.reportTomlReload(TomlReloadEvent{Status: TomlReloadFailed, Ledger: from, Err: err})
+		return err
+	}
+	c.reportTomlReload(TomlReloadEvent{Status: TomlReloadApplied, Ledger: from})
+	return nil
+}
+
+func (c *CaptiveStellarCore) reportTomlReload(event TomlReloadEvent) {
+	event.Path = c.config.TomlPath
+	switch event.Status {
+	case TomlReloadApplied:
+		if event.Ledger == 0 {
+			c.config.Log.Infof("reloaded captive core toml %s", event.Path)
+		} else {
+			c.config.Log.Infof("reloaded captive core toml %s, restarted stellar-core from ledger %d", event.Path, event.Ledger)
+		}
+	default:
+		c.config.Log.WithError(event.Err).Warnf("could not reload captive core toml %s", event.Path)
+	}
+
+	if c.tomlReloadCounter != nil {
+		c.tomlReloadCounter.WithLabelValues(string(event.Status)).Inc()
+	}
+	if c.config.OnTomlReload != nil {
+		c.config.OnTomlReload(event)
+	}
+}
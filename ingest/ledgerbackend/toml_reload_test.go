@@ -0,0 +1,146 @@
+package ledgerbackend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/log"
+)
+
+func TestCaptiveTomlReload(t *testing.T) {
+	original, err := os.ReadFile(filepath.Join("testdata", "sample-appendix-on-disk.cfg"))
+	require.NoError(t, err)
+	tomlPath := filepath.Join(t.TempDir(), "captive-core.cfg")
+	require.NoError(t, os.WriteFile(tomlPath, original, 0644))
+	params := CaptiveCoreTomlParams{
+		NetworkPassphrase:  network.TestNetworkPassphrase,
+		HistoryArchiveURLs: []string{"http://localhost:1170"},
+		Strict:             true,
+	}
+	toml, err := NewCaptiveCoreTomlFromData(original, params)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	firstMeta := make(chan metaResult, 100)
+	for i := 2; i <= 66; i++ {
+		meta := buildLedgerCloseMeta(testLedgerHeader{sequence: uint32(i)})
+		firstMeta <- metaResult{LedgerCloseMeta: &meta}
+	}
+	first := &stellarCoreRunnerMock{}
+	first.On("runFrom", uint32(64)).Return(nil).Once()
+	first.On("getMetaPipe").Return((<-chan metaResult)(firstMeta), true)
+	first.On("context").Return(ctx)
+	first.On("close").Return(nil).Once()
+
+	// the second runner resumes after the last ledger returned and never
+	// streams the following ledger
+	secondMeta := make(chan metaResult, 1)
+	secondLedger := buildLedgerCloseMeta(testLedgerHeader{sequence: 67})
+	secondMeta <- metaResult{LedgerCloseMeta: &secondLedger}
+	second := &stellarCoreRunnerMock{}
+	second.On("runFrom", uint32(66)).Return(nil).Once()
+	second.On("getMetaPipe").Return((<-chan metaResult)(secondMeta), true)
+	second.On("context").Return(ctx)
+	second.On("close").Return(nil).Once()
+
+	thirdMeta := make(chan metaResult, 1)
+	thirdLedger := buildLedgerCloseMeta(testLedgerHeader{sequence: 68})
+	thirdMeta <- metaResult{LedgerCloseMeta: &thirdLedger}
+	third := &stellarCoreRunnerMock{}
+	third.On("runFrom", uint32(67)).Return(nil).Once()
+	third.On("getMetaPipe").Return((<-chan metaResult)(thirdMeta), true)
+	third.On("context").Return(ctx)
+
+	mockArchive := &historyarchive.MockArchive{}
+	mockArchive.
+		On("GetRootHAS").
+		Return(historyarchive.HistoryArchiveState{CurrentLedger: uint32(129)}, nil)
+
+	var eventsLock sync.Mutex
+	var events []TomlReloadEvent
+	runners := []*stellarCoreRunnerMock{first, second, third}
+	var configs []*CaptiveCoreToml
+	var captiveBackend *CaptiveStellarCore
+	captiveBackend = &CaptiveStellarCore{
+		archive: mockArchive,
+		stellarCoreRunnerFactory: func() stellarCoreRunnerInterface {
+			configs = append(configs, captiveBackend.config.Toml)
+			runner := runners[0]
+			runners = runners[1:]
+			return runner
+		},
+		checkpointManager: historyarchive.NewCheckpointManager(64),
+		tomlReloads:       make(chan struct{}, 1),
+		config: CaptiveCoreConfig{
+			Toml:       toml,
+			TomlPath:   tomlPath,
+			TomlParams: params,
+			Log:        log.New(),
+			Context:    ctx,
+			OnTomlReload: func(event TomlReloadEvent) {
+				eventsLock.Lock()
+				defer eventsLock.Unlock()
+				events = append(events, event)
+			},
+		},
+	}
+	captiveBackend.registerMetrics(prometheus.NewRegistry(), "test")
+
+	require.NoError(t, captiveBackend.PrepareRange(ctx, UnboundedRange(65)))
+	ledger, err := captiveBackend.GetLedger(ctx, 66)
+	require.NoError(t, err)
+	require.Equal(t, uint32(66), ledger.LedgerSequence())
+
+	// invalid changes are ignored
+	require.NoError(t, os.WriteFile(tomlPath, []byte(strings.Replace(string(original), "sqlite3://stellar.db", "postgres://mydb", 1)), 0644))
+	captiveBackend.tomlChanged()
+	require.Len(t, events, 1)
+	assert.Equal(t, TomlReloadInvalid, events[0].Status)
+	assert.Equal(t, tomlPath, events[0].Path)
+	assert.ErrorContains(t, events[0].Err, "invalid DATABASE parameter")
+
+	// stellar-core is restarted from the next ledger with a valid change
+	changed := strings.Replace(string(original), `ADDRESS="localhost:123"`, `ADDRESS="localhost:124"`, 1)
+	require.NoError(t, os.WriteFile(tomlPath, []byte(changed), 0644))
+	captiveBackend.tomlChanged()
+	ledger, err = captiveBackend.GetLedger(ctx, 67)
+	require.NoError(t, err)
+	require.Equal(t, uint32(67), ledger.LedgerSequence())
+	require.Len(t, events, 2)
+	assert.Equal(t, TomlReloadEvent{Path: tomlPath, Status: TomlReloadApplied, Ledger: 67}, events[1])
+	require.Len(t, configs, 2)
+	assert.Same(t, toml, configs[0])
+	assert.Equal(t, "localhost:124", configs[1].Validators[0].Address)
+
+	// a reload restarts stellar-core while GetLedger is waiting for a ledger
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		captiveBackend.tomlChanged()
+	}()
+	ledger, err = captiveBackend.GetLedger(ctx, 68)
+	require.NoError(t, err)
+	require.Equal(t, uint32(68), ledger.LedgerSequence())
+	eventsLock.Lock()
+	require.Len(t, events, 3)
+	assert.Equal(t, TomlReloadEvent{Path: tomlPath, Status: TomlReloadApplied, Ledger: 68}, events[2])
+	eventsLock.Unlock()
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(captiveBackend.tomlReloadCounter.WithLabelValues(string(TomlReloadApplied))))
+	assert.Equal(t, float64(1), testutil.ToFloat64(captiveBackend.tomlReloadCounter.WithLabelValues(string(TomlReloadInvalid))))
+	first.AssertExpectations(t)
+	second.AssertExpectations(t)
+	third.AssertExpectations(t)
+	mockArchive.AssertExpectations(t)
+}
@@ -0,0 +1,264 @@
+package ledgerbackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/xdr"
+)
+
+// Ensure VerifyingBackend implements LedgerBackend
+var _ LedgerBackend = (*VerifyingBackend)(nil)
+
+// LedgerIntegrityCheck is a check performed by the VerifyingBackend.
+type LedgerIntegrityCheck string
+
+const (
+	// LedgerHashCheck verifies the hash of the ledger header
+	LedgerHashCheck LedgerIntegrityCheck = "ledger hash"
+	// PreviousLedgerHashCheck verifies the ledger follows the previous ledger
+	PreviousLedgerHashCheck LedgerIntegrityCheck = "previous ledger hash"
+	// TxSetHashCheck verifies the transaction set matches the ledger header
+	TxSetHashCheck LedgerIntegrityCheck = "transaction set hash"
+	// TxSetResultHashCheck verifies the transaction results match the ledger
+	// header
+	TxSetResultHashCheck LedgerIntegrityCheck = "transaction result set hash"
+	// HistoryArchiveHashCheck verifies the ledger hash matches the one
+	// published in the history archive
+	HistoryArchiveHashCheck LedgerIntegrityCheck = "history archive ledger hash"
+)
+
+// LedgerIntegrityError is returned by the VerifyingBackend when a ledger fails
+// a check.
+type LedgerIntegrityError struct {
+	Sequence uint32
+	Check    LedgerIntegrityCheck
+	Expected xdr.Hash
+	Actual   xdr.Hash
+}
+
+func (e *LedgerIntegrityError) Error() string {
+	return fmt.Sprintf("ledger %d failed %s verification: expected %s, got %s",
+		e.Sequence, e.Check, e.Expected.HexString(), e.Actual.HexString())
+}
+
+type VerifyingBackendConfig struct {
+	// Optional, history archive trusted to anchor the ledgers. When set, the
+	// first ledger returned after a range is prepared or after a gap, and
+	// every checkpoint ledger, must match the ledger header published in the
+	// archive. Ledgers which are not published in the archive yet are
+	// verified at a later checkpoint, once the archive has caught up.
+	Archive historyarchive.ArchiveInterface
+}
+
+// VerifyingBackend is a ledger backend wrapping another backend, which
+// verifies the integrity of every ledger returned by GetLedger:
+//   - the ledger hash is the hash of the ledger header,
+//   - the transaction set and the transaction results hash to the values
+//     recorded in the ledger header,
+//   - consecutive ledgers are chained by their previous ledger hash.
+//
+// Those checks alone only prove the ledgers are consistent. Configure a
+// trusted history archive to prove they are the ledgers of the network: the
+// ledger hashes are then verified against the archive at the start of the
+// chain and at every checkpoint, once the archive has published them, so every
+// ledger up to the latest published checkpoint is authenticated through the
+// hash chain.
+//
+// Ledgers failing a check are not returned, GetLedger returns a
+// *LedgerIntegrityError instead.
+type VerifyingBackend struct {
+	LedgerBackend
+
+	config VerifyingBackendConfig
+	lock   sync.Mutex
+	// lastSeq and lastHash are the sequence and hash of the last ledger
+	// verified, lastSeq is 0 if no ledger was verified since the range was
+	// prepared
+	lastSeq  uint32
+	lastHash xdr.Hash
+	// latestArchiveLedger is the latest ledger published in the archive when
+	// it was last fetched, pendingArchiveChecks are the ledgers waiting for
+	// the archive to publish them
+	latestArchiveLedger  uint32
+	pendingArchiveChecks []archiveCheck
+}
+
+// archiveCheck is the hash of a ledger to verify against the history archive.
+type archiveCheck struct {
+	sequence uint32
+	hash     xdr.Hash
+}
+
+// NewVerifyingBackend returns a VerifyingBackend verifying the ledgers of base.
+func NewVerifyingBackend(base LedgerBackend, config VerifyingBackendConfig) *VerifyingBackend {
+	return &VerifyingBackend{LedgerBackend: base, config: config}
+}
+
+// PrepareRange prepares the wrapped backend, the ledger chain is anchored
+// again from the next ledger.
+func (v *VerifyingBackend) PrepareRange(ctx context.Context, ledgerRange Range) error {
+	if err := v.LedgerBackend.PrepareRange(ctx, ledgerRange); err != nil {
+		return err
+	}
+	v.lock.Lock()
+	v.lastSeq = 0
+	v.lock.Unlock()
+	return nil
+}
+
+// GetLedger returns the ledger from the wrapped backend once it is verified.
+func (v *VerifyingBackend) GetLedger(ctx context.Context, sequence uint32) (xdr.LedgerCloseMeta, error) {
+	lcm, err := v.LedgerBackend.GetLedger(ctx, sequence)
+	if err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	if err = v.verify(lcm); err != nil {
+		return xdr.LedgerCloseMeta{}, err
+	}
+	return lcm, nil
+}
+
+func (v *VerifyingBackend) verify(lcm xdr.LedgerCloseMeta) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	entry := lcm.LedgerHeaderHistoryEntry()
+	sequence := uint32(entry.Header.LedgerSeq)
+	check := func(check LedgerIntegrityCheck, expected, actual xdr.Hash) error {
+		if expected == actual {
+			return nil
+		}
+		return &LedgerIntegrityError{Sequence: sequence, Check: check, Expected: expected, Actual: actual}
+	}
+
+	hash, err := xdr.HashXdr(entry.Header)
+	if err != nil {
+		return fmt.Errorf("could not hash header of ledger %d: %w", sequence, err)
+	}
+	if err = check(LedgerHashCheck, entry.Hash, hash); err != nil {
+		return err
+	}
+	txSetHash, err := transactionSetHash(lcm)
+	if err != nil {
+		return fmt.Errorf("could not hash transaction set of ledger %d: %w", sequence, err)
+	}
+	if err = check(TxSetHashCheck, entry.Header.ScpValue.TxSetHash, txSetHash); err != nil {
+		return err
+	}
+	resultSet := xdr.TransactionResultSet{Results: make([]xdr.TransactionResultPair, 0, lcm.CountTransactions())}
+	for i := 0; i < lcm.CountTransactions(); i++ {
+		resultSet.Results = append(resultSet.Results, lcm.TransactionResultPair(i))
+	}
+	resultSetHash, err := xdr.HashXdr(resultSet)
+	if err != nil {
+		return fmt.Errorf("could not hash transaction results of ledger %d: %w", sequence, err)
+	}
+	if err = check(TxSetResultHashCheck, entry.Header.TxSetResultHash, resultSetHash); err != nil {
+		return err
+	}
+
+	anchor := false
+	switch {
+	case v.lastSeq != 0 && sequence == v.lastSeq+1:
+		err = check(PreviousLedgerHashCheck, v.lastHash, entry.Header.PreviousLedgerHash)
+	case v.lastSeq != 0 && sequence == v.lastSeq:
+		// the same ledger was requested again
+		err = check(LedgerHashCheck, v.lastHash, hash)
+	default:
+		// the ledger doesn't follow a verified ledger
+		anchor = true
+	}
+	if err != nil {
+		return err
+	}
+
+	if v.config.Archive != nil {
+		if err = v.verifyAgainstArchive(sequence, hash, anchor); err != nil {
+			return err
+		}
+	}
+
+	v.lastSeq = sequence
+	v.lastHash = hash
+	return nil
+}
+
+// verifyAgainstArchive verifies the hash of a ledger anchoring the chain or of
+// a checkpoint ledger against the history archive. Ledgers the archive hasn't
+// published yet, e.g. at the tip of the network, are queued and verified at a
+// later checkpoint once the archive has caught up, so an integrity error can
+// be returned for a ledger returned earlier.
+func (v *VerifyingBackend) verifyAgainstArchive(sequence uint32, hash xdr.Hash, anchor bool) error {
+	archive := v.config.Archive
+	if !anchor && !archive.GetCheckpointManager().IsCheckpoint(sequence) {
+		return nil
+	}
+
+	if sequence > v.latestArchiveLedger {
+		latest, err := archive.GetLatestLedgerSequence()
+		if err != nil {
+			return fmt.Errorf("could not get latest ledger from history archive: %w", err)
+		}
+		v.latestArchiveLedger = latest
+	}
+
+	remaining := v.pendingArchiveChecks[:0]
+	for i, pending := range v.pendingArchiveChecks {
+		if pending.sequence > v.latestArchiveLedger {
+			remaining = append(remaining, pending)
+			continue
+		}
+		if err := v.checkArchiveHash(pending.sequence, pending.hash); err != nil {
+			v.pendingArchiveChecks = append(remaining, v.pendingArchiveChecks[i:]...)
+			return err
+		}
+	}
+	v.pendingArchiveChecks = remaining
+
+	if sequence <= v.latestArchiveLedger {
+		return v.checkArchiveHash(sequence, hash)
+	}
+	if n := len(v.pendingArchiveChecks); n == 0 || v.pendingArchiveChecks[n-1].sequence != sequence {
+		v.pendingArchiveChecks = append(v.pendingArchiveChecks, archiveCheck{sequence: sequence, hash: hash})
+	}
+	return nil
+}
+
+func (v *VerifyingBackend) checkArchiveHash(sequence uint32, hash xdr.Hash) error {
+	trusted, err := v.config.Archive.GetLedgerHeader(sequence)
+	if err != nil {
+		return fmt.Errorf("could not get header of ledger %d from history archive: %w", sequence, err)
+	}
+	if trusted.Hash != hash {
+		return &LedgerIntegrityError{
+			Sequence: sequence,
+			Check:    HistoryArchiveHashCheck,
+			Expected: trusted.Hash,
+			Actual:   hash,
+		}
+	}
+	return nil
+}
+
+// transactionSetHash returns the hash of the transaction set of a ledger, as
+// recorded in the ledger header.
+func transactionSetHash(lcm xdr.LedgerCloseMeta) (xdr.Hash, error) {
+	switch lcm.V {
+	case 0:
+		// legacy transaction sets are hashed in hash order, which differs
+		// from the order of the ledger
+		txSet := lcm.MustV0().TxSet
+		txSet.Txs = append([]xdr.TransactionEnvelope(nil), txSet.Txs...)
+		hash, err := historyarchive.HashTxSet(&txSet)
+		return xdr.Hash(hash), err
+	case 1:
+		return xdr.HashXdr(lcm.MustV1().TxSet)
+	case 2:
+		return xdr.HashXdr(lcm.MustV2().TxSet)
+	default:
+		return xdr.Hash{}, fmt.Errorf("unsupported LedgerCloseMeta.V: %d", lcm.V)
+	}
+}
@@ -0,0 +1,210 @@
+package ledgerbackend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+func createVerifiableLedgers(t *testing.T, seed int64, end uint32) map[uint32]xdr.LedgerCloseMeta {
+	ctx := context.Background()
+	backend, err := NewSyntheticBackend(SyntheticScenario{
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		Seed:                seed,
+		Accounts:            5,
+		Assets:              1,
+		Payments:            1,
+		ContractInvocations: 1,
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, BoundedRange(2, end)))
+	ledgers := map[uint32]xdr.LedgerCloseMeta{}
+	for seq := uint32(2); seq <= end; seq++ {
+		ledgers[seq], err = backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+	}
+	return ledgers
+}
+
+func TestVerifyingBackend(t *testing.T) {
+	ctx := context.Background()
+	ledgers := createVerifiableLedgers(t, 1, 17)
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetCheckpointManager").Return(historyarchive.NewCheckpointManager(8))
+	archive.On("GetLatestLedgerSequence").Return(uint32(23), nil).Once()
+	// ledger 3 anchors the chain, 7 and 15 are checkpoints
+	for _, seq := range []uint32{3, 7, 15} {
+		archive.On("GetLedgerHeader", seq).Return(ledgers[seq].LedgerHeaderHistoryEntry(), nil).Once()
+	}
+	t.Cleanup(func() { archive.AssertExpectations(t) })
+
+	base, err := NewSyntheticBackend(SyntheticScenario{
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		Seed:                1,
+		Accounts:            5,
+		Assets:              1,
+		Payments:            1,
+		ContractInvocations: 1,
+	})
+	require.NoError(t, err)
+	backend := NewVerifyingBackend(base, VerifyingBackendConfig{Archive: archive})
+	require.NoError(t, backend.PrepareRange(ctx, BoundedRange(3, 17)))
+	for seq := uint32(3); seq <= 17; seq++ {
+		lcm, err := backend.GetLedger(ctx, seq)
+		require.NoError(t, err)
+		require.Equal(t, ledgers[seq], lcm)
+	}
+	// the same ledger can be requested again
+	_, err = backend.GetLedger(ctx, 17)
+	require.NoError(t, err)
+
+	// the chain is anchored again after the range is prepared
+	archive.On("GetLedgerHeader", uint32(10)).Return(xdr.LedgerHeaderHistoryEntry{}, errors.New("unavailable")).Once()
+	require.NoError(t, backend.PrepareRange(ctx, BoundedRange(10, 17)))
+	_, err = backend.GetLedger(ctx, 10)
+	require.EqualError(t, err, "could not get header of ledger 10 from history archive: unavailable")
+}
+
+func TestVerifyingBackendArchiveBehind(t *testing.T) {
+	ctx := context.Background()
+	ledgers := createVerifiableLedgers(t, 1, 23)
+	forkedLedgers := createVerifiableLedgers(t, 2, 15)
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetCheckpointManager").Return(historyarchive.NewCheckpointManager(8))
+	t.Cleanup(func() { archive.AssertExpectations(t) })
+	base := &MockDatabaseBackend{}
+	t.Cleanup(func() { base.AssertExpectations(t) })
+	backend := NewVerifyingBackend(base, VerifyingBackendConfig{Archive: archive})
+	base.On("PrepareRange", ctx, UnboundedRange(3)).Return(nil).Once()
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(3)))
+	getLedgers := func(from, to uint32) {
+		for seq := from; seq <= to; seq++ {
+			base.On("GetLedger", ctx, seq).Return(ledgers[seq], nil).Once()
+			lcm, err := backend.GetLedger(ctx, seq)
+			require.NoError(t, err)
+			require.Equal(t, ledgers[seq], lcm)
+		}
+	}
+
+	// the archive hasn't published the anchor and the first checkpoint yet
+	archive.On("GetLatestLedgerSequence").Return(uint32(0), nil).Twice()
+	getLedgers(3, 14)
+	// they are verified at the next checkpoint
+	archive.On("GetLatestLedgerSequence").Return(uint32(7), nil).Once()
+	archive.On("GetLedgerHeader", uint32(3)).Return(ledgers[3].LedgerHeaderHistoryEntry(), nil).Once()
+	archive.On("GetLedgerHeader", uint32(7)).Return(ledgers[7].LedgerHeaderHistoryEntry(), nil).Once()
+	getLedgers(15, 22)
+
+	// a queued ledger which doesn't match the archive fails a later ledger
+	archive.On("GetLatestLedgerSequence").Return(uint32(15), nil).Once()
+	archive.On("GetLedgerHeader", uint32(15)).Return(forkedLedgers[15].LedgerHeaderHistoryEntry(), nil).Once()
+	base.On("GetLedger", ctx, uint32(23)).Return(ledgers[23], nil).Once()
+	_, err := backend.GetLedger(ctx, 23)
+	require.Equal(t, &LedgerIntegrityError{
+		Sequence: 15,
+		Check:    HistoryArchiveHashCheck,
+		Expected: forkedLedgers[15].LedgerHash(),
+		Actual:   ledgers[15].LedgerHash(),
+	}, err)
+}
+
+func TestVerifyingBackendIntegrityErrors(t *testing.T) {
+	ctx := context.Background()
+	ledgers := createVerifiableLedgers(t, 1, 6)
+	forkedLedgers := createVerifiableLedgers(t, 2, 6)
+
+	for _, testCase := range []struct {
+		name     string
+		sequence uint32
+		tamper   func(lcm *xdr.LedgerCloseMeta) xdr.LedgerCloseMeta
+		check    LedgerIntegrityCheck
+	}{
+		{
+			name:     "header modified",
+			sequence: 5,
+			tamper: func(lcm *xdr.LedgerCloseMeta) xdr.LedgerCloseMeta {
+				lcm.V2.LedgerHeader.Header.FeePool++
+				return *lcm
+			},
+			check: LedgerHashCheck,
+		},
+		{
+			name:     "transaction removed",
+			sequence: 5,
+			tamper: func(lcm *xdr.LedgerCloseMeta) xdr.LedgerCloseMeta {
+				components := *lcm.V2.TxSet.V1TxSet.Phases[0].V0Components
+				txs := components[0].TxsMaybeDiscountedFee.Txs
+				components[0].TxsMaybeDiscountedFee.Txs = txs[1:]
+				return *lcm
+			},
+			check: TxSetHashCheck,
+		},
+		{
+			name:     "result modified",
+			sequence: 5,
+			tamper: func(lcm *xdr.LedgerCloseMeta) xdr.LedgerCloseMeta {
+				lcm.V2.TxProcessing[0].Result.Result.FeeCharged++
+				return *lcm
+			},
+			check: TxSetResultHashCheck,
+		},
+		{
+			name:     "ledger of another chain",
+			sequence: 5,
+			tamper: func(lcm *xdr.LedgerCloseMeta) xdr.LedgerCloseMeta {
+				return forkedLedgers[5]
+			},
+			check: PreviousLedgerHashCheck,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			base := &MockDatabaseBackend{}
+			backend := NewVerifyingBackend(base, VerifyingBackendConfig{})
+			base.On("PrepareRange", ctx, BoundedRange(4, 6)).Return(nil).Once()
+			require.NoError(t, backend.PrepareRange(ctx, BoundedRange(4, 6)))
+			base.On("GetLedger", ctx, uint32(4)).Return(ledgers[4], nil).Once()
+			_, err := backend.GetLedger(ctx, 4)
+			require.NoError(t, err)
+
+			// tamper with a copy of the ledger
+			var lcm xdr.LedgerCloseMeta
+			raw, err := ledgers[testCase.sequence].MarshalBinary()
+			require.NoError(t, err)
+			require.NoError(t, lcm.UnmarshalBinary(raw))
+			base.On("GetLedger", ctx, testCase.sequence).Return(testCase.tamper(&lcm), nil).Once()
+
+			_, err = backend.GetLedger(ctx, testCase.sequence)
+			var integrityErr *LedgerIntegrityError
+			require.ErrorAs(t, err, &integrityErr)
+			require.Equal(t, testCase.check, integrityErr.Check)
+			require.Equal(t, testCase.sequence, integrityErr.Sequence)
+			base.AssertExpectations(t)
+		})
+	}
+
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetLatestLedgerSequence").Return(uint32(7), nil).Once()
+	archive.On("GetLedgerHeader", uint32(4)).Return(forkedLedgers[4].LedgerHeaderHistoryEntry(), nil).Once()
+	base := &MockDatabaseBackend{}
+	backend := NewVerifyingBackend(base, VerifyingBackendConfig{Archive: archive})
+	base.On("PrepareRange", ctx, UnboundedRange(4)).Return(nil).Once()
+	require.NoError(t, backend.PrepareRange(ctx, UnboundedRange(4)))
+	base.On("GetLedger", ctx, uint32(4)).Return(ledgers[4], nil).Once()
+	_, err := backend.GetLedger(ctx, 4)
+	require.Equal(t, &LedgerIntegrityError{
+		Sequence: 4,
+		Check:    HistoryArchiveHashCheck,
+		Expected: forkedLedgers[4].LedgerHash(),
+		Actual:   ledgers[4].LedgerHash(),
+	}, err)
+	require.EqualError(t, err, "ledger 4 failed history archive ledger hash verification: expected "+
+		forkedLedgers[4].LedgerHash().HexString()+", got "+ledgers[4].LedgerHash().HexString())
+	archive.AssertExpectations(t)
+	base.AssertExpectations(t)
+}
@@ -0,0 +1,488 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	defaultParallelWorkers   = 4
+	defaultReorderBufferSize = 1000
+)
+
+// ParallelPublisherConfig configures ApplyLedgerMetadataParallel.
+type ParallelPublisherConfig struct {
+	// BufferedStorageConfig, required, configures the BufferedStorageBackend
+	// of each worker. Downloads run concurrently for every worker, up to
+	// Workers * BufferedStorageConfig.NumWorkers files at a time.
+	BufferedStorageConfig ledgerbackend.BufferedStorageBackendConfig
+	// DataStoreConfig, required
+	DataStoreConfig datastore.DataStoreConfig
+	// Log, optional, if nil uses go default logger
+	Log *log.Entry
+
+	// Workers, optional, number of subranges processed concurrently,
+	// defaults to 4.
+	Workers uint32
+	// SubrangeSize, optional, number of ledgers of each subrange, rounded up
+	// to a multiple of the ledgers per file of the datastore. Defaults to
+	// splitting the range evenly between the workers.
+	SubrangeSize uint32
+	// Ordered, optional, when true the callback is invoked in ledger sequence
+	// order from a single goroutine. Otherwise it is invoked concurrently by
+	// the workers, in sequence order within each subrange only.
+	Ordered bool
+	// ReorderBufferSize, optional, the maximum number of ledgers buffered
+	// while waiting for an earlier ledger in ordered mode, defaults to 1000.
+	ReorderBufferSize uint32
+	// Checkpoints, optional, records the progress of each subrange so a
+	// failed run of the same range and SubrangeSize resumes where it stopped.
+	Checkpoints ParallelCheckpointStore
+}
+
+// ParallelCheckpointStore persists the progress of ApplyLedgerMetadataParallel.
+// Implementations must be safe for concurrent use.
+type ParallelCheckpointStore interface {
+	// Load returns the last ledger processed of each subrange, by the first
+	// ledger of the subrange.
+	Load(ctx context.Context) (map[uint32]uint32, error)
+	// Save records lastLedger as the last ledger processed of the subrange
+	// starting at subrangeStart.
+	Save(ctx context.Context, subrangeStart, lastLedger uint32) error
+}
+
+// FileCheckpointStore is a ParallelCheckpointStore keeping checkpoints in a
+// JSON file on the local filesystem.
+type FileCheckpointStore struct {
+	path string
+	lock sync.Mutex
+}
+
+// NewFileCheckpointStore returns a FileCheckpointStore using the file at
+// path, which is created on the first Save.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) load() (map[uint32]uint32, error) {
+	checkpoints := map[uint32]uint32{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	if err = json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoints %s: %w", s.path, err)
+	}
+	return checkpoints, nil
+}
+
+func (s *FileCheckpointStore) Load(_ context.Context) (map[uint32]uint32, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.load()
+}
+
+func (s *FileCheckpointStore) Save(_ context.Context, subrangeStart, lastLedger uint32) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	checkpoints, err := s.load()
+	if err != nil {
+		return err
+	}
+	checkpoints[subrangeStart] = lastLedger
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced atomically so a crash can't leave it truncated
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return nil
+}
+
+// SplitLedgerRange splits a bounded range into consecutive subranges of
+// subrangeSize ledgers, rounded up to a multiple of the ledgers per file of
+// the schema. Subranges are aligned on file boundaries so no ledger file is
+// downloaded by more than one of them, only the first and last subranges may
+// be shorter.
+func SplitLedgerRange(ledgerRange ledgerbackend.Range, schema datastore.DataStoreSchema, subrangeSize uint32) ([]ledgerbackend.Range, error) {
+	if !ledgerRange.Bounded() {
+		return nil, fmt.Errorf("invalid range %v, must be bounded", ledgerRange)
+	}
+	if ledgerRange.To() < ledgerRange.From() {
+		return nil, fmt.Errorf("invalid range %v, end must be greater than or equal to start", ledgerRange)
+	}
+	if schema.LedgersPerFile == 0 {
+		return nil, fmt.Errorf("ledgersPerFile must be > 0")
+	}
+	if subrangeSize == 0 {
+		return nil, fmt.Errorf("subrange size must be > 0")
+	}
+	if remainder := subrangeSize % schema.LedgersPerFile; remainder != 0 {
+		subrangeSize += schema.LedgersPerFile - remainder
+	}
+
+	var subranges []ledgerbackend.Range
+	// uint64 avoids overflowing after the last ledger
+	for start := uint64(ledgerRange.From()); start <= uint64(ledgerRange.To()); {
+		// subranges end on the boundary of the file containing their first
+		// ledger, then cover subrangeSize ledgers
+		end := uint64(schema.GetSequenceNumberStartBoundary(uint32(start))) + uint64(subrangeSize) - 1
+		end = min(end, uint64(ledgerRange.To()))
+		subranges = append(subranges, ledgerbackend.BoundedRange(uint32(start), uint32(end)))
+		start = end + 1
+	}
+	return subranges, nil
+}
+
+// ApplyLedgerMetadataParallel - emits ledger metadata for a bounded range
+// like ApplyLedgerMetadata, but splits the range into file aligned subranges
+// processed concurrently by workers, each owning a BufferedStorageBackend.
+//
+// The function is blocking, it will only return when the range is completed,
+// the ctx is canceled, or an error occurs. Processing stops on the first
+// error, ledgers already emitted by other workers are not rolled back.
+//
+// ledgerRange - the requested range, must be bounded.
+//
+// publisherConfig - ParallelPublisherConfig. Set Ordered to receive ledgers in
+// sequence order, otherwise callback must be safe for concurrent use. Set
+// Checkpoints to resume a failed run.
+//
+// ctx - the context. Caller uses this to cancel the internal ledger processing,
+// when canceled, the function will return asap with that error.
+//
+// callback - function. Invoked for every LedgerCloseMeta. If callback invocation
+// returns an error, the processing will stop and return an error asap.
+//
+// return - error, nil only if the range completed processing with no errors.
+func ApplyLedgerMetadataParallel(ledgerRange ledgerbackend.Range,
+	publisherConfig ParallelPublisherConfig,
+	ctx context.Context,
+	callback func(xdr.LedgerCloseMeta) error) error {
+
+	logger := publisherConfig.Log
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+	if !ledgerRange.Bounded() || ledgerRange.To() <= ledgerRange.From() {
+		return fmt.Errorf("invalid range, must be bounded with end greater than start")
+	}
+	ledgerRange = ledgerbackend.BoundedRange(max(2, ledgerRange.From()), ledgerRange.To())
+
+	workers := publisherConfig.Workers
+	if workers == 0 {
+		workers = defaultParallelWorkers
+	}
+	reorderBufferSize := publisherConfig.ReorderBufferSize
+	if reorderBufferSize == 0 {
+		reorderBufferSize = defaultReorderBufferSize
+	}
+
+	dataStore, err := datastoreFactory(ctx, publisherConfig.DataStoreConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create datastore: %w", err)
+	}
+	defer dataStore.Close()
+
+	schema, err := datastore.LoadSchema(context.Background(), dataStore, publisherConfig.DataStoreConfig)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve datastore schema: %w", err)
+	}
+	// a backend is created upfront so an invalid config fails before any
+	// ledger is processed
+	if _, err = ledgerbackend.NewBufferedStorageBackend(publisherConfig.BufferedStorageConfig, dataStore, schema); err != nil {
+		return fmt.Errorf("failed to create buffered storage backend: %w", err)
+	}
+
+	subrangeSize := publisherConfig.SubrangeSize
+	if subrangeSize == 0 {
+		subrangeSize = (ledgerRange.To() - ledgerRange.From() + workers) / workers
+	}
+	subranges, err := SplitLedgerRange(ledgerRange, schema, subrangeSize)
+	if err != nil {
+		return err
+	}
+
+	// skip the ledgers processed by a previous run
+	var checkpoints map[uint32]uint32
+	if publisherConfig.Checkpoints != nil {
+		if checkpoints, err = publisherConfig.Checkpoints.Load(ctx); err != nil {
+			return fmt.Errorf("failed to load checkpoints: %w", err)
+		}
+	}
+	var pending []parallelSubrange
+	for _, subrange := range subranges {
+		next := subrange.From()
+		if last, ok := checkpoints[subrange.From()]; ok && last >= subrange.From() {
+			if last >= subrange.To() {
+				continue
+			}
+			next = last + 1
+		}
+		pending = append(pending, parallelSubrange{start: subrange.From(), next: next, end: subrange.To()})
+	}
+	logger.Infof("Processing ledgers %d-%d in %d subranges with %d workers, %d subranges remaining",
+		ledgerRange.From(), ledgerRange.To(), len(subranges), workers, len(pending))
+
+	p := &parallelProducer{
+		config:    publisherConfig,
+		logger:    logger,
+		dataStore: dataStore,
+		schema:    schema,
+		callback:  callback,
+	}
+	return p.run(ctx, pending, workers, reorderBufferSize)
+}
+
+// parallelSubrange is a subrange with ledgers left to process, from next to
+// end. start is the first ledger of the subrange, which identifies its
+// checkpoints.
+type parallelSubrange struct {
+	start, next, end uint32
+}
+
+type parallelProducer struct {
+	config    ParallelPublisherConfig
+	logger    *log.Entry
+	dataStore datastore.DataStore
+	schema    datastore.DataStoreSchema
+	callback  func(xdr.LedgerCloseMeta) error
+	reorder   *reorderBuffer
+}
+
+func (p *parallelProducer) run(ctx context.Context, pending []parallelSubrange, workers, reorderBufferSize uint32) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	if p.config.Ordered && len(pending) > 0 {
+		p.reorder = newReorderBuffer(ctx, pending[0].next, reorderBufferSize)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.deliverOrdered(ctx, pending); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+	// subranges are assigned in sequence order, so in ordered mode the
+	// subrange of the next ledger to deliver is always being processed
+	subrangeCh := make(chan parallelSubrange)
+	for i := uint32(0); i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for subrange := range subrangeCh {
+				if err := p.processSubrange(ctx, subrange); err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+	for _, subrange := range pending {
+		select {
+		case subrangeCh <- subrange:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(subrangeCh)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// the parent context was canceled
+	return ctx.Err()
+}
+
+// processSubrange reads the ledgers of a subrange with its own backend and
+// either invokes the callback or hands them to the reorder buffer.
+func (p *parallelProducer) processSubrange(ctx context.Context, subrange parallelSubrange) error {
+	backend, err := ledgerbackend.NewBufferedStorageBackend(p.config.BufferedStorageConfig, p.dataStore, p.schema)
+	if err != nil {
+		return fmt.Errorf("failed to create buffered storage backend: %w", err)
+	}
+	defer backend.Close()
+
+	p.logger.Infof("Processing subrange %d-%d from ledger %d", subrange.start, subrange.end, subrange.next)
+	if err = backend.PrepareRange(ctx, ledgerbackend.BoundedRange(subrange.next, subrange.end)); err != nil {
+		return fmt.Errorf("failed to prepare range %d-%d: %w", subrange.next, subrange.end, err)
+	}
+
+	startTime := time.Now()
+	for ledgerSeq := subrange.next; ledgerSeq <= subrange.end; ledgerSeq++ {
+		ledgerCloseMeta, err := backend.GetLedger(ctx, ledgerSeq)
+		if err != nil {
+			return fmt.Errorf("error getting ledger, %w", err)
+		}
+
+		if p.reorder != nil {
+			if err = p.reorder.put(ledgerCloseMeta); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = p.callback(ledgerCloseMeta); err != nil {
+			return fmt.Errorf("received an error from callback invocation: %w", err)
+		}
+		if err = p.checkpoint(ctx, subrange, ledgerSeq); err != nil {
+			return err
+		}
+	}
+
+	p.logger.WithFields(log.F{
+		"start":    subrange.next,
+		"end":      subrange.end,
+		"duration": time.Since(startTime).Seconds(),
+	}).Info("Subrange read from the backend")
+	return nil
+}
+
+// deliverOrdered invokes the callback for the ledgers of the pending
+// subranges in sequence order, as they are handed to the reorder buffer.
+func (p *parallelProducer) deliverOrdered(ctx context.Context, pending []parallelSubrange) error {
+	for _, subrange := range pending {
+		for ledgerSeq := subrange.next; ledgerSeq <= subrange.end; ledgerSeq++ {
+			ledgerCloseMeta, err := p.reorder.take(ledgerSeq)
+			if err != nil {
+				return err
+			}
+			if err = p.callback(ledgerCloseMeta); err != nil {
+				return fmt.Errorf("received an error from callback invocation: %w", err)
+			}
+			if err = p.checkpoint(ctx, subrange, ledgerSeq); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkpoint saves the progress of a subrange after the last ledger of each
+// file and of the subrange.
+func (p *parallelProducer) checkpoint(ctx context.Context, subrange parallelSubrange, ledgerSeq uint32) error {
+	if p.config.Checkpoints == nil {
+		return nil
+	}
+	if ledgerSeq != subrange.end && ledgerSeq != p.schema.GetSequenceNumberEndBoundary(ledgerSeq) {
+		return nil
+	}
+	if err := p.config.Checkpoints.Save(ctx, subrange.start, ledgerSeq); err != nil {
+		return fmt.Errorf("failed to save checkpoint of subrange %d-%d: %w", subrange.start, subrange.end, err)
+	}
+	return nil
+}
+
+// reorderBuffer re-sequences ledgers read concurrently. Ledgers are only
+// admitted within size ledgers of the next ledger to deliver, so the buffer
+// stays bounded and the ledger being waited for can always be admitted.
+type reorderBuffer struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	next    uint32
+	size    uint32
+	ledgers map[uint32]xdr.LedgerCloseMeta
+	err     error
+}
+
+func newReorderBuffer(ctx context.Context, next, size uint32) *reorderBuffer {
+	b := &reorderBuffer{
+		next:    next,
+		size:    size,
+		ledgers: make(map[uint32]xdr.LedgerCloseMeta, size),
+	}
+	b.cond = sync.NewCond(&b.lock)
+	// wake up blocked callers once the context is done, the goroutine exits
+	// when the producer cancels the context on return
+	go func() {
+		<-ctx.Done()
+		b.lock.Lock()
+		b.err = ctx.Err()
+		b.lock.Unlock()
+		b.cond.Broadcast()
+	}()
+	return b
+}
+
+// put adds a ledger to the buffer, blocking while it is too far ahead of the
+// next ledger to deliver.
+func (b *reorderBuffer) put(ledgerCloseMeta xdr.LedgerCloseMeta) error {
+	seq := ledgerCloseMeta.LedgerSequence()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for b.err == nil && uint64(seq) >= uint64(b.next)+uint64(b.size) {
+		b.cond.Wait()
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.ledgers[seq] = ledgerCloseMeta
+	b.cond.Broadcast()
+	return nil
+}
+
+// take removes the ledger seq from the buffer, blocking until it is added.
+// Ledgers must be taken in ascending order.
+func (b *reorderBuffer) take(seq uint32) (xdr.LedgerCloseMeta, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	// ledgers skipped by a checkpoint are never added
+	b.next = seq
+	b.cond.Broadcast()
+	for {
+		if b.err != nil {
+			return xdr.LedgerCloseMeta{}, b.err
+		}
+		if ledgerCloseMeta, ok := b.ledgers[seq]; ok {
+			delete(b.ledgers, seq)
+			b.next = seq + 1
+			b.cond.Broadcast()
+			return ledgerCloseMeta, nil
+		}
+		b.cond.Wait()
+	}
+}
@@ -0,0 +1,170 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+// createFilesystemDataStore exports ledgers start to end to a filesystem
+// datastore with 2 ledgers per file and returns its config.
+func createFilesystemDataStore(t *testing.T, start, end uint32) datastore.DataStoreConfig {
+	ctx := context.Background()
+	config := datastore.DataStoreConfig{
+		Type:              "Filesystem",
+		Params:            map[string]string{"destination_path": t.TempDir()},
+		Schema:            datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4},
+		NetworkPassphrase: "passphrase",
+	}
+	dataStore, err := datastore.NewDataStore(ctx, config)
+	require.NoError(t, err)
+	_, _, err = datastore.PublishConfig(ctx, dataStore, config)
+	require.NoError(t, err)
+
+	for fileStart := config.Schema.GetSequenceNumberStartBoundary(start); fileStart <= end; fileStart += 2 {
+		batch := xdr.LedgerCloseMetaBatch{StartSequence: xdr.Uint32(max(fileStart, start)), EndSequence: xdr.Uint32(fileStart + 1)}
+		for seq := max(fileStart, start); seq <= fileStart+1; seq++ {
+			require.NoError(t, batch.AddLedger(createLedgerCloseMeta(seq)))
+		}
+		var buf bytes.Buffer
+		_, err = compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+		require.NoError(t, err)
+		require.NoError(t, dataStore.PutFile(ctx, config.Schema.GetObjectKeyFromSequenceNumber(fileStart), &buf, nil))
+	}
+	datastoreFactory = datastore.NewDataStore
+	return config
+}
+
+func sequenceRange(start, end uint32) []uint32 {
+	var seqs []uint32
+	for seq := start; seq <= end; seq++ {
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+func TestSplitLedgerRange(t *testing.T) {
+	schema := datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: 1}
+	subranges, err := SplitLedgerRange(ledgerbackend.BoundedRange(2, 21), schema, 7)
+	require.NoError(t, err)
+	require.Equal(t, []ledgerbackend.Range{
+		ledgerbackend.BoundedRange(2, 7),
+		ledgerbackend.BoundedRange(8, 15),
+		ledgerbackend.BoundedRange(16, 21),
+	}, subranges)
+
+	subranges, err = SplitLedgerRange(ledgerbackend.BoundedRange(9, 9), schema, 8)
+	require.NoError(t, err)
+	require.Equal(t, []ledgerbackend.Range{ledgerbackend.BoundedRange(9, 9)}, subranges)
+
+	_, err = SplitLedgerRange(ledgerbackend.UnboundedRange(2), schema, 8)
+	require.ErrorContains(t, err, "must be bounded")
+	_, err = SplitLedgerRange(ledgerbackend.BoundedRange(2, 10), schema, 0)
+	require.EqualError(t, err, "subrange size must be > 0")
+}
+
+func TestApplyLedgerMetadataParallel(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		config := ParallelPublisherConfig{
+			DataStoreConfig:       createFilesystemDataStore(t, 2, 41),
+			BufferedStorageConfig: DefaultBufferedStorageBackendConfig(2),
+			Workers:               3,
+			SubrangeSize:          5,
+			Ordered:               ordered,
+			ReorderBufferSize:     3,
+		}
+
+		var lock sync.Mutex
+		var seqs []uint32
+		require.NoError(t, ApplyLedgerMetadataParallel(ledgerbackend.BoundedRange(3, 40), config, context.Background(),
+			func(lcm xdr.LedgerCloseMeta) error {
+				lock.Lock()
+				defer lock.Unlock()
+				seqs = append(seqs, lcm.LedgerSequence())
+				return nil
+			}))
+
+		if ordered {
+			require.True(t, sort.SliceIsSorted(seqs, func(i, j int) bool { return seqs[i] < seqs[j] }))
+		} else {
+			sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+		}
+		require.Equal(t, sequenceRange(3, 40), seqs)
+	}
+}
+
+func TestApplyLedgerMetadataParallelResume(t *testing.T) {
+	checkpoints := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoints.json"))
+	config := ParallelPublisherConfig{
+		DataStoreConfig:       createFilesystemDataStore(t, 2, 41),
+		BufferedStorageConfig: DefaultBufferedStorageBackendConfig(2),
+		Workers:               4,
+		SubrangeSize:          8,
+		Ordered:               true,
+		Checkpoints:           checkpoints,
+	}
+
+	var seqs []uint32
+	callback := func(lcm xdr.LedgerCloseMeta) error {
+		if lcm.LedgerSequence() == 21 {
+			return errors.New("uhoh")
+		}
+		seqs = append(seqs, lcm.LedgerSequence())
+		return nil
+	}
+	require.ErrorContains(t,
+		ApplyLedgerMetadataParallel(ledgerbackend.BoundedRange(2, 41), config, context.Background(), callback),
+		"received an error from callback invocation: uhoh")
+	require.Equal(t, sequenceRange(2, 20), seqs)
+
+	// ledger 20 was processed but the file of ledgers 20-21 wasn't
+	saved, err := checkpoints.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]uint32{2: 9, 10: 17, 18: 19}, saved)
+
+	seqs = nil
+	callback = func(lcm xdr.LedgerCloseMeta) error {
+		seqs = append(seqs, lcm.LedgerSequence())
+		return nil
+	}
+	require.NoError(t,
+		ApplyLedgerMetadataParallel(ledgerbackend.BoundedRange(2, 41), config, context.Background(), callback))
+	require.Equal(t, sequenceRange(20, 41), seqs)
+
+	// a completed range is not processed again
+	seqs = nil
+	require.NoError(t,
+		ApplyLedgerMetadataParallel(ledgerbackend.BoundedRange(2, 41), config, context.Background(), callback))
+	require.Empty(t, seqs)
+}
+
+func TestApplyLedgerMetadataParallelGetLedgerError(t *testing.T) {
+	config := ParallelPublisherConfig{
+		DataStoreConfig:       createFilesystemDataStore(t, 2, 9),
+		BufferedStorageConfig: DefaultBufferedStorageBackendConfig(2),
+		Workers:               2,
+		SubrangeSize:          4,
+	}
+	config.BufferedStorageConfig.RetryLimit = 0
+
+	// ledgers 10 and 11 are missing
+	err := ApplyLedgerMetadataParallel(ledgerbackend.BoundedRange(2, 11), config, context.Background(),
+		func(lcm xdr.LedgerCloseMeta) error { return nil })
+	require.ErrorContains(t, err, "error getting ledger")
+
+	err = ApplyLedgerMetadataParallel(ledgerbackend.UnboundedRange(2), config, context.Background(),
+		func(lcm xdr.LedgerCloseMeta) error { return nil })
+	require.EqualError(t, err, "invalid range, must be bounded with end greater than start")
+}
@@ -188,7 +188,7 @@ func configManifestJSON(t *testing.T) []byte {
 	var expectedManifest = datastore.DatastoreManifest{
 		NetworkPassphrase: "passphrase",
 		Version:           "1.0",
-		Compression:       "xyz",
+		Compression:       "zstd",
 		LedgersPerFile:    1,
 		FilesPerPartition: 1,
 	}
@@ -223,7 +223,7 @@ func createMockdataStore(t *testing.T, start, end, partitionSize uint32) *datast
 	var expectedManifest = datastore.DatastoreManifest{
 		NetworkPassphrase: "passphrase",
 		Version:           "1.0",
-		Compression:       "xyz",
+		Compression:       "zstd",
 		LedgersPerFile:    1,
 		FilesPerPartition: partitionSize,
 	}
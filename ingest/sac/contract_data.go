@@ -190,14 +190,29 @@ func ContractBalanceFromContractData(ledgerEntry xdr.LedgerEntry, passphrase str
 	if !ok {
 		return [32]byte{}, nil, false
 	}
-	if contractData.Durability != xdr.ContractDataDurabilityPersistent {
-		return [32]byte{}, nil, false
-	}
 	// we don't support asset stats for lumens
 	nativeAssetContractID, err := xdr.MustNewNativeAsset().ContractID(passphrase)
 	if err != nil || (contractData.Contract.ContractId != nil && *contractData.Contract.ContractId == nativeAssetContractID) {
 		return [32]byte{}, nil, false
 	}
+	return contractBalanceFromContractData(contractData)
+}
+
+// ContractBalanceFromContractDataWithNative is like
+// ContractBalanceFromContractData but it also returns the balances held in
+// the Stellar Asset Contract of lumens.
+func ContractBalanceFromContractDataWithNative(ledgerEntry xdr.LedgerEntry) ([32]byte, *big.Int, bool) {
+	contractData, ok := ledgerEntry.Data.GetContractData()
+	if !ok {
+		return [32]byte{}, nil, false
+	}
+	return contractBalanceFromContractData(contractData)
+}
+
+func contractBalanceFromContractData(contractData xdr.ContractDataEntry) ([32]byte, *big.Int, bool) {
+	if contractData.Durability != xdr.ContractDataDurabilityPersistent {
+		return [32]byte{}, nil, false
+	}
 
 	keyEnumVecPtr, ok := contractData.Key.GetVec()
 	if !ok || keyEnumVecPtr == nil {
@@ -0,0 +1,381 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// StateSnapshotConfig configures a StateSnapshotReader.
+type StateSnapshotConfig struct {
+	// Archive is the history archive the state of the checkpoint preceding
+	// the ledger is read from.
+	Archive historyarchive.ArchiveInterface
+	// LedgerBackend is the backend the ledgers following the checkpoint are
+	// read from. It is only required if the ledger is not a checkpoint ledger,
+	// the range of the ledgers is prepared if needed.
+	LedgerBackend ledgerbackend.LedgerBackend
+	// NetworkPassphrase is the passphrase of the network of the ledgers.
+	NetworkPassphrase string
+
+	// Optional fields
+
+	// EntryTypes are the types of the ledger entries returned. All the entries
+	// are returned if empty.
+	EntryTypes []xdr.LedgerEntryType
+	// Accounts and Contracts restrict the ledger entries returned to the
+	// entries they own: the accounts, trust lines, offers and data entries of
+	// the accounts, and the contract data of the contracts along with their
+	// TTL entries. All the entries are returned if both are empty.
+	Accounts  []string
+	Contracts []string
+	// SpillDirectory is the directory of the temporary files the ledger
+	// entries and their sorted index are written to while the state is built.
+	// When empty, the state is held in memory, which doesn't fit the state of
+	// pubnet on most hosts. The memory used doesn't depend on the size of the
+	// state when set.
+	SpillDirectory string
+}
+
+// StateSnapshotReader is a ChangeReader which returns the state of the
+// network at any ledger, as CREATED changes in ledger key order. The state is
+// built from the history archive snapshot of the checkpoint preceding the
+// ledger, on which the changes of the following ledgers are applied once
+// compacted by a ChangeCompactor, and from which the entries they evicted are
+// removed along with their TTL entries.
+//
+// The state is built on the first call to Read, which can take a long time for
+// pubnet. Note that the returned StateSnapshotReader is not thread safe and
+// should not be shared by multiple goroutines.
+type StateSnapshotReader struct {
+	ctx      context.Context
+	config   StateSnapshotConfig
+	sequence uint32
+
+	entryTypes map[xdr.LedgerEntryType]bool
+	accounts   map[string]bool
+	contracts  map[xdr.ContractId]bool
+
+	store    stateSnapshotStore
+	iterator stateSnapshotIterator
+	// ownedKeyHashes are the hashes of the keys of the contract entries
+	// retained, which own the TTL entries retained when filtering by owner
+	ownedKeyHashes map[xdr.Hash]bool
+	// pendingTTLs are the TTL entries of the checkpoint whose owner has not
+	// been read yet when filtering by owner
+	pendingTTLs    *pendingTTLs
+	encodingBuffer *xdr.EncodingBuffer
+
+	// For testing
+	newCheckpointReader func(ctx context.Context, archive historyarchive.ArchiveInterface, sequence uint32) (ChangeReader, error)
+}
+
+// Ensure StateSnapshotReader implements ChangeReader
+var _ ChangeReader = (*StateSnapshotReader)(nil)
+
+// NewStateSnapshotReader constructs a new StateSnapshotReader returning the
+// state of the network after the given ledger was closed. The ledger must not
+// precede the first checkpoint ledger.
+func NewStateSnapshotReader(ctx context.Context, sequence uint32, config StateSnapshotConfig) (*StateSnapshotReader, error) {
+	if config.Archive == nil {
+		return nil, errors.New("history archive is required")
+	}
+	manager := config.Archive.GetCheckpointManager()
+	if checkpoint := manager.PrevCheckpoint(sequence); checkpoint > sequence {
+		return nil, errors.Errorf("ledger %d precedes the first checkpoint ledger %d", sequence, checkpoint)
+	} else if checkpoint < sequence && config.LedgerBackend == nil {
+		return nil, errors.Errorf("ledger backend is required, %d is not a checkpoint ledger", sequence)
+	}
+
+	reader := &StateSnapshotReader{
+		ctx:            ctx,
+		config:         config,
+		sequence:       sequence,
+		entryTypes:     map[xdr.LedgerEntryType]bool{},
+		accounts:       map[string]bool{},
+		contracts:      map[xdr.ContractId]bool{},
+		ownedKeyHashes: map[xdr.Hash]bool{},
+		encodingBuffer: xdr.NewEncodingBuffer(),
+		newCheckpointReader: func(ctx context.Context, archive historyarchive.ArchiveInterface, sequence uint32) (ChangeReader, error) {
+			return NewCheckpointChangeReader(ctx, archive, sequence)
+		},
+	}
+	for _, entryType := range config.EntryTypes {
+		reader.entryTypes[entryType] = true
+	}
+	for _, account := range config.Accounts {
+		if !strkey.IsValidEd25519PublicKey(account) {
+			return nil, errors.Errorf("invalid account %s", account)
+		}
+		reader.accounts[account] = true
+	}
+	for _, contract := range config.Contracts {
+		raw, err := strkey.Decode(strkey.VersionByteContract, contract)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid contract %s", contract)
+		}
+		var contractID xdr.ContractId
+		copy(contractID[:], raw)
+		reader.contracts[contractID] = true
+	}
+	return reader, nil
+}
+
+// Read returns the next ledger entry of the state.
+// If there are no entries remaining io.EOF is returned as an error.
+func (r *StateSnapshotReader) Read() (Change, error) {
+	if r.iterator == nil {
+		if err := r.build(); err != nil {
+			return Change{}, err
+		}
+	}
+	_, entry, err := r.iterator.next()
+	if err == io.EOF {
+		return Change{}, io.EOF
+	}
+	if err != nil {
+		return Change{}, errors.Wrap(err, "could not read ledger entry of the state")
+	}
+	return Change{
+		Type:       entry.Data.Type,
+		ChangeType: xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+		Post:       &entry,
+	}, nil
+}
+
+func (r *StateSnapshotReader) build() error {
+	var err error
+	if r.config.SpillDirectory != "" {
+		if r.store, err = newSpillStateSnapshotStore(r.config.SpillDirectory); err != nil {
+			return err
+		}
+	} else {
+		r.store = newMemoryStateSnapshotStore()
+	}
+	if r.filteredByOwner() {
+		if r.pendingTTLs, err = newPendingTTLs(r.config.SpillDirectory); err != nil {
+			return err
+		}
+		defer r.pendingTTLs.close()
+	}
+
+	checkpoint := r.config.Archive.GetCheckpointManager().PrevCheckpoint(r.sequence)
+	checkpointReader, err := r.newCheckpointReader(r.ctx, r.config.Archive, checkpoint)
+	if err != nil {
+		return errors.Wrapf(err, "could not read state of checkpoint %d", checkpoint)
+	}
+	defer checkpointReader.Close()
+	for {
+		change, err := checkpointReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not read state of checkpoint %d", checkpoint)
+		}
+		if err = r.apply(change); err != nil {
+			return err
+		}
+	}
+	if r.pendingTTLs != nil {
+		// all the entries of the checkpoint are read, the pending TTL entries
+		// are retained if their owner was
+		pending := r.pendingTTLs
+		r.pendingTTLs = nil
+		err = pending.each(func(entry xdr.LedgerEntry) error {
+			return r.apply(Change{Type: entry.Data.Type, Post: &entry})
+		})
+		if err != nil {
+			return err
+		}
+		if err = pending.close(); err != nil {
+			return err
+		}
+	}
+
+	if checkpoint < r.sequence {
+		ledgerRange := ledgerbackend.BoundedRange(checkpoint+1, r.sequence)
+		prepared, err := r.config.LedgerBackend.IsPrepared(r.ctx, ledgerRange)
+		if err != nil {
+			return errors.Wrap(err, "could not check if ledger backend is prepared")
+		}
+		if !prepared {
+			if err = r.config.LedgerBackend.PrepareRange(r.ctx, ledgerRange); err != nil {
+				return errors.Wrapf(err, "could not prepare range %v", ledgerRange)
+			}
+		}
+		for sequence := checkpoint + 1; sequence <= r.sequence; sequence++ {
+			if err = r.applyLedger(sequence); err != nil {
+				return errors.Wrapf(err, "could not apply changes of ledger %d", sequence)
+			}
+		}
+	}
+
+	if r.iterator, err = r.store.iterate(); err != nil {
+		return errors.Wrap(err, "could not read ledger entries of the state")
+	}
+	return nil
+}
+
+func (r *StateSnapshotReader) applyLedger(sequence uint32) error {
+	changeReader, err := NewLedgerChangeReader(r.ctx, r.config.LedgerBackend, r.config.NetworkPassphrase, sequence)
+	if err != nil {
+		return err
+	}
+	defer changeReader.Close()
+
+	compactor := NewChangeCompactor(ChangeCompactorConfig{SuppressRemoveAfterRestoreChange: true})
+	for {
+		change, err := changeReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = compactor.AddChange(change); err != nil {
+			return err
+		}
+	}
+	// TTL entries are applied last, once the entries they refer to are
+	// retained
+	changes := compactor.GetChanges()
+	for _, change := range changes {
+		if change.Type == xdr.LedgerEntryTypeTtl {
+			continue
+		}
+		if err = r.apply(change); err != nil {
+			return err
+		}
+	}
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryTypeTtl {
+			continue
+		}
+		if err = r.apply(change); err != nil {
+			return err
+		}
+	}
+
+	// entries are evicted at the end of the ledger, they are not returned as
+	// changes
+	evictedKeys, err := changeReader.lcm.EvictedLedgerKeys()
+	if err != nil {
+		return err
+	}
+	for _, ledgerKey := range evictedKeys {
+		if err = r.evict(ledgerKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evict removes an evicted entry along with its TTL entry.
+func (r *StateSnapshotReader) evict(ledgerKey xdr.LedgerKey) error {
+	key, err := r.encodingBuffer.UnsafeMarshalBinary(ledgerKey)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal ledger key")
+	}
+	if err = r.store.remove(string(key)); err != nil {
+		return err
+	}
+	if ledgerKey.Type == xdr.LedgerEntryTypeTtl {
+		return nil
+	}
+
+	ttlKey := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeTtl,
+		Ttl:  &xdr.LedgerKeyTtl{KeyHash: sha256.Sum256(key)},
+	}
+	if key, err = r.encodingBuffer.UnsafeMarshalBinary(ttlKey); err != nil {
+		return errors.Wrap(err, "could not marshal ledger key")
+	}
+	return r.store.remove(string(key))
+}
+
+func (r *StateSnapshotReader) apply(change Change) error {
+	entry := change.Post
+	if entry == nil {
+		entry = change.Pre
+	}
+	if entry.Data.Type == xdr.LedgerEntryTypeTtl {
+		// the owner of a TTL entry is only known by the hash of its key
+		if r.filteredByOwner() && !r.ownedKeyHashes[entry.Data.MustTtl().KeyHash] {
+			if r.pendingTTLs != nil {
+				return r.pendingTTLs.add(*entry)
+			}
+			return nil
+		}
+	} else if !r.owned(*entry) {
+		return nil
+	}
+	trackOwner := r.filteredByOwner() && entry.Data.Type == xdr.LedgerEntryTypeContractData
+	if len(r.entryTypes) > 0 && !r.entryTypes[entry.Data.Type] && !trackOwner {
+		return nil
+	}
+
+	ledgerKey, err := entry.LedgerKey()
+	if err != nil {
+		return errors.Wrap(err, "could not get ledger key")
+	}
+	key, err := r.encodingBuffer.UnsafeMarshalBinary(ledgerKey)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal ledger key")
+	}
+	if trackOwner {
+		r.ownedKeyHashes[sha256.Sum256(key)] = true
+	}
+	if len(r.entryTypes) > 0 && !r.entryTypes[entry.Data.Type] {
+		return nil
+	}
+	if change.Post == nil {
+		return r.store.remove(string(key))
+	}
+	return r.store.put(string(key), *change.Post)
+}
+
+// filteredByOwner returns true if the entries are filtered by owner.
+func (r *StateSnapshotReader) filteredByOwner() bool {
+	return len(r.accounts) > 0 || len(r.contracts) > 0
+}
+
+// owned returns true if the entry is owned by one of the accounts or contracts
+// of the config, or if the entries are not filtered by owner.
+func (r *StateSnapshotReader) owned(entry xdr.LedgerEntry) bool {
+	if !r.filteredByOwner() {
+		return true
+	}
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		return r.accounts[entry.Data.MustAccount().AccountId.Address()]
+	case xdr.LedgerEntryTypeTrustline:
+		return r.accounts[entry.Data.MustTrustLine().AccountId.Address()]
+	case xdr.LedgerEntryTypeOffer:
+		return r.accounts[entry.Data.MustOffer().SellerId.Address()]
+	case xdr.LedgerEntryTypeData:
+		return r.accounts[entry.Data.MustData().AccountId.Address()]
+	case xdr.LedgerEntryTypeContractData:
+		contractID, ok := entry.Data.MustContractData().Contract.GetContractId()
+		return ok && r.contracts[contractID]
+	default:
+		return false
+	}
+}
+
+// Close releases the state built.
+func (r *StateSnapshotReader) Close() error {
+	r.iterator = nil
+	if r.store == nil {
+		return nil
+	}
+	err := r.store.close()
+	r.store = nil
+	return err
+}
@@ -0,0 +1,289 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+var stateSnapshotScenario = ledgerbackend.SyntheticScenario{
+	NetworkPassphrase:   network.TestNetworkPassphrase,
+	Seed:                3,
+	Accounts:            6,
+	Assets:              2,
+	Payments:            2,
+	Offers:              2,
+	ContractInvocations: 1,
+}
+
+// replayState returns the state of the synthetic scenario after the given
+// ledger, starting from the given state, by applying every change in order.
+func replayState(t *testing.T, state map[string]xdr.LedgerEntry, from, to uint32) map[string]xdr.LedgerEntry {
+	ctx := context.Background()
+	backend, err := ledgerbackend.NewSyntheticBackend(stateSnapshotScenario)
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, ledgerbackend.BoundedRange(from, to)))
+
+	replayed := map[string]xdr.LedgerEntry{}
+	for key, entry := range state {
+		replayed[key] = entry
+	}
+	for sequence := from; sequence <= to; sequence++ {
+		reader, err := NewLedgerChangeReader(ctx, backend, network.TestNetworkPassphrase, sequence)
+		require.NoError(t, err)
+		for {
+			change, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			if change.Post == nil {
+				delete(replayed, stateKey(t, *change.Pre))
+			} else {
+				replayed[stateKey(t, *change.Post)] = *change.Post
+			}
+		}
+	}
+	return replayed
+}
+
+func stateKey(t *testing.T, entry xdr.LedgerEntry) string {
+	key, err := entry.LedgerKey()
+	require.NoError(t, err)
+	raw, err := key.MarshalBinary()
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func contractEntries(t *testing.T, contractID xdr.ContractId) []xdr.LedgerEntry {
+	data := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 1,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+				Durability: xdr.ContractDataDurabilityPersistent,
+				Val:        xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+			},
+		},
+	}
+	return []xdr.LedgerEntry{data, {
+		LastModifiedLedgerSeq: 1,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl: &xdr.TtlEntry{
+				KeyHash:            sha256.Sum256([]byte(stateKey(t, data))),
+				LiveUntilLedgerSeq: 1000,
+			},
+		},
+	}}
+}
+
+func readStateSnapshot(t *testing.T, sequence uint32, checkpointState map[string]xdr.LedgerEntry, config StateSnapshotConfig) map[string]xdr.LedgerEntry {
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetCheckpointManager").Return(historyarchive.NewCheckpointManager(8))
+	config.Archive = archive
+	config.NetworkPassphrase = network.TestNetworkPassphrase
+
+	reader, err := NewStateSnapshotReader(context.Background(), sequence, config)
+	require.NoError(t, err)
+	// the checkpoint entries are read in reverse key order, so the TTL
+	// entries are read before the contract entries they refer to
+	keys := make([]string, 0, len(checkpointState))
+	for key := range checkpointState {
+		keys = append(keys, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	checkpointReader := &MockChangeReader{}
+	for _, key := range keys {
+		entry := checkpointState[key]
+		checkpointReader.On("Read").Return(Change{
+			Type:       entry.Data.Type,
+			ChangeType: xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+			Post:       &entry,
+		}, nil).Once()
+	}
+	checkpointReader.On("Read").Return(Change{}, io.EOF).Once()
+	checkpointReader.On("Close").Return(nil).Once()
+	reader.newCheckpointReader = func(ctx context.Context, archive historyarchive.ArchiveInterface, sequence uint32) (ChangeReader, error) {
+		require.Equal(t, uint32(7), sequence)
+		return checkpointReader, nil
+	}
+
+	state := map[string]xdr.LedgerEntry{}
+	var previousKey string
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, xdr.LedgerEntryChangeTypeLedgerEntryCreated, change.ChangeType)
+		key := stateKey(t, *change.Post)
+		require.Greater(t, key, previousKey, "entries are returned in key order")
+		previousKey = key
+		state[key] = *change.Post
+	}
+	require.NoError(t, reader.Close())
+	checkpointReader.AssertExpectations(t)
+	return state
+}
+
+func TestStateSnapshotReader(t *testing.T) {
+	var contractID, otherContractID xdr.ContractId
+	contractID[0], otherContractID[0] = 1, 2
+	checkpointState := replayState(t, nil, 2, 7)
+	for _, entry := range append(contractEntries(t, contractID), contractEntries(t, otherContractID)...) {
+		checkpointState[stateKey(t, entry)] = entry
+	}
+	expected := replayState(t, checkpointState, 8, 14)
+	require.NotEqual(t, checkpointState, expected)
+
+	backend, err := ledgerbackend.NewSyntheticBackend(stateSnapshotScenario)
+	require.NoError(t, err)
+	require.Equal(t, expected, readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend: backend,
+	}))
+	require.Equal(t, checkpointState, readStateSnapshot(t, 7, checkpointState, StateSnapshotConfig{}))
+
+	spillDirectory := t.TempDir()
+	require.Equal(t, expected, readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend:  backend,
+		SpillDirectory: spillDirectory,
+	}))
+	files, err := os.ReadDir(spillDirectory)
+	require.NoError(t, err)
+	require.Empty(t, files, "spill file is removed on close")
+
+	trustLines := readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend: backend,
+		EntryTypes:    []xdr.LedgerEntryType{xdr.LedgerEntryTypeTrustline},
+	})
+	expectedTrustLines := map[string]xdr.LedgerEntry{}
+	for key, entry := range expected {
+		if entry.Data.Type == xdr.LedgerEntryTypeTrustline {
+			expectedTrustLines[key] = entry
+		}
+	}
+	require.NotEmpty(t, trustLines)
+	require.Equal(t, expectedTrustLines, trustLines)
+
+	var account string
+	for _, entry := range expected {
+		if entry.Data.Type == xdr.LedgerEntryTypeTrustline {
+			account = entry.Data.MustTrustLine().AccountId.Address()
+			break
+		}
+	}
+	owned := readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend: backend,
+		Accounts:      []string{account},
+		Contracts:     []string{strkey.MustEncode(strkey.VersionByteContract, contractID[:])},
+	})
+	expectedOwned := map[string]xdr.LedgerEntry{}
+	for _, entry := range contractEntries(t, contractID) {
+		expectedOwned[stateKey(t, entry)] = entry
+	}
+	for key, entry := range expected {
+		switch entry.Data.Type {
+		case xdr.LedgerEntryTypeAccount:
+			if entry.Data.MustAccount().AccountId.Address() == account {
+				expectedOwned[key] = entry
+			}
+		case xdr.LedgerEntryTypeTrustline:
+			if entry.Data.MustTrustLine().AccountId.Address() == account {
+				expectedOwned[key] = entry
+			}
+		case xdr.LedgerEntryTypeOffer:
+			if entry.Data.MustOffer().SellerId.Address() == account {
+				expectedOwned[key] = entry
+			}
+		}
+	}
+	require.Equal(t, expectedOwned, owned)
+
+	require.Equal(t, expectedOwned, readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend:  backend,
+		Accounts:       []string{account},
+		Contracts:      []string{strkey.MustEncode(strkey.VersionByteContract, contractID[:])},
+		SpillDirectory: spillDirectory,
+	}))
+	files, err = os.ReadDir(spillDirectory)
+	require.NoError(t, err)
+	require.Empty(t, files, "spill files are removed on close")
+
+	ttls := readStateSnapshot(t, 14, checkpointState, StateSnapshotConfig{
+		LedgerBackend: backend,
+		EntryTypes:    []xdr.LedgerEntryType{xdr.LedgerEntryTypeTtl},
+		Contracts:     []string{strkey.MustEncode(strkey.VersionByteContract, contractID[:])},
+	})
+	ttl := contractEntries(t, contractID)[1]
+	require.Equal(t, map[string]xdr.LedgerEntry{stateKey(t, ttl): ttl}, ttls)
+}
+
+func TestStateSnapshotReaderEvictions(t *testing.T) {
+	var contractID, otherContractID xdr.ContractId
+	contractID[0], otherContractID[0] = 1, 2
+	checkpointState := map[string]xdr.LedgerEntry{}
+	for _, entry := range append(contractEntries(t, contractID), contractEntries(t, otherContractID)...) {
+		checkpointState[stateKey(t, entry)] = entry
+	}
+	evicted := contractEntries(t, otherContractID)
+	evictedKey, err := evicted[0].LedgerKey()
+	require.NoError(t, err)
+
+	// ledger 8 evicts the contract data entry of the other contract
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("IsPrepared", mock.Anything, ledgerbackend.BoundedRange(8, 8)).Return(true, nil)
+	backend.On("GetLedger", mock.Anything, uint32(8)).Return(xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 8}},
+			TxSet: xdr.GeneralizedTransactionSet{
+				V:       1,
+				V1TxSet: &xdr.TransactionSetV1{},
+			},
+			EvictedKeys: []xdr.LedgerKey{evictedKey},
+		},
+	}, nil)
+
+	expected := map[string]xdr.LedgerEntry{}
+	for _, entry := range contractEntries(t, contractID) {
+		expected[stateKey(t, entry)] = entry
+	}
+	require.Equal(t, expected, readStateSnapshot(t, 8, checkpointState, StateSnapshotConfig{
+		LedgerBackend: backend,
+	}))
+	require.Equal(t, expected, readStateSnapshot(t, 8, checkpointState, StateSnapshotConfig{
+		LedgerBackend:  backend,
+		SpillDirectory: t.TempDir(),
+	}))
+}
+
+func TestStateSnapshotReaderInvalidConfig(t *testing.T) {
+	ctx := context.Background()
+	archive := &historyarchive.MockArchive{}
+	archive.On("GetCheckpointManager").Return(historyarchive.NewCheckpointManager(8))
+
+	_, err := NewStateSnapshotReader(ctx, 20, StateSnapshotConfig{})
+	require.EqualError(t, err, "history archive is required")
+	_, err = NewStateSnapshotReader(ctx, 5, StateSnapshotConfig{Archive: archive})
+	require.EqualError(t, err, "ledger 5 precedes the first checkpoint ledger 7")
+	_, err = NewStateSnapshotReader(ctx, 20, StateSnapshotConfig{Archive: archive})
+	require.EqualError(t, err, "ledger backend is required, 20 is not a checkpoint ledger")
+	_, err = NewStateSnapshotReader(ctx, 23, StateSnapshotConfig{Archive: archive, Accounts: []string{"GABC"}})
+	require.EqualError(t, err, "invalid account GABC")
+}
@@ -0,0 +1,441 @@
+package ingest
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// stateSnapshotStore holds the ledger entries of a StateSnapshotReader, keyed
+// by their binary encoded ledger key.
+type stateSnapshotStore interface {
+	put(key string, entry xdr.LedgerEntry) error
+	remove(key string) error
+	// iterate returns an iterator over the entries stored, in key order. The
+	// store must not be modified once iterate is called.
+	iterate() (stateSnapshotIterator, error)
+	close() error
+}
+
+// stateSnapshotIterator iterates over the entries of a stateSnapshotStore.
+type stateSnapshotIterator interface {
+	// next returns the key and the entry following the previous ones, or
+	// io.EOF if there are no entries remaining.
+	next() (string, xdr.LedgerEntry, error)
+}
+
+// memoryStateSnapshotStore keeps all the entries in memory.
+type memoryStateSnapshotStore struct {
+	entries map[string]xdr.LedgerEntry
+}
+
+func newMemoryStateSnapshotStore() *memoryStateSnapshotStore {
+	return &memoryStateSnapshotStore{entries: map[string]xdr.LedgerEntry{}}
+}
+
+func (s *memoryStateSnapshotStore) put(key string, entry xdr.LedgerEntry) error {
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *memoryStateSnapshotStore) remove(key string) error {
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStateSnapshotStore) iterate() (stateSnapshotIterator, error) {
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return &memoryStateSnapshotIterator{store: s, keys: keys}, nil
+}
+
+func (s *memoryStateSnapshotStore) close() error {
+	s.entries = nil
+	return nil
+}
+
+type memoryStateSnapshotIterator struct {
+	store *memoryStateSnapshotStore
+	keys  []string
+}
+
+func (i *memoryStateSnapshotIterator) next() (string, xdr.LedgerEntry, error) {
+	if len(i.keys) == 0 {
+		return "", xdr.LedgerEntry{}, io.EOF
+	}
+	key := i.keys[0]
+	i.keys = i.keys[1:]
+	return key, i.store.entries[key], nil
+}
+
+// spillIndexBufferSize is the number of index records buffered in memory by a
+// spillStateSnapshotStore before they are written to disk as a sorted run.
+const spillIndexBufferSize = 1 << 18
+
+// spillRemoved is the size of the index records of removed entries.
+const spillRemoved = -1
+
+// spillIndexRecord locates the latest version of an entry in the entries file
+// of a spillStateSnapshotStore, or records its removal.
+type spillIndexRecord struct {
+	key    string
+	offset int64
+	size   int32
+}
+
+// spillRun is a run of index records sorted by key in the index file.
+type spillRun struct {
+	offset int64
+	size   int64
+}
+
+// spillStateSnapshotStore appends the encoded entries to a temporary file, and
+// their location to a bounded buffer of index records. Once full, the buffer is
+// sorted by key and written to a temporary index file as a run. The runs are
+// merged when the entries are iterated, the records of the latest run winning
+// over the records of the same key in previous runs, so the memory used
+// doesn't depend on the size of the state.
+type spillStateSnapshotStore struct {
+	entries     *os.File
+	entryWriter *bufio.Writer
+	entriesSize int64
+
+	index       *os.File
+	indexWriter *bufio.Writer
+	indexSize   int64
+	runs        []spillRun
+	records     []spillIndexRecord
+	bufferSize  int
+
+	encodingBuffer *xdr.EncodingBuffer
+}
+
+func newSpillStateSnapshotStore(dir string) (*spillStateSnapshotStore, error) {
+	entries, err := os.CreateTemp(dir, "state-snapshot-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create spill file")
+	}
+	index, err := os.CreateTemp(dir, "state-snapshot-index-*")
+	if err != nil {
+		entries.Close()
+		os.Remove(entries.Name())
+		return nil, errors.Wrap(err, "could not create spill index file")
+	}
+	return &spillStateSnapshotStore{
+		entries:        entries,
+		entryWriter:    bufio.NewWriter(entries),
+		index:          index,
+		indexWriter:    bufio.NewWriter(index),
+		bufferSize:     spillIndexBufferSize,
+		encodingBuffer: xdr.NewEncodingBuffer(),
+	}, nil
+}
+
+func (s *spillStateSnapshotStore) put(key string, entry xdr.LedgerEntry) error {
+	raw, err := s.encodingBuffer.UnsafeMarshalBinary(&entry)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal ledger entry")
+	}
+	if _, err = s.entryWriter.Write(raw); err != nil {
+		return errors.Wrap(err, "could not write to spill file")
+	}
+	record := spillIndexRecord{key: key, offset: s.entriesSize, size: int32(len(raw))}
+	s.entriesSize += int64(len(raw))
+	return s.addRecord(record)
+}
+
+func (s *spillStateSnapshotStore) remove(key string) error {
+	// the entry stays in the spill file until the store is closed
+	return s.addRecord(spillIndexRecord{key: key, size: spillRemoved})
+}
+
+func (s *spillStateSnapshotStore) addRecord(record spillIndexRecord) error {
+	s.records = append(s.records, record)
+	if len(s.records) >= s.bufferSize {
+		return s.writeRun()
+	}
+	return nil
+}
+
+// writeRun writes the buffered index records to the index file, sorted by key
+// and keeping the latest record of every key.
+func (s *spillStateSnapshotStore) writeRun() error {
+	if len(s.records) == 0 {
+		return nil
+	}
+	sort.SliceStable(s.records, func(i, j int) bool {
+		return s.records[i].key < s.records[j].key
+	})
+	run := spillRun{offset: s.indexSize}
+	var header [16]byte
+	for i, record := range s.records {
+		if i+1 < len(s.records) && s.records[i+1].key == record.key {
+			continue
+		}
+		binary.BigEndian.PutUint32(header[0:4], uint32(len(record.key)))
+		binary.BigEndian.PutUint64(header[4:12], uint64(record.offset))
+		binary.BigEndian.PutUint32(header[12:16], uint32(record.size))
+		if _, err := s.indexWriter.Write(header[:]); err != nil {
+			return errors.Wrap(err, "could not write to spill index file")
+		}
+		if _, err := s.indexWriter.WriteString(record.key); err != nil {
+			return errors.Wrap(err, "could not write to spill index file")
+		}
+		run.size += int64(len(header) + len(record.key))
+	}
+	s.indexSize += run.size
+	s.runs = append(s.runs, run)
+	s.records = s.records[:0]
+	return nil
+}
+
+func (s *spillStateSnapshotStore) iterate() (stateSnapshotIterator, error) {
+	if err := s.writeRun(); err != nil {
+		return nil, err
+	}
+	s.records = nil
+	if err := s.entryWriter.Flush(); err != nil {
+		return nil, errors.Wrap(err, "could not write to spill file")
+	}
+	if err := s.indexWriter.Flush(); err != nil {
+		return nil, errors.Wrap(err, "could not write to spill index file")
+	}
+
+	iterator := &spillStateSnapshotIterator{store: s}
+	for i, run := range s.runs {
+		reader := &spillRunReader{
+			run:    i,
+			reader: bufio.NewReader(io.NewSectionReader(s.index, run.offset, run.size)),
+		}
+		ok, err := reader.advance()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			iterator.readers = append(iterator.readers, reader)
+		}
+	}
+	heap.Init(&iterator.readers)
+	return iterator, nil
+}
+
+func (s *spillStateSnapshotStore) read(record spillIndexRecord) (xdr.LedgerEntry, error) {
+	raw := make([]byte, record.size)
+	if _, err := s.entries.ReadAt(raw, record.offset); err != nil && err != io.EOF {
+		return xdr.LedgerEntry{}, errors.Wrap(err, "could not read from spill file")
+	}
+	var entry xdr.LedgerEntry
+	if err := entry.UnmarshalBinary(raw); err != nil {
+		return xdr.LedgerEntry{}, errors.Wrap(err, "could not unmarshal ledger entry")
+	}
+	return entry, nil
+}
+
+func (s *spillStateSnapshotStore) close() error {
+	s.records = nil
+	var closeErr error
+	for _, file := range []*os.File{s.entries, s.index} {
+		if err := file.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+		if err := os.Remove(file.Name()); err != nil {
+			return errors.Wrap(err, "could not remove spill file")
+		}
+	}
+	return closeErr
+}
+
+// spillRunReader reads the index records of a run.
+type spillRunReader struct {
+	run     int
+	reader  *bufio.Reader
+	current spillIndexRecord
+}
+
+// advance reads the next record of the run, returning false at the end of the
+// run.
+func (r *spillRunReader) advance() (bool, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r.reader, header[:]); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "could not read from spill index file")
+	}
+	key := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r.reader, key); err != nil {
+		return false, errors.Wrap(err, "could not read from spill index file")
+	}
+	r.current = spillIndexRecord{
+		key:    string(key),
+		offset: int64(binary.BigEndian.Uint64(header[4:12])),
+		size:   int32(binary.BigEndian.Uint32(header[12:16])),
+	}
+	return true, nil
+}
+
+// spillRunHeap orders the run readers by their current key, and by the latest
+// run for equal keys.
+type spillRunHeap []*spillRunReader
+
+func (h spillRunHeap) Len() int { return len(h) }
+func (h spillRunHeap) Less(i, j int) bool {
+	if h[i].current.key != h[j].current.key {
+		return h[i].current.key < h[j].current.key
+	}
+	return h[i].run > h[j].run
+}
+func (h spillRunHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *spillRunHeap) Push(x interface{}) { *h = append(*h, x.(*spillRunReader)) }
+func (h *spillRunHeap) Pop() interface{} {
+	old := *h
+	reader := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return reader
+}
+
+// spillStateSnapshotIterator merges the runs of a spillStateSnapshotStore.
+type spillStateSnapshotIterator struct {
+	store   *spillStateSnapshotStore
+	readers spillRunHeap
+}
+
+func (i *spillStateSnapshotIterator) next() (string, xdr.LedgerEntry, error) {
+	for len(i.readers) > 0 {
+		// the latest record of the smallest key, the previous records of
+		// the key are skipped
+		record := i.readers[0].current
+		for len(i.readers) > 0 && i.readers[0].current.key == record.key {
+			if err := i.advance(); err != nil {
+				return "", xdr.LedgerEntry{}, err
+			}
+		}
+		if record.size == spillRemoved {
+			continue
+		}
+		entry, err := i.store.read(record)
+		if err != nil {
+			return "", xdr.LedgerEntry{}, err
+		}
+		return record.key, entry, nil
+	}
+	return "", xdr.LedgerEntry{}, io.EOF
+}
+
+func (i *spillStateSnapshotIterator) advance() error {
+	ok, err := i.readers[0].advance()
+	if err != nil {
+		return err
+	}
+	if ok {
+		heap.Fix(&i.readers, 0)
+	} else {
+		heap.Pop(&i.readers)
+	}
+	return nil
+}
+
+// pendingTTLs holds the ttl entries of a checkpoint which can't be filtered by
+// owner yet, because the entry they refer to may be read later. They are
+// written to a temporary file if a directory is given.
+type pendingTTLs struct {
+	entries []xdr.LedgerEntry
+	file    *os.File
+	writer  *bufio.Writer
+
+	encodingBuffer *xdr.EncodingBuffer
+}
+
+func newPendingTTLs(dir string) (*pendingTTLs, error) {
+	pending := &pendingTTLs{encodingBuffer: xdr.NewEncodingBuffer()}
+	if dir != "" {
+		file, err := os.CreateTemp(dir, "state-snapshot-ttls-*")
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create spill file")
+		}
+		pending.file = file
+		pending.writer = bufio.NewWriter(file)
+	}
+	return pending, nil
+}
+
+func (p *pendingTTLs) add(entry xdr.LedgerEntry) error {
+	if p.file == nil {
+		p.entries = append(p.entries, entry)
+		return nil
+	}
+	raw, err := p.encodingBuffer.UnsafeMarshalBinary(&entry)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal ledger entry")
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(raw)))
+	if _, err = p.writer.Write(size[:]); err != nil {
+		return errors.Wrap(err, "could not write to spill file")
+	}
+	if _, err = p.writer.Write(raw); err != nil {
+		return errors.Wrap(err, "could not write to spill file")
+	}
+	return nil
+}
+
+// each calls f with every entry added, in order.
+func (p *pendingTTLs) each(f func(entry xdr.LedgerEntry) error) error {
+	if p.file == nil {
+		for _, entry := range p.entries {
+			if err := f(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := p.writer.Flush(); err != nil {
+		return errors.Wrap(err, "could not write to spill file")
+	}
+	if _, err := p.file.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not read from spill file")
+	}
+	reader := bufio.NewReader(p.file)
+	var size [4]byte
+	for {
+		if _, err := io.ReadFull(reader, size[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "could not read from spill file")
+		}
+		raw := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(reader, raw); err != nil {
+			return errors.Wrap(err, "could not read from spill file")
+		}
+		var entry xdr.LedgerEntry
+		if err := entry.UnmarshalBinary(raw); err != nil {
+			return errors.Wrap(err, "could not unmarshal ledger entry")
+		}
+		if err := f(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// close removes the temporary file, it can be called more than once.
+func (p *pendingTTLs) close() error {
+	p.entries = nil
+	if p.file == nil {
+		return nil
+	}
+	file := p.file
+	p.file = nil
+	closeErr := file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return errors.Wrap(err, "could not remove spill file")
+	}
+	return closeErr
+}
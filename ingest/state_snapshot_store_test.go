@@ -0,0 +1,69 @@
+package ingest
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func TestSpillStateSnapshotStore(t *testing.T) {
+	memoryStore := newMemoryStateSnapshotStore()
+	spillStore, err := newSpillStateSnapshotStore(t.TempDir())
+	require.NoError(t, err)
+	// the index records are written in many runs, with the versions of an
+	// entry spread across runs
+	spillStore.bufferSize = 7
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var raw xdr.Uint256
+		raw[0] = byte(random.Intn(100))
+		accountID := xdr.AccountId{Type: xdr.PublicKeyTypePublicKeyTypeEd25519, Ed25519: &raw}
+		entry := xdr.LedgerEntry{
+			LastModifiedLedgerSeq: xdr.Uint32(i),
+			Data: xdr.LedgerEntryData{
+				Type:    xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{AccountId: accountID, Balance: xdr.Int64(i)},
+			},
+		}
+		key := stateKey(t, entry)
+		if random.Intn(4) == 0 {
+			require.NoError(t, memoryStore.remove(key))
+			require.NoError(t, spillStore.remove(key))
+		} else {
+			require.NoError(t, memoryStore.put(key, entry))
+			require.NoError(t, spillStore.put(key, entry))
+		}
+	}
+
+	read := func(store stateSnapshotStore) ([]string, []xdr.LedgerEntry) {
+		iterator, err := store.iterate()
+		require.NoError(t, err)
+		var keys []string
+		var entries []xdr.LedgerEntry
+		for {
+			key, entry, err := iterator.next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			keys = append(keys, key)
+			entries = append(entries, entry)
+		}
+		return keys, entries
+	}
+	expectedKeys, expectedEntries := read(memoryStore)
+	require.NotEmpty(t, expectedKeys)
+	require.Less(t, len(expectedKeys), 100)
+	keys, entries := read(spillStore)
+	require.Equal(t, expectedKeys, keys)
+	require.Equal(t, expectedEntries, entries)
+	require.Greater(t, len(spillStore.runs), 100)
+
+	require.NoError(t, memoryStore.close())
+	require.NoError(t, spillStore.close())
+}
@@ -0,0 +1,110 @@
+package statestore
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// The store is an append-only log of records. Every record is made of a
+// header, the type of the record, the size of its payload and the CRC-32 of
+// the type and the payload, followed by the payload:
+//
+//   - putRecord sets a ledger entry, its payload is the size of the ledger key
+//     followed by the ledger key and the ledger entry,
+//   - deleteRecord removes a ledger entry, its payload is the ledger key,
+//   - commitRecord commits the records preceding it, its payload is the
+//     sequence of the ledger the store is at.
+//
+// Records following the last commit record, which were written by an
+// interrupted Apply, are discarded when the store is opened.
+type recordType byte
+
+const (
+	putRecord recordType = iota + 1
+	deleteRecord
+	commitRecord
+)
+
+const (
+	recordHeaderSize = 9
+	// maxRecordSize bounds the payload of a record, which holds a single
+	// ledger entry, so a corrupted size is detected before it is allocated
+	maxRecordSize = 1 << 24
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeRecordHeader returns the header of a record.
+func encodeRecordHeader(typ recordType, payload ...[]byte) []byte {
+	header := make([]byte, recordHeaderSize)
+	header[0] = byte(typ)
+	crc := crc32.Update(0, crcTable, header[:1])
+	size := 0
+	for _, part := range payload {
+		crc = crc32.Update(crc, crcTable, part)
+		size += len(part)
+	}
+	binary.BigEndian.PutUint32(header[1:5], uint32(size))
+	binary.BigEndian.PutUint32(header[5:9], crc)
+	return header
+}
+
+// record is a record read from the log.
+type record struct {
+	typ     recordType
+	payload []byte
+	// offset is the offset of the payload in the log
+	offset int64
+}
+
+// errTornRecord is returned when the end of the log is reached in the middle
+// of a record, or a record is corrupted, as it happens when the process stops
+// while writing.
+var errTornRecord = errors.New("torn record")
+
+// logReader reads the records of a log.
+type logReader struct {
+	reader *bufio.Reader
+	offset int64
+}
+
+func (r *logReader) read() (record, error) {
+	header := make([]byte, recordHeaderSize)
+	if n, err := io.ReadFull(r.reader, header); err == io.EOF {
+		return record{}, io.EOF
+	} else if err == io.ErrUnexpectedEOF {
+		return record{}, errTornRecord
+	} else if err != nil {
+		return record{}, errors.Wrap(err, "could not read record header")
+	} else {
+		r.offset += int64(n)
+	}
+
+	size := binary.BigEndian.Uint32(header[1:5])
+	if size > maxRecordSize {
+		return record{}, errTornRecord
+	}
+	rec := record{
+		typ:     recordType(header[0]),
+		payload: make([]byte, size),
+		offset:  r.offset,
+	}
+	n, err := io.ReadFull(r.reader, rec.payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return record{}, errTornRecord
+	} else if err != nil {
+		return record{}, errors.Wrap(err, "could not read record payload")
+	}
+	r.offset += int64(n)
+
+	crc := crc32.Update(0, crcTable, header[:1])
+	crc = crc32.Update(crc, crcTable, rec.payload)
+	if crc != binary.BigEndian.Uint32(header[5:9]) {
+		return record{}, errTornRecord
+	}
+	return rec, nil
+}
@@ -0,0 +1,571 @@
+// Package statestore provides an embedded, persistent store of the current
+// ledger state, for ingestion consumers which need the state (balances, trust
+// lines, contract data...) without maintaining it in a database.
+//
+// The store applies the changes of consecutive ledgers, usually read with
+// ingest.LedgerChangeReader, after being bootstrapped from a history archive
+// checkpoint. Every ledger is applied atomically and the store records the
+// last ledger applied, so a consumer stopped at any time resumes ingestion
+// from the ledger following Store.LastLedger.
+//
+// The key and location of every ledger entry are held in memory, and the
+// whole log is read to rebuild them when the store is opened. For pubnet,
+// this takes several GB of RAM and opening the store can take minutes.
+package statestore
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	logFileName        = "state.log"
+	compactLogFileName = "state.log.compact"
+	// minCompactionSize is the size of the log from which it is compacted
+	// once most of it holds stale records
+	minCompactionSize = 64 << 20
+)
+
+// Config configures a Store.
+type Config struct {
+	// Path is the directory of the store, it is created if it doesn't exist.
+	Path string
+	// DisableSync disables syncing the store to disk every time a ledger is
+	// applied. Applying ledgers is faster but the ledgers applied last can be
+	// lost if the host crashes, the store is still consistent.
+	DisableSync bool
+}
+
+// location is the location of a ledger entry in the log.
+type location struct {
+	offset int64
+	size   uint32
+}
+
+// Store is a persistent key-value store of ledger entries, keyed by ledger
+// key. Ledger entries are appended to a log on disk and the location of every
+// ledger entry is indexed in memory, so only the ledger keys need to fit in
+// memory. The log is compacted once most of it holds stale ledger entries.
+//
+// Store is safe for concurrent use, ledger entries can be read while a ledger
+// is applied, they are read as of the last ledger applied.
+type Store struct {
+	config Config
+
+	// applyLock serializes the writes to the log
+	applyLock sync.Mutex
+
+	// lock protects the fields below
+	lock sync.RWMutex
+	file *os.File
+	// size is the size of the log up to the last commit record
+	size int64
+	// liveSize is the size of the records of the ledger entries in the store
+	liveSize   int64
+	entries    map[xdr.LedgerEntryType]map[string]location
+	lastLedger uint32
+	closed     bool
+}
+
+// NewStore opens the store in the directory of the config, or creates it if
+// there is none. Ledger entries written by a ledger which was interrupted
+// before being fully applied are discarded.
+func NewStore(config Config) (*Store, error) {
+	if config.Path == "" {
+		return nil, errors.New("path is required")
+	}
+	if err := os.MkdirAll(config.Path, 0755); err != nil {
+		return nil, errors.Wrapf(err, "could not create directory %s", config.Path)
+	}
+	// a compacted log which wasn't renamed is incomplete
+	if err := os.Remove(filepath.Join(config.Path, compactLogFileName)); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "could not remove compacted log")
+	}
+
+	s := &Store{config: config}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the log and indexes the ledger entries it holds.
+func (s *Store) open() error {
+	file, err := os.OpenFile(filepath.Join(s.config.Path, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not open log")
+	}
+
+	s.file = file
+	s.size = 0
+	s.liveSize = 0
+	s.entries = map[xdr.LedgerEntryType]map[string]location{}
+	s.lastLedger = 0
+
+	reader := &logReader{reader: bufio.NewReader(file)}
+	var pending []indexUpdate
+	for {
+		rec, err := reader.read()
+		if err == io.EOF || err == errTornRecord {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		switch rec.typ {
+		case putRecord, deleteRecord:
+			update, err := decodeIndexUpdate(rec)
+			if err != nil {
+				file.Close()
+				return err
+			}
+			pending = append(pending, update)
+		case commitRecord:
+			if len(rec.payload) != 4 {
+				file.Close()
+				return errors.New("invalid commit record")
+			}
+			s.applyIndexUpdates(pending, binary.BigEndian.Uint32(rec.payload), reader.offset)
+			pending = nil
+		default:
+			file.Close()
+			return errors.Errorf("unknown record type %d", rec.typ)
+		}
+	}
+
+	// discard the records of an interrupted apply
+	if err = file.Truncate(s.size); err != nil {
+		file.Close()
+		return errors.Wrap(err, "could not truncate log")
+	}
+	return nil
+}
+
+// indexUpdate is the update of the index of a ledger entry written to the
+// log, which is applied once the ledger is committed.
+type indexUpdate struct {
+	key        string
+	entryType  xdr.LedgerEntryType
+	location   location
+	removed    bool
+	recordSize int64
+}
+
+func decodeIndexUpdate(rec record) (indexUpdate, error) {
+	update := indexUpdate{recordSize: recordHeaderSize + int64(len(rec.payload))}
+	switch rec.typ {
+	case putRecord:
+		if len(rec.payload) < 4 {
+			return indexUpdate{}, errors.New("invalid put record")
+		}
+		keySize := binary.BigEndian.Uint32(rec.payload)
+		if uint32(len(rec.payload)-4) < keySize {
+			return indexUpdate{}, errors.New("invalid put record")
+		}
+		update.key = string(rec.payload[4 : 4+keySize])
+		update.location = location{
+			offset: rec.offset + 4 + int64(keySize),
+			size:   uint32(len(rec.payload)) - 4 - keySize,
+		}
+	case deleteRecord:
+		update.key = string(rec.payload)
+		update.removed = true
+	}
+	if len(update.key) < 4 {
+		return indexUpdate{}, errors.New("invalid ledger key")
+	}
+	update.entryType = xdr.LedgerEntryType(binary.BigEndian.Uint32([]byte(update.key[:4])))
+	return update, nil
+}
+
+// applyIndexUpdates updates the index once a ledger is committed.
+func (s *Store) applyIndexUpdates(updates []indexUpdate, ledger uint32, size int64) {
+	for _, update := range updates {
+		entries := s.entries[update.entryType]
+		if entries == nil {
+			entries = map[string]location{}
+			s.entries[update.entryType] = entries
+		}
+		if previous, ok := entries[update.key]; ok {
+			s.liveSize -= putRecordSize(update.key, previous)
+		}
+		if update.removed {
+			delete(entries, update.key)
+		} else {
+			entries[update.key] = update.location
+			s.liveSize += update.recordSize
+		}
+	}
+	s.lastLedger = ledger
+	s.size = size
+}
+
+func putRecordSize(key string, loc location) int64 {
+	return recordHeaderSize + 4 + int64(len(key)) + int64(loc.size)
+}
+
+// LastLedger returns the sequence of the last ledger applied, or 0 if the
+// store is empty.
+func (s *Store) LastLedger() uint32 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.lastLedger
+}
+
+// Get returns the ledger entry of the given key. The boolean returned is false
+// if there is no such entry.
+func (s *Store) Get(key xdr.LedgerKey) (xdr.LedgerEntry, bool, error) {
+	raw, err := key.MarshalBinary()
+	if err != nil {
+		return xdr.LedgerEntry{}, false, errors.Wrap(err, "could not marshal ledger key")
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return xdr.LedgerEntry{}, false, errors.New("store is closed")
+	}
+	loc, ok := s.entries[key.Type][string(raw)]
+	if !ok {
+		return xdr.LedgerEntry{}, false, nil
+	}
+	entry, err := s.readEntry(loc)
+	if err != nil {
+		return xdr.LedgerEntry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Scan calls fn with every ledger entry of the given type, in ledger key
+// order, until fn returns an error. The store must not be modified by fn.
+func (s *Store) Scan(entryType xdr.LedgerEntryType, fn func(xdr.LedgerEntry) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return errors.New("store is closed")
+	}
+
+	entries := s.entries[entryType]
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry, err := s.readEntry(entries[key])
+		if err != nil {
+			return err
+		}
+		if err = fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count returns the number of ledger entries of the given type.
+func (s *Store) Count(entryType xdr.LedgerEntryType) int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.entries[entryType])
+}
+
+func (s *Store) readEntry(loc location) (xdr.LedgerEntry, error) {
+	raw := make([]byte, loc.size)
+	if _, err := s.file.ReadAt(raw, loc.offset); err != nil {
+		return xdr.LedgerEntry{}, errors.Wrap(err, "could not read ledger entry")
+	}
+	var entry xdr.LedgerEntry
+	if err := entry.UnmarshalBinary(raw); err != nil {
+		return xdr.LedgerEntry{}, errors.Wrap(err, "could not unmarshal ledger entry")
+	}
+	return entry, nil
+}
+
+// Apply applies all the changes read from the reader, which are the changes
+// of the given ledger, then removes the evicted ledger entries along with
+// their TTL entries, and records the ledger as the last ledger applied.
+// Evicted entries are not returned as changes by ingest.LedgerChangeReader,
+// they are the keys returned by xdr.LedgerCloseMeta.EvictedLedgerKeys. The
+// changes and evictions are applied atomically: if Apply fails, or the process
+// stops while they are applied, none of them is.
+//
+// Apply bootstraps the store with the changes of any ledger when it is empty,
+// e.g. the state of a checkpoint read with ingest.CheckpointChangeReader. The
+// ledger must follow the last ledger applied otherwise.
+func (s *Store) Apply(ledger uint32, reader ingest.ChangeReader, evicted []xdr.LedgerKey) error {
+	s.applyLock.Lock()
+	defer s.applyLock.Unlock()
+
+	s.lock.RLock()
+	closed, lastLedger, size := s.closed, s.lastLedger, s.size
+	s.lock.RUnlock()
+	if closed {
+		return errors.New("store is closed")
+	}
+	if ledger == 0 {
+		return errors.New("invalid ledger 0")
+	}
+	if lastLedger != 0 && ledger != lastLedger+1 {
+		return errors.Errorf("ledger %d does not follow the last ledger applied %d", ledger, lastLedger)
+	}
+
+	updates, newSize, err := s.writeChanges(ledger, reader, evicted, size)
+	if err != nil {
+		// discard the records written
+		if truncateErr := s.file.Truncate(size); truncateErr != nil {
+			return errors.Wrapf(err, "could not truncate log (%v)", truncateErr)
+		}
+		return err
+	}
+
+	s.lock.Lock()
+	s.applyIndexUpdates(updates, ledger, newSize)
+	compact := s.size >= minCompactionSize && s.size > 2*s.liveSize
+	s.lock.Unlock()
+
+	if compact {
+		return s.compact()
+	}
+	return nil
+}
+
+// writeChanges writes the records of the changes and evictions and the commit
+// record of the ledger at the end of the log, it returns the updates of the
+// index and the size of the log.
+func (s *Store) writeChanges(ledger uint32, reader ingest.ChangeReader, evicted []xdr.LedgerKey, offset int64) ([]indexUpdate, int64, error) {
+	writer := bufio.NewWriter(io.NewOffsetWriter(s.file, offset))
+	var updates []indexUpdate
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "could not read change")
+		}
+
+		entry := change.Post
+		if entry == nil {
+			entry = change.Pre
+		}
+		if entry == nil {
+			return nil, 0, errors.New("change has no ledger entry")
+		}
+		ledgerKey, err := entry.LedgerKey()
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "could not get ledger key")
+		}
+		key, err := ledgerKey.MarshalBinary()
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "could not marshal ledger key")
+		}
+
+		var update indexUpdate
+		if change.Post == nil {
+			update, offset, err = writeRecord(writer, offset, deleteRecord, key)
+		} else {
+			raw, marshalErr := change.Post.MarshalBinary()
+			if marshalErr != nil {
+				return nil, 0, errors.Wrap(marshalErr, "could not marshal ledger entry")
+			}
+			keySize := make([]byte, 4)
+			binary.BigEndian.PutUint32(keySize, uint32(len(key)))
+			update, offset, err = writeRecord(writer, offset, putRecord, keySize, key, raw)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		updates = append(updates, update)
+	}
+
+	keys, err := evictedKeys(evicted)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, key := range keys {
+		var update indexUpdate
+		if update, offset, err = writeRecord(writer, offset, deleteRecord, key); err != nil {
+			return nil, 0, err
+		}
+		updates = append(updates, update)
+	}
+
+	if err := writeCommitRecord(writer, ledger); err != nil {
+		return nil, 0, err
+	}
+	if !s.config.DisableSync {
+		if err := s.file.Sync(); err != nil {
+			return nil, 0, errors.Wrap(err, "could not sync log")
+		}
+	}
+	return updates, offset + recordHeaderSize + 4, nil
+}
+
+// evictedKeys returns the keys of the evicted ledger entries and of their TTL
+// entries, without duplicates.
+func evictedKeys(evicted []xdr.LedgerKey) ([][]byte, error) {
+	var keys [][]byte
+	seen := map[string]bool{}
+	add := func(ledgerKey xdr.LedgerKey) ([]byte, error) {
+		key, err := ledgerKey.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal ledger key")
+		}
+		if !seen[string(key)] {
+			seen[string(key)] = true
+			keys = append(keys, key)
+		}
+		return key, nil
+	}
+	for _, ledgerKey := range evicted {
+		key, err := add(ledgerKey)
+		if err != nil {
+			return nil, err
+		}
+		if ledgerKey.Type == xdr.LedgerEntryTypeTtl {
+			continue
+		}
+		if _, err = add(xdr.LedgerKey{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl:  &xdr.LedgerKeyTtl{KeyHash: sha256.Sum256(key)},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// writeRecord writes a record at the given offset of the log, and returns the
+// update of the index it makes and the offset following it.
+func writeRecord(writer io.Writer, offset int64, typ recordType, payload ...[]byte) (indexUpdate, int64, error) {
+	header := encodeRecordHeader(typ, payload...)
+	var joined []byte
+	for _, part := range payload {
+		joined = append(joined, part...)
+	}
+	if _, err := writer.Write(header); err != nil {
+		return indexUpdate{}, 0, errors.Wrap(err, "could not write to log")
+	}
+	if _, err := writer.Write(joined); err != nil {
+		return indexUpdate{}, 0, errors.Wrap(err, "could not write to log")
+	}
+	update, err := decodeIndexUpdate(record{typ: typ, payload: joined, offset: offset + recordHeaderSize})
+	if err != nil {
+		return indexUpdate{}, 0, err
+	}
+	return update, offset + recordHeaderSize + int64(len(joined)), nil
+}
+
+// writeCommitRecord writes the commit record of the ledger and flushes the
+// writer.
+func writeCommitRecord(writer *bufio.Writer, ledger uint32) error {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, ledger)
+	if _, err := writer.Write(encodeRecordHeader(commitRecord, payload)); err != nil {
+		return errors.Wrap(err, "could not write to log")
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return errors.Wrap(err, "could not write to log")
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.Wrap(err, "could not write to log")
+	}
+	return nil
+}
+
+// Compact rewrites the log with the ledger entries in the store only,
+// reclaiming the space of the stale ledger entries.
+func (s *Store) Compact() error {
+	s.applyLock.Lock()
+	defer s.applyLock.Unlock()
+	return s.compact()
+}
+
+func (s *Store) compact() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return errors.New("store is closed")
+	}
+
+	path := filepath.Join(s.config.Path, compactLogFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not create compacted log")
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	var offset int64
+	for _, entries := range s.entries {
+		for key, loc := range entries {
+			raw := make([]byte, loc.size)
+			if _, err = s.file.ReadAt(raw, loc.offset); err != nil {
+				return errors.Wrap(err, "could not read ledger entry")
+			}
+			keySize := make([]byte, 4)
+			binary.BigEndian.PutUint32(keySize, uint32(len(key)))
+			if _, offset, err = writeRecord(writer, offset, putRecord, keySize, []byte(key), raw); err != nil {
+				return err
+			}
+		}
+	}
+	if err = writeCommitRecord(writer, s.lastLedger); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return errors.Wrap(err, "could not sync compacted log")
+	}
+
+	if err = os.Rename(path, filepath.Join(s.config.Path, logFileName)); err != nil {
+		return errors.Wrap(err, "could not replace log with compacted log")
+	}
+	if err = s.file.Close(); err != nil {
+		return errors.Wrap(err, "could not close log")
+	}
+	return s.open()
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	s.applyLock.Lock()
+	defer s.applyLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.entries = nil
+	return s.file.Close()
+}
+
+// Bootstrap bootstraps an empty store with the state of the given checkpoint
+// ledger, read from the history archive.
+func Bootstrap(ctx context.Context, store *Store, archive historyarchive.ArchiveInterface, checkpoint uint32) error {
+	if lastLedger := store.LastLedger(); lastLedger != 0 {
+		return errors.Errorf("store is not empty, it is at ledger %d", lastLedger)
+	}
+	reader, err := ingest.NewCheckpointChangeReader(ctx, archive, checkpoint)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return store.Apply(checkpoint, reader, nil)
+}
@@ -0,0 +1,292 @@
+package statestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+// ledgerChanges returns the change readers of the synthetic ledgers in the
+// given range.
+func ledgerChanges(t *testing.T, from, to uint32) map[uint32]func() ingest.ChangeReader {
+	ctx := context.Background()
+	backend, err := ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Seed:              5,
+		Accounts:          8,
+		Assets:            2,
+		Payments:          2,
+		Offers:            2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, ledgerbackend.BoundedRange(from, to)))
+
+	readers := map[uint32]func() ingest.ChangeReader{}
+	for sequence := from; sequence <= to; sequence++ {
+		lcm, err := backend.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+		readers[sequence] = func() ingest.ChangeReader {
+			reader, err := ingest.NewLedgerChangeReaderFromLedgerCloseMeta(network.TestNetworkPassphrase, lcm)
+			require.NoError(t, err)
+			return reader
+		}
+	}
+	return readers
+}
+
+// expectedState applies the changes to the state in order, removed entries
+// are kept as nil.
+func expectedState(t *testing.T, state map[string]*xdr.LedgerEntry, reader ingest.ChangeReader) {
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		entry := change.Post
+		if entry == nil {
+			entry = change.Pre
+		}
+		key, err := entry.LedgerKey()
+		require.NoError(t, err)
+		raw, err := key.MarshalBinary()
+		require.NoError(t, err)
+		state[string(raw)] = change.Post
+	}
+}
+
+func requireState(t *testing.T, store *Store, state map[string]*xdr.LedgerEntry) {
+	live := 0
+	for rawKey, entry := range state {
+		var key xdr.LedgerKey
+		require.NoError(t, key.UnmarshalBinary([]byte(rawKey)))
+		stored, ok, err := store.Get(key)
+		require.NoError(t, err)
+		if entry == nil {
+			require.False(t, ok)
+			continue
+		}
+		live++
+		require.True(t, ok)
+		require.Equal(t, *entry, stored)
+	}
+
+	count := 0
+	for _, entryType := range []xdr.LedgerEntryType{
+		xdr.LedgerEntryTypeAccount,
+		xdr.LedgerEntryTypeTrustline,
+		xdr.LedgerEntryTypeOffer,
+	} {
+		var previousKey string
+		require.NoError(t, store.Scan(entryType, func(entry xdr.LedgerEntry) error {
+			require.Equal(t, entryType, entry.Data.Type)
+			key, err := entry.LedgerKey()
+			require.NoError(t, err)
+			raw, err := key.MarshalBinary()
+			require.NoError(t, err)
+			require.Greater(t, string(raw), previousKey, "entries are scanned in key order")
+			previousKey = string(raw)
+			require.Equal(t, state[string(raw)], &entry)
+			count++
+			return nil
+		}))
+		require.Equal(t, store.Count(entryType), func() int {
+			n := 0
+			for _, entry := range state {
+				if entry != nil && entry.Data.Type == entryType {
+					n++
+				}
+			}
+			return n
+		}())
+	}
+	require.Equal(t, live, count)
+}
+
+func TestStoreApply(t *testing.T) {
+	path := t.TempDir()
+	changes := ledgerChanges(t, 2, 20)
+	store, err := NewStore(Config{Path: path})
+	require.NoError(t, err)
+	require.Equal(t, uint32(0), store.LastLedger())
+
+	state := map[string]*xdr.LedgerEntry{}
+	for sequence := uint32(2); sequence <= 12; sequence++ {
+		require.NoError(t, store.Apply(sequence, changes[sequence](), nil))
+		expectedState(t, state, changes[sequence]())
+		require.Equal(t, sequence, store.LastLedger())
+	}
+	requireState(t, store, state)
+	require.EqualError(t, store.Apply(14, changes[14](), nil), "ledger 14 does not follow the last ledger applied 12")
+
+	// the state is restored from disk
+	require.NoError(t, store.Close())
+	require.EqualError(t, store.Scan(xdr.LedgerEntryTypeAccount, nil), "store is closed")
+	store, err = NewStore(Config{Path: path})
+	require.NoError(t, err)
+	require.Equal(t, uint32(12), store.LastLedger())
+	requireState(t, store, state)
+
+	for sequence := uint32(13); sequence <= 20; sequence++ {
+		require.NoError(t, store.Apply(sequence, changes[sequence](), nil))
+		expectedState(t, state, changes[sequence]())
+	}
+	requireState(t, store, state)
+
+	info, err := os.Stat(filepath.Join(path, logFileName))
+	require.NoError(t, err)
+	require.NoError(t, store.Compact())
+	compacted, err := os.Stat(filepath.Join(path, logFileName))
+	require.NoError(t, err)
+	require.Less(t, compacted.Size(), info.Size())
+	require.Equal(t, uint32(20), store.LastLedger())
+	requireState(t, store, state)
+
+	require.NoError(t, store.Close())
+	store, err = NewStore(Config{Path: path})
+	require.NoError(t, err)
+	require.Equal(t, uint32(20), store.LastLedger())
+	requireState(t, store, state)
+	require.NoError(t, store.Close())
+}
+
+func TestStoreApplyIsAtomic(t *testing.T) {
+	path := t.TempDir()
+	changes := ledgerChanges(t, 2, 4)
+	store, err := NewStore(Config{Path: path, DisableSync: true})
+	require.NoError(t, err)
+
+	state := map[string]*xdr.LedgerEntry{}
+	for sequence := uint32(2); sequence <= 3; sequence++ {
+		require.NoError(t, store.Apply(sequence, changes[sequence](), nil))
+		expectedState(t, state, changes[sequence]())
+	}
+	info, err := os.Stat(filepath.Join(path, logFileName))
+	require.NoError(t, err)
+
+	// a reader failing after some changes are written
+	reader := &ingest.MockChangeReader{}
+	change, err := changes[4]().Read()
+	require.NoError(t, err)
+	reader.On("Read").Return(change, nil).Times(3)
+	reader.On("Read").Return(ingest.Change{}, errors.New("read error")).Once()
+	require.EqualError(t, store.Apply(4, reader, nil), "could not read change: read error")
+	reader.AssertExpectations(t)
+	require.Equal(t, uint32(3), store.LastLedger())
+	requireState(t, store, state)
+	failed, err := os.Stat(filepath.Join(path, logFileName))
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), failed.Size())
+	require.NoError(t, store.Close())
+
+	// the process stopped while writing the changes of a ledger
+	rawChange, err := change.Post.MarshalBinary()
+	require.NoError(t, err)
+	file, err := os.OpenFile(filepath.Join(path, logFileName), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.Write(append(encodeRecordHeader(putRecord, rawChange), rawChange[:10]...))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = NewStore(Config{Path: path})
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), store.LastLedger())
+	requireState(t, store, state)
+	recovered, err := os.Stat(filepath.Join(path, logFileName))
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), recovered.Size())
+
+	require.NoError(t, store.Apply(4, changes[4](), nil))
+	expectedState(t, state, changes[4]())
+	requireState(t, store, state)
+	require.NoError(t, store.Close())
+}
+
+func TestStoreApplyEvictions(t *testing.T) {
+	path := t.TempDir()
+	store, err := NewStore(Config{Path: path})
+	require.NoError(t, err)
+
+	var contractID xdr.ContractId
+	contractID[0] = 1
+	data := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 2,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract:   xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+				Durability: xdr.ContractDataDurabilityPersistent,
+				Val:        xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+			},
+		},
+	}
+	dataKey, err := data.LedgerKey()
+	require.NoError(t, err)
+	rawDataKey, err := dataKey.MarshalBinary()
+	require.NoError(t, err)
+	ttl := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 2,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl:  &xdr.TtlEntry{KeyHash: sha256.Sum256(rawDataKey), LiveUntilLedgerSeq: 2},
+		},
+	}
+	ttlKey, err := ttl.LedgerKey()
+	require.NoError(t, err)
+
+	reader := &ingest.MockChangeReader{}
+	for _, entry := range []xdr.LedgerEntry{data, ttl} {
+		reader.On("Read").Return(ingest.Change{
+			Type:       entry.Data.Type,
+			ChangeType: xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+			Post:       &entry,
+		}, nil).Once()
+	}
+	reader.On("Read").Return(ingest.Change{}, io.EOF).Once()
+	require.NoError(t, store.Apply(2, reader, nil))
+	_, ok, err := store.Get(ttlKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the TTL entry of the evicted entry is removed with it
+	reader = &ingest.MockChangeReader{}
+	reader.On("Read").Return(ingest.Change{}, io.EOF).Once()
+	require.NoError(t, store.Apply(3, reader, []xdr.LedgerKey{dataKey}))
+	require.NoError(t, store.Close())
+
+	store, err = NewStore(Config{Path: path})
+	require.NoError(t, err)
+	defer store.Close()
+	require.Equal(t, uint32(3), store.LastLedger())
+	for _, key := range []xdr.LedgerKey{dataKey, ttlKey} {
+		_, ok, err = store.Get(key)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+	require.Zero(t, store.Count(xdr.LedgerEntryTypeContractData))
+	require.Zero(t, store.Count(xdr.LedgerEntryTypeTtl))
+}
+
+func TestBootstrapRequiresEmptyStore(t *testing.T) {
+	changes := ledgerChanges(t, 2, 2)
+	store, err := NewStore(Config{Path: t.TempDir()})
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.Apply(2, changes[2](), nil))
+
+	err = Bootstrap(context.Background(), store, &historyarchive.MockArchive{}, 63)
+	require.EqualError(t, err, "store is not empty, it is at ledger 2")
+}
@@ -0,0 +1,238 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"reflect"
+	"sync"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// xdrSkipper returns the size of the XDR encoding of a value at the start of
+// the data, without decoding it.
+type xdrSkipper func(data []byte) (int, error)
+
+var errXDRTruncated = errors.New("xdr: unexpected end of data")
+
+// xdrUnion is implemented by the union types generated by xdrgen.
+type xdrUnion interface {
+	SwitchFieldName() string
+	ArmForSwitch(sw int32) (string, bool)
+}
+
+var (
+	xdrUnionType = reflect.TypeOf((*xdrUnion)(nil)).Elem()
+	xdrSkippers  sync.Map // reflect.Type -> xdrSkipper
+)
+
+// xdrSkipperOf returns the skipper of the XDR type generated by xdrgen. The
+// skippers follow the encoding rules of the reflection based encoder of
+// go-xdr, which the generated types are compatible with.
+func xdrSkipperOf(t reflect.Type) xdrSkipper {
+	if skipper, ok := xdrSkippers.Load(t); ok {
+		return skipper.(xdrSkipper)
+	}
+	compiler := xdrSkipperCompiler{skippers: map[reflect.Type]*xdrSkipper{}}
+	skipper := compiler.compile(t)
+	xdrSkippers.Store(t, skipper)
+	return skipper
+}
+
+type xdrSkipperCompiler struct {
+	// skippers are the skippers being compiled, which recursive types
+	// refer to before they are built
+	skippers map[reflect.Type]*xdrSkipper
+}
+
+func (c xdrSkipperCompiler) compile(t reflect.Type) xdrSkipper {
+	if skipper, ok := c.skippers[t]; ok {
+		return func(data []byte) (int, error) {
+			return (*skipper)(data)
+		}
+	}
+	skipper := new(xdrSkipper)
+	c.skippers[t] = skipper
+	*skipper = c.build(t)
+	return *skipper
+}
+
+func (c xdrSkipperCompiler) build(t reflect.Type) xdrSkipper {
+	if size, ok := xdrFixedSize(t); ok {
+		return skipXDRFixed(size)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		// optional values are preceded by a bool telling if they are set
+		elem := c.compile(t.Elem())
+		return func(data []byte) (int, error) {
+			present, err := readXDRUint32(data)
+			if err != nil || present == 0 {
+				return 4, err
+			}
+			n, err := elem(data[4:])
+			return 4 + n, err
+		}
+	case reflect.String:
+		return skipXDROpaque
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return skipXDROpaque
+		}
+		elem := c.compile(t.Elem())
+		return func(data []byte) (int, error) {
+			count, err := readXDRUint32(data)
+			if err != nil {
+				return 0, err
+			}
+			// every element is encoded in 4 bytes or more
+			if uint64(count)*4 > uint64(len(data)-4) {
+				return 0, errXDRTruncated
+			}
+			return skipXDRElements(data, 4, int(count), elem)
+		}
+	case reflect.Array:
+		elem := c.compile(t.Elem())
+		count := t.Len()
+		return func(data []byte) (int, error) {
+			return skipXDRElements(data, 0, count, elem)
+		}
+	case reflect.Struct:
+		if t.Implements(xdrUnionType) {
+			return c.buildUnion(t)
+		}
+		var fields []xdrSkipper
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.IsExported() {
+				fields = append(fields, c.compile(field.Type))
+			}
+		}
+		return func(data []byte) (int, error) {
+			offset := 0
+			for _, field := range fields {
+				n, err := field(data[offset:])
+				offset += n
+				if err != nil {
+					return offset, err
+				}
+			}
+			return offset, nil
+		}
+	default:
+		return func([]byte) (int, error) {
+			return 0, errors.Errorf("xdr: unsupported type %s", t)
+		}
+	}
+}
+
+// buildUnion builds the skipper of a union, which is encoded as its
+// discriminant followed by the value of the arm it selects.
+func (c xdrSkipperCompiler) buildUnion(t reflect.Type) xdrSkipper {
+	union := reflect.Zero(t).Interface().(xdrUnion)
+	arms := map[string]xdrSkipper{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name != union.SwitchFieldName() && field.Type.Kind() == reflect.Ptr {
+			arms[field.Name] = c.compile(field.Type.Elem())
+		}
+	}
+	return func(data []byte) (int, error) {
+		sw, err := readXDRUint32(data)
+		if err != nil {
+			return 0, err
+		}
+		name, ok := union.ArmForSwitch(int32(sw))
+		if !ok {
+			return 4, errors.Errorf("xdr: invalid discriminant %d of %s", int32(sw), t)
+		}
+		if name == "" {
+			return 4, nil
+		}
+		arm, ok := arms[name]
+		if !ok {
+			return 4, errors.Errorf("xdr: unknown arm %s of %s", name, t)
+		}
+		n, err := arm(data[4:])
+		return 4 + n, err
+	}
+}
+
+// xdrFixedSize returns the size of the encoding of the values of the type if
+// it doesn't depend on the value.
+func xdrFixedSize(t reflect.Type) (int, bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4, true
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8, true
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return xdrPadded(t.Len()), true
+		}
+		size, ok := xdrFixedSize(t.Elem())
+		return size * t.Len(), ok
+	case reflect.Struct:
+		if t.Implements(xdrUnionType) {
+			return 0, false
+		}
+		total := 0
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			size, ok := xdrFixedSize(field.Type)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	default:
+		return 0, false
+	}
+}
+
+func xdrPadded(size int) int {
+	return (size + 3) &^ 3
+}
+
+func readXDRUint32(data []byte) (uint32, error) {
+	if len(data) < 4 {
+		return 0, errXDRTruncated
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+func skipXDRFixed(size int) xdrSkipper {
+	return func(data []byte) (int, error) {
+		if len(data) < size {
+			return 0, errXDRTruncated
+		}
+		return size, nil
+	}
+}
+
+// skipXDROpaque skips variable length opaque data and strings.
+func skipXDROpaque(data []byte) (int, error) {
+	length, err := readXDRUint32(data)
+	if err != nil {
+		return 0, err
+	}
+	size := 4 + (uint64(length)+3)&^3
+	if size > uint64(len(data)) {
+		return 0, errXDRTruncated
+	}
+	return int(size), nil
+}
+
+func skipXDRElements(data []byte, offset, count int, elem xdrSkipper) (int, error) {
+	for i := 0; i < count; i++ {
+		n, err := elem(data[offset:])
+		offset += n
+		if err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
@@ -0,0 +1,70 @@
+package ingest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+func TestXDRSkipper(t *testing.T) {
+	ctx := context.Background()
+	backend, err := ledgerbackend.NewSyntheticBackend(ledgerbackend.SyntheticScenario{
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		Seed:                7,
+		Assets:              2,
+		Payments:            2,
+		Offers:              1,
+		ContractInvocations: 1,
+		FeeBumpRatio:        0.5,
+		UnifiedEvents:       true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.PrepareRange(ctx, ledgerbackend.BoundedRange(2, 5)))
+
+	skip := xdrSkipperOf(reflect.TypeOf(xdr.LedgerCloseMeta{}))
+	for sequence := uint32(2); sequence <= 5; sequence++ {
+		lcm, err := backend.GetLedger(ctx, sequence)
+		require.NoError(t, err)
+		raw, err := lcm.MarshalBinary()
+		require.NoError(t, err)
+
+		size, err := skip(raw)
+		require.NoError(t, err)
+		require.Equal(t, len(raw), size)
+
+		// trailing data is not skipped
+		size, err = skip(append(raw, 0, 0, 0, 0))
+		require.NoError(t, err)
+		require.Equal(t, len(raw), size)
+
+		_, err = skip(raw[:len(raw)-1])
+		require.ErrorIs(t, err, errXDRTruncated)
+	}
+
+	// optional values and strings
+	sym := xdr.ScSymbol("abcde")
+	val := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym}
+	for _, value := range []interface{ MarshalBinary() ([]byte, error) }{
+		val,
+		xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: new(*xdr.ScVec)},
+		xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: func() **xdr.ScVec {
+			vec := &xdr.ScVec{val, val}
+			return &vec
+		}()},
+	} {
+		raw, err := value.MarshalBinary()
+		require.NoError(t, err)
+		size, err := xdrSkipperOf(reflect.TypeOf(value))(raw)
+		require.NoError(t, err)
+		require.Equal(t, len(raw), size)
+	}
+
+	_, err = xdrSkipperOf(reflect.TypeOf(xdr.ScVal{}))([]byte{0, 0, 0, 0xff})
+	require.ErrorContains(t, err, "invalid discriminant")
+}
@@ -223,6 +223,29 @@ type Balance struct {
 	base.Asset
 }
 
+// BalanceSnapshot represents the balance of an asset held by an account or a
+// contract at the end of a ledger in which the balance changed.
+type BalanceSnapshot struct {
+	Links struct {
+		Ledger hal.Link `json:"ledger"`
+	} `json:"_links"`
+
+	ID      string `json:"id"`
+	PT      string `json:"paging_token"`
+	Address string `json:"address"`
+	base.Asset
+	// ContractID is the stellar asset contract of the asset.
+	ContractID      string    `json:"contract_id"`
+	Ledger          int32     `json:"ledger"`
+	LedgerCloseTime time.Time `json:"ledger_close_time"`
+	Balance         string    `json:"balance"`
+}
+
+// PagingToken implementation for hal.Pageable
+func (res BalanceSnapshot) PagingToken() string {
+	return res.PT
+}
+
 // Ledger represents a single closed ledger
 type Ledger struct {
 	Links struct {
@@ -403,6 +426,64 @@ func (p *TradePrice) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// ContractEvent represents an event emitted by a smart contract, or by the
+// network on its behalf, while applying an operation.
+type ContractEvent struct {
+	Links struct {
+		Transaction hal.Link `json:"transaction"`
+		Operation   hal.Link `json:"operation"`
+	} `json:"_links"`
+
+	ID              string    `json:"id"`
+	PT              string    `json:"paging_token"`
+	Type            string    `json:"type"`
+	ContractID      string    `json:"contract_id,omitempty"`
+	Ledger          int32     `json:"ledger"`
+	LedgerCloseTime time.Time `json:"ledger_close_time"`
+	TransactionHash string    `json:"transaction_hash"`
+	// Topics are the ScVal topics of the event, base64 XDR encoded
+	Topics []string `json:"topics"`
+	// Value is the ScVal data of the event, base64 XDR encoded
+	Value string `json:"value"`
+}
+
+// PagingToken implementation for hal.Pageable
+func (res ContractEvent) PagingToken() string {
+	return res.PT
+}
+
+// TokenTransfer represents a movement of tokens: a transfer, mint, burn,
+// clawback or fee of a classic asset, stellar asset contract or SEP-41 token.
+type TokenTransfer struct {
+	Links struct {
+		Transaction hal.Link  `json:"transaction"`
+		Operation   *hal.Link `json:"operation,omitempty"`
+	} `json:"_links"`
+
+	ID              string    `json:"id"`
+	PT              string    `json:"paging_token"`
+	Type            string    `json:"type"`
+	Ledger          int32     `json:"ledger"`
+	LedgerCloseTime time.Time `json:"ledger_close_time"`
+	TransactionHash string    `json:"transaction_hash"`
+	// ContractID is the token contract, the stellar asset contract of the
+	// asset for classic assets.
+	ContractID  string `json:"contract_id"`
+	AssetType   string `json:"asset_type,omitempty"`
+	AssetCode   string `json:"asset_code,omitempty"`
+	AssetIssuer string `json:"asset_issuer,omitempty"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	// Amount is in the raw units of the token. The refund of the fee of
+	// soroban transactions is a fee event with a negative amount.
+	Amount string `json:"amount"`
+}
+
+// PagingToken implementation for hal.Pageable
+func (res TokenTransfer) PagingToken() string {
+	return res.PT
+}
+
 // Trade represents a horizon digested trade
 type Trade struct {
 	Links struct {
@@ -703,6 +784,30 @@ type TradeAggregationsPage struct {
 	} `json:"_embedded"`
 }
 
+// ContractEventsPage returns a list of contract event records
+type ContractEventsPage struct {
+	Links    hal.Links `json:"_links"`
+	Embedded struct {
+		Records []ContractEvent `json:"records"`
+	} `json:"_embedded"`
+}
+
+// BalanceHistoryPage returns a list of balance snapshots
+type BalanceHistoryPage struct {
+	Links    hal.Links `json:"_links"`
+	Embedded struct {
+		Records []BalanceSnapshot `json:"records"`
+	} `json:"_embedded"`
+}
+
+// TokenTransfersPage returns a list of token transfer records
+type TokenTransfersPage struct {
+	Links    hal.Links `json:"_links"`
+	Embedded struct {
+		Records []TokenTransfer `json:"records"`
+	} `json:"_embedded"`
+}
+
 // TradesPage returns a list of trade records
 type TradesPage struct {
 	Links    hal.Links `json:"_links"`
@@ -862,6 +967,84 @@ type LiquidityPoolReserve struct {
 	Amount string `json:"amount"`
 }
 
+// ContractValue is a smart contract value (ScVal), both in its XDR form and
+// decoded to JSON.
+type ContractValue struct {
+	// XDR is the base64 encoded ScVal XDR
+	XDR string `json:"xdr"`
+	// JSON is the ScVal decoded to JSON. 64 bits and larger integers are
+	// decoded to strings, bytes to hex strings and maps to lists of key and
+	// value objects.
+	JSON json.RawMessage `json:"json"`
+}
+
+// ContractStorageEntry is a key and value pair of the instance storage of a
+// smart contract
+type ContractStorageEntry struct {
+	Key   ContractValue `json:"key"`
+	Value ContractValue `json:"value"`
+}
+
+// Contract represents the instance of a smart contract
+type Contract struct {
+	Links struct {
+		Self   hal.Link  `json:"self"`
+		Data   hal.Link  `json:"data"`
+		Events hal.Link  `json:"events"`
+		Code   *hal.Link `json:"code,omitempty"`
+	} `json:"_links"`
+
+	ID string `json:"id"`
+	// ExecutableType is either wasm or stellar_asset
+	ExecutableType     string                 `json:"executable_type"`
+	WasmHash           string                 `json:"wasm_hash,omitempty"`
+	Storage            []ContractStorageEntry `json:"storage"`
+	LastModifiedLedger uint32                 `json:"last_modified_ledger"`
+	LiveUntilLedgerSeq uint32                 `json:"live_until_ledger_seq,omitempty"`
+}
+
+// ContractData represents a contract data entry of a smart contract
+type ContractData struct {
+	Links struct {
+		Contract hal.Link `json:"contract"`
+	} `json:"_links"`
+
+	PT         string `json:"paging_token"`
+	ContractID string `json:"contract_id"`
+	// Durability is either persistent or temporary
+	Durability         string        `json:"durability"`
+	Key                ContractValue `json:"key"`
+	Value              ContractValue `json:"value"`
+	LastModifiedLedger uint32        `json:"last_modified_ledger"`
+	LiveUntilLedgerSeq uint32        `json:"live_until_ledger_seq,omitempty"`
+}
+
+// PagingToken implementation for hal.Pageable
+func (res ContractData) PagingToken() string {
+	return res.PT
+}
+
+// ContractDataPage returns a list of contract data records
+type ContractDataPage struct {
+	Links    hal.Links `json:"_links"`
+	Embedded struct {
+		Records []ContractData `json:"records"`
+	} `json:"_embedded"`
+}
+
+// ContractCode represents the wasm code of smart contracts
+type ContractCode struct {
+	Links struct {
+		Self hal.Link `json:"self"`
+	} `json:"_links"`
+
+	Hash string `json:"hash"`
+	// Code is the base64 encoded wasm code
+	Code               string `json:"code"`
+	LastModifiedLedger uint32 `json:"last_modified_ledger"`
+	LiveUntilLedgerSeq uint32 `json:"live_until_ledger_seq,omitempty"`
+}
+
 type AssetFilterConfig struct {
 	Whitelist    []string `json:"whitelist"`
 	Enabled      *bool    `json:"enabled"`
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest"
 	"github.com/stellar/go/ingest/ledgerbackend"
 	"github.com/stellar/go/ingest/loadtest"
 	"github.com/stellar/go/support/compressxdr"
@@ -87,6 +89,11 @@ type App struct {
 	exportManager *ExportManager
 	uploader      Uploader
 	adminServer   *http.Server
+
+	// sourceDataStore and sourceSchema describe the datastore ledgers are
+	// read from when the ledger backend is a datastore.
+	sourceDataStore datastore.DataStore
+	sourceSchema    datastore.DataStoreSchema
 }
 
 func NewApp() *App {
@@ -110,11 +117,17 @@ func (a *App) init(ctx context.Context, runtimeSettings RuntimeSettings) error {
 	if a.config, err = NewConfig(runtimeSettings, nil); err != nil {
 		return errors.Wrap(err, "Could not load configuration")
 	}
-	if archive, err = a.config.GenerateHistoryArchive(ctx, logger); err != nil {
-		return err
-	}
-	if err = a.config.ValidateAndSetLedgerRange(ctx, archive); err != nil {
-		return err
+	if a.config.LedgerBackend == DataStoreBackend {
+		if err = a.initSourceDataStore(ctx); err != nil {
+			return err
+		}
+	} else {
+		if archive, err = a.config.GenerateHistoryArchive(ctx, logger); err != nil {
+			return err
+		}
+		if err = a.config.ValidateAndSetLedgerRange(ctx, archive); err != nil {
+			return err
+		}
 	}
 
 	if a.dataStore, err = datastore.NewDataStore(ctx, a.config.DataStoreConfig); err != nil {
@@ -148,6 +161,12 @@ func (a *App) init(ctx context.Context, runtimeSettings RuntimeSettings) error {
 		logger.WithField("manifest", manifest).Infof("Datastore config manifest already exists.")
 	}
 
+	// New ledger files are compressed with the most recent zstd dictionary
+	// published to the datastore by the train-dictionary command.
+	if a.config.DataStoreConfig.Schema.ZstdDictionaries, err = datastore.LoadZstdDictionaries(ctx, a.dataStore, manifest); err != nil {
+		return fmt.Errorf("could not load zstd dictionaries %w", err)
+	}
+
 	if a.config.Resumable() {
 		if err = a.applyResumability(ctx); err != nil {
 			return err
@@ -157,7 +176,7 @@ func (a *App) init(ctx context.Context, runtimeSettings RuntimeSettings) error {
 	logger.Infof("Final computed ledger range for backend retrieval and export, start=%d, end=%d",
 		a.config.StartLedger, a.config.EndLedger)
 
-	if a.ledgerBackend, err = newLedgerBackend(a.config, registry); err != nil {
+	if a.ledgerBackend, err = a.newLedgerBackend(registry); err != nil {
 		return err
 	}
 
@@ -169,6 +188,11 @@ func (a *App) init(ctx context.Context, runtimeSettings RuntimeSettings) error {
 		return err
 	}
 	a.uploader = NewUploader(a.dataStore, queue, registry, a.config.Mode == Replace)
+	if a.config.LedgerIndexes {
+		if a.uploader.indexer, err = newLedgerIndexer(a.dataStore, a.config.DataStoreConfig.Schema); err != nil {
+			return err
+		}
+	}
 
 	if a.config.AdminPort != 0 {
 		a.adminServer = newAdminServer(a.config.AdminPort, registry)
@@ -187,7 +211,7 @@ func validateExistingFileExtension(ctx context.Context, ds datastore.DataStore)
 		return fmt.Errorf("unable to determine ledger file extension from data store: %w", err)
 	}
 
-	if fileExt != compressxdr.DefaultCompressor.Name() {
+	if !slices.Contains(compressxdr.Extensions(), fileExt) {
 		return fmt.Errorf("detected older incompatible ledger files in the data store (extension %q). "+
 			"Galexie v23.0+ requires starting with an empty datastore", fileExt)
 	}
@@ -216,10 +240,31 @@ func (a *App) applyResumability(ctx context.Context) error {
 	return nil
 }
 
+// initSourceDataStore connects to the datastore ledgers are read from,
+// loads its schema and validates the requested ledger range against it.
+func (a *App) initSourceDataStore(ctx context.Context) error {
+	var err error
+	if a.sourceDataStore, err = datastore.NewDataStore(ctx, a.config.SourceDataStoreConfig); err != nil {
+		return fmt.Errorf("could not connect to source data store %w", err)
+	}
+	if a.sourceSchema, err = datastore.LoadSchema(ctx, a.sourceDataStore, a.config.SourceDataStoreConfig); err != nil {
+		return fmt.Errorf("could not load source datastore schema %w", err)
+	}
+	logger.Infof("Reading ledgers from source datastore with ledgers_per_file=%d, files_per_partition=%d, file_extension=%q",
+		a.sourceSchema.LedgersPerFile, a.sourceSchema.FilesPerPartition, a.sourceSchema.FileExtension)
+
+	return a.config.ValidateAndSetSourceDataStoreLedgerRange(ctx, a.sourceDataStore)
+}
+
 func (a *App) close() {
 	if err := a.dataStore.Close(); err != nil {
 		logger.WithError(err).Error("Error closing datastore")
 	}
+	if a.sourceDataStore != nil {
+		if err := a.sourceDataStore.Close(); err != nil {
+			logger.WithError(err).Error("Error closing source datastore")
+		}
+	}
 	if err := a.ledgerBackend.Close(); err != nil {
 		logger.WithError(err).Error("Error closing ledgerBackend")
 	}
@@ -240,6 +285,21 @@ func (a *App) Run(runtimeSettings RuntimeSettings) error {
 	ctx, cancel := context.WithCancel(runtimeSettings.Ctx)
 	defer cancel()
 
+	switch runtimeSettings.Mode {
+	case TrainDictionary:
+		if err := a.trainDictionary(ctx, runtimeSettings); err != nil {
+			logger.WithError(err).Error("Stopping Galexie")
+			return err
+		}
+		return nil
+	case Verify:
+		if err := a.runVerify(ctx, runtimeSettings); err != nil {
+			logger.WithError(err).Error("Stopping Galexie")
+			return err
+		}
+		return nil
+	}
+
 	if err := a.init(ctx, runtimeSettings); err != nil {
 		var dataAlreadyExported *DataAlreadyExportedError
 		if errors.As(err, &dataAlreadyExported) {
@@ -318,8 +378,39 @@ func (a *App) Run(runtimeSettings RuntimeSettings) error {
 	return nil
 }
 
-// newLedgerBackend Creates and initializes a captive core ledger backend
-func newLedgerBackend(config *Config, prometheusRegistry *prometheus.Registry) (ledgerbackend.LedgerBackend, error) {
+// newLedgerBackend Creates and initializes the configured ledger backend:
+// captive core, an RPC server or the source datastore.
+func (a *App) newLedgerBackend(prometheusRegistry *prometheus.Registry) (ledgerbackend.LedgerBackend, error) {
+	var backend ledgerbackend.LedgerBackend
+	var err error
+	switch a.config.LedgerBackend {
+	case RPCBackend:
+		backend = ledgerbackend.NewRPCLedgerBackend(ledgerbackend.RPCLedgerBackendOptions{
+			RPCServerURL: a.config.RPCConfig.RPCServerURL,
+			BufferSize:   a.config.RPCConfig.BufferSize,
+		})
+	case DataStoreBackend:
+		backend, err = ledgerbackend.NewBufferedStorageBackend(
+			ingest.DefaultBufferedStorageBackendConfig(a.sourceSchema.LedgersPerFile), a.sourceDataStore, a.sourceSchema)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create source datastore ledger backend")
+		}
+	default:
+		if backend, err = newCaptiveCoreBackend(a.config); err != nil {
+			return nil, err
+		}
+	}
+
+	// For load test mode, wrap the backend with loadtest backend
+	if a.config.Mode == LoadTest {
+		backend = newLoadTestBackend(a.config, backend)
+	}
+
+	return ledgerbackend.WithMetrics(backend, prometheusRegistry, nameSpace), nil
+}
+
+// newCaptiveCoreBackend Creates and initializes a captive core ledger backend
+func newCaptiveCoreBackend(config *Config) (ledgerbackend.LedgerBackend, error) {
 	// best effort check on a core bin available from PATH to provide as default if
 	// no core bin is provided from config.
 	coreBinFromPath, _ := exec.LookPath("stellar-core")
@@ -328,19 +419,12 @@ func newLedgerBackend(config *Config, prometheusRegistry *prometheus.Registry) (
 		return nil, err
 	}
 
-	var captiveCoreBackend ledgerbackend.LedgerBackend
 	// Create a new captive core backend
-	captiveCoreBackend, err = ledgerbackend.NewCaptive(captiveConfig)
+	captiveCoreBackend, err := ledgerbackend.NewCaptive(captiveConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create captive-core instance")
 	}
-
-	// For load test mode, wrap the backend with loadtest backend
-	if config.Mode == LoadTest {
-		captiveCoreBackend = newLoadTestBackend(config, captiveCoreBackend)
-	}
-
-	return ledgerbackend.WithMetrics(captiveCoreBackend, prometheusRegistry, nameSpace), nil
+	return captiveCoreBackend, nil
 }
 
 func newLoadTestBackend(config *Config, backend ledgerbackend.LedgerBackend) *loadtest.LedgerBackend {
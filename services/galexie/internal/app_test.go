@@ -162,6 +162,11 @@ func TestValidateExistingFileExtension(t *testing.T) {
 			files:       []string{".config.json", "ledger/FFFFFFFF--0.xdr." + compressxdr.DefaultCompressor.Name()},
 			expectedErr: nil,
 		},
+		{
+			name:        "valid schema filename with other registered compressor extension, no error",
+			files:       []string{".config.json", "ledger/FFFFFFFF--0.xdr.gz"},
+			expectedErr: nil,
+		},
 		{
 			name:  "valid schema filename with non-default extension returns error",
 			files: []string{".config.json", "ledger/FFFFFFFE--0-999.xdr.zstd"},
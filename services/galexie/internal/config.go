@@ -16,6 +16,7 @@ import (
 
 	"github.com/pelletier/go-toml"
 
+	"github.com/stellar/go/support/compressxdr"
 	"github.com/stellar/go/support/datastore"
 	"github.com/stellar/go/support/errors"
 	"github.com/stellar/go/support/storage"
@@ -35,6 +36,9 @@ const (
 	Append
 	Replace
 	LoadTest
+	TrainDictionary
+	Verify
+	Migrate
 )
 
 func (mode Mode) Name() string {
@@ -47,13 +51,16 @@ func (mode Mode) Name() string {
 		return "Replace"
 	case LoadTest:
 		return "Load Test"
+	case TrainDictionary:
+		return "Train Dictionary"
+	case Verify:
+		return "Verify"
+	case Migrate:
+		return "Migrate"
 	}
 	return "none"
 }
 
-// user-configurable in the future.
-const compressionType = "zstd"
-
 type RuntimeSettings struct {
 	StartLedger    uint32
 	EndLedger      uint32
@@ -64,6 +71,23 @@ type RuntimeSettings struct {
 	LoadTestMerge         bool
 	LoadTestLedgersPath   string
 	LoadTestCloseDuration time.Duration
+	// Train dictionary specific fields
+	DictionaryMaxSize int
+	// Verify specific fields
+	VerifyCheckArchive bool
+	VerifyReportPath   string
+}
+
+// Ledger backends galexie can export ledgers from.
+const (
+	CaptiveCoreBackend = "captive_core"
+	RPCBackend         = "rpc"
+	DataStoreBackend   = "datastore"
+)
+
+type RPCConfig struct {
+	RPCServerURL string `toml:"rpc_server_url"`
+	BufferSize   uint32 `toml:"buffer_size"`
 }
 
 type StellarCoreConfig struct {
@@ -83,6 +107,17 @@ type Config struct {
 	StellarCoreConfig StellarCoreConfig         `toml:"stellar_core_config"`
 	UserAgent         string                    `toml:"user_agent"`
 
+	// LedgerBackend selects where ledgers are exported from, one of
+	// captive_core (default), rpc or datastore.
+	LedgerBackend string    `toml:"ledger_backend"`
+	RPCConfig     RPCConfig `toml:"rpc_config"`
+	// SourceDataStoreConfig is the datastore ledgers are read from when the
+	// ledger backend is datastore, as with the migrate command.
+	SourceDataStoreConfig datastore.DataStoreConfig `toml:"source_datastore_config"`
+	// LedgerIndexes enables writing ledger indexes of the destination
+	// datastore, see datastore.LedgerIndexReader.
+	LedgerIndexes bool `toml:"ledger_indexes"`
+
 	StartLedger uint32
 	EndLedger   uint32
 	Mode        Mode
@@ -122,6 +157,7 @@ func NewConfig(settings RuntimeSettings, getCoreVersionFn ledgerbackend.CoreBuil
 	if err = config.processToml(settings.ConfigFilePath); err != nil {
 		return nil, err
 	}
+	logger.Infof("Ledger backend: %v", config.LedgerBackend)
 	logger.Infof("Network Config Archive URLs: %v", config.StellarCoreConfig.HistoryArchiveUrls)
 	logger.Infof("Network Config Archive Passphrase: %v", config.StellarCoreConfig.NetworkPassphrase)
 	logger.Infof("Network Config Stellar Core Binary Path: %v", config.StellarCoreConfig.StellarCoreBinaryPath)
@@ -131,7 +167,7 @@ func NewConfig(settings RuntimeSettings, getCoreVersionFn ledgerbackend.CoreBuil
 }
 
 func (config *Config) Resumable() bool {
-	return config.Mode == Append
+	return config.Mode == Append || config.Mode == Migrate
 }
 
 // Validates requested ledger range, and will automatically adjust it
@@ -197,6 +233,50 @@ func (config *Config) ValidateAndSetLedgerRange(ctx context.Context, archive his
 	return nil
 }
 
+// ValidateAndSetSourceDataStoreLedgerRange validates the requested ledger
+// range against the ledgers available in the source datastore. For the
+// migrate command an end of 0 migrates up to the latest ledger in the source
+// datastore, other unbounded modes follow the source datastore as it grows.
+// A bounded range is aligned to the destination file boundaries, excluding a
+// trailing destination file which the source can't fill yet.
+func (config *Config) ValidateAndSetSourceDataStoreLedgerRange(ctx context.Context, source datastore.DataStore) error {
+	if config.StartLedger < 2 {
+		return errors.New("invalid start value, must be greater than one.")
+	}
+
+	if (config.Mode == ScanFill || config.Mode == Replace) && config.EndLedger == 0 {
+		return errors.New("invalid end value, unbounded mode not supported, end must be greater than start.")
+	}
+
+	if config.EndLedger != 0 && config.EndLedger <= config.StartLedger {
+		return errors.New("invalid end value, must be greater than start")
+	}
+
+	latestSourceLedger, err := datastore.FindLatestLedgerSequence(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve the latest ledger sequence from the source datastore.")
+	}
+	logger.Infof("Latest ledger sequence in the source datastore was detected as %d", latestSourceLedger)
+
+	if config.EndLedger == 0 && config.Mode == Migrate {
+		config.EndLedger = latestSourceLedger
+	}
+	if config.EndLedger > latestSourceLedger {
+		return errors.Errorf("end %d exceeds latest ledger %d in the source datastore",
+			config.EndLedger, latestSourceLedger)
+	}
+
+	config.adjustLedgerRange()
+	if config.EndLedger > latestSourceLedger {
+		config.EndLedger = config.DataStoreConfig.Schema.GetSequenceNumberStartBoundary(latestSourceLedger+1) - 1
+		logger.Infof("Source datastore can't fill the last destination file yet, migrating up to end=%d", config.EndLedger)
+	}
+	if config.EndLedger != 0 && config.EndLedger < config.StartLedger {
+		return errors.Errorf("source datastore has no ledgers to fill a destination file starting at %d", config.StartLedger)
+	}
+	return nil
+}
+
 func (config *Config) GenerateHistoryArchive(ctx context.Context, entry *log.Entry) (historyarchive.ArchiveInterface, error) {
 	return historyarchive.NewArchivePool(config.StellarCoreConfig.HistoryArchiveUrls, historyarchive.ArchiveOptions{
 		ConnectOptions: storage.ConnectOptions{
@@ -320,9 +400,46 @@ func (config *Config) processToml(tomlPath string) error {
 		}
 	}
 
+	// The migrate command always reads ledgers from the source datastore.
+	if config.Mode == Migrate {
+		if config.LedgerBackend != "" && config.LedgerBackend != DataStoreBackend {
+			return errors.Errorf("Invalid ledger_backend %q, migrate requires the 'datastore' ledger backend",
+				config.LedgerBackend)
+		}
+		config.LedgerBackend = DataStoreBackend
+	}
+
+	switch config.LedgerBackend {
+	case "":
+		config.LedgerBackend = CaptiveCoreBackend
+	case CaptiveCoreBackend:
+	case RPCBackend:
+		if config.RPCConfig.RPCServerURL == "" {
+			return errors.New("Invalid rpc_config, 'rpc_server_url' must be set when ledger_backend is 'rpc'")
+		}
+	case DataStoreBackend:
+		if config.SourceDataStoreConfig.Type == "" {
+			return errors.New("Invalid source_datastore_config, 'type' must be set when ledger_backend is 'datastore'")
+		}
+	default:
+		return errors.Errorf("Invalid ledger_backend %q, must be one of 'captive_core', 'rpc' or 'datastore'",
+			config.LedgerBackend)
+	}
+
 	// Populate the datastore config with the network passphrase for datastore manifest.
 	config.DataStoreConfig.NetworkPassphrase = config.StellarCoreConfig.NetworkPassphrase
-	config.DataStoreConfig.Compression = compressionType
+	config.SourceDataStoreConfig.NetworkPassphrase = config.StellarCoreConfig.NetworkPassphrase
+
+	// Compression is optional, defaults to zstd. Ledger files are named with
+	// the extension of the selected compressor.
+	if config.DataStoreConfig.Compression == "" {
+		config.DataStoreConfig.Compression = compressxdr.DefaultCompression
+	}
+	compressor, err := compressxdr.NewCompressor(config.DataStoreConfig.Compression)
+	if err != nil {
+		return errors.Wrap(err, "Invalid datastore_config.compression")
+	}
+	config.DataStoreConfig.Schema.FileExtension = compressor.Name()
 
 	return nil
 }
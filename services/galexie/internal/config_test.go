@@ -30,6 +30,8 @@ func TestNewConfig(t *testing.T) {
 	require.Equal(t, config.DataStoreConfig.Type, "ABC")
 	require.Equal(t, config.DataStoreConfig.Schema.FilesPerPartition, uint32(1))
 	require.Equal(t, config.DataStoreConfig.Schema.LedgersPerFile, uint32(3))
+	require.Equal(t, config.DataStoreConfig.Compression, "zstd")
+	require.Equal(t, config.DataStoreConfig.Schema.FileExtension, "zst")
 	require.Equal(t, config.UserAgent, "galexie")
 	require.True(t, config.Resumable())
 	url, ok := config.DataStoreConfig.Params["destination_bucket_path"]
@@ -79,6 +81,54 @@ func TestInvalidConfigFilePath(t *testing.T) {
 	require.ErrorContains(t, err, "config file test/notfound.toml was not found")
 }
 
+func TestNewConfigCompression(t *testing.T) {
+	config, err := NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/compression.toml", Mode: Append}, nil)
+	require.NoError(t, err)
+	require.Equal(t, config.DataStoreConfig.Compression, "gzip")
+	require.Equal(t, config.DataStoreConfig.Schema.FileExtension, "gz")
+
+	_, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/invalid_compression.toml", Mode: Append}, nil)
+	require.ErrorContains(t, err, `Invalid datastore_config.compression: unsupported compression type "brotli"`)
+}
+
+func TestNewConfigLedgerBackend(t *testing.T) {
+	config, err := NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/test.toml", Mode: Append}, nil)
+	require.NoError(t, err)
+	require.Equal(t, CaptiveCoreBackend, config.LedgerBackend)
+
+	config, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/rpc_backend.toml", Mode: Append}, nil)
+	require.NoError(t, err)
+	require.Equal(t, RPCBackend, config.LedgerBackend)
+	require.Equal(t, RPCConfig{RPCServerURL: "http://localhost:8000", BufferSize: 25}, config.RPCConfig)
+
+	config, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/datastore_backend.toml", Mode: Append}, nil)
+	require.NoError(t, err)
+	require.Equal(t, DataStoreBackend, config.LedgerBackend)
+	require.Equal(t, "Filesystem", config.SourceDataStoreConfig.Type)
+	require.Equal(t, network.PublicNetworkPassphrase, config.SourceDataStoreConfig.NetworkPassphrase)
+
+	_, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/invalid_ledger_backend.toml", Mode: Append}, nil)
+	require.EqualError(t, err, `Invalid ledger_backend "horizon", must be one of 'captive_core', 'rpc' or 'datastore'`)
+
+	_, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/invalid_rpc_backend.toml", Mode: Append}, nil)
+	require.EqualError(t, err, "Invalid rpc_config, 'rpc_server_url' must be set when ledger_backend is 'rpc'")
+
+	_, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/invalid_datastore_backend.toml", Mode: Append}, nil)
+	require.EqualError(t, err, "Invalid source_datastore_config, 'type' must be set when ledger_backend is 'datastore'")
+
+	_, err = NewConfig(
+		RuntimeSettings{StartLedger: 2, EndLedger: 3, ConfigFilePath: "test/rpc_backend.toml", Mode: Migrate}, nil)
+	require.EqualError(t, err, `Invalid ledger_backend "rpc", migrate requires the 'datastore' ledger backend`)
+}
+
 func TestNoCaptiveCoreBin(t *testing.T) {
 	cfg, err := NewConfig(
 		RuntimeSettings{ConfigFilePath: "test/no_core_bin.toml"}, nil)
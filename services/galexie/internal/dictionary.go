@@ -0,0 +1,125 @@
+package galexie
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+)
+
+// defaultDictionaryMaxSize is the default maximum size of trained zstd
+// dictionaries, matching the default of the zstd CLI.
+const defaultDictionaryMaxSize = 112640
+
+// trainDictionary trains a zstd dictionary from the ledger files already
+// exported to the datastore for the requested range and publishes it
+// alongside the datastore manifest. Ledger files exported afterwards are
+// compressed with the new dictionary.
+func (a *App) trainDictionary(ctx context.Context, runtimeSettings RuntimeSettings) error {
+	var err error
+	if a.config, err = NewConfig(runtimeSettings, nil); err != nil {
+		return errors.Wrap(err, "Could not load configuration")
+	}
+	if a.config.StartLedger < 2 {
+		return errors.New("invalid start value, must be greater than one.")
+	}
+	if a.config.EndLedger < a.config.StartLedger {
+		return errors.New("invalid end value, must be greater than or equal to start")
+	}
+
+	if a.dataStore, err = datastore.NewDataStore(ctx, a.config.DataStoreConfig); err != nil {
+		return fmt.Errorf("could not connect to destination data store %w", err)
+	}
+	defer func() {
+		if err := a.dataStore.Close(); err != nil {
+			logger.WithError(err).Error("Error closing datastore")
+		}
+	}()
+
+	schema, err := datastore.LoadSchema(ctx, a.dataStore, a.config.DataStoreConfig)
+	if err != nil {
+		return fmt.Errorf("could not load datastore schema %w", err)
+	}
+
+	maxSize := runtimeSettings.DictionaryMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultDictionaryMaxSize
+	}
+	dictionary, err := trainZstdDictionary(ctx, a.dataStore, schema, a.config.StartLedger, a.config.EndLedger, maxSize)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := datastore.PublishZstdDictionary(ctx, a.dataStore, dictionary)
+	if err != nil {
+		return fmt.Errorf("could not publish zstd dictionary %w", err)
+	}
+	logger.WithField("manifest", manifest).Infof("Successfully published zstd dictionary %d of %d bytes.",
+		manifest.ZstdDictionaries[len(manifest.ZstdDictionaries)-1], len(dictionary))
+	return nil
+}
+
+// trainZstdDictionary trains a zstd dictionary of at most maxSize bytes from
+// the uncompressed ledger files of the given range. The dictionary id is one
+// greater than the id of the most recent dictionary in the schema.
+func trainZstdDictionary(ctx context.Context, dataStore datastore.DataStore, schema datastore.DataStoreSchema,
+	start, end uint32, maxSize int) ([]byte, error) {
+	compressor, err := schema.GetCompressor()
+	if err != nil {
+		return nil, err
+	}
+
+	var id uint32 = 1
+	if len(schema.ZstdDictionaries) > 0 {
+		latest, err := compressxdr.NewZstdDictCompressor(schema.ZstdDictionaries...)
+		if err != nil {
+			return nil, err
+		}
+		id = latest.DictionaryID() + 1
+	}
+
+	var samples [][]byte
+	for seq := schema.GetSequenceNumberStartBoundary(start); seq <= end; seq += schema.LedgersPerFile {
+		objectKey := schema.GetObjectKeyFromSequenceNumber(seq)
+		sample, err := readUncompressedFile(ctx, dataStore, compressor, objectKey)
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("Skipping missing ledger file %s", objectKey)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no ledger files found in the range %d-%d to train the zstd dictionary from", start, end)
+	}
+
+	logger.Infof("Training zstd dictionary %d from %d ledger files", id, len(samples))
+	return compressxdr.TrainZstdDictionary(samples, id, maxSize)
+}
+
+func readUncompressedFile(ctx context.Context, dataStore datastore.DataStore, compressor compressxdr.Compressor, objectKey string) ([]byte, error) {
+	reader, err := dataStore.GetFile(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decompressed, err := compressor.NewReader(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decompress ledger file %s", objectKey)
+	}
+	defer decompressed.Close()
+
+	data, err := io.ReadAll(decompressed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read ledger file %s", objectKey)
+	}
+	return data, nil
+}
@@ -0,0 +1,82 @@
+package galexie
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+func TestTrainZstdDictionary(t *testing.T) {
+	ctx := context.Background()
+	store, err := datastore.NewDataStore(ctx, datastore.DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	cfg := datastore.DataStoreConfig{
+		NetworkPassphrase: "test",
+		Compression:       compressxdr.CompressionZstd,
+		Schema:            datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10},
+	}
+	_, _, err = datastore.PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+
+	// ledgers 2-199 are exported, except for 50 which is skipped
+	for seq := uint32(2); seq < 200; seq++ {
+		if seq == 50 {
+			continue
+		}
+		lcm := createLedgerCloseMeta(seq)
+		binary.BigEndian.PutUint32(lcm.V0.LedgerHeader.Header.TxSetResultHash[:], seq*7919)
+		lcm.V0.LedgerHeader.Hash = sha256.Sum256(lcm.V0.LedgerHeader.Header.TxSetResultHash[:])
+		batch := xdr.LedgerCloseMetaBatch{
+			StartSequence:    xdr.Uint32(seq),
+			EndSequence:      xdr.Uint32(seq),
+			LedgerCloseMetas: []xdr.LedgerCloseMeta{lcm},
+		}
+		var buf bytes.Buffer
+		_, err = compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+		require.NoError(t, err)
+		require.NoError(t, store.PutFile(ctx, cfg.Schema.GetObjectKeyFromSequenceNumber(seq), &buf, nil))
+	}
+
+	schema, err := datastore.LoadSchema(ctx, store, cfg)
+	require.NoError(t, err)
+	_, err = trainZstdDictionary(ctx, store, schema, 300, 400, 4096)
+	require.ErrorContains(t, err, "no ledger files found in the range 300-400")
+
+	dictionary, err := trainZstdDictionary(ctx, store, schema, 2, 199, 4096)
+	require.NoError(t, err)
+	_, err = datastore.PublishZstdDictionary(ctx, store, dictionary)
+	require.NoError(t, err)
+
+	schema, err = datastore.LoadSchema(ctx, store, cfg)
+	require.NoError(t, err)
+	compressor, err := schema.GetCompressor()
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), compressor.(*compressxdr.ZstdDictCompressor).DictionaryID())
+
+	// new ledger files record the dictionary id in their metadata
+	archive, err := NewLedgerMetaArchiveFromXDR("test", "v1", "key", xdr.LedgerCloseMetaBatch{
+		StartSequence:    200,
+		EndSequence:      200,
+		LedgerCloseMetas: []xdr.LedgerCloseMeta{createLedgerCloseMeta(200)},
+	}, compressor)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), archive.metaData.CompressionDictionaryID)
+
+	// retraining publishes the next version of the dictionary
+	dictionary, err = trainZstdDictionary(ctx, store, schema, 2, 199, 4096)
+	require.NoError(t, err)
+	manifest, err := datastore.PublishZstdDictionary(ctx, store, dictionary)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 2}, manifest.ZstdDictionaries)
+}
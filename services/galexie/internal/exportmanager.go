@@ -8,6 +8,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/compressxdr"
 	"github.com/stellar/go/support/datastore"
 	"github.com/stellar/go/xdr"
 )
@@ -20,6 +21,7 @@ type ExportManager struct {
 	latestLedgerMetric *prometheus.GaugeVec
 	networkPassPhrase  string
 	coreVersion        string
+	compressor         compressxdr.Compressor
 }
 
 // NewExportManager creates a new ExportManager with the provided configuration.
@@ -33,6 +35,11 @@ func NewExportManager(dataStoreSchema datastore.DataStoreSchema,
 		return nil, errors.Errorf("Invalid ledgers per file (%d): must be at least 1", dataStoreSchema.LedgersPerFile)
 	}
 
+	compressor, err := dataStoreSchema.GetCompressor()
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid file extension")
+	}
+
 	latestLedgerMetric := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: nameSpace, Subsystem: "export_manager", Name: "latest_ledger",
 		Help: "sequence number of the latest ledger consumed by the export manager",
@@ -46,6 +53,7 @@ func NewExportManager(dataStoreSchema datastore.DataStoreSchema,
 		latestLedgerMetric: latestLedgerMetric,
 		networkPassPhrase:  networkPassPhrase,
 		coreVersion:        coreVersion,
+		compressor:         compressor,
 	}, nil
 }
 
@@ -75,7 +83,7 @@ func (e *ExportManager) AddLedgerCloseMeta(ctx context.Context, ledgerCloseMeta
 	}
 
 	if ledgerSeq >= uint32(e.currentMetaArchive.EndSequence) {
-		ledgerMetaArchive, err := NewLedgerMetaArchiveFromXDR(e.networkPassPhrase, e.coreVersion, objectKey, *e.currentMetaArchive)
+		ledgerMetaArchive, err := NewLedgerMetaArchiveFromXDR(e.networkPassPhrase, e.coreVersion, objectKey, *e.currentMetaArchive, e.compressor)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,172 @@
+package galexie
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+// ledgerIndexer maintains the ledger indexes of the datastore as ledger files
+// are uploaded. Each index covers a range of ledgers, at most a partition, and
+// is written when its last ledger is indexed and when the export stops, so
+// the range being exported is indexed up to the last ledger uploaded before
+// the shutdown.
+type ledgerIndexer struct {
+	dataStore  datastore.DataStore
+	schema     datastore.DataStoreSchema
+	compressor compressxdr.Compressor
+	// index is the index of the range being exported, nil until the first
+	// batch is indexed.
+	index *datastore.LedgerIndex
+}
+
+func newLedgerIndexer(dataStore datastore.DataStore, schema datastore.DataStoreSchema) (*ledgerIndexer, error) {
+	compressor, err := schema.GetCompressor()
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerIndexer{
+		dataStore:  dataStore,
+		schema:     schema,
+		compressor: compressor,
+	}, nil
+}
+
+// addBatch indexes the ledgers of an uploaded batch. Ledgers which were
+// already indexed are indexed again, since they may have been replaced, and
+// the ledgers indexed after them are kept.
+func (l *ledgerIndexer) addBatch(ctx context.Context, batch *xdr.LedgerCloseMetaBatch) error {
+	for _, lcm := range batch.LedgerCloseMetas {
+		if err := l.addLedger(ctx, lcm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *ledgerIndexer) addLedger(ctx context.Context, lcm xdr.LedgerCloseMeta) error {
+	seq := lcm.LedgerSequence()
+	if l.index == nil || seq < l.index.Start || seq > l.index.End {
+		if err := l.flush(ctx); err != nil {
+			return err
+		}
+		if err := l.loadIndex(ctx, seq); err != nil {
+			return err
+		}
+	}
+
+	var reindexTo uint32
+	if l.index.LastLedger != 0 && seq < l.index.FirstLedger {
+		// the ledgers between seq and the first indexed ledger were not
+		// exported when the range was indexed, it is indexed again from its
+		// files
+		reindexTo = l.index.LastLedger
+		l.index = datastore.NewLedgerIndex(l.schema, seq)
+	}
+	if l.index.LastLedger == 0 || seq > l.index.LastLedger {
+		from := l.index.Start
+		if l.index.LastLedger != 0 {
+			from = l.index.LastLedger + 1
+		}
+		if seq > from {
+			if err := l.backfill(ctx, from, seq-1); err != nil {
+				return err
+			}
+		}
+	}
+	// the entries of an indexed ledger are replaced
+	if err := l.index.AddLedger(lcm); err != nil {
+		return err
+	}
+	if reindexTo > seq {
+		if err := l.backfill(ctx, seq+1, reindexTo); err != nil {
+			return err
+		}
+	}
+
+	if seq == l.index.End {
+		return l.flush(ctx)
+	}
+	return nil
+}
+
+// loadIndex loads the index of the range containing ledgerSeq, which is
+// empty if the range was never indexed.
+func (l *ledgerIndexer) loadIndex(ctx context.Context, ledgerSeq uint32) error {
+	index, err := datastore.GetLedgerIndex(ctx, l.dataStore, l.schema, ledgerSeq)
+	if errors.Is(err, os.ErrNotExist) {
+		index = datastore.NewLedgerIndex(l.schema, ledgerSeq)
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to load ledger index for ledger %d", ledgerSeq)
+	}
+	l.index = index
+	return nil
+}
+
+// backfill indexes the ledgers from start to end from the ledger files in
+// the datastore, e.g. ledgers uploaded by a previous run which stopped
+// before writing the index of the range. Missing files are skipped until a
+// ledger is indexed, since the datastore may start within the range.
+func (l *ledgerIndexer) backfill(ctx context.Context, start, end uint32) error {
+	for start <= end {
+		objectKey := l.schema.GetObjectKeyFromSequenceNumber(start)
+		batch, err := l.readFile(ctx, objectKey)
+		if errors.Is(err, os.ErrNotExist) && l.index.LastLedger == 0 {
+			start = l.schema.GetSequenceNumberEndBoundary(start) + 1
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to backfill ledger index from %s", objectKey)
+		}
+		for _, lcm := range batch.LedgerCloseMetas {
+			seq := lcm.LedgerSequence()
+			if seq < start || seq > end {
+				continue
+			}
+			if err = l.index.AddLedger(lcm); err != nil {
+				return err
+			}
+		}
+		start = l.schema.GetSequenceNumberEndBoundary(start) + 1
+	}
+	return nil
+}
+
+func (l *ledgerIndexer) readFile(ctx context.Context, objectKey string) (xdr.LedgerCloseMetaBatch, error) {
+	var batch xdr.LedgerCloseMetaBatch
+	reader, err := l.dataStore.GetFile(ctx, objectKey)
+	if err != nil {
+		return batch, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return batch, errors.Wrapf(err, "failed reading file: %s", objectKey)
+	}
+	decoder := compressxdr.NewXDRDecoder(l.compressor, &batch)
+	if _, err = decoder.ReadFrom(bytes.NewReader(data)); err != nil {
+		return batch, errors.Wrap(err, "failed to decode ledger file")
+	}
+	return batch, nil
+}
+
+// flush writes the index of the range being exported, if any ledger of it was
+// indexed.
+func (l *ledgerIndexer) flush(ctx context.Context) error {
+	if l.index == nil || l.index.LastLedger == 0 {
+		return nil
+	}
+	if err := datastore.PutLedgerIndex(ctx, l.dataStore, l.schema, l.index); err != nil {
+		return errors.Wrapf(err, "failed to write ledger index %s",
+			datastore.LedgerIndexPath(l.schema, l.index.Start))
+	}
+	logger.Infof("Wrote ledger index of ledgers %d-%d", l.index.FirstLedger, l.index.LastLedger)
+	return nil
+}
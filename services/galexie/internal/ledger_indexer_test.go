@@ -0,0 +1,108 @@
+package galexie
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+func createTestBatch(t *testing.T, start, end uint32) xdr.LedgerCloseMetaBatch {
+	batch := xdr.LedgerCloseMetaBatch{StartSequence: xdr.Uint32(start), EndSequence: xdr.Uint32(end)}
+	for seq := start; seq <= end; seq++ {
+		require.NoError(t, batch.AddLedger(createChainedLedgerCloseMeta(seq)))
+	}
+	return batch
+}
+
+func requireLedgerIndexRange(t *testing.T, store datastore.DataStore, schema datastore.DataStoreSchema, seq, first, last uint32) {
+	index, err := datastore.GetLedgerIndex(context.Background(), store, schema, seq)
+	require.NoError(t, err)
+	require.Equal(t, first, index.FirstLedger)
+	require.Equal(t, last, index.LastLedger)
+}
+
+func TestLedgerIndexer(t *testing.T) {
+	ctx := context.Background()
+	store, err := datastore.NewDataStore(ctx, datastore.DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 4}
+
+	// ledgers 2 to 5 were exported without indexes
+	putTestBatch(t, store, schema, createTestBatch(t, 2, 3))
+	putTestBatch(t, store, schema, createTestBatch(t, 4, 5))
+
+	indexer, err := newLedgerIndexer(store, schema)
+	require.NoError(t, err)
+	batch := createTestBatch(t, 6, 7)
+	require.NoError(t, indexer.addBatch(ctx, &batch))
+	// the index is written once its last ledger is indexed
+	requireLedgerIndexRange(t, store, schema, 0, 2, 7)
+
+	batch = createTestBatch(t, 8, 9)
+	require.NoError(t, indexer.addBatch(ctx, &batch))
+	_, err = datastore.GetLedgerIndex(ctx, store, schema, 8)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, indexer.flush(ctx))
+	requireLedgerIndexRange(t, store, schema, 8, 8, 9)
+
+	// a later export replaces ledgers 8 and 9, then skips ledgers 10 and 11
+	// which were exported without indexes
+	putTestBatch(t, store, schema, createTestBatch(t, 10, 11))
+	indexer, err = newLedgerIndexer(store, schema)
+	require.NoError(t, err)
+	batch = createTestBatch(t, 8, 9)
+	require.NoError(t, indexer.addBatch(ctx, &batch))
+	batch = createTestBatch(t, 12, 13)
+	require.NoError(t, indexer.addBatch(ctx, &batch))
+	require.NoError(t, indexer.flush(ctx))
+	requireLedgerIndexRange(t, store, schema, 8, 8, 13)
+
+	// ledgers 14 and 15 are missing
+	indexer, err = newLedgerIndexer(store, schema)
+	require.NoError(t, err)
+	batch = createTestBatch(t, 16, 17)
+	require.NoError(t, indexer.addBatch(ctx, &batch))
+	batch = createTestBatch(t, 20, 21)
+	require.ErrorContains(t, indexer.addBatch(ctx, &batch), "failed to backfill ledger index from "+
+		schema.GetObjectKeyFromSequenceNumber(18))
+}
+
+func TestLedgerIndexerReexport(t *testing.T) {
+	ctx := context.Background()
+	store, err := datastore.NewDataStore(ctx, datastore.DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 8}
+	export := func(start, end uint32) {
+		indexer, err := newLedgerIndexer(store, schema)
+		require.NoError(t, err)
+		for seq := start; seq <= end; seq++ {
+			batch := createTestBatch(t, seq, seq)
+			putTestBatch(t, store, schema, batch)
+			require.NoError(t, indexer.addBatch(ctx, &batch))
+		}
+		require.NoError(t, indexer.flush(ctx))
+	}
+
+	export(3, 7)
+	requireLedgerIndexRange(t, store, schema, 0, 3, 7)
+
+	// re-exporting the middle of an index keeps its later ledgers
+	export(4, 5)
+	requireLedgerIndexRange(t, store, schema, 0, 3, 7)
+
+	// exporting a ledger preceding the first indexed ledger indexes the
+	// range again
+	export(2, 2)
+	requireLedgerIndexRange(t, store, schema, 0, 2, 7)
+}
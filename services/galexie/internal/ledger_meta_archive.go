@@ -8,36 +8,23 @@ import (
 
 // LedgerMetaArchive represents a file with metadata and binary data.
 type LedgerMetaArchive struct {
-	ObjectKey string
-	Data      xdr.LedgerCloseMetaBatch
-	metaData  datastore.MetaData
+	ObjectKey  string
+	Data       xdr.LedgerCloseMetaBatch
+	metaData   datastore.MetaData
+	compressor compressxdr.Compressor
 }
 
 // NewLedgerMetaArchiveFromXDR creates a new LedgerMetaArchive instance.
-func NewLedgerMetaArchiveFromXDR(networkPassPhrase string, coreVersion string, key string, data xdr.LedgerCloseMetaBatch) (*LedgerMetaArchive, error) {
-	startLedger, err := data.GetLedger(uint32(data.StartSequence))
-	if err != nil {
-		return &LedgerMetaArchive{}, err
-
-	}
-	endLedger, err := data.GetLedger(uint32(data.EndSequence))
+func NewLedgerMetaArchiveFromXDR(networkPassPhrase string, coreVersion string, key string, data xdr.LedgerCloseMetaBatch, compressor compressxdr.Compressor) (*LedgerMetaArchive, error) {
+	metaData, err := datastore.NewLedgerBatchMetaData(data, networkPassPhrase, compressor, coreVersion, version)
 	if err != nil {
 		return &LedgerMetaArchive{}, err
 	}
 
 	return &LedgerMetaArchive{
-		ObjectKey: key,
-		Data:      data,
-		metaData: datastore.MetaData{
-			StartLedger:          startLedger.LedgerSequence(),
-			EndLedger:            endLedger.LedgerSequence(),
-			StartLedgerCloseTime: startLedger.LedgerCloseTime(),
-			EndLedgerCloseTime:   endLedger.LedgerCloseTime(),
-			NetworkPassPhrase:    networkPassPhrase,
-			CompressionType:      compressxdr.DefaultCompressor.Name(),
-			ProtocolVersion:      endLedger.ProtocolVersion(),
-			CoreVersion:          coreVersion,
-			Version:              version,
-		},
+		ObjectKey:  key,
+		Data:       data,
+		compressor: compressor,
+		metaData:   metaData,
 	}, nil
 }
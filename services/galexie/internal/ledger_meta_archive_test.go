@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/stellar/go/support/compressxdr"
 	"github.com/stellar/go/support/datastore"
 	"github.com/stellar/go/xdr"
 )
@@ -18,7 +19,7 @@ func TestNewLedgerMetaArchiveFromXDR(t *testing.T) {
 		},
 	}
 
-	archive, err := NewLedgerMetaArchiveFromXDR("testnet", "v1.2.3", "key", data)
+	archive, err := NewLedgerMetaArchiveFromXDR("testnet", "v1.2.3", "key", data, compressxdr.DefaultCompressor)
 
 	require.NoError(t, err)
 	require.NotNil(t, archive)
@@ -34,6 +35,8 @@ func TestNewLedgerMetaArchiveFromXDR(t *testing.T) {
 		ProtocolVersion:      21,
 		CoreVersion:          "v1.2.3",
 		Version:              "develop",
+		StartLedgerHash:      xdr.Hash{}.HexString(),
+		EndLedgerHash:        xdr.Hash{}.HexString(),
 	}
 
 	require.Equal(t, expectedMetaData, archive.metaData)
@@ -42,14 +45,14 @@ func TestNewLedgerMetaArchiveFromXDR(t *testing.T) {
 		StartSequence: 1234,
 		EndSequence:   1237,
 		LedgerCloseMetas: []xdr.LedgerCloseMeta{
-			createLedgerCloseMeta(1234),
-			createLedgerCloseMeta(1235),
-			createLedgerCloseMeta(1236),
-			createLedgerCloseMeta(1237),
+			createChainedLedgerCloseMeta(1234),
+			createChainedLedgerCloseMeta(1235),
+			createChainedLedgerCloseMeta(1236),
+			createChainedLedgerCloseMeta(1237),
 		},
 	}
 
-	archive, err = NewLedgerMetaArchiveFromXDR("testnet", "v1.2.3", "key", data)
+	archive, err = NewLedgerMetaArchiveFromXDR("testnet", "v1.2.3", "key", data, compressxdr.DefaultCompressor)
 
 	require.NoError(t, err)
 	require.NotNil(t, archive)
@@ -65,6 +68,8 @@ func TestNewLedgerMetaArchiveFromXDR(t *testing.T) {
 		ProtocolVersion:      21,
 		CoreVersion:          "v1.2.3",
 		Version:              "develop",
+		StartLedgerHash:      testLedgerHash(1234).HexString(),
+		EndLedgerHash:        testLedgerHash(1237).HexString(),
 	}
 
 	require.Equal(t, expectedMetaData, archive.metaData)
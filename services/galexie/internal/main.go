@@ -109,10 +109,80 @@ func defineCommands() *cobra.Command {
 		},
 	}
 
+	var trainDictionaryCmd = &cobra.Command{
+		Use: "train-dictionary",
+		Short: "trains a zstd dictionary from the ledger files already exported to the data lake between 'start' and 'end' " +
+			"and publishes it alongside the datastore manifest. ledger files exported afterwards are compressed with the new dictionary.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings := bindTrainDictionaryCliParameters(
+				cmd.PersistentFlags().Lookup("start"),
+				cmd.PersistentFlags().Lookup("end"),
+				cmd.PersistentFlags().Lookup("max-size"),
+				cmd.PersistentFlags().Lookup("config-file"),
+			)
+			settings.Mode = TrainDictionary
+			settings.Ctx = cmd.Context()
+			if settings.Ctx == nil {
+				settings.Ctx = context.Background()
+			}
+			return galexieCmdRunner(settings)
+		},
+	}
+
+	var verifyCmd = &cobra.Command{
+		Use: "verify",
+		Short: "audits the ledger files exported to the data lake between 'start' and 'end' and writes a JSON report " +
+			"of missing, corrupt, misaligned or mismatched files. exits with an error if any issue is found.",
+		Long: "audits the ledger files exported to the data lake between 'start' and 'end'. every file is decoded and " +
+			"checked for alignment with the datastore schema, sequence continuity and the previous ledger hash chain. " +
+			"with 'check-archive', checkpoint ledger hashes are also cross-checked against the history archives. " +
+			"a JSON report of missing, corrupt, misaligned or mismatched files is written, and the command exits with an error if any issue is found.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings := bindVerifyCliParameters(
+				cmd.PersistentFlags().Lookup("start"),
+				cmd.PersistentFlags().Lookup("end"),
+				cmd.PersistentFlags().Lookup("check-archive"),
+				cmd.PersistentFlags().Lookup("report"),
+				cmd.PersistentFlags().Lookup("config-file"),
+			)
+			settings.Mode = Verify
+			settings.Ctx = cmd.Context()
+			if settings.Ctx == nil {
+				settings.Ctx = context.Background()
+			}
+			return galexieCmdRunner(settings)
+		},
+	}
+
+	var migrateCmd = &cobra.Command{
+		Use: "migrate",
+		Short: "re-exports ledgers from the source datastore into the data lake, which may use a different schema or compression. " +
+			"resumes from the first ledger missing in the data lake after 'start', does not require stellar-core.",
+		Long: "re-exports ledgers between 'start' and 'end' from the 'source_datastore_config' datastore into the 'datastore_config' " +
+			"data lake, which may use a different ledgers per file, files per partition or compression. like append, it resumes from " +
+			"the first ledger missing in the data lake after 'start'. if 'end' is absent or '0', ledgers are migrated up to the " +
+			"latest ledger in the source datastore. stellar-core is not required.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings := bindCliParameters(cmd.PersistentFlags().Lookup("start"),
+				cmd.PersistentFlags().Lookup("end"),
+				cmd.PersistentFlags().Lookup("config-file"),
+			)
+			settings.Mode = Migrate
+			settings.Ctx = cmd.Context()
+			if settings.Ctx == nil {
+				settings.Ctx = context.Background()
+			}
+			return galexieCmdRunner(settings)
+		},
+	}
+
 	rootCmd.AddCommand(scanAndFillCmd)
 	rootCmd.AddCommand(appendCmd)
 	rootCmd.AddCommand(ReplaceCmd)
 	rootCmd.AddCommand(loadTestCmd)
+	rootCmd.AddCommand(trainDictionaryCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(migrateCmd)
 
 	commonFlags := pflag.NewFlagSet("common_flags", pflag.ExitOnError)
 	commonFlags.Uint32P("start", "s", 0, "Starting ledger (inclusive), must be set to a value greater than 1")
@@ -140,6 +210,21 @@ func defineCommands() *cobra.Command {
 	loadTestCmd.PersistentFlags().String("config-file", "config.toml", "Path to the TOML config file. Defaults to 'config.toml' on runtime working directory path.")
 	viper.BindPFlags(loadTestCmd.PersistentFlags())
 
+	trainDictionaryCmd.PersistentFlags().AddFlagSet(commonFlags)
+	trainDictionaryCmd.PersistentFlags().Int("max-size", defaultDictionaryMaxSize, "maximum size in bytes of the trained dictionary.")
+	viper.BindPFlags(trainDictionaryCmd.PersistentFlags())
+
+	migrateCmd.PersistentFlags().Uint32P("start", "s", 0, "Starting ledger (inclusive), must be set to a value greater than 1")
+	migrateCmd.PersistentFlags().Uint32P("end", "e", 0, "Ending ledger (inclusive), optional, must be greater than 'start' and at most the "+
+		"latest ledger in the source datastore. If 'end' is absent or '0', ledgers are migrated up to the latest ledger in the source datastore.")
+	migrateCmd.PersistentFlags().String("config-file", "config.toml", "Path to the TOML config file. Defaults to 'config.toml' on runtime working directory path.")
+	viper.BindPFlags(migrateCmd.PersistentFlags())
+
+	verifyCmd.PersistentFlags().AddFlagSet(commonFlags)
+	verifyCmd.PersistentFlags().Bool("check-archive", false, "whether to cross-check the hashes of checkpoint ledgers against the history archives.")
+	verifyCmd.PersistentFlags().String("report", "", "path of the JSON report file. Defaults to writing the report to stdout.")
+	viper.BindPFlags(verifyCmd.PersistentFlags())
+
 	return rootCmd
 }
 
@@ -191,3 +276,27 @@ func bindLoadTestCliParameters(startFlag *pflag.Flag, endFlag *pflag.Flag, merge
 
 	return settings
 }
+
+func bindTrainDictionaryCliParameters(startFlag *pflag.Flag, endFlag *pflag.Flag, maxSizeFlag *pflag.Flag, configFileFlag *pflag.Flag) RuntimeSettings {
+	settings := bindCliParameters(startFlag, endFlag, configFileFlag)
+
+	viper.BindPFlag(maxSizeFlag.Name, maxSizeFlag)
+	viper.BindEnv(maxSizeFlag.Name, strutils.KebabToConstantCase(maxSizeFlag.Name))
+	settings.DictionaryMaxSize = viper.GetInt(maxSizeFlag.Name)
+
+	return settings
+}
+
+func bindVerifyCliParameters(startFlag *pflag.Flag, endFlag *pflag.Flag, checkArchiveFlag *pflag.Flag, reportFlag *pflag.Flag, configFileFlag *pflag.Flag) RuntimeSettings {
+	settings := bindCliParameters(startFlag, endFlag, configFileFlag)
+
+	viper.BindPFlag(checkArchiveFlag.Name, checkArchiveFlag)
+	viper.BindEnv(checkArchiveFlag.Name, strutils.KebabToConstantCase(checkArchiveFlag.Name))
+	settings.VerifyCheckArchive = viper.GetBool(checkArchiveFlag.Name)
+
+	viper.BindPFlag(reportFlag.Name, reportFlag)
+	viper.BindEnv(reportFlag.Name, strutils.KebabToConstantCase(reportFlag.Name))
+	settings.VerifyReportPath = viper.GetString(reportFlag.Name)
+
+	return settings
+}
@@ -132,6 +132,68 @@ func TestFlagsOutput(t *testing.T) {
 			expectedErrOutput: "test error",
 			appRunner:         appRunnerError,
 		},
+		{
+			name:              "train-dictionary sub-command with all parameters",
+			commandArgs:       []string{"train-dictionary", "--start", "4", "--end", "5", "--max-size", "2048", "--config-file", "myfile"},
+			expectedErrOutput: "",
+			appRunner:         appRunnerSuccess,
+			expectedSettings: RuntimeSettings{
+				StartLedger:       4,
+				EndLedger:         5,
+				ConfigFilePath:    "myfile",
+				Mode:              TrainDictionary,
+				Ctx:               ctx,
+				DictionaryMaxSize: 2048,
+			},
+		},
+		{
+			name:              "train-dictionary sub-command with defaults",
+			commandArgs:       []string{"train-dictionary", "--start", "4", "--end", "5", "--config-file", "myfile"},
+			expectedErrOutput: "",
+			appRunner:         appRunnerSuccess,
+			expectedSettings: RuntimeSettings{
+				StartLedger:       4,
+				EndLedger:         5,
+				ConfigFilePath:    "myfile",
+				Mode:              TrainDictionary,
+				Ctx:               ctx,
+				DictionaryMaxSize: defaultDictionaryMaxSize,
+			},
+		},
+		{
+			name:              "migrate sub-command with start and end absent",
+			commandArgs:       []string{"migrate", "--config-file", "myfile"},
+			expectedErrOutput: "",
+			appRunner:         appRunnerSuccess,
+			expectedSettings: RuntimeSettings{
+				StartLedger:    0,
+				EndLedger:      0,
+				ConfigFilePath: "myfile",
+				Mode:           Migrate,
+				Ctx:            ctx,
+			},
+		},
+		{
+			name:              "verify sub-command with all parameters",
+			commandArgs:       []string{"verify", "--start", "4", "--end", "5", "--check-archive", "--report", "report.json", "--config-file", "myfile"},
+			expectedErrOutput: "",
+			appRunner:         appRunnerSuccess,
+			expectedSettings: RuntimeSettings{
+				StartLedger:        4,
+				EndLedger:          5,
+				ConfigFilePath:     "myfile",
+				Mode:               Verify,
+				Ctx:                ctx,
+				VerifyCheckArchive: true,
+				VerifyReportPath:   "report.json",
+			},
+		},
+		{
+			name:              "verify sub-command prints app error",
+			commandArgs:       []string{"verify", "--start", "4", "--end", "5", "--config-file", "myfile"},
+			expectedErrOutput: "test error",
+			appRunner:         appRunnerError,
+		},
 		{
 			name:              "load-test sub-command with all parameters",
 			commandArgs:       []string{"load-test", "--start", "4", "--end", "5", "--merge", "--ledgers-path", "ledgers.xdr", "--close-duration", "3.5", "--config-file", "myfile"},
@@ -0,0 +1,154 @@
+package galexie
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+const migrateTestConfig = `
+[stellar_core_config]
+network = "pubnet"
+
+[datastore_config]
+type = "Filesystem"
+compression = "gzip"
+
+[datastore_config.params]
+destination_path = %q
+
+[datastore_config.schema]
+ledgers_per_file = 4
+files_per_partition = 2
+
+[source_datastore_config]
+type = "Filesystem"
+
+[source_datastore_config.params]
+destination_path = %q
+`
+
+// createMigrateSource creates a source datastore with one ledger per file
+// holding ledgers 2 to end.
+func createMigrateSource(t *testing.T, end uint32) string {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := datastore.FromFilesystemPath(dir)
+	require.NoError(t, err)
+	cfg := datastore.DataStoreConfig{
+		NetworkPassphrase: network.PublicNetworkPassphrase,
+		Compression:       "zstd",
+		Schema:            datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10},
+	}
+	_, _, err = datastore.PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+	for seq := uint32(2); seq <= end; seq++ {
+		putTestBatch(t, store, cfg.Schema, xdr.LedgerCloseMetaBatch{
+			StartSequence:    xdr.Uint32(seq),
+			EndSequence:      xdr.Uint32(seq),
+			LedgerCloseMetas: []xdr.LedgerCloseMeta{createChainedLedgerCloseMeta(seq)},
+		})
+	}
+	return dir
+}
+
+func runMigrate(t *testing.T, sourceDir, destinationDir string, start, end uint32) error {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath,
+		[]byte(fmt.Sprintf(migrateTestConfig, destinationDir, sourceDir)), 0o644))
+	return NewApp().Run(RuntimeSettings{
+		StartLedger:    start,
+		EndLedger:      end,
+		ConfigFilePath: configPath,
+		Mode:           Migrate,
+		Ctx:            context.Background(),
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	sourceDir := createMigrateSource(t, 21)
+	destinationDir := t.TempDir()
+
+	require.NoError(t, runMigrate(t, sourceDir, destinationDir, 2, 9))
+
+	destination, err := datastore.FromFilesystemPath(destinationDir)
+	require.NoError(t, err)
+	schema, err := datastore.LoadSchema(ctx, destination, datastore.DataStoreConfig{})
+	require.NoError(t, err)
+	require.Equal(t, datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: 2, FileExtension: "gz"}, schema)
+	latest, err := datastore.FindLatestLedgerSequence(ctx, destination)
+	require.NoError(t, err)
+	require.Equal(t, uint32(11), latest)
+
+	// resumes after ledger 11 and stops before the partial file 20-23
+	require.NoError(t, runMigrate(t, sourceDir, destinationDir, 2, 0))
+	latest, err = datastore.FindLatestLedgerSequence(ctx, destination)
+	require.NoError(t, err)
+	require.Equal(t, uint32(19), latest)
+
+	v, err := newVerifier(destination, schema, nil)
+	require.NoError(t, err)
+	report, err := v.verify(ctx, 2, 19)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint32(18), report.LedgersChecked)
+
+	// nothing left to migrate
+	require.NoError(t, runMigrate(t, sourceDir, destinationDir, 2, 19))
+
+	err = runMigrate(t, sourceDir, destinationDir, 2, 30)
+	require.ErrorContains(t, err, "end 30 exceeds latest ledger 21 in the source datastore")
+}
+
+func TestMigrateRequiresSource(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+[stellar_core_config]
+network = "pubnet"
+
+[datastore_config]
+type = "Filesystem"
+
+[datastore_config.params]
+destination_path = "`+t.TempDir()+`"
+`), 0o644))
+
+	err := NewApp().Run(RuntimeSettings{
+		StartLedger:    2,
+		ConfigFilePath: configPath,
+		Mode:           Migrate,
+		Ctx:            context.Background(),
+	})
+	require.ErrorContains(t, err, "Invalid source_datastore_config, 'type' must be set when ledger_backend is 'datastore'")
+}
+
+func TestValidateAndSetSourceDataStoreLedgerRange(t *testing.T) {
+	ctx := context.Background()
+	source, err := datastore.FromFilesystemPath(createMigrateSource(t, 21))
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 4, FilesPerPartition: 1}
+
+	// unbounded append follows the source datastore as it grows
+	config := &Config{StartLedger: 5, Mode: Append, DataStoreConfig: datastore.DataStoreConfig{Schema: schema}}
+	require.NoError(t, config.ValidateAndSetSourceDataStoreLedgerRange(ctx, source))
+	require.Equal(t, uint32(4), config.StartLedger)
+	require.Equal(t, uint32(0), config.EndLedger)
+
+	config = &Config{StartLedger: 5, Mode: ScanFill, DataStoreConfig: datastore.DataStoreConfig{Schema: schema}}
+	require.EqualError(t, config.ValidateAndSetSourceDataStoreLedgerRange(ctx, source),
+		"invalid end value, unbounded mode not supported, end must be greater than start.")
+
+	config = &Config{StartLedger: 5, EndLedger: 21, Mode: ScanFill, DataStoreConfig: datastore.DataStoreConfig{Schema: schema}}
+	require.NoError(t, config.ValidateAndSetSourceDataStoreLedgerRange(ctx, source))
+	require.Equal(t, uint32(4), config.StartLedger)
+	require.Equal(t, uint32(19), config.EndLedger)
+}
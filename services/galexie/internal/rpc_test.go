@@ -0,0 +1,122 @@
+package galexie
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+	"github.com/stellar/stellar-rpc/protocol"
+)
+
+const rpcTestConfig = `
+ledger_backend = "rpc"
+ledger_indexes = true
+
+[rpc_config]
+rpc_server_url = %q
+buffer_size = 3
+
+[stellar_core_config]
+network = "pubnet"
+history_archive_urls = [%q]
+
+[datastore_config]
+type = "Filesystem"
+
+[datastore_config.params]
+destination_path = %q
+
+[datastore_config.schema]
+ledgers_per_file = 4
+files_per_partition = 2
+`
+
+// newFakeRPCServer serves the getHealth and getLedgers JSON-RPC methods for
+// ledgers 2 to latest, and the root history archive state of the network.
+func newFakeRPCServer(t *testing.T, latest uint32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/.well-known/stellar-history.json" {
+			_, err := fmt.Fprintf(w, `{"version": 1, "server": "fake", "currentLedger": %d}`, latest)
+			require.NoError(t, err)
+			return
+		}
+
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		var result interface{}
+		switch request.Method {
+		case "getHealth":
+			result = protocol.GetHealthResponse{Status: "healthy", LatestLedger: latest, OldestLedger: 2}
+		case "getLedgers":
+			var params protocol.GetLedgersRequest
+			require.NoError(t, json.Unmarshal(request.Params, &params))
+			response := protocol.GetLedgersResponse{LatestLedger: latest, OldestLedger: 2}
+			for seq := params.StartLedger; seq <= latest && uint(len(response.Ledgers)) < params.Pagination.Limit; seq++ {
+				encoded, err := xdr.MarshalBase64(createChainedLedgerCloseMeta(seq))
+				require.NoError(t, err)
+				response.Ledgers = append(response.Ledgers, protocol.LedgerInfo{Sequence: seq, LedgerMetadata: encoded})
+			}
+			result = response
+		default:
+			t.Fatalf("unexpected rpc method %s", request.Method)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.ID,
+			"result":  result,
+		}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestExportFromRPC(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRPCServer(t, 20)
+	destinationDir := t.TempDir()
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(configPath,
+		[]byte(fmt.Sprintf(rpcTestConfig, server.URL, server.URL, destinationDir)), 0o644))
+
+	require.NoError(t, NewApp().Run(RuntimeSettings{
+		StartLedger:    2,
+		EndLedger:      9,
+		ConfigFilePath: configPath,
+		Mode:           Append,
+		Ctx:            ctx,
+	}))
+
+	destination, err := datastore.FromFilesystemPath(destinationDir)
+	require.NoError(t, err)
+	schema, err := datastore.LoadSchema(ctx, destination, datastore.DataStoreConfig{})
+	require.NoError(t, err)
+	latest, err := datastore.FindLatestLedgerSequence(ctx, destination)
+	require.NoError(t, err)
+	require.Equal(t, uint32(11), latest)
+
+	v, err := newVerifier(destination, schema, nil)
+	require.NoError(t, err)
+	report, err := v.verify(ctx, 2, 11)
+	require.NoError(t, err)
+	require.Empty(t, report.Issues)
+	require.Equal(t, uint32(10), report.LedgersChecked)
+
+	requireLedgerIndexRange(t, destination, schema, 2, 2, 7)
+	requireLedgerIndexRange(t, destination, schema, 8, 8, 11)
+}
@@ -3,7 +3,6 @@ package galexie
 import (
 	"context"
 	"fmt"
-	"io"
 	"strconv"
 	"time"
 
@@ -21,6 +20,8 @@ type Uploader struct {
 	objectSizeMetrics    *prometheus.SummaryVec
 	latestLedgerMetric   prometheus.Gauge
 	overwriteExisting    bool
+	// indexer, when set, maintains the ledger indexes of the uploaded files.
+	indexer *ledgerIndexer
 }
 
 // NewUploader constructs a new Uploader instance
@@ -61,65 +62,32 @@ func NewUploader(
 	}
 }
 
-type writerRecorder struct {
-	io.Writer
-	count *int64
-}
-
-func (r writerRecorder) Write(p []byte) (int, error) {
-	total, err := r.Writer.Write(p)
-	*r.count += int64(total)
-	return total, err
-}
-
-type writerToRecorder struct {
-	io.WriterTo
-	totalCompressed   int64
-	totalUncompressed int64
-}
-
-func (r *writerToRecorder) WriteTo(w io.Writer) (int64, error) {
-	uncompressedCount, err := r.WriterTo.WriteTo(writerRecorder{
-		Writer: w,
-		count:  &r.totalCompressed,
-	})
-	r.totalUncompressed += uncompressedCount
-	return uncompressedCount, err
-}
-
 // Upload uploads the serialized binary data of ledger TxMeta to the specified destination.
 func (u Uploader) Upload(ctx context.Context, metaArchive *LedgerMetaArchive) error {
 	logger.Infof("Uploading %s, overwrite=%t", metaArchive.ObjectKey, u.overwriteExisting)
 	startTime := time.Now()
 	numLedgers := strconv.FormatUint(uint64(len(metaArchive.Data.LedgerCloseMetas)), 10)
 
-	xdrEncoder := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, &metaArchive.Data)
-
-	writerTo := &writerToRecorder{
-		WriterTo: xdrEncoder,
+	compressor := metaArchive.compressor
+	if compressor == nil {
+		compressor = compressxdr.DefaultCompressor
+	}
+	upload, err := datastore.UploadLedgerBatch(
+		ctx, u.dataStore, metaArchive.ObjectKey, &metaArchive.Data, compressor, metaArchive.metaData, u.overwriteExisting,
+	)
+	if err != nil {
+		return err
 	}
 
-	var uploaded bool
-	var err error
-	var alreadyExists string
-	if u.overwriteExisting {
-		// Overwrite unconditionally.
-		if err = u.dataStore.PutFile(ctx, metaArchive.ObjectKey, writerTo, metaArchive.metaData.ToMap()); err != nil {
-			return fmt.Errorf("error uploading %s (overwrite): %w", metaArchive.ObjectKey, err)
-		}
+	if upload.Uploaded {
 		logger.Infof("Uploaded %s successfully", metaArchive.ObjectKey)
 	} else {
-		// Create only if it doesn't already exist.
-		uploaded, err = u.dataStore.PutFileIfNotExists(ctx, metaArchive.ObjectKey, writerTo, metaArchive.metaData.ToMap())
-		if err != nil {
-			return fmt.Errorf("error uploading %s: %w", metaArchive.ObjectKey, err)
-		}
-		if uploaded {
-			logger.Infof("Uploaded %s successfully", metaArchive.ObjectKey)
-		} else {
-			logger.Infof("Skipped %s (already exists)", metaArchive.ObjectKey)
-		}
-		alreadyExists = strconv.FormatBool(!uploaded)
+		logger.Infof("Skipped %s (already exists)", metaArchive.ObjectKey)
+	}
+	// files are overwritten unconditionally, whether they existed is unknown
+	var alreadyExists string
+	if !u.overwriteExisting {
+		alreadyExists = strconv.FormatBool(!upload.Uploaded)
 	}
 
 	u.uploadDurationMetric.With(prometheus.Labels{
@@ -130,13 +98,24 @@ func (u Uploader) Upload(ctx context.Context, metaArchive *LedgerMetaArchive) er
 		"compression":    "none",
 		"ledgers":        numLedgers,
 		"already_exists": alreadyExists,
-	}).Observe(float64(writerTo.totalUncompressed))
-	u.objectSizeMetrics.With(prometheus.Labels{
-		"compression":    xdrEncoder.Compressor.Name(),
-		"ledgers":        numLedgers,
-		"already_exists": alreadyExists,
-	}).Observe(float64(writerTo.totalCompressed))
+	}).Observe(float64(upload.UncompressedSize))
+	// The uncompressed size is already observed above when files are stored uncompressed.
+	if compressor.Name() != (compressxdr.NoneCompressor{}).Name() {
+		u.objectSizeMetrics.With(prometheus.Labels{
+			"compression":    compressor.Name(),
+			"ledgers":        numLedgers,
+			"already_exists": alreadyExists,
+		}).Observe(float64(upload.CompressedSize))
+	}
 	u.latestLedgerMetric.Set(float64(metaArchive.Data.EndSequence))
+
+	// Skipped files are indexed as well, the index of their partition may
+	// have not been written before the previous export stopped.
+	if u.indexer != nil {
+		if err = u.indexer.addBatch(ctx, &metaArchive.Data); err != nil {
+			return fmt.Errorf("error indexing %s: %w", metaArchive.ObjectKey, err)
+		}
+	}
 	return nil
 }
 
@@ -175,6 +154,9 @@ func (u Uploader) Run(ctx context.Context, shutdownDelayTime time.Duration) erro
 		}
 		if !ok {
 			logger.Info("Meta archive channel closed, stopping uploader")
+			if u.indexer != nil {
+				return u.indexer.flush(uploadCtx)
+			}
 			return nil
 		}
 
@@ -3,6 +3,8 @@ package galexie
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strconv"
@@ -41,6 +43,17 @@ func (s *UploaderSuite) TearDownTest() {
 	s.mockDataStore.AssertExpectations(s.T())
 }
 
+// contentMetaData returns the object metadata uploaded for data, which
+// includes the SHA-256 of the compressed file content.
+func (s *UploaderSuite) contentMetaData(metadata datastore.MetaData, data xdr.LedgerCloseMetaBatch) map[string]string {
+	var buf bytes.Buffer
+	_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, data).WriteTo(&buf)
+	s.Require().NoError(err)
+	sum := sha256.Sum256(buf.Bytes())
+	metadata.ContentSHA256 = hex.EncodeToString(sum[:])
+	return metadata.ToMap()
+}
+
 func (s *UploaderSuite) TestUploadWithMetadata() {
 	key, start, end := "test-1-100", uint32(1), uint32(100)
 	archive := NewLedgerMetaArchive(key, start, end)
@@ -60,7 +73,7 @@ func (s *UploaderSuite) TestUploadWithMetadata() {
 	}
 	archive.metaData = metadata
 	var capturedBuf bytes.Buffer
-	s.mockDataStore.On("PutFileIfNotExists", mock.Anything, key, mock.Anything, metadata.ToMap()).
+	s.mockDataStore.On("PutFileIfNotExists", mock.Anything, key, mock.Anything, s.contentMetaData(metadata, archive.Data)).
 		Run(func(args mock.Arguments) {
 			_ = args.Get(1).(string)
 			_, err := args.Get(2).(io.WriterTo).WriteTo(&capturedBuf)
@@ -99,7 +112,7 @@ func (s *UploaderSuite) TestUploadPaths() {
 
 			if c.overwrite {
 				s.mockDataStore.
-					On("PutFile", mock.Anything, key, mock.Anything, datastore.MetaData{}.ToMap()).
+					On("PutFile", mock.Anything, key, mock.Anything, s.contentMetaData(datastore.MetaData{}, archive.Data)).
 					Run(func(args mock.Arguments) {
 						capturedKey = args.Get(1).(string)
 						_, err := args.Get(2).(io.WriterTo).WriteTo(&capturedBuf)
@@ -107,7 +120,7 @@ func (s *UploaderSuite) TestUploadPaths() {
 					}).Return(nil).Once()
 			} else {
 				s.mockDataStore.
-					On("PutFileIfNotExists", mock.Anything, key, mock.Anything, datastore.MetaData{}.ToMap()).
+					On("PutFileIfNotExists", mock.Anything, key, mock.Anything, s.contentMetaData(datastore.MetaData{}, archive.Data)).
 					Run(func(args mock.Arguments) {
 						capturedKey = args.Get(1).(string)
 						_, err := args.Get(2).(io.WriterTo).WriteTo(&capturedBuf)
@@ -237,7 +250,7 @@ func (s *UploaderSuite) testUploadPutError(putOkReturnVal bool) {
 	archive := NewLedgerMetaArchive(key, start, end)
 
 	s.mockDataStore.On("PutFileIfNotExists", context.Background(), key,
-		mock.Anything, datastore.MetaData{}.ToMap()).Return(putOkReturnVal, errors.New("error in PutFileIfNotExists")).Once()
+		mock.Anything, s.contentMetaData(datastore.MetaData{}, archive.Data)).Return(putOkReturnVal, errors.New("error in PutFileIfNotExists")).Once()
 
 	registry := prometheus.NewRegistry()
 	queue := NewUploadQueue(1, registry)
@@ -281,7 +294,7 @@ func (s *UploaderSuite) TestUploadPutReplaceError() {
 	archive := NewLedgerMetaArchive(key, start, end)
 
 	s.mockDataStore.On("PutFile", context.Background(), key,
-		mock.Anything, datastore.MetaData{}.ToMap()).Return(errors.New("error in PutFile")).Once()
+		mock.Anything, s.contentMetaData(datastore.MetaData{}, archive.Data)).Return(errors.New("error in PutFile")).Once()
 
 	registry := prometheus.NewRegistry()
 	queue := NewUploadQueue(1, registry)
@@ -356,11 +369,11 @@ func (s *UploaderSuite) TestRunContextCancel() {
 	registry := prometheus.NewRegistry()
 	queue := NewUploadQueue(1, registry)
 
-	first := s.mockDataStore.On("PutFileIfNotExists", mock.Anything, "test", mock.Anything, datastore.MetaData{}.ToMap()).
+	first := s.mockDataStore.On("PutFileIfNotExists", mock.Anything, "test", mock.Anything, s.contentMetaData(datastore.MetaData{}, NewLedgerMetaArchive("test", 1, 1).Data)).
 		Return(true, nil).Once().Run(func(args mock.Arguments) {
 		cancel()
 	})
-	s.mockDataStore.On("PutFileIfNotExists", mock.Anything, "test1", mock.Anything, datastore.MetaData{}.ToMap()).
+	s.mockDataStore.On("PutFileIfNotExists", mock.Anything, "test1", mock.Anything, s.contentMetaData(datastore.MetaData{}, NewLedgerMetaArchive("test1", 2, 2).Data)).
 		Return(true, nil).Once().NotBefore(first).Run(func(args mock.Arguments) {
 		ctxArg := args.Get(0).(context.Context)
 		s.Require().NoError(ctxArg.Err())
@@ -0,0 +1,302 @@
+package galexie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+// Kinds of issues found by the verify command.
+const (
+	// VerifyMissing is reported for ledger files absent from the datastore.
+	VerifyMissing = "missing"
+	// VerifyCorrupt is reported for ledger files which cannot be decoded.
+	VerifyCorrupt = "corrupt"
+	// VerifyMisaligned is reported for ledger files whose ledger range does
+	// not match the file boundaries of the datastore schema.
+	VerifyMisaligned = "misaligned"
+	// VerifySequenceGap is reported for ledger files which do not contain
+	// every ledger of their range, in order.
+	VerifySequenceGap = "sequence_gap"
+	// VerifyHashMismatch is reported for ledgers whose previous ledger hash
+	// does not match the hash of the preceding ledger.
+	VerifyHashMismatch = "hash_mismatch"
+	// VerifyArchiveMismatch is reported for ledgers whose hash does not match
+	// the ledger header published in the history archive.
+	VerifyArchiveMismatch = "archive_mismatch"
+)
+
+// VerifyIssue describes a problem found with a ledger file.
+type VerifyIssue struct {
+	Kind      string `json:"kind"`
+	ObjectKey string `json:"object_key"`
+	Ledger    uint32 `json:"ledger,omitempty"`
+	Detail    string `json:"detail"`
+}
+
+// VerifyReport is the machine-readable outcome of the verify command.
+type VerifyReport struct {
+	StartLedger    uint32        `json:"start_ledger"`
+	EndLedger      uint32        `json:"end_ledger"`
+	FilesChecked   uint32        `json:"files_checked"`
+	LedgersChecked uint32        `json:"ledgers_checked"`
+	ArchiveChecked uint32        `json:"archive_checked"`
+	Issues         []VerifyIssue `json:"issues"`
+}
+
+// verifier audits the ledger files of a datastore.
+type verifier struct {
+	dataStore  datastore.DataStore
+	schema     datastore.DataStoreSchema
+	compressor compressxdr.Compressor
+	// archive is optional, when set the hashes of checkpoint ledgers are
+	// cross-checked against the ledger headers published in the archive.
+	archive historyarchive.ArchiveInterface
+
+	report VerifyReport
+	// previous is the last ledger verified, it is reset after a missing or
+	// corrupt file since the hash chain can't be verified across it.
+	previous *xdr.LedgerCloseMeta
+	// latestArchiveLedger is the latest ledger published in the archive,
+	// later checkpoints are not cross-checked.
+	latestArchiveLedger uint32
+}
+
+func newVerifier(dataStore datastore.DataStore, schema datastore.DataStoreSchema, archive historyarchive.ArchiveInterface) (*verifier, error) {
+	compressor, err := schema.GetCompressor()
+	if err != nil {
+		return nil, err
+	}
+	return &verifier{
+		dataStore:  dataStore,
+		schema:     schema,
+		compressor: compressor,
+		archive:    archive,
+	}, nil
+}
+
+// verify walks the ledger files containing the range start to end, which
+// must be at least 2, and reports every issue found. An error is only
+// returned if the verification could not be completed.
+//
+// Ledgers are verified to be chained by their previous ledger hash within and
+// across files, so the archive cross-check of checkpoint ledgers anchors every
+// ledger up to the last checkpoint of the range.
+func (v *verifier) verify(ctx context.Context, start, end uint32) (VerifyReport, error) {
+	v.report = VerifyReport{StartLedger: start, EndLedger: end, Issues: []VerifyIssue{}}
+	v.previous = nil
+
+	if v.archive != nil {
+		latest, err := v.archive.GetLatestLedgerSequence()
+		if err != nil {
+			return VerifyReport{}, errors.Wrap(err, "failed to retrieve the latest ledger sequence from history archives")
+		}
+		v.latestArchiveLedger = latest
+	}
+
+	// uint64 avoids overflowing at the end of the uint32 range
+	for fileStart := uint64(v.schema.GetSequenceNumberStartBoundary(start)); fileStart <= uint64(end); fileStart += uint64(v.schema.LedgersPerFile) {
+		if err := ctx.Err(); err != nil {
+			return VerifyReport{}, err
+		}
+		if err := v.verifyFile(ctx, uint32(fileStart), start, end); err != nil {
+			return VerifyReport{}, err
+		}
+	}
+	return v.report, nil
+}
+
+func (v *verifier) addIssue(kind, objectKey string, ledger uint32, format string, args ...interface{}) {
+	v.report.Issues = append(v.report.Issues, VerifyIssue{
+		Kind:      kind,
+		ObjectKey: objectKey,
+		Ledger:    ledger,
+		Detail:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *verifier) verifyFile(ctx context.Context, fileStart, start, end uint32) error {
+	objectKey := v.schema.GetObjectKeyFromSequenceNumber(fileStart)
+	v.report.FilesChecked++
+
+	batch, err := v.readFile(ctx, objectKey)
+	if errors.Is(err, os.ErrNotExist) {
+		v.addIssue(VerifyMissing, objectKey, 0, "ledger file is missing")
+		v.previous = nil
+		return nil
+	}
+	if err != nil {
+		var corrupt corruptFileError
+		if errors.As(err, &corrupt) {
+			v.addIssue(VerifyCorrupt, objectKey, 0, "%v", corrupt.err)
+			v.previous = nil
+			return nil
+		}
+		return err
+	}
+
+	// The first file of a network starts at ledger 2, not at its boundary.
+	expectedStart := max(fileStart, 2)
+	expectedEnd := v.schema.GetSequenceNumberEndBoundary(fileStart)
+	if uint32(batch.StartSequence) != expectedStart || uint32(batch.EndSequence) != expectedEnd {
+		v.addIssue(VerifyMisaligned, objectKey, 0, "ledger file contains ledgers %d-%d, expected %d-%d",
+			batch.StartSequence, batch.EndSequence, expectedStart, expectedEnd)
+	}
+
+	expectedCount := int64(batch.EndSequence) - int64(batch.StartSequence) + 1
+	if int64(len(batch.LedgerCloseMetas)) != expectedCount {
+		v.addIssue(VerifySequenceGap, objectKey, 0, "ledger file contains %d ledgers, expected %d",
+			len(batch.LedgerCloseMetas), max(expectedCount, 0))
+	}
+
+	for i := range batch.LedgerCloseMetas {
+		lcm := batch.LedgerCloseMetas[i]
+		seq := lcm.LedgerSequence()
+		if seq != uint32(batch.StartSequence)+uint32(i) {
+			v.addIssue(VerifySequenceGap, objectKey, seq, "ledger %d found at position %d of a file starting at %d",
+				seq, i, batch.StartSequence)
+		}
+		if seq < start || seq > end {
+			v.previous = &lcm
+			continue
+		}
+		v.report.LedgersChecked++
+
+		if v.previous != nil && v.previous.LedgerSequence()+1 == seq &&
+			lcm.PreviousLedgerHash() != v.previous.LedgerHash() {
+			v.addIssue(VerifyHashMismatch, objectKey, seq, "previous ledger hash %s does not match hash %s of ledger %d",
+				lcm.PreviousLedgerHash().HexString(), v.previous.LedgerHash().HexString(), v.previous.LedgerSequence())
+		}
+		if err := v.verifyAgainstArchive(objectKey, lcm); err != nil {
+			return err
+		}
+		v.previous = &lcm
+	}
+	return nil
+}
+
+func (v *verifier) verifyAgainstArchive(objectKey string, lcm xdr.LedgerCloseMeta) error {
+	seq := lcm.LedgerSequence()
+	if v.archive == nil || seq > v.latestArchiveLedger || !v.archive.GetCheckpointManager().IsCheckpoint(seq) {
+		return nil
+	}
+
+	header, err := v.archive.GetLedgerHeader(seq)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve ledger header %d from history archives", seq)
+	}
+	v.report.ArchiveChecked++
+	if header.Hash != lcm.LedgerHash() {
+		v.addIssue(VerifyArchiveMismatch, objectKey, seq, "ledger hash %s does not match hash %s in history archives",
+			lcm.LedgerHash().HexString(), header.Hash.HexString())
+	}
+	return nil
+}
+
+// corruptFileError wraps errors caused by the content of a ledger file, as
+// opposed to errors retrieving it.
+type corruptFileError struct {
+	err error
+}
+
+func (e corruptFileError) Error() string {
+	return e.err.Error()
+}
+
+func (v *verifier) readFile(ctx context.Context, objectKey string) (xdr.LedgerCloseMetaBatch, error) {
+	var batch xdr.LedgerCloseMetaBatch
+	reader, err := v.dataStore.GetFile(ctx, objectKey)
+	if err != nil {
+		return batch, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return batch, errors.Wrapf(err, "failed reading file: %s", objectKey)
+	}
+
+	decoder := compressxdr.NewXDRDecoder(v.compressor, &batch)
+	if _, err := decoder.ReadFrom(bytes.NewReader(data)); err != nil {
+		return batch, corruptFileError{err: errors.Wrap(err, "failed to decode ledger file")}
+	}
+	return batch, nil
+}
+
+// runVerify audits the ledger files of the requested range and writes the
+// report as JSON to the configured path, or stdout. It returns an error if
+// any issue was found.
+func (a *App) runVerify(ctx context.Context, runtimeSettings RuntimeSettings) error {
+	var err error
+	if a.config, err = NewConfig(runtimeSettings, nil); err != nil {
+		return errors.Wrap(err, "Could not load configuration")
+	}
+	if a.config.StartLedger < 2 {
+		return errors.New("invalid start value, must be greater than one.")
+	}
+	if a.config.EndLedger < a.config.StartLedger {
+		return errors.New("invalid end value, must be greater than or equal to start")
+	}
+
+	if a.dataStore, err = datastore.NewDataStore(ctx, a.config.DataStoreConfig); err != nil {
+		return fmt.Errorf("could not connect to destination data store %w", err)
+	}
+	defer func() {
+		if err := a.dataStore.Close(); err != nil {
+			logger.WithError(err).Error("Error closing datastore")
+		}
+	}()
+
+	schema, err := datastore.LoadSchema(ctx, a.dataStore, a.config.DataStoreConfig)
+	if err != nil {
+		return fmt.Errorf("could not load datastore schema %w", err)
+	}
+
+	var archive historyarchive.ArchiveInterface
+	if runtimeSettings.VerifyCheckArchive {
+		if archive, err = a.config.GenerateHistoryArchive(ctx, logger); err != nil {
+			return err
+		}
+	}
+
+	v, err := newVerifier(a.dataStore, schema, archive)
+	if err != nil {
+		return err
+	}
+	report, err := v.verify(ctx, a.config.StartLedger, a.config.EndLedger)
+	if err != nil {
+		return errors.Wrap(err, "verification failed")
+	}
+
+	out := io.Writer(os.Stdout)
+	if runtimeSettings.VerifyReportPath != "" {
+		f, err := os.Create(runtimeSettings.VerifyReportPath)
+		if err != nil {
+			return errors.Wrap(err, "could not create report file")
+		}
+		defer f.Close()
+		out = f
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return errors.Wrap(err, "could not write report")
+	}
+
+	logger.Infof("Verified %d ledgers in %d files between %d and %d, %d checked against history archives",
+		report.LedgersChecked, report.FilesChecked, report.StartLedger, report.EndLedger, report.ArchiveChecked)
+	if len(report.Issues) > 0 {
+		return errors.Errorf("verification found %d issues", len(report.Issues))
+	}
+	return nil
+}
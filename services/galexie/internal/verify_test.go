@@ -0,0 +1,139 @@
+package galexie
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+func testLedgerHash(seq uint32) xdr.Hash {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], seq)
+	return sha256.Sum256(b[:])
+}
+
+// createChainedLedgerCloseMeta creates a ledger whose previous ledger hash
+// matches the hash of the ledger created for seq-1.
+func createChainedLedgerCloseMeta(seq uint32) xdr.LedgerCloseMeta {
+	lcm := createLedgerCloseMeta(seq)
+	lcm.V0.LedgerHeader.Hash = testLedgerHash(seq)
+	lcm.V0.LedgerHeader.Header.PreviousLedgerHash = testLedgerHash(seq - 1)
+	return lcm
+}
+
+func putTestBatch(t *testing.T, store datastore.DataStore, schema datastore.DataStoreSchema, batch xdr.LedgerCloseMetaBatch) {
+	var buf bytes.Buffer
+	_, err := compressxdr.NewXDREncoder(compressxdr.DefaultCompressor, batch).WriteTo(&buf)
+	require.NoError(t, err)
+	key := schema.GetObjectKeyFromSequenceNumber(uint32(batch.StartSequence))
+	metadata := datastore.MetaData{StartLedger: uint32(batch.StartSequence), EndLedger: uint32(batch.EndSequence)}
+	require.NoError(t, store.PutFile(context.Background(), key, &buf, metadata.ToMap()))
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	store, err := datastore.NewDataStore(ctx, datastore.DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 1}
+
+	for start := uint32(2); start < 20; start += 2 {
+		batch := xdr.LedgerCloseMetaBatch{StartSequence: xdr.Uint32(start), EndSequence: xdr.Uint32(start + 1)}
+		for seq := start; seq <= start+1; seq++ {
+			require.NoError(t, batch.AddLedger(createChainedLedgerCloseMeta(seq)))
+		}
+		switch start {
+		case 8:
+			// missing
+			continue
+		case 10:
+			key := schema.GetObjectKeyFromSequenceNumber(start)
+			require.NoError(t, store.PutFile(ctx, key, bytes.NewReader([]byte("garbage")), nil))
+			continue
+		case 12:
+			batch.LedgerCloseMetas[1].V0.LedgerHeader.Header.PreviousLedgerHash = xdr.Hash{1}
+		case 16:
+			batch.EndSequence = 18
+		case 18:
+			batch.LedgerCloseMetas = batch.LedgerCloseMetas[:1]
+		}
+		putTestBatch(t, store, schema, batch)
+	}
+
+	v, err := newVerifier(store, schema, nil)
+	require.NoError(t, err)
+	report, err := v.verify(ctx, 3, 19)
+	require.NoError(t, err)
+	// the decoding error details depend on the codec
+	require.Len(t, report.Issues, 6)
+	require.Contains(t, report.Issues[1].Detail, "failed to decode ledger file: ")
+	report.Issues[1].Detail = ""
+	require.Equal(t, VerifyReport{
+		StartLedger:    3,
+		EndLedger:      19,
+		FilesChecked:   9,
+		LedgersChecked: 12,
+		Issues: []VerifyIssue{
+			{Kind: VerifyMissing, ObjectKey: schema.GetObjectKeyFromSequenceNumber(8), Detail: "ledger file is missing"},
+			{Kind: VerifyCorrupt, ObjectKey: schema.GetObjectKeyFromSequenceNumber(10)},
+			{Kind: VerifyHashMismatch, ObjectKey: schema.GetObjectKeyFromSequenceNumber(12), Ledger: 13,
+				Detail: "previous ledger hash " + xdr.Hash{1}.HexString() + " does not match hash " +
+					testLedgerHash(12).HexString() + " of ledger 12"},
+			{Kind: VerifyMisaligned, ObjectKey: schema.GetObjectKeyFromSequenceNumber(16),
+				Detail: "ledger file contains ledgers 16-18, expected 16-17"},
+			{Kind: VerifySequenceGap, ObjectKey: schema.GetObjectKeyFromSequenceNumber(16),
+				Detail: "ledger file contains 2 ledgers, expected 3"},
+			{Kind: VerifySequenceGap, ObjectKey: schema.GetObjectKeyFromSequenceNumber(18),
+				Detail: "ledger file contains 1 ledgers, expected 2"},
+		},
+	}, report)
+}
+
+func TestVerifyAgainstArchive(t *testing.T) {
+	ctx := context.Background()
+	store, err := datastore.NewDataStore(ctx, datastore.DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	schema := datastore.DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 1}
+	for seq := uint32(2); seq <= 20; seq++ {
+		putTestBatch(t, store, schema, xdr.LedgerCloseMetaBatch{
+			StartSequence:    xdr.Uint32(seq),
+			EndSequence:      xdr.Uint32(seq),
+			LedgerCloseMetas: []xdr.LedgerCloseMeta{createChainedLedgerCloseMeta(seq)},
+		})
+	}
+
+	mockArchive := &historyarchive.MockArchive{}
+	mockArchive.On("GetLatestLedgerSequence").Return(uint32(16), nil).Once()
+	mockArchive.On("GetCheckpointManager").Return(historyarchive.NewCheckpointManager(8))
+	mockArchive.On("GetLedgerHeader", uint32(7)).Return(xdr.LedgerHeaderHistoryEntry{Hash: testLedgerHash(7)}, nil).Once()
+	mockArchive.On("GetLedgerHeader", uint32(15)).Return(xdr.LedgerHeaderHistoryEntry{Hash: xdr.Hash{2}}, nil).Once()
+	t.Cleanup(func() {
+		mockArchive.AssertExpectations(t)
+	})
+
+	v, err := newVerifier(store, schema, mockArchive)
+	require.NoError(t, err)
+	// only checkpoints 7 and 15 fall within the range and are published
+	report, err := v.verify(ctx, 2, 20)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), report.ArchiveChecked)
+	require.Equal(t, []VerifyIssue{
+		{Kind: VerifyArchiveMismatch, ObjectKey: schema.GetObjectKeyFromSequenceNumber(15), Ledger: 15,
+			Detail: "ledger hash " + testLedgerHash(15).HexString() + " does not match hash " +
+				xdr.Hash{2}.HexString() + " in history archives"},
+	}, report.Issues)
+}
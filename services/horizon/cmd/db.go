@@ -414,8 +414,9 @@ func DefineDBCommands(rootCmd *cobra.Command, horizonConfig *horizon.Config, hor
 
 			reaper := ingest.NewReaper(
 				ingest.ReapConfig{
-					RetentionCount: uint32(horizonConfig.HistoryRetentionCount),
-					BatchSize:      uint32(horizonConfig.HistoryRetentionReapCount),
+					RetentionCount:               uint32(horizonConfig.HistoryRetentionCount),
+					BatchSize:                    uint32(horizonConfig.HistoryRetentionReapCount),
+					BalanceHistoryRetentionCount: uint32(horizonConfig.BalanceHistoryRetentionCount),
 				},
 				session,
 			)
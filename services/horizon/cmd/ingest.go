@@ -406,9 +406,10 @@ func DefineIngestCommands(rootCmd *cobra.Command, horizonConfig *horizon.Config,
 				RoundingSlippageFilter:               horizonConfig.RoundingSlippageFilter,
 				SkipTxmeta:                           horizonConfig.SkipTxmeta,
 				ReapConfig: ingest.ReapConfig{
-					Frequency:      horizonConfig.ReapFrequency,
-					RetentionCount: uint32(horizonConfig.HistoryRetentionCount),
-					BatchSize:      uint32(horizonConfig.HistoryRetentionReapCount),
+					Frequency:                    horizonConfig.ReapFrequency,
+					RetentionCount:               uint32(horizonConfig.HistoryRetentionCount),
+					BatchSize:                    uint32(horizonConfig.HistoryRetentionReapCount),
+					BalanceHistoryRetentionCount: uint32(horizonConfig.BalanceHistoryRetentionCount),
 				},
 				LedgerBackendType:    ledgerBackendType,
 				StorageBackendConfig: storageBackendConfig,
@@ -0,0 +1,137 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/resourceadapter"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/support/time"
+	"github.com/stellar/go/xdr"
+)
+
+// BalanceHistoryQuery query struct for balance history end-points
+type BalanceHistoryQuery struct {
+	AccountID   string      `schema:"account_id" valid:"accountID,optional"`
+	ContractID  string      `schema:"contract_id" valid:"contractID,optional"`
+	Asset       string      `schema:"asset" valid:"asset"`
+	StartLedger uint32      `schema:"start_ledger" valid:"-"`
+	EndLedger   uint32      `schema:"end_ledger" valid:"-"`
+	StartTime   time.Millis `schema:"start_time" valid:"-"`
+	EndTime     time.Millis `schema:"end_time" valid:"-"`
+}
+
+// Validate runs custom validations on the asset and on the ledger and time
+// ranges.
+func (q BalanceHistoryQuery) Validate() error {
+	if q.Asset == "" {
+		return problem.MakeInvalidFieldProblem(
+			"asset",
+			errors.New("Missing required field"),
+		)
+	}
+	if q.EndLedger > 0 && q.EndLedger <= q.StartLedger {
+		return problem.MakeInvalidFieldProblem(
+			"end_ledger",
+			errors.New("end_ledger must be greater than start_ledger"),
+		)
+	}
+	if !q.EndTime.IsNil() && q.EndTime.ToInt64() <= q.StartTime.ToInt64() {
+		return problem.MakeInvalidFieldProblem(
+			"end_time",
+			errors.New("end_time must be greater than start_time"),
+		)
+	}
+	return nil
+}
+
+// asset returns the queried asset and the id of its stellar asset contract.
+func (q BalanceHistoryQuery) asset(networkPassphrase string) (xdr.Asset, string, error) {
+	assets, err := xdr.BuildAssets(q.Asset)
+	if err != nil {
+		return xdr.Asset{}, "", err
+	}
+	rawID, err := assets[0].ContractID(networkPassphrase)
+	if err != nil {
+		return xdr.Asset{}, "", err
+	}
+	contractID, err := strkey.Encode(strkey.VersionByteContract, rawID[:])
+	if err != nil {
+		return xdr.Asset{}, "", err
+	}
+	return assets[0], contractID, nil
+}
+
+// GetBalanceHistoryHandler is the action handler for the end-points returning
+// the balance history of an account or a contract.
+type GetBalanceHistoryHandler struct {
+	LedgerState       *ledger.State
+	NetworkPassphrase string
+}
+
+// GetResourcePage returns a page of balance snapshots.
+func (handler GetBalanceHistoryHandler) GetResourcePage(w HeaderWriter, r *http.Request) ([]hal.Pageable, error) {
+	ctx := r.Context()
+
+	pq, err := GetPageQuery(handler.LedgerState, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAndAdjustCursor(handler.LedgerState, &pq)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := BalanceHistoryQuery{}
+	if err = getParams(&qp, r); err != nil {
+		return nil, err
+	}
+	asset, contractID, err := qp.asset(handler.NetworkPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute asset contract id")
+	}
+
+	query := history.BalanceHistoryQuery{
+		Address:     qp.AccountID,
+		ContractID:  contractID,
+		StartLedger: qp.StartLedger,
+		EndLedger:   qp.EndLedger,
+	}
+	if qp.ContractID != "" {
+		query.Address = qp.ContractID
+	}
+	if !qp.StartTime.IsNil() {
+		query.StartTime = qp.StartTime.ToTime()
+	}
+	if !qp.EndTime.IsNil() {
+		query.EndTime = qp.EndTime.ToTime()
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := historyQ.BalanceHistory(ctx, query, pq)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading balance history records")
+	}
+
+	var response []hal.Pageable
+	for _, record := range records {
+		var res horizon.BalanceSnapshot
+		if err = resourceadapter.PopulateBalanceSnapshot(ctx, &res, asset, record); err != nil {
+			return nil, err
+		}
+		response = append(response, res)
+	}
+
+	return response, nil
+}
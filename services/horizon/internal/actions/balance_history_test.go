@@ -0,0 +1,151 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+func TestBalanceHistoryQuery_InvalidParams(t *testing.T) {
+	for _, testCase := range []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{
+			query:  "",
+			field:  "asset",
+			reason: "Missing required field",
+		},
+		{
+			query:  "asset=USD",
+			field:  "asset",
+			reason: customTagsErrorMessages["asset"],
+		},
+		{
+			query:  "asset=native&account_id=CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+			field:  "account_id",
+			reason: "Account ID must start with `G` and contain 56 alphanum characters",
+		},
+		{
+			query:  "asset=native&start_ledger=10&end_ledger=10",
+			field:  "end_ledger",
+			reason: "end_ledger must be greater than start_ledger",
+		},
+		{
+			query:  "asset=native&start_time=2000&end_time=1000",
+			field:  "end_time",
+			reason: "end_time must be greater than start_time",
+		},
+	} {
+		called := false
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			qp := BalanceHistoryQuery{}
+			err := getParams(&qp, r)
+			p, ok := err.(*problem.P)
+			if assert.True(t, ok, testCase.query) {
+				assert.Equal(t, 400, p.Status)
+				assert.Equal(t, testCase.field, p.Extras["invalid_field"])
+				assert.Equal(t, testCase.reason, p.Extras["reason"])
+			}
+			called = true
+		}))
+
+		_, err := http.Get(s.URL + "/?" + testCase.query)
+		assert.NoError(t, err)
+		assert.True(t, called)
+		s.Close()
+	}
+}
+
+func TestGetBalanceHistoryHandler(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+
+	q := &history.Q{tt.HorizonSession()}
+	handler := GetBalanceHistoryHandler{
+		LedgerState:       &ledger.State{},
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+	handler.LedgerState.SetHorizonStatus(ledger.HorizonStatus{
+		HistoryLatest:    60,
+		HistoryElder:     1,
+		ExpHistoryLatest: 60,
+	})
+
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(network.TestNetworkPassphrase)
+	tt.Require.NoError(err)
+	xlmContract := strkey.MustEncode(strkey.VersionByteContract, xlmContractID[:])
+	account := "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	builder := q.NewBalanceSnapshotBatchInsertBuilder()
+	for i, balance := range []string{"100", "250", "0"} {
+		tt.Assert.NoError(builder.Add(history.BalanceSnapshot{
+			Address:         account,
+			ContractID:      xlmContract,
+			LedgerSequence:  uint32(10 * (i + 1)),
+			LedgerCloseTime: start.Add(time.Duration(i) * time.Minute),
+			Balance:         balance,
+		}))
+	}
+	tt.Assert.NoError(builder.Add(history.BalanceSnapshot{
+		Address:         account,
+		ContractID:      "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		LedgerSequence:  10,
+		LedgerCloseTime: start,
+		Balance:         "1",
+	}))
+	tt.Assert.NoError(q.Begin(tt.Ctx))
+	tt.Assert.NoError(builder.Exec(tt.Ctx, q))
+	tt.Assert.NoError(q.Commit())
+
+	balances := func(queryParams map[string]string) []string {
+		records, err := handler.GetResourcePage(
+			httptest.NewRecorder(),
+			makeRequest(t, queryParams, map[string]string{"account_id": account}, q),
+		)
+		tt.Assert.NoError(err)
+		var result []string
+		for _, record := range records {
+			snapshot := record.(horizon.BalanceSnapshot)
+			tt.Assert.Equal(xlmContract, snapshot.ContractID)
+			tt.Assert.Equal("native", snapshot.Type)
+			result = append(result, snapshot.Balance)
+		}
+		return result
+	}
+
+	tt.Assert.Equal(
+		[]string{"0.0000100", "0.0000250", "0.0000000"},
+		balances(map[string]string{"asset": "native"}),
+	)
+	tt.Assert.Equal(
+		[]string{"0.0000250"},
+		balances(map[string]string{"asset": "native", "start_ledger": "11", "end_ledger": "30"}),
+	)
+	// the balance at a point in time is the last snapshot before it
+	tt.Assert.Equal(
+		[]string{"0.0000250"},
+		balances(map[string]string{
+			"asset":    "native",
+			"end_time": strconv.FormatInt(start.Add(90*time.Second).UnixMilli(), 10),
+			"order":    "desc",
+			"limit":    "1",
+		}),
+	)
+}
@@ -0,0 +1,139 @@
+package actions
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/resourceadapter"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+// ContractEventTopicWildcard is the topic segment matching any topic.
+const ContractEventTopicWildcard = "*"
+
+// ContractEventsQuery query struct for contract events end-points
+type ContractEventsQuery struct {
+	ContractID string `schema:"contract_id" valid:"contractID,optional"`
+	Type       string `schema:"type" valid:"contractEventType,optional"`
+	// Topics is a comma separated list of up to four topic segments, each
+	// one either a base64 encoded ScVal XDR or the * wildcard. Events are
+	// matched if their first topics match the segments.
+	Topics string `schema:"topics" valid:"-"`
+}
+
+// Validate runs custom validations on the topics
+func (q ContractEventsQuery) Validate() error {
+	_, err := q.topics()
+	return err
+}
+
+// topics returns the topic segments of the query, the wildcard being
+// returned as an empty string.
+func (q ContractEventsQuery) topics() ([]string, error) {
+	if q.Topics == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(q.Topics, ",")
+	if len(segments) > history.MaxContractEventTopics {
+		return nil, problem.MakeInvalidFieldProblem(
+			"topics",
+			errors.Errorf("at most %d topic segments are allowed", history.MaxContractEventTopics),
+		)
+	}
+
+	topics := make([]string, len(segments))
+	for i, segment := range segments {
+		if segment == ContractEventTopicWildcard {
+			continue
+		}
+		// '+' is decoded as a space when it is not escaped in the query
+		segment = strings.ReplaceAll(segment, " ", "+")
+		var topic xdr.ScVal
+		if err := xdr.SafeUnmarshalBase64(segment, &topic); err != nil {
+			return nil, problem.MakeInvalidFieldProblem(
+				"topics",
+				errors.Errorf("topic segment %d must be %s or a base64 encoded ScVal XDR", i+1, ContractEventTopicWildcard),
+			)
+		}
+		// the topics are stored in their canonical encoding
+		encoded, err := xdr.MarshalBase64(topic)
+		if err != nil {
+			return nil, problem.MakeInvalidFieldProblem("topics", err)
+		}
+		topics[i] = encoded
+	}
+	return topics, nil
+}
+
+// eventType returns the xdr type of the events queried, nil if all the types
+// are queried.
+func (q ContractEventsQuery) eventType() *xdr.ContractEventType {
+	for eventType, name := range history.ContractEventTypeNames {
+		if q.Type == name {
+			eventType := eventType
+			return &eventType
+		}
+	}
+	return nil
+}
+
+// GetContractEventsHandler is the action handler for all end-points returning
+// a list of contract events.
+type GetContractEventsHandler struct {
+	LedgerState *ledger.State
+}
+
+// GetResourcePage returns a page of contract events.
+func (handler GetContractEventsHandler) GetResourcePage(w HeaderWriter, r *http.Request) ([]hal.Pageable, error) {
+	ctx := r.Context()
+
+	pq, err := GetPageQuery(handler.LedgerState, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAndAdjustCursor(handler.LedgerState, &pq)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := ContractEventsQuery{}
+	if err = getParams(&qp, r); err != nil {
+		return nil, err
+	}
+	topics, err := qp.topics()
+	if err != nil {
+		return nil, err
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := historyQ.ContractEvents(ctx, history.ContractEventsQuery{
+		ContractID: qp.ContractID,
+		Type:       qp.eventType(),
+		Topics:     topics,
+	}, pq, handler.LedgerState.CurrentStatus().HistoryElder)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading contract event records")
+	}
+
+	var response []hal.Pageable
+	for _, record := range records {
+		var res horizon.ContractEvent
+		resourceadapter.PopulateContractEvent(ctx, &res, record)
+		response = append(response, res)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,195 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func TestContractEventsQuery_Topics(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		topics   string
+		expected []string
+		reason   string
+	}{
+		{
+			name: "no topics",
+		},
+		{
+			name:     "wildcards",
+			topics:   "AAAADwAAAAh0cmFuc2Zlcg==,*,AAAAAQ==",
+			expected: []string{"AAAADwAAAAh0cmFuc2Zlcg==", "", "AAAAAQ=="},
+		},
+		{
+			name:     "unescaped plus",
+			topics:   "AAAABQAAAAAAAAH/,AAAABQAAAAAAAAH+",
+			expected: []string{"AAAABQAAAAAAAAH/", "AAAABQAAAAAAAAH+"},
+		},
+		{
+			name:   "too many topics",
+			topics: "*,*,*,*,*",
+			reason: "at most 4 topic segments are allowed",
+		},
+		{
+			name:   "invalid topic",
+			topics: "*,foobar",
+			reason: "topic segment 2 must be * or a base64 encoded ScVal XDR",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			called := false
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				qp := ContractEventsQuery{}
+				err := getParams(&qp, r)
+				called = true
+				if testCase.reason == "" {
+					assert.NoError(t, err)
+					topics, err := qp.topics()
+					assert.NoError(t, err)
+					assert.Equal(t, testCase.expected, topics)
+					return
+				}
+				p, ok := err.(*problem.P)
+				if assert.True(t, ok) {
+					assert.Equal(t, 400, p.Status)
+					assert.Equal(t, "topics", p.Extras["invalid_field"])
+					assert.Equal(t, testCase.reason, p.Extras["reason"])
+				}
+			}))
+			defer s.Close()
+
+			_, err := http.Get(s.URL + "/?topics=" + testCase.topics)
+			assert.NoError(t, err)
+			assert.True(t, called)
+		})
+	}
+}
+
+func TestContractEventsQuery_InvalidParams(t *testing.T) {
+	for _, testCase := range []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{
+			query:  "contract_id=GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+			field:  "contract_id",
+			reason: "Contract ID must start with `C` and contain 56 alphanum characters",
+		},
+		{
+			query:  "type=diagnostic",
+			field:  "type",
+			reason: "Contract event type must be contract or system",
+		},
+	} {
+		called := false
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			qp := ContractEventsQuery{}
+			err := getParams(&qp, r)
+			p, ok := err.(*problem.P)
+			if assert.True(t, ok) {
+				assert.Equal(t, 400, p.Status)
+				assert.Equal(t, testCase.field, p.Extras["invalid_field"])
+				assert.Equal(t, testCase.reason, p.Extras["reason"])
+			}
+			called = true
+		}))
+
+		_, err := http.Get(s.URL + "/?" + testCase.query)
+		assert.NoError(t, err)
+		assert.True(t, called)
+		s.Close()
+	}
+}
+
+func TestGetContractEventsHandler(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+
+	q := &history.Q{tt.HorizonSession()}
+	handler := GetContractEventsHandler{
+		LedgerState: &ledger.State{},
+	}
+	handler.LedgerState.SetHorizonStatus(ledger.HorizonStatus{
+		HistoryLatest:    56,
+		HistoryElder:     56,
+		ExpHistoryLatest: 56,
+	})
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	txHash := "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+	opID := toid.New(56, 1, 1).ToInt64()
+	builder := q.NewContractEventBatchInsertBuilder()
+	tt.Assert.NoError(builder.Add(history.ContractEvent{
+		HistoryOperationID: opID,
+		Order:              1,
+		TransactionHash:    txHash,
+		ContractID:         null.StringFrom(contractID),
+		Type:               int32(xdr.ContractEventTypeContract),
+		Topic1:             null.StringFrom("AAAADwAAAAh0cmFuc2Zlcg=="),
+		Topic2:             null.StringFrom("AAAAAQ=="),
+		Value:              "AAAAAwAAAAc=",
+	}))
+	tt.Assert.NoError(builder.Add(history.ContractEvent{
+		HistoryOperationID: opID,
+		Order:              2,
+		TransactionHash:    txHash,
+		Type:               int32(xdr.ContractEventTypeSystem),
+		Value:              "AAAAAwAAAAc=",
+	}))
+	tt.Assert.NoError(q.Begin(tt.Ctx))
+	tt.Assert.NoError(builder.Exec(tt.Ctx, q))
+	tt.Assert.NoError(q.Commit())
+
+	records, err := handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{}, map[string]string{}, q),
+	)
+	tt.Assert.NoError(err)
+	tt.Assert.Len(records, 2)
+
+	records, err = handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(
+			t,
+			map[string]string{"topics": "AAAADwAAAAh0cmFuc2Zlcg==,*"},
+			map[string]string{"contract_id": contractID},
+			q,
+		),
+	)
+	tt.Assert.NoError(err)
+	if tt.Assert.Len(records, 1) {
+		event := records[0].(horizon.ContractEvent)
+		tt.Assert.Equal(contractID, event.ContractID)
+		tt.Assert.Equal("contract", event.Type)
+		tt.Assert.Equal(int32(56), event.Ledger)
+		tt.Assert.Equal(txHash, event.TransactionHash)
+		tt.Assert.Equal([]string{"AAAADwAAAAh0cmFuc2Zlcg==", "AAAAAQ=="}, event.Topics)
+		tt.Assert.Equal("AAAAAwAAAAc=", event.Value)
+	}
+
+	records, err = handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{"type": "system"}, map[string]string{}, q),
+	)
+	tt.Assert.NoError(err)
+	if tt.Assert.Len(records, 1) {
+		event := records[0].(horizon.ContractEvent)
+		tt.Assert.Empty(event.ContractID)
+		tt.Assert.Equal("system", event.Type)
+		tt.Assert.Empty(event.Topics)
+	}
+}
@@ -0,0 +1,204 @@
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/resourceadapter"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+// ContractQuery query struct for contracts/{contract_id} end-point
+type ContractQuery struct {
+	ContractID string `schema:"contract_id" valid:"contractID"`
+}
+
+// instanceKeyHash returns the hex encoded key hash of the contract data entry
+// storing the instance of the contract.
+func (q ContractQuery) instanceKeyHash() (string, error) {
+	var contractID xdr.ContractId
+	rawID, err := strkey.Decode(strkey.VersionByteContract, q.ContractID)
+	if err != nil {
+		return "", err
+	}
+	copy(contractID[:], rawID)
+
+	var key xdr.LedgerKey
+	err = key.SetContractData(
+		xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: &contractID},
+		xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+		xdr.ContractDataDurabilityPersistent,
+	)
+	if err != nil {
+		return "", err
+	}
+	bin, err := key.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	keyHash := sha256.Sum256(bin)
+	return hex.EncodeToString(keyHash[:]), nil
+}
+
+// GetContractByIDHandler is the action handler for the end-point returning a
+// contract instance.
+type GetContractByIDHandler struct{}
+
+// GetResource returns a contract instance.
+func (handler GetContractByIDHandler) GetResource(w HeaderWriter, r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	qp := ContractQuery{}
+	if err := getParams(&qp, r); err != nil {
+		return nil, err
+	}
+	keyHash, err := qp.instanceKeyHash()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute contract instance key")
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	row, err := historyQ.GetContractDataByKeyHash(ctx, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var resource horizon.Contract
+	if err = resourceadapter.PopulateContract(ctx, &resource, row); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// ContractDataQuery query struct for contracts/{contract_id}/data end-point
+type ContractDataQuery struct {
+	ContractID string `schema:"contract_id" valid:"contractID"`
+	Durability string `schema:"durability" valid:"contractDataDurability,optional"`
+	// KeyPrefix is a base64 encoded ScVal XDR. If it is a vector, the contract
+	// data whose key is a vector starting with its elements are returned.
+	KeyPrefix string `schema:"key_prefix" valid:"-"`
+}
+
+// Validate runs custom validations on the key prefix
+func (q ContractDataQuery) Validate() error {
+	_, err := q.keyPrefix()
+	return err
+}
+
+func (q ContractDataQuery) keyPrefix() (*xdr.ScVal, error) {
+	if q.KeyPrefix == "" {
+		return nil, nil
+	}
+	var prefix xdr.ScVal
+	// '+' is decoded as a space when it is not escaped in the query
+	encoded := strings.ReplaceAll(q.KeyPrefix, " ", "+")
+	if err := xdr.SafeUnmarshalBase64(encoded, &prefix); err != nil {
+		return nil, problem.MakeInvalidFieldProblem(
+			"key_prefix",
+			errors.New("key_prefix must be a base64 encoded ScVal XDR"),
+		)
+	}
+	return &prefix, nil
+}
+
+func (q ContractDataQuery) durability() *xdr.ContractDataDurability {
+	for durability, name := range history.ContractDataDurabilityNames {
+		if q.Durability == name {
+			durability := durability
+			return &durability
+		}
+	}
+	return nil
+}
+
+// GetContractDataHandler is the action handler for the end-point returning the
+// contract data of a contract.
+type GetContractDataHandler struct {
+	LedgerState *ledger.State
+}
+
+// GetResourcePage returns a page of contract data.
+func (handler GetContractDataHandler) GetResourcePage(w HeaderWriter, r *http.Request) ([]hal.Pageable, error) {
+	ctx := r.Context()
+
+	pq, err := GetPageQuery(handler.LedgerState, r, DisableCursorValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := ContractDataQuery{}
+	if err = getParams(&qp, r); err != nil {
+		return nil, err
+	}
+	keyPrefix, err := qp.keyPrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := historyQ.ContractDataByContractID(ctx, qp.ContractID, history.ContractDataQuery{
+		Durability: qp.durability(),
+		KeyPrefix:  keyPrefix,
+	}, pq)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading contract data records")
+	}
+
+	var response []hal.Pageable
+	for _, record := range records {
+		var res horizon.ContractData
+		if err = resourceadapter.PopulateContractData(ctx, &res, record); err != nil {
+			return nil, err
+		}
+		response = append(response, res)
+	}
+
+	return response, nil
+}
+
+// ContractCodeQuery query struct for contract_code/{hash} end-point
+type ContractCodeQuery struct {
+	Hash string `schema:"hash" valid:"sha256"`
+}
+
+// GetContractCodeByHashHandler is the action handler for the end-point
+// returning a contract code.
+type GetContractCodeByHashHandler struct{}
+
+// GetResource returns a contract code.
+func (handler GetContractCodeByHashHandler) GetResource(w HeaderWriter, r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	qp := ContractCodeQuery{}
+	if err := getParams(&qp, r); err != nil {
+		return nil, err
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	row, err := historyQ.GetContractCodeByHash(ctx, strings.ToLower(qp.Hash))
+	if err != nil {
+		return nil, err
+	}
+
+	var resource horizon.ContractCode
+	resourceadapter.PopulateContractCode(ctx, &resource, row)
+	return resource, nil
+}
@@ -0,0 +1,179 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+func TestContractDataQuery_KeyPrefix(t *testing.T) {
+	called := false
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qp := ContractDataQuery{}
+		assert.NoError(t, getParams(&qp, r))
+		prefix, err := qp.keyPrefix()
+		assert.NoError(t, err)
+		if assert.NotNil(t, prefix) {
+			assert.Equal(t, xdr.ScValTypeScvU64, prefix.Type)
+			assert.Equal(t, xdr.Uint64(510), *prefix.U64)
+		}
+		called = true
+	}))
+	defer s.Close()
+
+	// the unescaped '+' must not be decoded as a space
+	_, err := http.Get(s.URL + "/?key_prefix=AAAABQAAAAAAAAH+")
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestContractDataQuery_InvalidParams(t *testing.T) {
+	for _, testCase := range []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{
+			query:  "contract_id=GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+			field:  "contract_id",
+			reason: "Contract ID must start with `C` and contain 56 alphanum characters",
+		},
+		{
+			query:  "durability=instance",
+			field:  "durability",
+			reason: "Contract data durability must be persistent or temporary",
+		},
+		{
+			query:  "key_prefix=foobar",
+			field:  "key_prefix",
+			reason: "key_prefix must be a base64 encoded ScVal XDR",
+		},
+	} {
+		called := false
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			qp := ContractDataQuery{}
+			err := getParams(&qp, r)
+			p, ok := err.(*problem.P)
+			if assert.True(t, ok) {
+				assert.Equal(t, 400, p.Status)
+				assert.Equal(t, testCase.field, p.Extras["invalid_field"])
+				assert.Equal(t, testCase.reason, p.Extras["reason"])
+			}
+			called = true
+		}))
+
+		_, err := http.Get(s.URL + "/?" + testCase.query)
+		assert.NoError(t, err)
+		assert.True(t, called)
+		s.Close()
+	}
+}
+
+func TestContractStateHandlers(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &history.Q{tt.HorizonSession()}
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	instanceKeyHash, err := ContractQuery{ContractID: contractID}.instanceKeyHash()
+	tt.Require.NoError(err)
+
+	wasmHash := xdr.Hash{2}
+	instance, err := xdr.MarshalBase64(xdr.ScVal{
+		Type: xdr.ScValTypeScvContractInstance,
+		Instance: &xdr.ScContractInstance{
+			Executable: xdr.ContractExecutable{
+				Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+				WasmHash: &wasmHash,
+			},
+		},
+	})
+	tt.Require.NoError(err)
+	instanceKey, err := xdr.MarshalBase64(xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance})
+	tt.Require.NoError(err)
+
+	tt.Require.NoError(q.Begin(tt.Ctx))
+	tt.Require.NoError(q.UpsertContractData(tt.Ctx, []history.ContractData{
+		{
+			KeyHash:            instanceKeyHash,
+			ContractID:         contractID,
+			Durability:         int32(xdr.ContractDataDurabilityPersistent),
+			Key:                instanceKey,
+			Value:              instance,
+			LastModifiedLedger: 10,
+		},
+		{
+			KeyHash:            "0100000000000000000000000000000000000000000000000000000000000000",
+			ContractID:         contractID,
+			Durability:         int32(xdr.ContractDataDurabilityTemporary),
+			Key:                "AAAADwAAAAdjb3VudGVyAA==",
+			Value:              "AAAAAwAAAAc=",
+			LastModifiedLedger: 11,
+		},
+	}))
+	tt.Require.NoError(q.UpsertContractCode(tt.Ctx, []history.ContractCode{{
+		Hash:               wasmHash.HexString(),
+		KeyHash:            "0300000000000000000000000000000000000000000000000000000000000000",
+		Code:               "AGFzbQ==",
+		LastModifiedLedger: 9,
+	}}))
+	tt.Require.NoError(q.Commit())
+
+	resource, err := GetContractByIDHandler{}.GetResource(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{}, map[string]string{"contract_id": contractID}, q),
+	)
+	tt.Require.NoError(err)
+	contract := resource.(horizon.Contract)
+	tt.Assert.Equal(contractID, contract.ID)
+	tt.Assert.Equal("wasm", contract.ExecutableType)
+	tt.Assert.Equal(wasmHash.HexString(), contract.WasmHash)
+
+	_, err = GetContractByIDHandler{}.GetResource(
+		httptest.NewRecorder(),
+		makeRequest(
+			t,
+			map[string]string{},
+			map[string]string{"contract_id": "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO"},
+			q,
+		),
+	)
+	tt.Assert.True(q.NoRows(err))
+
+	dataHandler := GetContractDataHandler{LedgerState: &ledger.State{}}
+	records, err := dataHandler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(
+			t,
+			map[string]string{"durability": "temporary"},
+			map[string]string{"contract_id": contractID},
+			q,
+		),
+	)
+	tt.Require.NoError(err)
+	if tt.Assert.Len(records, 1) {
+		data := records[0].(horizon.ContractData)
+		tt.Assert.Equal("temporary", data.Durability)
+		tt.Assert.JSONEq(`"counter"`, string(data.Key.JSON))
+		tt.Assert.JSONEq(`7`, string(data.Value.JSON))
+	}
+
+	resource, err = GetContractCodeByHashHandler{}.GetResource(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{}, map[string]string{"hash": wasmHash.HexString()}, q),
+	)
+	tt.Require.NoError(err)
+	code := resource.(horizon.ContractCode)
+	tt.Assert.Equal(wasmHash.HexString(), code.Hash)
+	tt.Assert.Equal("AGFzbQ==", code.Code)
+}
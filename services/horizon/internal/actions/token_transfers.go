@@ -0,0 +1,125 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/resourceadapter"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/xdr"
+)
+
+// TokenTransfersQuery query struct for token transfers end-points
+type TokenTransfersQuery struct {
+	AccountID  string `schema:"account_id" valid:"accountID,optional"`
+	ContractID string `schema:"contract_id" valid:"contractID,optional"`
+	Asset      string `schema:"asset" valid:"asset,optional"`
+	Type       string `schema:"type" valid:"tokenTransferType,optional"`
+}
+
+// contractIDs returns the ids of the token contracts filtered by the query:
+// the contract_id parameter and the stellar asset contract of the asset
+// parameter.
+func (q TokenTransfersQuery) contractIDs(networkPassphrase string) ([]string, error) {
+	var contractIDs []string
+	if q.ContractID != "" {
+		contractIDs = append(contractIDs, q.ContractID)
+	}
+	if q.Asset != "" {
+		assets, err := xdr.BuildAssets(q.Asset)
+		if err != nil {
+			return nil, err
+		}
+		rawID, err := assets[0].ContractID(networkPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		contractID, err := strkey.Encode(strkey.VersionByteContract, rawID[:])
+		if err != nil {
+			return nil, err
+		}
+		contractIDs = append(contractIDs, contractID)
+	}
+	return contractIDs, nil
+}
+
+// transferType returns the type of the transfers queried, nil if all the
+// types are queried.
+func (q TokenTransfersQuery) transferType() *int32 {
+	for transferType, name := range history.TokenTransferTypeNames {
+		if q.Type == name {
+			transferType := transferType
+			return &transferType
+		}
+	}
+	return nil
+}
+
+// GetTokenTransfersHandler is the action handler for all end-points returning
+// a list of token transfers.
+type GetTokenTransfersHandler struct {
+	LedgerState       *ledger.State
+	NetworkPassphrase string
+}
+
+// GetResourcePage returns a page of token transfers.
+func (handler GetTokenTransfersHandler) GetResourcePage(w HeaderWriter, r *http.Request) ([]hal.Pageable, error) {
+	ctx := r.Context()
+
+	pq, err := GetPageQuery(handler.LedgerState, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateAndAdjustCursor(handler.LedgerState, &pq)
+	if err != nil {
+		return nil, err
+	}
+
+	qp := TokenTransfersQuery{}
+	if err = getParams(&qp, r); err != nil {
+		return nil, err
+	}
+	contractIDs, err := qp.contractIDs(handler.NetworkPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not compute asset contract id")
+	}
+
+	query := history.TokenTransfersQuery{
+		Address: qp.AccountID,
+		Type:    qp.transferType(),
+	}
+	if len(contractIDs) > 0 {
+		query.ContractID = contractIDs[0]
+	}
+	// the transfers of a contract never match an asset of another contract
+	if len(contractIDs) == 2 && contractIDs[0] != contractIDs[1] {
+		return []hal.Pageable{}, nil
+	}
+
+	historyQ, err := horizonContext.HistoryQFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := historyQ.TokenTransfers(ctx, query, pq, handler.LedgerState.CurrentStatus().HistoryElder)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading token transfer records")
+	}
+
+	var response []hal.Pageable
+	for _, record := range records {
+		var res horizon.TokenTransfer
+		if err = resourceadapter.PopulateTokenTransfer(ctx, &res, record); err != nil {
+			return nil, err
+		}
+		response = append(response, res)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,188 @@
+package actions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func TestTokenTransfersQuery_ContractIDs(t *testing.T) {
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	xlmContract := strkey.MustEncode(strkey.VersionByteContract, xlmContractID[:])
+
+	contractIDs, err := TokenTransfersQuery{}.contractIDs(network.TestNetworkPassphrase)
+	assert.NoError(t, err)
+	assert.Empty(t, contractIDs)
+
+	contractIDs, err = TokenTransfersQuery{Asset: "native"}.contractIDs(network.TestNetworkPassphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{xlmContract}, contractIDs)
+
+	contractIDs, err = TokenTransfersQuery{
+		ContractID: "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Asset:      "native",
+	}.contractIDs(network.TestNetworkPassphrase)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", xlmContract}, contractIDs)
+}
+
+func TestTokenTransfersQuery_InvalidParams(t *testing.T) {
+	for _, testCase := range []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{
+			query:  "account_id=CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+			field:  "account_id",
+			reason: "Account ID must start with `G` and contain 56 alphanum characters",
+		},
+		{
+			query:  "contract_id=GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY",
+			field:  "contract_id",
+			reason: "Contract ID must start with `C` and contain 56 alphanum characters",
+		},
+		{
+			query:  "asset=USD",
+			field:  "asset",
+			reason: customTagsErrorMessages["asset"],
+		},
+		{
+			query:  "type=payment",
+			field:  "type",
+			reason: "Token transfer type must be transfer, mint, burn, clawback or fee",
+		},
+	} {
+		called := false
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			qp := TokenTransfersQuery{}
+			err := getParams(&qp, r)
+			p, ok := err.(*problem.P)
+			if assert.True(t, ok) {
+				assert.Equal(t, 400, p.Status)
+				assert.Equal(t, testCase.field, p.Extras["invalid_field"])
+				assert.Equal(t, testCase.reason, p.Extras["reason"])
+			}
+			called = true
+		}))
+
+		_, err := http.Get(s.URL + "/?" + testCase.query)
+		assert.NoError(t, err)
+		assert.True(t, called)
+		s.Close()
+	}
+}
+
+func TestGetTokenTransfersHandler(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+
+	q := &history.Q{tt.HorizonSession()}
+	handler := GetTokenTransfersHandler{
+		LedgerState:       &ledger.State{},
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+	handler.LedgerState.SetHorizonStatus(ledger.HorizonStatus{
+		HistoryLatest:    56,
+		HistoryElder:     56,
+		ExpHistoryLatest: 56,
+	})
+
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(network.TestNetworkPassphrase)
+	tt.Require.NoError(err)
+	xlmContract := strkey.MustEncode(strkey.VersionByteContract, xlmContractID[:])
+	source := "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"
+	destination := "GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON"
+	txHash := "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+	closeTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	builder := q.NewTokenTransferBatchInsertBuilder()
+	tt.Assert.NoError(builder.Add(history.TokenTransfer{
+		HistoryOperationID: toid.New(56, 1, 0).ToInt64(),
+		Order:              1,
+		TransactionHash:    txHash,
+		LedgerCloseTime:    closeTime,
+		Type:               history.TokenTransferTypeFee,
+		ContractID:         xlmContract,
+		Asset:              null.StringFrom("native"),
+		FromAddress:        null.StringFrom(source),
+		Amount:             "100",
+	}))
+	tt.Assert.NoError(builder.Add(history.TokenTransfer{
+		HistoryOperationID: toid.New(56, 1, 1).ToInt64(),
+		Order:              1,
+		TransactionHash:    txHash,
+		LedgerCloseTime:    closeTime,
+		Type:               history.TokenTransferTypeTransfer,
+		ContractID:         "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		FromAddress:        null.StringFrom(source),
+		ToAddress:          null.StringFrom(destination),
+		Amount:             "170141183460469231731687303715884105727",
+	}))
+	tt.Assert.NoError(q.Begin(tt.Ctx))
+	tt.Assert.NoError(builder.Exec(tt.Ctx, q))
+	tt.Assert.NoError(q.Commit())
+
+	records, err := handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{}, map[string]string{}, q),
+	)
+	tt.Assert.NoError(err)
+	tt.Assert.Len(records, 2)
+
+	records, err = handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{}, map[string]string{"account_id": destination}, q),
+	)
+	tt.Assert.NoError(err)
+	if tt.Assert.Len(records, 1) {
+		transfer := records[0].(horizon.TokenTransfer)
+		tt.Assert.Equal("transfer", transfer.Type)
+		tt.Assert.Equal(source, transfer.From)
+		tt.Assert.Equal(destination, transfer.To)
+		tt.Assert.Empty(transfer.AssetType)
+		tt.Assert.Equal("170141183460469231731687303715884105727", transfer.Amount)
+	}
+
+	records, err = handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(t, map[string]string{"asset": "native"}, map[string]string{}, q),
+	)
+	tt.Assert.NoError(err)
+	if tt.Assert.Len(records, 1) {
+		transfer := records[0].(horizon.TokenTransfer)
+		tt.Assert.Equal("fee", transfer.Type)
+		tt.Assert.Equal("native", transfer.AssetType)
+		tt.Assert.Equal(xlmContract, transfer.ContractID)
+		tt.Assert.Nil(transfer.Links.Operation)
+	}
+
+	records, err = handler.GetResourcePage(
+		httptest.NewRecorder(),
+		makeRequest(
+			t,
+			map[string]string{"asset": "native"},
+			map[string]string{"contract_id": "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"},
+			q,
+		),
+	)
+	tt.Assert.NoError(err)
+	tt.Assert.Empty(records)
+}
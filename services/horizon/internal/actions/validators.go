@@ -10,6 +10,7 @@ import (
 
 	"github.com/stellar/go/amount"
 	"github.com/stellar/go/services/horizon/internal/assets"
+	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/errors"
 	"github.com/stellar/go/xdr"
 )
@@ -25,23 +26,62 @@ func init() {
 	govalidator.TagMap["assetType"] = isAssetType
 	govalidator.TagMap["asset"] = isAsset
 	govalidator.TagMap["claimableBalanceID"] = isClaimableBalanceID
+	govalidator.TagMap["contractID"] = isContractID
+	govalidator.TagMap["contractEventType"] = isContractEventType
+	govalidator.TagMap["contractDataDurability"] = isContractDataDurability
 	govalidator.TagMap["transactionHash"] = isTransactionHash
 	govalidator.TagMap["sha256"] = govalidator.IsSHA256
+	govalidator.TagMap["tokenTransferType"] = isTokenTransferType
 	govalidator.TagMap["tradeType"] = isTradeType
 }
 
 var customTagsErrorMessages = map[string]string{
-	"accountID":            "Account ID must start with `G` and contain 56 alphanum characters",
-	"amount":               "Amount must be positive",
-	"asset":                "Asset must be the string \"native\" or a string of the form \"Code:IssuerAccountID\" for issued assets.",
-	"assetType":            "Asset type must be native, credit_alphanum4 or credit_alphanum12",
-	"bool":                 "Filter should be true or false",
-	"claimable_balance_id": "Claimable Balance ID must be the hex-encoded XDR representation of a Claimable Balance ID",
-	"ledger_id":            "Ledger ID must be an integer higher than 0",
-	"offer_id":             "Offer ID must be an integer higher than 0",
-	"op_id":                "Operation ID must be an integer higher than 0",
-	"transactionHash":      "Transaction hash must be a hex-encoded, lowercase SHA-256 hash",
-	"tradeType":            "Trade type must be all, orderbook, or liquidity_pool",
+	"accountID":              "Account ID must start with `G` and contain 56 alphanum characters",
+	"amount":                 "Amount must be positive",
+	"asset":                  "Asset must be the string \"native\" or a string of the form \"Code:IssuerAccountID\" for issued assets.",
+	"assetType":              "Asset type must be native, credit_alphanum4 or credit_alphanum12",
+	"bool":                   "Filter should be true or false",
+	"claimable_balance_id":   "Claimable Balance ID must be the hex-encoded XDR representation of a Claimable Balance ID",
+	"contractID":             "Contract ID must start with `C` and contain 56 alphanum characters",
+	"contractEventType":      "Contract event type must be contract or system",
+	"contractDataDurability": "Contract data durability must be persistent or temporary",
+	"ledger_id":              "Ledger ID must be an integer higher than 0",
+	"offer_id":               "Offer ID must be an integer higher than 0",
+	"op_id":                  "Operation ID must be an integer higher than 0",
+	"tokenTransferType":      "Token transfer type must be transfer, mint, burn, clawback or fee",
+	"transactionHash":        "Transaction hash must be a hex-encoded, lowercase SHA-256 hash",
+	"tradeType":              "Trade type must be all, orderbook, or liquidity_pool",
+}
+
+func isContractID(str string) bool {
+	return strkey.IsValidContractAddress(str)
+}
+
+func isContractEventType(eventType string) bool {
+	for _, name := range history.ContractEventTypeNames {
+		if eventType == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isTokenTransferType(transferType string) bool {
+	for _, name := range history.TokenTransferTypeNames {
+		if transferType == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isContractDataDurability(durability string) bool {
+	for _, name := range history.ContractDataDurabilityNames {
+		if durability == name {
+			return true
+		}
+	}
+	return false
 }
 
 func isTradeType(tradeType string) bool {
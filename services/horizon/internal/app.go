@@ -529,6 +529,8 @@ func (a *App) init() error {
 		FriendbotURL:            a.config.FriendbotURL,
 		DisableTxSub:            a.config.DisableTxSub,
 		StellarCoreURL:          a.config.StellarCoreURL,
+		EnableGraphQL:           a.config.EnableGraphQL,
+		GraphQLMaxQueryCost:     a.config.GraphQLMaxQueryCost,
 		HealthCheck: healthCheck{
 			session: a.historyQ.SessionInterface,
 			ctx:     a.ctx,
@@ -79,6 +79,10 @@ type Config struct {
 	// especially if enabling reaping for the first time or in times of
 	// increased ledger load.
 	HistoryRetentionReapCount uint
+	// BalanceHistoryRetentionCount represents the minimum number of ledgers
+	// worth of balance history to retain in the horizon database, it allows
+	// retaining less balance history than the rest of the history.
+	BalanceHistoryRetentionCount uint
 	// ReapFrequency configures how often (in units of ledgers) history is reaped.
 	// If ReapFrequency is set to 1 history is reaped after ingesting every ledger.
 	// If ReapFrequency is set to 2 history is reaped after ingesting every two ledgers.
@@ -125,6 +129,11 @@ type Config struct {
 	Network string
 	// DisableTxSub disables transaction submission functionality for Horizon.
 	DisableTxSub bool
+	// EnableGraphQL enables the `/graphql` endpoint.
+	EnableGraphQL bool
+	// GraphQLMaxQueryCost is the maximum cost of the queries sent to the
+	// `/graphql` endpoint, the number of database queries they can run.
+	GraphQLMaxQueryCost uint
 	// SkipTxmeta, when enabled, will not store meta xdr in history transaction table
 	SkipTxmeta bool
 	// EmitVerboseMeta, when enabled will include all kinds of events in txMeta - diagnosticEvents/classicEvents
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/toid"
+)
+
+// BalanceSnapshot is a row of data from the `history_balances` table. It is
+// the balance of an asset held by an account or a contract at the end of a
+// ledger in which the balance changed.
+type BalanceSnapshot struct {
+	Address string `db:"address"`
+	// ContractID is the id of the stellar asset contract of the asset.
+	ContractID      string    `db:"contract_id"`
+	LedgerSequence  uint32    `db:"ledger_sequence"`
+	LedgerCloseTime time.Time `db:"ledger_closed_at"`
+	// Balance is the balance in raw units of the asset.
+	Balance string `db:"balance"`
+}
+
+// ID returns a unique id for this balance snapshot
+func (r *BalanceSnapshot) ID() string {
+	return fmt.Sprintf("%s-%s-%d", r.Address, r.ContractID, r.LedgerSequence)
+}
+
+// PagingToken returns a cursor for this balance snapshot, the id of its
+// ledger.
+func (r *BalanceSnapshot) PagingToken() string {
+	return toid.New(int32(r.LedgerSequence), 0, 0).String()
+}
+
+// BalanceHistoryQuery is the filter of the balance snapshots returned by
+// BalanceHistory.
+type BalanceHistoryQuery struct {
+	Address    string
+	ContractID string
+	// StartLedger is the first ledger of the range, the range is unbounded
+	// if 0.
+	StartLedger uint32
+	// EndLedger is the ledger following the range, the range is unbounded
+	// if 0.
+	EndLedger uint32
+	// StartTime is the first close time of the range, the range is unbounded
+	// if zero.
+	StartTime time.Time
+	// EndTime is the close time following the range, the range is unbounded
+	// if zero.
+	EndTime time.Time
+}
+
+// BalanceHistory returns a page of the balance snapshots of an address for an
+// asset contract.
+func (q *Q) BalanceHistory(ctx context.Context, query BalanceHistoryQuery, page db2.PageQuery) ([]BalanceSnapshot, error) {
+	cursor, err := page.CursorInt64()
+	if err != nil {
+		return nil, err
+	}
+	cursorLedger := toid.Parse(cursor).LedgerSequence
+
+	sql := selectBalanceSnapshot.
+		Where("hb.address = ?", query.Address).
+		Where("hb.contract_id = ?", query.ContractID)
+	if query.StartLedger > 0 {
+		sql = sql.Where("hb.ledger_sequence >= ?", query.StartLedger)
+	}
+	if query.EndLedger > 0 {
+		sql = sql.Where("hb.ledger_sequence < ?", query.EndLedger)
+	}
+	if !query.StartTime.IsZero() {
+		sql = sql.Where("hb.ledger_closed_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		sql = sql.Where("hb.ledger_closed_at < ?", query.EndTime)
+	}
+
+	switch page.Order {
+	case "asc":
+		sql = sql.
+			Where("hb.ledger_sequence > ?", cursorLedger).
+			OrderBy("hb.ledger_sequence asc")
+	case "desc":
+		sql = sql.
+			Where("hb.ledger_sequence < ?", cursorLedger).
+			OrderBy("hb.ledger_sequence desc")
+	}
+
+	sql = sql.Limit(page.Limit)
+
+	var rows []BalanceSnapshot
+	if err = q.Select(ctx, &rows, sql); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// DeleteBalanceHistoryRange removes the balance snapshots of the ledgers in
+// [start, end) and returns the number of removed rows.
+func (q *Q) DeleteBalanceHistoryRange(ctx context.Context, start, end uint32) (int64, error) {
+	sql := sq.Delete("history_balances").
+		Where("ledger_sequence >= ? AND ledger_sequence < ?", start, end)
+	result, err := q.Exec(ctx, sql)
+	if err != nil {
+		return 0, errors.Wrap(err, "error deleting balance history")
+	}
+	return result.RowsAffected()
+}
+
+// GetNextBalanceHistoryLedger returns the first ledger following start with
+// balance snapshots, and false if there is none.
+func (q *Q) GetNextBalanceHistoryLedger(ctx context.Context, start uint32) (uint32, bool, error) {
+	var value uint32
+	err := q.GetRaw(ctx, &value,
+		`SELECT ledger_sequence FROM history_balances WHERE ledger_sequence > ? ORDER BY ledger_sequence ASC LIMIT 1`,
+		start,
+	)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+// QBalanceHistory defines history_balances related queries.
+type QBalanceHistory interface {
+	NewBalanceSnapshotBatchInsertBuilder() BalanceSnapshotBatchInsertBuilder
+	DeleteBalanceHistoryRange(ctx context.Context, start, end uint32) (int64, error)
+	GetNextBalanceHistoryLedger(ctx context.Context, start uint32) (uint32, bool, error)
+}
+
+// BalanceSnapshotBatchInsertBuilder is used to insert balance snapshots into
+// the history_balances table
+type BalanceSnapshotBatchInsertBuilder interface {
+	Add(snapshot BalanceSnapshot) error
+	Exec(ctx context.Context, session db.SessionInterface) error
+}
+
+// balanceSnapshotBatchInsertBuilder is a simple wrapper around db.FastBatchInsertBuilder
+type balanceSnapshotBatchInsertBuilder struct {
+	table   string
+	builder db.FastBatchInsertBuilder
+}
+
+// NewBalanceSnapshotBatchInsertBuilder constructs a new BalanceSnapshotBatchInsertBuilder instance
+func (q *Q) NewBalanceSnapshotBatchInsertBuilder() BalanceSnapshotBatchInsertBuilder {
+	return &balanceSnapshotBatchInsertBuilder{
+		table:   "history_balances",
+		builder: db.FastBatchInsertBuilder{},
+	}
+}
+
+// Add adds a balance snapshot to the batch
+func (i *balanceSnapshotBatchInsertBuilder) Add(snapshot BalanceSnapshot) error {
+	return i.builder.RowStruct(snapshot)
+}
+
+func (i *balanceSnapshotBatchInsertBuilder) Exec(ctx context.Context, session db.SessionInterface) error {
+	return i.builder.Exec(ctx, session, i.table)
+}
+
+var selectBalanceSnapshot = sq.Select("hb.*").
+	From("history_balances hb")
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/toid"
+)
+
+func TestBalanceHistory(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &Q{tt.HorizonSession()}
+	tt.Require.NoError(q.Begin(tt.Ctx))
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	address := "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"
+	closeTime := time.Unix(1000, 0).UTC()
+	builder := q.NewBalanceSnapshotBatchInsertBuilder()
+	for _, sequence := range []uint32{10, 20, 30, 40} {
+		tt.Require.NoError(builder.Add(BalanceSnapshot{
+			Address:         address,
+			ContractID:      contractID,
+			LedgerSequence:  sequence,
+			LedgerCloseTime: closeTime.Add(time.Duration(sequence) * time.Second),
+			Balance:         "170141183460469231731687303715884105727",
+		}))
+	}
+	tt.Require.NoError(builder.Exec(tt.Ctx, q))
+	tt.Require.NoError(q.Commit())
+
+	ledgers := func(query BalanceHistoryQuery, page db2.PageQuery) []uint32 {
+		rows, err := q.BalanceHistory(tt.Ctx, query, page)
+		tt.Require.NoError(err)
+		var result []uint32
+		for _, row := range rows {
+			tt.Assert.Equal("170141183460469231731687303715884105727", row.Balance)
+			result = append(result, row.LedgerSequence)
+		}
+		return result
+	}
+	query := BalanceHistoryQuery{Address: address, ContractID: contractID}
+	asc := db2.PageQuery{Order: "asc", Limit: 10}
+	desc := db2.PageQuery{Order: "desc", Limit: 10}
+
+	tt.Assert.Equal([]uint32{10, 20, 30, 40}, ledgers(query, asc))
+	tt.Assert.Equal([]uint32{40, 30, 20, 10}, ledgers(query, desc))
+
+	page := asc
+	page.Cursor = toid.New(20, 0, 0).String()
+	tt.Assert.Equal([]uint32{30, 40}, ledgers(query, page))
+
+	ranged := query
+	ranged.StartLedger = 20
+	ranged.EndLedger = 40
+	tt.Assert.Equal([]uint32{20, 30}, ledgers(ranged, asc))
+
+	ranged = query
+	ranged.StartTime = closeTime.Add(11 * time.Second)
+	ranged.EndTime = closeTime.Add(31 * time.Second)
+	tt.Assert.Equal([]uint32{20, 30}, ledgers(ranged, asc))
+
+	other := query
+	other.ContractID = "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO"
+	tt.Assert.Empty(ledgers(other, asc))
+
+	start, end, err := toid.LedgerRangeInclusive(1, 10)
+	tt.Require.NoError(err)
+	_, err = q.DeleteRangeAll(tt.Ctx, start, end)
+	tt.Require.NoError(err)
+	tt.Assert.Equal([]uint32{20, 30, 40}, ledgers(query, asc))
+
+	next, ok, err := q.GetNextBalanceHistoryLedger(tt.Ctx, 0)
+	tt.Require.NoError(err)
+	tt.Assert.True(ok)
+	tt.Assert.Equal(uint32(20), next)
+	next, ok, err = q.GetNextBalanceHistoryLedger(tt.Ctx, 20)
+	tt.Require.NoError(err)
+	tt.Assert.True(ok)
+	tt.Assert.Equal(uint32(30), next)
+
+	deleted, err := q.DeleteBalanceHistoryRange(tt.Ctx, 0, 40)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(int64(2), deleted)
+	tt.Assert.Equal([]uint32{40}, ledgers(query, asc))
+
+	_, ok, err = q.GetNextBalanceHistoryLedger(tt.Ctx, 40)
+	tt.Require.NoError(err)
+	tt.Assert.False(ok)
+}
@@ -0,0 +1,170 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// MaxContractEventTopics is the maximum number of topics of a contract event.
+const MaxContractEventTopics = 4
+
+// ContractEventTypeNames are the names of the types of the contract events
+// stored in the history_contract_events table. Diagnostic events are not
+// stored.
+var ContractEventTypeNames = map[xdr.ContractEventType]string{
+	xdr.ContractEventTypeSystem:   "system",
+	xdr.ContractEventTypeContract: "contract",
+}
+
+// ContractEvent is a row of data from the `history_contract_events` table
+type ContractEvent struct {
+	HistoryOperationID int64       `db:"history_operation_id"`
+	Order              int32       `db:"order"`
+	TransactionHash    string      `db:"transaction_hash"`
+	LedgerCloseTime    time.Time   `db:"ledger_closed_at"`
+	ContractID         null.String `db:"contract_id"`
+	Type               int32       `db:"type"`
+	Topic1             null.String `db:"topic1"`
+	Topic2             null.String `db:"topic2"`
+	Topic3             null.String `db:"topic3"`
+	Topic4             null.String `db:"topic4"`
+	Value              string      `db:"value"`
+}
+
+// ID returns a lexically ordered id for this contract event record
+func (r *ContractEvent) ID() string {
+	return fmt.Sprintf("%019d-%010d", r.HistoryOperationID, r.Order)
+}
+
+// LedgerSequence return the ledger in which the contract event occurred.
+func (r *ContractEvent) LedgerSequence() int32 {
+	id := toid.Parse(r.HistoryOperationID)
+	return id.LedgerSequence
+}
+
+// PagingToken returns a cursor for this contract event
+func (r *ContractEvent) PagingToken() string {
+	return fmt.Sprintf("%d-%d", r.HistoryOperationID, r.Order)
+}
+
+// Topics returns the topics of the contract event, base64 encoded.
+func (r *ContractEvent) Topics() []string {
+	var topics []string
+	for _, topic := range []null.String{r.Topic1, r.Topic2, r.Topic3, r.Topic4} {
+		if !topic.Valid {
+			break
+		}
+		topics = append(topics, topic.String)
+	}
+	return topics
+}
+
+// ContractEventsQuery is the filter of the contract events returned by
+// ContractEvents.
+type ContractEventsQuery struct {
+	// ContractID is the id of the contract which emitted the events, events
+	// of any contract are returned if empty.
+	ContractID string
+	// Type is the type of the events, events of any type are returned if nil.
+	Type *xdr.ContractEventType
+	// Topics are matched against the first topics of the events, in order. An
+	// empty topic matches any value and events with fewer topics than
+	// Topics are never matched.
+	Topics []string
+}
+
+// ContractEvents returns a page of contract events matching the query.
+func (q *Q) ContractEvents(ctx context.Context, query ContractEventsQuery, page db2.PageQuery, oldestLedger int32) ([]ContractEvent, error) {
+	if len(query.Topics) > MaxContractEventTopics {
+		return nil, fmt.Errorf("too many topics: %d", len(query.Topics))
+	}
+	op, idx, err := parseEffectsCursor(page)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := selectContractEvent
+	if query.ContractID != "" {
+		sql = sql.Where("hce.contract_id = ?", query.ContractID)
+	}
+	if query.Type != nil {
+		sql = sql.Where("hce.type = ?", int32(*query.Type))
+	}
+	for i, topic := range query.Topics {
+		column := fmt.Sprintf("hce.topic%d", i+1)
+		if topic == "" {
+			sql = sql.Where(column + " IS NOT NULL")
+		} else {
+			sql = sql.Where(column+" = ?", topic)
+		}
+	}
+
+	switch page.Order {
+	case "asc":
+		sql = sql.
+			Where("(hce.history_operation_id, hce.order) > (?, ?)", op, idx).
+			OrderBy("hce.history_operation_id asc, hce.order asc")
+	case "desc":
+		if lowerBound := lowestLedgerBound(oldestLedger); lowerBound > 0 {
+			sql = sql.Where("hce.history_operation_id > ?", lowerBound)
+		}
+		sql = sql.
+			Where("(hce.history_operation_id, hce.order) < (?, ?)", op, idx).
+			OrderBy("hce.history_operation_id desc, hce.order desc")
+	}
+
+	sql = sql.Limit(page.Limit)
+
+	var rows []ContractEvent
+	if err = q.Select(ctx, &rows, sql); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QContractEvents defines history_contract_events related queries.
+type QContractEvents interface {
+	NewContractEventBatchInsertBuilder() ContractEventBatchInsertBuilder
+}
+
+// ContractEventBatchInsertBuilder is used to insert contract events into the
+// history_contract_events table
+type ContractEventBatchInsertBuilder interface {
+	Add(event ContractEvent) error
+	Exec(ctx context.Context, session db.SessionInterface) error
+}
+
+// contractEventBatchInsertBuilder is a simple wrapper around db.FastBatchInsertBuilder
+type contractEventBatchInsertBuilder struct {
+	table   string
+	builder db.FastBatchInsertBuilder
+}
+
+// NewContractEventBatchInsertBuilder constructs a new ContractEventBatchInsertBuilder instance
+func (q *Q) NewContractEventBatchInsertBuilder() ContractEventBatchInsertBuilder {
+	return &contractEventBatchInsertBuilder{
+		table:   "history_contract_events",
+		builder: db.FastBatchInsertBuilder{},
+	}
+}
+
+// Add adds a contract event to the batch
+func (i *contractEventBatchInsertBuilder) Add(event ContractEvent) error {
+	return i.builder.RowStruct(event)
+}
+
+func (i *contractEventBatchInsertBuilder) Exec(ctx context.Context, session db.SessionInterface) error {
+	return i.builder.Exec(ctx, session, i.table)
+}
+
+var selectContractEvent = sq.Select("hce.*").
+	From("history_contract_events hce")
@@ -0,0 +1,137 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func TestContractEvents(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &Q{tt.HorizonSession()}
+	tt.Require.NoError(q.Begin(tt.Ctx))
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	otherContractID := "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO"
+	sequence := int32(56)
+	closeTime := time.Unix(1000, 0).UTC()
+	events := []ContractEvent{
+		{
+			HistoryOperationID: toid.New(sequence, 1, 1).ToInt64(),
+			Order:              1,
+			ContractID:         null.StringFrom(contractID),
+			Type:               int32(xdr.ContractEventTypeContract),
+			Topic1:             null.StringFrom("AAAADwAAAAh0cmFuc2Zlcg=="),
+			Topic2:             null.StringFrom("AAAAAQ=="),
+			Value:              "AAAAAwAAAAc=",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 1, 1).ToInt64(),
+			Order:              2,
+			ContractID:         null.StringFrom(otherContractID),
+			Type:               int32(xdr.ContractEventTypeContract),
+			Topic1:             null.StringFrom("AAAADwAAAAh0cmFuc2Zlcg=="),
+			Value:              "AAAAAwAAAAc=",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 2, 1).ToInt64(),
+			Order:              1,
+			Type:               int32(xdr.ContractEventTypeSystem),
+			Value:              "AAAAAwAAAAc=",
+		},
+	}
+
+	builder := q.NewContractEventBatchInsertBuilder()
+	for _, event := range events {
+		event.TransactionHash = "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+		event.LedgerCloseTime = closeTime
+		tt.Require.NoError(builder.Add(event))
+	}
+	tt.Require.NoError(builder.Exec(tt.Ctx, q))
+	tt.Require.NoError(q.Commit())
+
+	page := db2.PageQuery{
+		Cursor: "0-0",
+		Order:  "asc",
+		Limit:  200,
+	}
+	rows, err := q.ContractEvents(tt.Ctx, ContractEventsQuery{}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 3)
+	tt.Assert.Equal(events[0].PagingToken(), rows[0].PagingToken())
+	tt.Assert.Equal(events[1].PagingToken(), rows[1].PagingToken())
+	tt.Assert.Equal(events[2].PagingToken(), rows[2].PagingToken())
+	tt.Assert.Equal(contractID, rows[0].ContractID.String)
+	tt.Assert.Equal([]string{"AAAADwAAAAh0cmFuc2Zlcg==", "AAAAAQ=="}, rows[0].Topics())
+	tt.Assert.Equal(sequence, rows[0].LedgerSequence())
+	tt.Assert.Equal(closeTime, rows[0].LedgerCloseTime.UTC())
+	tt.Assert.False(rows[2].ContractID.Valid)
+	tt.Assert.Empty(rows[2].Topics())
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{ContractID: contractID}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(events[0].PagingToken(), rows[0].PagingToken())
+
+	systemType := xdr.ContractEventTypeSystem
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{Type: &systemType}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(events[2].PagingToken(), rows[0].PagingToken())
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{
+		Topics: []string{"AAAADwAAAAh0cmFuc2Zlcg=="},
+	}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 2)
+
+	// the wildcard does not match events with fewer topics
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{
+		Topics: []string{"AAAADwAAAAh0cmFuc2Zlcg==", ""},
+	}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(events[0].PagingToken(), rows[0].PagingToken())
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{
+		Topics: []string{"", "AAAAAwAAAAc="},
+	}, page, 0)
+	tt.Require.NoError(err)
+	tt.Require.Empty(rows)
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{}, db2.PageQuery{
+		Cursor: events[1].PagingToken(),
+		Order:  "asc",
+		Limit:  200,
+	}, 0)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(events[2].PagingToken(), rows[0].PagingToken())
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{}, db2.PageQuery{
+		Cursor: fmt.Sprintf("%d-0", toid.New(sequence+2, 0, 0).ToInt64()),
+		Order:  "desc",
+		Limit:  2,
+	}, sequence-3)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 2)
+	tt.Assert.Equal(events[2].PagingToken(), rows[0].PagingToken())
+	tt.Assert.Equal(events[1].PagingToken(), rows[1].PagingToken())
+
+	rows, err = q.ContractEvents(tt.Ctx, ContractEventsQuery{}, db2.PageQuery{
+		Cursor: fmt.Sprintf("%d-0", toid.New(sequence+5, 0, 0).ToInt64()),
+		Order:  "desc",
+		Limit:  200,
+	}, sequence+2)
+	tt.Require.NoError(err)
+	tt.Require.Empty(rows)
+}
@@ -0,0 +1,302 @@
+package history
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// ContractDataDurabilityNames are the names of the durabilities of the contract
+// data stored in the contract_data table.
+var ContractDataDurabilityNames = map[xdr.ContractDataDurability]string{
+	xdr.ContractDataDurabilityTemporary:  "temporary",
+	xdr.ContractDataDurabilityPersistent: "persistent",
+}
+
+// ContractData is a row of data from the `contract_data` table
+type ContractData struct {
+	KeyHash    string `db:"key_hash"`
+	ContractID string `db:"contract_id"`
+	Durability int32  `db:"durability"`
+	// Key and Value are base64 encoded ScVal XDR
+	Key                string `db:"key"`
+	Value              string `db:"value"`
+	LastModifiedLedger uint32 `db:"last_modified_ledger"`
+	// LiveUntilLedgerSeq is loaded from the `contract_ttls` table, it is
+	// null if the ttl entry of the contract data was not ingested.
+	LiveUntilLedgerSeq null.Int `db:"live_until_ledger_seq"`
+}
+
+// ContractCode is a row of data from the `contract_code` table
+type ContractCode struct {
+	Hash    string `db:"hash"`
+	KeyHash string `db:"key_hash"`
+	// Code is the base64 encoded wasm code
+	Code               string `db:"code"`
+	LastModifiedLedger uint32 `db:"last_modified_ledger"`
+	// LiveUntilLedgerSeq is loaded from the `contract_ttls` table, it is
+	// null if the ttl entry of the contract code was not ingested.
+	LiveUntilLedgerSeq null.Int `db:"live_until_ledger_seq"`
+}
+
+// ContractTTL is a row of data from the `contract_ttls` table
+type ContractTTL struct {
+	KeyHash            string `db:"key_hash"`
+	LiveUntilLedgerSeq uint32 `db:"live_until_ledger_seq"`
+	LastModifiedLedger uint32 `db:"last_modified_ledger"`
+}
+
+// ContractDataQuery is the filter of the contract data returned by
+// ContractDataByContractID.
+type ContractDataQuery struct {
+	// Durability is the durability of the contract data, contract data of any
+	// durability is returned if nil.
+	Durability *xdr.ContractDataDurability
+	// KeyPrefix filters the contract data by key. If KeyPrefix is a vector
+	// the contract data whose key is a vector starting with the elements of
+	// KeyPrefix are returned, otherwise only the contract data whose key is
+	// equal to KeyPrefix is returned.
+	KeyPrefix *xdr.ScVal
+}
+
+// QContractState defines contract_data, contract_code and contract_ttls
+// related queries.
+type QContractState interface {
+	UpsertContractData(ctx context.Context, data []ContractData) error
+	RemoveContractData(ctx context.Context, keyHashes []string) (int64, error)
+	UpsertContractCode(ctx context.Context, code []ContractCode) error
+	RemoveContractCode(ctx context.Context, keyHashes []string) (int64, error)
+	UpsertContractTTLs(ctx context.Context, ttls []ContractTTL) error
+	RemoveContractTTLs(ctx context.Context, keyHashes []string) (int64, error)
+	GetContractDataByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractData, error)
+	GetContractCodeByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractCode, error)
+	GetContractTTLsByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractTTL, error)
+	CountContractData(ctx context.Context) (int, error)
+	CountContractCode(ctx context.Context) (int, error)
+	CountContractTTLs(ctx context.Context) (int, error)
+}
+
+// UpsertContractData upserts a batch of contract data in the contract_data table.
+func (q *Q) UpsertContractData(ctx context.Context, data []ContractData) error {
+	var keyHash, contractID, durability, key, value, lastModifiedLedger []interface{}
+
+	for _, d := range data {
+		keyHash = append(keyHash, d.KeyHash)
+		contractID = append(contractID, d.ContractID)
+		durability = append(durability, d.Durability)
+		key = append(key, d.Key)
+		value = append(value, d.Value)
+		lastModifiedLedger = append(lastModifiedLedger, d.LastModifiedLedger)
+	}
+
+	upsertFields := []upsertField{
+		{"key_hash", "character(64)", keyHash},
+		{"contract_id", "character varying(56)", contractID},
+		{"durability", "smallint", durability},
+		{"key", "text", key},
+		{"value", "text", value},
+		{"last_modified_ledger", "integer", lastModifiedLedger},
+	}
+
+	return q.upsertRows(ctx, "contract_data", "key_hash", upsertFields)
+}
+
+// RemoveContractData deletes rows in the contract_data table.
+// Returns number of rows affected and error.
+func (q *Q) RemoveContractData(ctx context.Context, keyHashes []string) (int64, error) {
+	return q.removeContractStateRows(ctx, "contract_data", keyHashes)
+}
+
+// UpsertContractCode upserts a batch of contract code in the contract_code table.
+func (q *Q) UpsertContractCode(ctx context.Context, code []ContractCode) error {
+	var hash, keyHash, wasm, lastModifiedLedger []interface{}
+
+	for _, c := range code {
+		hash = append(hash, c.Hash)
+		keyHash = append(keyHash, c.KeyHash)
+		wasm = append(wasm, c.Code)
+		lastModifiedLedger = append(lastModifiedLedger, c.LastModifiedLedger)
+	}
+
+	upsertFields := []upsertField{
+		{"hash", "character(64)", hash},
+		{"key_hash", "character(64)", keyHash},
+		{"code", "text", wasm},
+		{"last_modified_ledger", "integer", lastModifiedLedger},
+	}
+
+	return q.upsertRows(ctx, "contract_code", "hash", upsertFields)
+}
+
+// RemoveContractCode deletes rows in the contract_code table.
+// Returns number of rows affected and error.
+func (q *Q) RemoveContractCode(ctx context.Context, keyHashes []string) (int64, error) {
+	return q.removeContractStateRows(ctx, "contract_code", keyHashes)
+}
+
+// UpsertContractTTLs upserts a batch of ttls in the contract_ttls table.
+func (q *Q) UpsertContractTTLs(ctx context.Context, ttls []ContractTTL) error {
+	var keyHash, liveUntilLedgerSeq, lastModifiedLedger []interface{}
+
+	for _, ttl := range ttls {
+		keyHash = append(keyHash, ttl.KeyHash)
+		liveUntilLedgerSeq = append(liveUntilLedgerSeq, ttl.LiveUntilLedgerSeq)
+		lastModifiedLedger = append(lastModifiedLedger, ttl.LastModifiedLedger)
+	}
+
+	upsertFields := []upsertField{
+		{"key_hash", "character(64)", keyHash},
+		{"live_until_ledger_seq", "integer", liveUntilLedgerSeq},
+		{"last_modified_ledger", "integer", lastModifiedLedger},
+	}
+
+	return q.upsertRows(ctx, "contract_ttls", "key_hash", upsertFields)
+}
+
+// RemoveContractTTLs deletes rows in the contract_ttls table.
+// Returns number of rows affected and error.
+func (q *Q) RemoveContractTTLs(ctx context.Context, keyHashes []string) (int64, error) {
+	return q.removeContractStateRows(ctx, "contract_ttls", keyHashes)
+}
+
+func (q *Q) removeContractStateRows(ctx context.Context, table string, keyHashes []string) (int64, error) {
+	sql := sq.Delete(table).Where(sq.Eq{"key_hash": keyHashes})
+	result, err := q.Exec(ctx, sql)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetContractDataByKeyHash loads a row from the `contract_data` table.
+func (q *Q) GetContractDataByKeyHash(ctx context.Context, keyHash string) (ContractData, error) {
+	var data ContractData
+	sql := selectContractData.Where("cd.key_hash = ?", keyHash).Limit(1)
+	err := q.Get(ctx, &data, sql)
+	return data, err
+}
+
+// GetContractDataByKeyHashes loads the rows of the `contract_data` table with
+// the given key hashes.
+func (q *Q) GetContractDataByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractData, error) {
+	var data []ContractData
+	sql := selectContractData.Where(sq.Eq{"cd.key_hash": keyHashes})
+	err := q.Select(ctx, &data, sql)
+	return data, err
+}
+
+// GetContractCodeByKeyHashes loads the rows of the `contract_code` table with
+// the given key hashes.
+func (q *Q) GetContractCodeByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractCode, error) {
+	var code []ContractCode
+	sql := selectContractCode.Where(sq.Eq{"cc.key_hash": keyHashes})
+	err := q.Select(ctx, &code, sql)
+	return code, err
+}
+
+// GetContractTTLsByKeyHashes loads the rows of the `contract_ttls` table with
+// the given key hashes.
+func (q *Q) GetContractTTLsByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractTTL, error) {
+	var ttls []ContractTTL
+	sql := sq.Select("key_hash", "live_until_ledger_seq", "last_modified_ledger").
+		From("contract_ttls").
+		Where(sq.Eq{"key_hash": keyHashes})
+	err := q.Select(ctx, &ttls, sql)
+	return ttls, err
+}
+
+// CountContractData returns the total number of rows in the contract_data table.
+func (q *Q) CountContractData(ctx context.Context) (int, error) {
+	return q.countContractStateRows(ctx, "contract_data")
+}
+
+// CountContractCode returns the total number of rows in the contract_code table.
+func (q *Q) CountContractCode(ctx context.Context) (int, error) {
+	return q.countContractStateRows(ctx, "contract_code")
+}
+
+// CountContractTTLs returns the total number of rows in the contract_ttls table.
+func (q *Q) CountContractTTLs(ctx context.Context) (int, error) {
+	return q.countContractStateRows(ctx, "contract_ttls")
+}
+
+func (q *Q) countContractStateRows(ctx context.Context, table string) (int, error) {
+	var count int
+	if err := q.Get(ctx, &count, sq.Select("count(*)").From(table)); err != nil {
+		return 0, errors.Wrap(err, "could not run select query")
+	}
+	return count, nil
+}
+
+// ContractDataByContractID returns a page of the contract data of a contract,
+// ordered by key hash.
+func (q *Q) ContractDataByContractID(ctx context.Context, contractID string, query ContractDataQuery, page db2.PageQuery) ([]ContractData, error) {
+	sql := selectContractData.Where("cd.contract_id = ?", contractID)
+	if query.Durability != nil {
+		sql = sql.Where("cd.durability = ?", int32(*query.Durability))
+	}
+	if query.KeyPrefix != nil {
+		prefix, err := query.KeyPrefix.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal key prefix")
+		}
+		if vec, ok := query.KeyPrefix.GetVec(); ok && vec != nil {
+			// A vector is encoded as its type, the optional flag and its
+			// length followed by the encoding of its elements. Keys with more
+			// elements have a different length but start with the same type
+			// and flag.
+			sql = sql.Where(
+				"substring(decode(cd.key, 'base64') from 1 for 8) = ? AND "+
+					"substring(decode(cd.key, 'base64') from 13 for ?) = ?",
+				prefix[:8], len(prefix)-12, prefix[12:],
+			)
+		} else {
+			sql = sql.Where("decode(cd.key, 'base64') = ?", prefix)
+		}
+	}
+
+	sql, err := page.ApplyToUsingCursor(sql, "cd.key_hash", page.Cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not apply query to page")
+	}
+
+	var data []ContractData
+	if err := q.Select(ctx, &data, sql); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetContractCodeByHash loads a row from the `contract_code` table.
+func (q *Q) GetContractCodeByHash(ctx context.Context, hash string) (ContractCode, error) {
+	var code ContractCode
+	sql := selectContractCode.Where("cc.hash = ?", hash).Limit(1)
+	err := q.Get(ctx, &code, sql)
+	return code, err
+}
+
+var selectContractData = sq.Select(
+	"cd.key_hash",
+	"cd.contract_id",
+	"cd.durability",
+	"cd.key",
+	"cd.value",
+	"cd.last_modified_ledger",
+	"ct.live_until_ledger_seq",
+).From("contract_data cd").
+	LeftJoin("contract_ttls ct ON ct.key_hash = cd.key_hash")
+
+var selectContractCode = sq.Select(
+	"cc.hash",
+	"cc.key_hash",
+	"cc.code",
+	"cc.last_modified_ledger",
+	"ct.live_until_ledger_seq",
+).From("contract_code cc").
+	LeftJoin("contract_ttls ct ON ct.key_hash = cc.key_hash")
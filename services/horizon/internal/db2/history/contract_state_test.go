@@ -0,0 +1,170 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/xdr"
+)
+
+func TestContractState(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &Q{tt.HorizonSession()}
+	tt.Require.NoError(q.Begin(tt.Ctx))
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	balance := xdr.ScSymbol("Balance")
+	admin := xdr.ScSymbol("Admin")
+	encode := func(vals ...xdr.ScSymbol) string {
+		vec := xdr.ScVec{}
+		for i := range vals {
+			vec = append(vec, xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &vals[i]})
+		}
+		pvec := &vec
+		encoded, err := xdr.MarshalBase64(xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &pvec})
+		tt.Require.NoError(err)
+		return encoded
+	}
+
+	data := []ContractData{
+		{
+			KeyHash:            "0100000000000000000000000000000000000000000000000000000000000000",
+			ContractID:         contractID,
+			Durability:         int32(xdr.ContractDataDurabilityPersistent),
+			Key:                encode(balance, "GA"),
+			Value:              "AAAAAwAAAAc=",
+			LastModifiedLedger: 10,
+		},
+		{
+			KeyHash:            "0200000000000000000000000000000000000000000000000000000000000000",
+			ContractID:         contractID,
+			Durability:         int32(xdr.ContractDataDurabilityTemporary),
+			Key:                encode(balance, "GB"),
+			Value:              "AAAAAwAAAAc=",
+			LastModifiedLedger: 10,
+		},
+		{
+			KeyHash:            "0300000000000000000000000000000000000000000000000000000000000000",
+			ContractID:         contractID,
+			Durability:         int32(xdr.ContractDataDurabilityPersistent),
+			Key:                encode(admin),
+			Value:              "AAAAAwAAAAc=",
+			LastModifiedLedger: 10,
+		},
+		{
+			KeyHash:            "0400000000000000000000000000000000000000000000000000000000000000",
+			ContractID:         "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO",
+			Durability:         int32(xdr.ContractDataDurabilityPersistent),
+			Key:                encode(balance, "GA"),
+			Value:              "AAAAAwAAAAc=",
+			LastModifiedLedger: 10,
+		},
+	}
+	code := ContractCode{
+		Hash:               "0500000000000000000000000000000000000000000000000000000000000000",
+		KeyHash:            "0600000000000000000000000000000000000000000000000000000000000000",
+		Code:               "AGFzbQ==",
+		LastModifiedLedger: 11,
+	}
+	tt.Require.NoError(q.UpsertContractData(tt.Ctx, data))
+	tt.Require.NoError(q.UpsertContractCode(tt.Ctx, []ContractCode{code}))
+	tt.Require.NoError(q.UpsertContractTTLs(tt.Ctx, []ContractTTL{
+		{KeyHash: data[0].KeyHash, LiveUntilLedgerSeq: 100, LastModifiedLedger: 10},
+		{KeyHash: code.KeyHash, LiveUntilLedgerSeq: 200, LastModifiedLedger: 11},
+	}))
+	tt.Require.NoError(q.Commit())
+
+	row, err := q.GetContractDataByKeyHash(tt.Ctx, data[0].KeyHash)
+	tt.Require.NoError(err)
+	expected := data[0]
+	expected.LiveUntilLedgerSeq = null.IntFrom(100)
+	tt.Assert.Equal(expected, row)
+
+	codeRow, err := q.GetContractCodeByHash(tt.Ctx, code.Hash)
+	tt.Require.NoError(err)
+	code.LiveUntilLedgerSeq = null.IntFrom(200)
+	tt.Assert.Equal(code, codeRow)
+
+	page := db2.PageQuery{Order: "asc", Limit: 10}
+	rows, err := q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{}, page)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 3)
+	tt.Assert.Equal(data[0].KeyHash, rows[0].KeyHash)
+	tt.Assert.Equal(data[1].KeyHash, rows[1].KeyHash)
+	tt.Assert.Equal(data[2].KeyHash, rows[2].KeyHash)
+	tt.Assert.False(rows[1].LiveUntilLedgerSeq.Valid)
+
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{}, db2.PageQuery{
+		Cursor: data[0].KeyHash,
+		Order:  "desc",
+		Limit:  10,
+	})
+	tt.Require.NoError(err)
+	tt.Assert.Empty(rows)
+
+	temporary := xdr.ContractDataDurabilityTemporary
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{Durability: &temporary}, page)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(data[1].KeyHash, rows[0].KeyHash)
+
+	var prefix xdr.ScVal
+	tt.Require.NoError(xdr.SafeUnmarshalBase64(encode(balance), &prefix))
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{KeyPrefix: &prefix}, page)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 2)
+	tt.Assert.Equal(data[0].KeyHash, rows[0].KeyHash)
+	tt.Assert.Equal(data[1].KeyHash, rows[1].KeyHash)
+
+	tt.Require.NoError(xdr.SafeUnmarshalBase64(encode(admin), &prefix))
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{KeyPrefix: &prefix}, page)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(data[2].KeyHash, rows[0].KeyHash)
+
+	prefix = xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &balance}
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{KeyPrefix: &prefix}, page)
+	tt.Require.NoError(err)
+	tt.Assert.Empty(rows)
+
+	rows, err = q.GetContractDataByKeyHashes(tt.Ctx, []string{data[1].KeyHash, data[3].KeyHash, code.KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.ElementsMatch([]ContractData{data[1], data[3]}, rows)
+	codeRows, err := q.GetContractCodeByKeyHashes(tt.Ctx, []string{code.KeyHash, data[0].KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.Equal([]ContractCode{code}, codeRows)
+	ttls, err := q.GetContractTTLsByKeyHashes(tt.Ctx, []string{data[0].KeyHash, data[1].KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.Equal([]ContractTTL{{KeyHash: data[0].KeyHash, LiveUntilLedgerSeq: 100, LastModifiedLedger: 10}}, ttls)
+	count, err := q.CountContractData(tt.Ctx)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(4, count)
+	count, err = q.CountContractCode(tt.Ctx)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(1, count)
+	count, err = q.CountContractTTLs(tt.Ctx)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(2, count)
+
+	removed, err := q.RemoveContractData(tt.Ctx, []string{data[0].KeyHash, data[1].KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.Equal(int64(2), removed)
+	removed, err = q.RemoveContractCode(tt.Ctx, []string{code.KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.Equal(int64(1), removed)
+	removed, err = q.RemoveContractTTLs(tt.Ctx, []string{data[0].KeyHash, code.KeyHash})
+	tt.Require.NoError(err)
+	tt.Assert.Equal(int64(2), removed)
+
+	_, err = q.GetContractCodeByHash(tt.Ctx, code.Hash)
+	tt.Assert.True(q.NoRows(err))
+	rows, err = q.ContractDataByContractID(tt.Ctx, contractID, ContractDataQuery{}, page)
+	tt.Require.NoError(err)
+	tt.Require.Len(rows, 1)
+	tt.Assert.Equal(data[2].KeyHash, rows[0].KeyHash)
+}
@@ -16,6 +16,9 @@ func (q *Q) TruncateIngestStateTables(ctx context.Context) error {
 		"accounts_signers",
 		"claimable_balances",
 		"claimable_balance_claimants",
+		"contract_code",
+		"contract_data",
+		"contract_ttls",
 		"exp_asset_stats",
 		"contract_asset_balances",
 		"contract_asset_stats",
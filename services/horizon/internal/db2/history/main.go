@@ -25,6 +25,7 @@ import (
 	"github.com/stellar/go/support/db"
 	"github.com/stellar/go/support/errors"
 	strtime "github.com/stellar/go/support/time"
+	"github.com/stellar/go/toid"
 	"github.com/stellar/go/xdr"
 )
 
@@ -266,6 +267,10 @@ type IngestionQ interface {
 	QAssetStats
 	QClaimableBalances
 	QHistoryClaimableBalances
+	QContractEvents
+	QTokenTransfers
+	QBalanceHistory
+	QContractState
 	QData
 	QEffects
 	QLedgers
@@ -1206,6 +1211,8 @@ func constructFindReapLookupTablesQuery(table string, batchSize int, offset int6
 func (q *Q) DeleteRangeAll(ctx context.Context, start, end int64) (int64, error) {
 	var total int64
 	for table, column := range map[string]string{
+		"history_contract_events":                "history_operation_id",
+		"history_token_transfers":                "history_operation_id",
 		"history_effects":                        "history_operation_id",
 		"history_ledgers":                        "id",
 		"history_operation_claimable_balances":   "history_operation_id",
@@ -1225,6 +1232,13 @@ func (q *Q) DeleteRangeAll(ctx context.Context, start, end int64) (int64, error)
 		}
 		total += count
 	}
+	// history_balances is keyed by ledger sequence rather than by toid
+	count, err := q.DeleteBalanceHistoryRange(ctx,
+		uint32(toid.Parse(start).LedgerSequence), uint32(toid.Parse(end).LedgerSequence))
+	if err != nil {
+		return 0, errors.Wrap(err, "Error clearing history_balances")
+	}
+	total += count
 	return total, nil
 }
 
@@ -0,0 +1,26 @@
+package history
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/stellar/go/support/db"
+)
+
+// MockBalanceSnapshotBatchInsertBuilder mock BalanceSnapshotBatchInsertBuilder
+type MockBalanceSnapshotBatchInsertBuilder struct {
+	mock.Mock
+}
+
+// Add mock
+func (m *MockBalanceSnapshotBatchInsertBuilder) Add(snapshot BalanceSnapshot) error {
+	a := m.Called(snapshot)
+	return a.Error(0)
+}
+
+// Exec mock
+func (m *MockBalanceSnapshotBatchInsertBuilder) Exec(ctx context.Context, session db.SessionInterface) error {
+	a := m.Called(ctx, session)
+	return a.Error(0)
+}
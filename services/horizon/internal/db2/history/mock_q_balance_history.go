@@ -0,0 +1,27 @@
+package history
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockQBalanceHistory is a mock implementation of the QBalanceHistory interface
+type MockQBalanceHistory struct {
+	mock.Mock
+}
+
+func (m *MockQBalanceHistory) NewBalanceSnapshotBatchInsertBuilder() BalanceSnapshotBatchInsertBuilder {
+	a := m.Called()
+	return a.Get(0).(BalanceSnapshotBatchInsertBuilder)
+}
+
+func (m *MockQBalanceHistory) DeleteBalanceHistoryRange(ctx context.Context, start, end uint32) (int64, error) {
+	a := m.Called(ctx, start, end)
+	return a.Get(0).(int64), a.Error(1)
+}
+
+func (m *MockQBalanceHistory) GetNextBalanceHistoryLedger(ctx context.Context, start uint32) (uint32, bool, error) {
+	a := m.Called(ctx, start)
+	return a.Get(0).(uint32), a.Get(1).(bool), a.Error(2)
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockQContractState is a mock implementation of the QContractState interface
+type MockQContractState struct {
+	mock.Mock
+}
+
+func (m *MockQContractState) UpsertContractData(ctx context.Context, data []ContractData) error {
+	a := m.Called(ctx, data)
+	return a.Error(0)
+}
+
+func (m *MockQContractState) RemoveContractData(ctx context.Context, keyHashes []string) (int64, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).(int64), a.Error(1)
+}
+
+func (m *MockQContractState) UpsertContractCode(ctx context.Context, code []ContractCode) error {
+	a := m.Called(ctx, code)
+	return a.Error(0)
+}
+
+func (m *MockQContractState) RemoveContractCode(ctx context.Context, keyHashes []string) (int64, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).(int64), a.Error(1)
+}
+
+func (m *MockQContractState) UpsertContractTTLs(ctx context.Context, ttls []ContractTTL) error {
+	a := m.Called(ctx, ttls)
+	return a.Error(0)
+}
+
+func (m *MockQContractState) RemoveContractTTLs(ctx context.Context, keyHashes []string) (int64, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).(int64), a.Error(1)
+}
+
+func (m *MockQContractState) GetContractDataByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractData, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).([]ContractData), a.Error(1)
+}
+
+func (m *MockQContractState) GetContractCodeByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractCode, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).([]ContractCode), a.Error(1)
+}
+
+func (m *MockQContractState) GetContractTTLsByKeyHashes(ctx context.Context, keyHashes []string) ([]ContractTTL, error) {
+	a := m.Called(ctx, keyHashes)
+	return a.Get(0).([]ContractTTL), a.Error(1)
+}
+
+func (m *MockQContractState) CountContractData(ctx context.Context) (int, error) {
+	a := m.Called(ctx)
+	return a.Get(0).(int), a.Error(1)
+}
+
+func (m *MockQContractState) CountContractCode(ctx context.Context) (int, error) {
+	a := m.Called(ctx)
+	return a.Get(0).(int), a.Error(1)
+}
+
+func (m *MockQContractState) CountContractTTLs(ctx context.Context) (int, error) {
+	a := m.Called(ctx)
+	return a.Get(0).(int), a.Error(1)
+}
@@ -0,0 +1,15 @@
+package history
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockQTokenTransfers is a mock implementation of the QTokenTransfers interface
+type MockQTokenTransfers struct {
+	mock.Mock
+}
+
+func (m *MockQTokenTransfers) NewTokenTransferBatchInsertBuilder() TokenTransferBatchInsertBuilder {
+	a := m.Called()
+	return a.Get(0).(TokenTransferBatchInsertBuilder)
+}
@@ -0,0 +1,26 @@
+package history
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/stellar/go/support/db"
+)
+
+// MockTokenTransferBatchInsertBuilder mock TokenTransferBatchInsertBuilder
+type MockTokenTransferBatchInsertBuilder struct {
+	mock.Mock
+}
+
+// Add mock
+func (m *MockTokenTransferBatchInsertBuilder) Add(transfer TokenTransfer) error {
+	a := m.Called(transfer)
+	return a.Error(0)
+}
+
+// Exec mock
+func (m *MockTokenTransferBatchInsertBuilder) Exec(ctx context.Context, session db.SessionInterface) error {
+	a := m.Called(ctx, session)
+	return a.Error(0)
+}
@@ -0,0 +1,195 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/toid"
+)
+
+// Types of the token transfers stored in the history_token_transfers table.
+const (
+	TokenTransferTypeTransfer int32 = iota + 1
+	TokenTransferTypeMint
+	TokenTransferTypeBurn
+	TokenTransferTypeClawback
+	TokenTransferTypeFee
+)
+
+// TokenTransferTypeNames are the names of the types of the token transfers
+// stored in the history_token_transfers table.
+var TokenTransferTypeNames = map[int32]string{
+	TokenTransferTypeTransfer: "transfer",
+	TokenTransferTypeMint:     "mint",
+	TokenTransferTypeBurn:     "burn",
+	TokenTransferTypeClawback: "clawback",
+	TokenTransferTypeFee:      "fee",
+}
+
+// TokenTransfer is a row of data from the `history_token_transfers` table
+type TokenTransfer struct {
+	// HistoryOperationID is the id of the operation which moved the tokens,
+	// or the id of the transaction for fee events.
+	HistoryOperationID int64       `db:"history_operation_id"`
+	Order              int32       `db:"order"`
+	TransactionHash    string      `db:"transaction_hash"`
+	LedgerCloseTime    time.Time   `db:"ledger_closed_at"`
+	Type               int32       `db:"type"`
+	ContractID         string      `db:"contract_id"`
+	Asset              null.String `db:"asset"`
+	FromAddress        null.String `db:"from_address"`
+	ToAddress          null.String `db:"to_address"`
+	Amount             string      `db:"amount"`
+}
+
+// ID returns a lexically ordered id for this token transfer record
+func (r *TokenTransfer) ID() string {
+	return fmt.Sprintf("%019d-%010d", r.HistoryOperationID, r.Order)
+}
+
+// LedgerSequence return the ledger in which the token transfer occurred.
+func (r *TokenTransfer) LedgerSequence() int32 {
+	id := toid.Parse(r.HistoryOperationID)
+	return id.LedgerSequence
+}
+
+// OperationID returns the id of the operation which moved the tokens, 0 for
+// fee events.
+func (r *TokenTransfer) OperationID() int64 {
+	if toid.Parse(r.HistoryOperationID).OperationOrder == 0 {
+		return 0
+	}
+	return r.HistoryOperationID
+}
+
+// PagingToken returns a cursor for this token transfer
+func (r *TokenTransfer) PagingToken() string {
+	return fmt.Sprintf("%d-%d", r.HistoryOperationID, r.Order)
+}
+
+// TokenTransfersQuery is the filter of the token transfers returned by
+// TokenTransfers.
+type TokenTransfersQuery struct {
+	// Address matches the transfers from or to the address, transfers of any
+	// address are returned if empty.
+	Address string
+	// ContractID is the id of the token contract, transfers of any token are
+	// returned if empty.
+	ContractID string
+	// Type is the type of the transfers, transfers of any type are returned
+	// if nil.
+	Type *int32
+}
+
+// TokenTransfers returns a page of token transfers matching the query.
+func (q *Q) TokenTransfers(ctx context.Context, query TokenTransfersQuery, page db2.PageQuery, oldestLedger int32) ([]TokenTransfer, error) {
+	op, idx, err := parseEffectsCursor(page)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := selectTokenTransfer
+	if query.ContractID != "" {
+		sql = sql.Where("htt.contract_id = ?", query.ContractID)
+	}
+	if query.Type != nil {
+		sql = sql.Where("htt.type = ?", *query.Type)
+	}
+
+	switch page.Order {
+	case "asc":
+		sql = sql.
+			Where("(htt.history_operation_id, htt.order) > (?, ?)", op, idx).
+			OrderBy("htt.history_operation_id asc, htt.order asc")
+	case "desc":
+		if lowerBound := lowestLedgerBound(oldestLedger); lowerBound > 0 {
+			sql = sql.Where("htt.history_operation_id > ?", lowerBound)
+		}
+		sql = sql.
+			Where("(htt.history_operation_id, htt.order) < (?, ?)", op, idx).
+			OrderBy("htt.history_operation_id desc, htt.order desc")
+	}
+
+	sql = sql.Limit(page.Limit)
+
+	var rows []TokenTransfer
+	if query.Address == "" {
+		if err = q.Select(ctx, &rows, sql); err != nil {
+			return nil, err
+		}
+		return rows, nil
+	}
+
+	// An OR of the addresses can't use the indexes to return the transfers in
+	// order, so the pages of the transfers from and to the address are read
+	// from their own index and merged. Transfers from the address to itself
+	// are only read from the first index.
+	fromSQL, fromArgs, err := sql.Where("htt.from_address = ?", query.Address).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	toSQL, toArgs, err := sql.
+		Where("htt.to_address = ?", query.Address).
+		Where("htt.from_address IS DISTINCT FROM ?", query.Address).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	rawSQL := fmt.Sprintf("(%s) UNION ALL (%s) ", fromSQL, toSQL)
+	switch page.Order {
+	case "asc":
+		rawSQL += `ORDER BY history_operation_id asc, "order" asc `
+	case "desc":
+		rawSQL += `ORDER BY history_operation_id desc, "order" desc `
+	}
+	rawSQL += fmt.Sprintf("LIMIT %d", page.Limit)
+
+	if err = q.SelectRaw(ctx, &rows, rawSQL, append(fromArgs, toArgs...)...); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QTokenTransfers defines history_token_transfers related queries.
+type QTokenTransfers interface {
+	NewTokenTransferBatchInsertBuilder() TokenTransferBatchInsertBuilder
+}
+
+// TokenTransferBatchInsertBuilder is used to insert token transfers into the
+// history_token_transfers table
+type TokenTransferBatchInsertBuilder interface {
+	Add(transfer TokenTransfer) error
+	Exec(ctx context.Context, session db.SessionInterface) error
+}
+
+// tokenTransferBatchInsertBuilder is a simple wrapper around db.FastBatchInsertBuilder
+type tokenTransferBatchInsertBuilder struct {
+	table   string
+	builder db.FastBatchInsertBuilder
+}
+
+// NewTokenTransferBatchInsertBuilder constructs a new TokenTransferBatchInsertBuilder instance
+func (q *Q) NewTokenTransferBatchInsertBuilder() TokenTransferBatchInsertBuilder {
+	return &tokenTransferBatchInsertBuilder{
+		table:   "history_token_transfers",
+		builder: db.FastBatchInsertBuilder{},
+	}
+}
+
+// Add adds a token transfer to the batch
+func (i *tokenTransferBatchInsertBuilder) Add(transfer TokenTransfer) error {
+	return i.builder.RowStruct(transfer)
+}
+
+func (i *tokenTransferBatchInsertBuilder) Exec(ctx context.Context, session db.SessionInterface) error {
+	return i.builder.Exec(ctx, session, i.table)
+}
+
+var selectTokenTransfer = sq.Select("htt.*").
+	From("history_token_transfers htt")
@@ -0,0 +1,124 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/test"
+	"github.com/stellar/go/toid"
+)
+
+func TestTokenTransfers(t *testing.T) {
+	tt := test.Start(t)
+	defer tt.Finish()
+	test.ResetHorizonDB(t, tt.HorizonDB)
+	q := &Q{tt.HorizonSession()}
+	tt.Require.NoError(q.Begin(tt.Ctx))
+
+	contractID := "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"
+	otherContractID := "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO"
+	source := "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"
+	destination := "GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON"
+	sequence := int32(56)
+	closeTime := time.Unix(1000, 0).UTC()
+	transfers := []TokenTransfer{
+		{
+			HistoryOperationID: toid.New(sequence, 1, 0).ToInt64(),
+			Order:              1,
+			Type:               TokenTransferTypeFee,
+			ContractID:         otherContractID,
+			Asset:              null.StringFrom("native"),
+			FromAddress:        null.StringFrom(source),
+			Amount:             "100",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 1, 0).ToInt64(),
+			Order:              2,
+			Type:               TokenTransferTypeFee,
+			ContractID:         otherContractID,
+			Asset:              null.StringFrom("native"),
+			FromAddress:        null.StringFrom(source),
+			Amount:             "-40",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 1, 1).ToInt64(),
+			Order:              1,
+			Type:               TokenTransferTypeTransfer,
+			ContractID:         contractID,
+			FromAddress:        null.StringFrom(source),
+			ToAddress:          null.StringFrom(destination),
+			Amount:             "170141183460469231731687303715884105727",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 2, 1).ToInt64(),
+			Order:              1,
+			Type:               TokenTransferTypeMint,
+			ContractID:         contractID,
+			ToAddress:          null.StringFrom(contractID),
+			Amount:             "5",
+		},
+		{
+			HistoryOperationID: toid.New(sequence, 3, 1).ToInt64(),
+			Order:              1,
+			Type:               TokenTransferTypeTransfer,
+			ContractID:         otherContractID,
+			FromAddress:        null.StringFrom(destination),
+			ToAddress:          null.StringFrom(destination),
+			Amount:             "7",
+		},
+	}
+
+	builder := q.NewTokenTransferBatchInsertBuilder()
+	for i := range transfers {
+		transfers[i].TransactionHash = "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+		transfers[i].LedgerCloseTime = closeTime
+		tt.Require.NoError(builder.Add(transfers[i]))
+	}
+	tt.Require.NoError(builder.Exec(tt.Ctx, q))
+	tt.Require.NoError(q.Commit())
+
+	page := db2.PageQuery{Order: "asc", Limit: 10}
+	rows, err := q.TokenTransfers(tt.Ctx, TokenTransfersQuery{}, page, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(transfers, rows)
+
+	// transfers to the address itself are returned once
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{Address: destination}, page, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal([]TokenTransfer{transfers[2], transfers[4]}, rows)
+
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{Address: source}, db2.PageQuery{
+		Cursor: transfers[2].PagingToken(),
+		Order:  "desc",
+		Limit:  1,
+	}, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(transfers[1:2], rows)
+
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{Address: source}, page, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(transfers[:3], rows)
+
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{ContractID: contractID}, page, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(transfers[2:4], rows)
+
+	mint := TokenTransferTypeMint
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{ContractID: contractID, Type: &mint}, page, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal(transfers[3:4], rows)
+
+	rows, err = q.TokenTransfers(tt.Ctx, TokenTransfersQuery{}, db2.PageQuery{
+		Cursor: transfers[2].PagingToken(),
+		Order:  "desc",
+		Limit:  10,
+	}, 0)
+	tt.Require.NoError(err)
+	tt.Assert.Equal([]TokenTransfer{transfers[1], transfers[0]}, rows)
+
+	tt.Assert.Equal(int64(0), transfers[0].OperationID())
+	tt.Assert.Equal(transfers[2].HistoryOperationID, transfers[2].OperationID())
+}
@@ -68,6 +68,11 @@
 // migrations/69_add_asset_contracts_table.sql (671B)
 // migrations/6_create_assets_table.sql (366B)
 // migrations/70_replace_timestamp_trade_aggregations_brin_index.sql (317B)
+// migrations/71_add_history_contract_events.sql (1.043kB)
+// migrations/72_add_contract_state_tables.sql (1.119kB)
+// migrations/73_add_history_token_transfers.sql (1.362kB)
+// migrations/74_add_history_balances.sql (691B)
+// migrations/75_add_history_contract_events_indexes.sql (1.171kB)
 // migrations/7_modify_trades_table.sql (2.303kB)
 // migrations/8_add_aggregators.sql (907B)
 // migrations/8_create_asset_stats_table.sql (441B)
@@ -1500,6 +1505,106 @@ func migrations70_replace_timestamp_trade_aggregations_brin_indexSql() (*asset,
 	return a, nil
 }
 
+var _migrations71_add_history_contract_eventsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb5\x53\x5d\x6f\xda\x30\x14\x7d\xf7\xaf\x38\xca\x53\x60\x44\xd5\x3a\xc6\x0b\x9a\x26\x5a\xa2\x0a\x8d\x85\x2a\x84\x89\x3e\x45\x8e\x73\x47\x2c\x25\x71\x64\x1b\x10\xfb\xf5\x73\xf9\x28\x94\x95\x76\xaa\xd4\xbc\xf9\xde\x73\xcf\xb9\xc7\x27\x0e\x02\x7c\xaa\xe4\x42\x73\x4b\x98\x35\x8c\xdd\xc6\xe1\x20\x09\x91\x0c\x6e\xc6\x21\x0a\x69\xac\xd2\x9b\x54\xa8\xda\x6a\x2e\x6c\x4a\x2b\xaa\xad\x81\xcf\xe0\xbe\x43\x57\x35\xe4\xc6\xa5\xaa\x53\x99\x23\x93\x0b\x59\x5b\x44\x93\x04\xd1\x6c\x3c\xee\x6c\x91\x9e\xd2\x39\x69\x0f\xae\x43\x0b\xd2\x67\x5d\x47\x5d\x1b\xc7\xfe\xc8\x50\x70\x53\x40\x14\xfc\x51\x8d\xb4\xdf\xeb\xb6\xce\xc0\x25\xe5\x8e\x21\x15\xa5\x32\x94\xa7\xdc\xc2\xca\x8a\x8c\xe5\x55\x83\xb5\xb4\x85\x5a\xee\x2a\xf8\xa3\x6a\x3a\x1b\x7d\x72\xe1\xd6\x7c\x92\xc0\x8a\xeb\x8d\xac\x17\xfe\xd7\x5e\x6b\xbf\xce\xa6\x21\x98\x8a\x97\xe5\xbf\x46\xac\x6a\xa4\xf8\x8c\x24\x9c\x27\x1d\x04\x01\xa6\xe2\x17\x2f\x31\x1f\xc6\xce\x1a\x32\x6e\xa8\xd7\x3d\xe2\xae\x77\xb8\x63\xe1\xcb\x79\xa1\x7b\x52\x58\xf1\x72\x49\xdb\xf3\x51\xf3\x35\x89\xfb\x78\xf4\x73\x10\x3f\xe0\x47\xf8\x00\xff\xa5\x28\x3a\x87\x6b\x6f\xb1\x56\x9f\xb1\xab\x36\xa6\xcb\xa6\x51\xda\xe5\xe7\x19\x2a\x49\x58\xb4\xf1\x5b\xab\xea\x62\xcc\xeb\x82\x34\x3d\xbb\xb6\x6f\xf8\x8e\x2d\x29\xb2\x0d\x5e\x16\xdd\x47\xdd\xbe\x3a\xfc\x4a\xa3\x68\x18\xce\xe1\x5d\x10\x49\xb3\x93\x92\xcc\x3d\x4c\xa2\x8b\xfb\xcc\xa6\xa3\xe8\x0e\x99\xd5\x44\xf0\x4f\x86\x3a\x78\xdd\x7f\xff\xfd\xe6\xf7\x81\x7f\x8c\xef\x1d\xf9\xff\x5b\xde\xe1\xdf\x76\xcb\x82\x93\x47\x3d\x54\xeb\x9a\xb1\x61\x3c\xb9\x7f\xe3\x51\x0b\x6e\x04\xcf\xa9\xcf\xfe\x02\xa9\x19\x2b\xaf\x13\x04\x00\x00")
+
+func migrations71_add_history_contract_eventsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations71_add_history_contract_eventsSql,
+		"migrations/71_add_history_contract_events.sql",
+	)
+}
+
+func migrations71_add_history_contract_eventsSql() (*asset, error) {
+	bytes, err := migrations71_add_history_contract_eventsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/71_add_history_contract_events.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3d, 0x76, 0xf8, 0xf9, 0x6c, 0x31, 0xea, 0xc9, 0x85, 0xdf, 0xae, 0x10, 0xd7, 0x27, 0x9e, 0x44, 0x93, 0x01, 0x6f, 0x78, 0xe8, 0x34, 0xe3, 0xd9, 0x6f, 0x7c, 0x15, 0xb1, 0x9b, 0x30, 0x66, 0x5f}}
+	return a, nil
+}
+
+var _migrations72_add_contract_state_tablesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb5\x53\xd1\x6e\xda\x40\x10\x7c\xf7\x57\x8c\x78\x22\x14\x2b\x52\x94\xf0\x12\x55\x15\x0d\x56\x84\x4a\x4d\x6a\xec\x8a\x3c\x59\x6b\xdf\x82\x4f\x39\xdb\xf4\xee\x80\xfa\xef\x8b\x5d\x02\x84\x5a\x55\xa8\xd4\x7b\x39\xe9\x6e\x34\x3b\x3b\x3b\xeb\xba\xf8\x90\xcb\xa5\x26\xcb\x88\x56\x8e\xf3\x10\x78\xc3\xd0\x43\x38\xfc\x3c\xf1\x90\x96\x85\xd5\x94\xda\x58\x90\x25\x74\x1d\xec\xce\x0b\x57\x71\x46\x26\x43\x9a\x51\xfd\xc7\xba\x3b\xb8\xbd\x82\x3f\x0d\xe1\x47\x93\x09\x9e\x82\xf1\xd7\x61\xf0\x8c\x2f\xde\x73\x1f\xae\x8b\x8c\x7f\x82\x8b\xb4\x14\x2c\x60\x32\xba\xb9\x1b\xa0\x5c\xc0\x66\x0c\xc5\x62\xc9\xba\x26\x6c\x88\x0f\xc5\xa4\x38\x72\x63\x43\xba\x92\xc5\xb2\x7b\x37\x38\xd6\xe8\x37\x78\xb1\xd6\x94\x48\x25\x6d\x05\x93\x93\x52\xb2\xb0\x67\x88\x1d\x33\x42\x6f\x1e\x1e\x9f\x6b\x41\xb3\xf4\x3b\x29\xcc\x47\x01\x64\x81\x84\x0c\x0f\x6e\x1b\xf4\x86\xd4\x9a\x2f\xc0\x2b\x32\x36\xce\x4b\x21\x17\x92\x45\xbc\x6f\x66\x27\x82\xeb\xfb\x95\xc1\xb9\xba\x77\x9c\xeb\x1e\x66\xeb\xd5\xaa\xd4\xd6\xa0\x63\x58\x71\x6a\xd1\xc3\x42\x97\xf9\x99\xc3\xdb\x8c\x35\xbf\x31\xe2\x23\x3e\xa1\xd4\x62\xc7\x98\x54\x07\xeb\x3b\xe8\x5d\xbf\x0e\x6a\xec\x8f\xbc\x39\x3a\x6f\x78\xe2\xa4\x8a\x4f\x48\x3a\x98\xfa\x67\x85\xa2\xd9\xd8\x7f\x44\x62\x35\x33\xba\x27\xd0\xfe\xa1\x46\xad\xbb\x3d\x0b\xf5\x28\xf7\x59\x78\x6f\x0e\xde\x15\x9c\xc8\x1f\x7f\x8b\xbc\x4b\x33\x23\xda\x46\xb6\x25\x93\xff\xfe\xfb\xd7\x91\xb5\xb7\x6e\xad\x32\xff\x7b\x0d\x94\xdc\x70\xbc\x2e\xac\x54\x7b\x81\xb1\xe1\x1f\x7f\x88\xec\x5f\xd8\x8f\x7b\xb2\xe6\xa3\x72\x5b\x38\xce\x28\x98\x3e\xb5\xae\x79\x4a\x26\x25\xc1\xf7\xad\x88\xc6\xd4\xbf\x22\x1a\x8f\x0e\x88\x5f\x09\x53\x28\x62\x5f\x04\x00\x00")
+
+func migrations72_add_contract_state_tablesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations72_add_contract_state_tablesSql,
+		"migrations/72_add_contract_state_tables.sql",
+	)
+}
+
+func migrations72_add_contract_state_tablesSql() (*asset, error) {
+	bytes, err := migrations72_add_contract_state_tablesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/72_add_contract_state_tables.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7f, 0xf5, 0x8a, 0x64, 0x68, 0xf6, 0xc3, 0xa5, 0x9b, 0x2a, 0x07, 0xe1, 0x1f, 0x92, 0xc3, 0xa3, 0x04, 0xdc, 0x8c, 0xdc, 0x87, 0x42, 0x6d, 0x76, 0xb4, 0x22, 0x90, 0x58, 0x11, 0xcf, 0xc6, 0xb7}}
+	return a, nil
+}
+
+var _migrations73_add_history_token_transfersSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb5\x54\xc1\x8e\xda\x30\x10\xbd\xe7\x2b\x46\x39\x05\x9a\x68\x2f\xed\x5e\x50\x55\xb1\x25\xaa\x50\x69\x58\xb1\x41\xda\x3d\x45\xc6\x99\x10\xab\x89\x1d\xd9\xce\xa2\xf4\xeb\xeb\xd8\x0b\x84\x15\x14\xb4\x52\x73\xcb\xcc\xbc\xf7\xe6\xcd\x8c\x1c\x45\xf0\xa9\x66\x5b\x49\x34\xc2\xba\xf1\xbc\xef\xab\x78\x9a\xc6\x90\x4e\x1f\x16\x31\x94\x4c\x69\x21\xbb\x4c\x8b\xdf\xc8\x33\x2d\x09\x57\x05\x4a\x05\x81\x07\xe6\xdb\x67\x45\x83\x06\xce\x04\xcf\x58\x0e\x1b\xb6\x65\x5c\x43\xb2\x4c\x21\x59\x2f\x16\x21\x44\x11\xe8\x12\xc1\x82\x09\xed\xcb\xc0\x94\x15\x42\x42\x81\x08\xf8\x8a\x5c\x2b\x4b\xe7\x0b\x99\xa3\xf4\xc1\xc0\x71\x8b\xf2\x48\x61\xb3\x03\x7c\x56\x12\x55\x02\x2d\x89\x34\xff\x28\x83\xfb\xcf\xa3\x77\xc5\x15\xe6\x86\x21\xa3\x95\x50\x98\x67\x44\x83\x66\x35\x2a\x4d\xea\x06\x76\x4c\x97\xa2\x75\x11\xf8\x23\x38\xbe\xd7\xe9\x1a\x04\x55\x93\xaa\x3a\xb1\x61\x73\x54\x70\xdd\x6b\xf6\x3e\x0f\xf2\xf0\x4a\x64\xc7\xf8\x36\xf8\x72\x3f\x3a\x63\xbb\x9f\xdc\x01\x18\xda\x98\xd2\x58\x55\x44\x02\x51\x0a\xf5\x21\x67\x47\x42\x2b\x13\x64\xd4\xa5\xdc\x58\x5c\x55\x1a\x3f\xa7\x96\x94\x12\x2e\x38\xa3\xa4\xea\xeb\x6b\x10\x85\xa5\xb4\x45\xa1\x95\x76\x3c\xad\x59\x4d\xed\xd4\x1d\x4d\x21\x45\x9d\x91\x3c\x97\xa8\x94\x63\x73\x76\xc5\x99\x20\xa9\x45\x6b\xcc\xf3\xb6\x46\x69\x9a\x39\x31\x25\xc9\x0e\x5a\xce\xb4\xda\x4b\x5b\x0d\x0b\x7b\x5c\xcd\x7f\x4d\x57\x2f\xf0\x33\x7e\x81\xe0\xdc\x71\x84\xfb\x1d\x8f\xbc\xd1\xc4\xf3\xee\xc6\xf0\xd4\x36\x8d\x90\x86\xcc\x57\x58\xa1\x19\xc2\xd8\x36\x7a\xf1\xf0\x76\x25\x4a\x3c\xd9\xc3\x57\xf8\x06\x96\x14\x36\x1d\x9c\x17\x7d\xbb\xab\xf1\xdd\xfe\xb8\xe7\xc9\x2c\x7e\x06\xff\x82\x48\xb6\xe9\xb2\x81\x82\x0f\xcb\xe4\x62\x3f\xeb\xa7\x79\xf2\x03\x36\x5a\x9a\x53\x0e\x06\xa0\x10\xfe\xed\x7f\xf2\x71\xf3\x27\x8b\x74\xee\x87\x5b\xfc\x3f\xf3\x18\x8a\xde\x3e\x90\x21\xea\xfa\x44\x6e\x6e\xe6\xe8\xf6\xf6\x56\x8e\x98\xeb\x8d\x78\xd1\xe0\x4d\x9c\x89\x1d\xf7\xbc\xd9\x6a\xf9\x78\xe5\x4d\xa4\x44\x51\x92\xe3\xc4\xfb\x0b\x82\x8f\x90\xe6\x52\x05\x00\x00")
+
+func migrations73_add_history_token_transfersSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations73_add_history_token_transfersSql,
+		"migrations/73_add_history_token_transfers.sql",
+	)
+}
+
+func migrations73_add_history_token_transfersSql() (*asset, error) {
+	bytes, err := migrations73_add_history_token_transfersSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/73_add_history_token_transfers.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x01, 0x33, 0x44, 0x54, 0xaa, 0x2c, 0x00, 0xfb, 0x6d, 0x68, 0xa8, 0x5b, 0x8d, 0xf6, 0x92, 0x39, 0x95, 0x8a, 0x58, 0xf5, 0xe1, 0x9d, 0x37, 0xcf, 0x2d, 0x18, 0x78, 0x9d, 0xf8, 0xae, 0x44, 0x00}}
+	return a, nil
+}
+
+var _migrations74_add_history_balancesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8d\x52\xc1\x6e\xc2\x30\x0c\xbd\xe7\x2b\xac\x9d\x80\x51\x71\xda\x2e\x9c\xd8\xa8\x26\x34\x56\x50\x01\x69\x9c\x2a\x93\x78\x34\x52\x49\xba\xc4\x5d\xc5\xbe\x7e\xa1\x80\x80\x4e\x93\x96\x5b\x9e\xed\xf7\x9e\xad\x17\x45\x70\xbf\xd3\x5b\x87\x4c\xb0\x2a\x85\x78\x4e\xe3\xd1\x32\x86\xe5\xe8\x69\x1a\x43\xae\x3d\x5b\xb7\xcf\x36\x58\xa0\x91\xe4\xa1\x23\x20\x3c\x54\xca\x91\xf7\x20\x73\x74\x28\x99\x1c\x7c\xa1\xdb\x6b\xb3\xed\x3c\x3c\x76\x21\x99\x2d\x21\x59\x4d\xa7\x7d\x88\x22\xe0\x9c\x00\xa5\xb4\x95\x61\xb0\x0e\xa4\x35\x7c\x18\x81\xdc\x16\x2a\x0c\x34\xf5\x13\x7b\x43\x7d\x6e\xc8\xb4\xfa\x2f\xbd\x67\x2a\x0a\x74\x80\xde\x13\x5f\x14\xec\xc7\x51\xfc\x80\x36\xd4\x05\xa9\x2d\xb9\xcc\xd3\x67\x45\x41\x0e\xb4\x61\x0a\xc0\x85\xf0\xba\x4b\x16\xd6\x93\xca\x90\x81\xf5\x8e\x3c\xe3\xae\x84\x5a\x73\x6e\xab\x23\x02\xdf\xd6\x50\x6b\xf4\xb4\x07\x98\x6a\x47\x4e\xcb\x5b\xa7\x0e\x6b\xa8\x8c\x66\x7f\x63\x0c\x0e\x02\xe1\x43\x46\x9d\xf1\xa3\x81\x86\x70\x9e\x4e\xde\x46\xe9\x1a\x5e\xe3\x35\x74\x4e\x47\xef\x5f\x9f\xa8\xdf\x5e\xaa\x2b\xba\x43\x21\x06\x3d\x58\x54\x65\x69\x5d\x50\x73\x84\x65\xeb\xd0\xc1\x42\xc5\x5e\x2b\x6a\x40\x47\x4c\x86\xb5\x35\x61\x3f\xa3\x6c\x0d\xbd\xc1\x39\x04\x93\x64\x1c\xbf\xc3\x5d\x3b\x05\xd9\x66\x9f\xb5\x74\xef\x60\x96\xfc\x4e\xcb\x6a\x31\x49\x5e\x60\xc3\x8e\x08\x3a\x6d\xa7\xc1\x67\x74\x95\xbd\xb1\xad\x8d\x10\xe3\x74\x36\xff\x2b\x7b\x12\xbd\x44\x45\x43\xf1\x03\x29\xaa\xe6\xc7\xb3\x02\x00\x00")
+
+func migrations74_add_history_balancesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations74_add_history_balancesSql,
+		"migrations/74_add_history_balances.sql",
+	)
+}
+
+func migrations74_add_history_balancesSql() (*asset, error) {
+	bytes, err := migrations74_add_history_balancesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/74_add_history_balances.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xcc, 0x75, 0xc5, 0x04, 0x90, 0xc1, 0x51, 0x9f, 0x54, 0x63, 0x7b, 0x43, 0x1c, 0x45, 0x5b, 0x6e, 0x27, 0x5e, 0x00, 0x61, 0xb8, 0x68, 0x74, 0x9d, 0x6e, 0x74, 0xf1, 0x83, 0xdc, 0x2b, 0x45, 0xa8}}
+	return a, nil
+}
+
+var _migrations75_add_history_contract_events_indexesSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb5\x93\x41\x6b\xc2\x40\x10\x85\xef\xfb\x2b\x1e\x7b\xb2\xa9\x41\x68\x72\x93\x52\xc4\x84\xe2\x25\x16\xad\xd0\xdb\x62\xe2\xa8\x0b\x9a\x59\x36\xdb\x4a\xfe\x7d\xa3\x81\xd2\x4b\xa8\x4b\xd9\xf3\x7c\xbc\x37\x1f\xcc\xc4\x31\x1e\xcf\xfa\x60\xb7\x8e\xb0\x31\x42\x4c\x22\xac\x3f\x8d\x61\xeb\x1a\xc8\x86\x4e\x54\x39\x44\xd8\x5b\x3e\xe3\xa8\x1b\xc7\xb6\x55\x15\xd7\xce\x6e\x2b\xa7\xe8\x8b\xea\x0e\xbb\x1c\xc9\x12\x5c\x6b\x08\xcf\x78\x01\xdb\x1d\x59\x94\xed\x0f\xcf\x86\xba\x78\xcd\xb5\xd2\xbb\x71\x3f\x96\x88\x26\x62\xbe\xca\x67\xef\x39\x16\x45\x96\x7f\x40\x0e\xa4\xab\xb2\x55\xd7\x68\x89\x65\x31\xb8\xc1\x66\xbd\x28\x5e\x51\x3a\x4b\x84\xd1\x95\x1e\x0f\x94\xcb\xbe\xfd\x61\xfa\x0f\x4f\x36\xba\x7a\x0a\x65\x7a\x0b\xf7\x70\xbd\xf1\xa1\x6d\x93\x90\xb6\x89\xa7\x6d\x12\xda\x36\x0d\x69\x9b\x7a\xda\xa6\x7f\xdb\x8a\xf8\xd7\x0b\x67\x7c\xa9\x85\xc8\x56\xcb\xb7\x7b\xff\x6a\x7a\x37\xdd\xdf\xa6\x1f\x9f\x78\xf2\x69\xc7\x7f\x03\xc4\x06\x0b\xd8\x93\x04\x00\x00")
+
+func migrations75_add_history_contract_events_indexesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations75_add_history_contract_events_indexesSql,
+		"migrations/75_add_history_contract_events_indexes.sql",
+	)
+}
+
+func migrations75_add_history_contract_events_indexesSql() (*asset, error) {
+	bytes, err := migrations75_add_history_contract_events_indexesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations/75_add_history_contract_events_indexes.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3e, 0xee, 0x1f, 0xe2, 0x73, 0xc2, 0x6e, 0x9a, 0xcf, 0xad, 0xab, 0xe2, 0x88, 0x22, 0x35, 0x0a, 0x85, 0x26, 0x18, 0x79, 0x37, 0x62, 0xc3, 0x20, 0xc1, 0xdb, 0xfc, 0xa7, 0x62, 0x46, 0xe6, 0xa0}}
+	return a, nil
+}
+
 var _migrations7_modify_trades_tableSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x54\x4d\x8f\xda\x30\x14\xbc\xe7\x57\x3c\xed\x29\x51\xc3\xaa\xad\xda\xbd\x6c\x55\x09\x58\x97\x46\x65\xc3\x36\x04\xa9\xb7\xc8\x89\xdf\x06\xab\xc1\x8e\x6c\xa7\x88\x7f\x5f\x05\x08\xcd\x27\xb0\xbb\x87\x5e\x93\x99\x79\x6f\xec\xf1\x8c\x46\xf0\x6e\xc3\x53\x45\x0d\xc2\x2a\xb7\x46\x23\x60\x4a\xe6\x60\xd6\x08\x32\x63\x60\x14\x65\xa8\xc1\xd0\x38\xc3\x5b\xc8\x0b\x03\x14\x04\x6e\x41\x0a\x04\x2e\x20\xcf\x68\x82\xd6\x43\xb0\x78\x82\x70\x3c\x99\x13\x58\x73\x6d\xa4\xda\x45\x07\xde\xbd\x35\x0d\xc8\x38\x24\xbd\x3f\xc1\xb6\x00\xe0\xf4\x51\xe6\xa8\xa8\xe1\x52\x44\x9c\xc1\xc4\x9b\x79\x7e\x08\xfe\x22\x04\x7f\x35\x9f\xbb\x7b\xe4\x8d\x54\x0c\xd5\x0d\x78\x7e\x48\x66\x24\x68\xfd\xcd\x90\xa5\xa8\xa2\x24\x93\x1a\x59\x44\x0d\x84\xde\x23\x59\x86\xe3\xc7\xa7\x16\x50\x3e\x3f\xa3\x1a\x1c\x12\x53\x8d\x11\x4d\x12\x59\x08\xd3\x03\x82\x80\x7c\x23\x01\xf1\xa7\x64\x79\xda\xfc\x88\xd6\x36\x67\x4e\x5d\x44\x6b\xbc\x5a\xa2\xc4\x76\x04\x36\xa5\x6c\x87\x3e\xfd\x4e\xa6\x3f\xc0\xae\x43\xbe\xc2\xfb\x23\x71\xbf\x09\xaa\x37\x3b\x38\xe9\xbc\xc1\xc4\x49\xe3\xac\x8f\x16\xea\x9f\x95\xbd\x41\xae\x23\x8d\x59\x86\x0a\x26\x8b\xc5\x9c\x8c\xfd\xc3\xbf\x3d\xd7\x6e\x1e\xf3\x97\xce\xd2\x8e\xe5\xdc\x5b\x55\x04\x57\xbe\xf7\x73\x45\xc0\xf3\x1f\xc8\x2f\x58\x1b\xc5\xa2\x9c\x33\x58\xf8\xed\x54\xae\x96\x9e\x3f\x83\xd8\x28\x44\xb0\xfb\xc2\xe9\x56\x41\x74\x4e\xf1\xae\x8b\x52\xae\x22\xc3\x37\x18\x65\x52\xfe\x2e\xf2\xc1\x09\x93\x30\x20\xa4\x69\xc1\xed\x38\x70\x3b\xb1\xee\x1d\x5a\xd1\xae\x1a\xd9\x39\xa5\x3e\xc5\xeb\x1d\x5c\xb5\x60\xbc\x8b\xf6\xcf\xee\xd2\x79\x57\x6f\xb3\xbc\x37\xab\x5e\x4d\x0f\x72\x2b\x1a\xe5\x24\x70\x8b\xaa\xea\x25\x85\x5c\x68\x53\xe2\xaa\xde\x92\x02\x6f\x87\x7b\x09\x12\xaa\x13\xca\xf0\xd5\xfd\x14\xf3\x94\x0b\x33\xd0\x4f\x5c\x18\x4c\x51\x0d\xd5\x4e\x2f\xf7\x10\xf2\xc1\xdf\x71\xb1\x3b\x47\x96\x19\x3b\x5e\xa7\xd9\xe5\x08\xc9\x9a\x2a\x9a\x18\x54\xf0\x87\xaa\x1d\x17\xa9\x7d\xf7\xc9\x19\xe6\x70\xad\x0b\x54\x3d\xac\xcf\x77\x67\x58\x89\x64\x7d\x93\x3e\x7c\xec\xe7\x1c\x5e\x77\x6b\xfd\xaa\x03\xea\x90\x5a\x01\xc8\x22\x5d\x9b\x97\x1a\x6b\xb0\x5e\x60\xad\xc1\xbb\xda\x5c\xc5\x3a\x6b\xaf\x09\x2a\x0d\xfe\x87\x62\x7a\xc5\x13\x6c\x8b\x94\x1a\xe5\x55\x5d\x92\x68\xe5\xd1\x6d\xc7\xc6\xed\xa6\x6f\x60\xda\xe1\xe4\x2e\xcd\xeb\x04\xc5\xed\xde\xa6\xdb\x17\x0c\xe7\xfe\x6f\x00\x00\x00\xff\xff\x2a\xff\xe8\x4a\xff\x08\x00\x00")
 
 func migrations7_modify_trades_tableSqlBytes() ([]byte, error) {
@@ -1739,6 +1844,11 @@ var _bindata = map[string]func() (*asset, error){
 	"migrations/69_add_asset_contracts_table.sql":                        migrations69_add_asset_contracts_tableSql,
 	"migrations/6_create_assets_table.sql":                               migrations6_create_assets_tableSql,
 	"migrations/70_replace_timestamp_trade_aggregations_brin_index.sql":  migrations70_replace_timestamp_trade_aggregations_brin_indexSql,
+	"migrations/71_add_history_contract_events.sql":                      migrations71_add_history_contract_eventsSql,
+	"migrations/72_add_contract_state_tables.sql":                        migrations72_add_contract_state_tablesSql,
+	"migrations/73_add_history_token_transfers.sql":                      migrations73_add_history_token_transfersSql,
+	"migrations/74_add_history_balances.sql":                             migrations74_add_history_balancesSql,
+	"migrations/75_add_history_contract_events_indexes.sql":              migrations75_add_history_contract_events_indexesSql,
 	"migrations/7_modify_trades_table.sql":                               migrations7_modify_trades_tableSql,
 	"migrations/8_add_aggregators.sql":                                   migrations8_add_aggregatorsSql,
 	"migrations/8_create_asset_stats_table.sql":                          migrations8_create_asset_stats_tableSql,
@@ -1857,6 +1967,11 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"69_add_asset_contracts_table.sql":                        {migrations69_add_asset_contracts_tableSql, map[string]*bintree{}},
 		"6_create_assets_table.sql":                               {migrations6_create_assets_tableSql, map[string]*bintree{}},
 		"70_replace_timestamp_trade_aggregations_brin_index.sql":  {migrations70_replace_timestamp_trade_aggregations_brin_indexSql, map[string]*bintree{}},
+		"71_add_history_contract_events.sql":                      {migrations71_add_history_contract_eventsSql, map[string]*bintree{}},
+		"72_add_contract_state_tables.sql":                        {migrations72_add_contract_state_tablesSql, map[string]*bintree{}},
+		"73_add_history_token_transfers.sql":                      {migrations73_add_history_token_transfersSql, map[string]*bintree{}},
+		"74_add_history_balances.sql":                             {migrations74_add_history_balancesSql, map[string]*bintree{}},
+		"75_add_history_contract_events_indexes.sql":              {migrations75_add_history_contract_events_indexesSql, map[string]*bintree{}},
 		"7_modify_trades_table.sql":                               {migrations7_modify_trades_tableSql, map[string]*bintree{}},
 		"8_add_aggregators.sql":                                   {migrations8_add_aggregatorsSql, map[string]*bintree{}},
 		"8_create_asset_stats_table.sql":                          {migrations8_create_asset_stats_tableSql, map[string]*bintree{}},
@@ -0,0 +1,25 @@
+-- +migrate Up
+
+CREATE TABLE history_contract_events (
+    history_operation_id bigint NOT NULL,
+    "order" integer NOT NULL,
+    transaction_hash character(64) NOT NULL,
+    ledger_closed_at timestamp without time zone NOT NULL,
+    contract_id character varying(56),
+    type smallint NOT NULL,
+    topic1 TEXT, -- ScVal XDR in base64
+    topic2 TEXT,
+    topic3 TEXT,
+    topic4 TEXT,
+    value TEXT NOT NULL, -- ScVal XDR in base64
+    PRIMARY KEY (history_operation_id, "order")
+);
+
+/* Supports "select * from history_contract_events where contract_id = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_contract_id" ON history_contract_events USING btree (contract_id, history_operation_id, "order");
+/* Supports "select * from history_contract_events where topic1 = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_topic1" ON history_contract_events USING btree (topic1, history_operation_id, "order");
+
+-- +migrate Down
+
+DROP TABLE history_contract_events cascade;
@@ -0,0 +1,32 @@
+-- +migrate Up
+
+CREATE TABLE contract_data (
+    key_hash character(64) NOT NULL PRIMARY KEY, -- hex encoded sha256 of the ledger key
+    contract_id character varying(56) NOT NULL,
+    durability smallint NOT NULL,
+    key TEXT NOT NULL, -- ScVal XDR in base64
+    value TEXT NOT NULL, -- ScVal XDR in base64
+    last_modified_ledger integer NOT NULL
+);
+
+/* Supports "select * from contract_data where contract_id = ? order by key_hash" */
+CREATE INDEX "contract_data_by_contract_id" ON contract_data USING btree (contract_id, key_hash);
+
+CREATE TABLE contract_code (
+    hash character(64) NOT NULL PRIMARY KEY,
+    key_hash character(64) NOT NULL UNIQUE, -- hex encoded sha256 of the ledger key
+    code TEXT NOT NULL, -- wasm code in base64
+    last_modified_ledger integer NOT NULL
+);
+
+CREATE TABLE contract_ttls (
+    key_hash character(64) NOT NULL PRIMARY KEY, -- hex encoded sha256 of the ledger key
+    live_until_ledger_seq integer NOT NULL,
+    last_modified_ledger integer NOT NULL
+);
+
+-- +migrate Down
+
+DROP TABLE contract_data cascade;
+DROP TABLE contract_code cascade;
+DROP TABLE contract_ttls cascade;
@@ -0,0 +1,25 @@
+-- +migrate Up
+
+CREATE TABLE history_token_transfers (
+    history_operation_id bigint NOT NULL, -- the transaction id for fee events
+    "order" integer NOT NULL,
+    transaction_hash character(64) NOT NULL,
+    ledger_closed_at timestamp without time zone NOT NULL,
+    type smallint NOT NULL,
+    contract_id character varying(56) NOT NULL, -- the token contract, the stellar asset contract for classic assets
+    asset TEXT, -- canonical form of the asset, NULL for custom tokens
+    from_address TEXT,
+    to_address TEXT,
+    amount numeric NOT NULL, -- raw units of the token
+    PRIMARY KEY (history_operation_id, "order")
+);
+
+/* Supports "select * from history_token_transfers where contract_id = ? order by history_operation_id, order" */
+CREATE INDEX "history_token_transfers_by_contract_id" ON history_token_transfers USING btree (contract_id, history_operation_id, "order");
+/* Supports "select * from history_token_transfers where from_address = ? or to_address = ? order by history_operation_id, order" */
+CREATE INDEX "history_token_transfers_by_from_address" ON history_token_transfers USING btree (from_address, history_operation_id, "order");
+CREATE INDEX "history_token_transfers_by_to_address" ON history_token_transfers USING btree (to_address, history_operation_id, "order");
+
+-- +migrate Down
+
+DROP TABLE history_token_transfers cascade;
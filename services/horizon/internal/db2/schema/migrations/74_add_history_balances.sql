@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE history_balances (
+    address character varying(56) NOT NULL, -- the account or contract holding the balance
+    contract_id character varying(56) NOT NULL, -- the stellar asset contract of the asset
+    ledger_sequence integer NOT NULL,
+    ledger_closed_at timestamp without time zone NOT NULL,
+    balance numeric NOT NULL, -- raw units of the asset at the end of the ledger
+    PRIMARY KEY (address, contract_id, ledger_sequence)
+);
+
+/* Supports reaping the balances outside the retention window */
+CREATE INDEX "history_balances_by_ledger_sequence" ON history_balances USING btree (ledger_sequence);
+
+-- +migrate Down
+
+DROP TABLE history_balances cascade;
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+/* Supports "select * from history_contract_events where type = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_type" ON history_contract_events USING btree (type, history_operation_id, "order");
+/* Supports "select * from history_contract_events where topic2 = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_topic2" ON history_contract_events USING btree (topic2, history_operation_id, "order");
+/* Supports "select * from history_contract_events where topic3 = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_topic3" ON history_contract_events USING btree (topic3, history_operation_id, "order");
+/* Supports "select * from history_contract_events where topic4 = ? order by history_operation_id, order" */
+CREATE INDEX "history_contract_events_by_topic4" ON history_contract_events USING btree (topic4, history_operation_id, "order");
+
+-- +migrate Down
+
+DROP INDEX "history_contract_events_by_type";
+DROP INDEX "history_contract_events_by_topic2";
+DROP INDEX "history_contract_events_by_topic3";
+DROP INDEX "history_contract_events_by_topic4";
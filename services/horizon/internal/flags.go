@@ -524,6 +524,22 @@ func Flags() (*Config, support.ConfigOptions) {
 			Usage:          "the maximum number of assets on the path in `/paths` endpoint, warning: increasing this value will increase /paths response time",
 			UsedInCommands: ApiServerCommands,
 		},
+		&support.ConfigOption{
+			Name:           "enable-graphql",
+			ConfigKey:      &config.EnableGraphQL,
+			OptType:        types.Bool,
+			FlagDefault:    false,
+			Usage:          "enables the `/graphql` endpoint, a GraphQL API over accounts, transactions, operations, effects, trades, liquidity pools and claimable balances",
+			UsedInCommands: ApiServerCommands,
+		},
+		&support.ConfigOption{
+			Name:           "graphql-max-query-cost",
+			ConfigKey:      &config.GraphQLMaxQueryCost,
+			OptType:        types.Uint,
+			FlagDefault:    uint(100),
+			Usage:          "the maximum cost of the queries sent to the `/graphql` endpoint, the number of database queries they can run. Queries are charged their cost by the rate limiter, which allows bursts of 100 requests",
+			UsedInCommands: ApiServerCommands,
+		},
 		&support.ConfigOption{
 			Name:           "max-assets-per-path-request",
 			ConfigKey:      &config.MaxAssetsPerPathRequest,
@@ -647,6 +663,14 @@ func Flags() (*Config, support.ConfigOptions) {
 				return nil
 			},
 		},
+		&support.ConfigOption{
+			Name:           "balance-history-retention-count",
+			ConfigKey:      &config.BalanceHistoryRetentionCount,
+			OptType:        types.Uint,
+			FlagDefault:    uint(0),
+			Usage:          "the minimum number of ledgers of balance history to maintain within Horizon's history tables (0 = retain as many ledgers as --history-retention-count)",
+			UsedInCommands: IngestionCommands,
+		},
 		&support.ConfigOption{
 			Name:        "reap-frequency",
 			ConfigKey:   &config.ReapFrequency,
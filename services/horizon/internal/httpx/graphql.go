@@ -0,0 +1,279 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stellar/throttled"
+
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	hProblem "github.com/stellar/go/services/horizon/internal/render/problem"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/problem"
+)
+
+// graphQLRequest is a GraphQL request, sent as the json body of POST requests
+// or as the query parameters of GET requests.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLHandler serves GraphQL queries. Each query is charged its cost, the
+// number of database queries it can run, by the rate limiter of the REST
+// end-points.
+type graphQLHandler struct {
+	schema       graphql.Schema
+	rateLimiter  *throttled.HTTPRateLimiter
+	maxQueryCost int
+}
+
+func newGraphQLHandler(config *RouterConfig, rateLimiter *throttled.HTTPRateLimiter, ledgerState *ledger.State) (graphQLHandler, error) {
+	schema, err := newGraphQLSchema(graphQLResolver{
+		ledgerState: ledgerState,
+		skipTxMeta:  config.SkipTxMeta,
+	})
+	if err != nil {
+		return graphQLHandler{}, errors.Wrap(err, "could not build GraphQL schema")
+	}
+	return graphQLHandler{
+		schema:       schema,
+		rateLimiter:  rateLimiter,
+		maxQueryCost: int(config.GraphQLMaxQueryCost),
+	}, nil
+}
+
+func parseGraphQLRequest(r *http.Request) (graphQLRequest, error) {
+	var request graphQLRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return request, problem.MakeInvalidFieldProblem(
+				"body",
+				errors.New("The body must be a json object with the query, operationName and variables fields"),
+			)
+		}
+		return request, nil
+	}
+
+	query := r.URL.Query()
+	request.Query = query.Get("query")
+	request.OperationName = query.Get("operationName")
+	if variables := query.Get("variables"); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+			return request, problem.MakeInvalidFieldProblem(
+				"variables",
+				errors.New("Variables must be a json object"),
+			)
+		}
+	}
+	return request, nil
+}
+
+func writeGraphQLResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (handler graphQLHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request, err := parseGraphQLRequest(r)
+	if err != nil {
+		problem.Render(ctx, w, err)
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: request.Query})
+	if err != nil {
+		writeGraphQLResult(w, http.StatusBadRequest, &graphql.Result{
+			Errors: gqlerrors.FormatErrors(err),
+		})
+		return
+	}
+	validation := graphql.ValidateDocument(&handler.schema, document, nil)
+	if !validation.IsValid {
+		writeGraphQLResult(w, http.StatusBadRequest, &graphql.Result{
+			Errors: validation.Errors,
+		})
+		return
+	}
+
+	cost := graphQLQueryCost(handler.schema, document, request.OperationName, request.Variables)
+	if cost > handler.maxQueryCost {
+		writeGraphQLResult(w, http.StatusBadRequest, &graphql.Result{
+			Errors: gqlerrors.FormatErrors(fmt.Errorf(
+				"query cost %d exceeds the maximum query cost of %d", cost, handler.maxQueryCost,
+			)),
+		})
+		return
+	}
+	// the request itself was already charged by the rate limiting middleware
+	if handler.rateLimiter != nil && cost > 1 {
+		limited, _, err := handler.rateLimiter.RateLimiter.RateLimit(handler.rateLimiter.VaryBy.Key(r), cost-1)
+		if err != nil {
+			problem.Render(ctx, w, errors.Wrap(err, "RateLimiter error"))
+			return
+		}
+		if limited {
+			problem.Render(ctx, w, hProblem.RateLimitExceeded)
+			return
+		}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        handler.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+	writeGraphQLResult(w, http.StatusOK, result)
+}
+
+// graphQLQueryCost returns the cost of the operation of a validated document:
+// the maximum number of database queries it runs. Each field resolved with a
+// query costs 1 for every parent object it can be resolved for, so the fields
+// nested in a list of records are multiplied by the limit of the list.
+func graphQLQueryCost(schema graphql.Schema, document *ast.Document, operationName string, variables map[string]interface{}) int {
+	fragments := map[string]*ast.FragmentDefinition{}
+	var operation *ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if operationName == "" || (definition.Name != nil && definition.Name.Value == operationName) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return 0
+	}
+
+	// variables which are not set take the default value of their definition
+	values := map[string]interface{}{}
+	for _, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue != nil {
+			values[definition.Variable.Name.Value] = definition.DefaultValue.GetValue()
+		}
+	}
+	for name, value := range variables {
+		values[name] = value
+	}
+
+	cost := graphQLCost{
+		schema:    schema,
+		fragments: fragments,
+		variables: values,
+	}
+	return cost.selectionSet(schema.QueryType(), operation.SelectionSet, 1)
+}
+
+// graphQLMaxCost bounds the cost of queries so that deeply nested lists don't
+// overflow it.
+const graphQLMaxCost = math.MaxInt32
+
+func saturatedGraphQLCost(cost int) int {
+	if cost > graphQLMaxCost {
+		return graphQLMaxCost
+	}
+	return cost
+}
+
+type graphQLCost struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+func (c graphQLCost) selectionSet(parent *graphql.Object, selectionSet *ast.SelectionSet, multiplier int) int {
+	if parent == nil || selectionSet == nil {
+		return 0
+	}
+
+	total := 0
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			total = saturatedGraphQLCost(total + c.field(parent, selection, multiplier))
+		case *ast.InlineFragment:
+			total = saturatedGraphQLCost(total + c.selectionSet(c.fragmentType(parent, selection.TypeCondition), selection.SelectionSet, multiplier))
+		case *ast.FragmentSpread:
+			if fragment, ok := c.fragments[selection.Name.Value]; ok {
+				total = saturatedGraphQLCost(total + c.selectionSet(c.fragmentType(parent, fragment.TypeCondition), fragment.SelectionSet, multiplier))
+			}
+		}
+	}
+	return total
+}
+
+func (c graphQLCost) fragmentType(parent *graphql.Object, condition *ast.Named) *graphql.Object {
+	if condition == nil {
+		return parent
+	}
+	object, _ := c.schema.Type(condition.Name.Value).(*graphql.Object)
+	return object
+}
+
+func (c graphQLCost) field(parent *graphql.Object, field *ast.Field, multiplier int) int {
+	definition, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		// introspection fields
+		return 0
+	}
+	object, ok := graphql.GetNamed(definition.Type).(*graphql.Object)
+	if !ok {
+		return 0
+	}
+
+	cost := 0
+	// the nested objects of a record are resolved from the record itself
+	if definition.Resolve != nil {
+		cost = multiplier
+		if _, isList := definition.Type.(*graphql.List); isList {
+			multiplier = saturatedGraphQLCost(multiplier * c.limit(field))
+		}
+	}
+	return saturatedGraphQLCost(cost + c.selectionSet(object, field.SelectionSet, multiplier))
+}
+
+func (c graphQLCost) limit(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+		var limit int
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			limit, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			switch variable := c.variables[value.Name.Value].(type) {
+			case float64:
+				limit = int(variable)
+			case int:
+				limit = variable
+			case string:
+				// default values of the variable definitions
+				limit, _ = strconv.Atoi(variable)
+			case nil:
+				limit = db2.DefaultPageSize
+			}
+		}
+		if limit < 1 || limit > db2.MaxPageSize {
+			// invalid limits are rejected by the resolvers
+			return 1
+		}
+		return limit
+	}
+	return db2.DefaultPageSize
+}
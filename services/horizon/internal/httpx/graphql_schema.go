@@ -0,0 +1,1136 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/actions"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/resourceadapter"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/xdr"
+)
+
+// graphQLOperationIDKey holds the id of the operation of effects and trades,
+// which is not part of their json representation, in their GraphQL objects.
+const graphQLOperationIDKey = "__operation_id"
+
+// graphQLError is the error returned by GraphQL resolvers. Its extensions hold
+// the type and status of the problem the REST end-points would render.
+type graphQLError struct {
+	message string
+	problem problem.P
+}
+
+func (e graphQLError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e graphQLError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"type":   e.problem.Type,
+		"status": e.problem.Status,
+	}
+	for key, value := range e.problem.Extras {
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// newGraphQLError maps err to the problem rendered by the REST end-points.
+// Unknown errors are logged and replaced by a server error.
+func newGraphQLError(ctx context.Context, err error) error {
+	var p problem.P
+	message := ""
+	switch cause := errors.Cause(err).(type) {
+	case problem.P:
+		p = cause
+	case *problem.P:
+		p = *cause
+	default:
+		if known, ok := problem.IsKnownError(err).(problem.P); ok {
+			p = known
+			if p.Status < 500 {
+				message = fmt.Sprintf("%s: %s", p.Title, cause.Error())
+			}
+		} else {
+			log.Ctx(ctx).WithStack(err).Error(err)
+			p = problem.ServerError
+		}
+	}
+	if message == "" {
+		message = p.Title
+		if reason, ok := p.Extras["reason"]; ok {
+			message = fmt.Sprintf("%s: invalid %v: %v", p.Title, p.Extras["invalid_field"], reason)
+		}
+	}
+	return graphQLError{message: message, problem: p}
+}
+
+// graphQLArgValidators validates the id arguments of the GraphQL fields with
+// the validators of the matching REST query parameters.
+var graphQLArgValidators = map[string]struct {
+	tag    string
+	reason string
+}{
+	"account_id":           {"accountID", "Account ID must start with `G` and contain 56 alphanum characters"},
+	"transaction_hash":     {"transactionHash", "Transaction hash must be a hex-encoded, lowercase SHA-256 hash"},
+	"liquidity_pool_id":    {"sha256", "Liquidity pool ID must be a hex-encoded SHA-256 hash"},
+	"claimable_balance_id": {"claimableBalanceID", "Claimable Balance ID must be the hex-encoded XDR representation of a Claimable Balance ID"},
+}
+
+func validateGraphQLArg(arg, kind, value string) error {
+	validator := graphQLArgValidators[kind]
+	if !govalidator.TagMap[validator.tag](value) {
+		return problem.MakeInvalidFieldProblem(arg, errors.New(validator.reason))
+	}
+	return nil
+}
+
+// graphQLObject returns the json representation of a resource, without its
+// links, which is resolved by the default resolvers of the GraphQL fields.
+func graphQLObject(resource interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal resource")
+	}
+	var object map[string]interface{}
+	if err = json.Unmarshal(raw, &object); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal resource")
+	}
+	delete(object, "_links")
+	return object, nil
+}
+
+// graphQLFilter restricts the records returned by a GraphQL list field to the
+// ones of its parent object.
+type graphQLFilter struct {
+	account          string
+	transaction      string
+	operation        int64
+	liquidityPool    string
+	claimableBalance string
+}
+
+// graphQLResolver resolves the fields of the GraphQL schema with the history
+// queries and the resource adapters of the REST end-points.
+type graphQLResolver struct {
+	ledgerState *ledger.State
+	skipTxMeta  bool
+}
+
+func (r graphQLResolver) historyQ(ctx context.Context) (*history.Q, error) {
+	session, ok := ctx.Value(&horizonContext.SessionContextKey).(db.SessionInterface)
+	if !ok {
+		return nil, errors.New("missing session in request context")
+	}
+	return &history.Q{SessionInterface: session}, nil
+}
+
+func (r graphQLResolver) pageQuery(p graphql.ResolveParams) (db2.PageQuery, error) {
+	cursor, _ := p.Args["cursor"].(string)
+	order, _ := p.Args["order"].(string)
+	limit, _ := p.Args["limit"].(int)
+	if limit < 0 {
+		return db2.PageQuery{}, db2.ErrInvalidLimit
+	}
+	return db2.NewPageQuery(cursor, false, order, uint64(limit))
+}
+
+func (r graphQLResolver) oldestLedger() int32 {
+	return r.ledgerState.CurrentStatus().HistoryElder
+}
+
+func (r graphQLResolver) loadLedgers(ctx context.Context, q *history.Q, sequences []int32) (map[int32]history.Ledger, error) {
+	ledgerCache := history.LedgerCache{}
+	for _, sequence := range sequences {
+		ledgerCache.Queue(sequence)
+	}
+	if err := ledgerCache.Load(ctx, q); err != nil {
+		return nil, errors.Wrap(err, "failed to load ledger batch")
+	}
+	return ledgerCache.Records, nil
+}
+
+func (r graphQLResolver) account(ctx context.Context, accountID string) (interface{}, error) {
+	if accountID == "" {
+		return nil, nil
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account, err := actions.AccountInfo(ctx, q, accountID)
+	if q.NoRows(errors.Cause(err)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return graphQLObject(account)
+}
+
+func (r graphQLResolver) transaction(ctx context.Context, hash string) (interface{}, error) {
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var record history.Transaction
+	err = q.TransactionByHash(ctx, &record, hash)
+	if q.NoRows(errors.Cause(err)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "loading transaction record")
+	}
+	var resource horizon.Transaction
+	if err = resourceadapter.PopulateTransaction(ctx, hash, &resource, record, r.skipTxMeta); err != nil {
+		return nil, errors.Wrap(err, "could not populate transaction")
+	}
+	return graphQLObject(resource)
+}
+
+func (r graphQLResolver) transactions(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := q.Transactions()
+	switch {
+	case filter.account != "":
+		txs.ForAccount(ctx, filter.account)
+	case filter.liquidityPool != "":
+		txs.ForLiquidityPool(ctx, filter.liquidityPool)
+	case filter.claimableBalance != "":
+		txs.ForClaimableBalance(ctx, filter.claimableBalance)
+	}
+	if includeFailed, _ := p.Args["include_failed"].(bool); includeFailed {
+		txs.IncludeFailed()
+	}
+
+	var records []history.Transaction
+	err = txs.Page(pq, r.oldestLedger()).Select(ctx, &records)
+	if q.NoRows(errors.Cause(err)) {
+		return []interface{}{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "executing transaction records query")
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		var resource horizon.Transaction
+		if err = resourceadapter.PopulateTransaction(ctx, record.TransactionHash, &resource, record, r.skipTxMeta); err != nil {
+			return nil, errors.Wrap(err, "could not populate transaction")
+		}
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+func (r graphQLResolver) operation(ctx context.Context, id int64) (interface{}, error) {
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record, _, err := q.OperationByID(ctx, false, id)
+	if q.NoRows(errors.Cause(err)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	objects, err := r.operationObjects(ctx, q, []history.Operation{record})
+	if err != nil {
+		return nil, err
+	}
+	return objects[0], nil
+}
+
+func (r graphQLResolver) operations(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := q.Operations()
+	switch {
+	case filter.account != "":
+		query.ForAccount(ctx, filter.account)
+	case filter.transaction != "":
+		query.ForTransaction(ctx, filter.transaction)
+	case filter.liquidityPool != "":
+		query.ForLiquidityPool(ctx, filter.liquidityPool)
+	case filter.claimableBalance != "":
+		query.ForClaimableBalance(ctx, filter.claimableBalance)
+	}
+	// the operations of a transaction are returned whether it failed or not,
+	// like in the REST end-points
+	if includeFailed, _ := p.Args["include_failed"].(bool); includeFailed || filter.transaction != "" {
+		query.IncludeFailed()
+	}
+
+	records, _, err := query.Page(pq, r.oldestLedger()).Fetch(ctx)
+	if q.NoRows(errors.Cause(err)) {
+		return []interface{}{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return r.operationObjects(ctx, q, records)
+}
+
+// operationObjects returns the GraphQL objects of the operations. The details
+// of each operation type are resolved by the details field.
+func (r graphQLResolver) operationObjects(ctx context.Context, q *history.Q, records []history.Operation) ([]interface{}, error) {
+	sequences := make([]int32, 0, len(records))
+	for _, record := range records {
+		sequences = append(sequences, record.LedgerSequence())
+	}
+	ledgers, err := r.loadLedgers(ctx, q, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		ledger, found := ledgers[record.LedgerSequence()]
+		if !found {
+			return nil, errors.Errorf("could not find ledger data for sequence %d", record.LedgerSequence())
+		}
+		resource, err := resourceadapter.NewOperation(ctx, record, record.TransactionHash, nil, ledger, r.skipTxMeta)
+		if err != nil {
+			return nil, err
+		}
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+func (r graphQLResolver) effects(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []history.Effect
+	switch {
+	case filter.account != "":
+		records, err = q.EffectsForAccount(ctx, filter.account, pq, r.oldestLedger())
+	case filter.liquidityPool != "":
+		records, err = q.EffectsForLiquidityPool(ctx, filter.liquidityPool, pq, r.oldestLedger())
+	case filter.operation > 0:
+		records, err = q.EffectsForOperation(ctx, filter.operation, pq)
+	case filter.transaction != "":
+		records, err = q.EffectsForTransaction(ctx, filter.transaction, pq)
+	default:
+		records, err = q.Effects(ctx, pq, r.oldestLedger())
+	}
+	if q.NoRows(errors.Cause(err)) {
+		return []interface{}{}, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "loading effect records")
+	}
+
+	sequences := make([]int32, 0, len(records))
+	for _, record := range records {
+		sequences = append(sequences, record.LedgerSequence())
+	}
+	ledgers, err := r.loadLedgers(ctx, q, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		resource, err := resourceadapter.NewEffect(ctx, record, ledgers[record.LedgerSequence()])
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create effect")
+		}
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		object[graphQLOperationIDKey] = record.HistoryOperationID
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+func (r graphQLResolver) trades(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	tradeType, _ := p.Args["trade_type"].(string)
+	if filter.liquidityPool == "" && !govalidator.TagMap["tradeType"](tradeType) {
+		return nil, problem.MakeInvalidFieldProblem(
+			"trade_type",
+			errors.New("Trade type must be all, orderbook, or liquidity_pool"),
+		)
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []history.Trade
+	if filter.liquidityPool != "" {
+		records, err = q.GetTradesForLiquidityPool(ctx, pq, r.oldestLedger(), filter.liquidityPool)
+	} else {
+		records, err = q.GetTrades(ctx, pq, r.oldestLedger(), filter.account, tradeType)
+	}
+	if q.NoRows(errors.Cause(err)) {
+		return []interface{}{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		var resource horizon.Trade
+		resourceadapter.PopulateTrade(ctx, &resource, record)
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		object[graphQLOperationIDKey] = record.HistoryOperationID
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+func (r graphQLResolver) liquidityPool(ctx context.Context, id string) (interface{}, error) {
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record, err := q.FindLiquidityPoolByID(ctx, id)
+	if q.NoRows(errors.Cause(err)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	objects, err := r.liquidityPoolObjects(ctx, q, []history.LiquidityPool{record})
+	if err != nil {
+		return nil, err
+	}
+	return objects[0], nil
+}
+
+func (r graphQLResolver) liquidityPools(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	query := history.LiquidityPoolsQuery{
+		PageQuery: pq,
+		Account:   filter.account,
+	}
+	if reserves, _ := p.Args["reserves"].([]interface{}); len(reserves) > 0 {
+		canonical := make([]string, 0, len(reserves))
+		for _, reserve := range reserves {
+			canonical = append(canonical, fmt.Sprint(reserve))
+		}
+		query.Assets, err = xdr.BuildAssets(strings.Join(canonical, ","))
+		if err != nil {
+			return nil, problem.MakeInvalidFieldProblem(
+				"reserves",
+				errors.New("Invalid reserves, should be a list of assets in canonical form"),
+			)
+		}
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := q.GetLiquidityPools(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return r.liquidityPoolObjects(ctx, q, records)
+}
+
+func (r graphQLResolver) liquidityPoolObjects(ctx context.Context, q *history.Q, records []history.LiquidityPool) ([]interface{}, error) {
+	sequences := make([]int32, 0, len(records))
+	for _, record := range records {
+		sequences = append(sequences, int32(record.LastModifiedLedger))
+	}
+	ledgers, err := r.loadLedgers(ctx, q, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		var ledger *history.Ledger
+		if l, ok := ledgers[int32(record.LastModifiedLedger)]; ok {
+			ledger = &l
+		}
+		var resource horizon.LiquidityPool
+		if err = resourceadapter.PopulateLiquidityPool(ctx, &resource, record, ledger); err != nil {
+			return nil, err
+		}
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+func (r graphQLResolver) claimableBalance(ctx context.Context, id string) (interface{}, error) {
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+	record, err := q.FindClaimableBalanceByID(ctx, id)
+	if q.NoRows(errors.Cause(err)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	objects, err := r.claimableBalanceObjects(ctx, q, []history.ClaimableBalance{record})
+	if err != nil {
+		return nil, err
+	}
+	return objects[0], nil
+}
+
+func (r graphQLResolver) claimableBalances(p graphql.ResolveParams, filter graphQLFilter) (interface{}, error) {
+	ctx := p.Context
+	pq, err := r.pageQuery(p)
+	if err != nil {
+		return nil, err
+	}
+	query := history.ClaimableBalancesQuery{PageQuery: pq}
+	if filter.account != "" {
+		query.Claimant = xdr.MustAddressPtr(filter.account)
+	}
+	if asset, _ := p.Args["asset"].(string); asset != "" {
+		assets, err := xdr.BuildAssets(asset)
+		if err != nil || len(assets) != 1 {
+			return nil, problem.MakeInvalidFieldProblem(
+				"asset",
+				errors.New("Asset must be the string \"native\" or a string of the form \"Code:IssuerAccountID\" for issued assets."),
+			)
+		}
+		query.Asset = &assets[0]
+	}
+	for arg, dest := range map[string]**xdr.AccountId{"sponsor": &query.Sponsor, "claimant": &query.Claimant} {
+		if address, _ := p.Args[arg].(string); address != "" {
+			if err = validateGraphQLArg(arg, "account_id", address); err != nil {
+				return nil, err
+			}
+			*dest = xdr.MustAddressPtr(address)
+		}
+	}
+	if _, _, err = query.Cursor(); err != nil {
+		return nil, problem.MakeInvalidFieldProblem(
+			"cursor",
+			errors.New("The first part should be a number higher than 0 and the second part should be a valid claimable balance ID"),
+		)
+	}
+	q, err := r.historyQ(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := q.GetClaimableBalances(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return r.claimableBalanceObjects(ctx, q, records)
+}
+
+func (r graphQLResolver) claimableBalanceObjects(ctx context.Context, q *history.Q, records []history.ClaimableBalance) ([]interface{}, error) {
+	sequences := make([]int32, 0, len(records))
+	for _, record := range records {
+		sequences = append(sequences, int32(record.LastModifiedLedger))
+	}
+	ledgers, err := r.loadLedgers(ctx, q, sequences)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		var ledger *history.Ledger
+		if l, ok := ledgers[int32(record.LastModifiedLedger)]; ok {
+			ledger = &l
+		}
+		var resource horizon.ClaimableBalance
+		if err = resourceadapter.PopulateClaimableBalance(ctx, &resource, record, ledger); err != nil {
+			return nil, err
+		}
+		object, err := graphQLObject(resource)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, object)
+	}
+	return result, nil
+}
+
+// graphQLJSON is a scalar holding arbitrary json values, used for the fields
+// whose shape depends on their type, like the details of operations and
+// effects.
+var graphQLJSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "An arbitrary json value.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		return valueAST.GetValue()
+	},
+})
+
+func graphQLScalarFields(scalar graphql.Output, names ...string) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, name := range names {
+		fields[name] = &graphql.Field{Type: scalar}
+	}
+	return fields
+}
+
+func mergeGraphQLFields(fieldSets ...graphql.Fields) graphql.Fields {
+	fields := graphql.Fields{}
+	for _, fieldSet := range fieldSets {
+		for name, field := range fieldSet {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+func graphQLSource(p graphql.ResolveParams, key string) string {
+	source, _ := p.Source.(map[string]interface{})
+	value, _ := source[key].(string)
+	return value
+}
+
+// resolve wraps the resolvers of the fields backed by database queries,
+// converting their errors to GraphQL errors. The query cost of a request is
+// computed from these fields.
+func (r graphQLResolver) resolve(fn graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := fn(p)
+		if err != nil {
+			return nil, newGraphQLError(p.Context, err)
+		}
+		return result, nil
+	}
+}
+
+// list returns a field returning a page of records, with the same paging
+// arguments as the REST end-points.
+func (r graphQLResolver) list(
+	itemType *graphql.Object,
+	description string,
+	extraArgs graphql.FieldConfigArgument,
+	fn func(graphql.ResolveParams) (interface{}, error),
+) *graphql.Field {
+	args := graphql.FieldConfigArgument{
+		"cursor": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The paging token of the record preceding the page.",
+		},
+		"order": &graphql.ArgumentConfig{
+			Type:         graphql.String,
+			DefaultValue: db2.OrderAscending,
+			Description:  "The order of the records, asc or desc.",
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: int(db2.DefaultPageSize),
+			Description:  fmt.Sprintf("The maximum number of records, at most %d.", db2.MaxPageSize),
+		},
+	}
+	for name, arg := range extraArgs {
+		args[name] = arg
+	}
+	return &graphql.Field{
+		Type:        graphql.NewList(itemType),
+		Description: description,
+		Args:        args,
+		Resolve:     r.resolve(fn),
+	}
+}
+
+var graphQLIncludeFailedArg = graphql.FieldConfigArgument{
+	"include_failed": &graphql.ArgumentConfig{
+		Type:         graphql.Boolean,
+		DefaultValue: false,
+		Description:  "Whether to include failed transactions.",
+	},
+}
+
+var graphQLTradeTypeArg = graphql.FieldConfigArgument{
+	"trade_type": &graphql.ArgumentConfig{
+		Type:         graphql.String,
+		DefaultValue: history.AllTrades,
+		Description:  "The type of trades, all, orderbook or liquidity_pool.",
+	},
+}
+
+// newGraphQLSchema builds the schema of the GraphQL end-point. The fields of
+// the objects are named after the json fields of the REST resources.
+func newGraphQLSchema(r graphQLResolver) (graphql.Schema, error) {
+	var account, transaction, operation, effect, trade, liquidityPool, claimableBalance *graphql.Object
+
+	idArg := func(description string) graphql.FieldConfigArgument {
+		return graphql.FieldConfigArgument{
+			"id": &graphql.ArgumentConfig{
+				Type:        graphql.NewNonNull(graphql.String),
+				Description: description,
+			},
+		}
+	}
+	accountField := func(key string) *graphql.Field {
+		return &graphql.Field{
+			Type:        account,
+			Description: "The account, null if it was removed.",
+			Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+				return r.account(p.Context, graphQLSource(p, key))
+			}),
+		}
+	}
+	operationField := func() *graphql.Field {
+		return &graphql.Field{
+			Type:        operation,
+			Description: "The operation which produced the record.",
+			Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+				source, _ := p.Source.(map[string]interface{})
+				id, _ := source[graphQLOperationIDKey].(int64)
+				return r.operation(p.Context, id)
+			}),
+		}
+	}
+	detailsField := &graphql.Field{
+		Type:        graphQLJSON,
+		Description: "The json representation of the record in the REST end-points.",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			source, _ := p.Source.(map[string]interface{})
+			details := map[string]interface{}{}
+			for key, value := range source {
+				if key != graphQLOperationIDKey {
+					details[key] = value
+				}
+			}
+			return details, nil
+		},
+	}
+
+	balance := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Balance",
+		Fields: mergeGraphQLFields(
+			graphQLScalarFields(graphql.String,
+				"balance", "liquidity_pool_id", "limit", "buying_liabilities", "selling_liabilities",
+				"sponsor", "asset_type", "asset_code", "asset_issuer",
+			),
+			graphQLScalarFields(graphql.Int, "last_modified_ledger"),
+			graphQLScalarFields(graphql.Boolean,
+				"is_authorized", "is_authorized_to_maintain_liabilities", "is_clawback_enabled",
+			),
+		),
+	})
+	signer := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Signer",
+		Fields: mergeGraphQLFields(
+			graphQLScalarFields(graphql.String, "key", "type", "sponsor"),
+			graphQLScalarFields(graphql.Int, "weight"),
+		),
+	})
+	thresholds := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "AccountThresholds",
+		Fields: graphQLScalarFields(graphql.Int, "low_threshold", "med_threshold", "high_threshold"),
+	})
+	flags := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AccountFlags",
+		Fields: graphQLScalarFields(graphql.Boolean,
+			"auth_required", "auth_revocable", "auth_immutable", "auth_clawback_enabled",
+		),
+	})
+
+	account = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Account",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			forAccount := func(p graphql.ResolveParams) graphQLFilter {
+				return graphQLFilter{account: graphQLSource(p, "account_id")}
+			}
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "account_id", "sequence", "sequence_time", "inflation_destination", "home_domain",
+					"last_modified_time", "sponsor", "paging_token",
+				),
+				graphQLScalarFields(graphql.Int,
+					"sequence_ledger", "subentry_count", "last_modified_ledger", "num_sponsoring", "num_sponsored",
+				),
+				graphql.Fields{
+					"thresholds": &graphql.Field{Type: thresholds},
+					"flags":      &graphql.Field{Type: flags},
+					"balances":   &graphql.Field{Type: graphql.NewList(balance)},
+					"signers":    &graphql.Field{Type: graphql.NewList(signer)},
+					"data":       &graphql.Field{Type: graphQLJSON},
+					"transactions": r.list(transaction, "The transactions of the account.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.transactions(p, forAccount(p))
+						}),
+					"operations": r.list(operation, "The operations of the account.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.operations(p, forAccount(p))
+						}),
+					"effects": r.list(effect, "The effects of the account.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.effects(p, forAccount(p))
+						}),
+					"trades": r.list(trade, "The trades of the account.", graphQLTradeTypeArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.trades(p, forAccount(p))
+						}),
+					"liquidity_pools": r.list(liquidityPool, "The liquidity pools the account holds shares of.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.liquidityPools(p, forAccount(p))
+						}),
+					"claimable_balances": r.list(claimableBalance, "The claimable balances the account can claim.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.claimableBalances(p, forAccount(p))
+						}),
+				},
+			)
+		}),
+	})
+
+	transaction = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			forTransaction := func(p graphql.ResolveParams) graphQLFilter {
+				return graphQLFilter{transaction: graphQLSource(p, "hash")}
+			}
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "hash", "created_at", "source_account", "account_muxed", "account_muxed_id",
+					"source_account_sequence", "fee_account", "fee_account_muxed", "fee_account_muxed_id",
+					"fee_charged", "max_fee", "envelope_xdr", "result_xdr", "result_meta_xdr", "fee_meta_xdr",
+					"memo_type", "memo_bytes", "memo",
+				),
+				graphQLScalarFields(graphql.Int, "ledger", "operation_count"),
+				graphQLScalarFields(graphQLJSON, "preconditions", "fee_bump_transaction", "inner_transaction"),
+				graphql.Fields{
+					"successful": &graphql.Field{Type: graphql.Boolean},
+					"signatures": &graphql.Field{Type: graphql.NewList(graphql.String)},
+					"account":    accountField("source_account"),
+					"operations": r.list(operation, "The operations of the transaction.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.operations(p, forTransaction(p))
+						}),
+					"effects": r.list(effect, "The effects of the transaction.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.effects(p, forTransaction(p))
+						}),
+				},
+			)
+		}),
+	})
+
+	operation = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Operation",
+		Description: "An operation. The fields specific to its type are in details.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "source_account", "source_account_muxed", "source_account_muxed_id",
+					"type", "created_at", "transaction_hash",
+				),
+				graphql.Fields{
+					"type_i":                 &graphql.Field{Type: graphql.Int},
+					"transaction_successful": &graphql.Field{Type: graphql.Boolean},
+					"details":                detailsField,
+					"account":                accountField("source_account"),
+					"transaction": &graphql.Field{
+						Type: transaction,
+						Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+							return r.transaction(p.Context, graphQLSource(p, "transaction_hash"))
+						}),
+					},
+					"effects": r.list(effect, "The effects of the operation.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							id, err := strconv.ParseInt(graphQLSource(p, "id"), 10, 64)
+							if err != nil {
+								return nil, errors.Wrap(err, "could not parse operation id")
+							}
+							return r.effects(p, graphQLFilter{operation: id})
+						}),
+				},
+			)
+		}),
+	})
+
+	effect = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Effect",
+		Description: "An effect. The fields specific to its type are in details.",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "account", "account_muxed", "account_muxed_id", "type", "created_at",
+				),
+				graphql.Fields{
+					"type_i":    &graphql.Field{Type: graphql.Int},
+					"details":   detailsField,
+					"operation": operationField(),
+					// the account field holds the address of the account
+					"account_entry": accountField("account"),
+				},
+			)
+		}),
+	})
+
+	tradePrice := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "TradePrice",
+		Fields: graphQLScalarFields(graphql.String, "n", "d"),
+	})
+	trade = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Trade",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "ledger_close_time", "offer_id", "trade_type",
+					"base_liquidity_pool_id", "base_offer_id", "base_account", "base_amount",
+					"base_asset_type", "base_asset_code", "base_asset_issuer",
+					"counter_liquidity_pool_id", "counter_offer_id", "counter_account", "counter_amount",
+					"counter_asset_type", "counter_asset_code", "counter_asset_issuer",
+				),
+				graphql.Fields{
+					"liquidity_pool_fee_bp": &graphql.Field{Type: graphql.Int},
+					"base_is_seller":        &graphql.Field{Type: graphql.Boolean},
+					"price":                 &graphql.Field{Type: tradePrice},
+					"operation":             operationField(),
+				},
+			)
+		}),
+	})
+
+	reserve := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "LiquidityPoolReserve",
+		Fields: graphQLScalarFields(graphql.String, "asset", "amount"),
+	})
+	liquidityPool = graphql.NewObject(graphql.ObjectConfig{
+		Name: "LiquidityPool",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			forPool := func(p graphql.ResolveParams) graphQLFilter {
+				return graphQLFilter{liquidityPool: graphQLSource(p, "id")}
+			}
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "type", "total_trustlines", "total_shares", "last_modified_time",
+				),
+				graphQLScalarFields(graphql.Int, "fee_bp", "last_modified_ledger"),
+				graphql.Fields{
+					"reserves": &graphql.Field{Type: graphql.NewList(reserve)},
+					"transactions": r.list(transaction, "The transactions of the liquidity pool.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.transactions(p, forPool(p))
+						}),
+					"operations": r.list(operation, "The operations of the liquidity pool.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.operations(p, forPool(p))
+						}),
+					"effects": r.list(effect, "The effects of the liquidity pool.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.effects(p, forPool(p))
+						}),
+					"trades": r.list(trade, "The trades of the liquidity pool.", nil,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.trades(p, forPool(p))
+						}),
+				},
+			)
+		}),
+	})
+
+	claimant := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Claimant",
+		Fields: graphql.Fields{
+			"destination": &graphql.Field{Type: graphql.String},
+			"predicate":   &graphql.Field{Type: graphQLJSON},
+		},
+	})
+	claimableBalanceFlags := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "ClaimableBalanceFlags",
+		Fields: graphQLScalarFields(graphql.Boolean, "clawback_enabled"),
+	})
+	claimableBalance = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ClaimableBalance",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			forBalance := func(p graphql.ResolveParams) graphQLFilter {
+				return graphQLFilter{claimableBalance: graphQLSource(p, "id")}
+			}
+			return mergeGraphQLFields(
+				graphQLScalarFields(graphql.String,
+					"id", "paging_token", "asset", "amount", "sponsor", "last_modified_time",
+				),
+				graphql.Fields{
+					"last_modified_ledger": &graphql.Field{Type: graphql.Int},
+					"claimants":            &graphql.Field{Type: graphql.NewList(claimant)},
+					"flags":                &graphql.Field{Type: claimableBalanceFlags},
+					"transactions": r.list(transaction, "The transactions of the claimable balance.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.transactions(p, forBalance(p))
+						}),
+					"operations": r.list(operation, "The operations of the claimable balance.", graphQLIncludeFailedArg,
+						func(p graphql.ResolveParams) (interface{}, error) {
+							return r.operations(p, forBalance(p))
+						}),
+				},
+			)
+		}),
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"account": &graphql.Field{
+				Type: account,
+				Args: idArg("The address of the account."),
+				Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := validateGraphQLArg("id", "account_id", id); err != nil {
+						return nil, err
+					}
+					return r.account(p.Context, id)
+				}),
+			},
+			"transaction": &graphql.Field{
+				Type: transaction,
+				Args: idArg("The hash of the transaction."),
+				Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := validateGraphQLArg("id", "transaction_hash", id); err != nil {
+						return nil, err
+					}
+					return r.transaction(p.Context, id)
+				}),
+			},
+			"transactions": r.list(transaction, "All the transactions.", graphQLIncludeFailedArg,
+				func(p graphql.ResolveParams) (interface{}, error) {
+					return r.transactions(p, graphQLFilter{})
+				}),
+			"operation": &graphql.Field{
+				Type: operation,
+				Args: idArg("The id of the operation."),
+				Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := strconv.ParseInt(p.Args["id"].(string), 10, 64)
+					if err != nil || id <= 0 {
+						return nil, problem.MakeInvalidFieldProblem(
+							"id",
+							errors.New("Operation ID must be an integer higher than 0"),
+						)
+					}
+					return r.operation(p.Context, id)
+				}),
+			},
+			"operations": r.list(operation, "All the operations.", graphQLIncludeFailedArg,
+				func(p graphql.ResolveParams) (interface{}, error) {
+					return r.operations(p, graphQLFilter{})
+				}),
+			"effects": r.list(effect, "All the effects.", nil,
+				func(p graphql.ResolveParams) (interface{}, error) {
+					return r.effects(p, graphQLFilter{})
+				}),
+			"trades": r.list(trade, "All the trades.", graphQLTradeTypeArg,
+				func(p graphql.ResolveParams) (interface{}, error) {
+					return r.trades(p, graphQLFilter{})
+				}),
+			"liquidity_pool": &graphql.Field{
+				Type: liquidityPool,
+				Args: idArg("The id of the liquidity pool."),
+				Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := validateGraphQLArg("id", "liquidity_pool_id", id); err != nil {
+						return nil, err
+					}
+					return r.liquidityPool(p.Context, id)
+				}),
+			},
+			"liquidity_pools": r.list(liquidityPool, "All the liquidity pools.", graphql.FieldConfigArgument{
+				"reserves": &graphql.ArgumentConfig{
+					Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+					Description: "The assets of the reserves of the pools, in canonical form.",
+				},
+			}, func(p graphql.ResolveParams) (interface{}, error) {
+				return r.liquidityPools(p, graphQLFilter{})
+			}),
+			"claimable_balance": &graphql.Field{
+				Type: claimableBalance,
+				Args: idArg("The id of the claimable balance."),
+				Resolve: r.resolve(func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(string)
+					if err := validateGraphQLArg("id", "claimable_balance_id", id); err != nil {
+						return nil, err
+					}
+					return r.claimableBalance(p.Context, id)
+				}),
+			},
+			"claimable_balances": r.list(claimableBalance, "All the claimable balances.", graphql.FieldConfigArgument{
+				"asset": &graphql.ArgumentConfig{
+					Type:        graphql.String,
+					Description: "The asset of the claimable balances, in canonical form.",
+				},
+				"sponsor": &graphql.ArgumentConfig{
+					Type:        graphql.String,
+					Description: "The sponsor of the claimable balances.",
+				},
+				"claimant": &graphql.ArgumentConfig{
+					Type:        graphql.String,
+					Description: "An account which can claim the claimable balances.",
+				},
+			}, func(p graphql.ResolveParams) (interface{}, error) {
+				return r.claimableBalances(p, graphQLFilter{})
+			}),
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
@@ -0,0 +1,226 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stellar/throttled"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/ledger"
+	"github.com/stellar/go/services/horizon/internal/test"
+)
+
+func newTestGraphQLHandler(t *testing.T, maxQueryCost uint, rateQuota *throttled.RateQuota) graphQLHandler {
+	var rateLimiter *throttled.HTTPRateLimiter
+	if rateQuota != nil {
+		var err error
+		rateLimiter, err = newRateLimiter(rateQuota)
+		require.NoError(t, err)
+	}
+	handler, err := newGraphQLHandler(
+		&RouterConfig{GraphQLMaxQueryCost: maxQueryCost},
+		rateLimiter,
+		&ledger.State{},
+	)
+	require.NoError(t, err)
+	return handler
+}
+
+func TestGraphQLQueryCost(t *testing.T) {
+	handler := newTestGraphQLHandler(t, 100, nil)
+	account := "GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON"
+
+	for _, testCase := range []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		cost      int
+	}{
+		{
+			name:  "record",
+			query: `{ account(id: "` + account + `") { id balances { balance } thresholds { low_threshold } } }`,
+			cost:  1,
+		},
+		{
+			name:  "nested list with default limit",
+			query: `{ account(id: "` + account + `") { operations { id effects { id } } } }`,
+			cost:  1 + 1 + 10,
+		},
+		{
+			name:  "nested lists with limits",
+			query: `{ transactions(limit: 50) { id account { id } operations(limit: 5) { id } } }`,
+			cost:  1 + 50 + 50,
+		},
+		{
+			name:  "limit variable default",
+			query: `query Effects($limit: Int = 20) { effects(limit: $limit) { operation { id } } }`,
+			cost:  1 + 20,
+		},
+		{
+			name:      "limit variable",
+			query:     `query Effects($limit: Int = 20) { effects(limit: $limit) { operation { id } } }`,
+			variables: map[string]interface{}{"limit": float64(5)},
+			cost:      1 + 5,
+		},
+		{
+			name:  "fragments",
+			query: `{ trades { ...trade } effects { ... on Effect { operation { id } } } } fragment trade on Trade { operation { id } }`,
+			cost:  1 + 10 + 1 + 10,
+		},
+		{
+			name:  "introspection",
+			query: `{ __schema { types { name } } }`,
+			cost:  0,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			document, err := parser.Parse(parser.ParseParams{Source: testCase.query})
+			require.NoError(t, err)
+			validation := graphql.ValidateDocument(&handler.schema, document, nil)
+			require.True(t, validation.IsValid, validation.Errors)
+			assert.Equal(t, testCase.cost, graphQLQueryCost(handler.schema, document, "", testCase.variables))
+		})
+	}
+
+	// deeply nested lists don't overflow the cost
+	query := "{ transactions(limit: 200) { id } }"
+	for i := 0; i < 8; i++ {
+		query = strings.Replace(query, "{ id }", "{ operations(limit: 200) { transaction { id } } }", 1)
+	}
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	require.NoError(t, err)
+	assert.Equal(t, graphQLMaxCost, graphQLQueryCost(handler.schema, document, "", nil))
+}
+
+func TestGraphQLHandlerRejectsRequests(t *testing.T) {
+	handler := newTestGraphQLHandler(t, 20, &throttled.RateQuota{
+		MaxRate:  throttled.PerHour(10),
+		MaxBurst: 5,
+	})
+
+	for _, testCase := range []struct {
+		name    string
+		request *http.Request
+		status  int
+		body    string
+	}{
+		{
+			name:    "invalid body",
+			request: httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{")),
+			status:  http.StatusBadRequest,
+			body:    "The body must be a json object",
+		},
+		{
+			name:    "invalid variables",
+			request: httptest.NewRequest(http.MethodGet, "/graphql?query=%7B__typename%7D&variables=1", nil),
+			status:  http.StatusBadRequest,
+			body:    "Variables must be a json object",
+		},
+		{
+			name:    "syntax error",
+			request: httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ account("}`)),
+			status:  http.StatusBadRequest,
+			body:    "Syntax Error",
+		},
+		{
+			name:    "unknown field",
+			request: httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ offers { id } }"}`)),
+			status:  http.StatusBadRequest,
+			body:    `Cannot query field \"offers\" on type \"Query\"`,
+		},
+		{
+			name: "query cost exceeded",
+			request: httptest.NewRequest(http.MethodGet, "/graphql?"+url.Values{
+				"query": []string{"{ transactions(limit: 20) { operations { id } } }"},
+			}.Encode(), nil),
+			status: http.StatusBadRequest,
+			body:   "query cost 21 exceeds the maximum query cost of 20",
+		},
+		{
+			name: "rate limited",
+			request: httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(
+				`{"query": "query Ops($limit: Int) { operations(limit: $limit) { transaction { id } } }", "variables": {"limit": 10}}`,
+			)),
+			status: http.StatusTooManyRequests,
+			body:   "rate_limit_exceeded",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, testCase.request)
+			assert.Equal(t, testCase.status, w.Code)
+			assert.Contains(t, w.Body.String(), testCase.body)
+		})
+	}
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	tt := test.Start(t)
+	tt.Scenario("base")
+	defer tt.Finish()
+
+	handler := newTestGraphQLHandler(t, 100, nil)
+	hash := "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d"
+	body, err := json.Marshal(graphQLRequest{
+		Query: `query Transaction($hash: String!) {
+			transaction(id: $hash) { hash successful operations { id transaction_hash details } }
+			missing: account(id: "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY") { id }
+			invalid: account(id: "G") { id }
+		}`,
+		Variables: map[string]interface{}{"hash": hash},
+	})
+	tt.Require.NoError(err)
+
+	request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	ctx := context.WithValue(request.Context(), &horizonContext.SessionContextKey, tt.HorizonSession())
+	ctx = context.WithValue(ctx, &horizonContext.RequestContextKey, request)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request.WithContext(ctx))
+	tt.Assert.Equal(http.StatusOK, w.Code)
+
+	var result struct {
+		Data struct {
+			Transaction struct {
+				Hash       string `json:"hash"`
+				Successful bool   `json:"successful"`
+				Operations []struct {
+					ID              string                 `json:"id"`
+					TransactionHash string                 `json:"transaction_hash"`
+					Details         map[string]interface{} `json:"details"`
+				} `json:"operations"`
+			} `json:"transaction"`
+			Missing *struct{} `json:"missing"`
+			Invalid *struct{} `json:"invalid"`
+		} `json:"data"`
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	tt.Require.NoError(json.Unmarshal(w.Body.Bytes(), &result))
+
+	tt.Assert.Equal(hash, result.Data.Transaction.Hash)
+	tt.Assert.True(result.Data.Transaction.Successful)
+	if tt.Assert.Len(result.Data.Transaction.Operations, 1) {
+		operation := result.Data.Transaction.Operations[0]
+		tt.Assert.Equal(hash, operation.TransactionHash)
+		tt.Assert.Equal(operation.ID, operation.Details["id"])
+		tt.Assert.NotContains(operation.Details, "_links")
+	}
+	tt.Assert.Nil(result.Data.Missing)
+	tt.Assert.Nil(result.Data.Invalid)
+	if tt.Assert.Len(result.Errors, 1) {
+		tt.Assert.Equal(float64(http.StatusBadRequest), result.Errors[0].Extensions["status"])
+		tt.Assert.Equal("id", result.Errors[0].Extensions["invalid_field"])
+	}
+}
@@ -55,6 +55,8 @@ type RouterConfig struct {
 	DisableTxSub            bool
 	SkipTxMeta              bool
 	StellarCoreURL          string
+	EnableGraphQL           bool
+	GraphQLMaxQueryCost     uint
 }
 
 type Router struct {
@@ -77,6 +79,11 @@ func NewRouter(config *RouterConfig, serverMetrics *ServerMetrics, ledgerState *
 	}
 	result.addMiddleware(config, rateLimiter, serverMetrics)
 	result.addRoutes(config, rateLimiter, ledgerState)
+	if config.EnableGraphQL {
+		if err := result.addGraphQLRoute(config, rateLimiter, ledgerState); err != nil {
+			return nil, err
+		}
+	}
 	return &result, nil
 }
 
@@ -208,6 +215,22 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 			})
 		})
 
+		r.Route("/contracts/{contract_id:\\w+}", func(r chi.Router) {
+			r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/", ObjectActionHandler{actions.GetContractByIDHandler{}})
+			r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/data", restPageHandler(ledgerState, actions.GetContractDataHandler{LedgerState: ledgerState}))
+			r.With(historyMiddleware).Method(http.MethodGet, "/balance_history", streamableHistoryPageHandler(ledgerState, actions.GetBalanceHistoryHandler{
+				LedgerState:       ledgerState,
+				NetworkPassphrase: config.NetworkPassphrase,
+			}, streamHandler))
+			r.With(historyMiddleware).Method(http.MethodGet, "/events", streamableHistoryPageHandler(ledgerState, actions.GetContractEventsHandler{LedgerState: ledgerState}, streamHandler))
+			r.With(historyMiddleware).Method(http.MethodGet, "/token_transfers", streamableHistoryPageHandler(ledgerState, actions.GetTokenTransfersHandler{
+				LedgerState:       ledgerState,
+				NetworkPassphrase: config.NetworkPassphrase,
+			}, streamHandler))
+		})
+
+		r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/contract_code/{hash:\\w+}", ObjectActionHandler{actions.GetContractCodeByHashHandler{}})
+
 		r.Route("/offers", func(r chi.Router) {
 			r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/", restPageHandler(ledgerState, actions.GetOffersHandler{LedgerState: ledgerState}))
 			r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/{offer_id}", ObjectActionHandler{actions.GetOfferByID{}})
@@ -247,6 +270,10 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 	// need to use absolute routes here. Make sure we use regexp check here for
 	// emptiness. Without it, requesting `/accounts//payments` return all payments!
 	r.Group(func(r chi.Router) {
+		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/balance_history", streamableHistoryPageHandler(ledgerState, actions.GetBalanceHistoryHandler{
+			LedgerState:       ledgerState,
+			NetworkPassphrase: config.NetworkPassphrase,
+		}, streamHandler))
 		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/effects", streamableHistoryPageHandler(ledgerState, actions.GetEffectsHandler{LedgerState: ledgerState}, streamHandler))
 		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/operations", streamableHistoryPageHandler(ledgerState, actions.GetOperationsHandler{
 			LedgerState:  ledgerState,
@@ -257,6 +284,10 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 			LedgerState:  ledgerState,
 			OnlyPayments: true,
 		}, streamHandler))
+		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/token_transfers", streamableHistoryPageHandler(ledgerState, actions.GetTokenTransfersHandler{
+			LedgerState:       ledgerState,
+			NetworkPassphrase: config.NetworkPassphrase,
+		}, streamHandler))
 		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/trades", streamableHistoryPageHandler(ledgerState, actions.GetTradesHandler{LedgerState: ledgerState, CoreStateGetter: config.CoreGetter}, streamHandler))
 		r.With(historyMiddleware).Method(http.MethodGet, "/accounts/{account_id:\\w+}/transactions", streamableHistoryPageHandler(ledgerState, actions.GetTransactionsHandler{LedgerState: ledgerState, SkipTxMeta: config.SkipTxMeta}, streamHandler))
 	})
@@ -330,6 +361,15 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 		// effect actions
 		r.With(historyMiddleware).Method(http.MethodGet, "/effects", streamableHistoryPageHandler(ledgerState, actions.GetEffectsHandler{LedgerState: ledgerState}, streamHandler))
 
+		// contract event actions
+		r.With(historyMiddleware).Method(http.MethodGet, "/contract_events", streamableHistoryPageHandler(ledgerState, actions.GetContractEventsHandler{LedgerState: ledgerState}, streamHandler))
+
+		// token transfer actions
+		r.With(historyMiddleware).Method(http.MethodGet, "/token_transfers", streamableHistoryPageHandler(ledgerState, actions.GetTokenTransfersHandler{
+			LedgerState:       ledgerState,
+			NetworkPassphrase: config.NetworkPassphrase,
+		}, streamHandler))
+
 		// trading related endpoints
 		r.With(historyMiddleware).Method(http.MethodGet, "/trades", streamableHistoryPageHandler(ledgerState, actions.GetTradesHandler{LedgerState: ledgerState, CoreStateGetter: config.CoreGetter}, streamHandler))
 		r.With(historyMiddleware).Method(http.MethodGet, "/trade_aggregations", ObjectActionHandler{actions.GetTradeAggregationsHandler{LedgerState: ledgerState, CoreStateGetter: config.CoreGetter}})
@@ -404,6 +444,22 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 	})
 }
 
+// addGraphQLRoute adds the /graphql end-point, behind the state middleware so
+// that all the records of a query belong to the same ledger.
+func (r *Router) addGraphQLRoute(config *RouterConfig, rateLimiter *throttled.HTTPRateLimiter, ledgerState *ledger.State) error {
+	handler, err := newGraphQLHandler(config, rateLimiter, ledgerState)
+	if err != nil {
+		return err
+	}
+	stateMiddleware := StateMiddleware{
+		HorizonSession:     config.DBSession,
+		ClientQueryTimeout: config.ClientQueryTimeout,
+	}
+	r.With(stateMiddleware.Wrap).Method(http.MethodGet, "/graphql", handler)
+	r.With(stateMiddleware.Wrap).Method(http.MethodPost, "/graphql", handler)
+	return nil
+}
+
 func AddMetricRoutes(mux *chi.Mux, metrics *prometheus.Registry) {
 	mux.Get("/metrics", promhttp.HandlerFor(metrics, promhttp.HandlerOpts{}).ServeHTTP)
 	mux.Get("/debug/pprof/heap", pprof.Index)
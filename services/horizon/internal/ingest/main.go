@@ -69,7 +69,9 @@ const (
 	// - 19: Archived contract asset balances are no longer stored in the horizon db.
 	// - 20: Mapping of asset to its contract instance is stored in a new
 	//       table (asset_contracts) in the horizon db.
-	CurrentVersion = 20
+	// - 21: Contract data, contract code and ttl ledger entries are stored in
+	//       new tables (contract_data, contract_code and contract_ttls).
+	CurrentVersion = 21
 
 	// MaxDBConnections is the size of the postgres connection pool dedicated to Horizon ingestion:
 	//  * Ledger ingestion,
@@ -412,6 +412,10 @@ type mockDBQ struct {
 	history.MockQFilter
 	history.MockQClaimableBalances
 	history.MockQHistoryClaimableBalances
+	history.MockQContractEvents
+	history.MockQContractState
+	history.MockQTokenTransfers
+	history.MockQBalanceHistory
 	history.MockQLiquidityPools
 	history.MockQHistoryLiquidityPools
 	history.MockQAssetStats
@@ -114,6 +114,7 @@ func buildChangeProcessor(
 	source ingestionSource,
 	ledgerSequence uint32,
 	networkPassphrase string,
+	evictedLedgerKeys []xdr.LedgerKey,
 ) *groupChangeProcessors {
 	statsChangeProcessor := &statsChangeProcessor{
 		StatsChangeProcessor: changeStats,
@@ -134,6 +135,7 @@ func buildChangeProcessor(
 		processors.NewTrustLinesProcessor(historyQ),
 		processors.NewClaimableBalancesChangeProcessor(historyQ),
 		processors.NewLiquidityPoolsChangeProcessor(historyQ, ledgerSequence),
+		processors.NewContractStateProcessor(historyQ, evictedLedgerKeys),
 	})
 }
 
@@ -161,7 +163,10 @@ func (s *ProcessorRunner) buildTransactionProcessor(ledgersProcessor *processors
 		processors.NewClaimableBalancesTransactionProcessor(cbLoader,
 			s.historyQ.NewTransactionClaimableBalanceBatchInsertBuilder(), s.historyQ.NewOperationClaimableBalanceBatchInsertBuilder()),
 		processors.NewLiquidityPoolsTransactionProcessor(lpLoader,
-			s.historyQ.NewTransactionLiquidityPoolBatchInsertBuilder(), s.historyQ.NewOperationLiquidityPoolBatchInsertBuilder())}
+			s.historyQ.NewTransactionLiquidityPoolBatchInsertBuilder(), s.historyQ.NewOperationLiquidityPoolBatchInsertBuilder()),
+		processors.NewContractEventsProcessor(s.historyQ.NewContractEventBatchInsertBuilder()),
+		processors.NewTokenTransferProcessor(s.historyQ.NewTokenTransferBatchInsertBuilder(), s.config.NetworkPassphrase),
+		processors.NewBalanceHistoryProcessor(s.historyQ.NewBalanceSnapshotBatchInsertBuilder(), s.config.NetworkPassphrase)}
 
 	return loaders, newGroupTransactionProcessors(processors, statsLedgerTransactionProcessor, tradeProcessor)
 }
@@ -212,6 +217,7 @@ func (s *ProcessorRunner) RunHistoryArchiveIngestion(
 		historyArchiveSource,
 		checkpointLedger,
 		s.config.NetworkPassphrase,
+		nil,
 	)
 
 	if err := registerChangeProcessors(
@@ -608,6 +614,7 @@ func (s *ProcessorRunner) RunAllProcessorsOnLedger(ledger xdr.LedgerCloseMeta) (
 		ledgerSource,
 		ledger.LedgerSequence(),
 		s.config.NetworkPassphrase,
+		evictedLedgerKeys,
 	)
 
 	registry := nameRegistry{}
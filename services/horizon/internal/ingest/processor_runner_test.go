@@ -152,7 +152,7 @@ func TestProcessorRunnerBuildChangeProcessor(t *testing.T) {
 	}
 
 	stats := &processors.StatsChangeProcessor{}
-	processor := buildChangeProcessor(runner.historyQ, stats, ledgerSource, 123, "")
+	processor := buildChangeProcessor(runner.historyQ, stats, ledgerSource, 123, "", nil)
 	assert.IsType(t, &groupChangeProcessors{}, processor)
 
 	assert.IsType(t, &statsChangeProcessor{}, processor.processors[0])
@@ -164,6 +164,7 @@ func TestProcessorRunnerBuildChangeProcessor(t *testing.T) {
 		Elem().FieldByName("ingestFromHistoryArchive").Bool())
 	assert.IsType(t, &processors.SignersProcessor{}, processor.processors[5])
 	assert.IsType(t, &processors.TrustLinesProcessor{}, processor.processors[6])
+	assert.IsType(t, &processors.ContractStateProcessor{}, processor.processors[9])
 
 	runner = ProcessorRunner{
 		ctx:      ctx,
@@ -171,7 +172,7 @@ func TestProcessorRunnerBuildChangeProcessor(t *testing.T) {
 		filters:  &MockFilters{},
 	}
 
-	processor = buildChangeProcessor(runner.historyQ, stats, historyArchiveSource, 456, "")
+	processor = buildChangeProcessor(runner.historyQ, stats, historyArchiveSource, 456, "", nil)
 	assert.IsType(t, &groupChangeProcessors{}, processor)
 
 	assert.IsType(t, &statsChangeProcessor{}, processor.processors[0])
@@ -183,6 +184,7 @@ func TestProcessorRunnerBuildChangeProcessor(t *testing.T) {
 		Elem().FieldByName("ingestFromHistoryArchive").Bool())
 	assert.IsType(t, &processors.SignersProcessor{}, processor.processors[5])
 	assert.IsType(t, &processors.TrustLinesProcessor{}, processor.processors[6])
+	assert.IsType(t, &processors.ContractStateProcessor{}, processor.processors[9])
 }
 
 func TestProcessorRunnerBuildTransactionProcessor(t *testing.T) {
@@ -198,6 +200,12 @@ func TestProcessorRunnerBuildTransactionProcessor(t *testing.T) {
 		Return(&history.MockLedgersBatchInsertBuilder{})
 	q.MockQEffects.On("NewEffectBatchInsertBuilder").
 		Return(&history.MockEffectBatchInsertBuilder{})
+	q.MockQContractEvents.On("NewContractEventBatchInsertBuilder").
+		Return(&history.MockContractEventBatchInsertBuilder{})
+	q.MockQTokenTransfers.On("NewTokenTransferBatchInsertBuilder").
+		Return(&history.MockTokenTransferBatchInsertBuilder{})
+	q.MockQBalanceHistory.On("NewBalanceSnapshotBatchInsertBuilder").
+		Return(&history.MockBalanceSnapshotBatchInsertBuilder{})
 	q.MockQOperations.On("NewOperationBatchInsertBuilder").
 		Return(&history.MockOperationsBatchInsertBuilder{})
 	q.On("NewTransactionParticipantsBatchInsertBuilder").
@@ -231,6 +239,9 @@ func TestProcessorRunnerBuildTransactionProcessor(t *testing.T) {
 	assert.IsType(t, &processors.ParticipantsProcessor{}, processor.processors[5])
 	assert.IsType(t, &processors.ClaimableBalancesTransactionProcessor{}, processor.processors[7])
 	assert.IsType(t, &processors.LiquidityPoolsTransactionProcessor{}, processor.processors[8])
+	assert.IsType(t, &processors.ContractEventsProcessor{}, processor.processors[9])
+	assert.IsType(t, &processors.TokenTransferProcessor{}, processor.processors[10])
+	assert.IsType(t, &processors.BalanceHistoryProcessor{}, processor.processors[11])
 }
 
 func TestProcessorRunnerRunAllProcessorsOnLedger(t *testing.T) {
@@ -576,6 +587,21 @@ func mockTxProcessorBatchBuilders(q *mockDBQ, mockSession *db.MockSession, ctx c
 	q.MockQHistoryLiquidityPools.On("NewOperationLiquidityPoolBatchInsertBuilder").
 		Return(mockOperationLiquidityPoolBatchInsertBuilder).Once()
 
+	mockContractEventBatchInsertBuilder := &history.MockContractEventBatchInsertBuilder{}
+	mockContractEventBatchInsertBuilder.On("Exec", ctx, mockSession).Return(nil).Once()
+	q.MockQContractEvents.On("NewContractEventBatchInsertBuilder").
+		Return(mockContractEventBatchInsertBuilder).Once()
+
+	mockTokenTransferBatchInsertBuilder := &history.MockTokenTransferBatchInsertBuilder{}
+	mockTokenTransferBatchInsertBuilder.On("Exec", ctx, mockSession).Return(nil).Once()
+	q.MockQTokenTransfers.On("NewTokenTransferBatchInsertBuilder").
+		Return(mockTokenTransferBatchInsertBuilder).Once()
+
+	mockBalanceSnapshotBatchInsertBuilder := &history.MockBalanceSnapshotBatchInsertBuilder{}
+	mockBalanceSnapshotBatchInsertBuilder.On("Exec", ctx, mockSession).Return(nil).Once()
+	q.MockQBalanceHistory.On("NewBalanceSnapshotBatchInsertBuilder").
+		Return(mockBalanceSnapshotBatchInsertBuilder).Once()
+
 	return []interface{}{mockTradeBatchInsertBuilder,
 		mockTransactionsBatchInsertBuilder,
 		mockOperationsBatchInsertBuilder,
@@ -585,7 +611,10 @@ func mockTxProcessorBatchBuilders(q *mockDBQ, mockSession *db.MockSession, ctx c
 		mockTransactionClaimableBalanceBatchInsertBuilder,
 		mockOperationClaimableBalanceBatchInsertBuilder,
 		mockTransactionLiquidityPoolBatchInsertBuilder,
-		mockOperationLiquidityPoolBatchInsertBuilder}
+		mockOperationLiquidityPoolBatchInsertBuilder,
+		mockContractEventBatchInsertBuilder,
+		mockTokenTransferBatchInsertBuilder,
+		mockBalanceSnapshotBatchInsertBuilder}
 }
 
 func mockChangeProcessorBatchBuilders(q *mockDBQ, ctx context.Context, mockExec bool) []interface{} {
@@ -0,0 +1,223 @@
+package processors
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// Phases in which the ledger entry changes of the transactions of a ledger
+// are applied. The fees of all the transactions are charged before any
+// transaction is applied, and protocol 23 refunds soroban fees after all the
+// transactions are applied.
+const (
+	balanceChangePhaseFee = iota
+	balanceChangePhaseApply
+	balanceChangePhaseRefund
+)
+
+type balanceKey struct {
+	ledger     uint32
+	address    string
+	contractID string
+}
+
+type balanceValue struct {
+	phase   int
+	txIndex uint32
+	row     history.BalanceSnapshot
+}
+
+// BalanceHistoryProcessor stores the balance of native, classic assets and
+// stellar asset contract balances held by accounts and contracts at the end
+// of each ledger in which they changed in the history_balances table.
+type BalanceHistoryProcessor struct {
+	networkPassphrase string
+	batch             history.BalanceSnapshotBatchInsertBuilder
+	balances          map[balanceKey]balanceValue
+	contractIDs       map[string]string
+}
+
+func NewBalanceHistoryProcessor(batch history.BalanceSnapshotBatchInsertBuilder, networkPassphrase string) *BalanceHistoryProcessor {
+	return &BalanceHistoryProcessor{
+		networkPassphrase: networkPassphrase,
+		batch:             batch,
+		balances:          map[balanceKey]balanceValue{},
+		contractIDs:       map[string]string{},
+	}
+}
+
+func (p *BalanceHistoryProcessor) Name() string {
+	return "processors.BalanceHistoryProcessor"
+}
+
+func (p *BalanceHistoryProcessor) ProcessTransaction(lcm xdr.LedgerCloseMeta, transaction ingest.LedgerTransaction) error {
+	changes, err := transaction.GetChanges()
+	if err != nil {
+		return errors.Wrap(err, "could not determine changes in transaction")
+	}
+
+	for phase, phaseChanges := range [][]ingest.Change{
+		balanceChangePhaseFee:    transaction.GetFeeChanges(),
+		balanceChangePhaseApply:  changes,
+		balanceChangePhaseRefund: transaction.GetPostApplyFeeChanges(),
+	} {
+		for _, change := range phaseChanges {
+			if err := p.processChange(lcm, phase, transaction.Index, change); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// processChange records the balance after the change, unless an earlier
+// change of the ledger was applied later.
+func (p *BalanceHistoryProcessor) processChange(lcm xdr.LedgerCloseMeta, phase int, txIndex uint32, change ingest.Change) error {
+	var pre, post *balance
+	var err error
+	if change.Pre != nil {
+		if pre, err = p.balanceFromEntry(*change.Pre); err != nil {
+			return err
+		}
+	}
+	if change.Post != nil {
+		if post, err = p.balanceFromEntry(*change.Post); err != nil {
+			return err
+		}
+	}
+
+	var current balance
+	switch {
+	case post != nil:
+		current = *post
+		if pre != nil && pre.amount == post.amount {
+			return nil
+		}
+	case pre != nil:
+		// the account, trust line or contract balance was removed
+		current = *pre
+		current.amount = "0"
+	default:
+		return nil
+	}
+
+	key := balanceKey{
+		ledger:     lcm.LedgerSequence(),
+		address:    current.address,
+		contractID: current.contractID,
+	}
+	if existing, ok := p.balances[key]; ok &&
+		(existing.phase > phase || (existing.phase == phase && existing.txIndex > txIndex)) {
+		return nil
+	}
+	p.balances[key] = balanceValue{
+		phase:   phase,
+		txIndex: txIndex,
+		row: history.BalanceSnapshot{
+			Address:         current.address,
+			ContractID:      current.contractID,
+			LedgerSequence:  key.ledger,
+			LedgerCloseTime: lcm.ClosedAt(),
+			Balance:         current.amount,
+		},
+	}
+	return nil
+}
+
+type balance struct {
+	address    string
+	contractID string
+	amount     string
+}
+
+// balanceFromEntry returns the balance held in the ledger entry, nil if the
+// entry holds no balance.
+func (p *BalanceHistoryProcessor) balanceFromEntry(entry xdr.LedgerEntry) (*balance, error) {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		account := entry.Data.MustAccount()
+		contractID, err := p.assetContractID(xdr.MustNewNativeAsset())
+		if err != nil {
+			return nil, err
+		}
+		return &balance{
+			address:    account.AccountId.Address(),
+			contractID: contractID,
+			amount:     strconv.FormatInt(int64(account.Balance), 10),
+		}, nil
+	case xdr.LedgerEntryTypeTrustline:
+		trustLine := entry.Data.MustTrustLine()
+		if trustLine.Asset.Type == xdr.AssetTypeAssetTypePoolShare {
+			return nil, nil
+		}
+		contractID, err := p.assetContractID(trustLine.Asset.ToAsset())
+		if err != nil {
+			return nil, err
+		}
+		return &balance{
+			address:    trustLine.AccountId.Address(),
+			contractID: contractID,
+			amount:     strconv.FormatInt(int64(trustLine.Balance), 10),
+		}, nil
+	case xdr.LedgerEntryTypeContractData:
+		holder, amount, ok := sac.ContractBalanceFromContractDataWithNative(entry)
+		contractData := entry.Data.MustContractData()
+		if !ok || contractData.Contract.ContractId == nil {
+			return nil, nil
+		}
+		contractID, err := strkey.Encode(strkey.VersionByteContract, contractData.Contract.ContractId[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "could not encode contract id")
+		}
+		address, err := strkey.Encode(strkey.VersionByteContract, holder[:])
+		if err != nil {
+			return nil, errors.Wrap(err, "could not encode balance holder")
+		}
+		return &balance{
+			address:    address,
+			contractID: contractID,
+			amount:     amount.String(),
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// assetContractID returns the id of the stellar asset contract of the asset.
+func (p *BalanceHistoryProcessor) assetContractID(asset xdr.Asset) (string, error) {
+	key := asset.StringCanonical()
+	if contractID, ok := p.contractIDs[key]; ok {
+		return contractID, nil
+	}
+	rawID, err := asset.ContractID(p.networkPassphrase)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not compute contract id of %s", key)
+	}
+	contractID, err := strkey.Encode(strkey.VersionByteContract, rawID[:])
+	if err != nil {
+		return "", errors.Wrap(err, "could not encode contract id")
+	}
+	p.contractIDs[key] = contractID
+	return contractID, nil
+}
+
+func (p *BalanceHistoryProcessor) Flush(ctx context.Context, session db.SessionInterface) error {
+	for _, value := range p.balances {
+		if err := p.batch.Add(value.row); err != nil {
+			return errors.Wrap(err, "error batch inserting balance snapshots")
+		}
+	}
+	if err := p.batch.Exec(ctx, session); err != nil {
+		return err
+	}
+	p.balances = map[balanceKey]balanceValue{}
+	return nil
+}
@@ -0,0 +1,150 @@
+package processors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/xdr"
+)
+
+func TestBalanceHistoryProcessor(t *testing.T) {
+	ctx := context.Background()
+	closeTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	lcm := xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq: 20,
+					ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(closeTime.Unix())},
+				},
+			},
+		},
+	}
+
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	issuer := keypair.MustRandom().Address()
+	usd := xdr.MustNewCreditAsset("USD", issuer)
+	contractID := func(asset xdr.Asset) (xdr.ContractId, string) {
+		rawID, err := asset.ContractID(network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		return rawID, strkey.MustEncode(strkey.VersionByteContract, rawID[:])
+	}
+	xlmRawContract, xlmContract := contractID(xdr.MustNewNativeAsset())
+	_, usdContract := contractID(usd)
+	holder := xdr.ContractId{1}
+	holderContract := strkey.MustEncode(strkey.VersionByteContract, holder[:])
+
+	account := func(address string, balance xdr.Int64, seqNum xdr.SequenceNumber) xdr.LedgerEntry {
+		return xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: xdr.MustAddress(address),
+					Balance:   balance,
+					SeqNum:    seqNum,
+				},
+			},
+		}
+	}
+	trustLine := func(address string, asset xdr.TrustLineAsset, balance xdr.Int64) xdr.LedgerEntry {
+		return xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeTrustline,
+				TrustLine: &xdr.TrustLineEntry{
+					AccountId: xdr.MustAddress(address),
+					Asset:     asset,
+					Balance:   balance,
+				},
+			},
+		}
+	}
+	contractBalance := func(amount uint64) xdr.LedgerEntry {
+		return xdr.LedgerEntry{Data: sac.BalanceToContractData(xlmRawContract, holder, amount)}
+	}
+	updated := func(pre, post xdr.LedgerEntry) xdr.LedgerEntryChanges {
+		return xdr.LedgerEntryChanges{
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+		}
+	}
+	removed := func(pre xdr.LedgerEntry) xdr.LedgerEntryChanges {
+		key, err := pre.LedgerKey()
+		require.NoError(t, err)
+		return xdr.LedgerEntryChanges{
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+			{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &key},
+		}
+	}
+	transaction := func(index uint32, feeChanges xdr.LedgerEntryChanges, opChanges ...xdr.LedgerEntryChanges) ingest.LedgerTransaction {
+		var operations []xdr.OperationMeta
+		for _, changes := range opChanges {
+			operations = append(operations, xdr.OperationMeta{Changes: changes})
+		}
+		return ingest.LedgerTransaction{
+			Index:      index,
+			FeeChanges: feeChanges,
+			UnsafeMeta: xdr.TransactionMeta{
+				V: 3,
+				V3: &xdr.TransactionMetaV3{
+					// the sequence number bump doesn't change the balance
+					TxChangesBefore: updated(account(source, 800, 1), account(source, 800, 2)),
+					Operations:      operations,
+				},
+			},
+			Ledger: lcm,
+		}
+	}
+
+	poolShare := xdr.TrustLineAsset{
+		Type:            xdr.AssetTypeAssetTypePoolShare,
+		LiquidityPoolId: &xdr.PoolId{1},
+	}
+	// the fees of all the transactions are charged before the transactions
+	// are applied
+	first := transaction(1,
+		updated(account(source, 1000, 1), account(source, 900, 1)),
+		updated(account(source, 850, 1), account(source, 750, 1)),
+		updated(account(destination, 0, 1), account(destination, 100, 1)),
+		updated(trustLine(destination, usd.ToTrustLineAsset(), 10), trustLine(destination, usd.ToTrustLineAsset(), 5)),
+		updated(trustLine(destination, poolShare, 10), trustLine(destination, poolShare, 20)),
+		updated(contractBalance(5), contractBalance(7)),
+	)
+	second := transaction(2,
+		updated(account(source, 900, 1), account(source, 850, 1)),
+		removed(trustLine(destination, usd.ToTrustLineAsset(), 5)),
+	)
+
+	row := func(address, contractID, balance string) history.BalanceSnapshot {
+		return history.BalanceSnapshot{
+			Address:         address,
+			ContractID:      contractID,
+			LedgerSequence:  20,
+			LedgerCloseTime: closeTime,
+			Balance:         balance,
+		}
+	}
+	batch := &history.MockBalanceSnapshotBatchInsertBuilder{}
+	batch.On("Add", row(source, xlmContract, "750")).Return(nil).Once()
+	batch.On("Add", row(destination, xlmContract, "100")).Return(nil).Once()
+	batch.On("Add", row(destination, usdContract, "0")).Return(nil).Once()
+	batch.On("Add", row(holderContract, xlmContract, "7")).Return(nil).Once()
+	session := &db.MockSession{}
+	batch.On("Exec", ctx, session).Return(nil).Once()
+
+	processor := NewBalanceHistoryProcessor(batch, network.TestNetworkPassphrase)
+	require.NoError(t, processor.ProcessTransaction(lcm, first))
+	require.NoError(t, processor.ProcessTransaction(lcm, second))
+	require.NoError(t, processor.Flush(ctx, session))
+	batch.AssertExpectations(t)
+}
@@ -0,0 +1,100 @@
+package processors
+
+import (
+	"context"
+
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// ContractEventsProcessor stores the contract events emitted by the
+// operations of successful transactions in the history_contract_events table.
+type ContractEventsProcessor struct {
+	batch history.ContractEventBatchInsertBuilder
+}
+
+func NewContractEventsProcessor(batch history.ContractEventBatchInsertBuilder) *ContractEventsProcessor {
+	return &ContractEventsProcessor{
+		batch: batch,
+	}
+}
+
+func (p *ContractEventsProcessor) Name() string {
+	return "processors.ContractEventsProcessor"
+}
+
+func (p *ContractEventsProcessor) ProcessTransaction(lcm xdr.LedgerCloseMeta, transaction ingest.LedgerTransaction) error {
+	if !transaction.Successful() {
+		return nil
+	}
+
+	events, err := transaction.GetTransactionEvents()
+	if err != nil {
+		return errors.Wrap(err, "could not read transaction events")
+	}
+
+	closeTime := lcm.ClosedAt()
+	for opIndex, opEvents := range events.OperationEvents {
+		operationID := toid.New(int32(lcm.LedgerSequence()), int32(transaction.Index), int32(opIndex+1)).ToInt64()
+		for i, event := range opEvents {
+			row, err := contractEventToRow(event)
+			if err != nil {
+				return err
+			}
+			row.HistoryOperationID = operationID
+			row.Order = int32(i + 1)
+			row.TransactionHash = transaction.Result.TransactionHash.HexString()
+			row.LedgerCloseTime = closeTime
+			if err := p.batch.Add(row); err != nil {
+				return errors.Wrap(err, "error batch inserting contract event rows")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *ContractEventsProcessor) Flush(ctx context.Context, session db.SessionInterface) error {
+	return p.batch.Exec(ctx, session)
+}
+
+// contractEventToRow converts the body of the event to a history row.
+func contractEventToRow(event xdr.ContractEvent) (history.ContractEvent, error) {
+	row := history.ContractEvent{Type: int32(event.Type)}
+	if event.ContractId != nil {
+		contractID, err := strkey.Encode(strkey.VersionByteContract, event.ContractId[:])
+		if err != nil {
+			return row, errors.Wrap(err, "could not encode contract id")
+		}
+		row.ContractID = null.StringFrom(contractID)
+	}
+
+	body, ok := event.Body.GetV0()
+	if !ok {
+		return row, errors.Errorf("unsupported contract event body version %d", event.Body.V)
+	}
+	if len(body.Topics) > history.MaxContractEventTopics {
+		return row, errors.Errorf("contract event has %d topics", len(body.Topics))
+	}
+	topics := []*null.String{&row.Topic1, &row.Topic2, &row.Topic3, &row.Topic4}
+	for i, topic := range body.Topics {
+		encoded, err := xdr.MarshalBase64(topic)
+		if err != nil {
+			return row, errors.Wrap(err, "could not encode contract event topic")
+		}
+		*topics[i] = null.StringFrom(encoded)
+	}
+	value, err := xdr.MarshalBase64(body.Data)
+	if err != nil {
+		return row, errors.Wrap(err, "could not encode contract event value")
+	}
+	row.Value = value
+	return row, nil
+}
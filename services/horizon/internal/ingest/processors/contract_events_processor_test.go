@@ -0,0 +1,130 @@
+package processors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func contractEventForTest(contractID *xdr.ContractId, topics ...xdr.ScVal) xdr.ContractEvent {
+	data := xdr.Uint32(7)
+	return xdr.ContractEvent{
+		ContractId: contractID,
+		Type:       xdr.ContractEventTypeContract,
+		Body: xdr.ContractEventBody{
+			V: 0,
+			V0: &xdr.ContractEventV0{
+				Topics: topics,
+				Data:   xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &data},
+			},
+		},
+	}
+}
+
+func TestContractEventsProcessor(t *testing.T) {
+	ctx := context.Background()
+	closeTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sequence := uint32(20)
+	lcm := xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq: xdr.Uint32(sequence),
+					ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(closeTime.Unix())},
+				},
+			},
+		},
+	}
+
+	var contractID xdr.ContractId
+	contractID[0] = 1
+	symbol := xdr.ScSymbol("transfer")
+	topic := xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &symbol}
+	other := xdr.ScVal{Type: xdr.ScValTypeScvBool, B: new(bool)}
+	first := contractEventForTest(&contractID, topic, other)
+	second := contractEventForTest(nil)
+	second.Type = xdr.ContractEventTypeSystem
+	third := contractEventForTest(&contractID, topic)
+
+	tx := createTransaction(true, 2, 3)
+	tx.Index = 3
+	tx.Result.TransactionHash = xdr.Hash{1, 2, 3}
+	tx.UnsafeMeta = xdr.TransactionMeta{
+		V: 4,
+		V4: &xdr.TransactionMetaV4{
+			Operations: []xdr.OperationMetaV2{
+				{Events: []xdr.ContractEvent{first, second}},
+				{Events: []xdr.ContractEvent{third}},
+			},
+		},
+	}
+	failedTx := createTransaction(false, 1, 3)
+	failedTx.UnsafeMeta = xdr.TransactionMeta{
+		V: 4,
+		V4: &xdr.TransactionMetaV4{
+			Operations: []xdr.OperationMetaV2{{Events: []xdr.ContractEvent{first}}},
+		},
+	}
+
+	encode := func(v xdr.ScVal) null.String {
+		encoded, err := xdr.MarshalBase64(v)
+		require.NoError(t, err)
+		return null.StringFrom(encoded)
+	}
+	value := encode(first.Body.V0.Data).String
+	address := strkey.MustEncode(strkey.VersionByteContract, contractID[:])
+	hash := "0102030000000000000000000000000000000000000000000000000000000000"
+
+	batch := &history.MockContractEventBatchInsertBuilder{}
+	batch.On("Add", history.ContractEvent{
+		HistoryOperationID: toid.New(20, 3, 1).ToInt64(),
+		Order:              1,
+		TransactionHash:    hash,
+		LedgerCloseTime:    closeTime,
+		ContractID:         null.StringFrom(address),
+		Type:               int32(xdr.ContractEventTypeContract),
+		Topic1:             encode(topic),
+		Topic2:             encode(other),
+		Value:              value,
+	}).Return(nil).Once()
+	batch.On("Add", history.ContractEvent{
+		HistoryOperationID: toid.New(20, 3, 1).ToInt64(),
+		Order:              2,
+		TransactionHash:    hash,
+		LedgerCloseTime:    closeTime,
+		Type:               int32(xdr.ContractEventTypeSystem),
+		Value:              value,
+	}).Return(nil).Once()
+	batch.On("Add", history.ContractEvent{
+		HistoryOperationID: toid.New(20, 3, 2).ToInt64(),
+		Order:              1,
+		TransactionHash:    hash,
+		LedgerCloseTime:    closeTime,
+		ContractID:         null.StringFrom(address),
+		Type:               int32(xdr.ContractEventTypeContract),
+		Topic1:             encode(topic),
+		Value:              value,
+	}).Return(nil).Once()
+	session := &db.MockSession{}
+	batch.On("Exec", ctx, session).Return(nil).Once()
+
+	processor := NewContractEventsProcessor(batch)
+	require.NoError(t, processor.ProcessTransaction(lcm, tx))
+	require.NoError(t, processor.ProcessTransaction(lcm, failedTx))
+	require.NoError(t, processor.Flush(ctx, session))
+	batch.AssertExpectations(t)
+
+	tooManyTopics := contractEventForTest(&contractID, topic, topic, topic, topic, topic)
+	tx.UnsafeMeta.V4.Operations = []xdr.OperationMetaV2{{Events: []xdr.ContractEvent{tooManyTopics}}}
+	assert.EqualError(t, processor.ProcessTransaction(lcm, tx), "contract event has 5 topics")
+}
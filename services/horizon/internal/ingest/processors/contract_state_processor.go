@@ -0,0 +1,251 @@
+package processors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// ContractStateProcessor maintains the contract_data, contract_code and
+// contract_ttls tables.
+type ContractStateProcessor struct {
+	qContractState history.QContractState
+	// evictedLedgerKeys are the keys evicted from the ledger, which are not
+	// part of the ledger entry changes. They are removed on the next Commit.
+	evictedLedgerKeys []xdr.LedgerKey
+
+	dataToUpsert []history.ContractData
+	dataToRemove []string
+	codeToUpsert []history.ContractCode
+	codeToRemove []string
+	ttlsToUpsert []history.ContractTTL
+	ttlsToRemove []string
+}
+
+// NewContractStateProcessor returns a processor removing the contract data and
+// code entries in evictedLedgerKeys, and their ttls, in addition to applying
+// the ledger entry changes.
+func NewContractStateProcessor(qContractState history.QContractState, evictedLedgerKeys []xdr.LedgerKey) *ContractStateProcessor {
+	p := &ContractStateProcessor{qContractState: qContractState, evictedLedgerKeys: evictedLedgerKeys}
+	p.reset()
+	return p
+}
+
+func (p *ContractStateProcessor) reset() {
+	p.dataToUpsert = []history.ContractData{}
+	p.dataToRemove = []string{}
+	p.codeToUpsert = []history.ContractCode{}
+	p.codeToRemove = []string{}
+	p.ttlsToUpsert = []history.ContractTTL{}
+	p.ttlsToRemove = []string{}
+}
+
+func (p *ContractStateProcessor) Name() string {
+	return "processors.ContractStateProcessor"
+}
+
+func (p *ContractStateProcessor) ProcessChange(ctx context.Context, change ingest.Change) error {
+	switch change.Type {
+	case xdr.LedgerEntryTypeContractData, xdr.LedgerEntryTypeContractCode, xdr.LedgerEntryTypeTtl:
+	default:
+		return nil
+	}
+
+	if change.Post == nil {
+		// Removed
+		keyHash, err := contractStateKeyHash(*change.Pre)
+		if err != nil {
+			return err
+		}
+		switch change.Type {
+		case xdr.LedgerEntryTypeContractData:
+			p.dataToRemove = append(p.dataToRemove, keyHash)
+		case xdr.LedgerEntryTypeContractCode:
+			p.codeToRemove = append(p.codeToRemove, keyHash)
+		case xdr.LedgerEntryTypeTtl:
+			p.ttlsToRemove = append(p.ttlsToRemove, keyHash)
+		}
+	} else {
+		// Created, updated or restored
+		if err := p.addUpsert(*change.Post); err != nil {
+			return err
+		}
+	}
+
+	if p.len() > maxBatchSize {
+		if err := p.Commit(ctx); err != nil {
+			return errors.Wrap(err, "error in Commit")
+		}
+	}
+
+	return nil
+}
+
+func (p *ContractStateProcessor) len() int {
+	return len(p.dataToUpsert) + len(p.dataToRemove) +
+		len(p.codeToUpsert) + len(p.codeToRemove) +
+		len(p.ttlsToUpsert) + len(p.ttlsToRemove)
+}
+
+func (p *ContractStateProcessor) addUpsert(entry xdr.LedgerEntry) error {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeContractData:
+		row, err := ContractDataToRow(entry)
+		if err != nil {
+			return err
+		}
+		p.dataToUpsert = append(p.dataToUpsert, row)
+	case xdr.LedgerEntryTypeContractCode:
+		row, err := ContractCodeToRow(entry)
+		if err != nil {
+			return err
+		}
+		p.codeToUpsert = append(p.codeToUpsert, row)
+	case xdr.LedgerEntryTypeTtl:
+		p.ttlsToUpsert = append(p.ttlsToUpsert, ContractTTLToRow(entry))
+	}
+	return nil
+}
+
+// addEvictions queues the removal of the evicted entries and of their ttls.
+func (p *ContractStateProcessor) addEvictions() error {
+	for _, key := range p.evictedLedgerKeys {
+		switch key.Type {
+		case xdr.LedgerEntryTypeContractData, xdr.LedgerEntryTypeContractCode:
+			bin, err := key.MarshalBinary()
+			if err != nil {
+				return errors.Wrap(err, "could not marshal evicted ledger key")
+			}
+			keyHash := sha256.Sum256(bin)
+			hexKeyHash := hex.EncodeToString(keyHash[:])
+			if key.Type == xdr.LedgerEntryTypeContractData {
+				p.dataToRemove = append(p.dataToRemove, hexKeyHash)
+			} else {
+				p.codeToRemove = append(p.codeToRemove, hexKeyHash)
+			}
+			p.ttlsToRemove = append(p.ttlsToRemove, hexKeyHash)
+		case xdr.LedgerEntryTypeTtl:
+			p.ttlsToRemove = append(p.ttlsToRemove, key.MustTtl().KeyHash.HexString())
+		}
+	}
+	p.evictedLedgerKeys = nil
+	return nil
+}
+
+func (p *ContractStateProcessor) Commit(ctx context.Context) error {
+	defer p.reset()
+
+	if err := p.addEvictions(); err != nil {
+		return err
+	}
+
+	if len(p.dataToUpsert) > 0 {
+		if err := p.qContractState.UpsertContractData(ctx, p.dataToUpsert); err != nil {
+			return errors.Wrap(err, "error upserting contract data")
+		}
+	}
+	if len(p.codeToUpsert) > 0 {
+		if err := p.qContractState.UpsertContractCode(ctx, p.codeToUpsert); err != nil {
+			return errors.Wrap(err, "error upserting contract code")
+		}
+	}
+	if len(p.ttlsToUpsert) > 0 {
+		if err := p.qContractState.UpsertContractTTLs(ctx, p.ttlsToUpsert); err != nil {
+			return errors.Wrap(err, "error upserting contract ttls")
+		}
+	}
+
+	// The ttl of an evicted entry can be evicted along with it, or its rows
+	// can be missing, so the number of removed rows is not checked.
+	if len(p.dataToRemove) > 0 {
+		if _, err := p.qContractState.RemoveContractData(ctx, p.dataToRemove); err != nil {
+			return errors.Wrap(err, "error removing contract data")
+		}
+	}
+	if len(p.codeToRemove) > 0 {
+		if _, err := p.qContractState.RemoveContractCode(ctx, p.codeToRemove); err != nil {
+			return errors.Wrap(err, "error removing contract code")
+		}
+	}
+	if len(p.ttlsToRemove) > 0 {
+		if _, err := p.qContractState.RemoveContractTTLs(ctx, p.ttlsToRemove); err != nil {
+			return errors.Wrap(err, "error removing contract ttls")
+		}
+	}
+
+	return nil
+}
+
+// contractStateKeyHash returns the hex encoded key hash of the entry, which is
+// the key hash referenced by ttl entries. The key hash of a ttl entry is the
+// key hash of the entry it refers to.
+func contractStateKeyHash(entry xdr.LedgerEntry) (string, error) {
+	if ttl, ok := entry.Data.GetTtl(); ok {
+		return ttl.KeyHash.HexString(), nil
+	}
+	keyHash, err := getKeyHash(entry)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(keyHash[:]), nil
+}
+
+// ContractDataToRow returns the contract_data row of a contract data entry.
+func ContractDataToRow(entry xdr.LedgerEntry) (history.ContractData, error) {
+	data := entry.Data.MustContractData()
+	keyHash, err := contractStateKeyHash(entry)
+	if err != nil {
+		return history.ContractData{}, err
+	}
+	contractID, err := data.Contract.String()
+	if err != nil {
+		return history.ContractData{}, errors.Wrap(err, "could not encode contract address")
+	}
+	key, err := xdr.MarshalBase64(data.Key)
+	if err != nil {
+		return history.ContractData{}, errors.Wrap(err, "could not encode contract data key")
+	}
+	value, err := xdr.MarshalBase64(data.Val)
+	if err != nil {
+		return history.ContractData{}, errors.Wrap(err, "could not encode contract data value")
+	}
+	return history.ContractData{
+		KeyHash:            keyHash,
+		ContractID:         contractID,
+		Durability:         int32(data.Durability),
+		Key:                key,
+		Value:              value,
+		LastModifiedLedger: uint32(entry.LastModifiedLedgerSeq),
+	}, nil
+}
+
+// ContractCodeToRow returns the contract_code row of a contract code entry.
+func ContractCodeToRow(entry xdr.LedgerEntry) (history.ContractCode, error) {
+	keyHash, err := contractStateKeyHash(entry)
+	if err != nil {
+		return history.ContractCode{}, err
+	}
+	code := entry.Data.MustContractCode()
+	return history.ContractCode{
+		Hash:               code.Hash.HexString(),
+		KeyHash:            keyHash,
+		Code:               base64.StdEncoding.EncodeToString(code.Code),
+		LastModifiedLedger: uint32(entry.LastModifiedLedgerSeq),
+	}, nil
+}
+
+// ContractTTLToRow returns the contract_ttls row of a ttl entry.
+func ContractTTLToRow(entry xdr.LedgerEntry) history.ContractTTL {
+	ttl := entry.Data.MustTtl()
+	return history.ContractTTL{
+		KeyHash:            ttl.KeyHash.HexString(),
+		LiveUntilLedgerSeq: uint32(ttl.LiveUntilLedgerSeq),
+		LastModifiedLedger: uint32(entry.LastModifiedLedgerSeq),
+	}
+}
@@ -0,0 +1,180 @@
+package processors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/xdr"
+)
+
+func TestContractStateProcessor(t *testing.T) {
+	ctx := context.Background()
+
+	var contractID xdr.ContractId
+	contractID[0] = 1
+	symbol := xdr.ScSymbol("counter")
+	value := xdr.Uint32(7)
+	dataEntry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 10,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractData,
+			ContractData: &xdr.ContractDataEntry{
+				Contract: xdr.ScAddress{
+					Type:       xdr.ScAddressTypeScAddressTypeContract,
+					ContractId: &contractID,
+				},
+				Key:        xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &symbol},
+				Durability: xdr.ContractDataDurabilityPersistent,
+				Val:        xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: &value},
+			},
+		},
+	}
+	dataKey, err := dataEntry.LedgerKey()
+	require.NoError(t, err)
+	bin, err := dataKey.MarshalBinary()
+	require.NoError(t, err)
+	dataKeyHash := sha256.Sum256(bin)
+
+	codeEntry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 11,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeContractCode,
+			ContractCode: &xdr.ContractCodeEntry{
+				Hash: xdr.Hash{2},
+				Code: []byte{0, 0x61, 0x73, 0x6d},
+			},
+		},
+	}
+	codeKey, err := codeEntry.LedgerKey()
+	require.NoError(t, err)
+	bin, err = codeKey.MarshalBinary()
+	require.NoError(t, err)
+	codeKeyHash := sha256.Sum256(bin)
+
+	ttlEntry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 12,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl: &xdr.TtlEntry{
+				KeyHash:            dataKeyHash,
+				LiveUntilLedgerSeq: 1000,
+			},
+		},
+	}
+
+	mockQ := &history.MockQContractState{}
+	mockQ.On("UpsertContractData", ctx, []history.ContractData{{
+		KeyHash:            hex.EncodeToString(dataKeyHash[:]),
+		ContractID:         "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Durability:         int32(xdr.ContractDataDurabilityPersistent),
+		Key:                "AAAADwAAAAdjb3VudGVyAA==",
+		Value:              "AAAAAwAAAAc=",
+		LastModifiedLedger: 10,
+	}}).Return(nil).Once()
+	mockQ.On("UpsertContractCode", ctx, []history.ContractCode{{
+		Hash:               "0200000000000000000000000000000000000000000000000000000000000000",
+		KeyHash:            hex.EncodeToString(codeKeyHash[:]),
+		Code:               "AGFzbQ==",
+		LastModifiedLedger: 11,
+	}}).Return(nil).Once()
+	mockQ.On("UpsertContractTTLs", ctx, []history.ContractTTL{{
+		KeyHash:            hex.EncodeToString(dataKeyHash[:]),
+		LiveUntilLedgerSeq: 1000,
+		LastModifiedLedger: 12,
+	}}).Return(nil).Once()
+
+	processor := NewContractStateProcessor(mockQ, nil)
+	for _, entry := range []xdr.LedgerEntry{dataEntry, codeEntry, ttlEntry} {
+		entry := entry
+		require.NoError(t, processor.ProcessChange(ctx, ingest.Change{
+			Type: entry.Data.Type,
+			Post: &entry,
+		}))
+	}
+	// other ledger entries are ignored
+	require.NoError(t, processor.ProcessChange(ctx, ingest.Change{
+		Type: xdr.LedgerEntryTypeAccount,
+		Post: &xdr.LedgerEntry{},
+	}))
+	require.NoError(t, processor.Commit(ctx))
+	mockQ.AssertExpectations(t)
+
+	mockQ = &history.MockQContractState{}
+	mockQ.On("RemoveContractData", ctx, []string{hex.EncodeToString(dataKeyHash[:])}).
+		Return(int64(1), nil).Once()
+	mockQ.On("RemoveContractCode", ctx, []string{hex.EncodeToString(codeKeyHash[:])}).
+		Return(int64(1), nil).Once()
+	// the number of removed rows is not checked
+	mockQ.On("RemoveContractTTLs", ctx, []string{hex.EncodeToString(dataKeyHash[:])}).
+		Return(int64(0), nil).Once()
+
+	processor = NewContractStateProcessor(mockQ, nil)
+	for _, entry := range []xdr.LedgerEntry{dataEntry, codeEntry, ttlEntry} {
+		entry := entry
+		require.NoError(t, processor.ProcessChange(ctx, ingest.Change{
+			Type: entry.Data.Type,
+			Pre:  &entry,
+		}))
+	}
+	assert.NoError(t, processor.Commit(ctx))
+	mockQ.AssertExpectations(t)
+}
+
+func TestContractStateProcessorEvictions(t *testing.T) {
+	ctx := context.Background()
+
+	dataKey := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeContractData,
+		ContractData: &xdr.LedgerKeyContractData{
+			Contract: xdr.ScAddress{
+				Type:       xdr.ScAddressTypeScAddressTypeContract,
+				ContractId: &xdr.ContractId{1},
+			},
+			Key:        xdr.ScVal{Type: xdr.ScValTypeScvLedgerKeyContractInstance},
+			Durability: xdr.ContractDataDurabilityTemporary,
+		},
+	}
+	bin, err := dataKey.MarshalBinary()
+	require.NoError(t, err)
+	dataKeyHash := sha256.Sum256(bin)
+	codeKey := xdr.LedgerKey{
+		Type:         xdr.LedgerEntryTypeContractCode,
+		ContractCode: &xdr.LedgerKeyContractCode{Hash: xdr.Hash{2}},
+	}
+	bin, err = codeKey.MarshalBinary()
+	require.NoError(t, err)
+	codeKeyHash := sha256.Sum256(bin)
+	ttlKey := xdr.LedgerKey{
+		Type: xdr.LedgerEntryTypeTtl,
+		Ttl:  &xdr.LedgerKeyTtl{KeyHash: dataKeyHash},
+	}
+	accountKey := xdr.LedgerKey{
+		Type:    xdr.LedgerEntryTypeAccount,
+		Account: &xdr.LedgerKeyAccount{AccountId: xdr.MustAddress("GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB")},
+	}
+
+	mockQ := &history.MockQContractState{}
+	mockQ.On("RemoveContractData", ctx, []string{hex.EncodeToString(dataKeyHash[:])}).
+		Return(int64(1), nil).Once()
+	mockQ.On("RemoveContractCode", ctx, []string{hex.EncodeToString(codeKeyHash[:])}).
+		Return(int64(1), nil).Once()
+	// the ttls of the evicted entries are removed too
+	mockQ.On("RemoveContractTTLs", ctx, []string{
+		hex.EncodeToString(dataKeyHash[:]),
+		hex.EncodeToString(codeKeyHash[:]),
+		hex.EncodeToString(dataKeyHash[:]),
+	}).Return(int64(2), nil).Once()
+
+	processor := NewContractStateProcessor(mockQ, []xdr.LedgerKey{dataKey, codeKey, ttlKey, accountKey})
+	require.NoError(t, processor.Commit(ctx))
+	// evictions are only removed once
+	require.NoError(t, processor.Commit(ctx))
+	mockQ.AssertExpectations(t)
+}
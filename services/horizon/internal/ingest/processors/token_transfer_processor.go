@@ -0,0 +1,108 @@
+package processors
+
+import (
+	"context"
+
+	"github.com/guregu/null"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// TokenTransferProcessor stores the token transfer events (transfers, mints,
+// burns, clawbacks and fees) of classic assets, stellar asset contracts and
+// SEP-41 tokens in the history_token_transfers table.
+type TokenTransferProcessor struct {
+	eventsProcessor *token_transfer.EventsProcessor
+	batch           history.TokenTransferBatchInsertBuilder
+}
+
+func NewTokenTransferProcessor(batch history.TokenTransferBatchInsertBuilder, networkPassphrase string) *TokenTransferProcessor {
+	return &TokenTransferProcessor{
+		eventsProcessor: token_transfer.NewEventsProcessor(networkPassphrase),
+		batch:           batch,
+	}
+}
+
+func (p *TokenTransferProcessor) Name() string {
+	return "processors.TokenTransferProcessor"
+}
+
+func (p *TokenTransferProcessor) ProcessTransaction(lcm xdr.LedgerCloseMeta, transaction ingest.LedgerTransaction) error {
+	events, err := p.eventsProcessor.EventsFromTransaction(transaction)
+	if err != nil {
+		return errors.Wrap(err, "could not read token transfer events")
+	}
+
+	ledgerSeq := int32(lcm.LedgerSequence())
+	txIndex := int32(transaction.Index)
+	// fee events, including the refund of soroban transactions, are
+	// attached to the transaction id
+	orders := map[int64]int32{}
+	for _, event := range append(events.FeeEvents, events.OperationEvents...) {
+		row, err := tokenTransferToRow(event)
+		if err != nil {
+			return err
+		}
+		row.HistoryOperationID = toid.New(ledgerSeq, txIndex, int32(event.GetMeta().GetOperationIndex())).ToInt64()
+		orders[row.HistoryOperationID]++
+		row.Order = orders[row.HistoryOperationID]
+		row.TransactionHash = transaction.Result.TransactionHash.HexString()
+		row.LedgerCloseTime = lcm.ClosedAt()
+		if err := p.batch.Add(row); err != nil {
+			return errors.Wrap(err, "error batch inserting token transfer rows")
+		}
+	}
+
+	return nil
+}
+
+func (p *TokenTransferProcessor) Flush(ctx context.Context, session db.SessionInterface) error {
+	return p.batch.Exec(ctx, session)
+}
+
+// tokenTransferToRow converts the body of the event to a history row.
+func tokenTransferToRow(event *token_transfer.TokenTransferEvent) (history.TokenTransfer, error) {
+	row := history.TokenTransfer{
+		ContractID: event.GetMeta().GetContractAddress(),
+		Amount:     event.GetAmount(),
+	}
+
+	var from, to string
+	switch {
+	case event.GetTransfer() != nil:
+		row.Type = history.TokenTransferTypeTransfer
+		from, to = event.GetTransfer().GetFrom(), event.GetTransfer().GetTo()
+	case event.GetMint() != nil:
+		row.Type = history.TokenTransferTypeMint
+		to = event.GetMint().GetTo()
+	case event.GetBurn() != nil:
+		row.Type = history.TokenTransferTypeBurn
+		from = event.GetBurn().GetFrom()
+	case event.GetClawback() != nil:
+		row.Type = history.TokenTransferTypeClawback
+		from = event.GetClawback().GetFrom()
+	case event.GetFee() != nil:
+		row.Type = history.TokenTransferTypeFee
+		from = event.GetFee().GetFrom()
+	default:
+		return row, errors.Errorf("unknown token transfer event %v", event)
+	}
+	if from != "" {
+		row.FromAddress = null.StringFrom(from)
+	}
+	if to != "" {
+		row.ToAddress = null.StringFrom(to)
+	}
+
+	// custom tokens have no asset
+	if asset := event.GetAsset(); asset != nil {
+		row.Asset = null.StringFrom(asset.ToXdrAsset().StringCanonical())
+	}
+	return row, nil
+}
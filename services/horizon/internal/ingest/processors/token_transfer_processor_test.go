@@ -0,0 +1,130 @@
+package processors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func TestTokenTransferProcessor(t *testing.T) {
+	ctx := context.Background()
+	closeTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	lcm := xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq:     20,
+					LedgerVersion: 22,
+					ScpValue:      xdr.StellarValue{CloseTime: xdr.TimePoint(closeTime.Unix())},
+				},
+			},
+		},
+	}
+
+	source := keypair.MustRandom().Address()
+	destination := keypair.MustRandom().Address()
+	payment := func(amount xdr.Int64) xdr.Operation {
+		return xdr.Operation{
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypePayment,
+				PaymentOp: &xdr.PaymentOp{
+					Destination: xdr.MustMuxedAddress(destination),
+					Asset:       xdr.MustNewNativeAsset(),
+					Amount:      amount,
+				},
+			},
+		}
+	}
+	paymentResult := xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type: xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{
+				Code: xdr.PaymentResultCodePaymentSuccess,
+			},
+		},
+	}
+	transaction := func(successful bool) ingest.LedgerTransaction {
+		code := xdr.TransactionResultCodeTxSuccess
+		if !successful {
+			code = xdr.TransactionResultCodeTxFailed
+		}
+		results := []xdr.OperationResult{paymentResult, paymentResult}
+		return ingest.LedgerTransaction{
+			Index: 3,
+			Envelope: xdr.TransactionEnvelope{
+				Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+				V1: &xdr.TransactionV1Envelope{
+					Tx: xdr.Transaction{
+						SourceAccount: xdr.MustMuxedAddress(source),
+						Fee:           200,
+						Operations:    []xdr.Operation{payment(10), payment(20)},
+					},
+				},
+			},
+			Result: xdr.TransactionResultPair{
+				TransactionHash: xdr.Hash{1, 2, 3},
+				Result: xdr.TransactionResult{
+					FeeCharged: 200,
+					Result: xdr.TransactionResultResult{
+						Code:    code,
+						Results: &results,
+					},
+				},
+			},
+			UnsafeMeta: xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{}},
+			Ledger:     lcm,
+		}
+	}
+
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	xlmContract := strkey.MustEncode(strkey.VersionByteContract, xlmContractID[:])
+	hash := "0102030000000000000000000000000000000000000000000000000000000000"
+	row := func(opIndex int32, order int32, typ int32, from, to, amount string) history.TokenTransfer {
+		transfer := history.TokenTransfer{
+			HistoryOperationID: toid.New(20, 3, opIndex).ToInt64(),
+			Order:              order,
+			TransactionHash:    hash,
+			LedgerCloseTime:    closeTime,
+			Type:               typ,
+			ContractID:         xlmContract,
+			Asset:              null.StringFrom("native"),
+			Amount:             amount,
+		}
+		if from != "" {
+			transfer.FromAddress = null.StringFrom(from)
+		}
+		if to != "" {
+			transfer.ToAddress = null.StringFrom(to)
+		}
+		return transfer
+	}
+
+	batch := &history.MockTokenTransferBatchInsertBuilder{}
+	// the fee events are attached to the transaction
+	batch.On("Add", row(0, 1, history.TokenTransferTypeFee, source, "", "200")).Return(nil).Twice()
+	batch.On("Add", row(1, 1, history.TokenTransferTypeTransfer, source, destination, "10")).Return(nil).Once()
+	batch.On("Add", row(2, 1, history.TokenTransferTypeTransfer, source, destination, "20")).Return(nil).Once()
+	session := &db.MockSession{}
+	batch.On("Exec", ctx, session).Return(nil).Once()
+
+	processor := NewTokenTransferProcessor(batch, network.TestNetworkPassphrase)
+	require.NoError(t, processor.ProcessTransaction(lcm, transaction(true)))
+	// only the fee is transferred by failed transactions
+	require.NoError(t, processor.ProcessTransaction(lcm, transaction(false)))
+	require.NoError(t, processor.Flush(ctx, session))
+	batch.AssertExpectations(t)
+}
@@ -36,6 +36,10 @@ type ReapConfig struct {
 	Frequency      uint
 	RetentionCount uint32
 	BatchSize      uint32
+	// BalanceHistoryRetentionCount is the number of ledgers of balance
+	// history to retain, it is only relevant when it is smaller than
+	// RetentionCount or when all the other history is retained.
+	BalanceHistoryRetentionCount uint32
 }
 
 // NewReaper creates a new Reaper instance
@@ -75,7 +79,7 @@ func newReaper(config ReapConfig, historyQ, reapLockQ history.IngestionQ) *Reape
 // DeleteUnretainedHistory removes all data associated with unretained ledgers.
 func (r *Reaper) DeleteUnretainedHistory(ctx context.Context) error {
 	// RetentionCount of 0 indicates "keep all history"
-	if r.config.RetentionCount == 0 {
+	if r.config.RetentionCount == 0 && r.config.BalanceHistoryRetentionCount == 0 {
 		return nil
 	}
 
@@ -106,6 +110,13 @@ func (r *Reaper) DeleteUnretainedHistory(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "error fetching latest history ledger")
 	}
+	// a failure to reap the balance history doesn't hold back reaping the
+	// rest of the history, its error is returned once the reap is done
+	balanceErr := r.deleteUnretainedBalanceHistory(ctx, latest)
+	if r.config.RetentionCount == 0 {
+		return balanceErr
+	}
+
 	var oldest uint32
 	err = r.historyQ.ElderLedger(ctx, &oldest)
 	if err != nil {
@@ -119,13 +130,13 @@ func (r *Reaper) DeleteUnretainedHistory(ctx context.Context) error {
 			WithField("oldest", oldest).
 			WithField("retention_count", r.config.RetentionCount).
 			Info("not enough history to reap")
-		return nil
+		return balanceErr
 	}
 
 	startTime := time.Now()
 	var totalDeleted int64
 	var complete bool
-	totalDeleted, err = r.clearBefore(ctx, oldest, targetElder)
+	totalDeleted, err = r.clearBefore(ctx, oldest, targetElder, r.deleteHistoryRange, r.historyQ.GetNextLedgerSequence)
 	elapsedSeconds := time.Since(startTime).Seconds()
 	logger := r.logger.
 		WithField("duration", elapsedSeconds).
@@ -145,9 +156,45 @@ func (r *Reaper) DeleteUnretainedHistory(ctx context.Context) error {
 	}
 	r.totalDeleted.With(labels).Observe(float64(totalDeleted))
 	r.totalDuration.With(labels).Observe(elapsedSeconds)
+	if err == nil {
+		err = balanceErr
+	}
 	return err
 }
 
+// deleteUnretainedBalanceHistory removes the balance snapshots of the
+// ledgers outside the balance history retention window, in batches.
+func (r *Reaper) deleteUnretainedBalanceHistory(ctx context.Context, latest uint32) error {
+	retentionCount := r.config.BalanceHistoryRetentionCount
+	if retentionCount == 0 || latest <= retentionCount {
+		return nil
+	}
+	targetElder := latest - retentionCount + 1
+
+	oldest, ok, err := r.historyQ.GetNextBalanceHistoryLedger(ctx, 0)
+	if err != nil {
+		r.logger.WithError(err).Warn("balance history reaper failed")
+		return errors.Wrap(err, "error fetching elder balance history ledger")
+	}
+	if !ok || oldest >= targetElder {
+		return nil
+	}
+
+	startTime := time.Now()
+	totalDeleted, err := r.clearBefore(ctx, oldest, targetElder, r.deleteBalanceHistoryRange, r.historyQ.GetNextBalanceHistoryLedger)
+	logger := r.logger.
+		WithField("duration", time.Since(startTime).Seconds()).
+		WithField("rows_deleted", totalDeleted)
+	if err != nil {
+		logger.WithError(err).Warn("balance history reaper failed")
+		return err
+	}
+	logger.
+		WithField("new_elder", targetElder).
+		Info("deleted balance history outside retention window")
+	return nil
+}
+
 // RegisterMetrics registers the prometheus metrics
 func (s *Reaper) RegisterMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(
@@ -170,7 +217,20 @@ func (s *Reaper) RegisterMetrics(registry *prometheus.Registry) {
 // hour, and slowing it down enough to leave some CPU for other processes.
 var sleep = 1 * time.Second
 
-func (r *Reaper) clearBefore(ctx context.Context, startSeq, endSeq uint32) (int64, error) {
+// ledgerRangeDeleter deletes the rows of the ledgers in [startSeq, endSeq]
+// and returns the number of deleted rows.
+type ledgerRangeDeleter func(ctx context.Context, startSeq, endSeq uint32) (int64, error)
+
+// nextLedgerFinder returns the first ledger following seq with rows to
+// delete, and false if there is none.
+type nextLedgerFinder func(ctx context.Context, seq uint32) (uint32, bool, error)
+
+func (r *Reaper) clearBefore(
+	ctx context.Context,
+	startSeq, endSeq uint32,
+	deleteRange ledgerRangeDeleter,
+	nextLedger nextLedgerFinder,
+) (int64, error) {
 	batchSize := r.config.BatchSize
 	var sum int64
 	if batchSize <= 0 {
@@ -188,13 +248,13 @@ func (r *Reaper) clearBefore(ctx context.Context, startSeq, endSeq uint32) (int6
 			batchEndSeq = endSeq - 1
 		}
 
-		count, err := r.deleteBatch(ctx, batchStartSeq, batchEndSeq)
+		count, err := r.deleteBatch(ctx, batchStartSeq, batchEndSeq, deleteRange)
 		if err != nil {
 			return sum, err
 		}
 		sum += count
 		if count == 0 {
-			next, ok, err := r.historyQ.GetNextLedgerSequence(ctx, batchStartSeq)
+			next, ok, err := nextLedger(ctx, batchStartSeq)
 			if err != nil {
 				return sum, errors.Wrapf(err, "could not find next ledger sequence after %d", batchStartSeq)
 			}
@@ -211,22 +271,41 @@ func (r *Reaper) clearBefore(ctx context.Context, startSeq, endSeq uint32) (int6
 	return sum, nil
 }
 
-func (r *Reaper) deleteBatch(ctx context.Context, batchStartSeq, batchEndSeq uint32) (int64, error) {
-	batchStart, batchEnd, err := toid.LedgerRangeInclusive(int32(batchStartSeq), int32(batchEndSeq))
+// deleteHistoryRange deletes the rows of the ledgers in [startSeq, endSeq]
+// from all the history tables.
+func (r *Reaper) deleteHistoryRange(ctx context.Context, startSeq, endSeq uint32) (int64, error) {
+	start, end, err := toid.LedgerRangeInclusive(int32(startSeq), int32(endSeq))
 	if err != nil {
 		return 0, err
 	}
+	count, err := r.historyQ.DeleteRangeAll(ctx, start, end)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error in DeleteRangeAll")
+	}
+	return count, nil
+}
 
+// deleteBalanceHistoryRange deletes the balance snapshots of the ledgers in
+// [startSeq, endSeq].
+func (r *Reaper) deleteBalanceHistoryRange(ctx context.Context, startSeq, endSeq uint32) (int64, error) {
+	count, err := r.historyQ.DeleteBalanceHistoryRange(ctx, startSeq, endSeq+1)
+	if err != nil {
+		return 0, errors.Wrap(err, "Error in DeleteBalanceHistoryRange")
+	}
+	return count, nil
+}
+
+func (r *Reaper) deleteBatch(ctx context.Context, batchStartSeq, batchEndSeq uint32, deleteRange ledgerRangeDeleter) (int64, error) {
 	startTime := time.Now()
-	err = r.historyQ.Begin(ctx)
+	err := r.historyQ.Begin(ctx)
 	if err != nil {
 		return 0, errors.Wrap(err, "Error in begin")
 	}
 	defer r.historyQ.Rollback()
 
-	count, err := r.historyQ.DeleteRangeAll(ctx, batchStart, batchEnd)
+	count, err := deleteRange(ctx, batchStartSeq, batchEndSeq)
 	if err != nil {
-		return 0, errors.Wrap(err, "Error in DeleteRangeAll")
+		return 0, err
 	}
 
 	err = r.historyQ.Commit()
@@ -204,6 +204,87 @@ func (t *ReaperTestSuite) TestSucceeds() {
 	t.Assert().NoError(t.reaper.DeleteUnretainedHistory(t.ctx))
 }
 
+func (t *ReaperTestSuite) TestBalanceHistoryOnly() {
+	t.reaper.config.RetentionCount = 0
+	t.reaper.config.BalanceHistoryRetentionCount = 20
+	assertMocksInOrder(
+		t.reapLockQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.reapLockQ.On("TryReaperLock", t.ctx).Return(true, nil).Once(),
+		t.historyQ.On("GetLatestHistoryLedger", t.ctx).Return(uint32(90), nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("GetNextBalanceHistoryLedger", t.ctx, uint32(0)).
+			Return(uint32(40), true, nil).Once(),
+		t.historyQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("DeleteBalanceHistoryRange", t.ctx, uint32(40), uint32(51)).
+			Return(int64(0), nil).Once(),
+		t.historyQ.On("Commit").Return(nil).Once(),
+		t.historyQ.On("Rollback").Return(nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("GetNextBalanceHistoryLedger", t.ctx, uint32(40)).
+			Return(uint32(55), true, nil).Once(),
+		t.historyQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("DeleteBalanceHistoryRange", t.ctx, uint32(55), uint32(66)).
+			Return(int64(10), nil).Once(),
+		t.historyQ.On("Commit").Return(nil).Once(),
+		t.historyQ.On("Rollback").Return(nil).Once(),
+		t.historyQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("DeleteBalanceHistoryRange", t.ctx, uint32(66), uint32(71)).
+			Return(int64(5), nil).Once(),
+		t.historyQ.On("Commit").Return(nil).Once(),
+		t.historyQ.On("Rollback").Return(nil).Once(),
+		t.reapLockQ.On("Rollback").Return(nil).Once(),
+	)
+	t.Assert().NoError(t.reaper.DeleteUnretainedHistory(t.ctx))
+	t.historyQ.MockQBalanceHistory.AssertExpectations(t.T())
+}
+
+func (t *ReaperTestSuite) TestBalanceHistoryWithinRetention() {
+	t.reaper.config.RetentionCount = 0
+	t.reaper.config.BalanceHistoryRetentionCount = 20
+	assertMocksInOrder(
+		t.reapLockQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.reapLockQ.On("TryReaperLock", t.ctx).Return(true, nil).Once(),
+		t.historyQ.On("GetLatestHistoryLedger", t.ctx).Return(uint32(90), nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("GetNextBalanceHistoryLedger", t.ctx, uint32(0)).
+			Return(uint32(71), true, nil).Once(),
+		t.reapLockQ.On("Rollback").Return(nil).Once(),
+	)
+	t.Assert().NoError(t.reaper.DeleteUnretainedHistory(t.ctx))
+	t.historyQ.MockQBalanceHistory.AssertExpectations(t.T())
+}
+
+func (t *ReaperTestSuite) TestBalanceHistoryFails() {
+	t.reaper.config.BalanceHistoryRetentionCount = 20
+	assertMocksInOrder(
+		t.reapLockQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.reapLockQ.On("TryReaperLock", t.ctx).Return(true, nil).Once(),
+		t.historyQ.On("GetLatestHistoryLedger", t.ctx).Return(uint32(90), nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("GetNextBalanceHistoryLedger", t.ctx, uint32(0)).
+			Return(uint32(50), true, nil).Once(),
+		t.historyQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.historyQ.MockQBalanceHistory.On("DeleteBalanceHistoryRange", t.ctx, uint32(50), uint32(61)).
+			Return(int64(0), fmt.Errorf("transient error")).Once(),
+		t.historyQ.On("Rollback").Return(nil).Once(),
+		// the rest of the history is reaped nonetheless
+		t.historyQ.On("ElderLedger", t.ctx, mock.AnythingOfType("*uint32")).
+			Return(nil).Once().Run(
+			func(args mock.Arguments) {
+				ledger := args.Get(1).(*uint32)
+				*ledger = 55
+			}),
+		t.historyQ.On("Begin", t.ctx).Return(nil).Once(),
+		t.historyQ.On("DeleteRangeAll", t.ctx,
+			toid.New(55, 0, 0).ToInt64(), toid.New(61, 0, 0).ToInt64(),
+		).Return(int64(400), nil).Once(),
+		t.historyQ.On("Commit").Return(nil).Once(),
+		t.historyQ.On("Rollback").Return(nil).Once(),
+		t.reapLockQ.On("Rollback").Return(nil).Once(),
+	)
+	t.Assert().EqualError(
+		t.reaper.DeleteUnretainedHistory(t.ctx),
+		"Error in DeleteBalanceHistoryRange: transient error",
+	)
+	t.historyQ.MockQBalanceHistory.AssertExpectations(t.T())
+}
+
 func (t *ReaperTestSuite) TestFails() {
 	assertMocksInOrder(
 		t.reapLockQ.On("Begin", t.ctx).Return(nil).Once(),
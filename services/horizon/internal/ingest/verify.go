@@ -69,7 +69,7 @@ type StateVerifier struct {
 // check them.
 // There is a test that checks it, to fix it: update the actual `verifyState`
 // method instead of just updating this value!
-const StateVerifierExpectedIngestionVersion = 20
+const StateVerifierExpectedIngestionVersion = 21
 
 func NewStateVerifier(stateReader ingestsdk.ChangeReader, tf TransformLedgerEntryFunction) *StateVerifier {
 	return &StateVerifier{
@@ -395,10 +395,9 @@ func (s *system) verifyState(verifyAgainstLatestCheckpoint bool, checkpointSeque
 
 	verifier := NewStateVerifier(stateReader, func(entry xdr.LedgerEntry) (bool, xdr.LedgerEntry) {
 		entryType := entry.Data.Type
-		// Won't be persisting protocol 20 ContractData ledger entries (except for Stellar Asset Contract
-		// ledger entries) to the history db, therefore must not allow it
-		// to be counted in history state-verifier accumulators.
-		if entryType == xdr.LedgerEntryTypeConfigSetting || entryType == xdr.LedgerEntryTypeContractCode {
+		// Config settings are not stored in the history db, therefore must
+		// not be counted in history state-verifier accumulators.
+		if entryType == xdr.LedgerEntryTypeConfigSetting {
 			return true, entry
 		}
 
@@ -426,6 +425,7 @@ func (s *system) verifyState(verifyAgainstLatestCheckpoint bool, checkpointSeque
 		trustLines := make([]xdr.LedgerKeyTrustLine, 0, verifyBatchSize)
 		cBalances := make([]xdr.ClaimableBalanceId, 0, verifyBatchSize)
 		lPools := make([]xdr.PoolId, 0, verifyBatchSize)
+		var contractState contractStateRows
 		for _, entry := range entries {
 			switch entry.Data.Type {
 			case xdr.LedgerEntryTypeAccount:
@@ -455,20 +455,33 @@ func (s *system) verifyState(verifyAgainstLatestCheckpoint bool, checkpointSeque
 				lPools = append(lPools, entry.Data.MustLiquidityPool().LiquidityPoolId)
 				totalByType["liquidity_pools"]++
 			case xdr.LedgerEntryTypeContractData:
-				// contract data is a special case.
-				// we don't store contract data entries in the db,
-				// however, we ingest contract data entries for asset stats.
+				// contract data entries are stored as rows which don't
+				// reconstruct the ledger entry, they are checked by
+				// checkContractState. We also ingest contract data entries
+				// for asset stats.
 				if err = verifier.Write(entry); err != nil {
 					return err
 				}
+				if err = contractState.add(entry); err != nil {
+					return err
+				}
 				contractDataEntries = append(contractDataEntries, entry)
 				totalByType["contract_data"]++
+			case xdr.LedgerEntryTypeContractCode:
+				if err = verifier.Write(entry); err != nil {
+					return err
+				}
+				if err = contractState.add(entry); err != nil {
+					return err
+				}
+				totalByType["contract_code"]++
 			case xdr.LedgerEntryTypeTtl:
-				// we don't store all expiration entries in the db,
-				// we will only verify expiration of contract balances in the horizon db.
 				if err = verifier.Write(entry); err != nil {
 					return err
 				}
+				if err = contractState.add(entry); err != nil {
+					return err
+				}
 				totalByType["ttl"]++
 				ttl := entry.Data.MustTtl()
 				createdExpirationEntries[ttl.KeyHash] = uint32(ttl.LiveUntilLedgerSeq)
@@ -508,6 +521,11 @@ func (s *system) verifyState(verifyAgainstLatestCheckpoint bool, checkpointSeque
 			return errors.Wrap(err, "addLiquidityPoolsToStateVerifier failed")
 		}
 
+		err = checkContractState(ctx, historyQ, contractState)
+		if err != nil {
+			return errors.Wrap(err, "checkContractState failed")
+		}
+
 		total += int64(len(entries))
 		localLog.WithField("total", total).Info("Batch added to StateVerifier")
 	}
@@ -562,9 +580,15 @@ func (s *system) verifyState(verifyAgainstLatestCheckpoint bool, checkpointSeque
 		return errors.Wrap(err, "Error running historyQ.CountLiquidityPools")
 	}
 
+	err = checkContractStateCounts(ctx, historyQ, totalByType)
+	if err != nil {
+		return errors.Wrap(err, "checkContractStateCounts failed")
+	}
+
 	err = verifier.Verify(
 		countAccounts + countData + countOffers + countTrustLines + countClaimableBalances +
-			countLiquidityPools + int(totalByType["contract_data"]) + int(totalByType["ttl"]),
+			countLiquidityPools + int(totalByType["contract_data"]) + int(totalByType["contract_code"]) +
+			int(totalByType["ttl"]),
 	)
 	if err != nil {
 		return errors.Wrap(err, "verifier.Verify failed")
@@ -793,6 +817,134 @@ func checkContractBalances(
 	return nil
 }
 
+// contractStateRows are the expected rows of the contract_data, contract_code
+// and contract_ttls tables for a batch of ledger entries.
+type contractStateRows struct {
+	data []history.ContractData
+	code []history.ContractCode
+	ttls []history.ContractTTL
+}
+
+func (r *contractStateRows) add(entry xdr.LedgerEntry) error {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeContractData:
+		row, err := processors.ContractDataToRow(entry)
+		if err != nil {
+			return err
+		}
+		r.data = append(r.data, row)
+	case xdr.LedgerEntryTypeContractCode:
+		row, err := processors.ContractCodeToRow(entry)
+		if err != nil {
+			return err
+		}
+		r.code = append(r.code, row)
+	case xdr.LedgerEntryTypeTtl:
+		r.ttls = append(r.ttls, processors.ContractTTLToRow(entry))
+	}
+	return nil
+}
+
+// checkContractState checks the rows of the contract_data, contract_code and
+// contract_ttls tables match the ledger entries in the HAS.
+func checkContractState(ctx context.Context, q history.IngestionQ, expected contractStateRows) error {
+	if len(expected.data) > 0 {
+		keyHashes := make([]string, 0, len(expected.data))
+		for _, row := range expected.data {
+			keyHashes = append(keyHashes, row.KeyHash)
+		}
+		rows, err := q.GetContractDataByKeyHashes(ctx, keyHashes)
+		if err != nil {
+			return err
+		}
+		byKeyHash := map[string]history.ContractData{}
+		for _, row := range rows {
+			// the ttl is checked with the contract_ttls rows
+			row.LiveUntilLedgerSeq = null.Int{}
+			byKeyHash[row.KeyHash] = row
+		}
+		for _, row := range expected.data {
+			if actual, ok := byKeyHash[row.KeyHash]; !ok || actual != row {
+				return ingestsdk.NewStateError(
+					fmt.Errorf("contract data %s is %+v in HAS but %+v in db", row.KeyHash, row, actual),
+				)
+			}
+		}
+	}
+
+	if len(expected.code) > 0 {
+		keyHashes := make([]string, 0, len(expected.code))
+		for _, row := range expected.code {
+			keyHashes = append(keyHashes, row.KeyHash)
+		}
+		rows, err := q.GetContractCodeByKeyHashes(ctx, keyHashes)
+		if err != nil {
+			return err
+		}
+		byKeyHash := map[string]history.ContractCode{}
+		for _, row := range rows {
+			row.LiveUntilLedgerSeq = null.Int{}
+			byKeyHash[row.KeyHash] = row
+		}
+		for _, row := range expected.code {
+			if actual, ok := byKeyHash[row.KeyHash]; !ok || actual != row {
+				return ingestsdk.NewStateError(
+					fmt.Errorf("contract code %s is %+v in HAS but %+v in db", row.KeyHash, row, actual),
+				)
+			}
+		}
+	}
+
+	if len(expected.ttls) > 0 {
+		keyHashes := make([]string, 0, len(expected.ttls))
+		for _, row := range expected.ttls {
+			keyHashes = append(keyHashes, row.KeyHash)
+		}
+		rows, err := q.GetContractTTLsByKeyHashes(ctx, keyHashes)
+		if err != nil {
+			return err
+		}
+		byKeyHash := map[string]history.ContractTTL{}
+		for _, row := range rows {
+			byKeyHash[row.KeyHash] = row
+		}
+		for _, row := range expected.ttls {
+			if actual, ok := byKeyHash[row.KeyHash]; !ok || actual != row {
+				return ingestsdk.NewStateError(
+					fmt.Errorf("contract ttl %s is %+v in HAS but %+v in db", row.KeyHash, row, actual),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// checkContractStateCounts checks the contract_data, contract_code and
+// contract_ttls tables have no rows besides the ledger entries in the HAS,
+// e.g. rows of evicted entries.
+func checkContractStateCounts(ctx context.Context, q history.IngestionQ, totalByType map[string]int64) error {
+	for _, table := range []struct {
+		name  string
+		count func(context.Context) (int, error)
+		total int64
+	}{
+		{"contract_data", q.CountContractData, totalByType["contract_data"]},
+		{"contract_code", q.CountContractCode, totalByType["contract_code"]},
+		{"contract_ttls", q.CountContractTTLs, totalByType["ttl"]},
+	} {
+		count, err := table.count(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "Error counting rows of %s", table.name)
+		}
+		if int64(count) != table.total {
+			return ingestsdk.NewStateError(
+				fmt.Errorf("%s has %d rows in db but %d entries in HAS", table.name, count, table.total),
+			)
+		}
+	}
+	return nil
+}
+
 func addAccountsToStateVerifier(ctx context.Context, verifier *StateVerifier, q history.IngestionQ, ids []string) error {
 	if len(ids) == 0 {
 		return nil
@@ -599,6 +599,9 @@ func (s *VerifyRangeStateTestSuite) TestSuccessWithVerify() {
 		}, nil).Once()
 
 	clonedQ.MockQLiquidityPools.On("CountLiquidityPools", s.ctx).Return(1, nil).Once()
+	clonedQ.MockQContractState.On("CountContractData", s.ctx).Return(0, nil).Once()
+	clonedQ.MockQContractState.On("CountContractCode", s.ctx).Return(0, nil).Once()
+	clonedQ.MockQContractState.On("CountContractTTLs", s.ctx).Return(0, nil).Once()
 	clonedQ.MockQLiquidityPools.
 		On("GetLiquidityPoolsByID", s.ctx, []string{liquidityPool.PoolID}).
 		Return([]history.LiquidityPool{liquidityPool}, nil).Once()
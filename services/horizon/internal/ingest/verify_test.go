@@ -1,6 +1,7 @@
 package ingest
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"fmt"
@@ -595,7 +596,7 @@ func TestTruncateIngestStateTables(t *testing.T) {
 	// insert ledger entries of all types into the DB
 	tt.Assert.NoError(q.BeginTx(tt.Ctx, &sql.TxOptions{}))
 	checkpointLedger := uint32(63)
-	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "")
+	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "", nil)
 	for _, change := range ingestsdk.GetChangesFromLedgerEntryChanges(ledgerEntries) {
 		tt.Assert.NoError(changeProcessor.ProcessChange(tt.Ctx, change))
 	}
@@ -607,7 +608,7 @@ func TestTruncateIngestStateTables(t *testing.T) {
 
 	// reinsert the same ledger entries from before
 	tt.Assert.NoError(q.BeginTx(tt.Ctx, &sql.TxOptions{}))
-	changeProcessor = buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "")
+	changeProcessor = buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "", nil)
 	for _, change := range ingestsdk.GetChangesFromLedgerEntryChanges(ledgerEntries) {
 		tt.Assert.NoError(changeProcessor.ProcessChange(tt.Ctx, change))
 	}
@@ -627,7 +628,7 @@ func TestStateVerifierLockBusy(t *testing.T) {
 	tt.Assert.NoError(q.BeginTx(tt.Ctx, &sql.TxOptions{}))
 
 	checkpointLedger := uint32(63)
-	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "")
+	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "", nil)
 
 	for _, change := range ingestsdk.GetChangesFromLedgerEntryChanges(generateRandomLedgerEntries(tt)) {
 		tt.Assert.NoError(changeProcessor.ProcessChange(tt.Ctx, change))
@@ -673,7 +674,7 @@ func TestStateVerifier(t *testing.T) {
 
 	ledger := rand.Int31()
 	checkpointLedger := uint32(ledger - (ledger % 64) - 1)
-	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "")
+	changeProcessor := buildChangeProcessor(q, &processors.StatsChangeProcessor{}, historyArchiveSource, checkpointLedger, "", nil)
 	mockChangeReader := &ingestsdk.MockChangeReader{}
 
 	for _, change := range ingestsdk.GetChangesFromLedgerEntryChanges(generateRandomLedgerEntries(tt)) {
@@ -771,3 +772,61 @@ func generateRandomLedgerEntries(tt *test.T) []xdr.LedgerEntryChange {
 
 	return changes
 }
+
+func TestCheckContractState(t *testing.T) {
+	ctx := context.Background()
+	ttlEntry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 10,
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTtl,
+			Ttl:  &xdr.TtlEntry{KeyHash: xdr.Hash{1}, LiveUntilLedgerSeq: 100},
+		},
+	}
+	codeEntry := xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 11,
+		Data: xdr.LedgerEntryData{
+			Type:         xdr.LedgerEntryTypeContractCode,
+			ContractCode: &xdr.ContractCodeEntry{Hash: xdr.Hash{2}, Code: []byte{0, 0x61, 0x73, 0x6d}},
+		},
+	}
+	var expected contractStateRows
+	assert.NoError(t, expected.add(ttlEntry))
+	assert.NoError(t, expected.add(codeEntry))
+	ttl := processors.ContractTTLToRow(ttlEntry)
+	code, err := processors.ContractCodeToRow(codeEntry)
+	assert.NoError(t, err)
+
+	q := &mockDBQ{}
+	q.MockQContractState.On("GetContractCodeByKeyHashes", ctx, []string{code.KeyHash}).
+		Return([]history.ContractCode{code}, nil).Once()
+	q.MockQContractState.On("GetContractTTLsByKeyHashes", ctx, []string{ttl.KeyHash}).
+		Return([]history.ContractTTL{ttl}, nil).Once()
+	assert.NoError(t, checkContractState(ctx, q, expected))
+
+	// rows which are missing or outdated in the db
+	q.MockQContractState.On("GetContractCodeByKeyHashes", ctx, []string{code.KeyHash}).
+		Return([]history.ContractCode{}, nil).Once()
+	err = checkContractState(ctx, q, expected)
+	assertStateError(t, err, true)
+	assert.ErrorContains(t, err, "contract code "+code.KeyHash+" is")
+	outdated := ttl
+	outdated.LiveUntilLedgerSeq = 50
+	q.MockQContractState.On("GetContractCodeByKeyHashes", ctx, []string{code.KeyHash}).
+		Return([]history.ContractCode{code}, nil).Once()
+	q.MockQContractState.On("GetContractTTLsByKeyHashes", ctx, []string{ttl.KeyHash}).
+		Return([]history.ContractTTL{outdated}, nil).Once()
+	err = checkContractState(ctx, q, expected)
+	assert.ErrorContains(t, err, "contract ttl "+ttl.KeyHash+" is")
+
+	// rows of entries which are not in the HAS, e.g. evicted entries
+	totalByType := map[string]int64{"contract_data": 2, "contract_code": 1, "ttl": 3}
+	q.MockQContractState.On("CountContractData", ctx).Return(2, nil).Once()
+	q.MockQContractState.On("CountContractCode", ctx).Return(1, nil).Once()
+	q.MockQContractState.On("CountContractTTLs", ctx).Return(3, nil).Once()
+	assert.NoError(t, checkContractStateCounts(ctx, q, totalByType))
+	q.MockQContractState.On("CountContractData", ctx).Return(3, nil).Once()
+	err = checkContractStateCounts(ctx, q, totalByType)
+	assertStateError(t, err, true)
+	assert.EqualError(t, err, "contract_data has 3 rows in db but 2 entries in HAS")
+	q.AssertExpectations(t)
+}
@@ -104,9 +104,10 @@ func initIngester(app *App) {
 		RoundingSlippageFilter:               app.config.RoundingSlippageFilter,
 		SkipTxmeta:                           app.config.SkipTxmeta,
 		ReapConfig: ingest.ReapConfig{
-			Frequency:      app.config.ReapFrequency,
-			RetentionCount: uint32(app.config.HistoryRetentionCount),
-			BatchSize:      uint32(app.config.HistoryRetentionReapCount),
+			Frequency:                    app.config.ReapFrequency,
+			RetentionCount:               uint32(app.config.HistoryRetentionCount),
+			BatchSize:                    uint32(app.config.HistoryRetentionReapCount),
+			BalanceHistoryRetentionCount: uint32(app.config.BalanceHistoryRetentionCount),
 		},
 	})
 
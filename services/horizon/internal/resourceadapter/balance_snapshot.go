@@ -0,0 +1,41 @@
+package resourceadapter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stellar/go/amount"
+	protocol "github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/xdr"
+)
+
+// PopulateBalanceSnapshot fills out the details of a balance snapshot of the
+// asset using a row from the history_balances table.
+func PopulateBalanceSnapshot(
+	ctx context.Context,
+	dest *protocol.BalanceSnapshot,
+	asset xdr.Asset,
+	row history.BalanceSnapshot,
+) error {
+	var err error
+	dest.ID = row.ID()
+	dest.PT = row.PagingToken()
+	dest.Address = row.Address
+	if err = asset.Extract(&dest.Type, &dest.Code, &dest.Issuer); err != nil {
+		return errors.Wrap(err, "could not extract balance asset")
+	}
+	dest.ContractID = row.ContractID
+	dest.Ledger = int32(row.LedgerSequence)
+	dest.LedgerCloseTime = row.LedgerCloseTime
+	if dest.Balance, err = amount.IntStringToAmount(row.Balance); err != nil {
+		return errors.Wrap(err, "invalid balance")
+	}
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	dest.Links.Ledger = lb.Link("/ledgers", fmt.Sprintf("%d", row.LedgerSequence))
+	return nil
+}
@@ -0,0 +1,50 @@
+package resourceadapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/test"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+func TestPopulateBalanceSnapshot(t *testing.T) {
+	ctx, _ := test.ContextWithLogBuffer()
+
+	row := history.BalanceSnapshot{
+		Address:         "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO",
+		ContractID:      "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		LedgerSequence:  56,
+		LedgerCloseTime: time.Unix(1000, 0).UTC(),
+		// contract balances can exceed the range of classic balances
+		Balance: "170141183460469231731687303715884105727",
+	}
+	asset := xdr.MustNewCreditAsset("USD", "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY")
+	var resource protocol.BalanceSnapshot
+	require.NoError(t, PopulateBalanceSnapshot(ctx, &resource, asset, row))
+
+	assert.Equal(t, row.ID(), resource.ID)
+	assert.Equal(t, toid.New(56, 0, 0).String(), resource.PagingToken())
+	assert.Equal(t, "CABAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAARHO", resource.Address)
+	assert.Equal(t, "credit_alphanum4", resource.Type)
+	assert.Equal(t, "USD", resource.Code)
+	assert.Equal(t, "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY", resource.Issuer)
+	assert.Equal(t, "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", resource.ContractID)
+	assert.Equal(t, int32(56), resource.Ledger)
+	assert.Equal(t, row.LedgerCloseTime, resource.LedgerCloseTime)
+	assert.Equal(t, "17014118346046923173168730371588.4105727", resource.Balance)
+	assert.Equal(t, "/ledgers/56", resource.Links.Ledger.Href)
+
+	row.Balance = "0"
+	resource = protocol.BalanceSnapshot{}
+	require.NoError(t, PopulateBalanceSnapshot(ctx, &resource, xdr.MustNewNativeAsset(), row))
+	assert.Equal(t, "native", resource.Type)
+	assert.Empty(t, resource.Code)
+	assert.Equal(t, "0.0000000", resource.Balance)
+}
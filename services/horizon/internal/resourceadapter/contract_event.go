@@ -0,0 +1,39 @@
+package resourceadapter
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/xdr"
+)
+
+// PopulateContractEvent fills out the details of a contract event using a row
+// from the history_contract_events table.
+func PopulateContractEvent(
+	ctx context.Context,
+	dest *protocol.ContractEvent,
+	row history.ContractEvent,
+) {
+	dest.ID = row.ID()
+	dest.PT = row.PagingToken()
+	dest.Type = history.ContractEventTypeNames[xdr.ContractEventType(row.Type)]
+	if row.ContractID.Valid {
+		dest.ContractID = row.ContractID.String
+	}
+	dest.Ledger = row.LedgerSequence()
+	dest.LedgerCloseTime = row.LedgerCloseTime
+	dest.TransactionHash = row.TransactionHash
+	dest.Topics = row.Topics()
+	if dest.Topics == nil {
+		dest.Topics = []string{}
+	}
+	dest.Value = row.Value
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	dest.Links.Transaction = lb.Link("/transactions", row.TransactionHash)
+	dest.Links.Operation = lb.Link("/operations", fmt.Sprintf("%d", row.HistoryOperationID))
+}
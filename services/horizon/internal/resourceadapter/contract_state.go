@@ -0,0 +1,207 @@
+package resourceadapter
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/xdr"
+)
+
+// PopulateContract fills out the details of a contract using the contract
+// data row of its instance.
+func PopulateContract(
+	ctx context.Context,
+	dest *protocol.Contract,
+	row history.ContractData,
+) error {
+	var val xdr.ScVal
+	if err := xdr.SafeUnmarshalBase64(row.Value, &val); err != nil {
+		return errors.Wrap(err, "could not decode contract instance")
+	}
+	instance, ok := val.GetInstance()
+	if !ok {
+		return errors.Errorf("contract instance has unexpected type %s", val.Type)
+	}
+
+	dest.ID = row.ContractID
+	switch instance.Executable.Type {
+	case xdr.ContractExecutableTypeContractExecutableWasm:
+		dest.ExecutableType = "wasm"
+		dest.WasmHash = instance.Executable.WasmHash.HexString()
+	case xdr.ContractExecutableTypeContractExecutableStellarAsset:
+		dest.ExecutableType = "stellar_asset"
+	}
+	dest.Storage = []protocol.ContractStorageEntry{}
+	if instance.Storage != nil {
+		for _, entry := range *instance.Storage {
+			var storageEntry protocol.ContractStorageEntry
+			if err := populateContractValue(&storageEntry.Key, entry.Key); err != nil {
+				return err
+			}
+			if err := populateContractValue(&storageEntry.Value, entry.Val); err != nil {
+				return err
+			}
+			dest.Storage = append(dest.Storage, storageEntry)
+		}
+	}
+	dest.LastModifiedLedger = row.LastModifiedLedger
+	dest.LiveUntilLedgerSeq = uint32(row.LiveUntilLedgerSeq.Int64)
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	self := fmt.Sprintf("/contracts/%s", row.ContractID)
+	dest.Links.Self = lb.Link(self)
+	dest.Links.Data = lb.PagedLink(self, "data")
+	dest.Links.Events = lb.PagedLink(self, "events")
+	if dest.WasmHash != "" {
+		code := lb.Link("/contract_code", dest.WasmHash)
+		dest.Links.Code = &code
+	}
+	return nil
+}
+
+// PopulateContractData fills out the details of a contract data entry using a
+// row from the contract_data table.
+func PopulateContractData(
+	ctx context.Context,
+	dest *protocol.ContractData,
+	row history.ContractData,
+) error {
+	var key, val xdr.ScVal
+	if err := xdr.SafeUnmarshalBase64(row.Key, &key); err != nil {
+		return errors.Wrap(err, "could not decode contract data key")
+	}
+	if err := xdr.SafeUnmarshalBase64(row.Value, &val); err != nil {
+		return errors.Wrap(err, "could not decode contract data value")
+	}
+
+	dest.PT = row.KeyHash
+	dest.ContractID = row.ContractID
+	dest.Durability = history.ContractDataDurabilityNames[xdr.ContractDataDurability(row.Durability)]
+	if err := populateContractValue(&dest.Key, key); err != nil {
+		return err
+	}
+	if err := populateContractValue(&dest.Value, val); err != nil {
+		return err
+	}
+	dest.LastModifiedLedger = row.LastModifiedLedger
+	dest.LiveUntilLedgerSeq = uint32(row.LiveUntilLedgerSeq.Int64)
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	dest.Links.Contract = lb.Link("/contracts", row.ContractID)
+	return nil
+}
+
+// PopulateContractCode fills out the details of a contract code using a row
+// from the contract_code table.
+func PopulateContractCode(
+	ctx context.Context,
+	dest *protocol.ContractCode,
+	row history.ContractCode,
+) {
+	dest.Hash = row.Hash
+	dest.Code = row.Code
+	dest.LastModifiedLedger = row.LastModifiedLedger
+	dest.LiveUntilLedgerSeq = uint32(row.LiveUntilLedgerSeq.Int64)
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	dest.Links.Self = lb.Link("/contract_code", row.Hash)
+}
+
+func populateContractValue(dest *protocol.ContractValue, val xdr.ScVal) error {
+	encoded, err := xdr.MarshalBase64(val)
+	if err != nil {
+		return errors.Wrap(err, "could not encode contract value")
+	}
+	decoded, err := json.Marshal(scValToJSON(val))
+	if err != nil {
+		return errors.Wrap(err, "could not decode contract value")
+	}
+	dest.XDR = encoded
+	dest.JSON = decoded
+	return nil
+}
+
+// scValToJSON converts a ScVal to a value which can be marshaled to JSON.
+func scValToJSON(val xdr.ScVal) interface{} {
+	switch val.Type {
+	case xdr.ScValTypeScvBool:
+		return *val.B
+	case xdr.ScValTypeScvVoid:
+		return nil
+	case xdr.ScValTypeScvU32:
+		return uint32(*val.U32)
+	case xdr.ScValTypeScvI32:
+		return int32(*val.I32)
+	case xdr.ScValTypeScvU64:
+		return fmt.Sprintf("%d", *val.U64)
+	case xdr.ScValTypeScvI64:
+		return fmt.Sprintf("%d", *val.I64)
+	case xdr.ScValTypeScvTimepoint:
+		return fmt.Sprintf("%d", *val.Timepoint)
+	case xdr.ScValTypeScvDuration:
+		return fmt.Sprintf("%d", *val.Duration)
+	case xdr.ScValTypeScvU128, xdr.ScValTypeScvI128, xdr.ScValTypeScvU256, xdr.ScValTypeScvI256:
+		// ScVal.String() returns the decimal representation of the integer
+		return val.String()
+	case xdr.ScValTypeScvBytes:
+		return hex.EncodeToString(*val.Bytes)
+	case xdr.ScValTypeScvString:
+		return string(*val.Str)
+	case xdr.ScValTypeScvSymbol:
+		return string(*val.Sym)
+	case xdr.ScValTypeScvVec:
+		if *val.Vec == nil {
+			return nil
+		}
+		vec := make([]interface{}, 0, len(**val.Vec))
+		for _, elem := range **val.Vec {
+			vec = append(vec, scValToJSON(elem))
+		}
+		return vec
+	case xdr.ScValTypeScvMap:
+		if *val.Map == nil {
+			return nil
+		}
+		return scMapToJSON(**val.Map)
+	case xdr.ScValTypeScvAddress:
+		address, err := val.Address.String()
+		if err != nil {
+			return nil
+		}
+		return address
+	case xdr.ScValTypeScvContractInstance:
+		instance := map[string]interface{}{}
+		switch val.Instance.Executable.Type {
+		case xdr.ContractExecutableTypeContractExecutableWasm:
+			instance["executable_type"] = "wasm"
+			instance["wasm_hash"] = val.Instance.Executable.WasmHash.HexString()
+		case xdr.ContractExecutableTypeContractExecutableStellarAsset:
+			instance["executable_type"] = "stellar_asset"
+		}
+		if val.Instance.Storage != nil {
+			instance["storage"] = scMapToJSON(*val.Instance.Storage)
+		}
+		return instance
+	default:
+		// errors and ledger keys
+		return val.String()
+	}
+}
+
+func scMapToJSON(scMap xdr.ScMap) []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0, len(scMap))
+	for _, entry := range scMap {
+		entries = append(entries, map[string]interface{}{
+			"key":   scValToJSON(entry.Key),
+			"value": scValToJSON(entry.Val),
+		})
+	}
+	return entries
+}
@@ -0,0 +1,114 @@
+package resourceadapter
+
+import (
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/test"
+	"github.com/stellar/go/xdr"
+)
+
+func TestPopulateContractData(t *testing.T) {
+	ctx, _ := test.ContextWithLogBuffer()
+
+	var contractID xdr.ContractId
+	contractID[0] = 1
+	balance := xdr.ScSymbol("Balance")
+	keyVec := &xdr.ScVec{
+		{Type: xdr.ScValTypeScvSymbol, Sym: &balance},
+		{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{
+			Type:       xdr.ScAddressTypeScAddressTypeContract,
+			ContractId: &contractID,
+		}},
+	}
+	key, err := xdr.MarshalBase64(xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &keyVec})
+	require.NoError(t, err)
+
+	amount := xdr.ScSymbol("amount")
+	authorized := xdr.ScSymbol("authorized")
+	yes := true
+	valueMap := &xdr.ScMap{
+		{
+			Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &amount},
+			Val: xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Hi: 0, Lo: 1000}},
+		},
+		{
+			Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &authorized},
+			Val: xdr.ScVal{Type: xdr.ScValTypeScvBool, B: &yes},
+		},
+	}
+	value, err := xdr.MarshalBase64(xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &valueMap})
+	require.NoError(t, err)
+
+	var resource protocol.ContractData
+	require.NoError(t, PopulateContractData(ctx, &resource, history.ContractData{
+		KeyHash:            "0100000000000000000000000000000000000000000000000000000000000000",
+		ContractID:         "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Durability:         int32(xdr.ContractDataDurabilityPersistent),
+		Key:                key,
+		Value:              value,
+		LastModifiedLedger: 10,
+		LiveUntilLedgerSeq: null.IntFrom(100),
+	}))
+
+	assert.Equal(t, "0100000000000000000000000000000000000000000000000000000000000000", resource.PagingToken())
+	assert.Equal(t, "persistent", resource.Durability)
+	assert.Equal(t, key, resource.Key.XDR)
+	assert.JSONEq(t, `["Balance", "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF"]`, string(resource.Key.JSON))
+	assert.Equal(t, value, resource.Value.XDR)
+	assert.JSONEq(t, `[{"key": "amount", "value": "1000"}, {"key": "authorized", "value": true}]`, string(resource.Value.JSON))
+	assert.Equal(t, uint32(10), resource.LastModifiedLedger)
+	assert.Equal(t, uint32(100), resource.LiveUntilLedgerSeq)
+	assert.Equal(t, "/contracts/CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", resource.Links.Contract.Href)
+}
+
+func TestPopulateContract(t *testing.T) {
+	ctx, _ := test.ContextWithLogBuffer()
+
+	admin := xdr.ScSymbol("Admin")
+	wasmHash := xdr.Hash{2}
+	storage := &xdr.ScMap{
+		{
+			Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &admin},
+			Val: xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+		},
+	}
+	value, err := xdr.MarshalBase64(xdr.ScVal{
+		Type: xdr.ScValTypeScvContractInstance,
+		Instance: &xdr.ScContractInstance{
+			Executable: xdr.ContractExecutable{
+				Type:     xdr.ContractExecutableTypeContractExecutableWasm,
+				WasmHash: &wasmHash,
+			},
+			Storage: storage,
+		},
+	})
+	require.NoError(t, err)
+
+	var resource protocol.Contract
+	require.NoError(t, PopulateContract(ctx, &resource, history.ContractData{
+		ContractID:         "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Durability:         int32(xdr.ContractDataDurabilityPersistent),
+		Value:              value,
+		LastModifiedLedger: 10,
+	}))
+
+	assert.Equal(t, "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF", resource.ID)
+	assert.Equal(t, "wasm", resource.ExecutableType)
+	assert.Equal(t, wasmHash.HexString(), resource.WasmHash)
+	require.Len(t, resource.Storage, 1)
+	assert.JSONEq(t, `"Admin"`, string(resource.Storage[0].Key.JSON))
+	assert.JSONEq(t, `null`, string(resource.Storage[0].Value.JSON))
+	assert.Equal(t, uint32(0), resource.LiveUntilLedgerSeq)
+	if assert.NotNil(t, resource.Links.Code) {
+		assert.Equal(t, "/contract_code/"+wasmHash.HexString(), resource.Links.Code.Href)
+	}
+
+	// the value is not a contract instance
+	assert.Error(t, PopulateContract(ctx, &resource, history.ContractData{Value: "AAAAAQ=="}))
+}
@@ -0,0 +1,49 @@
+package resourceadapter
+
+import (
+	"context"
+	"fmt"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	horizonContext "github.com/stellar/go/services/horizon/internal/context"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/xdr"
+)
+
+// PopulateTokenTransfer fills out the details of a token transfer using a row
+// from the history_token_transfers table.
+func PopulateTokenTransfer(
+	ctx context.Context,
+	dest *protocol.TokenTransfer,
+	row history.TokenTransfer,
+) error {
+	dest.ID = row.ID()
+	dest.PT = row.PagingToken()
+	dest.Type = history.TokenTransferTypeNames[row.Type]
+	dest.Ledger = row.LedgerSequence()
+	dest.LedgerCloseTime = row.LedgerCloseTime
+	dest.TransactionHash = row.TransactionHash
+	dest.ContractID = row.ContractID
+	if row.Asset.Valid {
+		assets, err := xdr.BuildAssets(row.Asset.String)
+		if err != nil || len(assets) != 1 {
+			return errors.Errorf("invalid token transfer asset %s", row.Asset.String)
+		}
+		if err = assets[0].Extract(&dest.AssetType, &dest.AssetCode, &dest.AssetIssuer); err != nil {
+			return errors.Wrap(err, "could not extract token transfer asset")
+		}
+	}
+	dest.From = row.FromAddress.String
+	dest.To = row.ToAddress.String
+	dest.Amount = row.Amount
+
+	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
+	dest.Links.Transaction = lb.Link("/transactions", row.TransactionHash)
+	if operationID := row.OperationID(); operationID != 0 {
+		operation := lb.Link("/operations", fmt.Sprintf("%d", operationID))
+		dest.Links.Operation = &operation
+	}
+	return nil
+}
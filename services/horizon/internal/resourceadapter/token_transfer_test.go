@@ -0,0 +1,57 @@
+package resourceadapter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	protocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/support/test"
+	"github.com/stellar/go/toid"
+)
+
+func TestPopulateTokenTransfer(t *testing.T) {
+	ctx, _ := test.ContextWithLogBuffer()
+
+	row := history.TokenTransfer{
+		HistoryOperationID: toid.New(56, 1, 2).ToInt64(),
+		Order:              1,
+		TransactionHash:    "2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d",
+		LedgerCloseTime:    time.Unix(1000, 0).UTC(),
+		Type:               history.TokenTransferTypeClawback,
+		ContractID:         "CAAQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABDQF",
+		Asset:              null.StringFrom("USD:GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY"),
+		FromAddress:        null.StringFrom("GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON"),
+		Amount:             "100",
+	}
+	var resource protocol.TokenTransfer
+	require.NoError(t, PopulateTokenTransfer(ctx, &resource, row))
+
+	assert.Equal(t, row.ID(), resource.ID)
+	assert.Equal(t, row.PagingToken(), resource.PagingToken())
+	assert.Equal(t, "clawback", resource.Type)
+	assert.Equal(t, int32(56), resource.Ledger)
+	assert.Equal(t, "credit_alphanum4", resource.AssetType)
+	assert.Equal(t, "USD", resource.AssetCode)
+	assert.Equal(t, "GAQAA5L65LSYH7CQ3VTJ7F3HHLGCL3DSLAR2Y47263D56MNNGHSQSTVY", resource.AssetIssuer)
+	assert.Equal(t, "GBXGQJWVLWOYHFLVTKWV5FGHA3LNYY2JQKM7OAJAUEQFU6LPCSEFVXON", resource.From)
+	assert.Empty(t, resource.To)
+	assert.Equal(t, "100", resource.Amount)
+	if assert.NotNil(t, resource.Links.Operation) {
+		assert.Equal(t, fmt.Sprintf("/operations/%d", row.HistoryOperationID), resource.Links.Operation.Href)
+	}
+
+	// fee events are not attached to an operation
+	row.HistoryOperationID = toid.New(56, 1, 0).ToInt64()
+	row.Asset = null.String{}
+	resource = protocol.TokenTransfer{}
+	require.NoError(t, PopulateTokenTransfer(ctx, &resource, row))
+	assert.Empty(t, resource.AssetType)
+	assert.Nil(t, resource.Links.Operation)
+	assert.Equal(t, "/transactions/2374e99349b9ef7dba9a5db3339b78fda8f34777b1af33ba468ad5c0df946d4d", resource.Links.Transaction.Href)
+}
@@ -2,6 +2,7 @@ package compressxdr
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -80,3 +81,113 @@ func BenchmarkEncodeLedgerCloseMetaBatch(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestEncodeDecodeWithRegisteredCompressors(t *testing.T) {
+	testData := xdr.LedgerCloseMetaBatch{StartSequence: 1000, EndSequence: 1005}
+
+	for _, compression := range []string{CompressionZstd, CompressionGzip, CompressionS2, CompressionNone} {
+		t.Run(compression, func(t *testing.T) {
+			compressor, err := NewCompressor(compression)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			_, err = NewXDREncoder(compressor, testData).WriteTo(&buf)
+			require.NoError(t, err)
+
+			fromExtension, err := NewCompressorFromExtension(compressor.Name())
+			require.NoError(t, err)
+			require.Equal(t, compressor, fromExtension)
+
+			lcmBatch := xdr.LedgerCloseMetaBatch{}
+			_, err = NewXDRDecoder(fromExtension, &lcmBatch).ReadFrom(&buf)
+			require.NoError(t, err)
+			require.Equal(t, testData, lcmBatch)
+		})
+	}
+}
+
+func TestCompressorRegistry(t *testing.T) {
+	compressor, err := NewCompressor("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultCompressor, compressor)
+
+	compressor, err = NewCompressorFromExtension("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultCompressor, compressor)
+
+	// ledger files written by older releases used the zstd extension
+	compressor, err = NewCompressorFromExtension("zstd")
+	require.NoError(t, err)
+	require.Equal(t, DefaultCompressor, compressor)
+
+	_, err = NewCompressor("brotli")
+	require.EqualError(t, err, `unsupported compression type "brotli"`)
+
+	_, err = NewCompressorFromExtension("br")
+	require.EqualError(t, err, `unsupported compressed file extension "br"`)
+
+	require.Equal(t, []string{"gz", "none", "s2", "zst"}, Extensions())
+
+	require.EqualError(t, RegisterCompressor(CompressionGzip, GzipCompressor{}),
+		`compression type "gzip" is already registered`)
+	require.EqualError(t, RegisterCompressor("gzip-fast", GzipCompressor{}),
+		`compressor name "gz" is already registered for compression type "gzip"`)
+}
+
+func TestZstdDictCompressor(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 200; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(
+			"ledger %d closed with tx set hash %032d and %d operations applied successfully", i, i*7919, i%13)))
+	}
+	dictV1, err := TrainZstdDictionary(samples, 1, 4096)
+	require.NoError(t, err)
+	dictV2, err := TrainZstdDictionary(samples, 2, 4096)
+	require.NoError(t, err)
+
+	_, err = TrainZstdDictionary(samples, 0, 4096)
+	require.Error(t, err)
+	_, err = TrainZstdDictionary(nil, 1, 4096)
+	require.Error(t, err)
+	degenerate := make([][]byte, 100)
+	for i := range degenerate {
+		degenerate[i] = []byte(fmt.Sprintf("ledger %03d", i))
+	}
+	_, err = TrainZstdDictionary(degenerate, 1, 4096)
+	require.ErrorContains(t, err, "failed to train zstd dictionary")
+	_, err = NewZstdDictCompressor()
+	require.Error(t, err)
+	_, err = NewZstdDictCompressor([]byte("not a dictionary"))
+	require.Error(t, err)
+
+	v1, err := NewZstdDictCompressor(dictV1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), v1.DictionaryID())
+	require.Equal(t, DefaultCompressor.Name(), v1.Name())
+
+	both, err := NewZstdDictCompressor(dictV1, dictV2)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), both.DictionaryID())
+
+	testData := createTestLedgerCloseMetaBatch(1000, 1005, 6)
+	roundTrip := func(encodeWith, decodeWith Compressor) error {
+		var buf bytes.Buffer
+		if _, err := NewXDREncoder(encodeWith, testData).WriteTo(&buf); err != nil {
+			return err
+		}
+		lcmBatch := xdr.LedgerCloseMetaBatch{}
+		if _, err := NewXDRDecoder(decodeWith, &lcmBatch).ReadFrom(&buf); err != nil {
+			return err
+		}
+		require.Equal(t, testData, lcmBatch)
+		return nil
+	}
+
+	// files compressed with older dictionaries or none remain readable
+	require.NoError(t, roundTrip(v1, both))
+	require.NoError(t, roundTrip(both, both))
+	require.NoError(t, roundTrip(DefaultCompressor, both))
+	// the dictionary is required to read the file
+	require.Error(t, roundTrip(both, v1))
+	require.Error(t, roundTrip(both, DefaultCompressor))
+}
@@ -1,13 +1,31 @@
 package compressxdr
 
 import (
+	"fmt"
 	"io"
+	"sort"
+	"sync"
 
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/s2"
 	"github.com/klauspost/compress/zstd"
 )
 
+// Compression types, as configured in DataStoreConfig.Compression and
+// recorded in the datastore manifest.
+const (
+	CompressionZstd = "zstd"
+	CompressionGzip = "gzip"
+	CompressionS2   = "s2"
+	CompressionNone = "none"
+)
+
 var DefaultCompressor = &ZstdCompressor{}
 
+// DefaultCompression is the compression type of DefaultCompressor.
+const DefaultCompression = CompressionZstd
+
 // Compressor represents a compression algorithm.
 type Compressor interface {
 	NewWriter(w io.Writer) (io.WriteCloser, error)
@@ -15,6 +33,93 @@ type Compressor interface {
 	Name() string
 }
 
+var (
+	registryLock sync.RWMutex
+	// compressors maps a compression type to its Compressor.
+	compressors = map[string]Compressor{
+		CompressionZstd: DefaultCompressor,
+		CompressionGzip: GzipCompressor{},
+		CompressionS2:   S2Compressor{},
+		CompressionNone: NoneCompressor{},
+	}
+)
+
+// legacyExtensions maps file extensions used by older releases of galexie to
+// their Compressor, e.g. ledger files written before SEP-0054 used ".zstd".
+var legacyExtensions = map[string]Compressor{
+	"zstd": DefaultCompressor,
+}
+
+// RegisterCompressor makes a Compressor available under the given compression
+// type. The Compressor name is used as the file extension of ledger files, so
+// both the compression type and the name must be unique.
+func RegisterCompressor(compression string, compressor Compressor) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	for existingType, existing := range compressors {
+		if existingType == compression {
+			return fmt.Errorf("compression type %q is already registered", compression)
+		}
+		if existing.Name() == compressor.Name() {
+			return fmt.Errorf("compressor name %q is already registered for compression type %q",
+				compressor.Name(), existingType)
+		}
+	}
+	compressors[compression] = compressor
+	return nil
+}
+
+// NewCompressor returns the Compressor registered for the given compression
+// type. An empty compression type returns DefaultCompressor.
+func NewCompressor(compression string) (Compressor, error) {
+	if compression == "" {
+		return DefaultCompressor, nil
+	}
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	if compressor, ok := compressors[compression]; ok {
+		return compressor, nil
+	}
+	return nil, fmt.Errorf("unsupported compression type %q", compression)
+}
+
+// NewCompressorFromExtension returns the registered Compressor whose name
+// matches the given file extension. An empty extension returns DefaultCompressor.
+func NewCompressorFromExtension(extension string) (Compressor, error) {
+	if extension == "" {
+		return DefaultCompressor, nil
+	}
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	for _, compressor := range compressors {
+		if compressor.Name() == extension {
+			return compressor, nil
+		}
+	}
+	if compressor, ok := legacyExtensions[extension]; ok {
+		return compressor, nil
+	}
+	return nil, fmt.Errorf("unsupported compressed file extension %q", extension)
+}
+
+// Extensions returns the names of all registered compressors, sorted.
+func Extensions() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	extensions := make([]string, 0, len(compressors))
+	for _, compressor := range compressors {
+		extensions = append(extensions, compressor.Name())
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
 // ZstdCompressor is an implementation of the Compressor interface for Zstd compression.
 type ZstdCompressor struct{}
 
@@ -36,3 +141,144 @@ func (z ZstdCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
 	}
 	return zr.IOReadCloser(), err
 }
+
+// GzipCompressor is an implementation of the Compressor interface for gzip compression.
+type GzipCompressor struct{}
+
+// Name returns the name of the compression algorithm.
+func (g GzipCompressor) Name() string {
+	return "gz"
+}
+
+// NewWriter creates a new gzip writer.
+func (g GzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// NewReader creates a new gzip reader.
+func (g GzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// S2Compressor is an implementation of the Compressor interface for S2
+// compression, a fast Snappy compatible codec trading compression ratio for speed.
+type S2Compressor struct{}
+
+// Name returns the name of the compression algorithm.
+func (s S2Compressor) Name() string {
+	return "s2"
+}
+
+// NewWriter creates a new S2 writer.
+func (s S2Compressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return s2.NewWriter(w), nil
+}
+
+// NewReader creates a new S2 reader.
+func (s S2Compressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(s2.NewReader(r)), nil
+}
+
+// NoneCompressor is an implementation of the Compressor interface which
+// stores data uncompressed.
+type NoneCompressor struct{}
+
+// Name returns the name of the compression algorithm.
+func (n NoneCompressor) Name() string {
+	return "none"
+}
+
+// NewWriter returns a writer which writes to w unchanged.
+func (n NoneCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+// NewReader returns a reader which reads from r unchanged.
+func (n NoneCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// ZstdDictCompressor is an implementation of the Compressor interface for Zstd
+// compression using pre-trained dictionaries. Data is compressed with the
+// most recent dictionary, while any of the dictionaries, or none, may have
+// been used to compress the data being read. Zstd frames record the id of
+// their dictionary, so files keep the same extension as ZstdCompressor.
+type ZstdDictCompressor struct {
+	dicts [][]byte
+	id    uint32
+}
+
+// NewZstdDictCompressor creates a ZstdDictCompressor from one or more
+// dictionaries, ordered from oldest to most recent.
+func NewZstdDictCompressor(dicts ...[]byte) (*ZstdDictCompressor, error) {
+	if len(dicts) == 0 {
+		return nil, fmt.Errorf("at least one zstd dictionary is required")
+	}
+	var id uint32
+	for _, dict := range dicts {
+		info, err := zstd.InspectDictionary(dict)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+		}
+		if info.ID() == 0 {
+			return nil, fmt.Errorf("invalid zstd dictionary: missing dictionary id")
+		}
+		id = info.ID()
+	}
+	return &ZstdDictCompressor{dicts: dicts, id: id}, nil
+}
+
+// Name returns the name of the compression algorithm.
+func (z *ZstdDictCompressor) Name() string {
+	return DefaultCompressor.Name()
+}
+
+// DictionaryID returns the id of the dictionary used to compress data.
+func (z *ZstdDictCompressor) DictionaryID() uint32 {
+	return z.id
+}
+
+// NewWriter creates a new Zstd writer using the most recent dictionary.
+func (z *ZstdDictCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderDict(z.dicts[len(z.dicts)-1]))
+}
+
+// NewReader creates a new Zstd reader which can decode data compressed with
+// any of the dictionaries or without a dictionary.
+func (z *ZstdDictCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	zr, err := zstd.NewReader(r, zstd.WithDecoderDicts(z.dicts...))
+	if err != nil {
+		return nil, err
+	}
+	return zr.IOReadCloser(), nil
+}
+
+// TrainZstdDictionary trains a zstd dictionary of at most maxSize bytes from
+// the given samples and assigns it the given id, which must be non-zero.
+func TrainZstdDictionary(samples [][]byte, id uint32, maxSize int) (dictionary []byte, err error) {
+	if id == 0 {
+		return nil, fmt.Errorf("zstd dictionary id must be non-zero")
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples to train the zstd dictionary from")
+	}
+	// the dictionary builder panics on some degenerate sample sets, e.g.
+	// when the samples have too little variety to build entropy tables from.
+	defer func() {
+		if r := recover(); r != nil {
+			dictionary, err = nil, fmt.Errorf("failed to train zstd dictionary: %v", r)
+		}
+	}()
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: maxSize,
+		HashBytes:   6,
+		ZstdDictID:  id,
+		ZstdLevel:   zstd.SpeedDefault,
+	})
+}
@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+
+	"github.com/stellar/go/support/compressxdr"
 )
 
 // ledgerFilenameRe is the regular expression that matches filenames produced by
@@ -28,6 +30,10 @@ type DatastoreManifest struct {
 	Compression       string `json:"compression"`
 	LedgersPerFile    uint32 `json:"ledgersPerBatch"`
 	FilesPerPartition uint32 `json:"batchesPerPartition"`
+	// ZstdDictionaries lists the ids of the zstd dictionaries published to the
+	// datastore, ordered from oldest to most recent. New ledger files are
+	// compressed with the most recent one.
+	ZstdDictionaries []uint32 `json:"zstdDictionaries,omitempty"`
 }
 
 // toDataStoreManifest transforms a user-provided config into a manifest for persistence.
@@ -160,6 +166,13 @@ func LoadSchema(ctx context.Context, dataStore DataStore, cfg DataStoreConfig) (
 		}
 
 		if errors.Is(err, os.ErrNotExist) {
+			if fileExt == "" && cfg.Compression != "" {
+				compressor, err := compressxdr.NewCompressor(cfg.Compression)
+				if err != nil {
+					return DataStoreSchema{}, err
+				}
+				fileExt = compressor.Name()
+			}
 			return DataStoreSchema{
 				LedgersPerFile:    cfg.Schema.LedgersPerFile,
 				FilesPerPartition: cfg.Schema.FilesPerPartition,
@@ -177,10 +190,26 @@ func LoadSchema(ctx context.Context, dataStore DataStore, cfg DataStoreConfig) (
 				"either remove the schema section from your local config or update it to match the datastore", err)
 	}
 
+	// An empty datastore has no ledger files to detect the extension from,
+	// use the compression recorded in the manifest instead.
+	if fileExt == "" && manifest.Compression != "" {
+		compressor, err := compressxdr.NewCompressor(manifest.Compression)
+		if err != nil {
+			return DataStoreSchema{}, fmt.Errorf("invalid compression in manifest: %w", err)
+		}
+		fileExt = compressor.Name()
+	}
+
+	dictionaries, err := LoadZstdDictionaries(ctx, dataStore, manifest)
+	if err != nil {
+		return DataStoreSchema{}, err
+	}
+
 	return DataStoreSchema{
 		LedgersPerFile:    manifest.LedgersPerFile,
 		FilesPerPartition: manifest.FilesPerPartition,
 		FileExtension:     fileExt,
+		ZstdDictionaries:  dictionaries,
 	}, nil
 }
 
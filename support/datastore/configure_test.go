@@ -199,6 +199,8 @@ func TestLoadSchema(t *testing.T) {
 		require.NotNil(t, schema)
 		require.Equal(t, uint32(1000), schema.LedgersPerFile)
 		require.Equal(t, uint32(10), schema.FilesPerPartition)
+		// no ledger files yet, extension is derived from the configured compression
+		require.Equal(t, "gz", schema.FileExtension)
 		mockOS.AssertExpectations(t)
 	})
 
@@ -213,6 +215,34 @@ func TestLoadSchema(t *testing.T) {
 		require.NotNil(t, schema)
 		require.Equal(t, uint32(1000), schema.LedgersPerFile)
 		require.Equal(t, uint32(10), schema.FilesPerPartition)
+		// no ledger files yet, extension is derived from the configured compression
+		require.Equal(t, "gz", schema.FileExtension)
+		mockOS.AssertExpectations(t)
+	})
+
+	t.Run("Manifest found, extension detected from ledger files", func(t *testing.T) {
+		mockOS := new(MockDataStore)
+		mockOS.On("GetFile", ctx, manifestFilename).Return(io.NopCloser(bytes.NewReader(validManifestBytes)), nil).Once()
+		mockOS.On("ListFilePaths", ctx, ListFileOptions{}).Return([]string{"FFFFFC17--1000-1999.xdr.s2"}, nil)
+		schema, err := LoadSchema(ctx, mockOS, defaultCfg)
+		require.NoError(t, err)
+		require.Equal(t, "s2", schema.FileExtension)
+		mockOS.AssertExpectations(t)
+	})
+
+	t.Run("Manifest found with unsupported compression", func(t *testing.T) {
+		mockOS := new(MockDataStore)
+		manifestBytes, err := json.Marshal(DatastoreManifest{
+			Version:           Version,
+			Compression:       "brotli",
+			LedgersPerFile:    1000,
+			FilesPerPartition: 10,
+		})
+		require.NoError(t, err)
+		mockOS.On("GetFile", ctx, manifestFilename).Return(io.NopCloser(bytes.NewReader(manifestBytes)), nil).Once()
+		mockOS.On("ListFilePaths", ctx, ListFileOptions{}).Return(nil, nil)
+		_, err = LoadSchema(ctx, mockOS, DataStoreConfig{})
+		require.ErrorContains(t, err, `unsupported compression type "brotli"`)
 		mockOS.AssertExpectations(t)
 	})
 
@@ -18,7 +18,9 @@ type DataStoreConfig struct {
 	Params            map[string]string `toml:"params"`
 	Schema            DataStoreSchema   `toml:"schema"`
 	NetworkPassphrase string
-	Compression       string
+	// Compression is the compressxdr compression type of ledger files,
+	// e.g. "zstd", "gzip", "s2" or "none".
+	Compression string `toml:"compression"`
 }
 
 const listFilePathsMaxLimit = 1000
@@ -57,6 +59,10 @@ func NewDataStore(ctx context.Context, datastoreConfig DataStoreConfig) (DataSto
 		return NewGCSDataStore(ctx, datastoreConfig)
 	case "S3":
 		return NewS3DataStore(ctx, datastoreConfig)
+	case "Filesystem":
+		return NewFilesystemDataStore(ctx, datastoreConfig)
+	case "HTTP":
+		return NewHTTPDataStore(ctx, datastoreConfig)
 
 	default:
 		return nil, fmt.Errorf("invalid datastore type %v, not supported", datastoreConfig.Type)
@@ -0,0 +1,111 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/stellar/go/support/compressxdr"
+)
+
+// zstdDictionaryDir is the directory, next to the manifest, where trained zstd
+// dictionaries are stored.
+const zstdDictionaryDir = ".dictionaries"
+
+// zstdDictionaryPath returns the object key of the zstd dictionary with the given id.
+func zstdDictionaryPath(id uint32) string {
+	return fmt.Sprintf("%s/zstd-%d.dict", zstdDictionaryDir, id)
+}
+
+// PublishZstdDictionary stores a trained zstd dictionary alongside the
+// datastore manifest and records its id in the manifest, making it the
+// dictionary new ledger files are compressed with. The datastore manifest
+// must exist and use zstd compression. Returns the updated manifest.
+func PublishZstdDictionary(ctx context.Context, dataStore DataStore, dictionary []byte) (DatastoreManifest, error) {
+	compressor, err := compressxdr.NewZstdDictCompressor(dictionary)
+	if err != nil {
+		return DatastoreManifest{}, err
+	}
+	id := compressor.DictionaryID()
+
+	manifest, err := readManifest(ctx, dataStore, manifestFilename)
+	if err != nil {
+		return DatastoreManifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest.Compression != "" && manifest.Compression != compressxdr.CompressionZstd {
+		return DatastoreManifest{}, fmt.Errorf("zstd dictionaries are not supported with %q compression",
+			manifest.Compression)
+	}
+	if slices.Contains(manifest.ZstdDictionaries, id) {
+		return DatastoreManifest{}, fmt.Errorf("zstd dictionary %d is already published", id)
+	}
+
+	path := zstdDictionaryPath(id)
+	ok, err := dataStore.PutFileIfNotExists(ctx, path, bytes.NewReader(dictionary), map[string]string{
+		"Content-Type": "application/octet-stream",
+	})
+	if err != nil {
+		return DatastoreManifest{}, fmt.Errorf("failed to write zstd dictionary %q: %w", path, err)
+	}
+	if !ok {
+		// left behind by an earlier attempt which failed to update the manifest
+		existing, err := readZstdDictionary(ctx, dataStore, id)
+		if err != nil {
+			return DatastoreManifest{}, err
+		}
+		if !bytes.Equal(existing, dictionary) {
+			return DatastoreManifest{}, fmt.Errorf("a different zstd dictionary with id %d already exists", id)
+		}
+	}
+
+	manifest.ZstdDictionaries = append(manifest.ZstdDictionaries, id)
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return DatastoreManifest{}, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := dataStore.PutFile(ctx, manifestFilename, bytes.NewReader(data), map[string]string{
+		"Content-Type": "application/json",
+	}); err != nil {
+		return DatastoreManifest{}, fmt.Errorf("failed to write manifest file %q: %w", manifestFilename, err)
+	}
+	return manifest, nil
+}
+
+// LoadZstdDictionaries reads the zstd dictionaries listed in the manifest from
+// the datastore, ordered from oldest to most recent.
+func LoadZstdDictionaries(ctx context.Context, dataStore DataStore, manifest DatastoreManifest) ([][]byte, error) {
+	var dictionaries [][]byte
+	for _, id := range manifest.ZstdDictionaries {
+		dictionary, err := readZstdDictionary(ctx, dataStore, id)
+		if err != nil {
+			return nil, err
+		}
+		dictionaries = append(dictionaries, dictionary)
+	}
+	return dictionaries, nil
+}
+
+func readZstdDictionary(ctx context.Context, dataStore DataStore, id uint32) ([]byte, error) {
+	path := zstdDictionaryPath(id)
+	reader, err := dataStore.GetFile(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open zstd dictionary %q: %w", path, err)
+	}
+	defer reader.Close()
+
+	dictionary, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading zstd dictionary %q: %w", path, err)
+	}
+	compressor, err := compressxdr.NewZstdDictCompressor(dictionary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary %q: %w", path, err)
+	}
+	if compressor.DictionaryID() != id {
+		return nil, fmt.Errorf("zstd dictionary %q has id %d", path, compressor.DictionaryID())
+	}
+	return dictionary, nil
+}
@@ -0,0 +1,107 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+func trainTestDictionary(t *testing.T, id uint32) []byte {
+	var samples [][]byte
+	for i := 0; i < 200; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(
+			"ledger %d closed with tx set hash %032d and %d operations", i, i*7919, i%13)))
+	}
+	dictionary, err := compressxdr.TrainZstdDictionary(samples, id, 4096)
+	require.NoError(t, err)
+	return dictionary
+}
+
+func TestPublishZstdDictionary(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+	cfg := DataStoreConfig{
+		NetworkPassphrase: "test",
+		Compression:       compressxdr.CompressionZstd,
+		Schema:            DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10},
+	}
+	dictV1 := trainTestDictionary(t, 1)
+	dictV2 := trainTestDictionary(t, 2)
+
+	_, err := PublishZstdDictionary(ctx, store, dictV1)
+	require.ErrorContains(t, err, "failed to read manifest")
+
+	_, _, err = PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+
+	manifest, err := PublishZstdDictionary(ctx, store, dictV1)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1}, manifest.ZstdDictionaries)
+
+	_, err = PublishZstdDictionary(ctx, store, dictV1)
+	require.ErrorContains(t, err, "zstd dictionary 1 is already published")
+
+	manifest, err = PublishZstdDictionary(ctx, store, dictV2)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 2}, manifest.ZstdDictionaries)
+
+	// the manifest still matches the local config
+	_, created, err := PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+	require.False(t, created)
+
+	schema, err := LoadSchema(ctx, store, cfg)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{dictV1, dictV2}, schema.ZstdDictionaries)
+
+	compressor, err := schema.GetCompressor()
+	require.NoError(t, err)
+	dictCompressor, ok := compressor.(*compressxdr.ZstdDictCompressor)
+	require.True(t, ok)
+	require.Equal(t, uint32(2), dictCompressor.DictionaryID())
+
+	batch := xdr.LedgerCloseMetaBatch{StartSequence: 5, EndSequence: 5}
+	var buf bytes.Buffer
+	_, err = compressxdr.NewXDREncoder(compressor, batch).WriteTo(&buf)
+	require.NoError(t, err)
+	var decoded xdr.LedgerCloseMetaBatch
+	_, err = compressxdr.NewXDRDecoder(compressor, &decoded).ReadFrom(&buf)
+	require.NoError(t, err)
+	require.Equal(t, batch, decoded)
+}
+
+func TestPublishZstdDictionaryRequiresZstd(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	_, _, err := PublishConfig(ctx, store, DataStoreConfig{
+		Compression: compressxdr.CompressionGzip,
+		Schema:      DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10},
+	})
+	require.NoError(t, err)
+
+	_, err = PublishZstdDictionary(ctx, store, trainTestDictionary(t, 1))
+	require.EqualError(t, err, `zstd dictionaries are not supported with "gzip" compression`)
+
+	_, err = PublishZstdDictionary(ctx, store, []byte("not a dictionary"))
+	require.ErrorContains(t, err, "invalid zstd dictionary")
+}
+
+func TestLoadZstdDictionariesValidatesID(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.PutFile(ctx, zstdDictionaryPath(3), bytes.NewReader(trainTestDictionary(t, 4)), nil))
+
+	_, err := LoadZstdDictionaries(ctx, store, DatastoreManifest{ZstdDictionaries: []uint32{3}})
+	require.EqualError(t, err, `zstd dictionary ".dictionaries/zstd-3.dict" has id 4`)
+
+	_, err = LoadZstdDictionaries(ctx, store, DatastoreManifest{ZstdDictionaries: []uint32{5}})
+	require.ErrorContains(t, err, `unable to open zstd dictionary ".dictionaries/zstd-5.dict"`)
+}
@@ -0,0 +1,326 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/log"
+)
+
+const (
+	// filesystemMetadataSuffix is appended to an object's path to form the
+	// path of the sidecar file holding its metadata.
+	filesystemMetadataSuffix = ".metadata.json"
+	// filesystemTempDir is a reserved directory under the datastore root where
+	// files are staged before being atomically moved into place. It lives on
+	// the same filesystem as the objects so that renames and links are atomic.
+	filesystemTempDir = ".tmp"
+)
+
+// FilesystemDataStore implements DataStore for a directory on the local filesystem.
+//
+// Each object is stored as a regular file at its key relative to the root
+// directory. Object metadata is stored next to the object in a JSON sidecar
+// file, which is hidden from ListFilePaths.
+type FilesystemDataStore struct {
+	root string
+}
+
+func NewFilesystemDataStore(ctx context.Context, dataStoreConfig DataStoreConfig) (DataStore, error) {
+	destinationPath, ok := dataStoreConfig.Params["destination_path"]
+	if !ok {
+		return nil, errors.New("invalid Filesystem config, no destination_path")
+	}
+
+	return FromFilesystemPath(destinationPath)
+}
+
+// FromFilesystemPath creates a FilesystemDataStore rooted at the given directory,
+// creating the directory if it does not exist.
+func FromFilesystemPath(root string) (DataStore, error) {
+	if root == "" {
+		return nil, errors.New("invalid Filesystem config, destination_path is empty")
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %s: %w", root, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(absRoot, filesystemTempDir), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", absRoot, err)
+	}
+
+	log.Debugf("creating Filesystem datastore at: %s", absRoot)
+	return FilesystemDataStore{root: absRoot}, nil
+}
+
+// fullPath maps an object key to its location on disk. Keys are always
+// slash separated and are confined to the datastore root.
+func (b FilesystemDataStore) fullPath(filePath string) string {
+	return filepath.Join(b.root, filepath.FromSlash(path.Clean("/"+filePath)))
+}
+
+// GetFileMetadata retrieves the metadata for the specified file.
+func (b FilesystemDataStore) GetFileMetadata(ctx context.Context, filePath string) (map[string]string, error) {
+	if _, err := b.stat(filePath); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(b.fullPath(filePath) + filesystemMetadataSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata for file %s: %w", filePath, err)
+	}
+
+	metaData := map[string]string{}
+	if err := json.Unmarshal(data, &metaData); err != nil {
+		return nil, fmt.Errorf("invalid metadata for file %s: %w", filePath, err)
+	}
+	return metaData, nil
+}
+
+// GetFileLastModified retrieves the last modified time of a file.
+func (b FilesystemDataStore) GetFileLastModified(ctx context.Context, filePath string) (time.Time, error) {
+	info, err := b.stat(filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// GetFile retrieves a file from the datastore.
+func (b FilesystemDataStore) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if _, err := b.stat(filePath); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(b.fullPath(filePath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("error retrieving file %s: %w", filePath, err)
+	}
+	log.Debugf("File retrieved successfully: %s", filePath)
+	return f, nil
+}
+
+// PutFile writes a file to the datastore, replacing any existing file.
+func (b FilesystemDataStore) PutFile(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) error {
+	if _, err := b.putFile(filePath, in, false, metaData); err != nil {
+		return fmt.Errorf("error uploading file %s: %w", filePath, err)
+	}
+	log.Debugf("File uploaded successfully: %s", filePath)
+	return nil
+}
+
+// PutFileIfNotExists writes a file to the datastore only if it doesn't already exist.
+func (b FilesystemDataStore) PutFileIfNotExists(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	written, err := b.putFile(filePath, in, true, metaData)
+	if err != nil {
+		return false, fmt.Errorf("error uploading file %s: %w", filePath, err)
+	}
+	if !written {
+		log.Debugf("Precondition failed: %s already exists in the datastore", filePath)
+		return false, nil
+	}
+	log.Debugf("File uploaded successfully: %s", filePath)
+	return true, nil
+}
+
+// Exists checks if a file exists in the datastore.
+func (b FilesystemDataStore) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, err := b.stat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Size retrieves the size of a file in the datastore.
+func (b FilesystemDataStore) Size(ctx context.Context, filePath string) (int64, error) {
+	info, err := b.stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Close does nothing for FilesystemDataStore as it does not hold any resources.
+func (b FilesystemDataStore) Close() error {
+	return nil
+}
+
+// stat returns the file info for an object, treating directories and
+// reserved files as missing.
+func (b FilesystemDataStore) stat(filePath string) (os.FileInfo, error) {
+	if b.isReserved(path.Clean("/" + filePath)[1:]) {
+		return nil, os.ErrNotExist
+	}
+	info, err := os.Stat(b.fullPath(filePath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, os.ErrNotExist
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, os.ErrNotExist
+	}
+	return info, nil
+}
+
+// isReserved reports whether the given slash separated key refers to the
+// staging directory or a metadata sidecar, neither of which are objects.
+func (b FilesystemDataStore) isReserved(key string) bool {
+	return key == filesystemTempDir ||
+		strings.HasPrefix(key, filesystemTempDir+"/") ||
+		strings.HasSuffix(key, filesystemMetadataSuffix)
+}
+
+// putFile stages the content and metadata in the temp directory and then
+// moves them into place. When onlyIfFileDoesNotExist is set, the content is
+// hard linked into place, which fails atomically if the destination already
+// exists. It returns false if the file was not written for that reason.
+func (b FilesystemDataStore) putFile(filePath string, in io.WriterTo, onlyIfFileDoesNotExist bool, metaData map[string]string) (bool, error) {
+	key := path.Clean("/" + filePath)[1:]
+	if key == "" || b.isReserved(key) {
+		return false, fmt.Errorf("invalid file path %q", filePath)
+	}
+	dest := b.fullPath(filePath)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return false, fmt.Errorf("failed to create directory for file %s: %w", filePath, err)
+	}
+
+	tmpData, err := b.writeTemp(func(w io.Writer) error {
+		_, err := in.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to write file %s: %w", filePath, err)
+	}
+	defer os.Remove(tmpData)
+
+	if metaData == nil {
+		metaData = map[string]string{}
+	}
+	tmpMeta, err := b.writeTemp(func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(metaData)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to write metadata for file %s: %w", filePath, err)
+	}
+	defer os.Remove(tmpMeta)
+
+	if onlyIfFileDoesNotExist {
+		if err := os.Link(tmpData, dest); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to put file %s: %w", filePath, err)
+		}
+	} else if err := os.Rename(tmpData, dest); err != nil {
+		return false, fmt.Errorf("failed to put file %s: %w", filePath, err)
+	}
+
+	if err := os.Rename(tmpMeta, dest+filesystemMetadataSuffix); err != nil {
+		return false, fmt.Errorf("failed to put metadata for file %s: %w", filePath, err)
+	}
+	return true, nil
+}
+
+// writeTemp creates a file in the staging directory, fills it using write
+// and syncs it to disk. It returns the path of the staged file.
+func (b FilesystemDataStore) writeTemp(write func(w io.Writer) error) (string, error) {
+	f, err := os.CreateTemp(filepath.Join(b.root, filesystemTempDir), "put-*")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+
+	if err = write(f); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// ListFilePaths lists up to 'limit' file paths under the provided prefix.
+// Returned paths are relative to the datastore root
+// and ordered lexicographically ascending, matching the cloud backends.
+// If limit <= 0, implementations default to a cap of 1,000; values > 1,000 are capped to 1,000.
+func (b FilesystemDataStore) ListFilePaths(ctx context.Context, options ListFileOptions) ([]string, error) {
+	// Only walk the deepest directory which can contain keys matching the prefix.
+	walkDir := ""
+	if i := strings.LastIndex(options.Prefix, "/"); i >= 0 {
+		walkDir = path.Clean("/" + options.Prefix[:i])[1:]
+	}
+
+	var keys []string
+	err := filepath.WalkDir(b.fullPath(walkDir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == filesystemTempDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if b.isReserved(key) || !strings.HasPrefix(key, options.Prefix) {
+			return nil
+		}
+		if options.StartAfter != "" && key <= options.StartAfter {
+			return nil
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// WalkDir visits entries in lexical order per directory, which differs from
+	// the ordering of full keys, e.g. "a/b" sorts after "a-c".
+	sort.Strings(keys)
+
+	limit := int(options.Limit)
+	if limit <= 0 || limit > listFilePathsMaxLimit {
+		limit = listFilePathsMaxLimit
+	}
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
@@ -0,0 +1,243 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFilesystemDataStore(t *testing.T) (DataStore, string) {
+	dir := t.TempDir()
+	store, err := NewDataStore(context.Background(), DataStoreConfig{
+		Type:   "Filesystem",
+		Params: map[string]string{"destination_path": dir},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	return store, dir
+}
+
+func TestFilesystemMissingPath(t *testing.T) {
+	_, err := NewDataStore(context.Background(), DataStoreConfig{Type: "Filesystem"})
+	require.EqualError(t, err, "invalid Filesystem config, no destination_path")
+}
+
+func TestFilesystemPutAndGetFile(t *testing.T) {
+	store, dir := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+	content := []byte("inside the file")
+	metadata := map[string]string{"start-ledger": "2", "end-ledger": "3"}
+
+	require.NoError(t, store.PutFile(ctx, "partition/file.txt", bytes.NewReader(content), metadata))
+
+	reader, err := store.GetFile(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	requireReaderContentEquals(t, reader, content)
+
+	onDisk, err := os.ReadFile(filepath.Join(dir, "partition", "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, content, onDisk)
+
+	gotMetadata, err := store.GetFileMetadata(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, metadata, gotMetadata)
+
+	size, err := store.Size(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), size)
+
+	lastModified, err := store.GetFileLastModified(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), lastModified, time.Minute)
+
+	exists, err := store.Exists(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// overwrite with new content and metadata
+	require.NoError(t, store.PutFile(ctx, "partition/file.txt", bytes.NewReader([]byte("updated")), nil))
+	reader, err = store.GetFile(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	requireReaderContentEquals(t, reader, []byte("updated"))
+	gotMetadata, err = store.GetFileMetadata(ctx, "partition/file.txt")
+	require.NoError(t, err)
+	require.Empty(t, gotMetadata)
+}
+
+func TestFilesystemMissingFile(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	exists, err := store.Exists(ctx, "missing.txt")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = store.GetFile(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = store.Size(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = store.GetFileMetadata(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = store.GetFileLastModified(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// directories and metadata sidecars are not objects
+	require.NoError(t, store.PutFile(ctx, "dir/file.txt", bytes.NewReader([]byte("x")), nil))
+	exists, err = store.Exists(ctx, "dir")
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = store.Exists(ctx, "dir/file.txt"+filesystemMetadataSuffix)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestFilesystemPutFileIfNotExists(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	ok, err := store.PutFileIfNotExists(ctx, "file.txt", bytes.NewReader([]byte("first")), map[string]string{"a": "1"})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = store.PutFileIfNotExists(ctx, "file.txt", bytes.NewReader([]byte("second")), map[string]string{"a": "2"})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	reader, err := store.GetFile(ctx, "file.txt")
+	require.NoError(t, err)
+	requireReaderContentEquals(t, reader, []byte("first"))
+
+	metadata, err := store.GetFileMetadata(ctx, "file.txt")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"a": "1"}, metadata)
+}
+
+func TestFilesystemPathsAreConfinedToRoot(t *testing.T) {
+	store, dir := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.PutFile(ctx, "../../escape.txt", bytes.NewReader([]byte("x")), nil))
+	_, err := os.Stat(filepath.Join(dir, "escape.txt"))
+	require.NoError(t, err)
+
+	require.Error(t, store.PutFile(ctx, "file.txt"+filesystemMetadataSuffix, bytes.NewReader([]byte("x")), nil))
+	require.Error(t, store.PutFile(ctx, filesystemTempDir+"/file.txt", bytes.NewReader([]byte("x")), nil))
+}
+
+func TestFilesystemListFilePaths(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	for _, key := range []string{
+		"a/b",
+		"a-c",
+		"a/a",
+		"b",
+		".config.json",
+		"FFFFFFFF--0-9/FFFFFFFF--0.xdr.zst",
+		"FFFFFFFF--0-9/FFFFFFFE--1.xdr.zst",
+		"FFFFFFF5--10-19/FFFFFFF5--10.xdr.zst",
+	} {
+		require.NoError(t, store.PutFile(ctx, key, bytes.NewReader([]byte(key)), map[string]string{"k": key}))
+	}
+
+	keys, err := store.ListFilePaths(ctx, ListFileOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		".config.json",
+		"FFFFFFF5--10-19/FFFFFFF5--10.xdr.zst",
+		"FFFFFFFF--0-9/FFFFFFFE--1.xdr.zst",
+		"FFFFFFFF--0-9/FFFFFFFF--0.xdr.zst",
+		"a-c",
+		"a/a",
+		"a/b",
+		"b",
+	}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a-c", "a/a", "a/b"}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "a/"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a/a", "a/b"}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "FFFFFFFF--0-9/FFFFFFFF"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"FFFFFFFF--0-9/FFFFFFFF--0.xdr.zst"}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "missing/"})
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{StartAfter: "FFFFFFFF--0-9/FFFFFFFE--1.xdr.zst", Limit: 3})
+	require.NoError(t, err)
+	require.Equal(t, []string{"FFFFFFFF--0-9/FFFFFFFF--0.xdr.zst", "a-c", "a/a"}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "a", StartAfter: "a-c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a/a", "a/b"}, keys)
+}
+
+func TestFilesystemListFilePathsLimit(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	for i := 0; i < listFilePathsMaxLimit+5; i++ {
+		key := fmt.Sprintf("dir/%04d", i)
+		require.NoError(t, store.PutFile(ctx, key, bytes.NewReader(nil), nil))
+	}
+
+	keys, err := store.ListFilePaths(ctx, ListFileOptions{})
+	require.NoError(t, err)
+	require.Len(t, keys, listFilePathsMaxLimit)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, keys, 10)
+}
+
+func TestFilesystemPublishAndLoadSchema(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+	cfg := DataStoreConfig{
+		Type:              "Filesystem",
+		NetworkPassphrase: "test",
+		Compression:       "zstd",
+		Schema:            DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 10},
+	}
+
+	_, created, err := PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+	require.True(t, created)
+
+	_, created, err = PublishConfig(ctx, store, cfg)
+	require.NoError(t, err)
+	require.False(t, created)
+
+	schema := cfg.Schema
+	key := schema.GetObjectKeyFromSequenceNumber(5)
+	require.NoError(t, store.PutFile(ctx, key, bytes.NewReader([]byte("x")),
+		MetaData{StartLedger: 5, EndLedger: 5}.ToMap()))
+
+	loaded, err := LoadSchema(ctx, store, DataStoreConfig{})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), loaded.LedgersPerFile)
+	require.Equal(t, uint32(10), loaded.FilesPerPartition)
+	require.Equal(t, "zst", loaded.FileExtension)
+
+	latest, err := FindLatestLedgerSequence(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, uint32(5), latest)
+}
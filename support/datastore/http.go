@@ -0,0 +1,353 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/log"
+)
+
+// ErrReadOnly is returned by write operations on a read-only DataStore.
+var ErrReadOnly = errors.New("datastore is read-only")
+
+// metadataHeaderPrefixes are the response header prefixes used by the cloud
+// providers to return custom object metadata over plain HTTP(S).
+var metadataHeaderPrefixes = []string{"X-Goog-Meta-", "X-Amz-Meta-"}
+
+// HTTPDataStore implements a read-only DataStore over plain HTTP(S), e.g. a
+// public galexie bucket, a static web server or a CDN in front of one.
+//
+// Static web servers cannot list their contents, so ListFilePaths derives the
+// object keys from the DataStoreSchema instead. Ledger files are assumed to be
+// contiguous from the configured start ledger up to the latest published file,
+// which is located by probing for file existence.
+type HTTPDataStore struct {
+	client      *http.Client
+	base        url.URL
+	startLedger uint32
+
+	lock   sync.Mutex
+	schema DataStoreSchema
+	// latestStart is the start boundary of the latest ledger file found so
+	// far, it is used as the starting point of the next search.
+	latestStart uint32
+}
+
+func NewHTTPDataStore(ctx context.Context, dataStoreConfig DataStoreConfig) (DataStore, error) {
+	baseURL, ok := dataStoreConfig.Params["base_url"]
+	if !ok {
+		return nil, errors.New("invalid HTTP config, no base_url")
+	}
+
+	// start_ledger is optional, defaults to the first ledger of a network.
+	startLedger := uint64(2)
+	if val, ok := dataStoreConfig.Params["start_ledger"]; ok {
+		var err error
+		if startLedger, err = strconv.ParseUint(val, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid HTTP config, start_ledger %q: %w", val, err)
+		}
+	}
+
+	return FromHTTPClient(ctx, &http.Client{}, baseURL, uint32(startLedger), dataStoreConfig.Schema)
+}
+
+// FromHTTPClient creates a read-only DataStore serving objects below baseURL.
+// If the schema is incomplete it is read from the datastore manifest on first use.
+func FromHTTPClient(ctx context.Context, client *http.Client, baseURL string, startLedger uint32, schema DataStoreSchema) (DataStore, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid HTTP config, unsupported base_url scheme %q", parsed.Scheme)
+	}
+
+	log.Debugf("creating HTTP datastore for: %s", parsed.String())
+	return &HTTPDataStore{
+		client:      client,
+		base:        *parsed,
+		startLedger: startLedger,
+		schema:      schema,
+	}, nil
+}
+
+func (b *HTTPDataStore) request(ctx context.Context, method, filePath string) (*http.Response, error) {
+	derived := b.base
+	derived.Path = path.Join("/", derived.Path, filePath)
+	req, err := http.NewRequestWithContext(ctx, method, derived.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, os.ErrNotExist
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad HTTP response '%s' for %s '%s'", resp.Status, method, derived.String())
+	}
+	return resp, nil
+}
+
+// head issues a HEAD request for the file and returns the response headers.
+func (b *HTTPDataStore) head(ctx context.Context, filePath string) (*http.Response, error) {
+	resp, err := b.request(ctx, http.MethodHead, filePath)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// GetFileMetadata retrieves the metadata for the specified file from its
+// x-goog-meta-* or x-amz-meta-* response headers.
+func (b *HTTPDataStore) GetFileMetadata(ctx context.Context, filePath string) (map[string]string, error) {
+	resp, err := b.head(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	metaData := map[string]string{}
+	for key, values := range resp.Header {
+		for _, prefix := range metadataHeaderPrefixes {
+			if strings.HasPrefix(key, prefix) && len(values) > 0 {
+				metaData[strings.ToLower(strings.TrimPrefix(key, prefix))] = values[0]
+			}
+		}
+	}
+	return metaData, nil
+}
+
+// GetFileLastModified retrieves the last modified time of a file from its Last-Modified header.
+func (b *HTTPDataStore) GetFileLastModified(ctx context.Context, filePath string) (time.Time, error) {
+	resp, err := b.head(ctx, filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid Last-Modified header for file %s: %w", filePath, err)
+	}
+	return lastModified, nil
+}
+
+// GetFile retrieves a file from the HTTP server.
+func (b *HTTPDataStore) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	resp, err := b.request(ctx, http.MethodGet, filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("error retrieving file %s: %w", filePath, err)
+	}
+	log.Debugf("File retrieved successfully: %s", filePath)
+	return resp.Body, nil
+}
+
+// PutFile is not supported, HTTPDataStore is read-only.
+func (b *HTTPDataStore) PutFile(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) error {
+	return fmt.Errorf("error uploading file %s: %w", filePath, ErrReadOnly)
+}
+
+// PutFileIfNotExists is not supported, HTTPDataStore is read-only.
+func (b *HTTPDataStore) PutFileIfNotExists(ctx context.Context, filePath string, in io.WriterTo, metaData map[string]string) (bool, error) {
+	return false, fmt.Errorf("error uploading file %s: %w", filePath, ErrReadOnly)
+}
+
+// Exists checks if a file exists on the HTTP server.
+func (b *HTTPDataStore) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, err := b.head(ctx, filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Size retrieves the size of a file from its Content-Length header.
+func (b *HTTPDataStore) Size(ctx context.Context, filePath string) (int64, error) {
+	resp, err := b.head(ctx, filePath)
+	if err != nil {
+		return 0, err
+	}
+	return resp.ContentLength, nil
+}
+
+// Close closes idle connections of the underlying HTTP client.
+func (b *HTTPDataStore) Close() error {
+	b.client.CloseIdleConnections()
+	return nil
+}
+
+// ListFilePaths lists up to 'limit' file paths under the provided prefix.
+// Returned paths are relative to the base URL
+// and ordered lexicographically ascending, matching the cloud backends.
+// If limit <= 0, implementations default to a cap of 1,000; values > 1,000 are capped to 1,000.
+//
+// The paths are derived from the schema: the manifest, if present, followed by
+// every ledger file from the latest published one down to the start ledger.
+func (b *HTTPDataStore) ListFilePaths(ctx context.Context, options ListFileOptions) ([]string, error) {
+	limit := int(options.Limit)
+	if limit <= 0 || limit > listFilePathsMaxLimit {
+		limit = listFilePathsMaxLimit
+	}
+	matches := func(key string) bool {
+		return strings.HasPrefix(key, options.Prefix) && (options.StartAfter == "" || key > options.StartAfter)
+	}
+
+	var keys []string
+	if matches(manifestFilename) {
+		exists, err := b.Exists(ctx, manifestFilename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check manifest file %q: %w", manifestFilename, err)
+		}
+		if exists {
+			keys = append(keys, manifestFilename)
+		}
+	}
+
+	schema, err := b.getSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latestStart, found, err := b.findLatestFileStart(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return keys, nil
+	}
+
+	// Ledger file keys sort in descending ledger order, so the i-th key in
+	// listing order is the file which starts i files below the latest one.
+	oldestStart := schema.GetSequenceNumberStartBoundary(b.startLedger)
+	count := int((latestStart-oldestStart)/schema.LedgersPerFile) + 1
+	keyAt := func(i int) string {
+		return schema.GetObjectKeyFromSequenceNumber(latestStart - uint32(i)*schema.LedgersPerFile)
+	}
+
+	i := sort.Search(count, func(i int) bool {
+		key := keyAt(i)
+		return key >= options.Prefix && (options.StartAfter == "" || key > options.StartAfter)
+	})
+	for ; i < count && len(keys) < limit; i++ {
+		key := keyAt(i)
+		if !strings.HasPrefix(key, options.Prefix) {
+			break
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// getSchema returns the configured schema, falling back to the datastore
+// manifest if the configuration is incomplete.
+func (b *HTTPDataStore) getSchema(ctx context.Context) (DataStoreSchema, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.schema.LedgersPerFile == 0 || b.schema.FilesPerPartition == 0 {
+		manifest, err := readManifest(ctx, b, manifestFilename)
+		if err != nil {
+			return DataStoreSchema{}, fmt.Errorf("ledgersPerFile and filesPerPartition are not configured "+
+				"and could not be read from the manifest: %w", err)
+		}
+		b.schema.LedgersPerFile = manifest.LedgersPerFile
+		b.schema.FilesPerPartition = manifest.FilesPerPartition
+		if b.schema.FileExtension == "" && manifest.Compression != "" {
+			compressor, err := compressxdr.NewCompressor(manifest.Compression)
+			if err != nil {
+				return DataStoreSchema{}, fmt.Errorf("invalid compression in manifest: %w", err)
+			}
+			b.schema.FileExtension = compressor.Name()
+		}
+	}
+	if b.schema.LedgersPerFile == 0 {
+		return DataStoreSchema{}, errors.New("invalid datastore schema, ledgersPerFile must be greater than zero")
+	}
+	return b.schema, nil
+}
+
+// findLatestFileStart returns the start boundary of the latest ledger file
+// published, assuming files are contiguous from the start ledger onwards. It
+// gallops forward from the latest file seen so far and then binary searches
+// between the last file found and the first missing one.
+func (b *HTTPDataStore) findLatestFileStart(ctx context.Context, schema DataStoreSchema) (uint32, bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	exists := func(start uint64) (bool, error) {
+		if start > math.MaxUint32 {
+			return false, nil
+		}
+		key := schema.GetObjectKeyFromSequenceNumber(uint32(start))
+		ok, err := b.Exists(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("error while checking existence of object key %v: %w", key, err)
+		}
+		return ok, nil
+	}
+
+	low := uint64(schema.GetSequenceNumberStartBoundary(b.startLedger))
+	if b.latestStart > uint32(low) {
+		low = uint64(b.latestStart)
+	}
+	ok, err := exists(low)
+	if err != nil {
+		return 0, false, err
+	}
+	if !ok {
+		return 0, false, nil
+	}
+
+	step := uint64(schema.LedgersPerFile)
+	files := uint64(1)
+	high := low + step
+	for {
+		ok, err = exists(high)
+		if err != nil {
+			return 0, false, err
+		}
+		if !ok {
+			break
+		}
+		low = high
+		files *= 2
+		high = low + files*step
+	}
+
+	// low exists and high doesn't, search for the last existing file between them.
+	for high-low > step {
+		mid := low + ((high-low)/step/2)*step
+		ok, err = exists(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	b.latestStart = uint32(low)
+	return uint32(low), true, nil
+}
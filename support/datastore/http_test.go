@@ -0,0 +1,236 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockHTTPObject struct {
+	body     []byte
+	metadata map[string]string
+}
+
+// newMockHTTPServer serves the given objects below /bucket, returning metadata
+// in x-goog-meta-* headers like a public GCS bucket.
+func newMockHTTPServer(t *testing.T, objects map[string]mockHTTPObject, requests *int32) *httptest.Server {
+	lastModified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests != nil {
+			atomic.AddInt32(requests, 1)
+		}
+		obj, ok := objects[strings.TrimPrefix(r.URL.Path, "/bucket/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		for k, v := range obj.metadata {
+			w.Header().Set("x-goog-meta-"+k, v)
+		}
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		http.ServeContent(w, r, "", lastModified, bytes.NewReader(obj.body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestHTTPDataStore(t *testing.T, server *httptest.Server, params map[string]string) DataStore {
+	if params == nil {
+		params = map[string]string{}
+	}
+	params["base_url"] = server.URL + "/bucket"
+	store, err := NewDataStore(context.Background(), DataStoreConfig{Type: "HTTP", Params: params})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, store.Close())
+	})
+	return store
+}
+
+func TestHTTPInvalidConfig(t *testing.T) {
+	_, err := NewDataStore(context.Background(), DataStoreConfig{Type: "HTTP"})
+	require.EqualError(t, err, "invalid HTTP config, no base_url")
+
+	_, err = NewDataStore(context.Background(), DataStoreConfig{
+		Type:   "HTTP",
+		Params: map[string]string{"base_url": "ftp://example.com"},
+	})
+	require.EqualError(t, err, `invalid HTTP config, unsupported base_url scheme "ftp"`)
+
+	_, err = NewDataStore(context.Background(), DataStoreConfig{
+		Type:   "HTTP",
+		Params: map[string]string{"base_url": "https://example.com", "start_ledger": "abc"},
+	})
+	require.ErrorContains(t, err, "invalid HTTP config, start_ledger")
+}
+
+func TestHTTPGetFile(t *testing.T) {
+	content := []byte("inside the file")
+	server := newMockHTTPServer(t, map[string]mockHTTPObject{
+		"dir/file.txt": {body: content, metadata: map[string]string{"start-ledger": "2", "end-ledger": "3"}},
+	}, nil)
+	store := newTestHTTPDataStore(t, server, nil)
+	ctx := context.Background()
+
+	reader, err := store.GetFile(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	requireReaderContentEquals(t, reader, content)
+
+	metadata, err := store.GetFileMetadata(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"start-ledger": "2", "end-ledger": "3"}, metadata)
+
+	size, err := store.Size(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), size)
+
+	lastModified, err := store.GetFileLastModified(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), lastModified.UTC())
+
+	exists, err := store.Exists(ctx, "dir/file.txt")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = store.Exists(ctx, "missing.txt")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = store.GetFile(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = store.Size(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = store.GetFileMetadata(ctx, "missing.txt")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPIsReadOnly(t *testing.T) {
+	server := newMockHTTPServer(t, map[string]mockHTTPObject{}, nil)
+	store := newTestHTTPDataStore(t, server, nil)
+	ctx := context.Background()
+
+	err := store.PutFile(ctx, "file.txt", bytes.NewReader(nil), nil)
+	require.ErrorIs(t, err, ErrReadOnly)
+
+	ok, err := store.PutFileIfNotExists(ctx, "file.txt", bytes.NewReader(nil), nil)
+	require.ErrorIs(t, err, ErrReadOnly)
+	require.False(t, ok)
+}
+
+func newLedgerObjects(t *testing.T, schema DataStoreSchema, start, end uint32) map[string]mockHTTPObject {
+	manifest, err := json.Marshal(DatastoreManifest{
+		NetworkPassphrase: "test",
+		Version:           Version,
+		Compression:       "zstd",
+		LedgersPerFile:    schema.LedgersPerFile,
+		FilesPerPartition: schema.FilesPerPartition,
+	})
+	require.NoError(t, err)
+
+	objects := map[string]mockHTTPObject{
+		manifestFilename: {body: manifest},
+	}
+	for seq := schema.GetSequenceNumberStartBoundary(start); seq <= end; seq += schema.LedgersPerFile {
+		objects[schema.GetObjectKeyFromSequenceNumber(seq)] = mockHTTPObject{
+			metadata: MetaData{
+				StartLedger: seq,
+				EndLedger:   schema.GetSequenceNumberEndBoundary(seq),
+			}.ToMap(),
+		}
+	}
+	return objects
+}
+
+func TestHTTPListFilePaths(t *testing.T) {
+	schema := DataStoreSchema{LedgersPerFile: 10, FilesPerPartition: 2}
+	server := newMockHTTPServer(t, newLedgerObjects(t, schema, 20, 79), nil)
+	store := newTestHTTPDataStore(t, server, map[string]string{"start_ledger": "20"})
+	ctx := context.Background()
+
+	keys, err := store.ListFilePaths(ctx, ListFileOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		".config.json",
+		"FFFFFFC3--60-79/FFFFFFB9--70-79.xdr.zst",
+		"FFFFFFC3--60-79/FFFFFFC3--60-69.xdr.zst",
+		"FFFFFFD7--40-59/FFFFFFCD--50-59.xdr.zst",
+		"FFFFFFD7--40-59/FFFFFFD7--40-49.xdr.zst",
+		"FFFFFFEB--20-39/FFFFFFE1--30-39.xdr.zst",
+		"FFFFFFEB--20-39/FFFFFFEB--20-29.xdr.zst",
+	}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "FFFFFFD7--40-59/"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"FFFFFFD7--40-59/FFFFFFCD--50-59.xdr.zst",
+		"FFFFFFD7--40-59/FFFFFFD7--40-49.xdr.zst",
+	}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{
+		StartAfter: "FFFFFFC3--60-79/FFFFFFC3--60-69.xdr.zst",
+		Limit:      2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"FFFFFFD7--40-59/FFFFFFCD--50-59.xdr.zst",
+		"FFFFFFD7--40-59/FFFFFFD7--40-49.xdr.zst",
+	}, keys)
+
+	keys, err = store.ListFilePaths(ctx, ListFileOptions{Prefix: "FFFFFF00"})
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
+
+func TestHTTPFindLatestLedger(t *testing.T) {
+	schema := DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 64000}
+	objects := newLedgerObjects(t, schema, 2, 1000)
+	var requests int32
+	server := newMockHTTPServer(t, objects, &requests)
+	store := newTestHTTPDataStore(t, server, nil)
+	ctx := context.Background()
+
+	latest, err := FindLatestLedgerSequence(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1000), latest)
+	require.Less(t, atomic.LoadInt32(&requests), int32(50))
+
+	latest, err = FindLatestLedgerUpToSequence(ctx, store, 500, schema)
+	require.NoError(t, err)
+	require.Equal(t, uint32(500), latest)
+
+	loaded, err := LoadSchema(ctx, store, DataStoreConfig{})
+	require.NoError(t, err)
+	require.Equal(t, DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 64000, FileExtension: "zst"}, loaded)
+
+	// newly published files are found on the next lookup
+	for seq := uint32(1001); seq <= 1003; seq++ {
+		objects[schema.GetObjectKeyFromSequenceNumber(seq)] = mockHTTPObject{
+			metadata: MetaData{StartLedger: seq, EndLedger: seq}.ToMap(),
+		}
+	}
+	latest, err = FindLatestLedgerSequence(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, uint32(1003), latest)
+}
+
+func TestHTTPListFilePathsEmpty(t *testing.T) {
+	server := newMockHTTPServer(t, newLedgerObjects(t, DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 1}, 2, 1), nil)
+	store := newTestHTTPDataStore(t, server, nil)
+
+	_, err := FindLatestLedgerSequence(context.Background(), store)
+	require.ErrorIs(t, err, ErrNoValidLedgerFiles)
+
+	_, err = GetLedgerFileExtension(context.Background(), store)
+	require.ErrorIs(t, err, ErrNoLedgerFiles)
+}
@@ -0,0 +1,116 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+// NewLedgerBatchMetaData returns the metadata of the file of a batch of
+// ledgers compressed with the given compressor. The SHA-256 of the file
+// content is set by UploadLedgerBatch.
+func NewLedgerBatchMetaData(
+	batch xdr.LedgerCloseMetaBatch,
+	networkPassphrase string,
+	compressor compressxdr.Compressor,
+	coreVersion string,
+	version string,
+) (MetaData, error) {
+	startLedger, err := batch.GetLedger(uint32(batch.StartSequence))
+	if err != nil {
+		return MetaData{}, err
+	}
+	endLedger, err := batch.GetLedger(uint32(batch.EndSequence))
+	if err != nil {
+		return MetaData{}, err
+	}
+
+	var dictionaryID uint32
+	if dictCompressor, ok := compressor.(*compressxdr.ZstdDictCompressor); ok {
+		dictionaryID = dictCompressor.DictionaryID()
+	}
+
+	return MetaData{
+		StartLedger:          startLedger.LedgerSequence(),
+		EndLedger:            endLedger.LedgerSequence(),
+		StartLedgerCloseTime: startLedger.LedgerCloseTime(),
+		EndLedgerCloseTime:   endLedger.LedgerCloseTime(),
+		NetworkPassPhrase:    networkPassphrase,
+		CompressionType:      compressor.Name(),
+		ProtocolVersion:      endLedger.ProtocolVersion(),
+		CoreVersion:          coreVersion,
+		Version:              version,
+		StartLedgerHash:      startLedger.LedgerHash().HexString(),
+		EndLedgerHash:        endLedger.LedgerHash().HexString(),
+
+		CompressionDictionaryID: dictionaryID,
+	}, nil
+}
+
+// LedgerBatchUpload describes the file uploaded by UploadLedgerBatch.
+type LedgerBatchUpload struct {
+	// Uploaded is false if the file already existed and was not overwritten.
+	Uploaded bool
+	// UncompressedSize and CompressedSize are the sizes in bytes of the
+	// encoded batch before and after compression.
+	UncompressedSize int64
+	CompressedSize   int64
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	io.Writer
+	count *int64
+}
+
+func (w countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	*w.count += int64(n)
+	return n, err
+}
+
+// UploadLedgerBatch encodes a batch of ledgers with the compressor and uploads
+// it to objectKey along with metaData, in which the SHA-256 of the file
+// content is set. The file is encoded before uploading so its SHA-256 can be
+// written along with it. Existing files are only replaced if overwrite is
+// true.
+func UploadLedgerBatch(
+	ctx context.Context,
+	dataStore DataStore,
+	objectKey string,
+	batch *xdr.LedgerCloseMetaBatch,
+	compressor compressxdr.Compressor,
+	metaData MetaData,
+	overwrite bool,
+) (LedgerBatchUpload, error) {
+	var result LedgerBatchUpload
+	var payload bytes.Buffer
+	contentHash := sha256.New()
+	uncompressedSize, err := compressxdr.NewXDREncoder(compressor, batch).WriteTo(countingWriter{
+		Writer: io.MultiWriter(&payload, contentHash),
+		count:  &result.CompressedSize,
+	})
+	if err != nil {
+		return LedgerBatchUpload{}, fmt.Errorf("error encoding %s: %w", objectKey, err)
+	}
+	result.UncompressedSize = uncompressedSize
+	metaData.ContentSHA256 = hex.EncodeToString(contentHash.Sum(nil))
+
+	if overwrite {
+		if err = dataStore.PutFile(ctx, objectKey, &payload, metaData.ToMap()); err != nil {
+			return LedgerBatchUpload{}, fmt.Errorf("error uploading %s (overwrite): %w", objectKey, err)
+		}
+		result.Uploaded = true
+		return result, nil
+	}
+	if result.Uploaded, err = dataStore.PutFileIfNotExists(ctx, objectKey, &payload, metaData.ToMap()); err != nil {
+		return LedgerBatchUpload{}, fmt.Errorf("error uploading %s: %w", objectKey, err)
+	}
+	return result, nil
+}
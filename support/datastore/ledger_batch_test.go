@@ -0,0 +1,79 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+func TestUploadLedgerBatch(t *testing.T) {
+	store, _ := newTestFilesystemDataStore(t)
+	ctx := context.Background()
+
+	batch := xdr.LedgerCloseMetaBatch{StartSequence: 5, EndSequence: 6}
+	for seq := uint32(5); seq <= 6; seq++ {
+		require.NoError(t, batch.AddLedger(xdr.LedgerCloseMeta{
+			V: 0,
+			V0: &xdr.LedgerCloseMetaV0{
+				LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+					Hash: xdr.Hash{byte(seq)},
+					Header: xdr.LedgerHeader{
+						LedgerSeq:     xdr.Uint32(seq),
+						LedgerVersion: 23,
+						ScpValue:      xdr.StellarValue{CloseTime: xdr.TimePoint(seq * 5)},
+					},
+				},
+			},
+		}))
+	}
+	compressor, err := compressxdr.NewCompressor(compressxdr.CompressionZstd)
+	require.NoError(t, err)
+	metaData, err := NewLedgerBatchMetaData(batch, "passphrase", compressor, "v23.0.0", "v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, MetaData{
+		StartLedger:          5,
+		EndLedger:            6,
+		StartLedgerCloseTime: 25,
+		EndLedgerCloseTime:   30,
+		ProtocolVersion:      23,
+		CoreVersion:          "v23.0.0",
+		NetworkPassPhrase:    "passphrase",
+		CompressionType:      compressor.Name(),
+		Version:              "v1.0.0",
+		StartLedgerHash:      xdr.Hash{5}.HexString(),
+		EndLedgerHash:        xdr.Hash{6}.HexString(),
+	}, metaData)
+
+	upload, err := UploadLedgerBatch(ctx, store, "batch", &batch, compressor, metaData, false)
+	require.NoError(t, err)
+	require.True(t, upload.Uploaded)
+	require.Positive(t, upload.CompressedSize)
+	require.Positive(t, upload.UncompressedSize)
+
+	reader, err := store.GetFile(ctx, "batch")
+	require.NoError(t, err)
+	var content bytes.Buffer
+	_, err = content.ReadFrom(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, upload.CompressedSize, int64(content.Len()))
+	stored, err := store.GetFileMetadata(ctx, "batch")
+	require.NoError(t, err)
+	sum := sha256.Sum256(content.Bytes())
+	metaData.ContentSHA256 = hex.EncodeToString(sum[:])
+	require.Equal(t, metaData.ToMap(), stored)
+
+	upload, err = UploadLedgerBatch(ctx, store, "batch", &batch, compressor, metaData, false)
+	require.NoError(t, err)
+	require.False(t, upload.Uploaded)
+	upload, err = UploadLedgerBatch(ctx, store, "batch", &batch, compressor, metaData, true)
+	require.NoError(t, err)
+	require.True(t, upload.Uploaded)
+}
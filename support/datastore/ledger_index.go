@@ -0,0 +1,464 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/xdr"
+)
+
+// ledgerIndexDir is the directory where the sidecar ledger indexes are
+// stored. Its name sorts after the upper case hex prefixes of every ledger
+// object key, so listing the datastore from the start, e.g. to find the latest
+// ledger, returns ledger files before any index.
+const ledgerIndexDir = "indexes"
+
+const (
+	ledgerIndexMagic             = "LIDX"
+	ledgerIndexTransactionsMagic = "LIDT"
+	ledgerIndexVersion           = 2
+	// ledgerIndexMaxLedgers bounds the number of ledgers covered by an index,
+	// and so the memory used to build it.
+	ledgerIndexMaxLedgers = 4096
+	// ledgerIndexTransactionShards is the number of files the transactions of
+	// an index are split into by hash, so finding a transaction only reads
+	// one of them.
+	ledgerIndexTransactionShards = 32
+)
+
+// ledgerIndexSize returns the number of ledgers covered by each ledger index:
+// as many whole ledger files as fit in ledgerIndexMaxLedgers, or at least
+// one, and no more than a partition.
+func ledgerIndexSize(schema DataStoreSchema) uint32 {
+	files := max(ledgerIndexMaxLedgers/schema.LedgersPerFile, 1)
+	return schema.LedgersPerFile * min(files, max(schema.FilesPerPartition, 1))
+}
+
+// LedgerIndexPath returns the directory holding the files of the ledger
+// index covering ledgerSeq.
+func LedgerIndexPath(schema DataStoreSchema, ledgerSeq uint32) string {
+	size := ledgerIndexSize(schema)
+	start := (ledgerSeq / size) * size
+	return fmt.Sprintf("%s/%08X--%d-%d", ledgerIndexDir, math.MaxUint32-start, start, start+size-1)
+}
+
+// ledgerIndexLedgersPath returns the object key of the close times and
+// contract events of the ledger index covering ledgerSeq.
+func ledgerIndexLedgersPath(schema DataStoreSchema, ledgerSeq uint32) string {
+	return LedgerIndexPath(schema, ledgerSeq) + "/ledgers.zst"
+}
+
+// ledgerIndexTransactionsPath returns the object key of a shard of the
+// transactions of the ledger index covering ledgerSeq.
+func ledgerIndexTransactionsPath(schema DataStoreSchema, ledgerSeq uint32, shard int) string {
+	return fmt.Sprintf("%s/transactions-%02d.zst", LedgerIndexPath(schema, ledgerSeq), shard)
+}
+
+// ledgerIndexShard returns the transactions shard of a transaction hash.
+func ledgerIndexShard(hash xdr.Hash) int {
+	return int(hash[0]) * ledgerIndexTransactionShards / 256
+}
+
+// LedgerIndex maps transaction hashes, close times and contract ids to the
+// ledgers of a range of a datastore, so they can be located without scanning
+// ledger files. Each index covers at most ledgerIndexMaxLedgers ledgers, and
+// is stored as a file of close times and contract events and a fixed number
+// of files of transactions, sharded by hash.
+type LedgerIndex struct {
+	// Start and End are the boundaries of the range covered by the index.
+	Start uint32
+	End   uint32
+	// FirstLedger and LastLedger are the range of indexed ledgers, both zero
+	// while the index is empty.
+	FirstLedger uint32
+	LastLedger  uint32
+
+	// closeTimes holds the close time of each indexed ledger, from FirstLedger.
+	closeTimes     []int64
+	transactions   map[xdr.Hash]uint32
+	contractEvents map[xdr.ContractId][]uint32
+}
+
+// NewLedgerIndex returns an empty index for the range containing ledgerSeq.
+func NewLedgerIndex(schema DataStoreSchema, ledgerSeq uint32) *LedgerIndex {
+	size := ledgerIndexSize(schema)
+	start := (ledgerSeq / size) * size
+	return &LedgerIndex{
+		Start:          start,
+		End:            start + size - 1,
+		transactions:   map[xdr.Hash]uint32{},
+		contractEvents: map[xdr.ContractId][]uint32{},
+	}
+}
+
+// AddLedger indexes the transactions, close time and contract events of a
+// ledger, which must either immediately follow the last indexed ledger or be
+// already indexed, in which case its entries are replaced, e.g. after it was
+// exported again.
+func (i *LedgerIndex) AddLedger(lcm xdr.LedgerCloseMeta) error {
+	seq := lcm.LedgerSequence()
+	if seq < i.Start || seq > i.End {
+		return fmt.Errorf("ledger %d is outside of the index range %d-%d", seq, i.Start, i.End)
+	}
+	replace := i.LastLedger != 0 && seq >= i.FirstLedger && seq <= i.LastLedger
+	if i.LastLedger != 0 && !replace && seq != i.LastLedger+1 {
+		return fmt.Errorf("ledger %d does not follow the last indexed ledger %d", seq, i.LastLedger)
+	}
+	if replace {
+		i.removeLedger(seq)
+	}
+
+	for tx := 0; tx < lcm.CountTransactions(); tx++ {
+		i.transactions[lcm.TransactionHash(tx)] = seq
+		meta := lcm.TxApplyProcessing(tx)
+		events, err := contractEvents(&meta)
+		if err != nil {
+			return fmt.Errorf("failed to read contract events of ledger %d: %w", seq, err)
+		}
+		for _, event := range events {
+			if event.ContractId == nil {
+				continue
+			}
+			ledgers := i.contractEvents[*event.ContractId]
+			if n, found := slices.BinarySearch(ledgers, seq); !found {
+				i.contractEvents[*event.ContractId] = slices.Insert(ledgers, n, seq)
+			}
+		}
+	}
+
+	if replace {
+		i.closeTimes[seq-i.FirstLedger] = lcm.LedgerCloseTime()
+		return nil
+	}
+	if i.FirstLedger == 0 {
+		i.FirstLedger = seq
+	}
+	i.LastLedger = seq
+	i.closeTimes = append(i.closeTimes, lcm.LedgerCloseTime())
+	return nil
+}
+
+// removeLedger removes the transactions and contract events of an indexed
+// ledger, keeping its close time until it is replaced.
+func (i *LedgerIndex) removeLedger(ledger uint32) {
+	for hash, seq := range i.transactions {
+		if seq == ledger {
+			delete(i.transactions, hash)
+		}
+	}
+	for id, ledgers := range i.contractEvents {
+		n, found := slices.BinarySearch(ledgers, ledger)
+		if !found {
+			continue
+		}
+		if len(ledgers) == 1 {
+			delete(i.contractEvents, id)
+		} else {
+			i.contractEvents[id] = slices.Delete(ledgers, n, n+1)
+		}
+	}
+}
+
+// contractEvents returns the contract events emitted by a transaction,
+// including its transaction level events.
+func contractEvents(meta *xdr.TransactionMeta) ([]xdr.ContractEvent, error) {
+	if meta.V != 4 {
+		// before V4 all contract events are returned for any operation
+		return meta.GetContractEventsForOperation(0)
+	}
+	var events []xdr.ContractEvent
+	for _, op := range meta.MustV4().Operations {
+		events = append(events, op.Events...)
+	}
+	for _, event := range meta.MustV4().Events {
+		events = append(events, event.Event)
+	}
+	return events, nil
+}
+
+// TransactionLedger returns the ledger containing the transaction with the
+// given hash, if it is indexed.
+func (i *LedgerIndex) TransactionLedger(hash xdr.Hash) (uint32, bool) {
+	seq, ok := i.transactions[hash]
+	return seq, ok
+}
+
+// LedgerAtCloseTime returns the latest indexed ledger closed at or before
+// closeTime, a unix timestamp. It returns false if the first indexed ledger
+// closed after closeTime.
+func (i *LedgerIndex) LedgerAtCloseTime(closeTime int64) (uint32, bool) {
+	n := sort.Search(len(i.closeTimes), func(j int) bool {
+		return i.closeTimes[j] > closeTime
+	})
+	if n == 0 {
+		return 0, false
+	}
+	return i.FirstLedger + uint32(n) - 1, true
+}
+
+// ContractEventLedgers returns the indexed ledgers, in ascending order, in
+// which the contract emitted events.
+func (i *LedgerIndex) ContractEventLedgers(contractID xdr.ContractId) []uint32 {
+	return slices.Clone(i.contractEvents[contractID])
+}
+
+// ledgerIndexTransaction is an entry of a transactions shard.
+type ledgerIndexTransaction struct {
+	Hash   xdr.Hash
+	Ledger uint32
+}
+
+// writeLedgerIndexHeader writes the magic and version of an index file,
+// followed by the range of the index.
+func writeLedgerIndexHeader(buf *bytes.Buffer, magic string, i *LedgerIndex) {
+	buf.WriteString(magic)
+	buf.WriteByte(ledgerIndexVersion)
+	// writes to a bytes.Buffer never fail
+	_ = binary.Write(buf, binary.BigEndian, []uint32{i.Start, i.End, i.FirstLedger, i.LastLedger})
+}
+
+// readLedgerIndexHeader reads the header written by writeLedgerIndexHeader
+// into the range of the index.
+func readLedgerIndexHeader(r *bytes.Reader, magic string, i *LedgerIndex) error {
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:len(magic)]) != magic {
+		return fmt.Errorf("invalid ledger index header")
+	}
+	if version := header[len(magic)]; version != ledgerIndexVersion {
+		return fmt.Errorf("unsupported ledger index version %d", version)
+	}
+	var bounds [4]uint32
+	if err := binary.Read(r, binary.BigEndian, &bounds); err != nil {
+		return fmt.Errorf("invalid ledger index: %w", err)
+	}
+	i.Start, i.End, i.FirstLedger, i.LastLedger = bounds[0], bounds[1], bounds[2], bounds[3]
+	if i.LastLedger < i.FirstLedger || i.FirstLedger != 0 && i.FirstLedger < i.Start || i.LastLedger > i.End {
+		return fmt.Errorf("invalid ledger index range %d-%d", i.FirstLedger, i.LastLedger)
+	}
+	return nil
+}
+
+// marshalLedgers encodes the close times and contract events of the index.
+// Entries are sorted so the encoding of an index is deterministic.
+func (i *LedgerIndex) marshalLedgers() []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	}
+	writeLedgerIndexHeader(&buf, ledgerIndexMagic, i)
+	write(i.closeTimes)
+
+	ids := make([]xdr.ContractId, 0, len(i.contractEvents))
+	for id := range i.contractEvents {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b xdr.ContractId) int { return bytes.Compare(a[:], b[:]) })
+	write(uint32(len(ids)))
+	for _, id := range ids {
+		buf.Write(id[:])
+		write(uint32(len(i.contractEvents[id])))
+		write(i.contractEvents[id])
+	}
+	return buf.Bytes()
+}
+
+// marshalTransactions encodes the transactions of a shard of the index,
+// sorted by hash.
+func (i *LedgerIndex) marshalTransactions(shard int) []byte {
+	var entries []ledgerIndexTransaction
+	for hash, seq := range i.transactions {
+		if ledgerIndexShard(hash) == shard {
+			entries = append(entries, ledgerIndexTransaction{Hash: hash, Ledger: seq})
+		}
+	}
+	slices.SortFunc(entries, func(a, b ledgerIndexTransaction) int { return bytes.Compare(a.Hash[:], b.Hash[:]) })
+
+	var buf bytes.Buffer
+	writeLedgerIndexHeader(&buf, ledgerIndexTransactionsMagic, i)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(entries)))
+	_ = binary.Write(&buf, binary.BigEndian, entries)
+	return buf.Bytes()
+}
+
+// unmarshalLedgers decodes the close times and contract events encoded by
+// marshalLedgers, replacing the content of the index.
+func (i *LedgerIndex) unmarshalLedgers(data []byte) error {
+	r := bytes.NewReader(data)
+	index := LedgerIndex{
+		transactions:   map[xdr.Hash]uint32{},
+		contractEvents: map[xdr.ContractId][]uint32{},
+	}
+	if err := readLedgerIndexHeader(r, ledgerIndexMagic, &index); err != nil {
+		return err
+	}
+	if index.LastLedger != 0 {
+		if n := int64(index.LastLedger-index.FirstLedger) + 1; n*8 > int64(r.Len()) {
+			return fmt.Errorf("invalid ledger index close times: truncated")
+		}
+		index.closeTimes = make([]int64, index.LastLedger-index.FirstLedger+1)
+		if err := binary.Read(r, binary.BigEndian, index.closeTimes); err != nil {
+			return fmt.Errorf("invalid ledger index close times: %w", err)
+		}
+	}
+
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return fmt.Errorf("invalid ledger index contract events: %w", err)
+	}
+	for ; count > 0; count-- {
+		var entry struct {
+			ContractID xdr.ContractId
+			Count      uint32
+		}
+		if err := binary.Read(r, binary.BigEndian, &entry); err != nil {
+			return fmt.Errorf("invalid ledger index contract events: %w", err)
+		}
+		if int64(entry.Count)*4 > int64(r.Len()) {
+			return fmt.Errorf("invalid ledger index contract events: truncated")
+		}
+		ledgers := make([]uint32, entry.Count)
+		if err := binary.Read(r, binary.BigEndian, ledgers); err != nil {
+			return fmt.Errorf("invalid ledger index contract events: %w", err)
+		}
+		index.contractEvents[entry.ContractID] = ledgers
+	}
+
+	*i = index
+	return nil
+}
+
+// unmarshalTransactions decodes the transactions encoded by
+// marshalTransactions for the index with the given range.
+func unmarshalTransactions(data []byte, start, end uint32) ([]ledgerIndexTransaction, error) {
+	r := bytes.NewReader(data)
+	var index LedgerIndex
+	if err := readLedgerIndexHeader(r, ledgerIndexTransactionsMagic, &index); err != nil {
+		return nil, err
+	}
+	if index.Start != start || index.End != end {
+		return nil, fmt.Errorf("invalid ledger index transactions: range %d-%d, expected %d-%d",
+			index.Start, index.End, start, end)
+	}
+	var count uint32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, fmt.Errorf("invalid ledger index transactions: %w", err)
+	}
+	if int64(count)*int64(binary.Size(ledgerIndexTransaction{})) > int64(r.Len()) {
+		return nil, fmt.Errorf("invalid ledger index transactions: truncated")
+	}
+	entries := make([]ledgerIndexTransaction, count)
+	if err := binary.Read(r, binary.BigEndian, entries); err != nil {
+		return nil, fmt.Errorf("invalid ledger index transactions: %w", err)
+	}
+	return entries, nil
+}
+
+// PutLedgerIndex stores a ledger index in the datastore, replacing any
+// earlier version of it. The transactions shards are written before the
+// ledgers file, whose presence marks the index as readable.
+func PutLedgerIndex(ctx context.Context, dataStore DataStore, schema DataStoreSchema, index *LedgerIndex) error {
+	for shard := 0; shard < ledgerIndexTransactionShards; shard++ {
+		path := ledgerIndexTransactionsPath(schema, index.Start, shard)
+		if err := putLedgerIndexFile(ctx, dataStore, path, index.marshalTransactions(shard)); err != nil {
+			return err
+		}
+	}
+	return putLedgerIndexFile(ctx, dataStore, ledgerIndexLedgersPath(schema, index.Start), index.marshalLedgers())
+}
+
+func putLedgerIndexFile(ctx context.Context, dataStore DataStore, path string, data []byte) error {
+	var buf bytes.Buffer
+	writer, err := compressxdr.DefaultCompressor.NewWriter(&buf)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	if err = dataStore.PutFile(ctx, path, &buf, map[string]string{
+		"Content-Type": "application/octet-stream",
+	}); err != nil {
+		return fmt.Errorf("failed to write ledger index %q: %w", path, err)
+	}
+	return nil
+}
+
+// GetLedgerIndex reads the ledger index covering ledgerSeq from the
+// datastore, including every transactions shard. The error wraps
+// os.ErrNotExist if the range has no index.
+func GetLedgerIndex(ctx context.Context, dataStore DataStore, schema DataStoreSchema, ledgerSeq uint32) (*LedgerIndex, error) {
+	index, err := getLedgerIndexLedgers(ctx, dataStore, schema, ledgerSeq)
+	if err != nil {
+		return nil, err
+	}
+	for shard := 0; shard < ledgerIndexTransactionShards; shard++ {
+		entries, err := getLedgerIndexTransactions(ctx, dataStore, schema, index, shard)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			index.transactions[entry.Hash] = entry.Ledger
+		}
+	}
+	return index, nil
+}
+
+// getLedgerIndexLedgers reads the close times and contract events of the
+// ledger index covering ledgerSeq, without its transactions.
+func getLedgerIndexLedgers(ctx context.Context, dataStore DataStore, schema DataStoreSchema, ledgerSeq uint32) (*LedgerIndex, error) {
+	path := ledgerIndexLedgersPath(schema, ledgerSeq)
+	data, err := getLedgerIndexFile(ctx, dataStore, path)
+	if err != nil {
+		return nil, err
+	}
+	index := &LedgerIndex{}
+	if err = index.unmarshalLedgers(data); err != nil {
+		return nil, fmt.Errorf("unable to decode ledger index %q: %w", path, err)
+	}
+	return index, nil
+}
+
+// getLedgerIndexTransactions reads a transactions shard of an index, sorted
+// by hash.
+func getLedgerIndexTransactions(ctx context.Context, dataStore DataStore, schema DataStoreSchema, index *LedgerIndex, shard int) ([]ledgerIndexTransaction, error) {
+	path := ledgerIndexTransactionsPath(schema, index.Start, shard)
+	data, err := getLedgerIndexFile(ctx, dataStore, path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := unmarshalTransactions(data, index.Start, index.End)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode ledger index %q: %w", path, err)
+	}
+	return entries, nil
+}
+
+func getLedgerIndexFile(ctx context.Context, dataStore DataStore, path string) ([]byte, error) {
+	reader, err := dataStore.GetFile(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ledger index %q: %w", path, err)
+	}
+	defer reader.Close()
+
+	decompressed, err := compressxdr.DefaultCompressor.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress ledger index %q: %w", path, err)
+	}
+	defer decompressed.Close()
+	data, err := io.ReadAll(decompressed)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read ledger index %q: %w", path, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,160 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found in the ledger indexes")
+	ErrLedgerNotFound      = errors.New("no ledger found in the ledger indexes")
+)
+
+// LedgerIndexReader answers queries over the ledger indexes of a datastore,
+// e.g. to find the ledger of a transaction without scanning ledger files.
+//
+// Queries cover a range of ledgers and read the index of every range of at
+// most ledgerIndexMaxLedgers ledgers they need, only reading the transactions
+// shard of the requested hash when looking up a transaction. An error
+// wrapping os.ErrNotExist is returned if one of them has no index. Ledgers
+// exported after the last update of an index are not covered by it.
+type LedgerIndexReader struct {
+	dataStore DataStore
+	schema    DataStoreSchema
+}
+
+// NewLedgerIndexReader returns a LedgerIndexReader for the ledger indexes of
+// a datastore with the given schema.
+func NewLedgerIndexReader(dataStore DataStore, schema DataStoreSchema) *LedgerIndexReader {
+	return &LedgerIndexReader{dataStore: dataStore, schema: schema}
+}
+
+// indexRange returns the numbers of the first and last indexes covering
+// ledgers of the range start to end.
+func (r *LedgerIndexReader) indexRange(start, end uint32) (uint32, uint32, error) {
+	if r.schema.LedgersPerFile == 0 {
+		return 0, 0, errors.New("ledgersPerFile must be > 0")
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("invalid ledger range %d-%d", start, end)
+	}
+	size := ledgerIndexSize(r.schema)
+	return start / size, end / size, nil
+}
+
+// ledgers returns the close times and contract events of an index, without
+// its transactions.
+func (r *LedgerIndexReader) ledgers(ctx context.Context, index uint32) (*LedgerIndex, error) {
+	return getLedgerIndexLedgers(ctx, r.dataStore, r.schema, index*ledgerIndexSize(r.schema))
+}
+
+// FindTransaction returns the ledger, between start and end, containing the
+// transaction with the given hash. Indexes are searched from the most recent.
+// ErrTransactionNotFound is returned if the transaction is not indexed.
+func (r *LedgerIndexReader) FindTransaction(ctx context.Context, hash xdr.Hash, start, end uint32) (uint32, error) {
+	first, last, err := r.indexRange(start, end)
+	if err != nil {
+		return 0, err
+	}
+	size := ledgerIndexSize(r.schema)
+	// uint64 avoids underflowing after the first index
+	for i := uint64(last) + 1; i > uint64(first); i-- {
+		index := NewLedgerIndex(r.schema, uint32(i-1)*size)
+		entries, err := getLedgerIndexTransactions(ctx, r.dataStore, r.schema, index, ledgerIndexShard(hash))
+		if err != nil {
+			return 0, err
+		}
+		n, found := slices.BinarySearchFunc(entries, hash, func(entry ledgerIndexTransaction, hash xdr.Hash) int {
+			return bytes.Compare(entry.Hash[:], hash[:])
+		})
+		if found && entries[n].Ledger >= start && entries[n].Ledger <= end {
+			return entries[n].Ledger, nil
+		}
+	}
+	return 0, ErrTransactionNotFound
+}
+
+// FindLedgerByCloseTime returns the latest ledger, between start and end,
+// closed at or before closeTime. ErrLedgerNotFound is returned if no indexed
+// ledger of the range closed at or before closeTime.
+func (r *LedgerIndexReader) FindLedgerByCloseTime(ctx context.Context, closeTime time.Time, start, end uint32) (uint32, error) {
+	first, last, err := r.indexRange(start, end)
+	if err != nil {
+		return 0, err
+	}
+	unixTime := closeTime.Unix()
+
+	indexes := map[uint32]*LedgerIndex{}
+	var lookupErr error
+	getIndex := func(i uint32) *LedgerIndex {
+		if index, ok := indexes[i]; ok {
+			return index
+		}
+		index, err := r.ledgers(ctx, i)
+		if err != nil {
+			lookupErr = err
+			return nil
+		}
+		indexes[i] = index
+		return index
+	}
+
+	// close times increase with ledgers, search the first index whose first
+	// ledger closed after closeTime.
+	n := sort.Search(int(last-first+1), func(i int) bool {
+		if lookupErr != nil {
+			return true
+		}
+		index := getIndex(first + uint32(i))
+		if index == nil {
+			return true
+		}
+		// an empty index is only expected at the end of the exported ledgers
+		return len(index.closeTimes) == 0 || index.closeTimes[0] > unixTime
+	})
+	if lookupErr != nil {
+		return 0, lookupErr
+	}
+	if n == 0 {
+		return 0, ErrLedgerNotFound
+	}
+
+	index := getIndex(first + uint32(n) - 1)
+	if lookupErr != nil {
+		return 0, lookupErr
+	}
+	seq, ok := index.LedgerAtCloseTime(unixTime)
+	if !ok || seq < start {
+		return 0, ErrLedgerNotFound
+	}
+	return min(seq, end), nil
+}
+
+// FindContractEventLedgers returns the ledgers, between start and end and in
+// ascending order, in which the contract emitted events.
+func (r *LedgerIndexReader) FindContractEventLedgers(ctx context.Context, contractID xdr.ContractId, start, end uint32) ([]uint32, error) {
+	first, last, err := r.indexRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	var ledgers []uint32
+	for i := uint64(first); i <= uint64(last); i++ {
+		index, err := r.ledgers(ctx, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		for _, seq := range index.ContractEventLedgers(contractID) {
+			if seq >= start && seq <= end {
+				ledgers = append(ledgers, seq)
+			}
+		}
+	}
+	return ledgers, nil
+}
@@ -0,0 +1,233 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func testTxHash(seq uint32, i int) xdr.Hash {
+	var b [8]byte
+	binary.BigEndian.PutUint32(b[:4], seq)
+	binary.BigEndian.PutUint32(b[4:], uint32(i))
+	return sha256.Sum256(b[:])
+}
+
+// createIndexedLedgerCloseMeta creates a ledger closed at seq*5 with two
+// transactions. The first emits an event of contractA using V3 meta, the
+// second an event of contractB in even ledgers using V4 meta.
+func createIndexedLedgerCloseMeta(seq uint32, contractA, contractB xdr.ContractId) xdr.LedgerCloseMeta {
+	v3 := xdr.TransactionResultMeta{
+		Result: xdr.TransactionResultPair{TransactionHash: testTxHash(seq, 0)},
+		TxApplyProcessing: xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+			SorobanMeta: &xdr.SorobanTransactionMeta{Events: []xdr.ContractEvent{{ContractId: &contractA}}},
+		}},
+	}
+	v4 := xdr.TransactionResultMeta{
+		Result:            xdr.TransactionResultPair{TransactionHash: testTxHash(seq, 1)},
+		TxApplyProcessing: xdr.TransactionMeta{V: 4, V4: &xdr.TransactionMetaV4{}},
+	}
+	if seq%2 == 0 {
+		v4.TxApplyProcessing.V4.Operations = []xdr.OperationMetaV2{
+			{Events: []xdr.ContractEvent{{ContractId: &contractB}, {ContractId: &contractB}}},
+		}
+	}
+	return xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq: xdr.Uint32(seq),
+					ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(seq * 5)},
+				},
+			},
+			TxProcessing: []xdr.TransactionResultMeta{v3, v4},
+		},
+	}
+}
+
+func TestLedgerIndex(t *testing.T) {
+	schema := DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 5}
+	contractA, contractB := xdr.ContractId{1}, xdr.ContractId{2}
+
+	index := NewLedgerIndex(schema, 13)
+	require.Equal(t, uint32(10), index.Start)
+	require.Equal(t, uint32(19), index.End)
+	require.EqualError(t, index.AddLedger(createIndexedLedgerCloseMeta(20, contractA, contractB)),
+		"ledger 20 is outside of the index range 10-19")
+
+	for seq := uint32(12); seq <= 17; seq++ {
+		require.NoError(t, index.AddLedger(createIndexedLedgerCloseMeta(seq, contractA, contractB)))
+	}
+	require.EqualError(t, index.AddLedger(createIndexedLedgerCloseMeta(19, contractA, contractB)),
+		"ledger 19 does not follow the last indexed ledger 17")
+
+	seq, ok := index.TransactionLedger(testTxHash(15, 1))
+	require.True(t, ok)
+	require.Equal(t, uint32(15), seq)
+	_, ok = index.TransactionLedger(testTxHash(18, 0))
+	require.False(t, ok)
+
+	_, ok = index.LedgerAtCloseTime(59)
+	require.False(t, ok)
+	seq, ok = index.LedgerAtCloseTime(77)
+	require.True(t, ok)
+	require.Equal(t, uint32(15), seq)
+	seq, ok = index.LedgerAtCloseTime(1000)
+	require.True(t, ok)
+	require.Equal(t, uint32(17), seq)
+
+	require.Equal(t, []uint32{12, 13, 14, 15, 16, 17}, index.ContractEventLedgers(contractA))
+	require.Equal(t, []uint32{12, 14, 16}, index.ContractEventLedgers(contractB))
+	require.Empty(t, index.ContractEventLedgers(xdr.ContractId{3}))
+
+	data := index.marshalLedgers()
+	decoded := &LedgerIndex{}
+	require.NoError(t, decoded.unmarshalLedgers(data))
+	for shard := 0; shard < ledgerIndexTransactionShards; shard++ {
+		entries, err := unmarshalTransactions(index.marshalTransactions(shard), 10, 19)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			require.Equal(t, shard, ledgerIndexShard(entry.Hash))
+			decoded.transactions[entry.Hash] = entry.Ledger
+		}
+	}
+	require.Equal(t, index, decoded)
+
+	require.ErrorContains(t, decoded.unmarshalLedgers(data[:len(data)-3]), "invalid ledger index contract events")
+	require.EqualError(t, decoded.unmarshalLedgers([]byte("LIDX\x01")), "unsupported ledger index version 1")
+	_, err := unmarshalTransactions(index.marshalTransactions(0), 20, 29)
+	require.EqualError(t, err, "invalid ledger index transactions: range 10-19, expected 20-29")
+
+	// replacing ledger 14 keeps the later ledgers
+	replaced := createIndexedLedgerCloseMeta(14, contractA, contractB)
+	replaced.V1.LedgerHeader.Header.ScpValue.CloseTime = 71
+	replaced.V1.TxProcessing = replaced.V1.TxProcessing[1:]
+	replaced.V1.TxProcessing[0].Result.TransactionHash = testTxHash(14, 2)
+	require.NoError(t, index.AddLedger(replaced))
+	require.Equal(t, uint32(12), index.FirstLedger)
+	require.Equal(t, uint32(17), index.LastLedger)
+	_, ok = index.TransactionLedger(testTxHash(14, 0))
+	require.False(t, ok)
+	seq, ok = index.TransactionLedger(testTxHash(14, 2))
+	require.True(t, ok)
+	require.Equal(t, uint32(14), seq)
+	seq, ok = index.TransactionLedger(testTxHash(16, 0))
+	require.True(t, ok)
+	require.Equal(t, uint32(16), seq)
+	seq, ok = index.LedgerAtCloseTime(71)
+	require.True(t, ok)
+	require.Equal(t, uint32(14), seq)
+	require.Equal(t, []uint32{12, 13, 15, 16, 17}, index.ContractEventLedgers(contractA))
+	require.Equal(t, []uint32{12, 14, 16}, index.ContractEventLedgers(contractB))
+
+	require.NoError(t, index.AddLedger(createIndexedLedgerCloseMeta(14, contractA, contractB)))
+	require.Equal(t, []uint32{12, 13, 14, 15, 16, 17}, index.ContractEventLedgers(contractA))
+	require.Equal(t, data, index.marshalLedgers())
+}
+
+func TestLedgerIndexSize(t *testing.T) {
+	for _, tc := range []struct {
+		ledgersPerFile, filesPerPartition, expected uint32
+	}{
+		{ledgersPerFile: 1, filesPerPartition: 64000, expected: 4096},
+		{ledgersPerFile: 64, filesPerPartition: 1000, expected: 4096},
+		{ledgersPerFile: 100, filesPerPartition: 1000, expected: 4000},
+		{ledgersPerFile: 10000, filesPerPartition: 10, expected: 10000},
+		{ledgersPerFile: 2, filesPerPartition: 5, expected: 10},
+		{ledgersPerFile: 2, filesPerPartition: 0, expected: 2},
+	} {
+		schema := DataStoreSchema{LedgersPerFile: tc.ledgersPerFile, FilesPerPartition: tc.filesPerPartition}
+		require.Equal(t, tc.expected, ledgerIndexSize(schema), "%+v", schema)
+	}
+}
+
+func TestLedgerIndexReader(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestFilesystemDataStore(t)
+	schema := DataStoreSchema{LedgersPerFile: 2, FilesPerPartition: 5}
+	contractA, contractB := xdr.ContractId{1}, xdr.ContractId{2}
+
+	// ledgers 2 to 25 are indexed in ranges 0-9, 10-19 and 20-29
+	for start := uint32(0); start < 30; start += 10 {
+		index := NewLedgerIndex(schema, start)
+		for seq := max(start, 2); seq <= min(start+9, 25); seq++ {
+			require.NoError(t, index.AddLedger(createIndexedLedgerCloseMeta(seq, contractA, contractB)))
+		}
+		require.NoError(t, PutLedgerIndex(ctx, store, schema, index))
+
+		stored, err := GetLedgerIndex(ctx, store, schema, start+5)
+		require.NoError(t, err)
+		require.Equal(t, index, stored)
+	}
+	require.Equal(t, "indexes/FFFFFFEB--20-29", LedgerIndexPath(schema, 25))
+	require.Equal(t, "indexes/FFFFFFEB--20-29/transactions-07.zst", ledgerIndexTransactionsPath(schema, 25, 7))
+
+	reader := NewLedgerIndexReader(store, schema)
+	seq, err := reader.FindTransaction(ctx, testTxHash(7, 1), 2, 25)
+	require.NoError(t, err)
+	require.Equal(t, uint32(7), seq)
+	_, err = reader.FindTransaction(ctx, testTxHash(7, 1), 10, 25)
+	require.ErrorIs(t, err, ErrTransactionNotFound)
+	_, err = reader.FindTransaction(ctx, testTxHash(7, 1), 2, 35)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, tc := range []struct {
+		closeTime  int64
+		start, end uint32
+		expected   uint32
+	}{
+		{closeTime: 10, start: 2, end: 25, expected: 2},
+		{closeTime: 49, start: 2, end: 25, expected: 9},
+		{closeTime: 50, start: 2, end: 25, expected: 10},
+		{closeTime: 99, start: 2, end: 25, expected: 19},
+		{closeTime: 1000, start: 2, end: 25, expected: 25},
+		{closeTime: 1000, start: 2, end: 14, expected: 14},
+		{closeTime: 70, start: 12, end: 25, expected: 14},
+	} {
+		seq, err = reader.FindLedgerByCloseTime(ctx, time.Unix(tc.closeTime, 0), tc.start, tc.end)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, seq, "close time %d", tc.closeTime)
+	}
+	_, err = reader.FindLedgerByCloseTime(ctx, time.Unix(9, 0), 2, 25)
+	require.ErrorIs(t, err, ErrLedgerNotFound)
+	_, err = reader.FindLedgerByCloseTime(ctx, time.Unix(60, 0), 13, 25)
+	require.ErrorIs(t, err, ErrLedgerNotFound)
+
+	ledgers, err := reader.FindContractEventLedgers(ctx, contractB, 5, 22)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{6, 8, 10, 12, 14, 16, 18, 20, 22}, ledgers)
+	_, err = reader.FindContractEventLedgers(ctx, contractB, 25, 30)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLedgerIndexesDoNotHideLedgerFiles(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestFilesystemDataStore(t)
+	schema := DataStoreSchema{LedgersPerFile: 1, FilesPerPartition: 1, FileExtension: "zst"}
+
+	// more indexes than a single page of ListFilePaths
+	for seq := uint32(2); seq < listFilePathsMaxLimit+5; seq++ {
+		require.NoError(t, store.PutFile(ctx, ledgerIndexLedgersPath(schema, seq), bytes.NewReader(nil), nil))
+	}
+	for seq := uint32(2); seq <= 4; seq++ {
+		require.NoError(t, store.PutFile(ctx, schema.GetObjectKeyFromSequenceNumber(seq),
+			bytes.NewReader(nil), MetaData{StartLedger: seq, EndLedger: seq}.ToMap()))
+	}
+
+	latest, err := FindLatestLedgerSequence(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4), latest)
+
+	ext, err := GetLedgerFileExtension(ctx, store)
+	require.NoError(t, err)
+	require.Equal(t, "zst", ext)
+}
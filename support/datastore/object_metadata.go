@@ -12,10 +12,21 @@ type MetaData struct {
 	NetworkPassPhrase    string
 	CompressionType      string
 	Version              string
+	// CompressionDictionaryID is the id of the zstd dictionary the file was
+	// compressed with, zero if no dictionary was used.
+	CompressionDictionaryID uint32
+	// ContentSHA256 is the hex encoded SHA-256 of the file content as stored,
+	// i.e. after compression. It is empty for files exported before checksums
+	// were recorded.
+	ContentSHA256 string
+	// StartLedgerHash and EndLedgerHash are the hex encoded hashes of the
+	// first and last ledgers in the file.
+	StartLedgerHash string
+	EndLedgerHash   string
 }
 
 func (m MetaData) ToMap() map[string]string {
-	return map[string]string{
+	data := map[string]string{
 		"start-ledger":            strconv.FormatUint(uint64(m.StartLedger), 10),
 		"end-ledger":              strconv.FormatUint(uint64(m.EndLedger), 10),
 		"start-ledger-close-time": strconv.FormatInt(m.StartLedgerCloseTime, 10),
@@ -26,7 +37,21 @@ func (m MetaData) ToMap() map[string]string {
 		"compression-type":        m.CompressionType,
 		"version":                 m.Version,
 	}
+	if m.CompressionDictionaryID != 0 {
+		data["compression-dictionary-id"] = strconv.FormatUint(uint64(m.CompressionDictionaryID), 10)
+	}
+	if m.ContentSHA256 != "" {
+		data["content-sha256"] = m.ContentSHA256
+	}
+	if m.StartLedgerHash != "" {
+		data["start-ledger-hash"] = m.StartLedgerHash
+	}
+	if m.EndLedgerHash != "" {
+		data["end-ledger-hash"] = m.EndLedgerHash
+	}
+	return data
 }
+
 func NewMetaDataFromMap(data map[string]string) (MetaData, error) {
 	var metaData MetaData
 
@@ -70,10 +95,21 @@ func NewMetaDataFromMap(data map[string]string) (MetaData, error) {
 		metaData.ProtocolVersion = uint32(protocolVersion)
 	}
 
+	if val, ok := data["compression-dictionary-id"]; ok {
+		dictionaryID, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return metaData, err
+		}
+		metaData.CompressionDictionaryID = uint32(dictionaryID)
+	}
+
 	metaData.CoreVersion = data["core-version"]
 	metaData.NetworkPassPhrase = data["network-passphrase"]
 	metaData.CompressionType = data["compression-type"]
 	metaData.Version = data["version"]
+	metaData.ContentSHA256 = data["content-sha256"]
+	metaData.StartLedgerHash = data["start-ledger-hash"]
+	metaData.EndLedgerHash = data["end-ledger-hash"]
 
 	return metaData, nil
 }
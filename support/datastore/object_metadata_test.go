@@ -38,6 +38,52 @@ func TestMetaDataToMap(t *testing.T) {
 				"version":                 "1.0.0",
 			},
 		},
+		{
+			name: "testToMapWithDictionary",
+			metaData: MetaData{
+				StartLedger:             2,
+				EndLedger:               2,
+				CompressionType:         "zst",
+				CompressionDictionaryID: 7,
+			},
+			expected: map[string]string{
+				"start-ledger":              "2",
+				"end-ledger":                "2",
+				"start-ledger-close-time":   "0",
+				"end-ledger-close-time":     "0",
+				"protocol-version":          "0",
+				"core-version":              "",
+				"network-passphrase":        "",
+				"compression-type":          "zst",
+				"version":                   "",
+				"compression-dictionary-id": "7",
+			},
+		},
+		{
+			name: "testToMapWithChecksum",
+			metaData: MetaData{
+				StartLedger:     2,
+				EndLedger:       3,
+				CompressionType: "zst",
+				ContentSHA256:   "a1b2",
+				StartLedgerHash: "c3d4",
+				EndLedgerHash:   "e5f6",
+			},
+			expected: map[string]string{
+				"start-ledger":            "2",
+				"end-ledger":              "3",
+				"start-ledger-close-time": "0",
+				"end-ledger-close-time":   "0",
+				"protocol-version":        "0",
+				"core-version":            "",
+				"network-passphrase":      "",
+				"compression-type":        "zst",
+				"version":                 "",
+				"content-sha256":          "a1b2",
+				"start-ledger-hash":       "c3d4",
+				"end-ledger-hash":         "e5f6",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -51,6 +97,11 @@ func TestMetaDataToMap(t *testing.T) {
 				NetworkPassPhrase:    tt.metaData.NetworkPassPhrase,
 				CompressionType:      tt.metaData.CompressionType,
 				Version:              tt.metaData.Version,
+
+				CompressionDictionaryID: tt.metaData.CompressionDictionaryID,
+				ContentSHA256:           tt.metaData.ContentSHA256,
+				StartLedgerHash:         tt.metaData.StartLedgerHash,
+				EndLedgerHash:           tt.metaData.EndLedgerHash,
 			}
 			got := m.ToMap()
 			require.Equal(t, got, tt.expected)
@@ -69,6 +120,11 @@ func TestNewMetaDataFromMap(t *testing.T) {
 		"network-passphrase":      "testnet passphrase",
 		"compression-type":        "gzip",
 		"version":                 "1.0.0",
+
+		"compression-dictionary-id": "42",
+		"content-sha256":            "a1b2",
+		"start-ledger-hash":         "c3d4",
+		"end-ledger-hash":           "e5f6",
 	}
 
 	expected := MetaData{
@@ -81,6 +137,11 @@ func TestNewMetaDataFromMap(t *testing.T) {
 		NetworkPassPhrase:    "testnet passphrase",
 		CompressionType:      "gzip",
 		Version:              "1.0.0",
+
+		CompressionDictionaryID: 42,
+		ContentSHA256:           "a1b2",
+		StartLedgerHash:         "c3d4",
+		EndLedgerHash:           "e5f6",
 	}
 
 	got, err := NewMetaDataFromMap(data)
@@ -10,7 +10,24 @@ import (
 type DataStoreSchema struct {
 	LedgersPerFile    uint32 `toml:"ledgers_per_file"`
 	FilesPerPartition uint32 `toml:"files_per_partition"`
-	FileExtension     string // Optional – for backward (zstd) compatibility only
+	FileExtension     string // Optional – defaults to the extension of compressxdr.DefaultCompressor
+	// ZstdDictionaries are the zstd dictionaries published to the datastore,
+	// ordered from oldest to most recent. They are loaded from the datastore
+	// by LoadSchema and LoadZstdDictionaries, never configured directly.
+	ZstdDictionaries [][]byte `toml:"-"`
+}
+
+// GetCompressor returns the compressor matching the schema's file extension.
+// Zstd ledger files use the schema's dictionaries, if any.
+func (ec DataStoreSchema) GetCompressor() (compressxdr.Compressor, error) {
+	compressor, err := compressxdr.NewCompressorFromExtension(ec.FileExtension)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := compressor.(*compressxdr.ZstdCompressor); ok && len(ec.ZstdDictionaries) > 0 {
+		return compressxdr.NewZstdDictCompressor(ec.ZstdDictionaries...)
+	}
+	return compressor, nil
 }
 
 func (ec DataStoreSchema) GetSequenceNumberStartBoundary(ledgerSeq uint32) uint32 {
@@ -24,6 +41,28 @@ func (ec DataStoreSchema) GetSequenceNumberEndBoundary(ledgerSeq uint32) uint32
 	return ec.GetSequenceNumberStartBoundary(ledgerSeq) + ec.LedgersPerFile - 1
 }
 
+// GetPartitionStartBoundary returns the first ledger of the partition
+// containing ledgerSeq. Without partitions, every file is its own partition.
+func (ec DataStoreSchema) GetPartitionStartBoundary(ledgerSeq uint32) uint32 {
+	partitionSize := ec.partitionSize()
+	if partitionSize == 0 {
+		return 0
+	}
+	return (ledgerSeq / partitionSize) * partitionSize
+}
+
+// GetPartitionEndBoundary returns the last ledger of the partition containing ledgerSeq.
+func (ec DataStoreSchema) GetPartitionEndBoundary(ledgerSeq uint32) uint32 {
+	return ec.GetPartitionStartBoundary(ledgerSeq) + ec.partitionSize() - 1
+}
+
+func (ec DataStoreSchema) partitionSize() uint32 {
+	if ec.FilesPerPartition > 1 {
+		return ec.LedgersPerFile * ec.FilesPerPartition
+	}
+	return ec.LedgersPerFile
+}
+
 // GetObjectKeyFromSequenceNumber generates the object key name from the ledger sequence number based on configuration.
 func (ec DataStoreSchema) GetObjectKeyFromSequenceNumber(ledgerSeq uint32) string {
 	var objectKey string